@@ -27,20 +27,22 @@ import (
 	"os"
 	"time"
 
+	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
-	"k8s.io/apimachinery/pkg/util/wait"
+	genericapiserver "k8s.io/apiserver/pkg/server"
 	cloudprovider "k8s.io/cloud-provider"
 	"k8s.io/cloud-provider/app"
 	"k8s.io/cloud-provider/app/config"
 	"k8s.io/cloud-provider/options"
 	cliflag "k8s.io/component-base/cli/flag"
 	"k8s.io/component-base/logs"
+	_ "k8s.io/component-base/logs/json/register"            // for the json logging format
 	_ "k8s.io/component-base/metrics/prometheus/restclient" // for client metric registration
 	_ "k8s.io/component-base/metrics/prometheus/version"    // for version metric registration
 	"k8s.io/klog/v2"
 	_ "k8s.io/kubernetes/pkg/features" // add the kubernetes feature gates
 
-	_ "sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud"
 )
 
 func main() {
@@ -50,9 +52,24 @@ func main() {
 	if err != nil {
 		klog.Fatalf("unable to initialize command options: %v", err)
 	}
+	// the binary runs this provider only, --cloud-provider can be omitted.
+	ccmOptions.KubeCloudShared.CloudProvider.Name = huaweicloud.ProviderName
 
 	fss := cliflag.NamedFlagSets{}
-	command := app.NewCloudControllerManagerCommand(ccmOptions, cloudInitializer, app.DefaultInitFuncConstructors, fss, wait.NeverStop)
+	// the controllers are stopped on SIGTERM or SIGINT, a second signal exits immediately.
+	stop := genericapiserver.SetupSignalHandler()
+	command := app.NewCloudControllerManagerCommand(ccmOptions, cloudInitializer, controllerInitFuncConstructors(), fss, stop)
+	command.Use = "huawei-cloud-controller-manager"
+	run := command.RunE
+	command.RunE = func(cmd *cobra.Command, args []string) error {
+		err := run(cmd, args)
+		// Run returns once stopped, wait for the in-flight reconciles of the load balancers before exiting.
+		if s, ok := initializedCloud.(shutdowner); ok {
+			s.Shutdown()
+		}
+		return err
+	}
+	command.AddCommand(newManifestsCommand())
 
 	// TODO: once we switch everything over to Cobra commands, we can go back to calling
 	// utilflag.InitFlags() (by removing its pflag.Parse() call). For now, we have to set the
@@ -71,6 +88,14 @@ func main() {
 	}
 }
 
+// shutdowner is implemented by the cloud provider waiting for its in-flight reconciles on the termination.
+type shutdowner interface {
+	Shutdown()
+}
+
+// initializedCloud is the cloud provider created by cloudInitializer, it is shut down before exiting.
+var initializedCloud cloudprovider.Interface
+
 func cloudInitializer(config *config.CompletedConfig) cloudprovider.Interface {
 	cloudConfig := config.ComponentConfig.KubeCloudShared.CloudProvider
 	logPrint("cloudConfig: ", cloudConfig)
@@ -90,6 +115,7 @@ func cloudInitializer(config *config.CompletedConfig) cloudprovider.Interface {
 			klog.Fatalf("no ClusterID found.  A ClusterID is required for the cloud provider to function properly.  This check can be bypassed by setting the allow-untagged-cloud option")
 		}
 	}
+	initializedCloud = cloud
 	return cloud
 }
 
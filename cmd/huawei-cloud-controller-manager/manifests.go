@@ -0,0 +1,400 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud"
+)
+
+const (
+	managerName        = "huawei-cloud-controller-manager"
+	serviceAccountName = "cloud-controller-manager"
+	clusterRoleName    = "system:cloud-controller-manager"
+	credentialsName    = "cloud-controller-manager:credentials"
+
+	rbacFile       = "rbac-huawei-cloud-controller-manager.yaml"
+	deploymentFile = "huawei-cloud-controller-manager.yaml"
+
+	generatedHeader = "# Code generated by huawei-cloud-controller-manager manifests. DO NOT EDIT.\n"
+)
+
+// manifestOptions are the options of the generated manifests.
+type manifestOptions struct {
+	namespace         string
+	image             string
+	replicas          int32
+	verbosity         int
+	leaderElect       bool
+	securePort        int32
+	healthPort        int32
+	featureGates      string
+	credentialsSecret string
+	outputDir         string
+}
+
+// manifest is an object of the manifests with the comment preceding it.
+type manifest struct {
+	comment string
+	object  interface{}
+}
+
+func newManifestsCommand() *cobra.Command {
+	o := &manifestOptions{}
+	command := &cobra.Command{
+		Use:   "manifests",
+		Short: "Print the example manifests to deploy the cloud controller manager",
+		Long: `Print the example manifests to deploy the cloud controller manager with the given flags.
+The RBAC and the deployment manifests are written to the files of --output-dir if it is specified,
+which is how the manifests of the repository are generated.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if o.outputDir == "" {
+				out := cmd.OutOrStdout()
+				if err := writeManifests(out, o.rbac()); err != nil {
+					return err
+				}
+				fmt.Fprintln(out, "---")
+				return writeManifests(out, o.deployment())
+			}
+			if err := writeManifestFile(filepath.Join(o.outputDir, rbacFile), o.rbac()); err != nil {
+				return err
+			}
+			return writeManifestFile(filepath.Join(o.outputDir, deploymentFile), o.deployment())
+		},
+	}
+
+	flags := command.Flags()
+	flags.StringVar(&o.namespace, "namespace", metav1.NamespaceSystem, "The namespace of the cloud controller manager.")
+	flags.StringVar(&o.image, "image",
+		"swr.cn-north-4.myhuaweicloud.com/k8s-cloudprovider/huawei-cloud-controller-manager:v0.26.1",
+		"The image of the cloud controller manager.")
+	flags.Int32Var(&o.replicas, "replicas", 2, "The replicas of the deployment, more than one requires --leader-elect.")
+	flags.IntVar(&o.verbosity, "v", 5, "The log level of the cloud controller manager.")
+	flags.BoolVar(&o.leaderElect, "leader-elect", true, "Whether the replicas elect a leader to run the controllers.")
+	flags.Int32Var(&o.securePort, "secure-port", 10258, "The secure port serving /healthz and /metrics.")
+	flags.Int32Var(&o.healthPort, "health-port", 10270,
+		"The port of bind-address in the [Health] section of the cloud config, which is probed. 0 disables the probes.")
+	flags.StringVar(&o.featureGates, "feature-gates", "", "The feature gates passed to the cloud controller manager.")
+	flags.StringVar(&o.credentialsSecret, "credentials-secret", "cloud-credentials",
+		"The secret of the credentials in the [Secret] section of the cloud config, which can be read.")
+	flags.StringVar(&o.outputDir, "output-dir", "", "The directory to write the manifest files to, instead of stdout.")
+	return command
+}
+
+// rbac returns the service account of the cloud controller manager and its permissions.
+func (o *manifestOptions) rbac() []manifest {
+	subjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: serviceAccountName, Namespace: o.namespace}}
+	return []manifest{
+		{object: &rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+			ObjectMeta: metav1.ObjectMeta{Name: clusterRoleName},
+			Rules:      clusterRules(),
+		}},
+		{object: &corev1.ServiceAccount{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+			ObjectMeta: metav1.ObjectMeta{Name: serviceAccountName, Namespace: o.namespace},
+		}},
+		{object: &rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+			ObjectMeta: metav1.ObjectMeta{Name: clusterRoleName},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: clusterRoleName},
+			Subjects:   subjects,
+		}},
+		{
+			comment: "The credentials are read from the secret specified by the [Secret] section of the cloud-config,\n" +
+				"generate the manifests with --namespace and --credentials-secret if they are customized.",
+			object: &rbacv1.Role{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+				ObjectMeta: metav1.ObjectMeta{Name: credentialsName, Namespace: o.namespace},
+				Rules: []rbacv1.PolicyRule{{
+					APIGroups:     []string{""},
+					Resources:     []string{"secrets"},
+					ResourceNames: []string{o.credentialsSecret},
+					Verbs:         []string{"get", "list", "watch"},
+				}},
+			},
+		},
+		{object: &rbacv1.RoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+			ObjectMeta: metav1.ObjectMeta{Name: credentialsName, Namespace: o.namespace},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: credentialsName},
+			Subjects:   subjects,
+		}},
+	}
+}
+
+// clusterRules returns the permissions of the controllers and the provider.
+func clusterRules() []rbacv1.PolicyRule {
+	return []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{"authentication.k8s.io"},
+			Resources: []string{"tokenreviews"},
+			Verbs:     []string{"get", "list", "watch", "create", "update", "patch"},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"configmaps", "endpoints", "pods", "services", "serviceaccounts", "serviceaccounts/token"},
+			Verbs:     []string{"get", "list", "watch", "create", "update", "patch"},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"nodes"},
+			Verbs:     []string{"get", "list", "watch", "delete", "patch", "update"},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"services/status", "pods/status"},
+			Verbs:     []string{"update", "patch"},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"nodes/status"},
+			Verbs:     []string{"patch", "update"},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"events", "endpoints"},
+			Verbs:     []string{"create", "patch", "update"},
+		},
+		{
+			APIGroups: []string{"coordination.k8s.io"},
+			Resources: []string{"leases"},
+			Verbs:     []string{"get", "update", "create", "delete"},
+		},
+		{
+			APIGroups: []string{"apiextensions.k8s.io"},
+			Resources: []string{"customresourcedefinitions"},
+			Verbs:     []string{"get", "update", "create", "delete"},
+		},
+		{
+			APIGroups: []string{"networking.k8s.io"},
+			Resources: []string{"ingresses"},
+			Verbs:     []string{"get", "list", "watch", "update", "create", "patch", "delete"},
+		},
+		{
+			APIGroups: []string{"networking.k8s.io"},
+			Resources: []string{"ingresses/status"},
+			Verbs:     []string{"update", "patch"},
+		},
+		{
+			APIGroups: []string{"discovery.k8s.io"},
+			Resources: []string{"endpointslices"},
+			Verbs:     []string{"get", "list", "watch"},
+		},
+	}
+}
+
+// args returns the command line of the cloud controller manager.
+func (o *manifestOptions) args() []string {
+	args := []string{
+		"/bin/" + managerName,
+		"--v=" + strconv.Itoa(o.verbosity),
+		"--cloud-config=/etc/config/cloud-config",
+		"--cloud-provider=" + huaweicloud.ProviderName,
+		"--use-service-account-credentials=true",
+		"--leader-elect=" + strconv.FormatBool(o.leaderElect),
+		"--secure-port=" + strconv.Itoa(int(o.securePort)),
+	}
+	if o.featureGates != "" {
+		args = append(args, "--feature-gates="+o.featureGates)
+	}
+	return args
+}
+
+// deployment returns the namespace of the load balancer config and the deployment of the cloud controller manager.
+func (o *manifestOptions) deployment() []manifest {
+	labels := map[string]string{"k8s-app": managerName}
+	hostPath := func(name, path string) corev1.Volume {
+		pathType := corev1.HostPathDirectoryOrCreate
+		return corev1.Volume{Name: name, VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{Path: path, Type: &pathType},
+		}}
+	}
+	controlPlane := func(key string) corev1.NodeSelectorTerm {
+		return corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+			{Key: key, Operator: corev1.NodeSelectorOpExists},
+		}}
+	}
+	runAsUser := int64(1001)
+
+	container := corev1.Container{
+		Name:  managerName,
+		Image: o.image,
+		Args:  o.args(),
+		Ports: []corev1.ContainerPort{{Name: "https", ContainerPort: o.securePort, Protocol: corev1.ProtocolTCP}},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "k8s-certs", MountPath: "/etc/kubernetes", ReadOnly: true},
+			{Name: "ca-certs", MountPath: "/etc/ssl/certs", ReadOnly: true},
+			{Name: "cloud-config-volume", MountPath: "/etc/config", ReadOnly: true},
+			{Name: "flexvolume-dir", MountPath: "/usr/libexec/kubernetes/kubelet-plugins/volume/exec"},
+		},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")},
+		},
+	}
+	comment := ""
+	if o.healthPort > 0 {
+		comment = fmt.Sprintf("The probes require bind-address=:%d in the [Health] section of the cloud config.", o.healthPort)
+		probe := func(path string) *corev1.Probe {
+			return &corev1.Probe{ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{Path: path, Port: intstr.FromInt(int(o.healthPort))},
+			}, PeriodSeconds: 30}
+		}
+		container.Ports = append(container.Ports,
+			corev1.ContainerPort{Name: "health", ContainerPort: o.healthPort, Protocol: corev1.ProtocolTCP})
+		container.LivenessProbe = probe("/livez")
+		container.LivenessProbe.InitialDelaySeconds = 30
+		container.ReadinessProbe = probe("/readyz")
+	}
+
+	return []manifest{
+		{object: &corev1.Namespace{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{Name: "huawei-cloud-provider"},
+		}},
+		{comment: comment, object: &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: managerName, Namespace: o.namespace, Labels: labels},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &o.replicas,
+				Strategy: appsv1.DeploymentStrategy{Type: appsv1.RollingUpdateDeploymentStrategyType},
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec: corev1.PodSpec{
+						Affinity: &corev1.Affinity{
+							PodAntiAffinity: &corev1.PodAntiAffinity{
+								RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+									LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
+									TopologyKey:   corev1.LabelHostname,
+								}},
+							},
+							NodeAffinity: &corev1.NodeAffinity{
+								RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+									NodeSelectorTerms: []corev1.NodeSelectorTerm{
+										controlPlane("node-role.kubernetes.io/master"),
+										controlPlane("node-role.kubernetes.io/control-plane"),
+									},
+								},
+							},
+						},
+						SecurityContext: &corev1.PodSecurityContext{RunAsUser: &runAsUser},
+						Tolerations: []corev1.Toleration{
+							{Key: "node.cloudprovider.kubernetes.io/uninitialized", Value: "true",
+								Effect: corev1.TaintEffectNoSchedule},
+							{Key: "node-role.kubernetes.io/master", Effect: corev1.TaintEffectNoSchedule},
+							{Key: "node-role.kubernetes.io/control-plane", Effect: corev1.TaintEffectNoSchedule},
+						},
+						ServiceAccountName: serviceAccountName,
+						Containers:         []corev1.Container{container},
+						HostNetwork:        true,
+						Volumes: []corev1.Volume{
+							hostPath("flexvolume-dir", "/usr/libexec/kubernetes/kubelet-plugins/volume/exec"),
+							hostPath("k8s-certs", "/etc/kubernetes"),
+							hostPath("ca-certs", "/etc/ssl/certs"),
+							{Name: "cloud-config-volume", VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{SecretName: "cloud-config"},
+							}},
+						},
+					},
+				},
+			},
+		}},
+	}
+}
+
+// writeManifests writes the objects in YAML separated by "---".
+func writeManifests(w io.Writer, manifests []manifest) error {
+	var buf bytes.Buffer
+	for i, m := range manifests {
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		if m.comment != "" {
+			buf.WriteString("# " + strings.ReplaceAll(m.comment, "\n", "\n# ") + "\n")
+		}
+		data, err := marshalManifest(m.object)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeManifestFile(path string, manifests []manifest) error {
+	var buf bytes.Buffer
+	buf.WriteString(generatedHeader)
+	if err := writeManifests(&buf, manifests); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// marshalManifest marshals the object in YAML without the empty creationTimestamp, spec and status.
+func marshalManifest(obj interface{}) ([]byte, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "status")
+	if spec, ok := fields["spec"].(map[string]interface{}); ok && len(spec) == 0 {
+		delete(fields, "spec")
+	}
+	removeCreationTimestamp(fields)
+	return yaml.Marshal(fields)
+}
+
+// removeCreationTimestamp removes the null creationTimestamp of the metadata, including the templates.
+func removeCreationTimestamp(fields map[string]interface{}) {
+	for key, value := range fields {
+		child, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if key == "metadata" {
+			if timestamp, ok := child["creationTimestamp"]; ok && timestamp == nil {
+				delete(child, "creationTimestamp")
+			}
+		}
+		removeCreationTimestamp(child)
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	cloudprovider "k8s.io/cloud-provider"
+	"k8s.io/cloud-provider/app"
+	"k8s.io/cloud-provider/app/config"
+	genericcontrollermanager "k8s.io/controller-manager/app"
+	"k8s.io/controller-manager/controller"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud"
+)
+
+// controllerInitFuncConstructors returns the default controllers of the cloud controller manager
+// and the node-ipam controller allocating the pod CIDRs of the nodes.
+func controllerInitFuncConstructors() map[string]app.ControllerInitFuncConstructor {
+	constructors := make(map[string]app.ControllerInitFuncConstructor, len(app.DefaultInitFuncConstructors)+1)
+	for name, constructor := range app.DefaultInitFuncConstructors {
+		constructors[name] = constructor
+	}
+	constructors["node-ipam"] = app.ControllerInitFuncConstructor{
+		InitContext: app.ControllerInitContext{ClientName: "node-controller"},
+		Constructor: startNodeIpamControllerWrapper,
+	}
+	return constructors
+}
+
+// startNodeIpamControllerWrapper starts the allocator of the pod CIDRs from the pod subnets of the VPC
+// with --allocate-node-cidrs and --cidr-allocator-type=CloudAllocator.
+func startNodeIpamControllerWrapper(initContext app.ControllerInitContext, completedConfig *config.CompletedConfig,
+	cloud cloudprovider.Interface) app.InitFunc {
+	return func(ctx context.Context, _ genericcontrollermanager.ControllerContext) (controller.Interface, bool, error) {
+		shared := completedConfig.ComponentConfig.KubeCloudShared
+		if !shared.AllocateNodeCIDRs || shared.CIDRAllocatorType != huaweicloud.CloudAllocatorType {
+			klog.Infof("Will not allocate the pod CIDRs of the nodes, --allocate-node-cidrs: %v, "+
+				"--cidr-allocator-type: %s", shared.AllocateNodeCIDRs, shared.CIDRAllocatorType)
+			return nil, false, nil
+		}
+		provider, ok := cloud.(*huaweicloud.CloudProvider)
+		if !ok {
+			return nil, false, fmt.Errorf("--cidr-allocator-type=%s requires the %s cloud provider",
+				huaweicloud.CloudAllocatorType, huaweicloud.ProviderName)
+		}
+		err := provider.StartPodCIDRAllocator(ctx, completedConfig.ClientBuilder.ClientOrDie(initContext.ClientName),
+			completedConfig.SharedInformers.Core().V1().Nodes(), shared.ClusterName, shared.ClusterCIDR)
+		if err != nil {
+			return nil, false, err
+		}
+		return nil, true, nil
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// hwslbctl checks the load balancer annotations of the services offline, e.g. in CI before the manifests are
+// applied, with the parsing and the validation of the cloud provider.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud"
+)
+
+// errFindings is returned if any error is found, the findings are already printed.
+var errFindings = errors.New("invalid annotations found")
+
+func main() {
+	// the parsing of the cloud provider logs the annotations it reads.
+	klog.LogToStderr(false)
+	klog.SetOutput(io.Discard)
+
+	command := &cobra.Command{
+		Use:           "hwslbctl",
+		Short:         "hwslbctl checks the Huawei Cloud load balancer annotations of the services",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	command.AddCommand(newValidateCommand())
+	if err := command.Execute(); err != nil {
+		if err != errFindings {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func newValidateCommand() *cobra.Command {
+	var files []string
+	var strict bool
+	command := &cobra.Command{
+		Use:   "validate -f FILE...",
+		Short: "Check the kubernetes.io/elb.* annotations of the LoadBalancer services in the manifests",
+		Long: "Check the kubernetes.io/elb.* annotations of the LoadBalancer services in the manifests, " +
+			"the objects of the other kinds are skipped. It exits with 1 if any error is found, " +
+			"or any warning with --strict.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if len(files) == 0 {
+				return errors.New("at least one manifest is required by -f")
+			}
+			failed := false
+			for _, file := range files {
+				services, err := readServices(file, cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+				for _, service := range services {
+					if validate(cmd.OutOrStdout(), file, service, strict) {
+						failed = true
+					}
+				}
+			}
+			if failed {
+				return errFindings
+			}
+			return nil
+		},
+	}
+	command.Flags().StringArrayVarP(&files, "filename", "f", nil,
+		"the manifest of the services, \"-\" reads the standard input, it can be repeated")
+	command.Flags().BoolVar(&strict, "strict", false, "fail on the warnings too")
+	return command
+}
+
+// validate prints the findings of the service, it returns true if the service fails the validation.
+func validate(out io.Writer, file string, service *v1.Service, strict bool) bool {
+	findings := huaweicloud.ValidateAnnotations(service)
+	name := fmt.Sprintf("%s: Service %s/%s", file, service.Namespace, service.Name)
+	if service.Namespace == "" {
+		name = fmt.Sprintf("%s: Service %s", file, service.Name)
+	}
+	if len(findings) == 0 {
+		fmt.Fprintf(out, "%s: ok\n", name)
+		return false
+	}
+
+	failed := false
+	for _, finding := range findings {
+		fmt.Fprintf(out, "%s: %s\n", name, finding)
+		if finding.Severity == huaweicloud.SeverityError || strict {
+			failed = true
+		}
+	}
+	return failed
+}
+
+// readServices returns the LoadBalancer services of the multi-document YAML or JSON manifest.
+func readServices(file string, stdin io.Reader) ([]*v1.Service, error) {
+	in := stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	decoder := serializer.NewCodecFactory(scheme.Scheme).UniversalDeserializer()
+	reader := yaml.NewYAMLReader(bufio.NewReader(in))
+	var services []*v1.Service
+	for i := 1; ; i++ {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return services, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", file, err)
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		obj, _, err := decoder.Decode(doc, nil, nil)
+		if err != nil {
+			// the objects of the unregistered kinds, such as the custom resources, are not services.
+			if runtime.IsNotRegisteredError(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to decode the document %d of %s: %v", i, file, err)
+		}
+		if service, ok := obj.(*v1.Service); ok && service.Spec.Type == v1.ServiceTypeLoadBalancer {
+			services = append(services, service)
+		}
+	}
+}
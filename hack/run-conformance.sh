@@ -0,0 +1,47 @@
+#!/usr/bin/env bash
+
+# Copyright 2023 The Kubernetes Authors.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Runs the LoadBalancers e2e tests of Kubernetes against the cluster of KUBECONFIG,
+# which runs the huawei-cloud-controller-manager.
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+REPO_ROOT=$(dirname "${BASH_SOURCE[0]}")/..
+KUBECONFIG=${KUBECONFIG:-"${HOME}/.kube/config"}
+KUBE_VERSION=${KUBE_VERSION:-"v1.26.2"}
+E2E_FOCUS=${E2E_FOCUS:-"\[sig-network\] LoadBalancers"}
+E2E_SKIP=${E2E_SKIP:-"\[Disruptive\]|\[Flaky\]"}
+ARTIFACTS_PATH=${ARTIFACTS_PATH:-"${REPO_ROOT}/e2e-logs"}
+mkdir -p "${ARTIFACTS_PATH}"
+
+kubectl version
+
+tmpPath=$(mktemp -d)
+trap 'rm -rf "${tmpPath}"' EXIT
+
+echo -e "\nDownload the e2e tests of Kubernetes ${KUBE_VERSION}"
+curl -sSL "https://dl.k8s.io/${KUBE_VERSION}/kubernetes-test-$(go env GOOS)-$(go env GOARCH).tar.gz" |
+  tar -xz -C "${tmpPath}" kubernetes/test/bin/e2e.test kubernetes/test/bin/ginkgo
+
+echo -e "\nRun the LoadBalancers e2e tests"
+"${tmpPath}/kubernetes/test/bin/ginkgo" -v --timeout=2h \
+  --focus="${E2E_FOCUS}" --skip="${E2E_SKIP}" \
+  "${tmpPath}/kubernetes/test/bin/e2e.test" -- \
+  --kubeconfig="${KUBECONFIG}" \
+  --provider=skeleton \
+  --report-dir="${ARTIFACTS_PATH}"
@@ -0,0 +1,32 @@
+#!/usr/bin/env bash
+
+# Copyright 2023 The Kubernetes Authors.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+REPO_ROOT=$(dirname "${BASH_SOURCE[0]}")/..
+TMP_DIR=$(mktemp -d)
+trap 'rm -rf "${TMP_DIR}"' EXIT
+
+cd "${REPO_ROOT}"
+go run ./cmd/huawei-cloud-controller-manager manifests --output-dir "${TMP_DIR}"
+for file in rbac-huawei-cloud-controller-manager.yaml huawei-cloud-controller-manager.yaml; do
+  if ! diff -u "manifests/${file}" "${TMP_DIR}/${file}"; then
+    echo "manifests/${file} is out of date, run make manifests" >&2
+    exit 1
+  fi
+done
@@ -19,3 +19,4 @@ REPO_ROOT=$(dirname "${BASH_SOURCE[0]}")/..
 $REPO_ROOT/hack/verify-gofmt.sh
 $REPO_ROOT/hack/verify-vendor.sh
 $REPO_ROOT/hack/verify-staticcheck.sh
+$REPO_ROOT/hack/verify-manifests.sh
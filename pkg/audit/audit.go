@@ -0,0 +1,190 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// The outcomes of the audited requests.
+const (
+	OutcomeSuccess = "Success"
+	OutcomeFailure = "Failure"
+)
+
+// Record is the audit record of a request creating, updating or deleting a resource of Huawei Cloud.
+type Record struct {
+	Time         time.Time `json:"time"`
+	Operation    string    `json:"operation"`
+	ResourceType string    `json:"resourceType"`
+	ResourceID   string    `json:"resourceID,omitempty"`
+	// Service is the Kubernetes Service initiating the request, in the form of "<namespace>/<name>".
+	Service     string `json:"service,omitempty"`
+	ReconcileID string `json:"reconcileID,omitempty"`
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	StatusCode  int    `json:"statusCode"`
+	RequestID   string `json:"requestID,omitempty"`
+	Outcome     string `json:"outcome"`
+}
+
+type initiatorKey struct{}
+
+type initiator struct {
+	service     string
+	reconcileID string
+}
+
+// NewContext returns the context of the reconcile of the Service, the requests sent with it are attributed to the Service.
+func NewContext(ctx context.Context, service, reconcileID string) context.Context {
+	return context.WithValue(ctx, initiatorKey{}, initiator{service: service, reconcileID: reconcileID})
+}
+
+var (
+	// idRegexp matches the IDs of the resources, which are UUIDs or 32 hex digits, such as the project IDs.
+	idRegexp      = regexp.MustCompile(`^[0-9a-fA-F]{8}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{12}$`)
+	versionRegexp = regexp.MustCompile(`^v\d+(\.\d+)?$`)
+)
+
+// NewRecord returns the record of the request, or nil if the request does not mutate a resource.
+func NewRecord(ctx context.Context, method string, u *url.URL) *Record {
+	operation := ""
+	switch method {
+	case http.MethodPost:
+		operation = "create"
+	case http.MethodPut, http.MethodPatch:
+		operation = "update"
+	case http.MethodDelete:
+		operation = "delete"
+	default:
+		return nil
+	}
+	if strings.HasSuffix(u.Path, "/auth/tokens") {
+		return nil
+	}
+
+	record := &Record{
+		Time:      time.Now(),
+		Operation: operation,
+		Method:    method,
+		URL:       u.Scheme + "://" + u.Host + u.Path,
+	}
+	if ctx != nil {
+		if i, ok := ctx.Value(initiatorKey{}).(initiator); ok {
+			record.Service, record.ReconcileID = i.service, i.reconcileID
+		}
+	}
+
+	// The path is in the form of "/<version>/<project ID>/<collection>/<ID>/.../<collection>[/<ID>][/<action>]".
+	for _, segment := range strings.Split(strings.Trim(u.Path, "/"), "/") {
+		switch {
+		case idRegexp.MatchString(segment):
+			record.ResourceID = segment
+		case versionRegexp.MatchString(segment):
+		case segment == "action":
+			record.Operation = "action"
+		case segment == "delete" && method == http.MethodPost:
+			record.Operation = "delete"
+		default:
+			record.ResourceType, record.ResourceID = segment, ""
+		}
+	}
+	return record
+}
+
+// Complete completes the record with the response, the ID of the created resource is read from the body.
+func (r *Record) Complete(statusCode int, requestID string, body []byte) {
+	r.StatusCode, r.RequestID = statusCode, requestID
+	r.Outcome = OutcomeSuccess
+	if statusCode >= http.StatusBadRequest {
+		r.Outcome = OutcomeFailure
+	}
+	if r.ResourceID == "" && r.Outcome == OutcomeSuccess {
+		r.ResourceID = createdID(body)
+	}
+}
+
+// createdID returns the ID of the created resource in the body such as {"listener": {"id": "..."}}.
+func createdID(body []byte) string {
+	var rst map[string]json.RawMessage
+	if err := json.Unmarshal(body, &rst); err != nil {
+		return ""
+	}
+	var resource struct {
+		ID string `json:"id"`
+	}
+	if raw, ok := rst["id"]; ok && json.Unmarshal(raw, &resource.ID) == nil {
+		return resource.ID
+	}
+	for _, raw := range rst {
+		if json.Unmarshal(raw, &resource) == nil && resource.ID != "" {
+			return resource.ID
+		}
+	}
+	return ""
+}
+
+var (
+	lock sync.Mutex
+	// output is the file the records are written to, they are logged if it is nil.
+	output io.Writer
+)
+
+// SetOutput writes the records to the file in JSON lines, instead of logging them.
+func SetOutput(file string) error {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open the audit log file %s: %s", file, err)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	output = f
+	return nil
+}
+
+// Log writes the record to the audit log file, or logs it if the file is not set.
+func Log(r *Record) {
+	lock.Lock()
+	defer lock.Unlock()
+	if output == nil {
+		klog.InfoS("Audit", "operation", r.Operation, "resourceType", r.ResourceType, "resourceID", r.ResourceID,
+			"service", r.Service, "reconcileID", r.ReconcileID, "method", r.Method, "url", r.URL,
+			"statusCode", r.StatusCode, "requestID", r.RequestID, "outcome", r.Outcome)
+		return
+	}
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		klog.Errorf("failed to marshal the audit record: %s", err)
+		return
+	}
+	if _, err = output.Write(append(line, '\n')); err != nil {
+		klog.Errorf("failed to write the audit record: %s", err)
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	projectID  = "0a1b2c3d4e5f60718293a4b5c6d7e8f9"
+	listenerID = "c8fd5f4b-6e1e-4c2a-9b9b-2b9d3d9f0a11"
+	poolID     = "5a4ad6b0-0b6c-4d7e-a5a9-6bd7b1f6d2c3"
+)
+
+func TestNewRecord(t *testing.T) {
+	tests := []struct {
+		method       string
+		path         string
+		operation    string
+		resourceType string
+		resourceID   string
+	}{
+		{"GET", "/v2/" + projectID + "/elb/listeners/" + listenerID, "", "", ""},
+		{"POST", "/v2/" + projectID + "/elb/listeners", "create", "listeners", ""},
+		{"PUT", "/v2/" + projectID + "/elb/listeners/" + listenerID, "update", "listeners", listenerID},
+		{"DELETE", "/v2/" + projectID + "/elb/pools/" + poolID + "/members/" + listenerID, "delete", "members",
+			listenerID},
+		{"POST", "/v1.0/" + projectID + "/elbaas/listeners/" + listenerID + "/members/action", "action", "members", ""},
+		{"POST", "/v1/" + projectID + "/cloudservers/" + poolID + "/nics/delete", "delete", "nics", ""},
+		{"DELETE", "/v2.0/dnat_rules/" + poolID, "delete", "dnat_rules", poolID},
+		{"POST", "/v3/auth/tokens", "", "", ""},
+	}
+
+	ctx := NewContext(context.Background(), "default/nginx", "reconcile-1")
+	for _, tt := range tests {
+		record := NewRecord(ctx, tt.method, &url.URL{Scheme: "https", Host: "elb.example.com", Path: tt.path})
+		if tt.operation == "" {
+			if record != nil {
+				t.Errorf("%s %s, expected not to be audited, got: %+v", tt.method, tt.path, record)
+			}
+			continue
+		}
+		if record == nil {
+			t.Fatalf("%s %s, expected to be audited", tt.method, tt.path)
+		}
+		if record.Operation != tt.operation || record.ResourceType != tt.resourceType ||
+			record.ResourceID != tt.resourceID {
+			t.Errorf("%s %s, expected %s %s %s, got: %s %s %s", tt.method, tt.path, tt.operation,
+				tt.resourceType, tt.resourceID, record.Operation, record.ResourceType, record.ResourceID)
+		}
+		if record.Service != "default/nginx" || record.ReconcileID != "reconcile-1" {
+			t.Errorf("%s %s, expected to be attributed to the service, got: %s %s", tt.method, tt.path,
+				record.Service, record.ReconcileID)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	u := &url.URL{Scheme: "https", Host: "elb.example.com", Path: "/v2/" + projectID + "/elb/listeners"}
+
+	record := NewRecord(context.Background(), "POST", u)
+	record.Complete(201, "request-1", []byte(`{"listener": {"id": "`+listenerID+`", "name": "listener"}}`))
+	if record.Outcome != OutcomeSuccess || record.ResourceID != listenerID || record.RequestID != "request-1" {
+		t.Errorf("expected the created listener, got: %+v", record)
+	}
+
+	record = NewRecord(context.Background(), "POST", u)
+	record.Complete(400, "request-2", []byte(`{"error_code": "ELB.8902", "error_msg": "invalid"}`))
+	if record.Outcome != OutcomeFailure || record.ResourceID != "" {
+		t.Errorf("expected the failure, got: %+v", record)
+	}
+}
+
+func TestSetOutput(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit.log")
+	if err := SetOutput(file); err != nil {
+		t.Fatalf("failed to set the output: %s", err)
+	}
+	defer func() { output = nil }()
+
+	record := NewRecord(context.Background(), "DELETE",
+		&url.URL{Scheme: "https", Host: "elb.example.com", Path: "/v2/" + projectID + "/elb/listeners/" + listenerID})
+	record.Complete(204, "request-1", nil)
+	Log(record)
+	Log(record)
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("failed to read the audit log: %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 records, got: %d", len(lines))
+	}
+	var got Record
+	if err = json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatalf("failed to unmarshal the record: %s", err)
+	}
+	if got.ResourceID != listenerID || got.Operation != "delete" || got.Outcome != OutcomeSuccess {
+		t.Errorf("unexpected record: %+v", got)
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alb
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	elbmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/elb/v3/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/metrics"
+)
+
+const (
+	azStateActive = "ACTIVE"
+	// azCacheName is the name of the AZ cache in the metrics.
+	azCacheName = "availability_zones"
+)
+
+// AZCache holds the AZ sets of the dedicated load balancers. They are loaded on the first use, and refreshed
+// on the first use after they expire, so the reconciliations rarely query the AZs and no goroutine is left running.
+// The TTL is read on each refresh, so the changes of the loadbalancer config apply from the next refresh.
+type AZCache struct {
+	elbClient DedicatedELBClient
+	// opts provides the TTL of the AZs.
+	opts func() *config.LoadBalancerOptions
+	now  func() time.Time
+
+	// refreshLock serializes the refreshes, the callers waiting for it use the AZs refreshed by the first one.
+	refreshLock sync.Mutex
+	lock        sync.RWMutex
+	zones       [][]elbmodel.AvailabilityZone
+	// expiresAt is the time to refresh the AZs, zero before the first load.
+	expiresAt time.Time
+}
+
+// NewAZCache returns an empty cache of the AZ sets, they are loaded on the first use.
+func NewAZCache(elbClient DedicatedELBClient, opts func() *config.LoadBalancerOptions) *AZCache {
+	return &AZCache{
+		elbClient: elbClient,
+		opts:      opts,
+		now:       time.Now,
+	}
+}
+
+// List returns the cached AZ sets, nil is returned if the AZs have never been loaded successfully.
+func (c *AZCache) List() [][]elbmodel.AvailabilityZone {
+	if c.expired() {
+		c.refreshLock.Lock()
+		// the AZs may be refreshed by another caller while waiting for the lock.
+		if c.expired() {
+			c.refresh()
+		}
+		c.refreshLock.Unlock()
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.zones == nil {
+		metrics.ObserveCacheLookup(azCacheName, metrics.CacheMiss)
+	} else {
+		metrics.ObserveCacheLookup(azCacheName, metrics.CacheHit)
+	}
+	return c.zones
+}
+
+func (c *AZCache) expired() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return !c.now().Before(c.expiresAt)
+}
+
+// refresh loads the AZs, the cached ones are kept on failure. The next refresh is after the TTL either way,
+// so that an unavailable ELB API is not queried by every reconciliation.
+func (c *AZCache) refresh() {
+	ttl := time.Duration(c.opts().AvailabilityZoneCacheTTL) * time.Second
+	zones, err := c.elbClient.ListAvailabilityZones()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.expiresAt = c.now().Add(ttl)
+	if err != nil {
+		klog.Warningf("failed to refresh the AZs of the dedicated load balancers, keep the cached: %s", err)
+		return
+	}
+	evicted := len(c.zones) - len(zones)
+	if evicted < 0 {
+		evicted = 0
+	}
+	c.zones = zones
+	metrics.ObserveCacheRefresh(azCacheName, len(zones), evicted)
+	klog.V(4).Infof("AZ cache refreshed, %d AZ sets found", len(zones))
+}
+
+// Validate checks that the AZs are active and in the same AZ set.
+// The check is skipped if the AZs are not loaded, the ELB API will reject the invalid AZs anyway.
+func (c *AZCache) Validate(azList []string) error {
+	zones := c.List()
+	if zones == nil {
+		return nil
+	}
+
+	for _, set := range zones {
+		if containsActiveAZs(set, azList) {
+			return nil
+		}
+	}
+
+	return status.Errorf(codes.InvalidArgument, "the AZs [%s] are not available or not in the same AZ set "+
+		"for the dedicated load balancer", strings.Join(azList, ", "))
+}
+
+func containsActiveAZs(set []elbmodel.AvailabilityZone, azList []string) bool {
+	for _, az := range azList {
+		found := false
+		for _, zone := range set {
+			if zone.Code == az && zone.State == azStateActive {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Snapshot returns the cached AZ sets in the form of "<code> <state>", it does not load the AZs.
+func (c *AZCache) Snapshot() [][]string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	var rst [][]string
+	for _, set := range c.zones {
+		var zones []string
+		for _, zone := range set {
+			zones = append(zones, zone.Code+" "+zone.State)
+		}
+		rst = append(rst, zones)
+	}
+	return rst
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alb
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	elbmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/elb/v3/model"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+)
+
+func TestAZCacheRefresh(t *testing.T) {
+	zones := [][]elbmodel.AvailabilityZone{{{Code: "az1", State: azStateActive}}}
+	var listErr error
+	client := &FakeDedicatedELBClient{
+		ListAvailabilityZonesFunc: func() ([][]elbmodel.AvailabilityZone, error) {
+			if listErr != nil {
+				return nil, listErr
+			}
+			return zones, nil
+		},
+	}
+	opts := &config.LoadBalancerOptions{AvailabilityZoneCacheTTL: 60}
+	now := time.Now()
+	c := NewAZCache(client, func() *config.LoadBalancerOptions { return opts })
+	c.now = func() time.Time { return now }
+
+	if err := c.Validate([]string{"az1"}); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if count := client.CallCount("ListAvailabilityZones"); count != 1 {
+		t.Fatalf("the AZs are listed %d times on the first use, expected 1", count)
+	}
+
+	now = now.Add(59 * time.Second)
+	zones = [][]elbmodel.AvailabilityZone{{{Code: "az2", State: azStateActive}}}
+	if err := c.Validate([]string{"az1"}); err != nil {
+		t.Fatalf("Validate() error = %v, expected the cached AZs before the TTL", err)
+	}
+	if count := client.CallCount("ListAvailabilityZones"); count != 1 {
+		t.Fatalf("the AZs are listed %d times before the TTL, expected 1", count)
+	}
+
+	// the TTL changed by the loadbalancer config applies from the next refresh.
+	opts = &config.LoadBalancerOptions{AvailabilityZoneCacheTTL: 10}
+	now = now.Add(time.Second)
+	if err := c.Validate([]string{"az1"}); err == nil {
+		t.Fatalf("Validate() succeeded, expected az1 to be unavailable after the refresh")
+	}
+	if count := client.CallCount("ListAvailabilityZones"); count != 2 {
+		t.Fatalf("the AZs are listed %d times after the TTL, expected 2", count)
+	}
+
+	listErr = errors.New("internal error")
+	now = now.Add(10 * time.Second)
+	if err := c.Validate([]string{"az2"}); err != nil {
+		t.Fatalf("Validate() error = %v, expected the cached AZs after a failed refresh", err)
+	}
+	c.List()
+	if count := client.CallCount("ListAvailabilityZones"); count != 3 {
+		t.Fatalf("the AZs are listed %d times after a failed refresh, expected 3", count)
+	}
+}
+
+func TestAZCacheConcurrentRefresh(t *testing.T) {
+	client := &FakeDedicatedELBClient{
+		ListAvailabilityZonesFunc: func() ([][]elbmodel.AvailabilityZone, error) {
+			time.Sleep(10 * time.Millisecond)
+			return [][]elbmodel.AvailabilityZone{{{Code: "az1", State: azStateActive}}}, nil
+		},
+	}
+	c := NewAZCache(client, func() *config.LoadBalancerOptions {
+		return &config.LoadBalancerOptions{AvailabilityZoneCacheTTL: 60}
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.List()
+		}()
+	}
+	wg.Wait()
+	if count := client.CallCount("ListAvailabilityZones"); count != 1 {
+		t.Fatalf("the AZs are listed %d times by the concurrent callers, expected 1", count)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package alb declares the client of the dedicated load balancers and caches their AZ sets.
+package alb
+
+import (
+	elbmodelv3 "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/elb/v3/model"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/wrapper"
+)
+
+// DedicatedELBClient is the client of the dedicated load balancer APIs used by the DedicatedLoadBalancer,
+// and by the SharedLoadBalancer for the listeners. It is implemented by the wrapper.DedicatedLoadBalanceClient
+// and by the FakeDedicatedELBClient of the unit tests.
+type DedicatedELBClient interface {
+	CreateInstance(opt *elbmodelv3.CreateLoadBalancerOption) (*elbmodelv3.LoadBalancer, error)
+	CreateInstanceCompleted(req *elbmodelv3.CreateLoadBalancerOption) (*elbmodelv3.LoadBalancer, error)
+	WaitStatusActive(id string) (*elbmodelv3.LoadBalancer, error)
+	GetInstance(id string) (*elbmodelv3.LoadBalancer, error)
+	ListInstances(req *elbmodelv3.ListLoadBalancersRequest) ([]elbmodelv3.LoadBalancer, error)
+	UpdateInstance(id, name, description string) (*elbmodelv3.LoadBalancer, error)
+	DeleteInstance(id string) error
+	CreateListener(req *elbmodelv3.CreateListenerOption) (*elbmodelv3.Listener, error)
+	GetListener(id string) (*elbmodelv3.Listener, error)
+	ListListeners(req *elbmodelv3.ListListenersRequest) ([]elbmodelv3.Listener, error)
+	UpdateListener(id string, opt *elbmodelv3.UpdateListenerOption) error
+	DeleteListener(elbID string, listenerID string) error
+	CreatePool(req *elbmodelv3.CreatePoolOption) (*elbmodelv3.Pool, error)
+	GetPool(id string) (*elbmodelv3.Pool, error)
+	ListPools(req *elbmodelv3.ListPoolsRequest) ([]elbmodelv3.Pool, error)
+	UpdatePool(id string, req *elbmodelv3.UpdatePoolOption) (*elbmodelv3.Pool, error)
+	DeletePool(id string) error
+	CreateHealthMonitor(req *elbmodelv3.CreateHealthMonitorOption) (*elbmodelv3.HealthMonitor, error)
+	GetHealthMonitor(id string) (*elbmodelv3.HealthMonitor, error)
+	UpdateHealthMonitor(id string, req *elbmodelv3.UpdateHealthMonitorOption) error
+	DeleteHealthMonitor(id string) error
+	AddMember(poolID string, req *elbmodelv3.CreateMemberOption) (*elbmodelv3.Member, error)
+	GetMember(id string) (*elbmodelv3.Member, error)
+	ListMembers(req *elbmodelv3.ListMembersRequest) ([]elbmodelv3.Member, error)
+	UpdateMember(id string, req *elbmodelv3.UpdateMemberOption) (*elbmodelv3.Member, error)
+	DeleteMember(poolID, memberID string) error
+	DeleteAllPoolMembers(poolID string) error
+	ListAvailabilityZones() ([][]elbmodelv3.AvailabilityZone, error)
+	ListQuotaDetails() ([]elbmodelv3.QuotaInfo, error)
+}
+
+var _ DedicatedELBClient = &wrapper.DedicatedLoadBalanceClient{}
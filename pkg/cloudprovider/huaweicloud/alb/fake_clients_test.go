@@ -0,0 +1,67 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alb
+
+import (
+	"sync"
+
+	elbmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/elb/v3/model"
+)
+
+// The fakes of the clients call the functions of the method names with the suffix Func, the methods whose
+// functions are nil return the zero values. The calls are counted by the method names.
+
+// fakeCalls counts the calls of the methods of a fake.
+type fakeCalls struct {
+	lock  sync.Mutex
+	calls map[string]int
+}
+
+func (f *fakeCalls) called(method string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.calls == nil {
+		f.calls = make(map[string]int)
+	}
+	f.calls[method]++
+}
+
+// CallCount returns the number of the calls of the method.
+func (f *fakeCalls) CallCount(method string) int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.calls[method]
+}
+
+// FakeDedicatedELBClient is the fake of the DedicatedELBClient used by the AZ cache, the methods not faked
+// panic with the nil DedicatedELBClient embedded.
+type FakeDedicatedELBClient struct {
+	DedicatedELBClient
+	fakeCalls
+
+	ListAvailabilityZonesFunc func() ([][]elbmodel.AvailabilityZone, error)
+}
+
+func (f *FakeDedicatedELBClient) ListAvailabilityZones() ([][]elbmodel.AvailabilityZone, error) {
+	f.called("ListAvailabilityZones")
+	if f.ListAvailabilityZonesFunc == nil {
+		return nil, nil
+	}
+	return f.ListAvailabilityZonesFunc()
+}
+
+var _ DedicatedELBClient = &FakeDedicatedELBClient{}
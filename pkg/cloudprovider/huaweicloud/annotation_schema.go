@@ -0,0 +1,98 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// AnnotationSchemaVersion is the version of the schema of the service annotations read by the providers.
+// It is increased when an annotation read by an earlier release is renamed or removed, and the annotation is added
+// to deprecatedAnnotations with the version it is deprecated in.
+const AnnotationSchemaVersion = 1
+
+// deprecatedAnnotation is an annotation of an earlier schema version.
+type deprecatedAnnotation struct {
+	// since is the schema version the annotation is deprecated in.
+	since int
+	// replacement is the annotation the value is copied to, empty if the annotation is ignored.
+	replacement string
+	// note explains how the annotation is handled without a replacement.
+	note string
+}
+
+// deprecatedAnnotations are the annotations of the earlier schema versions, keyed by annotation key.
+// They are converted before the service is passed to the providers, so all the providers handle them the same.
+// Only the annotations read by a release are listed, no annotation has been renamed or removed since the schema v1.
+var deprecatedAnnotations = map[string]deprecatedAnnotation{}
+
+// deprecatedAnnotationKeys returns the deprecated annotations of the service, sorted.
+func deprecatedAnnotationKeys(service *v1.Service) []string {
+	var keys []string
+	for key := range service.Annotations {
+		if _, ok := deprecatedAnnotations[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// deprecationMessage describes how the deprecated annotation is handled.
+func deprecationMessage(key string) string {
+	deprecated := deprecatedAnnotations[key]
+	if deprecated.replacement == "" {
+		return fmt.Sprintf("deprecated since the annotation schema v%d, %s", deprecated.since, deprecated.note)
+	}
+	return fmt.Sprintf("deprecated since the annotation schema v%d, use %s instead",
+		deprecated.since, deprecated.replacement)
+}
+
+// withCurrentAnnotations returns a copy of the service with the values of the deprecated annotations copied to
+// their replacements, the replacements set in the service take precedence.
+// The service is returned as is if nothing is copied.
+func withCurrentAnnotations(service *v1.Service) *v1.Service {
+	var rst *v1.Service
+	for _, key := range deprecatedAnnotationKeys(service) {
+		replacement := deprecatedAnnotations[key].replacement
+		if replacement == "" {
+			continue
+		}
+		if _, ok := service.Annotations[replacement]; ok {
+			continue
+		}
+		if rst == nil {
+			rst = service.DeepCopy()
+		}
+		rst.Annotations[replacement] = service.Annotations[key]
+	}
+	if rst == nil {
+		return service
+	}
+	return rst
+}
+
+// reportDeprecatedAnnotations records a Warning event of each deprecated annotation of the service.
+func (h *CloudProvider) reportDeprecatedAnnotations(service *v1.Service) {
+	for _, key := range deprecatedAnnotationKeys(service) {
+		h.eventRecorder.Event(service, v1.EventTypeWarning, "DeprecatedAnnotation",
+			fmt.Sprintf("annotation %s is %s", key, deprecationMessage(key)))
+	}
+}
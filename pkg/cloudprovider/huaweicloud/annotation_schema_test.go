@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// releasedAnnotations are the annotations read by the releases of the schema v1, only they can be deprecated.
+// The annotations of a new schema version are added here once it is released.
+var releasedAnnotations = sets.NewString(
+	"kubernetes.io/elb.availability-zones",
+	"kubernetes.io/elb.class",
+	"kubernetes.io/elb.default-tls-container-ref",
+	"kubernetes.io/elb.eip-auto-create-option",
+	"kubernetes.io/elb.eip-id",
+	"kubernetes.io/elb.enable-cross-vpc",
+	"kubernetes.io/elb.enable-transparent-client-ip",
+	"kubernetes.io/elb.health-check-flag",
+	"kubernetes.io/elb.health-check-option",
+	"kubernetes.io/elb.id",
+	"kubernetes.io/elb.idle-timeout",
+	"kubernetes.io/elb.keep-eip",
+	"kubernetes.io/elb.l4-flavor-id",
+	"kubernetes.io/elb.l7-flavor-id",
+	"kubernetes.io/elb.lb-algorithm",
+	"kubernetes.io/elb.request-timeout",
+	"kubernetes.io/elb.response-timeout",
+	"kubernetes.io/elb.session-affinity-flag",
+	"kubernetes.io/elb.session-affinity-option",
+	"kubernetes.io/elb.subnet-id",
+	"kubernetes.io/elb.x-forwarded-host",
+	"kubernetes.io/natgateway.id",
+)
+
+func TestDeprecatedAnnotationsReleased(t *testing.T) {
+	for key, deprecated := range deprecatedAnnotations {
+		if !releasedAnnotations.Has(key) {
+			t.Errorf("the deprecated annotation %s is not read by any release", key)
+		}
+		if deprecated.since <= 1 || deprecated.since > AnnotationSchemaVersion {
+			t.Errorf("the annotation %s is deprecated since v%d, expected a version in (v1, v%d]",
+				key, deprecated.since, AnnotationSchemaVersion)
+		}
+		if deprecated.replacement == "" && deprecated.note == "" {
+			t.Errorf("the annotation %s has neither a replacement nor a note", key)
+		}
+		if deprecated.replacement != "" && !isKnownAnnotation(deprecated.replacement) {
+			t.Errorf("the replacement %s of the annotation %s is not a current annotation", deprecated.replacement, key)
+		}
+	}
+}
+
+func TestWithCurrentAnnotations(t *testing.T) {
+	const deprecatedKey = "kubernetes.io/elb.deprecated"
+	defer func(annotations map[string]deprecatedAnnotation) {
+		deprecatedAnnotations = annotations
+	}(deprecatedAnnotations)
+	deprecatedAnnotations = map[string]deprecatedAnnotation{
+		deprecatedKey: {since: 2, replacement: ElbAlgorithm},
+	}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expected    map[string]string
+	}{
+		{
+			name:        "copies the value to the replacement",
+			annotations: map[string]string{deprecatedKey: "LEAST_CONNECTIONS"},
+			expected:    map[string]string{deprecatedKey: "LEAST_CONNECTIONS", ElbAlgorithm: "LEAST_CONNECTIONS"},
+		},
+		{
+			name:        "keeps the replacement set in the service",
+			annotations: map[string]string{deprecatedKey: "LEAST_CONNECTIONS", ElbAlgorithm: "ROUND_ROBIN"},
+			expected:    map[string]string{deprecatedKey: "LEAST_CONNECTIONS", ElbAlgorithm: "ROUND_ROBIN"},
+		},
+		{
+			name:        "keeps the service without the deprecated annotations",
+			annotations: map[string]string{ElbAlgorithm: "ROUND_ROBIN"},
+			expected:    map[string]string{ElbAlgorithm: "ROUND_ROBIN"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &v1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations}}
+			original := service.DeepCopy()
+
+			rst := withCurrentAnnotations(service)
+			if !reflect.DeepEqual(rst.Annotations, tt.expected) {
+				t.Errorf("withCurrentAnnotations() = %v, expected %v", rst.Annotations, tt.expected)
+			}
+			if !reflect.DeepEqual(service, original) {
+				t.Errorf("the service passed in is modified")
+			}
+		})
+	}
+}
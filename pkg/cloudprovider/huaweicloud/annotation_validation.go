@@ -0,0 +1,308 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	eipmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/eip/v2/model"
+	elbmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/elb/v2/model"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+)
+
+const (
+	// SeverityError is the severity of the findings the load balancer of the service fails with.
+	SeverityError = "error"
+	// SeverityWarning is the severity of the findings which are ignored or replaced by the defaults.
+	SeverityWarning = "warning"
+)
+
+// AnnotationFinding is a problem of an annotation of a service found by ValidateAnnotations.
+type AnnotationFinding struct {
+	Annotation string
+	Severity   string
+	Message    string
+}
+
+func (f AnnotationFinding) String() string {
+	return fmt.Sprintf("%s: %s: %s", f.Severity, f.Annotation, f.Message)
+}
+
+// knownAnnotations are the annotations of the services read by the cloud provider.
+var knownAnnotations = []string{
+	ElbClass, ElbID, ElbName, ElbRegion, ElbProject, ElbSubnetID, ElbEipID, ELBKeepEip, AutoCreateEipOptions,
+	ElbAlgorithm, ElbSessionAffinityFlag, ElbSessionAffinityOption, ElbHealthCheckFlag, ElbHealthCheckOptions,
+	ElbXForwardedHost, DefaultTLSContainerRef, ElbIdleTimeout, ElbRequestTimeout, ElbResponseTimeout,
+	ELBMarkAnnotation, ElbEnableCrossVpc, ElbL4FlavorID, ElbL7FlavorID, ElbAvailabilityZones,
+	ElbEnableTransparentClientIP, AnnotationsNATID, ElbMigrateTo, ElbSecurityGroupIDs, ElbDNSName,
+	ElbPoolProtocol, ElbInterruptedOperation, ElbAutoscaling, ElbMinL7FlavorID,
+	ElbGAEndpointGroupID, ElbAppliedIDs,
+}
+
+// ValidateAnnotations checks the annotations of the load balancer of the service offline,
+// with the parsing of the cloud provider and the validation of the loadbalancer-config options.
+// The cloud resources the annotations refer to, such as the subnets and the EIPs, are not checked.
+func ValidateAnnotations(service *v1.Service) []AnnotationFinding {
+	var findings []AnnotationFinding
+	add := func(annotation, severity, format string, args ...interface{}) {
+		findings = append(findings, AnnotationFinding{
+			Annotation: annotation,
+			Severity:   severity,
+			Message:    fmt.Sprintf(format, args...),
+		})
+	}
+
+	keys := make([]string, 0, len(service.Annotations))
+	for key := range service.Annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, ok := deprecatedAnnotations[key]; ok {
+			add(key, SeverityWarning, "%s", deprecationMessage(key))
+			continue
+		}
+		if (strings.HasPrefix(key, "kubernetes.io/elb.") || strings.HasPrefix(key, "kubernetes.io/natgateway.")) &&
+			!isKnownAnnotation(key) {
+			add(key, SeverityWarning, "unknown annotation, it is ignored")
+		}
+	}
+	// the values of the deprecated annotations are checked as the ones of their replacements.
+	service = withCurrentAnnotations(service)
+
+	version, err := getLoadBalancerVersion(service)
+	target, migrating := service.Annotations[ElbMigrateTo]
+	switch {
+	case err != nil && migrating && target != MigrateToDedicated && isSharedClass(service):
+		add(ElbMigrateTo, SeverityError, "invalid value %q, expected %s", target, MigrateToDedicated)
+	case err != nil:
+		add(ElbClass, SeverityError, "%s, expected one of shared, dedicated, dnat, elasticity", err)
+	case migrating && version != VersionSharedToDedicated:
+		add(ElbMigrateTo, SeverityWarning, "it is ignored as the class is not shared")
+	}
+	switch {
+	case err != nil:
+	case (version == VersionDedicated || version == VersionSharedToDedicated) && specifiedLoadBalancer(service) == "" &&
+		service.Annotations[ElbAvailabilityZones] == "":
+		add(ElbAvailabilityZones, SeverityError, "is required to create a dedicated load balancer")
+	case version == VersionSharedToDedicated && specifiedLoadBalancer(service) != "":
+		add(ElbMigrateTo, SeverityError, "the load balancer specified by %s can not be migrated",
+			specifiedLoadBalancer(service))
+	case version == VersionNAT:
+		if service.Annotations[AnnotationsNATID] == "" {
+			add(AnnotationsNATID, SeverityError, "is required for the dnat class")
+		}
+		if service.Spec.LoadBalancerIP == "" {
+			add(ElbClass, SeverityError, "spec.loadBalancerIP is required as the floating IP of the dnat class")
+		}
+	}
+
+	if len(loadBalancerIDs(service)) > 1 && err == nil && version != VersionShared && version != VersionDedicated {
+		add(ElbID, SeverityError, "the list of load balancers is supported by the shared and dedicated classes only")
+	}
+	if _, ok := service.Annotations[ElbName]; ok && err == nil && version != VersionShared &&
+		version != VersionDedicated && version != VersionSharedToDedicated {
+		add(ElbName, SeverityError, "is supported by the shared and dedicated classes only, use %s instead", ElbID)
+	}
+
+	if _, ok := service.Annotations[ElbSecurityGroupIDs]; ok && err == nil {
+		switch {
+		case version != VersionDedicated && version != VersionSharedToDedicated:
+			add(ElbSecurityGroupIDs, SeverityWarning, "it is ignored as the class is not dedicated")
+		case specifiedLoadBalancer(service) != "":
+			add(ElbSecurityGroupIDs, SeverityWarning, "it is ignored with the load balancer specified by %s",
+				specifiedLoadBalancer(service))
+		}
+	}
+
+	if _, ok := service.Annotations[ElbPoolProtocol]; ok && err == nil {
+		if version != VersionDedicated && version != VersionSharedToDedicated {
+			add(ElbPoolProtocol, SeverityWarning, "it is ignored as the class is not dedicated")
+		} else {
+			for _, port := range service.Spec.Ports {
+				if _, e := dedicatedPoolProtocol(service, port); e != nil {
+					add(ElbPoolProtocol, SeverityError, "%s", status.Convert(e).Message())
+				}
+			}
+		}
+	}
+
+	for _, key := range []string{ElbAutoscaling, ElbMinL7FlavorID} {
+		if _, ok := service.Annotations[key]; !ok || err != nil {
+			continue
+		}
+		switch {
+		case version != VersionDedicated && version != VersionSharedToDedicated:
+			add(key, SeverityWarning, "it is ignored as the class is not dedicated")
+		case specifiedLoadBalancer(service) != "":
+			add(key, SeverityWarning, "it is ignored with the load balancer specified by %s",
+				specifiedLoadBalancer(service))
+		case key == ElbAutoscaling:
+			if _, e := dedicatedAutoscaling(service, &config.LoadBalancerOptions{}); e != nil {
+				add(ElbMinL7FlavorID, SeverityError, "%s", status.Convert(e).Message())
+			}
+		}
+	}
+
+	for _, key := range []string{ELBKeepEip, ElbXForwardedHost, ElbEnableCrossVpc, ElbEnableTransparentClientIP,
+		ElbAutoscaling} {
+		if value, ok := service.Annotations[key]; ok && value != "true" && value != "false" {
+			add(key, SeverityWarning, "invalid value %q, expected true or false, the default is used", value)
+		}
+	}
+
+	validateOptionAnnotations(service, add)
+	validateEIPAnnotations(service, add)
+	return findings
+}
+
+func isSharedClass(service *v1.Service) bool {
+	class := service.Annotations[ElbClass]
+	return class == "shared" || class == ""
+}
+
+func isKnownAnnotation(key string) bool {
+	for _, known := range knownAnnotations {
+		if key == known {
+			return true
+		}
+	}
+	return false
+}
+
+// validateOptionAnnotations checks the annotations overriding the loadbalancer-config options, each with the
+// validation of the options.
+func validateOptionAnnotations(service *v1.Service, add func(annotation, severity, format string,
+	args ...interface{})) {
+	validate := func(key string, set func(opts *config.LoadBalancerOptions) error) {
+		if _, ok := service.Annotations[key]; !ok {
+			return
+		}
+		opts := config.NewDefaultELBConfig().LoadBalancerOpts
+		if err := set(&opts); err != nil {
+			add(key, SeverityError, "%s", err)
+			return
+		}
+		if err := opts.Validate(); err != nil {
+			add(key, SeverityError, "%s", err)
+		}
+	}
+	value := func(key string) string {
+		return service.Annotations[key]
+	}
+	timeout := func(key string, field *int) error {
+		v, err := strconv.Atoi(value(key))
+		if err != nil {
+			return fmt.Errorf("invalid value %q, expected an integer", value(key))
+		}
+		*field = v
+		return nil
+	}
+
+	validate(ElbAlgorithm, func(opts *config.LoadBalancerOptions) error {
+		opts.LBAlgorithm = value(ElbAlgorithm)
+		return nil
+	})
+	validate(ElbSessionAffinityFlag, func(opts *config.LoadBalancerOptions) error {
+		opts.SessionAffinityFlag = value(ElbSessionAffinityFlag)
+		return nil
+	})
+	validate(ElbHealthCheckFlag, func(opts *config.LoadBalancerOptions) error {
+		opts.HealthCheckFlag = value(ElbHealthCheckFlag)
+		return nil
+	})
+	validate(ElbHealthCheckOptions, func(opts *config.LoadBalancerOptions) error {
+		if err := json.Unmarshal([]byte(value(ElbHealthCheckOptions)), &opts.HealthCheckOption); err != nil {
+			return fmt.Errorf("invalid JSON: %s", err)
+		}
+		return nil
+	})
+	validate(ElbIdleTimeout, func(opts *config.LoadBalancerOptions) error {
+		return timeout(ElbIdleTimeout, &opts.IdleTimeout)
+	})
+	validate(ElbRequestTimeout, func(opts *config.LoadBalancerOptions) error {
+		return timeout(ElbRequestTimeout, &opts.RequestTimeout)
+	})
+	validate(ElbResponseTimeout, func(opts *config.LoadBalancerOptions) error {
+		return timeout(ElbResponseTimeout, &opts.ResponseTimeout)
+	})
+
+	if str, ok := service.Annotations[ElbSessionAffinityOption]; ok {
+		var persistence elbmodel.SessionPersistence
+		if err := json.Unmarshal([]byte(str), &persistence); err != nil {
+			add(ElbSessionAffinityOption, SeverityWarning, "invalid JSON, the default is used: %s", err)
+			return
+		}
+		types := elbmodel.GetSessionPersistenceTypeEnum()
+		switch persistence.Type {
+		case types.SOURCE_IP:
+			if timeout := persistence.PersistenceTimeout; timeout != nil &&
+				(*timeout < ELBSessionSourceIPMinTimeout || *timeout > ELBSessionSourceIPMaxTimeout) {
+				add(ElbSessionAffinityOption, SeverityError, "invalid persistence_timeout %d, expected %d to %d",
+					*timeout, ELBSessionSourceIPMinTimeout, ELBSessionSourceIPMaxTimeout)
+			}
+		case types.HTTP_COOKIE, types.APP_COOKIE:
+		default:
+			add(ElbSessionAffinityOption, SeverityError, "invalid type %q, expected one of %s, %s, %s",
+				persistence.Type.Value(), types.SOURCE_IP.Value(), types.HTTP_COOKIE.Value(),
+				types.APP_COOKIE.Value())
+		}
+	}
+}
+
+// validateEIPAnnotations checks the options of the EIP to create with parseEIPAutoCreateOptions.
+func validateEIPAnnotations(service *v1.Service, add func(annotation, severity, format string,
+	args ...interface{})) {
+	if _, ok := service.Annotations[AutoCreateEipOptions]; !ok {
+		return
+	}
+	if service.Annotations[ElbEipID] != "" {
+		add(AutoCreateEipOptions, SeverityWarning, "it is ignored as %s is set", ElbEipID)
+	}
+
+	opts, err := parseEIPAutoCreateOptions(service)
+	if err != nil {
+		add(AutoCreateEipOptions, SeverityError, "invalid JSON: %s", err)
+		return
+	}
+	if opts.IPType == "" {
+		add(AutoCreateEipOptions, SeverityError, "ip_type is required")
+	}
+	shareTypes := eipmodel.GetCreatePublicipBandwidthOptionShareTypeEnum()
+	if opts.ShareType != shareTypes.PER.Value() && opts.ShareType != shareTypes.WHOLE.Value() {
+		add(AutoCreateEipOptions, SeverityError, "invalid share_type %q, expected %s or %s",
+			opts.ShareType, shareTypes.PER.Value(), shareTypes.WHOLE.Value())
+	}
+	if opts.ShareType == shareTypes.WHOLE.Value() && opts.ShareID == "" {
+		add(AutoCreateEipOptions, SeverityError, "share_id is required for the %s share_type", opts.ShareType)
+	}
+	if opts.ShareType == shareTypes.PER.Value() && opts.BandwidthSize <= 0 {
+		add(AutoCreateEipOptions, SeverityError, "bandwidth_size is required for the %s share_type", opts.ShareType)
+	}
+	chargeModes := eipmodel.GetCreatePublicipBandwidthOptionChargeModeEnum()
+	if opts.ChargeMode != chargeModes.BANDWIDTH.Value() && opts.ChargeMode != chargeModes.TRAFFIC.Value() {
+		add(AutoCreateEipOptions, SeverityError, "invalid charge_mode %q, expected %s or %s",
+			opts.ChargeMode, chargeModes.BANDWIDTH.Value(), chargeModes.TRAFFIC.Value())
+	}
+}
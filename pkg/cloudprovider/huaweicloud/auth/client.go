@@ -0,0 +1,330 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package auth resolves the credentials, the project and the endpoints of the cloud config, and signs and sends
+// the requests of the APIs not provided by huaweicloud-sdk-go-v3, i.e. the classic load balancers and Octavia.
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/klog"
+	klogv2 "k8s.io/klog/v2"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/apigw/core"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/audit"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/common"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/metrics"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/utils"
+)
+
+const (
+	HeaderSecurityToken string = "X-Security-Token"
+	HeaderProject       string = "X-Project-Id"
+
+	// longThrottleLatency defines threshold for logging requests. All requests being
+	// throttle for more than longThrottleLatency will be logged.
+	longThrottleLatency = 50 * time.Millisecond
+)
+
+type AccessInfo struct {
+	Region        string
+	AccessKey     string
+	SecretKey     string
+	SecurityToken string
+	ServiceType   string
+}
+
+type ServiceClient struct {
+	Client   *http.Client
+	Endpoint string
+	// Catalog is the catalog name of the service for the metrics, such as "elb".
+	Catalog  string
+	Access   *AccessInfo
+	TenantId string // nolint:golint // struct field `TenantId` should be `TenantID`
+	// APIVersion is the version in the path of the project scoped APIs, such as "v2".
+	APIVersion string
+	// OnUnreachable is called with the endpoint if it is unreachable, so that the subsequent clients
+	// fail over to the fallback endpoint.
+	OnUnreachable func(endpoint string)
+	// Context is the context of the reconcile, the mutating requests are audited with its Service.
+	Context context.Context
+	// Acquire waits for a slot of the in-flight requests to the endpoint, and returns the function to release it.
+	Acquire func(endpoint string) (release func())
+	// Timeout is the timeout of a request including reading the response body, the timeout of the shared
+	// HTTP client applies if it is zero.
+	Timeout time.Duration
+}
+
+// ProjectPath returns the path prefix of the project scoped APIs, such as "/v2/{project_id}".
+func (s *ServiceClient) ProjectPath() string {
+	return "/" + s.APIVersion + "/" + s.TenantId
+}
+
+// Request is the request sent by DoRequest, see NewRequest.
+type Request struct {
+	method  string
+	url     string
+	params  url.Values
+	obj     interface{}
+	headers map[string]string
+}
+
+// httpClient is shared by the ServiceClients, so that the connections to the cloud APIs are kept alive
+// and reused across the clients.
+var httpClient = &http.Client{
+	Transport: newTransport(&config.AuthOptions{}),
+	Timeout:   config.DefaultRequestTimeout * time.Second,
+}
+
+// HTTPClient returns the HTTP client shared by the ServiceClients, see ConfigureHTTPClient.
+func HTTPClient() *http.Client {
+	return httpClient
+}
+
+// newTransport returns the transport with the proxy, the dial timeout and the connection pool of the options.
+func newTransport(opts *config.AuthOptions) *http.Transport {
+	proxyFunc := opts.ProxyFunc()
+	return &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+		},
+		Proxy: func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		},
+		DialContext:           opts.Dialer().DialContext,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: opts.GetRequestTimeout(),
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// ConfigureHTTPClient applies the proxy and the timeouts of the options to the HTTP client shared by the
+// ServiceClients.
+func ConfigureHTTPClient(opts *config.AuthOptions) {
+	httpClient.Transport = newTransport(opts)
+	httpClient.Timeout = opts.GetRequestTimeout()
+}
+
+// NewRequest is used to create a new request
+// if accessIn == nil mean not to sign header
+func NewRequest(method, url string, headersIn map[string]string, obj interface{}) *Request {
+	r := &Request{
+		method:  method,
+		url:     url,
+		params:  make(map[string][]string),
+		headers: headersIn,
+		obj:     obj,
+	}
+	return r
+}
+
+// decodeBody is used to JSON decode a body
+// APIError is the error response of the requests sent by the ServiceClient.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("request failed: %s, status code: %d", e.Body, e.StatusCode)
+}
+
+func DecodeBody(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+	resBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		return &APIError{StatusCode: resp.StatusCode, Body: string(resBody)}
+	}
+
+	if len(strings.Replace(string(resBody), " ", "", -1)) <= 2 {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(resBody))
+
+	err = dec.Decode(out)
+	if err != nil {
+		return fmt.Errorf("Decode failed: %s, err: %v", string(resBody), err)
+	}
+
+	return nil
+}
+
+// encodeBody is used to encode a request body
+func encodeBody(obj interface{}) (*bytes.Buffer, error) {
+	buf := bytes.NewBuffer(nil)
+	enc := json.NewEncoder(buf)
+	if err := enc.Encode(obj); err != nil {
+		return nil, fmt.Errorf("encode obj error")
+	}
+	return buf, nil
+}
+
+// DoRequest sends the request signed with the access keys of the ServiceClient.
+func DoRequest(service *ServiceClient, throttle flowcontrol.RateLimiter, r *Request) (*http.Response, error) {
+	logger := klogv2.Background()
+	if service.Context != nil {
+		logger = klogv2.FromContext(service.Context)
+	}
+	lrt := utils.LogRoundTripper{Logger: &logger}
+
+	var body io.Reader
+	// Check if we should encode the body
+	if r.obj != nil {
+		b, err := encodeBody(r.obj)
+		if err != nil {
+			return nil, err
+		}
+
+		body = b
+		lrt.LogPayload("Request Body", b.Bytes(), "application/json")
+	}
+
+	tryThrottle(throttle, r)
+
+	url := service.Endpoint + r.url
+	// Create the HTTP request, it is canceled with the context of the reconcile.
+	ctx := service.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("the request %s %s is not sent, the reconcile is canceled: %w", r.method, r.url, err)
+	}
+	cancel := context.CancelFunc(func() {})
+	if service.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, service.Timeout)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, url, body)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("http new request error")
+	}
+	req.Header.Set("User-Agent", "huaweicloud-kubernetes-ccm")
+
+	// add the sign to request header if needed.
+	if service.Access != nil {
+		sign := core.Signer{
+			Key:    service.Access.AccessKey,
+			Secret: service.Access.SecretKey,
+		}
+		req.Header.Set(HeaderProject, service.TenantId)
+
+		// distinguish 'Permanent Security Credentials' and 'Temporary Security Credentials'
+		// HeaderSecurityToken only be set in case of 'Temporary Security Credentials',
+		// it must be set before signing, because it is one of the signed headers.
+		if service.Access.SecurityToken != "" {
+			req.Header.Set(HeaderSecurityToken, service.Access.SecurityToken)
+		}
+
+		if err := sign.Sign(req); err != nil {
+			cancel()
+			return nil, fmt.Errorf("DoRequest failed to get sign key %v", err)
+		}
+	}
+
+	record := audit.NewRecord(service.Context, req.Method, req.URL)
+	if service.Acquire != nil {
+		release := service.Acquire(service.Endpoint)
+		defer release()
+	}
+	done := metrics.StartRequest(service.Catalog)
+	start := time.Now()
+	resp, err := service.Client.Do(req)
+	done()
+	if record != nil {
+		auditResponse(record, resp)
+	}
+	if err != nil {
+		cancel()
+		if service.Context != nil && service.Context.Err() != nil {
+			return resp, fmt.Errorf("the request %s %s is aborted, the reconcile is canceled: %w",
+				r.method, r.url, service.Context.Err())
+		}
+		metrics.ObserveError(service.Catalog, metrics.ErrorCodeConnection)
+		err = fmt.Errorf("http client do request error. %w", err)
+		if service.OnUnreachable != nil && common.IsUnreachable(err) {
+			service.OnUnreachable(service.Endpoint)
+		}
+		return resp, err
+	}
+	// The timeout of the request covers reading the body, the context is released once the body is closed.
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	metrics.ObserveRequest(service.Catalog, req.URL.Host, req.Method, resp.StatusCode, time.Since(start))
+	logger.V(6).Info("API response", "service", service.Catalog, "method", req.Method, "url", req.URL.String(),
+		"statusCode", resp.StatusCode, "requestID", resp.Header.Get("X-Request-Id"))
+	if resp.Body, err = lrt.LogResponse(resp.Body, resp.Header.Get("Content-Type")); err != nil {
+		return resp, fmt.Errorf("failed to read the response body: %w", err)
+	}
+
+	return resp, nil
+}
+
+// cancelOnClose is a response body releasing the context of the request once it is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// auditResponse completes the record with the response, which is nil if the request failed to be sent.
+func auditResponse(record *audit.Record, resp *http.Response) {
+	if resp == nil {
+		record.Complete(0, "", nil)
+		record.Outcome = audit.OutcomeFailure
+		audit.Log(record)
+		return
+	}
+	// The body is read for the ID of the created resource, and restored for the caller.
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	record.Complete(resp.StatusCode, resp.Header.Get("X-Request-Id"), body)
+	audit.Log(record)
+}
+
+func tryThrottle(throttle flowcontrol.RateLimiter, r *Request) {
+	now := time.Now()
+	if throttle != nil {
+		throttle.Accept()
+	}
+	if latency := time.Since(now); latency > longThrottleLatency {
+		klog.V(2).Infof("Throttling request took %v, request: %s:%s", latency, r.method, r.url)
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"fmt"
+
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/wrapper"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+)
+
+// ReadCSMSSecret returns the secret string of the CSMS secret version, see config.CSMSReader.
+func ReadCSMSSecret(authOpts *config.AuthOptions, secretName, versionID string) (string, error) {
+	return (&wrapper.CsmsClient{AuthOpts: authOpts}).GetSecretString(secretName, versionID)
+}
+
+// ResolveProjectID resolves the project-id from the project-name through IAM if it is empty.
+func ResolveProjectID(authOpts *config.AuthOptions) error {
+	if authOpts.ProjectID != "" || authOpts.ProjectName == "" {
+		return nil
+	}
+
+	projectID, err := (&wrapper.IamClient{AuthOpts: authOpts}).GetProjectID(authOpts.ProjectName)
+	if err != nil {
+		return fmt.Errorf("failed to resolve the ID of project %s: %s", authOpts.ProjectName, err)
+	}
+	klog.Infof("resolved the ID of project %s: %s", authOpts.ProjectName, projectID)
+	authOpts.ProjectID = projectID
+	return nil
+}
+
+// DiscoverEndpoints resolves the service endpoints of the region from the IAM service catalog
+// if endpoint-discovery is enabled.
+func DiscoverEndpoints(authOpts *config.AuthOptions) error {
+	if !authOpts.EndpointDiscovery {
+		return nil
+	}
+
+	endpoints, err := (&wrapper.IamClient{AuthOpts: authOpts}).ListEndpoints()
+	if err != nil {
+		return fmt.Errorf("failed to discover the endpoints of region %s: %s", authOpts.Region, err)
+	}
+	klog.Infof("discovered the endpoints of region %s: %v", authOpts.Region, endpoints)
+	authOpts.SetEndpoints(endpoints)
+	if authOpts.HCS {
+		for _, catalog := range []string{"elb", "nat", "er", "cce", "kms"} {
+			if !authOpts.ServiceSupported(catalog) {
+				klog.Warningf("the %s service is absent from region %s, the features depending on it are skipped",
+					catalog, authOpts.Region)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	vpcmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/vpc/v2/model"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/wrapper"
+)
+
+// VPCClient is the client of the VPC APIs used by the routes, the subnets and the security groups.
+// It is implemented by the wrapper.VpcClient and by the FakeVPCClient of the unit tests.
+type VPCClient interface {
+	ListRouteTables(req *vpcmodel.ListRouteTablesRequest) ([]vpcmodel.RouteTableListResp, error)
+	GetRouteTable(id string) (*vpcmodel.RouteTableResp, error)
+	AddRoutes(routeTableID string, routes []vpcmodel.RouteTableRoute) error
+	ModifyRoutes(routeTableID string, routes []vpcmodel.RouteTableRoute) error
+	DeleteRoutes(routeTableID string, routes []vpcmodel.RouteTableRoute) error
+	ListSubnets(req *vpcmodel.ListSubnetsRequest) ([]vpcmodel.Subnet, error)
+	ListPorts(req *vpcmodel.ListPortsRequest) ([]vpcmodel.Port, error)
+	GetPort(id string) (*vpcmodel.Port, error)
+	UpdatePort(id string, opts *vpcmodel.UpdatePortOption) error
+	ListSecurityGroupRules(req *vpcmodel.ListSecurityGroupRulesRequest) ([]vpcmodel.SecurityGroupRule, error)
+	CreateSecurityGroupRule(opts *vpcmodel.CreateSecurityGroupRuleOption) (*vpcmodel.SecurityGroupRule, error)
+	DeleteSecurityGroupRule(id string) error
+}
+
+var _ VPCClient = &wrapper.VpcClient{}
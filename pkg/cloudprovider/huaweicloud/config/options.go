@@ -0,0 +1,82 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package config holds the options of the load balancers in use, which are taken from the cloud config and
+// overridden by the loadbalancer-config ConfigMap, and replaced as a whole on the changes of either.
+package config
+
+import (
+	"reflect"
+	"sync/atomic"
+
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+)
+
+// Options is a snapshot of the options of the load balancers in use. It is replaced as a whole on the
+// changes of the loadbalancer-config ConfigMap and the reloads of the cloud config, and never modified in place,
+// so the reconciles read it without locking.
+type Options struct {
+	config.LoadbalancerConfig
+	// Sections is the cloud config of the [LoadBalancer] and [Networking] sections in use, the defaults of the
+	// options and the default annotations are taken from it. The other sections are the ones of the startup.
+	Sections *config.CloudConfig
+}
+
+// Store holds the latest snapshot of the options, it is shared by the providers of all regions and projects.
+type Store struct {
+	current atomic.Pointer[Options]
+}
+
+// NewStore returns the store of the options of the config and the sections of the cloud config.
+func NewStore(cfg *config.LoadbalancerConfig, sections *config.CloudConfig) *Store {
+	s := &Store{}
+	s.Store(cfg, sections)
+	return s
+}
+
+// Load returns the latest snapshot of the options.
+func (s *Store) Load() *Options {
+	return s.current.Load()
+}
+
+// Store replaces the options with the config and the sections of the cloud config.
+func (s *Store) Store(cfg *config.LoadbalancerConfig, sections *config.CloudConfig) {
+	s.current.Store(&Options{LoadbalancerConfig: *cfg, Sections: sections})
+}
+
+// Publish publishes the options of the sections of the cloud config overridden by the data of the
+// loadbalancer-config ConfigMap, the callers serialize the publishes. An invalid ConfigMap is rejected,
+// and the options in use are kept.
+func (s *Store) Publish(sections *config.CloudConfig, data map[string]string) {
+	cfg, err := config.ParseELBConfig(sections.NewELBConfig(), data)
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		klog.Errorf("%s in ConfigMap %s/%s, keep the options in use: %s", config.ErrInvalidLoadbalancerConfig,
+			config.ProviderNamespace, config.LoadbalancerConfigMap, err)
+		return
+	}
+	current := s.Load()
+	if current.Sections == sections && reflect.DeepEqual(current.LoadbalancerConfig, *cfg) {
+		return
+	}
+
+	klog.Infof("the loadbalancer config is changed, apply: %#v", cfg)
+	s.Store(cfg, sections)
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	lbconfig "sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/config"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+)
+
+// DefaultAnnotationsLabel labels the ConfigMaps of the default annotations of the LoadBalancer services in their
+// namespace, the value must be "true". The keys of the ConfigMaps are the annotations without the "kubernetes.io/"
+// prefix, such as "elb.class", as the keys of the ConfigMaps can not contain "/".
+const DefaultAnnotationsLabel = "kubernetes.io/elb.default-annotations"
+
+// watchLoadBalancerConfig watches the loadbalancer-config ConfigMap, and applies the changes to the options in use
+// without restarting, until the stop channel is closed.
+// The options absent in the ConfigMap are taken from the cloud config.
+func (h *CloudProvider) watchLoadBalancerConfig(stop <-chan struct{}) {
+	selector := fields.OneTermEqualSelector("metadata.name", config.LoadbalancerConfigMap).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = selector
+			return h.kubeClient.ConfigMaps(config.ProviderNamespace).List(context.TODO(), options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = selector
+			return h.kubeClient.ConfigMaps(config.ProviderNamespace).Watch(context.TODO(), options)
+		},
+	}
+
+	_, informer := cache.NewInformer(lw, &v1.ConfigMap{}, 0, cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			h.applyLoadBalancerConfig(obj.(*v1.ConfigMap).Data)
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			h.applyLoadBalancerConfig(newObj.(*v1.ConfigMap).Data)
+		},
+		DeleteFunc: func(_ interface{}) {
+			h.applyLoadBalancerConfig(nil)
+		},
+	})
+	h.health.addInformer("loadbalancer-config", informer.HasSynced)
+	go informer.Run(stop)
+}
+
+// watchNamespaceDefaults watches the ConfigMaps of the default annotations in all namespaces until the stop channel
+// is closed, the ConfigMaps are read from the cache of the informer.
+func (h *CloudProvider) watchNamespaceDefaults(stop <-chan struct{}) {
+	selector := labels.SelectorFromSet(labels.Set{DefaultAnnotationsLabel: "true"}).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.LabelSelector = selector
+			return h.kubeClient.ConfigMaps(metav1.NamespaceAll).List(context.TODO(), options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = selector
+			return h.kubeClient.ConfigMaps(metav1.NamespaceAll).Watch(context.TODO(), options)
+		},
+	}
+
+	indexer, informer := cache.NewIndexerInformer(lw, &v1.ConfigMap{}, 0, cache.ResourceEventHandlerFuncs{},
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	h.namespaceDefaults = corelisters.NewConfigMapLister(indexer)
+	h.health.addInformer("namespace-default-annotations", informer.HasSynced)
+	go informer.Run(stop)
+}
+
+// namespaceDefaultAnnotations returns the default annotations of the namespace, keyed by annotation key.
+// The ConfigMaps of the namespace are merged in the order of their names, the latter ones take precedence.
+// Only the elb.* annotations can be defaulted, the other keys are ignored.
+func (h *CloudProvider) namespaceDefaultAnnotations(namespace string) map[string]string {
+	if h.namespaceDefaults == nil {
+		return nil
+	}
+	selector := labels.SelectorFromSet(labels.Set{DefaultAnnotationsLabel: "true"})
+	configMaps, err := h.namespaceDefaults.ConfigMaps(namespace).List(selector)
+	if err != nil {
+		klog.Errorf("failed to list the default annotations of namespace %s: %s", namespace, err)
+		return nil
+	}
+	sort.Slice(configMaps, func(i, j int) bool {
+		return configMaps[i].Name < configMaps[j].Name
+	})
+
+	annotations := make(map[string]string)
+	for _, cm := range configMaps {
+		for key, value := range cm.Data {
+			if !strings.HasPrefix(key, "elb.") {
+				klog.Warningf("ignore the key %q of ConfigMap %s/%s, only the elb.* annotations can be defaulted",
+					key, cm.Namespace, cm.Name)
+				continue
+			}
+			annotations["kubernetes.io/"+key] = value
+		}
+	}
+	return annotations
+}
+
+// watchCredentialSecret watches the secret of the credentials until the stop channel is closed, and returns the
+// lister of the secret in the cache of the informer, which is the source the credentials are read from.
+// The credentials are re-read at once when the secret is changed.
+func (h *CloudProvider) watchCredentialSecret(stop <-chan struct{}) (corelisters.SecretLister, cache.InformerSynced) {
+	secretOpts := &h.cloudConfig.SecretOpts
+	selector := fields.OneTermEqualSelector("metadata.name", secretOpts.Name).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = selector
+			return h.kubeClient.Secrets(secretOpts.Namespace).List(context.TODO(), options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = selector
+			return h.kubeClient.Secrets(secretOpts.Namespace).Watch(context.TODO(), options)
+		},
+	}
+
+	indexer, informer := cache.NewIndexerInformer(lw, &v1.Secret{}, 0, cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if oldObj.(*v1.Secret).ResourceVersion == newObj.(*v1.Secret).ResourceVersion {
+				return
+			}
+			klog.Infof("the secret %s/%s is changed, reload the credentials", secretOpts.Namespace, secretOpts.Name)
+			h.cloudConfig.AuthOpts.ReloadCredentials()
+		},
+	}, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	h.health.addInformer("credentials-secret", informer.HasSynced)
+	go informer.Run(stop)
+	return corelisters.NewSecretLister(indexer), informer.HasSynced
+}
+
+// currentOptions returns the snapshot of the options the reconcile is bound to, or the latest one outside
+// the reconciles.
+func (b Basic) currentOptions() *lbconfig.Options {
+	if b.pinned != nil {
+		return b.pinned
+	}
+	return b.options.Load()
+}
+
+func (b Basic) loadbalancerOpts() *config.LoadBalancerOptions {
+	return &b.currentOptions().LoadBalancerOpts
+}
+
+func (b Basic) networkingOpts() *config.NetworkingOptions {
+	return &b.currentOptions().NetworkingOpts
+}
+
+func (b Basic) metadataOpts() *config.MetadataOptions {
+	return &b.currentOptions().MetadataOpts
+}
+
+func (b Basic) nodeOpts() *config.NodeOptions {
+	return &b.currentOptions().NodeOpts
+}
+
+// applyLoadBalancerConfig publishes the options of the ConfigMap, which are shared by the providers of all regions
+// and projects. An invalid config is rejected, and the options in use are kept.
+func (h *CloudProvider) applyLoadBalancerConfig(data map[string]string) {
+	h.configLock.Lock()
+	defer h.configLock.Unlock()
+	h.loadbalancerConfigData = data
+	h.options.Publish(h.options.Load().Sections, data)
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	ecsapi "sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/ecs"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+)
+
+// serviceState is the last known desired and actual state of the load balancer of a service.
+type serviceState struct {
+	Operation   string    `json:"operation"`
+	ReconcileID string    `json:"reconcileID"`
+	Time        time.Time `json:"time"`
+	Duration    string    `json:"duration"`
+	Error       string    `json:"error,omitempty"`
+
+	Desired desiredState `json:"desired"`
+	// Actual is the status of the load balancer returned by the last GetLoadBalancer or EnsureLoadBalancer,
+	// nil if the load balancer does not exist.
+	Actual           *v1.LoadBalancerStatus `json:"actual"`
+	ActualObservedAt *time.Time             `json:"actualObservedAt,omitempty"`
+}
+
+// desiredState is the load balancer specified by the service.
+type desiredState struct {
+	LoadBalancerIP string            `json:"loadBalancerIP,omitempty"`
+	Ports          []string          `json:"ports"`
+	Annotations    map[string]string `json:"annotations,omitempty"`
+}
+
+func newDesiredState(service *v1.Service) desiredState {
+	state := desiredState{
+		LoadBalancerIP: service.Spec.LoadBalancerIP,
+		Annotations:    make(map[string]string),
+	}
+	for _, port := range service.Spec.Ports {
+		state.Ports = append(state.Ports, strings.Join([]string{string(port.Protocol), port.Name,
+			strconv.Itoa(int(port.Port)), strconv.Itoa(int(port.NodePort))}, "/"))
+	}
+	for key, value := range service.Annotations {
+		if strings.HasPrefix(key, "kubernetes.io/elb.") {
+			state.Annotations[key] = value
+		}
+	}
+	return state
+}
+
+// serviceStates records the state of the load balancer of each service, keyed by "<namespace>/<name>".
+type serviceStates struct {
+	lock   sync.Mutex
+	states map[string]*serviceState
+}
+
+func newServiceStates() *serviceStates {
+	return &serviceStates{states: make(map[string]*serviceState)}
+}
+
+func (s *serviceStates) get(service *v1.Service) *serviceState {
+	key := service.Namespace + "/" + service.Name
+	state, ok := s.states[key]
+	if !ok {
+		state = &serviceState{}
+		s.states[key] = state
+	}
+	return state
+}
+
+// recordReconcile records the result of the reconcile, it is a no-op on a nil serviceStates.
+func (s *serviceStates) recordReconcile(info reconcileInfo, service *v1.Service, start time.Time, err error) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	state := s.get(service)
+	state.Operation = info.operation
+	state.ReconcileID = info.reconcileID
+	state.Time = start
+	state.Duration = time.Since(start).String()
+	state.Error = ""
+	if err != nil {
+		state.Error = err.Error()
+	}
+	state.Desired = newDesiredState(service)
+	if info.operation == "EnsureLoadBalancerDeleted" && err == nil {
+		delete(s.states, service.Namespace+"/"+service.Name)
+	}
+}
+
+// recordActual records the status of the load balancer observed, nil if it does not exist.
+func (s *serviceStates) recordActual(service *v1.Service, status *v1.LoadBalancerStatus) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	state := s.get(service)
+	state.Actual = status.DeepCopy()
+	state.ActualObservedAt = &now
+}
+
+func (s *serviceStates) snapshot() map[string]serviceState {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	rst := make(map[string]serviceState, len(s.states))
+	for key, state := range s.states {
+		rst[key] = *state
+	}
+	return rst
+}
+
+// debugState is the internal state of the provider served on /debug/state, it contains no secret.
+type debugState struct {
+	Credentials   map[string]config.AuthState          `json:"credentials"`
+	InstanceCache map[string]ecsapi.InstanceCacheState `json:"instanceCache"`
+	AZCache       map[string][][]string                `json:"azCache"`
+	Services      map[string]serviceState              `json:"services"`
+}
+
+func (h *CloudProvider) debugState() debugState {
+	state := debugState{
+		Credentials:   make(map[string]config.AuthState),
+		InstanceCache: make(map[string]ecsapi.InstanceCacheState),
+		AZCache:       make(map[string][][]string),
+		Services:      h.states.snapshot(),
+	}
+	for region, b := range h.allRegions() {
+		state.Credentials[region] = b.cloudConfig.AuthOpts.State()
+		if b.ecsCache != nil {
+			state.InstanceCache[region] = b.ecsCache.Snapshot()
+		}
+		if b.azCache != nil {
+			state.AZCache[region] = b.azCache.Snapshot()
+		}
+	}
+	for name := range h.cloudConfig.Projects {
+		if projectConfig, err := h.cloudConfig.ForProject(name); err == nil {
+			state.Credentials["project/"+name] = projectConfig.AuthOpts.State()
+		}
+	}
+	return state
+}
+
+func (h *CloudProvider) serveDebugState(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(h.debugState()); err != nil {
+		klog.Errorf("failed to write the debug state: %s", err)
+	}
+}
+
+// serve serves the health endpoints, and /debug/state if the debug token file is configured.
+func (h *CloudProvider) serve(opts *config.HealthOptions) error {
+	mux := http.NewServeMux()
+	h.health.install(mux)
+	if opts.DebugTokenFile != "" {
+		token, err := readDebugToken(opts.DebugTokenFile)
+		if err != nil {
+			return err
+		}
+		mux.Handle("/debug/state", withBearerToken(token, http.HandlerFunc(h.serveDebugState)))
+	}
+
+	go func() {
+		klog.Infof("serving the health endpoints on %s", opts.BindAddress)
+		if err := http.ListenAndServe(opts.BindAddress, mux); err != nil {
+			klog.Errorf("failed to serve the health endpoints on %s: %s", opts.BindAddress, err)
+		}
+	}()
+	return nil
+}
+
+func readDebugToken(file string) (string, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the debug token file %s: %s", file, err)
+	}
+	token := strings.TrimSpace(string(content))
+	if token == "" {
+		return "", fmt.Errorf("the debug token file %s is empty", file)
+	}
+	return token, nil
+}
+
+// withBearerToken rejects the requests without the bearer token.
+func withBearerToken(token string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -20,14 +20,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 
 	eipmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/eip/v2/model"
 	elbmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/elb/v3/model"
+	vpcmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/vpc/v2/model"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/pointer"
 
@@ -41,10 +44,72 @@ const (
 	ElbL4FlavorID        = "kubernetes.io/elb.l4-flavor-id"
 	ElbL7FlavorID        = "kubernetes.io/elb.l7-flavor-id"
 	ElbAvailabilityZones = "kubernetes.io/elb.availability-zones"
+	// ElbSecurityGroupIDs is the comma-separated list of the security groups of the VIP port
+	// of the auto-created dedicated load balancer.
+	ElbSecurityGroupIDs = "kubernetes.io/elb.security-group-ids"
+	// ElbPoolProtocol is the protocol of the pools of the dedicated load balancer, such as HTTPS to re-encrypt the
+	// traffic of the TERMINATED_HTTPS listeners to the backends terminating TLS themselves.
+	ElbPoolProtocol = "kubernetes.io/elb.pool-protocol"
+	// ElbAutoscaling creates the dedicated load balancer of the elastic flavors scaling with the traffic,
+	// "true" or "false". The l4-flavor-id and l7-flavor-id are the upper limits of the elastic flavors.
+	ElbAutoscaling = "kubernetes.io/elb.autoscaling"
+	// ElbMinL7FlavorID is the minimum elastic flavor at Layer 7, required by the autoscaling with HTTP listeners.
+	ElbMinL7FlavorID = "kubernetes.io/elb.min-l7-flavor-id"
 
 	ElbEnableTransparentClientIP = "kubernetes.io/elb.enable-transparent-client-ip"
 )
 
+// dedicatedPoolProtocols are the protocols of the pools allowed behind the protocols of the listeners,
+// the first one is the default.
+var dedicatedPoolProtocols = map[string][]string{
+	ProtocolTCP:             {ProtocolTCP},
+	ProtocolUDP:             {ProtocolUDP},
+	ProtocolHTTP:            {ProtocolHTTP},
+	ProtocolTerminatedHTTPS: {ProtocolHTTP, ProtocolHTTPS},
+}
+
+// dedicatedPoolProtocol returns the protocol of the pool of the port, the ElbPoolProtocol annotation
+// or the default of the protocol of the listener. An InvalidArgument error is returned if the annotation
+// is not allowed behind the listener.
+func dedicatedPoolProtocol(service *v1.Service, port v1.ServicePort) (string, error) {
+	listenerProtocol := parseProtocol(service, port)
+	allowed := dedicatedPoolProtocols[listenerProtocol]
+	if len(allowed) == 0 {
+		return "", status.Errorf(codes.InvalidArgument, "protocol %s of port %d is not supported",
+			listenerProtocol, port.Port)
+	}
+	protocol := strings.ToUpper(getStringFromSvsAnnotation(service, ElbPoolProtocol, allowed[0]))
+	for _, p := range allowed {
+		if p == protocol {
+			return protocol, nil
+		}
+	}
+	return "", status.Errorf(codes.InvalidArgument, "the pool protocol %s of annotation %s is not supported "+
+		"behind the %s listener of port %d, expected one of %s", protocol, ElbPoolProtocol, listenerProtocol,
+		port.Port, strings.Join(allowed, ", "))
+}
+
+// dedicatedAutoscaling returns the autoscaling option of the load balancer to create, nil if it is disabled.
+// An InvalidArgument error is returned if the minimum flavor at Layer 7 is absent with the HTTP listeners.
+func dedicatedAutoscaling(service *v1.Service, opts *config.LoadBalancerOptions) (
+	*elbmodel.CreateLoadbalancerAutoscalingOption, error) {
+	if !getBoolFromSvsAnnotation(service, ElbAutoscaling, opts.EnableAutoscaling) {
+		return nil, nil
+	}
+	autoscaling := &elbmodel.CreateLoadbalancerAutoscalingOption{Enable: true}
+	if minL7FlavorID := getStringFromSvsAnnotation(service, ElbMinL7FlavorID, opts.MinL7FlavorID); minL7FlavorID != "" {
+		autoscaling.MinL7FlavorId = &minL7FlavorID
+		return autoscaling, nil
+	}
+	for _, port := range service.Spec.Ports {
+		if protocol := parseProtocol(service, port); protocol == ProtocolHTTP || protocol == ProtocolTerminatedHTTPS {
+			return nil, status.Errorf(codes.InvalidArgument, "annotation %s is required by the autoscaling with "+
+				"the %s listener of port %d", ElbMinL7FlavorID, protocol, port.Port)
+		}
+	}
+	return autoscaling, nil
+}
+
 type DedicatedLoadBalancer struct {
 	Basic
 }
@@ -90,28 +155,33 @@ func (d *DedicatedLoadBalancer) getLoadBalancerInstance(ctx context.Context, clu
 		return d.dedicatedELBClient.GetInstance(id)
 	}
 
-	name := d.GetLoadBalancerName(ctx, clusterName, service)
-	names := []string{name}
-	list, err := d.dedicatedELBClient.ListInstances(&elbmodel.ListLoadBalancersRequest{Name: &names})
-	if err != nil {
-		return nil, err
-	}
+	names := d.loadBalancerNames(clusterName, service)
+	for _, name := range names {
+		// the shared load balancers of the converged regions are listed by the v3 API too, with the same name
+		// while the service migrates to the dedicated one.
+		list, err := d.dedicatedELBClient.ListInstances(&elbmodel.ListLoadBalancersRequest{
+			Name:       &[]string{name},
+			Guaranteed: pointer.Bool(true),
+		})
+		if err != nil {
+			return nil, err
+		}
 
-	count := len(list)
-	if count == 0 {
-		return nil, status.Errorf(codes.NotFound, "not found dedicated ELB instance %s", name)
-	}
-	if count != 1 {
-		return nil, status.Errorf(codes.Unavailable, "error, found %d dedicated ELB named %s, "+
-			"make sure there is only one", len(list), name)
+		count := len(list)
+		if count == 0 {
+			continue
+		}
+		if count != 1 {
+			return nil, status.Errorf(codes.Unavailable, "error, found %d dedicated ELB named %s, "+
+				"make sure there is only one", len(list), name)
+		}
+		return &list[0], nil
 	}
-	return &list[0], nil
+	return nil, status.Errorf(codes.NotFound, "not found dedicated ELB instance %s", names[0])
 }
 
 func (d *DedicatedLoadBalancer) GetLoadBalancerName(_ context.Context, clusterName string, service *v1.Service) string {
-	klog.Infof("GetLoadBalancerName: called with service %s/%s", service.Namespace, service.Name)
-	name := fmt.Sprintf("k8s_service_%s_%s_%s", clusterName, service.Namespace, service.Name)
-	return utils.CutString(name, defaultMaxNameLength)
+	return loadBalancerName(d.clusterID(clusterName), service)
 }
 
 func (d *DedicatedLoadBalancer) EnsureLoadBalancer(ctx context.Context, clusterName string, service *v1.Service, nodes []*v1.Node) (*v1.LoadBalancerStatus, error) {
@@ -134,10 +204,18 @@ func (d *DedicatedLoadBalancer) EnsureLoadBalancer(ctx context.Context, clusterN
 			return nil, e
 		}
 		loadbalancer, err = d.createLoadbalancer(clusterName, subnetID, service)
+		if err != nil {
+			d.invalidateSubnet(subnetID, err)
+		}
 	}
 	if err != nil {
 		return nil, err
 	}
+	if specifiedID == "" {
+		if err = d.ensureVipSecurityGroups(loadbalancer, service); err != nil {
+			return nil, err
+		}
+	}
 
 	// query ELB listeners list
 	loadbalancerIDs := []string{loadbalancer.Id}
@@ -149,12 +227,16 @@ func (d *DedicatedLoadBalancer) EnsureLoadBalancer(ctx context.Context, clusterN
 	}
 
 	for _, port := range service.Spec.Ports {
+		poolProtocol, err := dedicatedPoolProtocol(service, port)
+		if err != nil {
+			return nil, err
+		}
 		listener := d.filterListenerByPort(listeners, service, port)
 		// add or update listener
 		if listener == nil {
-			listener, err = d.createListener(loadbalancer.Id, service, port)
-		} else {
-			err = d.updateListener(listener, service, port)
+			listener, err = d.createListener(clusterName, loadbalancer.Id, service, port)
+		} else if err = d.checkListenerOwner(clusterName, service, listener.Id, port.Port, listener.Description); err == nil {
+			err = d.updateListener(clusterName, listener, service, port)
 		}
 		if err != nil {
 			return nil, err
@@ -162,11 +244,7 @@ func (d *DedicatedLoadBalancer) EnsureLoadBalancer(ctx context.Context, clusterN
 
 		listeners = d.popListener(listeners, listener.Id)
 
-		// query pool or create pool
-		pool, err := d.getPool(loadbalancer.Id, listener.Id)
-		if err != nil && common.IsNotFound(err) {
-			pool, err = d.createPool(listener, service)
-		}
+		pool, err := d.ensurePool(clusterName, loadbalancer.Id, listener, service, poolProtocol)
 		if err != nil {
 			return nil, err
 		}
@@ -190,6 +268,10 @@ func (d *DedicatedLoadBalancer) EnsureLoadBalancer(ctx context.Context, clusterN
 		}
 	}
 
+	if err = d.ensureSecurityGroupRules(service, "dedicated", nodes, d.dedicatedSecurityGroupSources(service)); err != nil {
+		return nil, err
+	}
+
 	ingressIP := loadbalancer.VipAddress
 
 	return &v1.LoadBalancerStatus{
@@ -199,8 +281,7 @@ func (d *DedicatedLoadBalancer) EnsureLoadBalancer(ctx context.Context, clusterN
 
 func (d *DedicatedLoadBalancer) createLoadbalancer(clusterName, subnetID string, service *v1.Service) (*elbmodel.LoadBalancer, error) {
 	name := d.GetLoadBalancerName(context.TODO(), clusterName, service)
-	desc := fmt.Sprintf("Created by the ELB service(%s/%s) of the k8s cluster(%s).",
-		service.Namespace, service.Name, clusterName)
+	desc := d.resourceDescription(clusterName, service)
 
 	azStr := getStringFromSvsAnnotation(service, ElbAvailabilityZones, "")
 	if azStr == "" {
@@ -208,6 +289,9 @@ func (d *DedicatedLoadBalancer) createLoadbalancer(clusterName, subnetID string,
 			"Invalid argument, annotation \"kubernetes.io/elb.availability-zones\" cannot be empty")
 	}
 	availabilityZoneList := strings.Split(azStr, ";")
+	if err := d.azCache.Validate(availabilityZoneList); err != nil {
+		return nil, err
+	}
 
 	createOpt := &elbmodel.CreateLoadBalancerOption{
 		Name:                 &name,
@@ -216,16 +300,21 @@ func (d *DedicatedLoadBalancer) createLoadbalancer(clusterName, subnetID string,
 		Provider:             pointer.String("vlb"),
 		Description:          &desc,
 	}
-	enableCrossVpc := getBoolFromSvsAnnotation(service, ElbEnableCrossVpc, d.loadbalancerOpts.EnableCrossVpc)
+	enableCrossVpc := getBoolFromSvsAnnotation(service, ElbEnableCrossVpc, d.loadbalancerOpts().EnableCrossVpc)
 	if enableCrossVpc {
 		createOpt.IpTargetEnable = &enableCrossVpc
 	}
-	if l4FlavorID := getStringFromSvsAnnotation(service, ElbL4FlavorID, d.loadbalancerOpts.L4FlavorID); l4FlavorID != "" {
+	if l4FlavorID := getStringFromSvsAnnotation(service, ElbL4FlavorID, d.loadbalancerOpts().L4FlavorID); l4FlavorID != "" {
 		createOpt.L4FlavorId = &l4FlavorID
 	}
-	if l7FlavorID := getStringFromSvsAnnotation(service, ElbL7FlavorID, d.loadbalancerOpts.L7FlavorID); l7FlavorID != "" {
+	if l7FlavorID := getStringFromSvsAnnotation(service, ElbL7FlavorID, d.loadbalancerOpts().L7FlavorID); l7FlavorID != "" {
 		createOpt.L7FlavorId = &l7FlavorID
 	}
+	autoscaling, err := dedicatedAutoscaling(service, d.loadbalancerOpts())
+	if err != nil {
+		return nil, err
+	}
+	createOpt.Autoscaling = autoscaling
 
 	// eip
 	eipID := getStringFromSvsAnnotation(service, ElbEipID, "")
@@ -248,6 +337,43 @@ func (d *DedicatedLoadBalancer) createLoadbalancer(clusterName, subnetID string,
 	return loadbalancer, nil
 }
 
+// parseSecurityGroupIDs returns the security groups of the ElbSecurityGroupIDs annotation of the service.
+func parseSecurityGroupIDs(service *v1.Service) []string {
+	var ids []string
+	for _, id := range strings.Split(getStringFromSvsAnnotation(service, ElbSecurityGroupIDs, ""), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// ensureVipSecurityGroups attaches the VIP port of the load balancer to the security groups of the
+// ElbSecurityGroupIDs annotation, instead of the default security group chosen by the API.
+// The security groups of the port are kept as is if the annotation is empty.
+func (d *DedicatedLoadBalancer) ensureVipSecurityGroups(loadbalancer *elbmodel.LoadBalancer, service *v1.Service) error {
+	groups := parseSecurityGroupIDs(service)
+	if len(groups) == 0 || loadbalancer.VipPortId == "" {
+		return nil
+	}
+	port, err := d.vpcClient.GetPort(loadbalancer.VipPortId)
+	if err != nil {
+		return fmt.Errorf("failed to get the VIP port %s of ELB %s: %w", loadbalancer.VipPortId, loadbalancer.Id, err)
+	}
+	if sets.NewString(port.SecurityGroups...).Equal(sets.NewString(groups...)) {
+		return nil
+	}
+
+	klog.Infof("Attach the VIP port %s of ELB %s to the security groups %v instead of %v",
+		port.Id, loadbalancer.Id, groups, port.SecurityGroups)
+	err = d.vpcClient.UpdatePort(port.Id, &vpcmodel.UpdatePortOption{SecurityGroups: &groups})
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "failed to attach the VIP port %s of ELB %s to "+
+			"the security groups %v of annotation %q: %s", port.Id, loadbalancer.Id, groups, ElbSecurityGroupIDs, err)
+	}
+	return nil
+}
+
 func (d *DedicatedLoadBalancer) parsePublicIP(service *v1.Service) (*elbmodel.CreateLoadBalancerPublicIpOption, error) {
 	eipOpt, err := parseEIPAutoCreateOptions(service)
 	if err != nil {
@@ -300,42 +426,44 @@ func (d *DedicatedLoadBalancer) filterListenerByPort(listeners []elbmodel.Listen
 	return nil
 }
 
-func (d *DedicatedLoadBalancer) createListener(loadbalancerID string, service *v1.Service, port v1.ServicePort,
-) (*elbmodel.Listener, error) {
+func (d *DedicatedLoadBalancer) createListener(clusterName, loadbalancerID string, service *v1.Service,
+	port v1.ServicePort) (*elbmodel.Listener, error) {
 	xForwardFor := getBoolFromSvsAnnotation(service, ElbXForwardedHost, false)
-	name := utils.CutString(fmt.Sprintf("%s_%s_%v", service.Name, port.Protocol, port.Port), defaultMaxNameLength)
+	name := d.listenerName(clusterName, service, string(port.Protocol), port.Port)
+	desc := d.resourceDescription(clusterName, service)
 
 	createOpt := &elbmodel.CreateListenerOption{
 		Name:           &name,
+		Description:    &desc,
 		LoadbalancerId: loadbalancerID,
 		ProtocolPort:   port.Port,
-		InsertHeaders:  &elbmodel.ListenerInsertHeaders{XForwardedHost: &xForwardFor},
 	}
 
 	protocol := parseProtocol(service, port)
+	if protocol != ProtocolUDP {
+		createOpt.InsertHeaders = &elbmodel.ListenerInsertHeaders{XForwardedHost: &xForwardFor}
+	}
 	if protocol == ProtocolTerminatedHTTPS {
 		defaultTLSContainerRef := getStringFromSvsAnnotation(service, DefaultTLSContainerRef, "")
 		createOpt.DefaultTlsContainerRef = &defaultTLSContainerRef
-	} else if xForwardFor {
-		protocol = ProtocolHTTP
 	}
 	createOpt.Protocol = protocol
 
 	transparentClientIPEnable := getBoolFromSvsAnnotation(service, ElbEnableTransparentClientIP,
-		d.loadbalancerOpts.EnableTransparentClientIP)
+		d.loadbalancerOpts().EnableTransparentClientIP)
 	if transparentClientIPEnable {
 		createOpt.TransparentClientIpEnable = &transparentClientIPEnable
 	}
 
-	if timeout := getIntFromSvsAnnotation(service, ElbIdleTimeout, d.loadbalancerOpts.IdleTimeout); timeout != 0 {
+	if timeout := getIntFromSvsAnnotation(service, ElbIdleTimeout, d.loadbalancerOpts().IdleTimeout); timeout != 0 {
 		createOpt.KeepaliveTimeout = pointer.Int32(int32(timeout))
 	}
 
 	if protocol == ProtocolHTTP || protocol == ProtocolTerminatedHTTPS {
-		if timeout := getIntFromSvsAnnotation(service, ElbRequestTimeout, d.loadbalancerOpts.RequestTimeout); timeout != 0 {
+		if timeout := getIntFromSvsAnnotation(service, ElbRequestTimeout, d.loadbalancerOpts().RequestTimeout); timeout != 0 {
 			createOpt.ClientTimeout = pointer.Int32(int32(timeout))
 		}
-		if timeout := getIntFromSvsAnnotation(service, ElbResponseTimeout, d.loadbalancerOpts.ResponseTimeout); timeout != 0 {
+		if timeout := getIntFromSvsAnnotation(service, ElbResponseTimeout, d.loadbalancerOpts().ResponseTimeout); timeout != 0 {
 			createOpt.MemberTimeout = pointer.Int32(int32(timeout))
 		}
 	}
@@ -349,47 +477,55 @@ func (d *DedicatedLoadBalancer) createListener(loadbalancerID string, service *v
 	return listener, nil
 }
 
-func (d *DedicatedLoadBalancer) updateListener(listener *elbmodel.Listener, service *v1.Service, port v1.ServicePort) error {
-	xForwardFor := getBoolFromSvsAnnotation(service, ElbXForwardedHost, false)
-	name := utils.CutString(fmt.Sprintf("%s_%s_%v", service.Name, port.Protocol, port.Port), defaultMaxNameLength)
+func (d *DedicatedLoadBalancer) updateListener(clusterName string, listener *elbmodel.Listener, service *v1.Service,
+	port v1.ServicePort) error {
+	name := d.listenerName(clusterName, service, string(port.Protocol), port.Port)
+	desc := d.resourceDescription(clusterName, service)
 
 	updateOpts := &elbmodel.UpdateListenerOption{
-		Name: &name,
+		Name:        &name,
+		Description: &desc,
 	}
 
 	protocol := parseProtocol(service, port)
 
 	transparentClientIPEnable := getBoolFromSvsAnnotation(service, ElbEnableTransparentClientIP,
-		d.loadbalancerOpts.EnableTransparentClientIP)
+		d.loadbalancerOpts().EnableTransparentClientIP)
 	if transparentClientIPEnable {
 		updateOpts.TransparentClientIpEnable = &transparentClientIPEnable
 	} else if protocol == ProtocolUDP || protocol == ProtocolTCP {
 		updateOpts.TransparentClientIpEnable = &transparentClientIPEnable
 	}
 
-	if timeout := getIntFromSvsAnnotation(service, ElbIdleTimeout, d.loadbalancerOpts.IdleTimeout); timeout != 0 {
+	if timeout := getIntFromSvsAnnotation(service, ElbIdleTimeout, d.loadbalancerOpts().IdleTimeout); timeout != 0 {
 		updateOpts.KeepaliveTimeout = pointer.Int32(int32(timeout))
 	}
 
 	if protocol == ProtocolTerminatedHTTPS {
 		defaultTLSContainerRef := getStringFromSvsAnnotation(service, DefaultTLSContainerRef, "")
 		updateOpts.DefaultTlsContainerRef = &defaultTLSContainerRef
-	} else if xForwardFor {
-		protocol = ProtocolHTTP
 	}
 
 	if protocol == ProtocolHTTP || protocol == ProtocolTerminatedHTTPS {
-		if timeout := getIntFromSvsAnnotation(service, ElbRequestTimeout, d.loadbalancerOpts.RequestTimeout); timeout != 0 {
+		if timeout := getIntFromSvsAnnotation(service, ElbRequestTimeout, d.loadbalancerOpts().RequestTimeout); timeout != 0 {
 			updateOpts.ClientTimeout = pointer.Int32(int32(timeout))
 		}
-		if timeout := getIntFromSvsAnnotation(service, ElbResponseTimeout, d.loadbalancerOpts.ResponseTimeout); timeout != 0 {
+		if timeout := getIntFromSvsAnnotation(service, ElbResponseTimeout, d.loadbalancerOpts().ResponseTimeout); timeout != 0 {
 			updateOpts.MemberTimeout = pointer.Int32(int32(timeout))
 		}
 	}
 
 	klog.V(4).Infof("[DEBUG] Update dedicated instance listener options: %s", utils.ToString(updateOpts))
 
-	err := d.dedicatedELBClient.UpdateListener(listener.Id, updateOpts)
+	current, err := d.dedicatedELBClient.GetListener(listener.Id)
+	if err != nil {
+		return err
+	}
+	if err = checkListenerRevision(listener.Id, listener.UpdatedAt, current.UpdatedAt); err != nil {
+		return err
+	}
+
+	err = d.dedicatedELBClient.UpdateListener(listener.Id, updateOpts)
 	if err != nil {
 		return err
 	}
@@ -436,7 +572,29 @@ func (d *DedicatedLoadBalancer) popListener(listeners []elbmodel.Listener, id st
 	return listeners
 }
 
-func (d *DedicatedLoadBalancer) createPool(listener *elbmodel.Listener, service *v1.Service) (*elbmodel.Pool, error) {
+// ensurePool returns the pool of the listener, it is created if absent, and recreated if its protocol is changed,
+// as the protocol of a pool can not be updated.
+func (d *DedicatedLoadBalancer) ensurePool(clusterName, loadbalancerID string, listener *elbmodel.Listener,
+	service *v1.Service, protocol string) (*elbmodel.Pool, error) {
+	pool, err := d.getPool(loadbalancerID, listener.Id)
+	if err != nil && !common.IsNotFound(err) {
+		return nil, err
+	}
+	if err == nil && pool.Protocol == protocol {
+		return pool, nil
+	}
+	if err == nil {
+		klog.Infof("recreating pool %s of listener %s of service %s/%s, the protocol is changed from %s to %s",
+			pool.Id, listener.Id, service.Namespace, service.Name, pool.Protocol, protocol)
+		if errs := d.deletePool(pool); len(errs) > 0 {
+			return nil, errors.NewAggregate(errs)
+		}
+	}
+	return d.createPool(clusterName, listener, service, protocol)
+}
+
+func (d *DedicatedLoadBalancer) createPool(clusterName string, listener *elbmodel.Listener, service *v1.Service,
+	protocol string) (*elbmodel.Pool, error) {
 	var sessionPersistence *elbmodel.CreatePoolSessionPersistenceOption
 
 	persistence := d.getSessionAffinity(service)
@@ -452,14 +610,12 @@ func (d *DedicatedLoadBalancer) createPool(listener *elbmodel.Listener, service
 		}
 	}
 
-	lbAlgorithm := getStringFromSvsAnnotation(service, ElbAlgorithm, d.loadbalancerOpts.LBAlgorithm)
-	name := fmt.Sprintf("pl_%s", listener.Name)
-	protocol := listener.Protocol
-	if protocol == ProtocolTerminatedHTTPS {
-		protocol = ProtocolHTTP
-	}
+	lbAlgorithm := getStringFromSvsAnnotation(service, ElbAlgorithm, d.loadbalancerOpts().LBAlgorithm)
+	name := utils.CutString(fmt.Sprintf("pl_%s", listener.Name), defaultMaxNameLength)
+	desc := d.resourceDescription(clusterName, service)
 	return d.dedicatedELBClient.CreatePool(&elbmodel.CreatePoolOption{
 		Name:               &name,
+		Description:        &desc,
 		Protocol:           protocol,
 		LbAlgorithm:        lbAlgorithm,
 		ListenerId:         &listener.Id,
@@ -469,8 +625,10 @@ func (d *DedicatedLoadBalancer) createPool(listener *elbmodel.Listener, service
 
 func (d *DedicatedLoadBalancer) getPool(elbID, listenerID string) (*elbmodel.Pool, error) {
 	loadbalancerIDs := []string{elbID}
+	listenerIDs := []string{listenerID}
 	pools, err := d.dedicatedELBClient.ListPools(&elbmodel.ListPoolsRequest{
 		LoadbalancerId: &loadbalancerIDs,
+		ListenerId:     &listenerIDs,
 	})
 	if err != nil {
 		return nil, err
@@ -516,12 +674,7 @@ func (d *DedicatedLoadBalancer) addOrRemoveMembers(loadbalancer *elbmodel.LoadBa
 		existsMember[fmt.Sprintf("%s:%d", m.Address, m.ProtocolPort)] = true
 	}
 
-	nodeNameMapping := make(map[string]*v1.Node)
-	for _, node := range nodes {
-		nodeNameMapping[node.Name] = node
-	}
-
-	podList, err := d.listPodsBySelector(context.TODO(), service.Namespace, service.Spec.Selector)
+	podList, err := d.listPodsBySelector(d.context(), service.Namespace, service.Spec.Selector)
 	if err != nil {
 		return err
 	}
@@ -537,10 +690,12 @@ func (d *DedicatedLoadBalancer) addOrRemoveMembers(loadbalancer *elbmodel.LoadBa
 			continue
 		}
 
-		node, ok := nodeNameMapping[pod.Spec.NodeName]
+		// The nodes excluded from the load balancers are not passed in, the pods on them are not served.
+		node, ok := d.nodes.get(pod.Spec.NodeName, nodes)
 		if !ok {
-			return fmt.Errorf("could not find the node where the Pod resides, Pod: %s/%s",
-				pod.Namespace, pod.Spec.NodeName)
+			klog.Warningf("the node %s of pod %s/%s is not eligible for the load balancers, skipping adding to ELB",
+				pod.Spec.NodeName, pod.Namespace, pod.Name)
+			continue
 		}
 
 		address, err := getNodeAddress(node)
@@ -550,7 +705,7 @@ func (d *DedicatedLoadBalancer) addOrRemoveMembers(loadbalancer *elbmodel.LoadBa
 				klog.Warningf("Failed to create SharedLoadBalancer pool member for node %s: %v", node.Name, err)
 				continue
 			} else {
-				return fmt.Errorf("error getting address for node %s: %v", node.Name, err)
+				return fmt.Errorf("error getting address for node %s: %w", node.Name, err)
 			}
 		}
 
@@ -571,6 +726,21 @@ func (d *DedicatedLoadBalancer) addOrRemoveMembers(loadbalancer *elbmodel.LoadBa
 		existsMember[key] = true
 	}
 
+	// The members are re-read before removing the obsolete ones, which may be added by another controller.
+	if len(members) > 0 {
+		current, err := d.dedicatedELBClient.ListMembers(&elbmodel.ListMembersRequest{PoolId: pool.Id})
+		if err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(current))
+		for _, m := range current {
+			keys = append(keys, fmt.Sprintf("%s:%d", m.Address, m.ProtocolPort))
+		}
+		if err = checkMembersRevision(pool.Id, existsMember, keys); err != nil {
+			return err
+		}
+	}
+
 	// delete the remaining elements in members
 	for _, member := range members {
 		klog.Infof("[addOrRemoveMembers] remove node from pool, name: %s, address: %s, port: %d",
@@ -603,13 +773,12 @@ func (d *DedicatedLoadBalancer) addMember(loadbalancer *elbmodel.LoadBalancer, p
 	}
 
 	if _, err = d.dedicatedELBClient.AddMember(pool.Id, opt); err != nil {
-		return fmt.Errorf("error creating SharedLoadBalancer pool member for node: %s, %v", node.Name, err)
+		return fmt.Errorf("error creating SharedLoadBalancer pool member for node: %s, %w", node.Name, err)
 	}
 
 	loadbalancer, err = d.dedicatedELBClient.WaitStatusActive(loadbalancer.Id)
 	if err != nil {
-		return fmt.Errorf("timeout when waiting for loadbalancer to be ACTIVE after adding members, "+
-			"current status %s", loadbalancer.ProvisioningStatus)
+		return fmt.Errorf("failed to wait for loadbalancer to be ACTIVE after adding members: %w", err)
 	}
 
 	return nil
@@ -622,10 +791,9 @@ func (d *DedicatedLoadBalancer) deleteMember(elbID string, poolID string, member
 		return fmt.Errorf("error deleting obsolete member %s for pool %s address %s: %v",
 			poolID, member.Id, member.Address, err)
 	}
-	loadbalancer, err := d.dedicatedELBClient.WaitStatusActive(elbID)
+	_, err = d.dedicatedELBClient.WaitStatusActive(elbID)
 	if err != nil {
-		return fmt.Errorf("timeout when waiting for loadbalancer to be ACTIVE after creating member, "+
-			"current provisioning status %s", loadbalancer.ProvisioningStatus)
+		return fmt.Errorf("failed to wait for loadbalancer to be ACTIVE after creating member: %w", err)
 	}
 	return nil
 }
@@ -641,7 +809,7 @@ func (d *DedicatedLoadBalancer) popMember(members []elbmodel.Member, addr string
 }
 
 func (d *DedicatedLoadBalancer) getSessionAffinity(service *v1.Service) *elbmodel.SessionPersistence {
-	globalOpts := d.loadbalancerOpts
+	globalOpts := d.loadbalancerOpts()
 	sessionMode := getStringFromSvsAnnotation(service, ElbSessionAffinityFlag, globalOpts.SessionAffinityFlag)
 	if sessionMode == "" || sessionMode == "off" {
 		return nil
@@ -674,7 +842,7 @@ func (d *DedicatedLoadBalancer) getSessionAffinity(service *v1.Service) *elbmode
 
 func (d *DedicatedLoadBalancer) addOrRemoveHealthMonitor(loadbalancerID string, pool *elbmodel.Pool,
 	port v1.ServicePort, service *v1.Service) error {
-	healthCheckOpts := getHealthCheckOptionFromAnnotation(service, d.loadbalancerOpts)
+	healthCheckOpts := getHealthCheckOptionFromAnnotation(service, d.loadbalancerOpts())
 	monitorID := pool.HealthmonitorId
 	klog.Infof("add or remove health check: %s : %#v", monitorID, healthCheckOpts)
 
@@ -694,7 +862,7 @@ func (d *DedicatedLoadBalancer) addOrRemoveHealthMonitor(loadbalancerID string,
 		klog.Infof("Deleting health monitor %s for pool %s", monitorID, pool.Id)
 		err := d.dedicatedELBClient.DeleteHealthMonitor(monitorID)
 		if err != nil {
-			return fmt.Errorf("failed to delete health monitor %s for pool %s, error: %v", monitorID, pool.Id, err)
+			return fmt.Errorf("failed to delete health monitor %s for pool %s, error: %w", monitorID, pool.Id, err)
 		}
 	}
 
@@ -707,6 +875,9 @@ func (d *DedicatedLoadBalancer) updateHealthMonitor(id string, protocol v1.Proto
 	if protocol == v1.ProtocolSCTP {
 		return status.Errorf(codes.InvalidArgument, "Protocol SCTP not supported")
 	}
+	if protocol == v1.ProtocolUDP {
+		monitorProtocol = "UDP_CONNECT"
+	}
 
 	return d.dedicatedELBClient.UpdateHealthMonitor(id, &elbmodel.UpdateHealthMonitorOption{
 		Type:       &monitorProtocol,
@@ -717,6 +888,9 @@ func (d *DedicatedLoadBalancer) updateHealthMonitor(id string, protocol v1.Proto
 }
 
 func (d *DedicatedLoadBalancer) createHealthMonitor(loadbalancerID, poolID, protocol string, opts *config.HealthCheckOption) (*elbmodel.HealthMonitor, error) {
+	if protocol == ProtocolUDP {
+		protocol = "UDP_CONNECT"
+	}
 	monitor, err := d.dedicatedELBClient.CreateHealthMonitor(&elbmodel.CreateHealthMonitorOption{
 		PoolId:     poolID,
 		Type:       protocol,
@@ -725,13 +899,12 @@ func (d *DedicatedLoadBalancer) createHealthMonitor(loadbalancerID, poolID, prot
 		MaxRetries: opts.MaxRetries,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("error creating SharedLoadBalancer pool health monitor: %v", err)
+		return nil, fmt.Errorf("error creating SharedLoadBalancer pool health monitor: %w", err)
 	}
 
-	loadbalancer, err := d.dedicatedELBClient.WaitStatusActive(loadbalancerID)
+	_, err = d.dedicatedELBClient.WaitStatusActive(loadbalancerID)
 	if err != nil {
-		return nil, fmt.Errorf("timeout when waiting for loadbalancer to be ACTIVE after creating member, "+
-			"current provisioning status %s", loadbalancer.ProvisioningStatus)
+		return nil, fmt.Errorf("failed to wait for loadbalancer to be ACTIVE after creating member: %w", err)
 	}
 	return monitor, nil
 }
@@ -759,12 +932,15 @@ func (d *DedicatedLoadBalancer) UpdateLoadBalancer(ctx context.Context, clusterN
 			return status.Errorf(codes.Unavailable, "error, can not find a listener matching %s:%v",
 				port.Protocol, port.Port)
 		}
-
-		// query pool or create pool
-		pool, err := d.getPool(loadbalancer.Id, listener.Id)
-		if err != nil && common.IsNotFound(err) {
-			pool, err = d.createPool(listener, service)
+		if err = d.checkListenerOwner(clusterName, service, listener.Id, port.Port, listener.Description); err != nil {
+			return err
+		}
+		poolProtocol, err := dedicatedPoolProtocol(service, port)
+		if err != nil {
+			return err
 		}
+
+		pool, err := d.ensurePool(clusterName, loadbalancer.Id, listener, service, poolProtocol)
 		if err != nil {
 			return err
 		}
@@ -779,13 +955,17 @@ func (d *DedicatedLoadBalancer) UpdateLoadBalancer(ctx context.Context, clusterN
 			return err
 		}
 	}
-	return nil
+	return d.ensureSecurityGroupRules(service, "dedicated", nodes, d.dedicatedSecurityGroupSources(service))
 }
 
 func (d *DedicatedLoadBalancer) EnsureLoadBalancerDeleted(ctx context.Context, clusterName string, service *v1.Service) error {
 	klog.Infof("EnsureLoadBalancerDeleted: called with service %s/%s", service.Namespace, service.Name)
 	serviceName := fmt.Sprintf("%s/%s", service.Namespace, service.Name)
 	klog.Infof("EnsureLoadBalancerDeleted(%s, %s)", clusterName, serviceName)
+	// the rules are removed first, the service is not reconciled again once the load balancer is deleted.
+	if err := d.deleteSecurityGroupRules(service, "dedicated"); err != nil {
+		return err
+	}
 
 	loadBalancer, err := d.getLoadBalancerInstance(ctx, clusterName, service)
 	if err != nil {
@@ -800,7 +980,7 @@ func (d *DedicatedLoadBalancer) EnsureLoadBalancerDeleted(ctx context.Context, c
 
 	specifiedID := getStringFromSvsAnnotation(service, ElbID, "")
 	if specifiedID != "" {
-		err = d.deleteListener(loadBalancer, service)
+		err = d.deleteListener(clusterName, loadBalancer, service)
 	} else {
 		err = d.deleteELBInstance(loadBalancer, service)
 	}
@@ -811,11 +991,19 @@ func (d *DedicatedLoadBalancer) EnsureLoadBalancerDeleted(ctx context.Context, c
 	return nil
 }
 
-func (d *DedicatedLoadBalancer) deleteListener(loadBalancer *elbmodel.LoadBalancer, service *v1.Service) error {
-	// query ELB listeners list
+func (d *DedicatedLoadBalancer) deleteListener(clusterName string, loadBalancer *elbmodel.LoadBalancer,
+	service *v1.Service) error {
+	// query the ELB listeners of the service ports
 	loadbalancerIDs := []string{loadBalancer.Id}
+	// the TCP and UDP ports of a mixed-protocol service may share the port number.
+	ports := sets.NewString()
+	for _, port := range service.Spec.Ports {
+		ports.Insert(strconv.Itoa(int(port.Port)))
+	}
+	portList := ports.List()
 	listenerArr, err := d.dedicatedELBClient.ListListeners(&elbmodel.ListListenersRequest{
 		LoadbalancerId: &loadbalancerIDs,
+		ProtocolPort:   &portList,
 	})
 	if err != nil {
 		return err
@@ -824,9 +1012,15 @@ func (d *DedicatedLoadBalancer) deleteListener(loadBalancer *elbmodel.LoadBalanc
 	listenersMatched := make([]elbmodel.Listener, 0)
 	for _, port := range service.Spec.Ports {
 		listener := d.filterListenerByPort(listenerArr, service, port)
-		if listener != nil {
-			listenersMatched = append(listenersMatched, *listener)
+		if listener == nil {
+			continue
+		}
+		if d.ownedByOthers(clusterName, service, listener.Description) {
+			klog.Warningf("the listener %s of port %d is not deleted, it is created by another service: %s",
+				listener.Id, port.Port, listener.Description)
+			continue
 		}
+		listenersMatched = append(listenersMatched, *listener)
 	}
 
 	if err = d.deleteListeners(loadBalancer.Id, listenersMatched); err != nil {
@@ -850,7 +1044,7 @@ func (d *DedicatedLoadBalancer) deleteELBInstance(loadBalancer *elbmodel.LoadBal
 	}
 
 	eipID := getStringFromSvsAnnotation(service, ElbEipID, "")
-	keepEip := getBoolFromSvsAnnotation(service, ELBKeepEip, d.loadbalancerOpts.KeepEIP)
+	keepEip := getBoolFromSvsAnnotation(service, ELBKeepEip, d.loadbalancerOpts().KeepEIP)
 	if err = unbindEIP(d.eipClient, loadBalancer.VipPortId, eipID, keepEip); err != nil {
 		return err
 	}
@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	elbmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/elb/v3/model"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDedicatedEnsureLoadBalancerDeleted(t *testing.T) {
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec: v1.ServiceSpec{Ports: []v1.ServicePort{
+			{Protocol: v1.ProtocolTCP, Port: 80},
+			{Protocol: v1.ProtocolTCP, Port: 443},
+		}},
+	}
+	withID := service.DeepCopy()
+	withID.Annotations = map[string]string{ElbID: "elb-1"}
+	owned := "Created by the ELB service(default/web) of the k8s cluster(kubernetes)."
+	others := "Created by the ELB service(default/api) of the k8s cluster(kubernetes)."
+
+	tests := []struct {
+		name      string
+		service   *v1.Service
+		listeners []elbmodel.Listener
+		listErr   error
+
+		expectedErr       bool
+		expectedListeners []string
+		expectedPools     []string
+	}{
+		{
+			name:    "deletes the listeners of the service on the specified load balancer",
+			service: withID,
+			listeners: []elbmodel.Listener{
+				{Id: "listener-80", Protocol: ProtocolTCP, ProtocolPort: 80, Description: owned},
+				{Id: "listener-8080", Protocol: ProtocolTCP, ProtocolPort: 8080, Description: owned},
+			},
+			expectedListeners: []string{"listener-80"},
+			expectedPools:     []string{"pool-listener-80"},
+		},
+		{
+			name:    "keeps the listener created by another service",
+			service: withID,
+			listeners: []elbmodel.Listener{
+				{Id: "listener-80", Protocol: ProtocolTCP, ProtocolPort: 80, Description: owned},
+				{Id: "listener-443", Protocol: ProtocolTCP, ProtocolPort: 443, Description: others},
+			},
+			expectedListeners: []string{"listener-80"},
+			expectedPools:     []string{"pool-listener-80"},
+		},
+		{
+			name:        "returns the error of listing the listeners",
+			service:     withID,
+			listErr:     errors.New("internal error"),
+			expectedErr: true,
+		},
+		{
+			name:    "ignores the load balancer not found",
+			service: service,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lock sync.Mutex
+			var deletedListeners, deletedPools []string
+			dedicatedELB := &FakeDedicatedELBClient{
+				GetInstanceFunc: func(id string) (*elbmodel.LoadBalancer, error) {
+					return &elbmodel.LoadBalancer{Id: id}, nil
+				},
+				ListInstancesFunc: func(_ *elbmodel.ListLoadBalancersRequest) ([]elbmodel.LoadBalancer, error) {
+					return nil, nil
+				},
+				ListListenersFunc: func(_ *elbmodel.ListListenersRequest) ([]elbmodel.Listener, error) {
+					return tt.listeners, tt.listErr
+				},
+				ListPoolsFunc: func(req *elbmodel.ListPoolsRequest) ([]elbmodel.Pool, error) {
+					listenerID := (*req.ListenerId)[0]
+					return []elbmodel.Pool{{
+						Id:        "pool-" + listenerID,
+						Listeners: []elbmodel.ListenerRef{{Id: listenerID}},
+					}}, nil
+				},
+				DeletePoolFunc: func(id string) error {
+					lock.Lock()
+					defer lock.Unlock()
+					deletedPools = append(deletedPools, id)
+					return nil
+				},
+				DeleteListenerFunc: func(_ string, listenerID string) error {
+					lock.Lock()
+					defer lock.Unlock()
+					deletedListeners = append(deletedListeners, listenerID)
+					return nil
+				},
+			}
+			sharedELB := &FakeSharedELBClient{}
+			d := &DedicatedLoadBalancer{Basic: newFakeBasic()}
+			d.sharedELBClient = sharedELB
+			d.dedicatedELBClient = dedicatedELB
+
+			err := d.EnsureLoadBalancerDeleted(context.TODO(), "kubernetes", tt.service)
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("EnsureLoadBalancerDeleted() error = %v, expectedErr %v", err, tt.expectedErr)
+			}
+			if !reflect.DeepEqual(deletedListeners, tt.expectedListeners) {
+				t.Errorf("deleted listeners = %v, expected %v", deletedListeners, tt.expectedListeners)
+			}
+			if !reflect.DeepEqual(deletedPools, tt.expectedPools) {
+				t.Errorf("deleted pools = %v, expected %v", deletedPools, tt.expectedPools)
+			}
+			if count := sharedELB.CallCount("DeleteInstance"); count != 0 {
+				t.Errorf("the load balancer is deleted %d times, expected to be kept", count)
+			}
+		})
+	}
+}
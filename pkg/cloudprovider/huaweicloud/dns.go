@@ -0,0 +1,279 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	dnsmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/dns/v2/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cloud-provider"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/common"
+)
+
+const (
+	// ElbDNSName is the domain name of the DNS record pointing at the address of the load balancer,
+	// such as "www.example.com". The record is created in the zone of the longest name the domain name ends with.
+	ElbDNSName = "kubernetes.io/elb.dns-name"
+
+	// dnsRecordTagKey is the tag of the DNS records of the services, the value is the UID of the service.
+	dnsRecordTagKey = "k8s_service_uid"
+	// dnsRecordTTL is the TTL of the DNS records in seconds.
+	dnsRecordTTL = 300
+)
+
+// dnsZone is a public or private zone of the DNS service.
+type dnsZone struct {
+	id       string
+	name     string
+	zoneType string
+}
+
+// basic returns the Basic of the provider, it is promoted to all the load balancer providers.
+func (b Basic) basic() Basic {
+	return b
+}
+
+// basicOf returns the Basic of the load balancer provider, false if the provider is not built on a Basic.
+func basicOf(provider cloudprovider.LoadBalancer) (Basic, bool) {
+	p, ok := provider.(interface{ basic() Basic })
+	if !ok {
+		return Basic{}, false
+	}
+	return p.basic(), true
+}
+
+// dnsRecordName returns the fully qualified domain name of the record, which ends with a dot.
+func dnsRecordName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	return name
+}
+
+// desiredDNSRecords returns the addresses of the load balancer keyed by the record type, A or AAAA.
+func desiredDNSRecords(lbStatus *v1.LoadBalancerStatus) map[string][]string {
+	records := make(map[string][]string)
+	if lbStatus == nil {
+		return records
+	}
+	for _, ingress := range lbStatus.Ingress {
+		ip := net.ParseIP(ingress.IP)
+		switch {
+		case ip == nil:
+			continue
+		case ip.To4() != nil:
+			records["A"] = append(records["A"], ingress.IP)
+		default:
+			records["AAAA"] = append(records["AAAA"], ingress.IP)
+		}
+	}
+	return records
+}
+
+// findDNSZone returns the zone of the record, it is the zone of the longest name the record name ends with.
+// The public zones take precedence over the private zones of the same name.
+func (b Basic) findDNSZone(name string) (*dnsZone, error) {
+	var zones []dnsZone
+	public, err := b.dnsClient.ListPublicZones()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the public DNS zones: %w", err)
+	}
+	for _, z := range public {
+		zones = append(zones, dnsZone{
+			id:       pointer.StringDeref(z.Id, ""),
+			name:     pointer.StringDeref(z.Name, ""),
+			zoneType: "public",
+		})
+	}
+	private, err := b.dnsClient.ListPrivateZones()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the private DNS zones: %w", err)
+	}
+	for _, z := range private {
+		zones = append(zones, dnsZone{
+			id:       pointer.StringDeref(z.Id, ""),
+			name:     pointer.StringDeref(z.Name, ""),
+			zoneType: "private",
+		})
+	}
+
+	var rst *dnsZone
+	for i, zone := range zones {
+		zoneName := dnsRecordName(zone.name)
+		if name != zoneName && !strings.HasSuffix(name, "."+zoneName) {
+			continue
+		}
+		if rst == nil || len(zoneName) > len(dnsRecordName(rst.name)) {
+			rst = &zones[i]
+		}
+	}
+	if rst == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid argument, no DNS zone of %s is found "+
+			"for annotation %q", name, ElbDNSName)
+	}
+	return rst, nil
+}
+
+// dnsSupported returns whether the DNS service is available in the region, the records are skipped if not.
+func (b Basic) dnsSupported(service *v1.Service) bool {
+	if b.cloudConfig.AuthOpts.ServiceSupported("dns") {
+		return true
+	}
+	klog.Warningf("the DNS records of service %s/%s are skipped, the DNS service is not supported in the region",
+		service.Namespace, service.Name)
+	return false
+}
+
+// listDNSRecords returns the DNS records of the service, which are tagged with its UID.
+func (b Basic) listDNSRecords(service *v1.Service) ([]dnsmodel.ListRecordSetsWithTags, error) {
+	tags := fmt.Sprintf("%s,%s", dnsRecordTagKey, service.UID)
+	records, err := b.dnsClient.ListRecordSets(&dnsmodel.ListRecordSetsRequest{Tags: &tags})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the DNS records of service %s/%s: %w", service.Namespace,
+			service.Name, err)
+	}
+	return records, nil
+}
+
+// ensureDNSRecords points the records of the ElbDNSName annotation at the addresses of the load balancer,
+// an A record for the IPv4 addresses and an AAAA record for the IPv6 ones, and removes the other records
+// of the service, such as the ones of a former name. Nothing is done without the annotation.
+func (b Basic) ensureDNSRecords(service *v1.Service, lbStatus *v1.LoadBalancerStatus) error {
+	annotation := getStringFromSvsAnnotation(service, ElbDNSName, "")
+	if annotation == "" || !b.dnsSupported(service) {
+		return nil
+	}
+	name := dnsRecordName(annotation)
+	desired := desiredDNSRecords(lbStatus)
+
+	existing, err := b.listDNSRecords(service)
+	if err != nil {
+		return err
+	}
+	var zone *dnsZone
+	for recordType, addresses := range desired {
+		sort.Strings(addresses)
+		var record *dnsmodel.ListRecordSetsWithTags
+		for i, r := range existing {
+			if pointer.StringDeref(r.Name, "") == name && pointer.StringDeref(r.Type, "") == recordType {
+				record = &existing[i]
+				existing = append(existing[:i], existing[i+1:]...)
+				break
+			}
+		}
+
+		if record != nil {
+			if err = b.updateDNSRecord(service, record, addresses); err != nil {
+				return err
+			}
+			continue
+		}
+		if zone == nil {
+			if zone, err = b.findDNSZone(name); err != nil {
+				return err
+			}
+		}
+		if err = b.createDNSRecord(service, zone, name, recordType, addresses); err != nil {
+			return err
+		}
+	}
+
+	// the records of the other names or types are obsolete.
+	return b.deleteDNSRecords(service, existing)
+}
+
+func (b Basic) createDNSRecord(service *v1.Service, zone *dnsZone, name, recordType string, addresses []string) error {
+	klog.Infof("Create the DNS %s record %s in the %s zone %s of service %s/%s: %v", recordType, name,
+		zone.zoneType, zone.name, service.Namespace, service.Name, addresses)
+	desc := fmt.Sprintf("Created by the ELB service(%s/%s) of the k8s cluster.", service.Namespace, service.Name)
+	_, err := b.dnsClient.CreateRecordSet(zone.id, &dnsmodel.CreateRecordSetReq{
+		Name:        name,
+		Description: &desc,
+		Type:        recordType,
+		Ttl:         pointer.Int32(dnsRecordTTL),
+		Records:     addresses,
+		Tags:        &[]dnsmodel.Tag{{Key: dnsRecordTagKey, Value: pointer.String(string(service.UID))}},
+	})
+	if common.GetStatusCode(err) == http.StatusConflict {
+		return status.Errorf(codes.AlreadyExists, "the DNS %s record %s already exists and is not created by "+
+			"service %s/%s, delete it or change annotation %q", recordType, name, service.Namespace, service.Name,
+			ElbDNSName)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create the DNS %s record %s: %w", recordType, name, err)
+	}
+	b.sendEvent("CreatedDNSRecord", fmt.Sprintf("Created the DNS %s record %s: %s", recordType, name,
+		strings.Join(addresses, ", ")), service)
+	return nil
+}
+
+// updateDNSRecord updates the addresses of the record if they are changed.
+func (b Basic) updateDNSRecord(service *v1.Service, record *dnsmodel.ListRecordSetsWithTags, addresses []string) error {
+	var current []string
+	if record.Records != nil {
+		current = *record.Records
+	}
+	if sets.NewString(current...).Equal(sets.NewString(addresses...)) {
+		return nil
+	}
+
+	name, recordType := pointer.StringDeref(record.Name, ""), pointer.StringDeref(record.Type, "")
+	klog.Infof("Update the DNS %s record %s of service %s/%s from %v to %v", recordType, name,
+		service.Namespace, service.Name, current, addresses)
+	err := b.dnsClient.UpdateRecordSet(pointer.StringDeref(record.ZoneId, ""), pointer.StringDeref(record.Id, ""),
+		&dnsmodel.UpdateRecordSetReq{Name: name, Type: recordType, Records: &addresses})
+	if err != nil {
+		return fmt.Errorf("failed to update the DNS %s record %s: %w", recordType, name, err)
+	}
+	return nil
+}
+
+func (b Basic) deleteDNSRecords(service *v1.Service, records []dnsmodel.ListRecordSetsWithTags) error {
+	for _, record := range records {
+		name, recordType := pointer.StringDeref(record.Name, ""), pointer.StringDeref(record.Type, "")
+		klog.Infof("Delete the DNS %s record %s of service %s/%s", recordType, name, service.Namespace, service.Name)
+		err := b.dnsClient.DeleteRecordSet(pointer.StringDeref(record.ZoneId, ""), pointer.StringDeref(record.Id, ""))
+		if err != nil && !common.IsNotFound(err) {
+			return fmt.Errorf("failed to delete the DNS %s record %s: %w", recordType, name, err)
+		}
+	}
+	return nil
+}
+
+// ensureDNSRecordsDeleted deletes the DNS records of the service if it has the ElbDNSName annotation.
+func (b Basic) ensureDNSRecordsDeleted(service *v1.Service) error {
+	if getStringFromSvsAnnotation(service, ElbDNSName, "") == "" || !b.dnsSupported(service) {
+		return nil
+	}
+	records, err := b.listDNSRecords(service)
+	if err != nil {
+		return err
+	}
+	return b.deleteDNSRecords(service, records)
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ecs declares the client of the ECSes and caches the ECS details of the cluster.
+package ecs
+
+import (
+	ecsmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ecs/v2/model"
+	v1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/wrapper"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+)
+
+// ECSClient is the client of the ECS APIs used by the instances and the members of the load balancers.
+// It is implemented by the wrapper.EcsClient and by the FakeECSClient of the unit tests.
+type ECSClient interface {
+	Get(id string) (*ecsmodel.ServerDetail, error)
+	GetByName(name string) (*ecsmodel.ServerDetail, error)
+	GetByIP(ip string) (*ecsmodel.ServerDetail, error)
+	List(req *ecsmodel.ListServersDetailsRequest) (*ecsmodel.ListServersDetailsResponse, error)
+	ListAll() ([]ecsmodel.ServerDetail, error)
+	ListInterfaces(req *ecsmodel.ListServerInterfacesRequest) ([]ecsmodel.InterfaceAttachment, error)
+	BuildAddresses(server *ecsmodel.ServerDetail, interfaces []ecsmodel.InterfaceAttachment,
+		networkingOpts *config.NetworkingOptions) ([]v1.NodeAddress, error)
+}
+
+var _ ECSClient = &wrapper.EcsClient{}
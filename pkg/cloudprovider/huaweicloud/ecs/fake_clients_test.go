@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ecs
+
+import (
+	"sync"
+
+	ecsmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ecs/v2/model"
+	v1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/wrapper"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+)
+
+// The fakes of the clients call the functions of the method names with the suffix Func, the methods whose
+// functions are nil return the zero values, except FakeECSClient.BuildAddresses builds the addresses as the
+// wrapper.EcsClient does. The calls are counted by the method names.
+
+// fakeCalls counts the calls of the methods of a fake.
+type fakeCalls struct {
+	lock  sync.Mutex
+	calls map[string]int
+}
+
+func (f *fakeCalls) called(method string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.calls == nil {
+		f.calls = make(map[string]int)
+	}
+	f.calls[method]++
+}
+
+// CallCount returns the number of the calls of the method.
+func (f *fakeCalls) CallCount(method string) int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.calls[method]
+}
+
+// FakeECSClient is the fake of the ECSClient.
+type FakeECSClient struct {
+	fakeCalls
+
+	GetFunc            func(id string) (*ecsmodel.ServerDetail, error)
+	GetByNameFunc      func(name string) (*ecsmodel.ServerDetail, error)
+	GetByIPFunc        func(ip string) (*ecsmodel.ServerDetail, error)
+	ListFunc           func(req *ecsmodel.ListServersDetailsRequest) (*ecsmodel.ListServersDetailsResponse, error)
+	ListAllFunc        func() ([]ecsmodel.ServerDetail, error)
+	ListInterfacesFunc func(req *ecsmodel.ListServerInterfacesRequest) ([]ecsmodel.InterfaceAttachment, error)
+	BuildAddressesFunc func(server *ecsmodel.ServerDetail, interfaces []ecsmodel.InterfaceAttachment, networkingOpts *config.NetworkingOptions) ([]v1.NodeAddress, error)
+}
+
+func (f *FakeECSClient) Get(id string) (*ecsmodel.ServerDetail, error) {
+	f.called("Get")
+	if f.GetFunc == nil {
+		return nil, nil
+	}
+	return f.GetFunc(id)
+}
+
+func (f *FakeECSClient) GetByName(name string) (*ecsmodel.ServerDetail, error) {
+	f.called("GetByName")
+	if f.GetByNameFunc == nil {
+		return nil, nil
+	}
+	return f.GetByNameFunc(name)
+}
+
+func (f *FakeECSClient) GetByIP(ip string) (*ecsmodel.ServerDetail, error) {
+	f.called("GetByIP")
+	if f.GetByIPFunc == nil {
+		return nil, nil
+	}
+	return f.GetByIPFunc(ip)
+}
+
+func (f *FakeECSClient) List(req *ecsmodel.ListServersDetailsRequest) (*ecsmodel.ListServersDetailsResponse, error) {
+	f.called("List")
+	if f.ListFunc == nil {
+		return nil, nil
+	}
+	return f.ListFunc(req)
+}
+
+func (f *FakeECSClient) ListAll() ([]ecsmodel.ServerDetail, error) {
+	f.called("ListAll")
+	if f.ListAllFunc == nil {
+		return nil, nil
+	}
+	return f.ListAllFunc()
+}
+
+func (f *FakeECSClient) ListInterfaces(req *ecsmodel.ListServerInterfacesRequest) ([]ecsmodel.InterfaceAttachment, error) {
+	f.called("ListInterfaces")
+	if f.ListInterfacesFunc == nil {
+		return nil, nil
+	}
+	return f.ListInterfacesFunc(req)
+}
+
+func (f *FakeECSClient) BuildAddresses(server *ecsmodel.ServerDetail, interfaces []ecsmodel.InterfaceAttachment,
+	networkingOpts *config.NetworkingOptions) ([]v1.NodeAddress, error) {
+	f.called("BuildAddresses")
+	if f.BuildAddressesFunc == nil {
+		return (&wrapper.EcsClient{}).BuildAddresses(server, interfaces, networkingOpts)
+	}
+	return f.BuildAddressesFunc(server, interfaces, networkingOpts)
+}
+
+var _ ECSClient = &FakeECSClient{}
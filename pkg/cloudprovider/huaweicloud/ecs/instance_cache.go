@@ -0,0 +1,224 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ecs
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	ecsmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ecs/v2/model"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/metrics"
+)
+
+const (
+	// DefaultInstanceCacheTTL is the time the ECSes are cached before the cache is refreshed by a bulk list call.
+	DefaultInstanceCacheTTL = 60 * time.Second
+	// instanceCacheRetryInterval is the interval to retry a failed refresh, the lookups in the meantime
+	// query the ECSes directly instead of listing the servers again.
+	instanceCacheRetryInterval = 10 * time.Second
+	// instanceCacheName is the name of the ECS cache in the metrics.
+	instanceCacheName = "ecs"
+)
+
+// InstanceCache holds the ECS details of the AZs of the cluster, it is refreshed by a bulk list call,
+// so that the concurrent node lookups during controller start do not query ECSes one by one.
+// The list API of ECS can not filter the servers by AZ, the servers are filtered by the AZs of the ECSes
+// looked up instead, the servers of the other AZs in the project are not kept. Before any ECS is looked up,
+// the servers of all the AZs are kept.
+type InstanceCache struct {
+	ecsClient ECSClient
+	ttl       time.Duration
+	now       func() time.Time
+
+	// refreshLock serializes the refreshes, the lookups of an expired cache wait for the refresh in flight
+	// and share its result, the lookups of the cached ECSes are not blocked by it.
+	refreshLock sync.Mutex
+
+	lock        sync.RWMutex
+	refreshedAt time.Time
+	retryAt     time.Time
+	zones       sets.String
+	byID        map[string]*ecsmodel.ServerDetail
+	byName      map[string]*ecsmodel.ServerDetail
+}
+
+// NewInstanceCache returns an empty cache of the ECSes, it is filled on the first lookup.
+func NewInstanceCache(ecsClient ECSClient, ttl time.Duration) *InstanceCache {
+	return &InstanceCache{
+		ecsClient: ecsClient,
+		ttl:       ttl,
+		now:       time.Now,
+		zones:     sets.NewString(),
+		byID:      make(map[string]*ecsmodel.ServerDetail),
+		byName:    make(map[string]*ecsmodel.ServerDetail),
+	}
+}
+
+// Get returns the ECS details by ID, it falls back to query the ECS if it is not cached.
+func (c *InstanceCache) Get(id string) (*ecsmodel.ServerDetail, error) {
+	if instance := c.lookup(func() *ecsmodel.ServerDetail { return c.byID[id] }); instance != nil {
+		return instance, nil
+	}
+
+	instance, err := c.ecsClient.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	c.add(instance)
+	return instance, nil
+}
+
+// GetByName returns the ECS details by name, it falls back to query the ECS if it is not cached.
+func (c *InstanceCache) GetByName(name string) (*ecsmodel.ServerDetail, error) {
+	if instance := c.lookup(func() *ecsmodel.ServerDetail { return c.byName[name] }); instance != nil {
+		return instance, nil
+	}
+
+	instance, err := c.ecsClient.GetByName(name)
+	if err != nil {
+		return nil, err
+	}
+	c.add(instance)
+	return instance, nil
+}
+
+func (c *InstanceCache) lookup(find func() *ecsmodel.ServerDetail) *ecsmodel.ServerDetail {
+	if c.refreshDue() {
+		c.refreshLock.Lock()
+		// The callers waiting for the lock share the result of the refresh, so only one bulk list call is sent.
+		if c.refreshDue() {
+			if err := c.refresh(); err != nil {
+				klog.Warningf("failed to refresh ECS cache, query ECS directly in %s: %s",
+					instanceCacheRetryInterval, err)
+			}
+		}
+		c.refreshLock.Unlock()
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var instance *ecsmodel.ServerDetail
+	if !c.expired() {
+		instance = find()
+	}
+	if instance == nil {
+		metrics.ObserveCacheLookup(instanceCacheName, metrics.CacheMiss)
+		return nil
+	}
+	metrics.ObserveCacheLookup(instanceCacheName, metrics.CacheHit)
+	c.zones.Insert(instance.OSEXTAZavailabilityZone)
+	return instance
+}
+
+// expired returns true if the cache is not refreshed within the TTL, the caller must hold the lock.
+func (c *InstanceCache) expired() bool {
+	return c.now().Sub(c.refreshedAt) > c.ttl
+}
+
+// refreshDue returns true if the cache is expired and no failed refresh is waiting for the retry.
+func (c *InstanceCache) refreshDue() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.expired() && !c.now().Before(c.retryAt)
+}
+
+func (c *InstanceCache) refresh() error {
+	servers, err := c.ecsClient.ListAll()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err != nil {
+		c.retryAt = c.now().Add(instanceCacheRetryInterval)
+		return err
+	}
+
+	// The names are checked against all the servers of the project, as the direct query by name does,
+	// a name shared with a server of the AZs not kept is still ambiguous.
+	names := make(map[string]int, len(servers))
+	for i := range servers {
+		names[servers[i].Name]++
+	}
+	byID := make(map[string]*ecsmodel.ServerDetail, len(servers))
+	byName := make(map[string]*ecsmodel.ServerDetail, len(servers))
+	for i := range servers {
+		s := &servers[i]
+		if c.zones.Len() > 0 && !c.zones.Has(s.OSEXTAZavailabilityZone) {
+			continue
+		}
+		byID[s.Id] = s
+		if names[s.Name] > 1 {
+			// the ECS name is not unique, query it by name directly
+			byName[s.Name] = nil
+			continue
+		}
+		byName[s.Name] = s
+	}
+	evicted := 0
+	for id := range c.byID {
+		if _, ok := byID[id]; !ok {
+			evicted++
+		}
+	}
+	c.byID = byID
+	c.byName = byName
+	c.refreshedAt = c.now()
+	metrics.ObserveCacheRefresh(instanceCacheName, len(byID), evicted)
+
+	klog.V(4).Infof("ECS cache refreshed, %d of %d servers kept in the AZs %v", len(byID), len(servers), c.zones.List())
+	return nil
+}
+
+func (c *InstanceCache) add(instance *ecsmodel.ServerDetail) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.byID[instance.Id] = instance
+	if cached, ok := c.byName[instance.Name]; ok && (cached == nil || cached.Id != instance.Id) {
+		// the ECS name is not unique, query it by name directly
+		c.byName[instance.Name] = nil
+	} else {
+		c.byName[instance.Name] = instance
+	}
+	c.zones.Insert(instance.OSEXTAZavailabilityZone)
+}
+
+// InstanceCacheState is the content of the ECS cache.
+type InstanceCacheState struct {
+	RefreshedAt time.Time `json:"refreshedAt"`
+	// Zones are the AZs of the ECSes kept in the cache.
+	Zones []string `json:"zones"`
+	// Instances are the cached ECSes in the form of "<ID> <name> <status>".
+	Instances []string `json:"instances"`
+}
+
+// Snapshot returns the content of the cache served on /debug/state, it does not refresh the cache.
+func (c *InstanceCache) Snapshot() InstanceCacheState {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	state := InstanceCacheState{RefreshedAt: c.refreshedAt, Zones: c.zones.List()}
+	for id, instance := range c.byID {
+		state.Instances = append(state.Instances, strings.Join([]string{id, instance.Name, instance.Status}, " "))
+	}
+	sort.Strings(state.Instances)
+	return state
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ecs
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	ecsmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ecs/v2/model"
+)
+
+func TestInstanceCacheLookup(t *testing.T) {
+	servers := []ecsmodel.ServerDetail{
+		{Id: "ecs-1", Name: "node-1", OSEXTAZavailabilityZone: "az1"},
+		{Id: "ecs-2", Name: "node-2", OSEXTAZavailabilityZone: "az2"},
+		{Id: "ecs-3", Name: "node-3", OSEXTAZavailabilityZone: "az1"},
+		{Id: "ecs-4", Name: "node-3", OSEXTAZavailabilityZone: "az1"},
+	}
+
+	tests := []struct {
+		name     string
+		lookup   func(c *InstanceCache) (*ecsmodel.ServerDetail, error)
+		expected string
+		gets     int
+	}{
+		{
+			name:     "gets the cached ECS by ID",
+			lookup:   func(c *InstanceCache) (*ecsmodel.ServerDetail, error) { return c.Get("ecs-2") },
+			expected: "ecs-2",
+		},
+		{
+			name:     "gets the cached ECS by name",
+			lookup:   func(c *InstanceCache) (*ecsmodel.ServerDetail, error) { return c.GetByName("node-1") },
+			expected: "ecs-1",
+		},
+		{
+			name:     "queries the ECS of a duplicate name directly",
+			lookup:   func(c *InstanceCache) (*ecsmodel.ServerDetail, error) { return c.GetByName("node-3") },
+			expected: "ecs-direct",
+			gets:     1,
+		},
+		{
+			name:     "queries the ECS not cached directly",
+			lookup:   func(c *InstanceCache) (*ecsmodel.ServerDetail, error) { return c.Get("ecs-5") },
+			expected: "ecs-direct",
+			gets:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &FakeECSClient{
+				ListAllFunc: func() ([]ecsmodel.ServerDetail, error) { return servers, nil },
+				GetFunc: func(id string) (*ecsmodel.ServerDetail, error) {
+					return &ecsmodel.ServerDetail{Id: "ecs-direct"}, nil
+				},
+				GetByNameFunc: func(name string) (*ecsmodel.ServerDetail, error) {
+					return &ecsmodel.ServerDetail{Id: "ecs-direct", Name: name}, nil
+				},
+			}
+			c := NewInstanceCache(client, time.Minute)
+
+			instance, err := tt.lookup(c)
+			if err != nil {
+				t.Fatalf("lookup error = %v", err)
+			}
+			if instance.Id != tt.expected {
+				t.Errorf("lookup got %s, expected %s", instance.Id, tt.expected)
+			}
+			if count := client.CallCount("Get") + client.CallCount("GetByName"); count != tt.gets {
+				t.Errorf("the ECS is queried directly %d times, expected %d", count, tt.gets)
+			}
+			if count := client.CallCount("ListAll"); count != 1 {
+				t.Errorf("the servers are listed %d times, expected 1", count)
+			}
+		})
+	}
+}
+
+func TestInstanceCacheAdd(t *testing.T) {
+	client := &FakeECSClient{
+		GetFunc: func(id string) (*ecsmodel.ServerDetail, error) {
+			return &ecsmodel.ServerDetail{Id: id, Name: "node-" + id, OSEXTAZavailabilityZone: "az1"}, nil
+		},
+	}
+	c := NewInstanceCache(client, time.Minute)
+
+	if _, err := c.Get("1"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	instance, err := c.GetByName("node-1")
+	if err != nil {
+		t.Fatalf("GetByName() error = %v", err)
+	}
+	if instance.Id != "1" {
+		t.Errorf("GetByName() got %s, expected the ECS added by Get()", instance.Id)
+	}
+	if count := client.CallCount("GetByName"); count != 0 {
+		t.Errorf("the ECS is queried by name %d times, expected to be cached", count)
+	}
+
+	c.add(&ecsmodel.ServerDetail{Id: "2", Name: "node-1"})
+	if cached := c.byName["node-1"]; cached != nil {
+		t.Errorf("the name shared by the ECSes %s and 2 is cached, expected to be queried directly", cached.Id)
+	}
+}
+
+func TestInstanceCacheRefresh(t *testing.T) {
+	servers := []ecsmodel.ServerDetail{
+		{Id: "ecs-1", Name: "node-1", OSEXTAZavailabilityZone: "az1"},
+		{Id: "ecs-2", Name: "node-2", OSEXTAZavailabilityZone: "az2"},
+	}
+	var listErr error
+	client := &FakeECSClient{
+		ListAllFunc: func() ([]ecsmodel.ServerDetail, error) {
+			if listErr != nil {
+				return nil, listErr
+			}
+			return servers, nil
+		},
+		GetFunc: func(id string) (*ecsmodel.ServerDetail, error) {
+			return &ecsmodel.ServerDetail{Id: id}, nil
+		},
+	}
+	now := time.Now()
+	c := NewInstanceCache(client, time.Minute)
+	c.now = func() time.Time { return now }
+
+	if _, err := c.Get("ecs-1"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(c.byID) != 2 {
+		t.Errorf("%d servers are cached before any lookup, expected the servers of all the AZs", len(c.byID))
+	}
+
+	// the servers of the AZs not looked up are not kept from the next refresh.
+	now = now.Add(time.Minute + time.Second)
+	if _, err := c.Get("ecs-1"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if count := client.CallCount("ListAll"); count != 2 {
+		t.Fatalf("the servers are listed %d times after the TTL, expected 2", count)
+	}
+	if _, ok := c.byID["ecs-2"]; ok || len(c.byID) != 1 {
+		t.Errorf("the cached servers are %v, expected only the servers of az1", c.byID)
+	}
+
+	// the name shared with a server of the AZs not kept is queried directly.
+	servers = append(servers, ecsmodel.ServerDetail{Id: "ecs-3", Name: "node-1", OSEXTAZavailabilityZone: "az2"})
+	now = now.Add(time.Minute + time.Second)
+	if _, err := c.Get("ecs-1"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if cached, ok := c.byName["node-1"]; !ok || cached != nil {
+		t.Errorf("the name node-1 shared by the ECSes of az1 and az2 is cached as %v, expected to be queried directly",
+			cached)
+	}
+
+	// a failed refresh is retried after the interval, the lookups in the meantime query the ECSes directly.
+	listErr = errors.New("internal error")
+	now = now.Add(time.Minute + time.Second)
+	for i := 0; i < 3; i++ {
+		if _, err := c.Get("ecs-1"); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+	}
+	if count := client.CallCount("ListAll"); count != 4 {
+		t.Errorf("the servers are listed %d times after a failed refresh, expected 4", count)
+	}
+	if count := client.CallCount("Get"); count != 3 {
+		t.Errorf("the ECS is queried directly %d times after a failed refresh, expected 3", count)
+	}
+
+	listErr = nil
+	now = now.Add(instanceCacheRetryInterval)
+	if _, err := c.Get("ecs-1"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if count := client.CallCount("ListAll"); count != 5 {
+		t.Errorf("the servers are listed %d times after the retry interval, expected 5", count)
+	}
+	if count := client.CallCount("Get"); count != 3 {
+		t.Errorf("the ECS is queried directly %d times after the retry, expected 3", count)
+	}
+}
+
+func TestInstanceCacheConcurrentLookups(t *testing.T) {
+	listing := make(chan struct{})
+	release := make(chan struct{})
+	client := &FakeECSClient{
+		ListAllFunc: func() ([]ecsmodel.ServerDetail, error) {
+			close(listing)
+			<-release
+			return []ecsmodel.ServerDetail{{Id: "ecs-1", Name: "node-1"}}, nil
+		},
+	}
+	c := NewInstanceCache(client, time.Minute)
+	c.add(&ecsmodel.ServerDetail{Id: "ecs-2", Name: "node-2"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if instance, err := c.Get("ecs-1"); err != nil || instance == nil {
+				t.Errorf("Get() = %v, %v, expected the cached ECS", instance, err)
+			}
+		}()
+	}
+
+	<-listing
+	// the cache is not locked by the refresh in flight.
+	c.add(&ecsmodel.ServerDetail{Id: "ecs-3", Name: "node-3"})
+	c.Snapshot()
+	close(release)
+	wg.Wait()
+
+	if count := client.CallCount("ListAll"); count != 1 {
+		t.Errorf("the servers are listed %d times by the concurrent lookups, expected 1", count)
+	}
+	if count := client.CallCount("Get"); count != 0 {
+		t.Errorf("the ECS is queried directly %d times, expected 0", count)
+	}
+}
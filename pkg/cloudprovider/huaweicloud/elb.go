@@ -31,10 +31,15 @@ import (
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/klog"
+
+	elbapi "sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/elb"
 )
 
 type ELBCloud struct {
 	Basic
+
+	// client is the client of the classic load balancers, the client of the cloud config is used if it is nil.
+	client elbapi.ClassicELBClient
 }
 
 // temp async job info
@@ -46,13 +51,15 @@ type tempJobInfo struct {
 
 type tempServicePort struct {
 	servicePort *v1.ServicePort
-	listener    *ListenerDetail
+	listener    *elbapi.ListenerDetail
 }
 
-// getELBClient
-func (elb *ELBCloud) ELBClient() (*ELBClient, error) {
-	authOpts := elb.cloudConfig.AuthOpts
-	return NewELBClient(authOpts.Cloud, authOpts.Region, authOpts.ProjectID, authOpts.AccessKey, authOpts.SecretKey), nil
+// ClassicELBClient returns the client of the classic load balancers.
+func (elb *ELBCloud) ClassicELBClient() (elbapi.ClassicELBClient, error) {
+	if elb.client != nil {
+		return elb.client, nil
+	}
+	return elbapi.NewELBServiceClient(&elb.cloudConfig.AuthOpts), nil
 }
 
 // GetLoadBalancer gets loadbalancer for service.
@@ -77,11 +84,11 @@ func (elb *ELBCloud) GetLoadBalancer(ctx context.Context, clusterName string, se
 // the process of upgrading, the delete member may be trigger this
 // way
 func (elb *ELBCloud) asyncWaitJobs(
-	elbProvider *ELBClient,
+	elbProvider elbapi.ClassicELBClient,
 	service *v1.Service,
 	jobs []tempJobInfo,
 	listenerID string,
-	newMembers []*Member,
+	newMembers []*elbapi.Member,
 	tryAgain bool) {
 	if len(jobs) == 0 && !tryAgain {
 		return
@@ -126,18 +133,18 @@ func (elb *ELBCloud) asyncWaitJobs(
 }
 
 func (elb *ELBCloud) ensureCreateListener(
-	elbProvider *ELBClient,
+	elbProvider elbapi.ClassicELBClient,
 	name string,
-	elbAlgorithm ELBAlgorithm,
+	elbAlgorithm elbapi.ELBAlgorithm,
 	port v1.ServicePort,
 	loadBalancerID string,
 	sessionAffinity string,
 	sessionAffinityOpts map[string]string) (listenerID string, err error) {
-	listenerConf := &Listener{
+	listenerConf := &elbapi.Listener{
 		LoadbalancerID:  "",
-		Protocol:        ELBProtocol(port.Protocol),
+		Protocol:        elbapi.ELBProtocol(port.Protocol),
 		Port:            int(port.Port),
-		BackendProtocol: ELBProtocol(port.Protocol),
+		BackendProtocol: elbapi.ELBProtocol(port.Protocol),
 		BackendPort:     int(port.NodePort),
 		LBAlgorithm:     elbAlgorithm,
 	}
@@ -159,7 +166,7 @@ func (elb *ELBCloud) ensureCreateListener(
 	listener, errRsp, err := elbProvider.CreateListener(listenerConf)
 	if err != nil {
 		// if the listener already exit
-		if errRsp != nil && errRsp.Error.Code == ElbError6101 {
+		if errRsp != nil && errRsp.Error.Code == elbapi.ElbError6101 {
 			return "", nil
 		}
 		return "", err
@@ -184,9 +191,10 @@ func (elb *ELBCloud) getPods(name, namespace string) (*v1.PodList, error) {
 	return elb.kubeClient.Pods(namespace).List(context.TODO(), opts)
 }
 
-// Not implemented
+// GetLoadBalancerName returns the name of the listeners of the service, the classic load balancer is shared
+// by the services and not named after them.
 func (elb *ELBCloud) GetLoadBalancerName(ctx context.Context, clusterName string, service *v1.Service) string {
-	return ""
+	return GetListenerName(service)
 }
 
 // EnsureTCPLoadBalancer is an implementation of TCPLoadBalancer.EnsureTCPLoadBalancer.
@@ -196,7 +204,7 @@ func (elb *ELBCloud) GetLoadBalancerName(ctx context.Context, clusterName string
 func (elb *ELBCloud) EnsureLoadBalancer(ctx context.Context, clusterName string, service *v1.Service, hosts []*v1.Node) (*v1.LoadBalancerStatus, error) {
 	// func (elb *ELBCloud) EnsureLoadBalancer(name, region string, loadBalancerIP net.IP, ports []*v1.ServicePort, hosts []string, servicename types.NamespacedName, affinityType v1.ServiceAffinity, annotations map[string]string) (*v1.LoadBalancerStatus, error) {
 	klog.Infof("Begin to ensure loadbalancer configuration of service(%s/%s)", service.Namespace, service.Name)
-	elbProvider, err := elb.ELBClient()
+	elbProvider, err := elb.ClassicELBClient()
 	if err != nil {
 		return nil, err
 	}
@@ -268,7 +276,7 @@ func (elb *ELBCloud) EnsureLoadBalancer(ctx context.Context, clusterName string,
 func (elb *ELBCloud) UpdateLoadBalancer(ctx context.Context, clusterName string, service *v1.Service, hosts []*v1.Node) error {
 	// if the node changed ,the server_id mark the VM will change, need to update the global
 	klog.Infof("Begin to update loadbalancer configuration of service(%s/%s)", service.Namespace, service.Name)
-	elbProvider, err := elb.ELBClient()
+	elbProvider, err := elb.ClassicELBClient()
 	if err != nil {
 		return err
 	}
@@ -303,14 +311,14 @@ func (elb *ELBCloud) UpdateLoadBalancer(ctx context.Context, clusterName string,
 
 // updateELbMembers delete the old node that pod has been evicted, add the new node the pod run in
 func (elb *ELBCloud) updateListenerMembers(
-	elbProvider *ELBClient,
+	elbProvider elbapi.ClassicELBClient,
 	service *v1.Service,
 	listenerID string,
-	newMembers []*Member,
-	preMembers []*MemDetail) error {
-	addMembers := []*Member{}
-	matchedMembers := map[string]*MemDetail{}
-	membersDel := &MembersDel{}
+	newMembers []*elbapi.Member,
+	preMembers []*elbapi.MemDetail) error {
+	addMembers := []*elbapi.Member{}
+	matchedMembers := map[string]*elbapi.MemDetail{}
+	membersDel := &elbapi.MembersDel{}
 	jobs := []tempJobInfo{}
 
 	for _, member := range newMembers {
@@ -330,7 +338,7 @@ func (elb *ELBCloud) updateListenerMembers(
 
 	for _, preMember := range preMembers {
 		if _, ok := matchedMembers[preMember.ServerID]; !ok {
-			memberRm := MemberRm{ID: preMember.ID, Address: preMember.Address}
+			memberRm := elbapi.MemberRm{ID: preMember.ID, Address: preMember.Address}
 			membersDel.RemoveMember = append(membersDel.RemoveMember, memberRm)
 		}
 	}
@@ -381,10 +389,10 @@ func (elb *ELBCloud) updateListenerMembers(
 // the member will wait the health check is ok, then
 // transfer the packages, so before delete the old member,
 // we need to check if there have available members
-func (elb *ELBCloud) gracefulRemoveElbMembers(existMembers map[string]*MemDetail) bool {
+func (elb *ELBCloud) gracefulRemoveElbMembers(existMembers map[string]*elbapi.MemDetail) bool {
 	hasAvailableMember := false
 	for _, member := range existMembers {
-		if member.HealthStatus == MemberNormal {
+		if member.HealthStatus == elbapi.MemberNormal {
 			hasAvailableMember = true
 			break
 		}
@@ -396,7 +404,7 @@ func (elb *ELBCloud) gracefulRemoveElbMembers(existMembers map[string]*MemDetail
 // EnsureTCPLoadBalancerDeleted is an implementation of TCPLoadBalancer.EnsureTCPLoadBalancerDeleted.
 func (elb *ELBCloud) EnsureLoadBalancerDeleted(ctx context.Context, clusterName string, service *v1.Service) error {
 	klog.Infof("Begin to delete loadbalancer configuration of service(%s/%s)", service.Namespace, service.Name)
-	elbProvider, err := elb.ELBClient()
+	elbProvider, err := elb.ClassicELBClient()
 	if err != nil {
 		return err
 	}
@@ -423,27 +431,28 @@ func (elb *ELBCloud) EnsureLoadBalancerDeleted(ctx context.Context, clusterName
 	return utilerrors.NewAggregate(errs)
 }
 
-func (elb *ELBCloud) getListenersByService(service *v1.Service) ([]*ListenerDetail, error) {
-	elbProvider, err := elb.ELBClient()
+func (elb *ELBCloud) getListenersByService(service *v1.Service) ([]*elbapi.ListenerDetail, error) {
+	elbProvider, err := elb.ClassicELBClient()
 	if err != nil {
 		return nil, err
 	}
 
-	// we should get all listeners, for three reasons:
+	// we should get the listeners of all load balancers, for three reasons:
 	// 1. service can without elb.id
 	// 2. service can without loadbalancerIP
 	// 3. service maybe update the loadbalancerIP
-	// and check the listener name(TODO: this is not a safe way)
-	// return the matched items.
-	listenerList, err := elbProvider.ListListeners("")
-	if err != nil {
-		return nil, err
-	}
-
-	var listeners []*ListenerDetail
-	for _, listener := range listenerList {
-		if listener.Name == GetListenerName(service) || listener.Name == GetOldListenerName(service) {
-			listeners = append(listeners, listener)
+	// so the listeners are filtered by the listener names of the service (TODO: this is not a safe way).
+	var listeners []*elbapi.ListenerDetail
+	for _, name := range []string{GetListenerName(service), GetOldListenerName(service)} {
+		listenerList, err := elbProvider.ListListeners(map[string]string{"name": name})
+		if err != nil {
+			return nil, err
+		}
+		for _, listener := range listenerList {
+			// the name filter of the API may match the names fuzzily
+			if listener.Name == name {
+				listeners = append(listeners, listener)
+			}
 		}
 	}
 	return listeners, nil
@@ -452,10 +461,10 @@ func (elb *ELBCloud) getListenersByService(service *v1.Service) ([]*ListenerDeta
 func (elb *ELBCloud) compare(
 	loadBalancerID string,
 	service *v1.Service,
-	listeners []*ListenerDetail) ([]v1.ServicePort, map[string]tempServicePort, []*ListenerDetail) {
+	listeners []*elbapi.ListenerDetail) ([]v1.ServicePort, map[string]tempServicePort, []*elbapi.ListenerDetail) {
 	needsCreate := []v1.ServicePort{}
 	needsUpdate := make(map[string]tempServicePort)
-	needsDelete := []*ListenerDetail{}
+	needsDelete := []*elbapi.ListenerDetail{}
 	for i := range service.Spec.Ports {
 		port := service.Spec.Ports[i]
 		if port.Name == HealthzCCE {
@@ -464,7 +473,7 @@ func (elb *ELBCloud) compare(
 		create := true
 		for j := range listeners {
 			listener := listeners[j]
-			if int(port.Port) == listener.Port &&
+			if int(port.Port) == listener.Port && strings.EqualFold(string(listener.Protocol), string(port.Protocol)) &&
 				listener.LoadbalancerID == loadBalancerID {
 				create = false
 				needsUpdate[listener.ID] = tempServicePort{
@@ -489,13 +498,13 @@ func (elb *ELBCloud) compare(
 	return needsCreate, needsUpdate, needsDelete
 }
 
-func (elb *ELBCloud) generateMembers(service *v1.Service) ([]*Member, error) {
+func (elb *ELBCloud) generateMembers(service *v1.Service) ([]*elbapi.Member, error) {
 	podList, err := elb.getPods(service.Name, service.Namespace)
 	if err != nil {
 		return nil, err
 	}
 
-	members := []*Member{}
+	members := []*elbapi.Member{}
 	hasNodeExist := map[string]bool{}
 	for _, item := range podList.Items {
 		if item.Status.HostIP == "" {
@@ -520,7 +529,7 @@ func (elb *ELBCloud) generateMembers(service *v1.Service) ([]*Member, error) {
 
 		hasNodeExist[item.Status.HostIP] = true
 		// Get the sever by private IP, it must only have one, if it exist.
-		member := Member{ServerID: node.Status.NodeInfo.MachineID, Address: item.Status.HostIP}
+		member := elbapi.Member{ServerID: node.Status.NodeInfo.MachineID, Address: item.Status.HostIP}
 		members = append(members, &member)
 	}
 
@@ -533,12 +542,12 @@ func (elb *ELBCloud) generateMembers(service *v1.Service) ([]*Member, error) {
 }
 
 func (elb *ELBCloud) createLoadBalancer(
-	elbProvider *ELBClient,
+	elbProvider elbapi.ClassicELBClient,
 	loadBalancerID string,
 	service *v1.Service,
 	needsCreate []v1.ServicePort,
 	healthCheckPort *v1.ServicePort,
-	members []*Member) error {
+	members []*elbapi.Member) error {
 	var (
 		errs []error
 		jobs []tempJobInfo
@@ -584,10 +593,10 @@ func (elb *ELBCloud) createLoadBalancer(
 		klog.Infof("Create listener(%s/%d) of loadbalancer(%s) success.", lsName, port.Port, service.Spec.LoadBalancerIP)
 
 		// Step 2. Create health check
-		healthCheck := HealthCheck{
+		healthCheck := elbapi.HealthCheck{
 			HealthcheckConnectPort: int(port.NodePort),
 			HealthcheckInterval:    5,
-			HealthcheckProtocol:    ELBProtocol(port.Protocol),
+			HealthcheckProtocol:    elbapi.ELBProtocol(port.Protocol),
 			HealthcheckTimeout:     10,
 			HealthyThreshold:       3,
 			ListenerID:             listenerID,
@@ -595,7 +604,7 @@ func (elb *ELBCloud) createLoadBalancer(
 		}
 		if healthCheckPort != nil {
 			healthCheck.HealthcheckConnectPort = int(healthCheckPort.NodePort)
-			healthCheck.HealthcheckProtocol = ELBProtocol(healthCheckPort.Protocol)
+			healthCheck.HealthcheckProtocol = elbapi.ELBProtocol(healthCheckPort.Protocol)
 		}
 
 		_, err = elbProvider.CreateHealthCheck(&healthCheck)
@@ -633,11 +642,11 @@ func (elb *ELBCloud) createLoadBalancer(
 }
 
 func (elb *ELBCloud) updateLoadBalancer(
-	elbProvider *ELBClient,
+	elbProvider elbapi.ClassicELBClient,
 	service *v1.Service,
 	needsUpdate map[string]tempServicePort,
 	healthCheckPort *v1.ServicePort,
-	members []*Member) error {
+	members []*elbapi.Member) error {
 	var errs []error
 
 	sessionAffinity, err := elb.getSessionAffinityType(service)
@@ -654,7 +663,7 @@ func (elb *ELBCloud) updateLoadBalancer(
 	}
 
 	for _, tempPort := range needsUpdate {
-		if ELBProtocol(tempPort.servicePort.Protocol) != tempPort.listener.Protocol {
+		if elbapi.ELBProtocol(tempPort.servicePort.Protocol) != tempPort.listener.Protocol {
 			msg := fmt.Sprintf("The protocol of listener(%s) can not be modified", tempPort.listener.ID)
 			elb.sendEvent("UpdateLoadBalancerFailed", msg, service)
 			continue
@@ -674,7 +683,7 @@ func (elb *ELBCloud) updateLoadBalancer(
 		if int(tempPort.servicePort.NodePort) != tempPort.listener.BackendPort || tempPort.listener.SessionSticky != sessionSticky || tempPort.listener.TCPTimeout != timeout {
 			klog.Infof("Needs to update listener(%s)'s backend port(%d->%d), session_sticky(%v->%v) ,session_timeout(%d->%d)of service(%s/%s)",
 				tempPort.listener.ID, tempPort.listener.BackendPort, tempPort.servicePort.NodePort, tempPort.listener.SessionSticky, sessionSticky, tempPort.listener.TCPTimeout, timeout, service.Namespace, service.Name)
-			ll := &Listener{}
+			ll := &elbapi.Listener{}
 			ll.BackendPort = int(tempPort.servicePort.NodePort)
 			ll.SessionSticky = sessionSticky
 			if sessionSticky {
@@ -716,9 +725,9 @@ func (elb *ELBCloud) updateLoadBalancer(
 }
 
 func (elb *ELBCloud) deleteLoadBalancer(
-	elbProvider *ELBClient,
+	elbProvider elbapi.ClassicELBClient,
 	service *v1.Service,
-	needsDelete []*ListenerDetail) error {
+	needsDelete []*elbapi.ListenerDetail) error {
 	if len(needsDelete) == 0 {
 		return nil
 	}
@@ -739,12 +748,12 @@ func (elb *ELBCloud) deleteLoadBalancer(
 }
 
 func deleteListener(
-	elbProvider *ELBClient,
+	elbProvider elbapi.ClassicELBClient,
 	listenerID, healthcheckID string) error {
 	if listenerID != "" {
 		err := elbProvider.DeleteMembers(listenerID)
 		if err != nil {
-			return fmt.Errorf("Delete members of listener(%s) error: %v", listenerID, err)
+			return fmt.Errorf("Delete members of listener(%s) error: %w", listenerID, err)
 		}
 	}
 
@@ -753,14 +762,14 @@ func deleteListener(
 	if healthcheckID != "" && healthcheckID != "null" {
 		err := elbProvider.DeleteHealthCheck(healthcheckID)
 		if err != nil {
-			return fmt.Errorf("Delete healthcheck of listener(%s) error: %v", listenerID, err)
+			return fmt.Errorf("Delete healthcheck of listener(%s) error: %w", listenerID, err)
 		}
 	}
 
 	if listenerID != "" {
 		err := elbProvider.DeleteListener(listenerID)
 		if err != nil {
-			return fmt.Errorf("Delete listener(%s) error: %v", listenerID, err)
+			return fmt.Errorf("Delete listener(%s) error: %w", listenerID, err)
 		}
 	}
 
@@ -768,7 +777,7 @@ func deleteListener(
 }
 
 func (elb *ELBCloud) updateHealthcheckIfNeeded(
-	elbProvider *ELBClient,
+	elbProvider elbapi.ClassicELBClient,
 	service *v1.Service,
 	tempPort tempServicePort,
 	healthCheckPort *v1.ServicePort) error {
@@ -781,8 +790,8 @@ func (elb *ELBCloud) updateHealthcheckIfNeeded(
 
 	var (
 		notexist bool
-		healthz  *HealthCheckDetail
-		errResp  *ErrorRsp
+		healthz  *elbapi.HealthCheckDetail
+		errResp  *elbapi.ErrorRsp
 		err      error
 	)
 	if tempPort.listener.HealthcheckID == "" ||
@@ -793,7 +802,7 @@ func (elb *ELBCloud) updateHealthcheckIfNeeded(
 		if err != nil {
 			// if healthcheck is not exist, this maybe happen when rollback is not finished,
 			// then we should create the healthcheck again.
-			if errResp != nil && errResp.Error.Code == ElbError7020 {
+			if errResp != nil && errResp.Error.Code == elbapi.ElbError7020 {
 				notexist = true
 			} else {
 				klog.Errorf("Get healthcheck of listener(%s) in service(%s/%s) error: %v",
@@ -807,10 +816,10 @@ func (elb *ELBCloud) updateHealthcheckIfNeeded(
 	if notexist {
 		klog.Infof("Needs to create healthcheck(%d/%s) of listener(%s) in service(%s/%s)",
 			healthcheckPort, healthcheckProtocol, tempPort.listener.ID, service.Namespace, service.Name)
-		h := &HealthCheck{
+		h := &elbapi.HealthCheck{
 			HealthcheckConnectPort: int(healthcheckPort),
 			HealthcheckInterval:    5,
-			HealthcheckProtocol:    ELBProtocol(healthcheckProtocol),
+			HealthcheckProtocol:    elbapi.ELBProtocol(healthcheckProtocol),
 			HealthcheckTimeout:     10,
 			HealthyThreshold:       3,
 			ListenerID:             tempPort.listener.ID,
@@ -827,14 +836,14 @@ func (elb *ELBCloud) updateHealthcheckIfNeeded(
 
 	// needs to update healthcheck
 	if int(healthcheckPort) != healthz.HealthcheckConnectPort ||
-		ELBProtocol(healthcheckProtocol) != healthz.HealthcheckProtocol {
+		elbapi.ELBProtocol(healthcheckProtocol) != healthz.HealthcheckProtocol {
 		klog.Infof("Needs to update healthcheck(%d/%s->%d/%s) of listener(%s) in service(%s/%s)",
 			healthz.HealthcheckConnectPort, healthz.HealthcheckProtocol, healthcheckPort, healthcheckProtocol,
 			tempPort.listener.ID, service.Namespace, service.Name)
-		h := &HealthCheck{
+		h := &elbapi.HealthCheck{
 			HealthcheckConnectPort: int(healthcheckPort),
 			HealthcheckInterval:    5,
-			HealthcheckProtocol:    ELBProtocol(healthcheckProtocol),
+			HealthcheckProtocol:    elbapi.ELBProtocol(healthcheckProtocol),
 			HealthcheckTimeout:     10,
 			HealthyThreshold:       3,
 			UnhealthyThreshold:     3,
@@ -897,6 +906,10 @@ func GetOldListenerName(service *v1.Service) string {
 }
 
 func (elb *ELBCloud) updateServiceStatus(kubeClient corev1.CoreV1Interface, service *v1.Service) {
+	service, err := latestService(kubeClient, service)
+	if err != nil {
+		return
+	}
 	for i := 0; i < MaxRetry; i++ {
 		toUpdate := service.DeepCopy()
 		mark, ok := toUpdate.Annotations[ELBMarkAnnotation]
@@ -920,7 +933,7 @@ func (elb *ELBCloud) updateServiceStatus(kubeClient corev1.CoreV1Interface, serv
 			}
 		}
 		toUpdate.Annotations[ELBMarkAnnotation] = mark
-		_, err := kubeClient.Services(service.Namespace).Update(context.TODO(), toUpdate, metav1.UpdateOptions{})
+		_, err = kubeClient.Services(service.Namespace).Update(context.TODO(), toUpdate, metav1.UpdateOptions{})
 		if err == nil {
 			return
 		}
@@ -934,20 +947,34 @@ func (elb *ELBCloud) updateServiceStatus(kubeClient corev1.CoreV1Interface, serv
 		}
 
 		if apierrors.IsConflict(err) {
-			service, err = kubeClient.Services(service.Namespace).Get(context.TODO(), service.Name, metav1.GetOptions{})
-			if err != nil {
-				klog.Warningf("Get service(%s/%s) error: %v", service.Namespace, service.Name, err)
-				continue
+			if service, err = latestService(kubeClient, service); err != nil {
+				return
 			}
 		}
 	}
 }
 
+// latestService returns the service stored in the API server, so that the default annotations
+// of the cloud config applied to the given one are not persisted. The given service is never returned instead,
+// the caller skips the update if it fails.
+func latestService(kubeClient corev1.CoreV1Interface, service *v1.Service) (*v1.Service, error) {
+	latest, err := kubeClient.Services(service.Namespace).Get(context.TODO(), service.Name, metav1.GetOptions{})
+	if err != nil {
+		klog.Warningf("Get service(%s/%s) error, skip updating it: %v", service.Namespace, service.Name, err)
+		return nil, err
+	}
+	return latest, nil
+}
+
 // if async job succeed, need to init mark again
 func updateServiceMarkIfNeeded(
 	kubeClient corev1.CoreV1Interface,
 	service *v1.Service,
 	tryAgain bool) {
+	service, err := latestService(kubeClient, service)
+	if err != nil {
+		return
+	}
 	for i := 0; i < MaxRetry; i++ {
 		toUpdate := service.DeepCopy()
 		_, ok := toUpdate.Annotations[ELBMarkAnnotation]
@@ -964,7 +991,7 @@ func updateServiceMarkIfNeeded(
 			delete(toUpdate.Annotations, ELBMarkAnnotation)
 		}
 
-		_, err := kubeClient.Services(service.Namespace).Update(context.TODO(), toUpdate, metav1.UpdateOptions{})
+		_, err = kubeClient.Services(service.Namespace).Update(context.TODO(), toUpdate, metav1.UpdateOptions{})
 		if err == nil {
 			return
 		}
@@ -979,10 +1006,8 @@ func updateServiceMarkIfNeeded(
 		}
 
 		if apierrors.IsConflict(err) {
-			service, err = kubeClient.Services(service.Namespace).Get(context.TODO(), service.Name, metav1.GetOptions{})
-			if err != nil {
-				klog.Warningf("Get service(%s/%s) error: %v", service.Namespace, service.Name, err)
-				continue
+			if service, err = latestService(kubeClient, service); err != nil {
+				return
 			}
 		}
 	}
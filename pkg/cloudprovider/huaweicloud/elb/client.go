@@ -14,25 +14,34 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
+// Package elb implements the client of the classic load balancer API (v1.0 elbaas), which is not provided by
+// huaweicloud-sdk-go-v3, and declares the clients of the classic and shared load balancers.
 // nolint:golint // stop check lint issues as this file will be refactored
-package huaweicloud
+package elb
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
 	"time"
 
 	"k8s.io/klog"
 
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/auth"
 	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/common"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
 )
 
 const tryJobStatusTimes = 100
 
+type ELBProtocol string
+type ELBAlgorithm string
+
 type DeleteFunc func(string) error
 type DeleteFuncWithObj func(string, interface{}) error
 
@@ -221,9 +230,18 @@ const (
 	ELBHealthStatusUnavailable = "UNAVAILABLE"
 )
 
-type ELBClient struct {
-	ecsClient *ServiceClient
-	elbClient *ServiceClient
+// ELBServiceClient is the client of the classic load balancer API (v1.0 elbaas), which is not provided by
+// huaweicloud-sdk-go-v3, so the requests are built and signed by the auth.ServiceClient.
+type ELBServiceClient struct {
+	elbClient *auth.ServiceClient
+}
+
+// context returns the context of the reconcile the client is built for, the waits for the jobs stop once it is done.
+func (e *ELBServiceClient) context() context.Context {
+	if e.elbClient.Context == nil {
+		return context.Background()
+	}
+	return e.elbClient.Context
 }
 
 // Asynchronous job query response
@@ -255,49 +273,30 @@ type ErrorRsp struct {
 	} `json:"error"`
 }
 
-// Ecs address
-type EcsAddress struct {
-	Addr string `json:"addr"`
-}
-
-// EcsServer ecs server info
-type Server struct {
-	Id        string                  `json:"id"`
-	Name      string                  `json:"name"`
-	Addresses map[string][]EcsAddress `json:"addresses,omitempty"`
-}
-
-// Ecs servicers deatil
-type EcsServers struct {
-	Servers []Server `json:"servers,omitempty"`
-}
-
-func NewELBClient(cloud, region, projectID, accessKey, secretKey string) *ELBClient {
-	elbEndpoint := fmt.Sprintf("https://ecs.%s.%s", region, cloud)
-	ecsEndpoint := fmt.Sprintf("https://ecs.%s.%s", region, cloud)
-
-	access := &AccessInfo{AccessKey: accessKey,
-		SecretKey:   secretKey,
-		Region:      region,
-		ServiceType: "ec2",
-	}
-
-	ecsClient := &ServiceClient{
-		Client:   httpClient,
-		Endpoint: ecsEndpoint,
-		Access:   access,
-		TenantId: projectID,
+// NewELBServiceClient returns the client of the classic load balancers, which are served by the ECS endpoint.
+func NewELBServiceClient(authOpts *config.AuthOptions) *ELBServiceClient {
+	accessKey, secretKey, securityToken := authOpts.GetAccessKeys()
+	access := &auth.AccessInfo{AccessKey: accessKey,
+		SecretKey:     secretKey,
+		SecurityToken: securityToken,
+		Region:        authOpts.Region,
+		ServiceType:   "ec2",
 	}
 
-	elbClient := &ServiceClient{
-		Client:   httpClient,
-		Endpoint: elbEndpoint,
-		Access:   access,
-		TenantId: projectID,
+	elbClient := &auth.ServiceClient{
+		Client:        auth.HTTPClient(),
+		Endpoint:      authOpts.GetEndpoint("ecs"),
+		Catalog:       "elb",
+		Access:        access,
+		TenantId:      authOpts.ProjectID,
+		APIVersion:    authOpts.GetAPIVersion("elb", "v1.0"),
+		OnUnreachable: func(endpoint string) { authOpts.ReportEndpointFailure("ecs", endpoint) },
+		Context:       authOpts.Context(),
+		Acquire:       authOpts.AcquireEndpoint,
+		Timeout:       authOpts.GetServiceRequestTimeout("elb"),
 	}
 
-	return &ELBClient{
-		ecsClient: ecsClient,
+	return &ELBServiceClient{
 		elbClient: elbClient,
 	}
 }
@@ -325,7 +324,7 @@ func IsValidBandwidth(bandwidth int) bool {
 	return 1 <= bandwidth && bandwidth <= 300
 }
 
-func (e *ELBClient) waitJobEnd(jobID string) (*AsyncJobResp, error) {
+func (e *ELBServiceClient) waitJobEnd(jobID string) (*AsyncJobResp, error) {
 	for i := 0; i < tryJobStatusTimes; i++ {
 		job, err := e.GetJobStatus(jobID)
 		if err != nil {
@@ -338,7 +337,11 @@ func (e *ELBClient) waitJobEnd(jobID string) (*AsyncJobResp, error) {
 		case ELBJobStatusFail:
 			return nil, fmt.Errorf("job status is failed. id: %s, reason: %s", jobID, job.FailReason)
 		default:
-			time.Sleep(time.Second * 3)
+			select {
+			case <-e.context().Done():
+				return nil, fmt.Errorf("stop waiting for job %s, the reconcile is canceled: %w", jobID, e.context().Err())
+			case <-time.After(time.Second * 3):
+			}
 			continue
 		}
 	}
@@ -346,8 +349,8 @@ func (e *ELBClient) waitJobEnd(jobID string) (*AsyncJobResp, error) {
 	return nil, fmt.Errorf("start job time out id: %s", jobID)
 }
 
-func (e *ELBClient) WaitJobComplete(jobID string) error {
-	err := common.WaitForCompleted(func() (bool, error) {
+func (e *ELBServiceClient) WaitJobComplete(jobID string) error {
+	err := common.WaitForCompletedWithContext(e.context(), func() (bool, error) {
 		job, err := e.GetJobStatus(jobID)
 		if err != nil {
 			klog.Errorf("Get job(%s) status error: %v", jobID, err)
@@ -368,8 +371,8 @@ func (e *ELBClient) WaitJobComplete(jobID string) error {
 	return err
 }
 
-func (e *ELBClient) WaitMemberComplete(listenerID string, newMembers []*Member) error {
-	err := common.WaitForCompleted(func() (bool, error) {
+func (e *ELBServiceClient) WaitMemberComplete(listenerID string, newMembers []*Member) error {
+	err := common.WaitForCompletedWithContext(e.context(), func() (bool, error) {
 		members, err := e.ListMembers(listenerID)
 		if err != nil {
 			klog.Errorf("List members(%s) error: %v", listenerID, err)
@@ -396,17 +399,17 @@ func (e *ELBClient) WaitMemberComplete(listenerID string, newMembers []*Member)
 	return err
 }
 
-func (e *ELBClient) GetJobStatus(jobID string) (*AsyncJobResp, error) {
-	url := "/v1.0/" + e.elbClient.TenantId + "/jobs/" + jobID
-	req := NewRequest(http.MethodGet, url, nil, nil)
+func (e *ELBServiceClient) GetJobStatus(jobID string) (*AsyncJobResp, error) {
+	url := e.elbClient.ProjectPath() + "/jobs/" + jobID
+	req := auth.NewRequest(http.MethodGet, url, nil, nil)
 
-	resp, err := DoRequest(e.elbClient, nil, req)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return nil, err
 	}
 
 	var job AsyncJobResp
-	err = DecodeBody(resp, &job)
+	err = auth.DecodeBody(resp, &job)
 	if err != nil {
 		return nil, fmt.Errorf("failed to getAsyncJobStatus : %v", err)
 	}
@@ -414,16 +417,16 @@ func (e *ELBClient) GetJobStatus(jobID string) (*AsyncJobResp, error) {
 	return &job, nil
 }
 
-func (e *ELBClient) Quota() (*Quota, error) {
-	req := NewRequest(http.MethodGet, "/v1.0/"+e.elbClient.TenantId+"/elbaas/quotas", nil, nil)
+func (e *ELBServiceClient) Quota() (*Quota, error) {
+	req := auth.NewRequest(http.MethodGet, e.elbClient.ProjectPath()+"/elbaas/quotas", nil, nil)
 
-	resp, err := DoRequest(e.elbClient, nil, req)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return nil, err
 	}
 
 	var quota Quota
-	err = DecodeBody(resp, &quota)
+	err = auth.DecodeBody(resp, &quota)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to getElbQuota : %v", err)
 	}
@@ -433,7 +436,7 @@ func (e *ELBClient) Quota() (*Quota, error) {
 
 // Create an ELB instance
 // 因为这个是通过portal创建的，所以无需API创建
-func (e *ELBClient) CreateLoadBalancer(elbConf *ELB) (string, error) {
+func (e *ELBServiceClient) CreateLoadBalancer(elbConf *ELB) (string, error) {
 	if !IsValidBandwidth(elbConf.Bandwidth) ||
 		!IsValidName(elbConf.Name) ||
 		!IsValidDesc(elbConf.Description) ||
@@ -462,15 +465,15 @@ func (e *ELBClient) CreateLoadBalancer(elbConf *ELB) (string, error) {
 		return "", fmt.Errorf("the quota of elb type can not be found")
 	}
 
-	url := "/v1.0/" + e.elbClient.TenantId + "/elbaas/loadbalancers"
-	req := NewRequest(http.MethodPost, url, nil, elbConf)
-	resp, err := DoRequest(e.elbClient, nil, req)
+	url := e.elbClient.ProjectPath() + "/elbaas/loadbalancers"
+	req := auth.NewRequest(http.MethodPost, url, nil, elbConf)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return "", err
 	}
 
 	var job JobResp
-	err = DecodeBody(resp, &job)
+	err = auth.DecodeBody(resp, &job)
 	if err != nil {
 		return "", fmt.Errorf("Failed to CreateElb : %v", err)
 	}
@@ -484,18 +487,18 @@ func (e *ELBClient) CreateLoadBalancer(elbConf *ELB) (string, error) {
 }
 
 // DeleteLoadBalancer deletes loadbalancer by ID.
-func (e *ELBClient) DeleteLoadBalancer(loadbalancerID string) error {
-	url := "/v1.0/" + e.elbClient.TenantId + "/elbaas/loadbalancers/" + loadbalancerID
+func (e *ELBServiceClient) DeleteLoadBalancer(loadbalancerID string) error {
+	url := e.elbClient.ProjectPath() + "/elbaas/loadbalancers/" + loadbalancerID
 
-	req := NewRequest(http.MethodDelete, url, nil, nil)
+	req := auth.NewRequest(http.MethodDelete, url, nil, nil)
 
-	resp, err := DoRequest(e.elbClient, nil, req)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return err
 	}
 
 	var job JobResp
-	err = DecodeBody(resp, &job)
+	err = auth.DecodeBody(resp, &job)
 	if err != nil {
 		return fmt.Errorf("Failed to DeleteElb : %v", err)
 	}
@@ -509,17 +512,17 @@ func (e *ELBClient) DeleteLoadBalancer(loadbalancerID string) error {
 }
 
 // GetLoadBalancer gets an ELB instance by ID.
-func (e *ELBClient) GetLoadBalancer(loadbalancerID string) (*ElbDetail, error) {
-	url := "/v1.0/" + e.elbClient.TenantId + "/elbaas/loadbalancers/" + loadbalancerID
-	req := NewRequest(http.MethodGet, url, nil, nil)
+func (e *ELBServiceClient) GetLoadBalancer(loadbalancerID string) (*ElbDetail, error) {
+	url := e.elbClient.ProjectPath() + "/elbaas/loadbalancers/" + loadbalancerID
+	req := auth.NewRequest(http.MethodGet, url, nil, nil)
 
-	resp, err := DoRequest(e.elbClient, nil, req)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return nil, err
 	}
 
 	var elbDetail ElbDetail
-	err = DecodeBody(resp, &elbDetail)
+	err = auth.DecodeBody(resp, &elbDetail)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to GetElbDetail : %v", err)
 	}
@@ -528,8 +531,8 @@ func (e *ELBClient) GetLoadBalancer(loadbalancerID string) (*ElbDetail, error) {
 }
 
 // ListLoadBalancers list ELBs.
-func (e *ELBClient) ListLoadBalancers(params map[string]string) (*ElbList, error) {
-	url := "/v1.0/" + e.elbClient.TenantId + "/elbaas/loadbalancers"
+func (e *ELBServiceClient) ListLoadBalancers(params map[string]string) (*ElbList, error) {
+	url := e.elbClient.ProjectPath() + "/elbaas/loadbalancers"
 	var query string
 	if len(params) != 0 {
 		query += "?"
@@ -544,8 +547,8 @@ func (e *ELBClient) ListLoadBalancers(params map[string]string) (*ElbList, error
 	}
 
 	url += query
-	req := NewRequest(http.MethodGet, url, nil, nil)
-	resp, err := DoRequest(e.elbClient, nil, req)
+	req := auth.NewRequest(http.MethodGet, url, nil, nil)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return nil, err
 	}
@@ -576,15 +579,15 @@ func (e *ELBClient) ListLoadBalancers(params map[string]string) (*ElbList, error
 	return &elbList, nil
 }
 
-func (e *ELBClient) ModifyElb(elbConf *ELB) (*ELB, error) {
+func (e *ELBServiceClient) ModifyElb(elbConf *ELB) (*ELB, error) {
 	return nil, nil
 }
 
-func (e *ELBClient) CreateListener(listenerConf *Listener) (*ListenerRsp, *ErrorRsp, error) {
-	url := "/v1.0/" + e.elbClient.TenantId + "/elbaas/listeners"
-	req := NewRequest(http.MethodPost, url, nil, listenerConf)
+func (e *ELBServiceClient) CreateListener(listenerConf *Listener) (*ListenerRsp, *ErrorRsp, error) {
+	url := e.elbClient.ProjectPath() + "/elbaas/listeners"
+	req := auth.NewRequest(http.MethodPost, url, nil, listenerConf)
 
-	resp, err := DoRequest(e.elbClient, nil, req)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -610,12 +613,12 @@ func (e *ELBClient) CreateListener(listenerConf *Listener) (*ListenerRsp, *Error
 	return &listener, nil, nil
 }
 
-func (e *ELBClient) DeleteListener(listenerID string) error {
-	url := "/v1.0/" + e.elbClient.TenantId + "/elbaas/listeners/" + listenerID
+func (e *ELBServiceClient) DeleteListener(listenerID string) error {
+	url := e.elbClient.ProjectPath() + "/elbaas/listeners/" + listenerID
 
-	req := NewRequest(http.MethodDelete, url, nil, nil)
+	req := auth.NewRequest(http.MethodDelete, url, nil, nil)
 
-	resp, err := DoRequest(e.elbClient, nil, req)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return err
 	}
@@ -629,17 +632,17 @@ func (e *ELBClient) DeleteListener(listenerID string) error {
 	return nil
 }
 
-func (e *ELBClient) GetListener(listenerID string) (*ListenerDetail, error) {
-	url := "/v1.0/" + e.elbClient.TenantId + "/elbaas/listeners/" + listenerID
-	req := NewRequest(http.MethodGet, url, nil, nil)
+func (e *ELBServiceClient) GetListener(listenerID string) (*ListenerDetail, error) {
+	url := e.elbClient.ProjectPath() + "/elbaas/listeners/" + listenerID
+	req := auth.NewRequest(http.MethodGet, url, nil, nil)
 
-	resp, err := DoRequest(e.elbClient, nil, req)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return nil, err
 	}
 
 	var listener ListenerDetail
-	err = DecodeBody(resp, &listener)
+	err = auth.DecodeBody(resp, &listener)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to GetListenerDetail : %v", err)
 	}
@@ -647,21 +650,29 @@ func (e *ELBClient) GetListener(listenerID string) (*ListenerDetail, error) {
 	return &listener, nil
 }
 
-func (e *ELBClient) ListListeners(loadbalancerID string) ([]*ListenerDetail, error) {
-	url := "/v1.0/" + e.elbClient.TenantId + "/elbaas/listeners"
-	if len(loadbalancerID) != 0 {
-		url = url + "?loadbalancer_id=" + loadbalancerID
+// ListListeners lists the listeners matching the filters of the query, such as "loadbalancer_id" and "name",
+// the filters with empty values are ignored.
+func (e *ELBServiceClient) ListListeners(filters map[string]string) ([]*ListenerDetail, error) {
+	query := url.Values{}
+	for key, value := range filters {
+		if key != "" && value != "" {
+			query.Set(key, value)
+		}
+	}
+	path := e.elbClient.ProjectPath() + "/elbaas/listeners"
+	if len(query) != 0 {
+		path += "?" + query.Encode()
 	}
 
-	req := NewRequest(http.MethodGet, url, nil, nil)
+	req := auth.NewRequest(http.MethodGet, path, nil, nil)
 
-	resp, err := DoRequest(e.elbClient, nil, req)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return nil, err
 	}
 
 	var listenerList []*ListenerDetail
-	err = DecodeBody(resp, &listenerList)
+	err = auth.DecodeBody(resp, &listenerList)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to GetListenersList : %v", err)
 	}
@@ -669,17 +680,17 @@ func (e *ELBClient) ListListeners(loadbalancerID string) ([]*ListenerDetail, err
 	return listenerList, nil
 }
 
-func (e *ELBClient) UpdateListener(listener *Listener, listenerID string) (*ListenerDetail, error) {
-	url := "/v1.0/" + e.elbClient.TenantId + "/elbaas/listeners/" + listenerID
-	req := NewRequest(http.MethodPut, url, nil, listener)
+func (e *ELBServiceClient) UpdateListener(listener *Listener, listenerID string) (*ListenerDetail, error) {
+	url := e.elbClient.ProjectPath() + "/elbaas/listeners/" + listenerID
+	req := auth.NewRequest(http.MethodPut, url, nil, listener)
 
-	resp, err := DoRequest(e.elbClient, nil, req)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return nil, err
 	}
 
 	var listenerDetail ListenerDetail
-	err = DecodeBody(resp, &listenerDetail)
+	err = auth.DecodeBody(resp, &listenerDetail)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to ModifyListener : %v", err)
 	}
@@ -687,18 +698,18 @@ func (e *ELBClient) UpdateListener(listener *Listener, listenerID string) (*List
 	return &listenerDetail, nil
 }
 
-func (e *ELBClient) CreateHealthCheck(healthConf *HealthCheck) (*HealthCheckRsp, error) {
-	url := "/v1.0/" + e.elbClient.TenantId + "/elbaas/healthcheck"
+func (e *ELBServiceClient) CreateHealthCheck(healthConf *HealthCheck) (*HealthCheckRsp, error) {
+	url := e.elbClient.ProjectPath() + "/elbaas/healthcheck"
 
-	req := NewRequest(http.MethodPost, url, nil, healthConf)
+	req := auth.NewRequest(http.MethodPost, url, nil, healthConf)
 
-	resp, err := DoRequest(e.elbClient, nil, req)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return nil, err
 	}
 
 	var healthCheck HealthCheckRsp
-	err = DecodeBody(resp, &healthCheck)
+	err = auth.DecodeBody(resp, &healthCheck)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to CreateHealthCheck : %v", err)
 	}
@@ -707,11 +718,11 @@ func (e *ELBClient) CreateHealthCheck(healthConf *HealthCheck) (*HealthCheckRsp,
 }
 
 // DeleteHealthCheck deletes a health check.
-func (e *ELBClient) DeleteHealthCheck(healthcheckID string) error {
-	url := "/v1.0/" + e.elbClient.TenantId + "/elbaas/healthcheck/" + healthcheckID
+func (e *ELBServiceClient) DeleteHealthCheck(healthcheckID string) error {
+	url := e.elbClient.ProjectPath() + "/elbaas/healthcheck/" + healthcheckID
 
-	req := NewRequest(http.MethodDelete, url, nil, nil)
-	resp, err := DoRequest(e.elbClient, nil, req)
+	req := auth.NewRequest(http.MethodDelete, url, nil, nil)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return err
 	}
@@ -727,11 +738,11 @@ func (e *ELBClient) DeleteHealthCheck(healthcheckID string) error {
 }
 
 // GetHealthCheck gets health check details info.
-func (e *ELBClient) GetHealthCheck(healthcheckID string) (*HealthCheckDetail, *ErrorRsp, error) {
-	url := "/v1.0/" + e.elbClient.TenantId + "/elbaas/healthcheck/" + healthcheckID
+func (e *ELBServiceClient) GetHealthCheck(healthcheckID string) (*HealthCheckDetail, *ErrorRsp, error) {
+	url := e.elbClient.ProjectPath() + "/elbaas/healthcheck/" + healthcheckID
 
-	req := NewRequest(http.MethodGet, url, nil, nil)
-	resp, err := DoRequest(e.elbClient, nil, req)
+	req := auth.NewRequest(http.MethodGet, url, nil, nil)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -757,18 +768,18 @@ func (e *ELBClient) GetHealthCheck(healthcheckID string) (*HealthCheckDetail, *E
 	return &healthCheck, nil, nil
 }
 
-func (e *ELBClient) UpdateHealthCheck(healthConf *HealthCheck, healthcheckID string) (*HealthCheckRsp, error) {
-	url := "/v1.0/" + e.elbClient.TenantId + "/elbaas/healthcheck/" + healthcheckID
+func (e *ELBServiceClient) UpdateHealthCheck(healthConf *HealthCheck, healthcheckID string) (*HealthCheckRsp, error) {
+	url := e.elbClient.ProjectPath() + "/elbaas/healthcheck/" + healthcheckID
 
-	req := NewRequest(http.MethodPut, url, nil, healthConf)
+	req := auth.NewRequest(http.MethodPut, url, nil, healthConf)
 
-	resp, err := DoRequest(e.elbClient, nil, req)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return nil, err
 	}
 
 	var healthCheck HealthCheckRsp
-	err = DecodeBody(resp, &healthCheck)
+	err = auth.DecodeBody(resp, &healthCheck)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to ModifyHealthCheck : %v", err)
 	}
@@ -776,18 +787,18 @@ func (e *ELBClient) UpdateHealthCheck(healthConf *HealthCheck, healthcheckID str
 	return &healthCheck, nil
 }
 
-func (e *ELBClient) RegisterInstancesWithListener(listenerID string, memberConf []*Member) (*AsyncJobResp, error) {
-	url := "/v1.0/" + e.elbClient.TenantId + "/elbaas/listeners/" + listenerID + "/members"
+func (e *ELBServiceClient) RegisterInstancesWithListener(listenerID string, memberConf []*Member) (*AsyncJobResp, error) {
+	url := e.elbClient.ProjectPath() + "/elbaas/listeners/" + listenerID + "/members"
 
-	req := NewRequest(http.MethodPost, url, nil, memberConf)
+	req := auth.NewRequest(http.MethodPost, url, nil, memberConf)
 
-	resp, err := DoRequest(e.elbClient, nil, req)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return nil, err
 	}
 
 	var job JobResp
-	err = DecodeBody(resp, &job)
+	err = auth.DecodeBody(resp, &job)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to AddMember : %v", err)
 	}
@@ -800,12 +811,12 @@ func (e *ELBClient) RegisterInstancesWithListener(listenerID string, memberConf
 	return asyJobRsp, nil
 }
 
-func (e *ELBClient) ListMembers(listenerID string) ([]*MemDetail, error) {
-	url := "/v1.0/" + e.elbClient.TenantId + "/elbaas/listeners/" + listenerID + "/members"
+func (e *ELBServiceClient) ListMembers(listenerID string) ([]*MemDetail, error) {
+	url := e.elbClient.ProjectPath() + "/elbaas/listeners/" + listenerID + "/members"
 
-	req := NewRequest(http.MethodGet, url, nil, nil)
+	req := auth.NewRequest(http.MethodGet, url, nil, nil)
 
-	resp, err := DoRequest(e.elbClient, nil, req)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return nil, err
 	}
@@ -831,7 +842,7 @@ func (e *ELBClient) ListMembers(listenerID string) ([]*MemDetail, error) {
 }
 
 // members as type *MembersDel
-func (e *ELBClient) DeleteMembers(listenerID string) error {
+func (e *ELBServiceClient) DeleteMembers(listenerID string) error {
 	members, err := e.ListMembers(listenerID)
 	if err != nil {
 		return err
@@ -847,16 +858,16 @@ func (e *ELBClient) DeleteMembers(listenerID string) error {
 		return nil
 	}
 
-	url := "/v1.0/" + e.elbClient.TenantId + "/elbaas/listeners/" + listenerID + "/members/action"
+	url := e.elbClient.ProjectPath() + "/elbaas/listeners/" + listenerID + "/members/action"
 
-	req := NewRequest(http.MethodPost, url, nil, memDel)
-	resp, err := DoRequest(e.elbClient, nil, req)
+	req := auth.NewRequest(http.MethodPost, url, nil, memDel)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return err
 	}
 
 	var job JobResp
-	err = DecodeBody(resp, &job)
+	err = auth.DecodeBody(resp, &job)
 	if err != nil {
 		return fmt.Errorf("Failed to DeleteMembers : %v", err)
 	}
@@ -870,16 +881,16 @@ func (e *ELBClient) DeleteMembers(listenerID string) error {
 }
 
 // members as type *MembersDel
-func (e *ELBClient) DeregisterInstancesFromListener(listenerID string, memDel *MembersDel) error {
-	url := "/v1.0/" + e.elbClient.TenantId + "/elbaas/listeners/" + listenerID + "/members/action"
-	req := NewRequest(http.MethodPost, url, nil, memDel)
-	resp, err := DoRequest(e.elbClient, nil, req)
+func (e *ELBServiceClient) DeregisterInstancesFromListener(listenerID string, memDel *MembersDel) error {
+	url := e.elbClient.ProjectPath() + "/elbaas/listeners/" + listenerID + "/members/action"
+	req := auth.NewRequest(http.MethodPost, url, nil, memDel)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return err
 	}
 
 	var job JobResp
-	err = DecodeBody(resp, &job)
+	err = auth.DecodeBody(resp, &job)
 	if err != nil {
 		return fmt.Errorf("Failed to DeleteSpecMembers : %v", err)
 	}
@@ -892,36 +903,18 @@ func (e *ELBClient) DeregisterInstancesFromListener(listenerID string, memDel *M
 	return nil
 }
 
-// GetEcsByIp get hws ecs server by IP address
-func (e *ELBClient) ListMachines() (*EcsServers, error) {
-	url := "/v2/" + e.ecsClient.TenantId + "/servers/detail"
-	req := NewRequest(http.MethodGet, url, nil, nil)
-	resp, err := DoRequest(e.ecsClient, nil, req)
-	if err != nil {
-		return nil, err
-	}
-
-	var servers EcsServers
-	err = DecodeBody(resp, &servers)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to GetEcsList : %v", err)
-	}
-
-	return &servers, nil
-}
-
-func (e *ELBClient) AsyncCreateMembers(listenerID string, memberConf []*Member) (*JobResp, error) {
-	url := "/v1.0/" + e.elbClient.TenantId + "/elbaas/listeners/" + listenerID + "/members"
+func (e *ELBServiceClient) AsyncCreateMembers(listenerID string, memberConf []*Member) (*JobResp, error) {
+	url := e.elbClient.ProjectPath() + "/elbaas/listeners/" + listenerID + "/members"
 
-	req := NewRequest(http.MethodPost, url, nil, memberConf)
+	req := auth.NewRequest(http.MethodPost, url, nil, memberConf)
 
-	resp, err := DoRequest(e.elbClient, nil, req)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return nil, err
 	}
 
 	var job JobResp
-	err = DecodeBody(resp, &job)
+	err = auth.DecodeBody(resp, &job)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to AddMembers : %v", err)
 	}
@@ -930,16 +923,16 @@ func (e *ELBClient) AsyncCreateMembers(listenerID string, memberConf []*Member)
 }
 
 // AsyncDeleteMembers deletes members as type *MembersDel.
-func (e *ELBClient) AsyncDeleteMembers(listenerID string, memDel *MembersDel) (*JobResp, error) {
-	url := "/v1.0/" + e.elbClient.TenantId + "/elbaas/listeners/" + listenerID + "/members/action"
-	req := NewRequest(http.MethodPost, url, nil, memDel)
-	resp, err := DoRequest(e.elbClient, nil, req)
+func (e *ELBServiceClient) AsyncDeleteMembers(listenerID string, memDel *MembersDel) (*JobResp, error) {
+	url := e.elbClient.ProjectPath() + "/elbaas/listeners/" + listenerID + "/members/action"
+	req := auth.NewRequest(http.MethodPost, url, nil, memDel)
+	resp, err := auth.DoRequest(e.elbClient, nil, req)
 	if err != nil {
 		return nil, err
 	}
 
 	var job JobResp
-	err = DecodeBody(resp, &job)
+	err = auth.DecodeBody(resp, &job)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to DeleteMembers : %v", err)
 	}
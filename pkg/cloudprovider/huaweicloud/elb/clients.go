@@ -0,0 +1,93 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elb
+
+import (
+	elbmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/elb/v2/model"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/wrapper"
+)
+
+// ClassicELBClient is the client of the classic load balancer APIs used by the ELBCloud.
+// It is implemented by the ELBServiceClient and by the FakeClassicELBClient of the unit tests.
+type ClassicELBClient interface {
+	WaitJobComplete(jobID string) error
+	WaitMemberComplete(listenerID string, newMembers []*Member) error
+	GetJobStatus(jobID string) (*AsyncJobResp, error)
+	Quota() (*Quota, error)
+
+	CreateLoadBalancer(elbConf *ELB) (string, error)
+	DeleteLoadBalancer(loadbalancerID string) error
+	GetLoadBalancer(loadbalancerID string) (*ElbDetail, error)
+	ListLoadBalancers(params map[string]string) (*ElbList, error)
+	ModifyElb(elbConf *ELB) (*ELB, error)
+
+	CreateListener(listenerConf *Listener) (*ListenerRsp, *ErrorRsp, error)
+	DeleteListener(listenerID string) error
+	GetListener(listenerID string) (*ListenerDetail, error)
+	ListListeners(filters map[string]string) ([]*ListenerDetail, error)
+	UpdateListener(listener *Listener, listenerID string) (*ListenerDetail, error)
+
+	CreateHealthCheck(healthConf *HealthCheck) (*HealthCheckRsp, error)
+	DeleteHealthCheck(healthcheckID string) error
+	GetHealthCheck(healthcheckID string) (*HealthCheckDetail, *ErrorRsp, error)
+	UpdateHealthCheck(healthConf *HealthCheck, healthcheckID string) (*HealthCheckRsp, error)
+
+	RegisterInstancesWithListener(listenerID string, memberConf []*Member) (*AsyncJobResp, error)
+	ListMembers(listenerID string) ([]*MemDetail, error)
+	DeleteMembers(listenerID string) error
+	DeregisterInstancesFromListener(listenerID string, memDel *MembersDel) error
+	AsyncCreateMembers(listenerID string, memberConf []*Member) (*JobResp, error)
+	AsyncDeleteMembers(listenerID string, memDel *MembersDel) (*JobResp, error)
+}
+
+// SharedELBClient is the client of the shared load balancer APIs used by the SharedLoadBalancer.
+// It is implemented by the wrapper.SharedLoadBalanceClient and by the FakeSharedELBClient of the unit tests.
+type SharedELBClient interface {
+	CreateInstance(req *elbmodel.CreateLoadbalancerReq) (*elbmodel.LoadbalancerResp, error)
+	CreateInstanceCompleted(req *elbmodel.CreateLoadbalancerReq) (*elbmodel.LoadbalancerResp, error)
+	WaitStatusActive(id string) (*elbmodel.LoadbalancerResp, error)
+	GetInstance(id string) (*elbmodel.LoadbalancerResp, error)
+	ListInstances(req *elbmodel.ListLoadbalancersRequest) ([]elbmodel.LoadbalancerResp, error)
+	UpdateInstance(id, name, description string) (*elbmodel.LoadbalancerResp, error)
+	DeleteInstance(id string) error
+	CreateListener(req *elbmodel.CreateListenerReq) (*elbmodel.ListenerResp, error)
+	GetListener(id string) (*elbmodel.ListenerResp, error)
+	ListListeners(req *elbmodel.ListListenersRequest) ([]elbmodel.ListenerResp, error)
+	UpdateListener(id string, req *elbmodel.UpdateListenerReq) error
+	DeleteListener(elbID string, listenerID string) error
+	CreatePool(req *elbmodel.CreatePoolReq) (*elbmodel.PoolResp, error)
+	GetPool(id string) (*elbmodel.PoolResp, error)
+	ListPools(req *elbmodel.ListPoolsRequest) ([]elbmodel.PoolResp, error)
+	UpdatePool(id string, req *elbmodel.UpdatePoolReq) (*elbmodel.PoolResp, error)
+	DeletePool(id string) error
+	CreateHealthMonitor(req *elbmodel.CreateHealthmonitorReq) (*elbmodel.HealthmonitorResp, error)
+	GetHealthMonitor(id string) (*elbmodel.HealthmonitorResp, error)
+	UpdateHealthMonitor(id string, req *elbmodel.UpdateHealthmonitorReq) error
+	DeleteHealthMonitor(id string) error
+	AddMember(poolID string, req *elbmodel.CreateMemberReq) (*elbmodel.MemberResp, error)
+	GetMember(id string) (*elbmodel.MemberResp, error)
+	ListMembers(req *elbmodel.ListMembersRequest) ([]elbmodel.MemberResp, error)
+	UpdateMember(id string, req *elbmodel.UpdateMemberReq) (*elbmodel.MemberResp, error)
+	DeleteMember(poolID, memberID string) error
+	DeleteAllPoolMembers(poolID string) error
+}
+
+var (
+	_ ClassicELBClient = &ELBServiceClient{}
+	_ SharedELBClient  = &wrapper.SharedLoadBalanceClient{}
+)
@@ -15,7 +15,7 @@ limitations under the License.
 */
 
 // nolint:golint // stop check lint issues as this file will be refactored
-package huaweicloud
+package elb
 
 type LbErrorCode string
 
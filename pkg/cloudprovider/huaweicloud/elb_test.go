@@ -0,0 +1,85 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"errors"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestUpdateServiceMarkWithoutDefaults(t *testing.T) {
+	stored := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web", Annotations: map[string]string{
+			ELBMarkAnnotation: "1",
+		}},
+	}
+	// the service passed in has the default annotations of the cloud config merged.
+	defaulted := stored.DeepCopy()
+	defaulted.Annotations[ElbClass] = "dedicated"
+
+	tests := []struct {
+		name   string
+		getErr error
+
+		expectedUpdated bool
+	}{
+		{
+			name:            "updates the service stored in the API server",
+			expectedUpdated: true,
+		},
+		{
+			name:   "skips the update if the service is not got",
+			getErr: errors.New("internal error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(stored.DeepCopy())
+			if tt.getErr != nil {
+				client.PrependReactor("get", "services", func(k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, tt.getErr
+				})
+			}
+
+			updateServiceMarkIfNeeded(client.CoreV1(), defaulted, false)
+
+			updated := false
+			for _, action := range client.Actions() {
+				if action.GetVerb() == "update" {
+					updated = true
+				}
+			}
+			if updated != tt.expectedUpdated {
+				t.Fatalf("the service is updated: %v, expected %v", updated, tt.expectedUpdated)
+			}
+			latest, err := client.Tracker().Get(v1.SchemeGroupVersion.WithResource("services"), "default", "web")
+			if err != nil {
+				t.Fatalf("failed to get the service: %v", err)
+			}
+			if _, ok := latest.(*v1.Service).Annotations[ElbClass]; ok {
+				t.Errorf("the default annotation %s is persisted to the service", ElbClass)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/reference"
+	"k8s.io/klog/v2"
+)
+
+// requestIDRegexp matches the request IDs in the messages, which differ between the identical failures.
+var requestIDRegexp = regexp.MustCompile(`request ID: [^,)\s]*`)
+
+type eventKey struct {
+	object    string
+	eventType string
+	reason    string
+	message   string
+}
+
+type eventEntry struct {
+	object  runtime.Object
+	first   time.Time
+	message string
+	// suppressed is the number of the identical events suppressed since the first one.
+	suppressed int
+}
+
+// dedupRecorder records the first of the identical events of an object in a window, and summarizes the suppressed
+// ones in an event at the end of the window, so that a failure repeated by the retries does not flood the object.
+type dedupRecorder struct {
+	record.EventRecorder
+	window time.Duration
+	now    func() time.Time
+
+	lock    sync.Mutex
+	entries map[eventKey]*eventEntry
+}
+
+func newDedupRecorder(recorder record.EventRecorder, window time.Duration) *dedupRecorder {
+	return &dedupRecorder{
+		EventRecorder: recorder,
+		window:        window,
+		now:           time.Now,
+		entries:       make(map[eventKey]*eventEntry),
+	}
+}
+
+// run summarizes the suppressed events of the ended windows until the stop channel is closed.
+func (r *dedupRecorder) run(stop <-chan struct{}) {
+	interval := r.window / 10
+	if interval < time.Second {
+		interval = time.Second
+	}
+	go wait.Until(r.flush, interval, stop)
+}
+
+func (r *dedupRecorder) Event(object runtime.Object, eventType, reason, message string) {
+	ref, err := reference.GetReference(scheme.Scheme, object)
+	if err != nil {
+		r.EventRecorder.Event(object, eventType, reason, message)
+		return
+	}
+	key := eventKey{
+		object:    fmt.Sprintf("%s/%s/%s/%s", ref.Kind, ref.Namespace, ref.Name, ref.UID),
+		eventType: eventType,
+		reason:    reason,
+		message:   requestIDRegexp.ReplaceAllString(message, ""),
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if entry, ok := r.entries[key]; ok {
+		entry.suppressed++
+		entry.message = message
+		return
+	}
+	r.entries[key] = &eventEntry{object: object, first: r.now(), message: message}
+	r.EventRecorder.Event(object, eventType, reason, message)
+}
+
+func (r *dedupRecorder) Eventf(object runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	r.Event(object, eventType, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// flush records a summarizing event of the suppressed events of each ended window.
+func (r *dedupRecorder) flush() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	now := r.now()
+	for key, entry := range r.entries {
+		if now.Sub(entry.first) < r.window {
+			continue
+		}
+		delete(r.entries, key)
+		if entry.suppressed == 0 {
+			continue
+		}
+		klog.V(4).Infof("%d identical %s events of %s are suppressed", entry.suppressed, key.reason, key.object)
+		r.EventRecorder.Event(entry.object, key.eventType, key.reason, fmt.Sprintf("%s (repeated %d more times in %s)",
+			entry.message, entry.suppressed, r.window))
+	}
+}
@@ -0,0 +1,999 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"sync"
+
+	ecsmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ecs/v2/model"
+	elbmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/elb/v2/model"
+	elbmodelv3 "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/elb/v3/model"
+	vpcmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/vpc/v2/model"
+	v1 "k8s.io/api/core/v1"
+
+	albapi "sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/alb"
+	lbconfig "sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/config"
+	ecsapi "sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/ecs"
+	elbapi "sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/elb"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/wrapper"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+)
+
+// The fakes of the clients call the functions of the method names with the suffix Func, the methods whose
+// functions are nil return the zero values, except FakeECSClient.BuildAddresses builds the addresses as the
+// wrapper.EcsClient does. The calls are counted by the method names.
+
+// newFakeBasic returns a Basic of the default cloud config without the clients, the tests set the fakes.
+func newFakeBasic() Basic {
+	cloudConfig := &config.CloudConfig{}
+	return Basic{
+		cloudConfig: cloudConfig,
+		options:     lbconfig.NewStore(cloudConfig.NewELBConfig(), cloudConfig),
+	}
+}
+
+// fakeCalls counts the calls of the methods of a fake.
+type fakeCalls struct {
+	lock  sync.Mutex
+	calls map[string]int
+}
+
+func (f *fakeCalls) called(method string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.calls == nil {
+		f.calls = make(map[string]int)
+	}
+	f.calls[method]++
+}
+
+// CallCount returns the number of the calls of the method.
+func (f *fakeCalls) CallCount(method string) int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.calls[method]
+}
+
+// FakeClassicELBClient is the fake of the ClassicELBClient.
+type FakeClassicELBClient struct {
+	fakeCalls
+
+	WaitJobCompleteFunc                 func(jobID string) error
+	WaitMemberCompleteFunc              func(listenerID string, newMembers []*elbapi.Member) error
+	GetJobStatusFunc                    func(jobID string) (*elbapi.AsyncJobResp, error)
+	QuotaFunc                           func() (*elbapi.Quota, error)
+	CreateLoadBalancerFunc              func(elbConf *elbapi.ELB) (string, error)
+	DeleteLoadBalancerFunc              func(loadbalancerID string) error
+	GetLoadBalancerFunc                 func(loadbalancerID string) (*elbapi.ElbDetail, error)
+	ListLoadBalancersFunc               func(params map[string]string) (*elbapi.ElbList, error)
+	ModifyElbFunc                       func(elbConf *elbapi.ELB) (*elbapi.ELB, error)
+	CreateListenerFunc                  func(listenerConf *elbapi.Listener) (*elbapi.ListenerRsp, *elbapi.ErrorRsp, error)
+	DeleteListenerFunc                  func(listenerID string) error
+	GetListenerFunc                     func(listenerID string) (*elbapi.ListenerDetail, error)
+	ListListenersFunc                   func(filters map[string]string) ([]*elbapi.ListenerDetail, error)
+	UpdateListenerFunc                  func(listener *elbapi.Listener, listenerID string) (*elbapi.ListenerDetail, error)
+	CreateHealthCheckFunc               func(healthConf *elbapi.HealthCheck) (*elbapi.HealthCheckRsp, error)
+	DeleteHealthCheckFunc               func(healthcheckID string) error
+	GetHealthCheckFunc                  func(healthcheckID string) (*elbapi.HealthCheckDetail, *elbapi.ErrorRsp, error)
+	UpdateHealthCheckFunc               func(healthConf *elbapi.HealthCheck, healthcheckID string) (*elbapi.HealthCheckRsp, error)
+	RegisterInstancesWithListenerFunc   func(listenerID string, memberConf []*elbapi.Member) (*elbapi.AsyncJobResp, error)
+	ListMembersFunc                     func(listenerID string) ([]*elbapi.MemDetail, error)
+	DeleteMembersFunc                   func(listenerID string) error
+	DeregisterInstancesFromListenerFunc func(listenerID string, memDel *elbapi.MembersDel) error
+	AsyncCreateMembersFunc              func(listenerID string, memberConf []*elbapi.Member) (*elbapi.JobResp, error)
+	AsyncDeleteMembersFunc              func(listenerID string, memDel *elbapi.MembersDel) (*elbapi.JobResp, error)
+}
+
+func (f *FakeClassicELBClient) WaitJobComplete(jobID string) error {
+	f.called("WaitJobComplete")
+	if f.WaitJobCompleteFunc == nil {
+		return nil
+	}
+	return f.WaitJobCompleteFunc(jobID)
+}
+
+func (f *FakeClassicELBClient) WaitMemberComplete(listenerID string, newMembers []*elbapi.Member) error {
+	f.called("WaitMemberComplete")
+	if f.WaitMemberCompleteFunc == nil {
+		return nil
+	}
+	return f.WaitMemberCompleteFunc(listenerID, newMembers)
+}
+
+func (f *FakeClassicELBClient) GetJobStatus(jobID string) (*elbapi.AsyncJobResp, error) {
+	f.called("GetJobStatus")
+	if f.GetJobStatusFunc == nil {
+		return nil, nil
+	}
+	return f.GetJobStatusFunc(jobID)
+}
+
+func (f *FakeClassicELBClient) Quota() (*elbapi.Quota, error) {
+	f.called("Quota")
+	if f.QuotaFunc == nil {
+		return nil, nil
+	}
+	return f.QuotaFunc()
+}
+
+func (f *FakeClassicELBClient) CreateLoadBalancer(elbConf *elbapi.ELB) (string, error) {
+	f.called("CreateLoadBalancer")
+	if f.CreateLoadBalancerFunc == nil {
+		return "", nil
+	}
+	return f.CreateLoadBalancerFunc(elbConf)
+}
+
+func (f *FakeClassicELBClient) DeleteLoadBalancer(loadbalancerID string) error {
+	f.called("DeleteLoadBalancer")
+	if f.DeleteLoadBalancerFunc == nil {
+		return nil
+	}
+	return f.DeleteLoadBalancerFunc(loadbalancerID)
+}
+
+func (f *FakeClassicELBClient) GetLoadBalancer(loadbalancerID string) (*elbapi.ElbDetail, error) {
+	f.called("GetLoadBalancer")
+	if f.GetLoadBalancerFunc == nil {
+		return nil, nil
+	}
+	return f.GetLoadBalancerFunc(loadbalancerID)
+}
+
+func (f *FakeClassicELBClient) ListLoadBalancers(params map[string]string) (*elbapi.ElbList, error) {
+	f.called("ListLoadBalancers")
+	if f.ListLoadBalancersFunc == nil {
+		return nil, nil
+	}
+	return f.ListLoadBalancersFunc(params)
+}
+
+func (f *FakeClassicELBClient) ModifyElb(elbConf *elbapi.ELB) (*elbapi.ELB, error) {
+	f.called("ModifyElb")
+	if f.ModifyElbFunc == nil {
+		return nil, nil
+	}
+	return f.ModifyElbFunc(elbConf)
+}
+
+func (f *FakeClassicELBClient) CreateListener(listenerConf *elbapi.Listener) (*elbapi.ListenerRsp, *elbapi.ErrorRsp, error) {
+	f.called("CreateListener")
+	if f.CreateListenerFunc == nil {
+		return nil, nil, nil
+	}
+	return f.CreateListenerFunc(listenerConf)
+}
+
+func (f *FakeClassicELBClient) DeleteListener(listenerID string) error {
+	f.called("DeleteListener")
+	if f.DeleteListenerFunc == nil {
+		return nil
+	}
+	return f.DeleteListenerFunc(listenerID)
+}
+
+func (f *FakeClassicELBClient) GetListener(listenerID string) (*elbapi.ListenerDetail, error) {
+	f.called("GetListener")
+	if f.GetListenerFunc == nil {
+		return nil, nil
+	}
+	return f.GetListenerFunc(listenerID)
+}
+
+func (f *FakeClassicELBClient) ListListeners(filters map[string]string) ([]*elbapi.ListenerDetail, error) {
+	f.called("ListListeners")
+	if f.ListListenersFunc == nil {
+		return nil, nil
+	}
+	return f.ListListenersFunc(filters)
+}
+
+func (f *FakeClassicELBClient) UpdateListener(listener *elbapi.Listener, listenerID string) (*elbapi.ListenerDetail, error) {
+	f.called("UpdateListener")
+	if f.UpdateListenerFunc == nil {
+		return nil, nil
+	}
+	return f.UpdateListenerFunc(listener, listenerID)
+}
+
+func (f *FakeClassicELBClient) CreateHealthCheck(healthConf *elbapi.HealthCheck) (*elbapi.HealthCheckRsp, error) {
+	f.called("CreateHealthCheck")
+	if f.CreateHealthCheckFunc == nil {
+		return nil, nil
+	}
+	return f.CreateHealthCheckFunc(healthConf)
+}
+
+func (f *FakeClassicELBClient) DeleteHealthCheck(healthcheckID string) error {
+	f.called("DeleteHealthCheck")
+	if f.DeleteHealthCheckFunc == nil {
+		return nil
+	}
+	return f.DeleteHealthCheckFunc(healthcheckID)
+}
+
+func (f *FakeClassicELBClient) GetHealthCheck(healthcheckID string) (*elbapi.HealthCheckDetail, *elbapi.ErrorRsp, error) {
+	f.called("GetHealthCheck")
+	if f.GetHealthCheckFunc == nil {
+		return nil, nil, nil
+	}
+	return f.GetHealthCheckFunc(healthcheckID)
+}
+
+func (f *FakeClassicELBClient) UpdateHealthCheck(healthConf *elbapi.HealthCheck, healthcheckID string) (*elbapi.HealthCheckRsp, error) {
+	f.called("UpdateHealthCheck")
+	if f.UpdateHealthCheckFunc == nil {
+		return nil, nil
+	}
+	return f.UpdateHealthCheckFunc(healthConf, healthcheckID)
+}
+
+func (f *FakeClassicELBClient) RegisterInstancesWithListener(listenerID string, memberConf []*elbapi.Member) (*elbapi.AsyncJobResp, error) {
+	f.called("RegisterInstancesWithListener")
+	if f.RegisterInstancesWithListenerFunc == nil {
+		return nil, nil
+	}
+	return f.RegisterInstancesWithListenerFunc(listenerID, memberConf)
+}
+
+func (f *FakeClassicELBClient) ListMembers(listenerID string) ([]*elbapi.MemDetail, error) {
+	f.called("ListMembers")
+	if f.ListMembersFunc == nil {
+		return nil, nil
+	}
+	return f.ListMembersFunc(listenerID)
+}
+
+func (f *FakeClassicELBClient) DeleteMembers(listenerID string) error {
+	f.called("DeleteMembers")
+	if f.DeleteMembersFunc == nil {
+		return nil
+	}
+	return f.DeleteMembersFunc(listenerID)
+}
+
+func (f *FakeClassicELBClient) DeregisterInstancesFromListener(listenerID string, memDel *elbapi.MembersDel) error {
+	f.called("DeregisterInstancesFromListener")
+	if f.DeregisterInstancesFromListenerFunc == nil {
+		return nil
+	}
+	return f.DeregisterInstancesFromListenerFunc(listenerID, memDel)
+}
+
+func (f *FakeClassicELBClient) AsyncCreateMembers(listenerID string, memberConf []*elbapi.Member) (*elbapi.JobResp, error) {
+	f.called("AsyncCreateMembers")
+	if f.AsyncCreateMembersFunc == nil {
+		return nil, nil
+	}
+	return f.AsyncCreateMembersFunc(listenerID, memberConf)
+}
+
+func (f *FakeClassicELBClient) AsyncDeleteMembers(listenerID string, memDel *elbapi.MembersDel) (*elbapi.JobResp, error) {
+	f.called("AsyncDeleteMembers")
+	if f.AsyncDeleteMembersFunc == nil {
+		return nil, nil
+	}
+	return f.AsyncDeleteMembersFunc(listenerID, memDel)
+}
+
+// FakeSharedELBClient is the fake of the SharedELBClient.
+type FakeSharedELBClient struct {
+	fakeCalls
+
+	CreateInstanceFunc          func(req *elbmodel.CreateLoadbalancerReq) (*elbmodel.LoadbalancerResp, error)
+	CreateInstanceCompletedFunc func(req *elbmodel.CreateLoadbalancerReq) (*elbmodel.LoadbalancerResp, error)
+	WaitStatusActiveFunc        func(id string) (*elbmodel.LoadbalancerResp, error)
+	GetInstanceFunc             func(id string) (*elbmodel.LoadbalancerResp, error)
+	ListInstancesFunc           func(req *elbmodel.ListLoadbalancersRequest) ([]elbmodel.LoadbalancerResp, error)
+	UpdateInstanceFunc          func(id, name, description string) (*elbmodel.LoadbalancerResp, error)
+	DeleteInstanceFunc          func(id string) error
+	CreateListenerFunc          func(req *elbmodel.CreateListenerReq) (*elbmodel.ListenerResp, error)
+	GetListenerFunc             func(id string) (*elbmodel.ListenerResp, error)
+	ListListenersFunc           func(req *elbmodel.ListListenersRequest) ([]elbmodel.ListenerResp, error)
+	UpdateListenerFunc          func(id string, req *elbmodel.UpdateListenerReq) error
+	DeleteListenerFunc          func(elbID string, listenerID string) error
+	CreatePoolFunc              func(req *elbmodel.CreatePoolReq) (*elbmodel.PoolResp, error)
+	GetPoolFunc                 func(id string) (*elbmodel.PoolResp, error)
+	ListPoolsFunc               func(req *elbmodel.ListPoolsRequest) ([]elbmodel.PoolResp, error)
+	UpdatePoolFunc              func(id string, req *elbmodel.UpdatePoolReq) (*elbmodel.PoolResp, error)
+	DeletePoolFunc              func(id string) error
+	CreateHealthMonitorFunc     func(req *elbmodel.CreateHealthmonitorReq) (*elbmodel.HealthmonitorResp, error)
+	GetHealthMonitorFunc        func(id string) (*elbmodel.HealthmonitorResp, error)
+	UpdateHealthMonitorFunc     func(id string, req *elbmodel.UpdateHealthmonitorReq) error
+	DeleteHealthMonitorFunc     func(id string) error
+	AddMemberFunc               func(poolID string, req *elbmodel.CreateMemberReq) (*elbmodel.MemberResp, error)
+	GetMemberFunc               func(id string) (*elbmodel.MemberResp, error)
+	ListMembersFunc             func(req *elbmodel.ListMembersRequest) ([]elbmodel.MemberResp, error)
+	UpdateMemberFunc            func(id string, req *elbmodel.UpdateMemberReq) (*elbmodel.MemberResp, error)
+	DeleteMemberFunc            func(poolID, memberID string) error
+	DeleteAllPoolMembersFunc    func(poolID string) error
+}
+
+func (f *FakeSharedELBClient) CreateInstance(req *elbmodel.CreateLoadbalancerReq) (*elbmodel.LoadbalancerResp, error) {
+	f.called("CreateInstance")
+	if f.CreateInstanceFunc == nil {
+		return nil, nil
+	}
+	return f.CreateInstanceFunc(req)
+}
+
+func (f *FakeSharedELBClient) CreateInstanceCompleted(req *elbmodel.CreateLoadbalancerReq) (*elbmodel.LoadbalancerResp, error) {
+	f.called("CreateInstanceCompleted")
+	if f.CreateInstanceCompletedFunc == nil {
+		return nil, nil
+	}
+	return f.CreateInstanceCompletedFunc(req)
+}
+
+func (f *FakeSharedELBClient) WaitStatusActive(id string) (*elbmodel.LoadbalancerResp, error) {
+	f.called("WaitStatusActive")
+	if f.WaitStatusActiveFunc == nil {
+		return nil, nil
+	}
+	return f.WaitStatusActiveFunc(id)
+}
+
+func (f *FakeSharedELBClient) GetInstance(id string) (*elbmodel.LoadbalancerResp, error) {
+	f.called("GetInstance")
+	if f.GetInstanceFunc == nil {
+		return nil, nil
+	}
+	return f.GetInstanceFunc(id)
+}
+
+func (f *FakeSharedELBClient) ListInstances(req *elbmodel.ListLoadbalancersRequest) ([]elbmodel.LoadbalancerResp, error) {
+	f.called("ListInstances")
+	if f.ListInstancesFunc == nil {
+		return nil, nil
+	}
+	return f.ListInstancesFunc(req)
+}
+
+func (f *FakeSharedELBClient) UpdateInstance(id, name, description string) (*elbmodel.LoadbalancerResp, error) {
+	f.called("UpdateInstance")
+	if f.UpdateInstanceFunc == nil {
+		return nil, nil
+	}
+	return f.UpdateInstanceFunc(id, name, description)
+}
+
+func (f *FakeSharedELBClient) DeleteInstance(id string) error {
+	f.called("DeleteInstance")
+	if f.DeleteInstanceFunc == nil {
+		return nil
+	}
+	return f.DeleteInstanceFunc(id)
+}
+
+func (f *FakeSharedELBClient) CreateListener(req *elbmodel.CreateListenerReq) (*elbmodel.ListenerResp, error) {
+	f.called("CreateListener")
+	if f.CreateListenerFunc == nil {
+		return nil, nil
+	}
+	return f.CreateListenerFunc(req)
+}
+
+func (f *FakeSharedELBClient) GetListener(id string) (*elbmodel.ListenerResp, error) {
+	f.called("GetListener")
+	if f.GetListenerFunc == nil {
+		return nil, nil
+	}
+	return f.GetListenerFunc(id)
+}
+
+func (f *FakeSharedELBClient) ListListeners(req *elbmodel.ListListenersRequest) ([]elbmodel.ListenerResp, error) {
+	f.called("ListListeners")
+	if f.ListListenersFunc == nil {
+		return nil, nil
+	}
+	return f.ListListenersFunc(req)
+}
+
+func (f *FakeSharedELBClient) UpdateListener(id string, req *elbmodel.UpdateListenerReq) error {
+	f.called("UpdateListener")
+	if f.UpdateListenerFunc == nil {
+		return nil
+	}
+	return f.UpdateListenerFunc(id, req)
+}
+
+func (f *FakeSharedELBClient) DeleteListener(elbID string, listenerID string) error {
+	f.called("DeleteListener")
+	if f.DeleteListenerFunc == nil {
+		return nil
+	}
+	return f.DeleteListenerFunc(elbID, listenerID)
+}
+
+func (f *FakeSharedELBClient) CreatePool(req *elbmodel.CreatePoolReq) (*elbmodel.PoolResp, error) {
+	f.called("CreatePool")
+	if f.CreatePoolFunc == nil {
+		return nil, nil
+	}
+	return f.CreatePoolFunc(req)
+}
+
+func (f *FakeSharedELBClient) GetPool(id string) (*elbmodel.PoolResp, error) {
+	f.called("GetPool")
+	if f.GetPoolFunc == nil {
+		return nil, nil
+	}
+	return f.GetPoolFunc(id)
+}
+
+func (f *FakeSharedELBClient) ListPools(req *elbmodel.ListPoolsRequest) ([]elbmodel.PoolResp, error) {
+	f.called("ListPools")
+	if f.ListPoolsFunc == nil {
+		return nil, nil
+	}
+	return f.ListPoolsFunc(req)
+}
+
+func (f *FakeSharedELBClient) UpdatePool(id string, req *elbmodel.UpdatePoolReq) (*elbmodel.PoolResp, error) {
+	f.called("UpdatePool")
+	if f.UpdatePoolFunc == nil {
+		return nil, nil
+	}
+	return f.UpdatePoolFunc(id, req)
+}
+
+func (f *FakeSharedELBClient) DeletePool(id string) error {
+	f.called("DeletePool")
+	if f.DeletePoolFunc == nil {
+		return nil
+	}
+	return f.DeletePoolFunc(id)
+}
+
+func (f *FakeSharedELBClient) CreateHealthMonitor(req *elbmodel.CreateHealthmonitorReq) (*elbmodel.HealthmonitorResp, error) {
+	f.called("CreateHealthMonitor")
+	if f.CreateHealthMonitorFunc == nil {
+		return nil, nil
+	}
+	return f.CreateHealthMonitorFunc(req)
+}
+
+func (f *FakeSharedELBClient) GetHealthMonitor(id string) (*elbmodel.HealthmonitorResp, error) {
+	f.called("GetHealthMonitor")
+	if f.GetHealthMonitorFunc == nil {
+		return nil, nil
+	}
+	return f.GetHealthMonitorFunc(id)
+}
+
+func (f *FakeSharedELBClient) UpdateHealthMonitor(id string, req *elbmodel.UpdateHealthmonitorReq) error {
+	f.called("UpdateHealthMonitor")
+	if f.UpdateHealthMonitorFunc == nil {
+		return nil
+	}
+	return f.UpdateHealthMonitorFunc(id, req)
+}
+
+func (f *FakeSharedELBClient) DeleteHealthMonitor(id string) error {
+	f.called("DeleteHealthMonitor")
+	if f.DeleteHealthMonitorFunc == nil {
+		return nil
+	}
+	return f.DeleteHealthMonitorFunc(id)
+}
+
+func (f *FakeSharedELBClient) AddMember(poolID string, req *elbmodel.CreateMemberReq) (*elbmodel.MemberResp, error) {
+	f.called("AddMember")
+	if f.AddMemberFunc == nil {
+		return nil, nil
+	}
+	return f.AddMemberFunc(poolID, req)
+}
+
+func (f *FakeSharedELBClient) GetMember(id string) (*elbmodel.MemberResp, error) {
+	f.called("GetMember")
+	if f.GetMemberFunc == nil {
+		return nil, nil
+	}
+	return f.GetMemberFunc(id)
+}
+
+func (f *FakeSharedELBClient) ListMembers(req *elbmodel.ListMembersRequest) ([]elbmodel.MemberResp, error) {
+	f.called("ListMembers")
+	if f.ListMembersFunc == nil {
+		return nil, nil
+	}
+	return f.ListMembersFunc(req)
+}
+
+func (f *FakeSharedELBClient) UpdateMember(id string, req *elbmodel.UpdateMemberReq) (*elbmodel.MemberResp, error) {
+	f.called("UpdateMember")
+	if f.UpdateMemberFunc == nil {
+		return nil, nil
+	}
+	return f.UpdateMemberFunc(id, req)
+}
+
+func (f *FakeSharedELBClient) DeleteMember(poolID, memberID string) error {
+	f.called("DeleteMember")
+	if f.DeleteMemberFunc == nil {
+		return nil
+	}
+	return f.DeleteMemberFunc(poolID, memberID)
+}
+
+func (f *FakeSharedELBClient) DeleteAllPoolMembers(poolID string) error {
+	f.called("DeleteAllPoolMembers")
+	if f.DeleteAllPoolMembersFunc == nil {
+		return nil
+	}
+	return f.DeleteAllPoolMembersFunc(poolID)
+}
+
+// FakeDedicatedELBClient is the fake of the DedicatedELBClient.
+type FakeDedicatedELBClient struct {
+	fakeCalls
+
+	CreateInstanceFunc          func(opt *elbmodelv3.CreateLoadBalancerOption) (*elbmodelv3.LoadBalancer, error)
+	CreateInstanceCompletedFunc func(req *elbmodelv3.CreateLoadBalancerOption) (*elbmodelv3.LoadBalancer, error)
+	WaitStatusActiveFunc        func(id string) (*elbmodelv3.LoadBalancer, error)
+	GetInstanceFunc             func(id string) (*elbmodelv3.LoadBalancer, error)
+	ListInstancesFunc           func(req *elbmodelv3.ListLoadBalancersRequest) ([]elbmodelv3.LoadBalancer, error)
+	UpdateInstanceFunc          func(id, name, description string) (*elbmodelv3.LoadBalancer, error)
+	DeleteInstanceFunc          func(id string) error
+	CreateListenerFunc          func(req *elbmodelv3.CreateListenerOption) (*elbmodelv3.Listener, error)
+	GetListenerFunc             func(id string) (*elbmodelv3.Listener, error)
+	ListListenersFunc           func(req *elbmodelv3.ListListenersRequest) ([]elbmodelv3.Listener, error)
+	UpdateListenerFunc          func(id string, opt *elbmodelv3.UpdateListenerOption) error
+	DeleteListenerFunc          func(elbID string, listenerID string) error
+	CreatePoolFunc              func(req *elbmodelv3.CreatePoolOption) (*elbmodelv3.Pool, error)
+	GetPoolFunc                 func(id string) (*elbmodelv3.Pool, error)
+	ListPoolsFunc               func(req *elbmodelv3.ListPoolsRequest) ([]elbmodelv3.Pool, error)
+	UpdatePoolFunc              func(id string, req *elbmodelv3.UpdatePoolOption) (*elbmodelv3.Pool, error)
+	DeletePoolFunc              func(id string) error
+	CreateHealthMonitorFunc     func(req *elbmodelv3.CreateHealthMonitorOption) (*elbmodelv3.HealthMonitor, error)
+	GetHealthMonitorFunc        func(id string) (*elbmodelv3.HealthMonitor, error)
+	UpdateHealthMonitorFunc     func(id string, req *elbmodelv3.UpdateHealthMonitorOption) error
+	DeleteHealthMonitorFunc     func(id string) error
+	AddMemberFunc               func(poolID string, req *elbmodelv3.CreateMemberOption) (*elbmodelv3.Member, error)
+	GetMemberFunc               func(id string) (*elbmodelv3.Member, error)
+	ListMembersFunc             func(req *elbmodelv3.ListMembersRequest) ([]elbmodelv3.Member, error)
+	UpdateMemberFunc            func(id string, req *elbmodelv3.UpdateMemberOption) (*elbmodelv3.Member, error)
+	DeleteMemberFunc            func(poolID, memberID string) error
+	DeleteAllPoolMembersFunc    func(poolID string) error
+	ListAvailabilityZonesFunc   func() ([][]elbmodelv3.AvailabilityZone, error)
+	ListQuotaDetailsFunc        func() ([]elbmodelv3.QuotaInfo, error)
+}
+
+func (f *FakeDedicatedELBClient) CreateInstance(opt *elbmodelv3.CreateLoadBalancerOption) (*elbmodelv3.LoadBalancer, error) {
+	f.called("CreateInstance")
+	if f.CreateInstanceFunc == nil {
+		return nil, nil
+	}
+	return f.CreateInstanceFunc(opt)
+}
+
+func (f *FakeDedicatedELBClient) CreateInstanceCompleted(req *elbmodelv3.CreateLoadBalancerOption) (*elbmodelv3.LoadBalancer, error) {
+	f.called("CreateInstanceCompleted")
+	if f.CreateInstanceCompletedFunc == nil {
+		return nil, nil
+	}
+	return f.CreateInstanceCompletedFunc(req)
+}
+
+func (f *FakeDedicatedELBClient) WaitStatusActive(id string) (*elbmodelv3.LoadBalancer, error) {
+	f.called("WaitStatusActive")
+	if f.WaitStatusActiveFunc == nil {
+		return nil, nil
+	}
+	return f.WaitStatusActiveFunc(id)
+}
+
+func (f *FakeDedicatedELBClient) GetInstance(id string) (*elbmodelv3.LoadBalancer, error) {
+	f.called("GetInstance")
+	if f.GetInstanceFunc == nil {
+		return nil, nil
+	}
+	return f.GetInstanceFunc(id)
+}
+
+func (f *FakeDedicatedELBClient) ListInstances(req *elbmodelv3.ListLoadBalancersRequest) ([]elbmodelv3.LoadBalancer, error) {
+	f.called("ListInstances")
+	if f.ListInstancesFunc == nil {
+		return nil, nil
+	}
+	return f.ListInstancesFunc(req)
+}
+
+func (f *FakeDedicatedELBClient) UpdateInstance(id, name, description string) (*elbmodelv3.LoadBalancer, error) {
+	f.called("UpdateInstance")
+	if f.UpdateInstanceFunc == nil {
+		return nil, nil
+	}
+	return f.UpdateInstanceFunc(id, name, description)
+}
+
+func (f *FakeDedicatedELBClient) DeleteInstance(id string) error {
+	f.called("DeleteInstance")
+	if f.DeleteInstanceFunc == nil {
+		return nil
+	}
+	return f.DeleteInstanceFunc(id)
+}
+
+func (f *FakeDedicatedELBClient) CreateListener(req *elbmodelv3.CreateListenerOption) (*elbmodelv3.Listener, error) {
+	f.called("CreateListener")
+	if f.CreateListenerFunc == nil {
+		return nil, nil
+	}
+	return f.CreateListenerFunc(req)
+}
+
+func (f *FakeDedicatedELBClient) GetListener(id string) (*elbmodelv3.Listener, error) {
+	f.called("GetListener")
+	if f.GetListenerFunc == nil {
+		return nil, nil
+	}
+	return f.GetListenerFunc(id)
+}
+
+func (f *FakeDedicatedELBClient) ListListeners(req *elbmodelv3.ListListenersRequest) ([]elbmodelv3.Listener, error) {
+	f.called("ListListeners")
+	if f.ListListenersFunc == nil {
+		return nil, nil
+	}
+	return f.ListListenersFunc(req)
+}
+
+func (f *FakeDedicatedELBClient) UpdateListener(id string, opt *elbmodelv3.UpdateListenerOption) error {
+	f.called("UpdateListener")
+	if f.UpdateListenerFunc == nil {
+		return nil
+	}
+	return f.UpdateListenerFunc(id, opt)
+}
+
+func (f *FakeDedicatedELBClient) DeleteListener(elbID string, listenerID string) error {
+	f.called("DeleteListener")
+	if f.DeleteListenerFunc == nil {
+		return nil
+	}
+	return f.DeleteListenerFunc(elbID, listenerID)
+}
+
+func (f *FakeDedicatedELBClient) CreatePool(req *elbmodelv3.CreatePoolOption) (*elbmodelv3.Pool, error) {
+	f.called("CreatePool")
+	if f.CreatePoolFunc == nil {
+		return nil, nil
+	}
+	return f.CreatePoolFunc(req)
+}
+
+func (f *FakeDedicatedELBClient) GetPool(id string) (*elbmodelv3.Pool, error) {
+	f.called("GetPool")
+	if f.GetPoolFunc == nil {
+		return nil, nil
+	}
+	return f.GetPoolFunc(id)
+}
+
+func (f *FakeDedicatedELBClient) ListPools(req *elbmodelv3.ListPoolsRequest) ([]elbmodelv3.Pool, error) {
+	f.called("ListPools")
+	if f.ListPoolsFunc == nil {
+		return nil, nil
+	}
+	return f.ListPoolsFunc(req)
+}
+
+func (f *FakeDedicatedELBClient) UpdatePool(id string, req *elbmodelv3.UpdatePoolOption) (*elbmodelv3.Pool, error) {
+	f.called("UpdatePool")
+	if f.UpdatePoolFunc == nil {
+		return nil, nil
+	}
+	return f.UpdatePoolFunc(id, req)
+}
+
+func (f *FakeDedicatedELBClient) DeletePool(id string) error {
+	f.called("DeletePool")
+	if f.DeletePoolFunc == nil {
+		return nil
+	}
+	return f.DeletePoolFunc(id)
+}
+
+func (f *FakeDedicatedELBClient) CreateHealthMonitor(req *elbmodelv3.CreateHealthMonitorOption) (*elbmodelv3.HealthMonitor, error) {
+	f.called("CreateHealthMonitor")
+	if f.CreateHealthMonitorFunc == nil {
+		return nil, nil
+	}
+	return f.CreateHealthMonitorFunc(req)
+}
+
+func (f *FakeDedicatedELBClient) GetHealthMonitor(id string) (*elbmodelv3.HealthMonitor, error) {
+	f.called("GetHealthMonitor")
+	if f.GetHealthMonitorFunc == nil {
+		return nil, nil
+	}
+	return f.GetHealthMonitorFunc(id)
+}
+
+func (f *FakeDedicatedELBClient) UpdateHealthMonitor(id string, req *elbmodelv3.UpdateHealthMonitorOption) error {
+	f.called("UpdateHealthMonitor")
+	if f.UpdateHealthMonitorFunc == nil {
+		return nil
+	}
+	return f.UpdateHealthMonitorFunc(id, req)
+}
+
+func (f *FakeDedicatedELBClient) DeleteHealthMonitor(id string) error {
+	f.called("DeleteHealthMonitor")
+	if f.DeleteHealthMonitorFunc == nil {
+		return nil
+	}
+	return f.DeleteHealthMonitorFunc(id)
+}
+
+func (f *FakeDedicatedELBClient) AddMember(poolID string, req *elbmodelv3.CreateMemberOption) (*elbmodelv3.Member, error) {
+	f.called("AddMember")
+	if f.AddMemberFunc == nil {
+		return nil, nil
+	}
+	return f.AddMemberFunc(poolID, req)
+}
+
+func (f *FakeDedicatedELBClient) GetMember(id string) (*elbmodelv3.Member, error) {
+	f.called("GetMember")
+	if f.GetMemberFunc == nil {
+		return nil, nil
+	}
+	return f.GetMemberFunc(id)
+}
+
+func (f *FakeDedicatedELBClient) ListMembers(req *elbmodelv3.ListMembersRequest) ([]elbmodelv3.Member, error) {
+	f.called("ListMembers")
+	if f.ListMembersFunc == nil {
+		return nil, nil
+	}
+	return f.ListMembersFunc(req)
+}
+
+func (f *FakeDedicatedELBClient) UpdateMember(id string, req *elbmodelv3.UpdateMemberOption) (*elbmodelv3.Member, error) {
+	f.called("UpdateMember")
+	if f.UpdateMemberFunc == nil {
+		return nil, nil
+	}
+	return f.UpdateMemberFunc(id, req)
+}
+
+func (f *FakeDedicatedELBClient) DeleteMember(poolID, memberID string) error {
+	f.called("DeleteMember")
+	if f.DeleteMemberFunc == nil {
+		return nil
+	}
+	return f.DeleteMemberFunc(poolID, memberID)
+}
+
+func (f *FakeDedicatedELBClient) DeleteAllPoolMembers(poolID string) error {
+	f.called("DeleteAllPoolMembers")
+	if f.DeleteAllPoolMembersFunc == nil {
+		return nil
+	}
+	return f.DeleteAllPoolMembersFunc(poolID)
+}
+
+func (f *FakeDedicatedELBClient) ListAvailabilityZones() ([][]elbmodelv3.AvailabilityZone, error) {
+	f.called("ListAvailabilityZones")
+	if f.ListAvailabilityZonesFunc == nil {
+		return nil, nil
+	}
+	return f.ListAvailabilityZonesFunc()
+}
+
+func (f *FakeDedicatedELBClient) ListQuotaDetails() ([]elbmodelv3.QuotaInfo, error) {
+	f.called("ListQuotaDetails")
+	if f.ListQuotaDetailsFunc == nil {
+		return nil, nil
+	}
+	return f.ListQuotaDetailsFunc()
+}
+
+// FakeECSClient is the fake of the ECSClient.
+type FakeECSClient struct {
+	fakeCalls
+
+	GetFunc            func(id string) (*ecsmodel.ServerDetail, error)
+	GetByNameFunc      func(name string) (*ecsmodel.ServerDetail, error)
+	GetByIPFunc        func(ip string) (*ecsmodel.ServerDetail, error)
+	ListFunc           func(req *ecsmodel.ListServersDetailsRequest) (*ecsmodel.ListServersDetailsResponse, error)
+	ListAllFunc        func() ([]ecsmodel.ServerDetail, error)
+	ListInterfacesFunc func(req *ecsmodel.ListServerInterfacesRequest) ([]ecsmodel.InterfaceAttachment, error)
+	BuildAddressesFunc func(server *ecsmodel.ServerDetail, interfaces []ecsmodel.InterfaceAttachment, networkingOpts *config.NetworkingOptions) ([]v1.NodeAddress, error)
+}
+
+func (f *FakeECSClient) Get(id string) (*ecsmodel.ServerDetail, error) {
+	f.called("Get")
+	if f.GetFunc == nil {
+		return nil, nil
+	}
+	return f.GetFunc(id)
+}
+
+func (f *FakeECSClient) GetByName(name string) (*ecsmodel.ServerDetail, error) {
+	f.called("GetByName")
+	if f.GetByNameFunc == nil {
+		return nil, nil
+	}
+	return f.GetByNameFunc(name)
+}
+
+func (f *FakeECSClient) GetByIP(ip string) (*ecsmodel.ServerDetail, error) {
+	f.called("GetByIP")
+	if f.GetByIPFunc == nil {
+		return nil, nil
+	}
+	return f.GetByIPFunc(ip)
+}
+
+func (f *FakeECSClient) List(req *ecsmodel.ListServersDetailsRequest) (*ecsmodel.ListServersDetailsResponse, error) {
+	f.called("List")
+	if f.ListFunc == nil {
+		return nil, nil
+	}
+	return f.ListFunc(req)
+}
+
+func (f *FakeECSClient) ListAll() ([]ecsmodel.ServerDetail, error) {
+	f.called("ListAll")
+	if f.ListAllFunc == nil {
+		return nil, nil
+	}
+	return f.ListAllFunc()
+}
+
+func (f *FakeECSClient) ListInterfaces(req *ecsmodel.ListServerInterfacesRequest) ([]ecsmodel.InterfaceAttachment, error) {
+	f.called("ListInterfaces")
+	if f.ListInterfacesFunc == nil {
+		return nil, nil
+	}
+	return f.ListInterfacesFunc(req)
+}
+
+func (f *FakeECSClient) BuildAddresses(server *ecsmodel.ServerDetail, interfaces []ecsmodel.InterfaceAttachment,
+	networkingOpts *config.NetworkingOptions) ([]v1.NodeAddress, error) {
+	f.called("BuildAddresses")
+	if f.BuildAddressesFunc == nil {
+		return (&wrapper.EcsClient{}).BuildAddresses(server, interfaces, networkingOpts)
+	}
+	return f.BuildAddressesFunc(server, interfaces, networkingOpts)
+}
+
+// FakeVPCClient is the fake of the VPCClient.
+type FakeVPCClient struct {
+	fakeCalls
+
+	ListRouteTablesFunc         func(req *vpcmodel.ListRouteTablesRequest) ([]vpcmodel.RouteTableListResp, error)
+	GetRouteTableFunc           func(id string) (*vpcmodel.RouteTableResp, error)
+	AddRoutesFunc               func(routeTableID string, routes []vpcmodel.RouteTableRoute) error
+	ModifyRoutesFunc            func(routeTableID string, routes []vpcmodel.RouteTableRoute) error
+	DeleteRoutesFunc            func(routeTableID string, routes []vpcmodel.RouteTableRoute) error
+	ListSubnetsFunc             func(req *vpcmodel.ListSubnetsRequest) ([]vpcmodel.Subnet, error)
+	ListPortsFunc               func(req *vpcmodel.ListPortsRequest) ([]vpcmodel.Port, error)
+	GetPortFunc                 func(id string) (*vpcmodel.Port, error)
+	UpdatePortFunc              func(id string, opts *vpcmodel.UpdatePortOption) error
+	ListSecurityGroupRulesFunc  func(req *vpcmodel.ListSecurityGroupRulesRequest) ([]vpcmodel.SecurityGroupRule, error)
+	CreateSecurityGroupRuleFunc func(opts *vpcmodel.CreateSecurityGroupRuleOption) (*vpcmodel.SecurityGroupRule, error)
+	DeleteSecurityGroupRuleFunc func(id string) error
+}
+
+func (f *FakeVPCClient) ListRouteTables(req *vpcmodel.ListRouteTablesRequest) ([]vpcmodel.RouteTableListResp, error) {
+	f.called("ListRouteTables")
+	if f.ListRouteTablesFunc == nil {
+		return nil, nil
+	}
+	return f.ListRouteTablesFunc(req)
+}
+
+func (f *FakeVPCClient) GetRouteTable(id string) (*vpcmodel.RouteTableResp, error) {
+	f.called("GetRouteTable")
+	if f.GetRouteTableFunc == nil {
+		return nil, nil
+	}
+	return f.GetRouteTableFunc(id)
+}
+
+func (f *FakeVPCClient) AddRoutes(routeTableID string, routes []vpcmodel.RouteTableRoute) error {
+	f.called("AddRoutes")
+	if f.AddRoutesFunc == nil {
+		return nil
+	}
+	return f.AddRoutesFunc(routeTableID, routes)
+}
+
+func (f *FakeVPCClient) ModifyRoutes(routeTableID string, routes []vpcmodel.RouteTableRoute) error {
+	f.called("ModifyRoutes")
+	if f.ModifyRoutesFunc == nil {
+		return nil
+	}
+	return f.ModifyRoutesFunc(routeTableID, routes)
+}
+
+func (f *FakeVPCClient) DeleteRoutes(routeTableID string, routes []vpcmodel.RouteTableRoute) error {
+	f.called("DeleteRoutes")
+	if f.DeleteRoutesFunc == nil {
+		return nil
+	}
+	return f.DeleteRoutesFunc(routeTableID, routes)
+}
+
+func (f *FakeVPCClient) ListSubnets(req *vpcmodel.ListSubnetsRequest) ([]vpcmodel.Subnet, error) {
+	f.called("ListSubnets")
+	if f.ListSubnetsFunc == nil {
+		return nil, nil
+	}
+	return f.ListSubnetsFunc(req)
+}
+
+func (f *FakeVPCClient) ListPorts(req *vpcmodel.ListPortsRequest) ([]vpcmodel.Port, error) {
+	f.called("ListPorts")
+	if f.ListPortsFunc == nil {
+		return nil, nil
+	}
+	return f.ListPortsFunc(req)
+}
+
+func (f *FakeVPCClient) GetPort(id string) (*vpcmodel.Port, error) {
+	f.called("GetPort")
+	if f.GetPortFunc == nil {
+		return nil, nil
+	}
+	return f.GetPortFunc(id)
+}
+
+func (f *FakeVPCClient) UpdatePort(id string, opts *vpcmodel.UpdatePortOption) error {
+	f.called("UpdatePort")
+	if f.UpdatePortFunc == nil {
+		return nil
+	}
+	return f.UpdatePortFunc(id, opts)
+}
+
+func (f *FakeVPCClient) ListSecurityGroupRules(req *vpcmodel.ListSecurityGroupRulesRequest) ([]vpcmodel.SecurityGroupRule, error) {
+	f.called("ListSecurityGroupRules")
+	if f.ListSecurityGroupRulesFunc == nil {
+		return nil, nil
+	}
+	return f.ListSecurityGroupRulesFunc(req)
+}
+
+func (f *FakeVPCClient) CreateSecurityGroupRule(opts *vpcmodel.CreateSecurityGroupRuleOption) (*vpcmodel.SecurityGroupRule, error) {
+	f.called("CreateSecurityGroupRule")
+	if f.CreateSecurityGroupRuleFunc == nil {
+		return nil, nil
+	}
+	return f.CreateSecurityGroupRuleFunc(opts)
+}
+
+func (f *FakeVPCClient) DeleteSecurityGroupRule(id string) error {
+	f.called("DeleteSecurityGroupRule")
+	if f.DeleteSecurityGroupRuleFunc == nil {
+		return nil
+	}
+	return f.DeleteSecurityGroupRuleFunc(id)
+}
+
+var (
+	_ elbapi.ClassicELBClient   = &FakeClassicELBClient{}
+	_ elbapi.SharedELBClient    = &FakeSharedELBClient{}
+	_ albapi.DedicatedELBClient = &FakeDedicatedELBClient{}
+	_ ecsapi.ECSClient          = &FakeECSClient{}
+	_ VPCClient                 = &FakeVPCClient{}
+)
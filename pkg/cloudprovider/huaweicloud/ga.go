@@ -0,0 +1,179 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	eipmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/eip/v2/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/common"
+)
+
+// ElbGAEndpointGroupID is the ID of the endpoint group of a Global Accelerator, the EIPs of the load balancer
+// are added to it as the endpoints, and removed from it before the load balancer is deleted.
+const ElbGAEndpointGroupID = "kubernetes.io/elb.ga-endpoint-group-id"
+
+// loadBalancerEIPs returns the public addresses of the EIPs of the ingress keyed by the EIP ID.
+// The address of an ingress is the public address of the EIP, or the VIP the EIP is bound to.
+func (b Basic) loadBalancerEIPs(lbStatus *v1.LoadBalancerStatus) (map[string]string, error) {
+	eips := make(map[string]string)
+	if lbStatus == nil {
+		return eips, nil
+	}
+	for _, ingress := range lbStatus.Ingress {
+		if ingress.IP == "" {
+			continue
+		}
+		ips, err := b.eipClient.List(&eipmodel.ListPublicipsRequest{PublicIpAddress: &[]string{ingress.IP}})
+		if err == nil && len(ips) == 0 {
+			ips, err = b.eipClient.List(&eipmodel.ListPublicipsRequest{PrivateIpAddress: &[]string{ingress.IP}})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the EIP of address %s: %w", ingress.IP, err)
+		}
+		for _, ip := range ips {
+			eips[pointer.StringDeref(ip.Id, "")] = pointer.StringDeref(ip.PublicIpAddress, "")
+		}
+	}
+	return eips, nil
+}
+
+// checkGADomain returns a FailedPrecondition error if domain-id is not configured, the requests to the Global
+// Accelerator are signed with the account.
+func (b Basic) checkGADomain(service *v1.Service) error {
+	if b.cloudConfig.AuthOpts.DomainID != "" {
+		return nil
+	}
+	return status.Errorf(codes.FailedPrecondition, "annotation %s of service %s/%s requires domain-id in the "+
+		"[Global] section of the cloud config", ElbGAEndpointGroupID, service.Namespace, service.Name)
+}
+
+// listGAEndpoints returns the IDs of the endpoints of the endpoint group keyed by the ID of the resource.
+func (b Basic) listGAEndpoints(groupID string) (map[string]string, error) {
+	endpoints, err := b.gaClient.ListEndpoints(groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the endpoints of the endpoint group %s: %w", groupID, err)
+	}
+	existing := make(map[string]string, len(endpoints))
+	for _, ep := range endpoints {
+		existing[pointer.StringDeref(ep.ResourceId, "")] = pointer.StringDeref(ep.Id, "")
+	}
+	return existing, nil
+}
+
+// ensureGAEndpoints adds the EIPs of the load balancer to the endpoint group of the ElbGAEndpointGroupID annotation,
+// and removes the EIPs the service had before from it, such as the ones of a replaced load balancer.
+// The other endpoints of the group are kept. Nothing is done without the annotation.
+func (b Basic) ensureGAEndpoints(service *v1.Service, lbStatus *v1.LoadBalancerStatus) error {
+	groupID := strings.TrimSpace(getStringFromSvsAnnotation(service, ElbGAEndpointGroupID, ""))
+	if groupID == "" {
+		return nil
+	}
+	if err := b.checkGADomain(service); err != nil {
+		return err
+	}
+	desired, err := b.loadBalancerEIPs(lbStatus)
+	if err != nil {
+		return err
+	}
+	if len(desired) == 0 {
+		return status.Errorf(codes.FailedPrecondition, "the load balancer of service %s/%s has no EIP to add to "+
+			"the endpoint group %s of annotation %s", service.Namespace, service.Name, groupID, ElbGAEndpointGroupID)
+	}
+	previous, err := b.loadBalancerEIPs(&service.Status.LoadBalancer)
+	if err != nil {
+		return err
+	}
+
+	existing, err := b.listGAEndpoints(groupID)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(desired))
+	for id := range desired {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if _, ok := existing[id]; ok {
+			continue
+		}
+		klog.Infof("Add the EIP %s(%s) of service %s/%s to the endpoint group %s", id, desired[id],
+			service.Namespace, service.Name, groupID)
+		if _, err = b.gaClient.CreateEndpoint(groupID, id, desired[id]); err != nil {
+			return fmt.Errorf("failed to add the EIP %s to the endpoint group %s: %w", id, groupID, err)
+		}
+		b.sendEvent("CreatedGAEndpoint", fmt.Sprintf("Added the EIP %s(%s) to the endpoint group %s",
+			id, desired[id], groupID), service)
+	}
+
+	for id := range desired {
+		delete(previous, id)
+	}
+	return b.deleteGAEndpoints(service, groupID, previous, existing)
+}
+
+// deleteGAEndpoints removes the endpoints of the EIPs from the endpoint group, the existing endpoints are keyed by
+// the EIP ID.
+func (b Basic) deleteGAEndpoints(service *v1.Service, groupID string, eips, existing map[string]string) error {
+	for id := range eips {
+		endpointID, ok := existing[id]
+		if !ok {
+			continue
+		}
+		klog.Infof("Remove the EIP %s of service %s/%s from the endpoint group %s", id, service.Namespace,
+			service.Name, groupID)
+		if err := b.gaClient.DeleteEndpoint(groupID, endpointID); err != nil && !common.IsNotFound(err) {
+			return fmt.Errorf("failed to remove the EIP %s from the endpoint group %s: %w", id, groupID, err)
+		}
+	}
+	return nil
+}
+
+// ensureGAEndpointsDeleted removes the EIPs of the service from the endpoint group of the ElbGAEndpointGroupID
+// annotation, it is called before the load balancer is deleted, which may release the EIPs.
+func (b Basic) ensureGAEndpointsDeleted(service *v1.Service) error {
+	groupID := strings.TrimSpace(getStringFromSvsAnnotation(service, ElbGAEndpointGroupID, ""))
+	if groupID == "" {
+		return nil
+	}
+	if err := b.checkGADomain(service); err != nil {
+		return err
+	}
+	eips, err := b.loadBalancerEIPs(&service.Status.LoadBalancer)
+	if err != nil || len(eips) == 0 {
+		return err
+	}
+	existing, err := b.listGAEndpoints(groupID)
+	if common.IsNotFound(err) {
+		klog.Warningf("the endpoint group %s of service %s/%s is deleted already", groupID, service.Namespace,
+			service.Name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return b.deleteGAEndpoints(service, groupID, eips, existing)
+}
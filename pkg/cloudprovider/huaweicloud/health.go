@@ -0,0 +1,173 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apiserver/pkg/server/healthz"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/common"
+)
+
+// healthChecker reports the informer sync state, and the credentials and the endpoints of the cloud
+// probed periodically. The checks pass until the informers are started and the cloud is probed,
+// as on the replicas which are not the leader.
+type healthChecker struct {
+	mu        sync.RWMutex
+	informers map[string]cache.InformerSynced
+	// credentialErr is the error of the credentials rejected by the cloud, or failed to be read.
+	credentialErr error
+	// endpointErrs holds the unreachable endpoints of the last probe, keyed by "<region>/<catalog name>".
+	endpointErrs map[string]error
+	// providerErrs holds the providers failed to be initialized, see CloudProvider.disableProvider.
+	providerErrs map[string]error
+}
+
+func newHealthChecker() *healthChecker {
+	return &healthChecker{
+		informers:    make(map[string]cache.InformerSynced),
+		providerErrs: make(map[string]error),
+	}
+}
+
+// addInformer adds the informer to the informer-sync check, it is a no-op on a nil checker.
+func (c *healthChecker) addInformer(name string, synced cache.InformerSynced) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.informers[name] = synced
+}
+
+// setCredentialError fails the credentials check until the next probe.
+func (c *healthChecker) setCredentialError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.credentialErr = err
+}
+
+// setProviderError fails the providers check, the provider is not retried until the restart.
+func (c *healthChecker) setProviderError(name string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.providerErrs[name] = err
+}
+
+// probe sends a cheap authenticated query to each endpoint of the regions, an endpoint rejecting the credentials
+// fails the credentials check, and an unreachable endpoint fails the cloud-endpoints check.
+func (c *healthChecker) probe(regions map[string]Basic) {
+	var credentialErr error
+	endpointErrs := make(map[string]error)
+	for region, b := range regions {
+		for catalog, check := range endpointChecks(b) {
+			if !b.cloudConfig.AuthOpts.ServiceSupported(catalog) {
+				continue
+			}
+			err := check()
+			switch code := common.GetStatusCode(err); {
+			case err == nil:
+			case code == http.StatusUnauthorized || code == http.StatusForbidden:
+				credentialErr = fmt.Errorf("the credentials are rejected by the %s endpoint of region %s: %s",
+					catalog, region, err)
+			case common.IsUnreachable(err):
+				endpointErrs[region+"/"+catalog] = err
+			default:
+				klog.V(4).Infof("the health probe of the %s endpoint of region %s failed, ignored: %s",
+					catalog, region, err)
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.credentialErr = credentialErr
+	c.endpointErrs = endpointErrs
+}
+
+func (c *healthChecker) checkInformers(_ *http.Request) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var names []string
+	for name, synced := range c.informers {
+		if !synced() {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		sort.Strings(names)
+		return fmt.Errorf("the informers are not synced: %s", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+func (c *healthChecker) checkCredentials(_ *http.Request) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.credentialErr
+}
+
+func (c *healthChecker) checkEndpoints(_ *http.Request) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var msgs []string
+	for endpoint, err := range c.endpointErrs {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", endpoint, err))
+	}
+	if len(msgs) > 0 {
+		sort.Strings(msgs)
+		return fmt.Errorf("the endpoints are unreachable: %s", strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+func (c *healthChecker) checkProviders(_ *http.Request) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var msgs []string
+	for name, err := range c.providerErrs {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", name, err))
+	}
+	if len(msgs) > 0 {
+		sort.Strings(msgs)
+		return fmt.Errorf("the providers are disabled: %s", strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// install installs /healthz and /readyz with all the checks, and /livez with the informer-sync check,
+// the unreachable cloud does not fail the liveness, because restarting does not help.
+func (c *healthChecker) install(mux *http.ServeMux) {
+	informerSync := healthz.NamedCheck("informer-sync", c.checkInformers)
+	checks := []healthz.HealthChecker{
+		informerSync,
+		healthz.NamedCheck("credentials", c.checkCredentials),
+		healthz.NamedCheck("cloud-endpoints", c.checkEndpoints),
+		healthz.NamedCheck("providers", c.checkProviders),
+	}
+
+	healthz.InstallHandler(mux, checks...)
+	healthz.InstallReadyzHandler(mux, checks...)
+	healthz.InstallLivezHandler(mux, informerSync)
+}
@@ -18,34 +18,49 @@ package huaweicloud
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"os"
+	"net/http"
+	"sync"
 	"time"
 
+	ccemodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/cce/v3/model"
 	ecsmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ecs/v2/model"
+	eipmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/eip/v2/model"
+	elbmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/elb/v3/model"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes/scheme"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
-	"k8s.io/client-go/rest"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
-	"k8s.io/client-go/tools/leaderelection"
-	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/cloud-provider"
 	"k8s.io/cloud-provider/options"
 	"k8s.io/klog/v2"
-
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/audit"
+	albapi "sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/alb"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/auth"
+	lbconfig "sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/config"
+	ecsapi "sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/ecs"
+	elbapi "sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/elb"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/throttle"
 	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/wrapper"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/common"
 	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/metrics"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/tracing"
 	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/utils/mutexkv"
 )
 
@@ -53,8 +68,14 @@ import (
 const (
 	ProviderName = "huaweicloud"
 
-	ElbClass = "kubernetes.io/elb.class"
-	ElbID    = "kubernetes.io/elb.id"
+	// clientName is the name of the Kubernetes clients, used as the user agent.
+	clientName = "cloud-controller-manager"
+
+	ElbClass  = "kubernetes.io/elb.class"
+	ElbID     = "kubernetes.io/elb.id"
+	ElbRegion = "kubernetes.io/elb.region"
+	// ElbProject is the name of the [Project] section in the cloud config to create the load balancer in.
+	ElbProject = "kubernetes.io/elb.project"
 
 	ElbSubnetID          = "kubernetes.io/elb.subnet-id"
 	ElbEipID             = "kubernetes.io/elb.eip-id"
@@ -96,44 +117,74 @@ const (
 	ProtocolHTTP            = "HTTP"
 	ProtocolHTTPS           = "HTTPS"
 	ProtocolTerminatedHTTPS = "TERMINATED_HTTPS"
-)
 
-type ELBProtocol string
-type ELBAlgorithm string
+	clusterEndpointInternal = "Internal"
+
+	// credentialRetryInterval is the interval to re-read the credentials from a malformed secret at startup.
+	credentialRetryInterval = 30 * time.Second
+)
 
 type Basic struct {
 	cloudControllerManagerOpts *options.CloudControllerManagerOptions
 	cloudConfig                *config.CloudConfig
 	loadBalancerConfig         *config.LoadbalancerConfig //nolint: unused
 
-	loadbalancerOpts *config.LoadBalancerOptions
-	networkingOpts   *config.NetworkingOptions
-	metadataOpts     *config.MetadataOptions
+	// options holds the options of the load balancers in use, it is shared by the copies of the Basic,
+	// including the ones of the additional regions and projects.
+	options *lbconfig.Store
+	// pinned is the snapshot of the options the reconcile is bound to by withContext, nil outside the reconciles.
+	pinned *lbconfig.Options
 
-	sharedELBClient    *wrapper.SharedLoadBalanceClient
-	dedicatedELBClient *wrapper.DedicatedLoadBalanceClient
+	sharedELBClient    elbapi.SharedELBClient
+	dedicatedELBClient albapi.DedicatedELBClient
 	eipClient          *wrapper.EIpClient
-	ecsClient          *wrapper.EcsClient
+	ecsClient          ecsapi.ECSClient
+	vpcClient          VPCClient
+	erClient           *wrapper.ErClient
+	dnsClient          *wrapper.DnsClient
+	gaClient           *wrapper.GaClient
+	cceClient          *wrapper.CceClient
+	ecsCache           *ecsapi.InstanceCache
+	subnetCache        *subnetCache
+	azCache            *albapi.AZCache
+
+	// region is the name of the additional region, it is empty for the region in the Global section.
+	region string
+	// regions holds the additional regions declared in the cloud config, keyed by region name.
+	regions map[string]Basic
+
+	*kubeClients
+}
 
-	restConfig    *rest.Config
-	kubeClient    *corev1.CoreV1Client
+// kubeClients holds the Kubernetes clients built in Initialize, it is shared by the copies of the Basic.
+type kubeClients struct {
+	kubeClient    corev1.CoreV1Interface
 	eventRecorder record.EventRecorder
+	// nodes is the cache of the nodes the members of the load balancers are built from.
+	nodes *nodeCache
 }
 
+// listPodsBySelector lists the pods of the selector, the list is memoized in the reconcile,
+// which lists the pods once instead of once for each port of the service.
 func (b Basic) listPodsBySelector(ctx context.Context, namespace string, selectors map[string]string) (*v1.PodList, error) {
 	labelSelector := labels.SelectorFromSet(selectors)
 	opts := metav1.ListOptions{LabelSelector: labelSelector.String()}
-	return b.kubeClient.Pods(namespace).List(ctx, opts)
+	return memoize(ctx, "pods/"+namespace+"/"+opts.LabelSelector, func() (*v1.PodList, error) {
+		return b.kubeClient.Pods(namespace).List(ctx, opts)
+	})
 }
 
 func (b Basic) sendEvent(reason, msg string, service *v1.Service) {
 	b.eventRecorder.Event(service, v1.EventTypeNormal, reason, msg)
 }
 
+// getSubnetID returns the subnet to create the load balancer in, it is checked against the VPC of the cloud config.
 func (b Basic) getSubnetID(service *v1.Service, node *v1.Node) (string, error) {
-	subnetID := getStringFromSvsAnnotation(service, ElbSubnetID, b.cloudConfig.VpcOpts.SubnetID)
-	if subnetID != "" {
-		return subnetID, nil
+	if subnetID := getStringFromSvsAnnotation(service, ElbSubnetID, ""); subnetID != "" {
+		return subnetID, b.checkSubnet(service, subnetID, fmt.Sprintf("annotation %s", ElbSubnetID))
+	}
+	if subnetID := b.cloudConfig.VpcOpts.SubnetID; subnetID != "" {
+		return subnetID, b.checkSubnet(service, subnetID, "subnet-id of the cloud config")
 	}
 
 	subnetID, err := b.getNodeSubnetID(node)
@@ -144,13 +195,24 @@ func (b Basic) getSubnetID(service *v1.Service, node *v1.Node) (string, error) {
 	return subnetID, nil
 }
 
+// getNodeSubnetID returns the subnet of the node address, it is resolved from the ECS interfaces and cached.
+// The subnet is memoized in the reconcile, so a reconcile keeps using the same subnet of the node.
 func (b Basic) getNodeSubnetID(node *v1.Node) (string, error) {
 	ipAddress, err := getNodeAddress(node)
 	if err != nil {
 		return "", err
 	}
 
-	instance, err := b.ecsClient.GetByName(node.Name)
+	return memoize(b.context(), "subnet/"+ipAddress, func() (string, error) {
+		return b.subnetCache.get(ipAddress, func() (string, error) {
+			return b.resolveNodeSubnetID(node, ipAddress)
+		})
+	})
+}
+
+// resolveNodeSubnetID resolves the subnet of the node address from the ECS interfaces of the node.
+func (b Basic) resolveNodeSubnetID(node *v1.Node, ipAddress string) (string, error) {
+	instance, err := b.getInstanceByNode(node)
 	if err != nil {
 		return "", err
 	}
@@ -171,19 +233,246 @@ func (b Basic) getNodeSubnetID(node *v1.Node) (string, error) {
 	return "", fmt.Errorf("failed to get node subnet ID")
 }
 
+// invalidateSubnet invalidates the cached subnet if the load balancer failed to be created in it,
+// such as the subnet is deleted or moved to another VPC.
+func (b Basic) invalidateSubnet(subnetID string, err error) {
+	code := common.GetStatusCode(err)
+	if code == 0 {
+		code = common.GetStatusCode(errors.Unwrap(err))
+	}
+	if code == http.StatusNotFound || code == http.StatusBadRequest {
+		b.subnetCache.invalidate(subnetID)
+	}
+}
+
+// getInstanceByNode queries the ECS of the node by name, and falls back to its private IP addresses
+// if the node name differs from the ECS name.
+func (b Basic) getInstanceByNode(node *v1.Node) (*ecsmodel.ServerDetail, error) {
+	instance, err := b.ecsCache.GetByName(node.Name)
+	if err == nil || !common.IsNotFound(err) {
+		return instance, err
+	}
+
+	for _, addr := range node.Status.Addresses {
+		if addr.Type != v1.NodeInternalIP {
+			continue
+		}
+		klog.V(4).Infof("ECS named %s not found, query ECS by private IP: %s", node.Name, addr.Address)
+		ins, e := b.ecsClient.GetByIP(addr.Address)
+		if e == nil {
+			return ins, nil
+		}
+		if !common.IsNotFound(e) {
+			return nil, e
+		}
+	}
+	return nil, err
+}
+
+// getInstanceByNodeName queries the ECS of the node, see getInstanceByNode.
+func (b Basic) getInstanceByNodeName(ctx context.Context, name string) (*ecsmodel.ServerDetail, error) {
+	instance, err := b.ecsCache.GetByName(name)
+	if err == nil || !common.IsNotFound(err) {
+		return instance, err
+	}
+
+	node, e := b.kubeClient.Nodes().Get(ctx, name, metav1.GetOptions{})
+	if e != nil {
+		klog.Warningf("failed to query node %s: %s", name, e)
+		return nil, err
+	}
+	return b.getInstanceByNode(node)
+}
+
+// forRegion returns the Basic bound to the region, an empty name indicates the region in the Global section.
+func (b Basic) forRegion(region string) (Basic, error) {
+	if region == "" || region == b.cloudConfig.AuthOpts.Region {
+		return b, nil
+	}
+	rb, ok := b.regions[region]
+	if !ok {
+		return Basic{}, status.Errorf(codes.InvalidArgument, "region %s is not configured in the cloud config", region)
+	}
+	return rb, nil
+}
+
+// forProviderID returns the Basic bound to the region of the providerID and to the context, and the instance ID.
+func (b Basic) forProviderID(ctx context.Context, providerID string) (Basic, string, error) {
+	region, instanceID, err := parseProviderID(providerID)
+	if err != nil {
+		return Basic{}, "", err
+	}
+	rb, err := b.forRegion(region)
+	if err != nil {
+		return Basic{}, "", err
+	}
+	return rb.withContext(ctx), instanceID, nil
+}
+
+// providerID returns the provider ID of the instance, the region is included for the additional regions.
+func (b Basic) providerID(instanceID string) string {
+	if b.region == "" {
+		return instanceID
+	}
+	return fmt.Sprintf("%s://%s/%s", ProviderName, b.region, instanceID)
+}
+
+// findInstanceByNode queries the ECS of the node in the Global region, and then in the additional regions.
+func (b Basic) findInstanceByNode(node *v1.Node) (Basic, *ecsmodel.ServerDetail, error) {
+	instance, err := b.getInstanceByNode(node)
+	if err == nil || !common.IsNotFound(err) {
+		return b, instance, err
+	}
+
+	for _, rb := range b.regions {
+		ins, e := rb.getInstanceByNode(node)
+		if e == nil {
+			return rb, ins, nil
+		}
+		if !common.IsNotFound(e) {
+			return Basic{}, nil, e
+		}
+	}
+	return Basic{}, nil, err
+}
+
+// findInstanceByNodeName queries the ECS of the node in the Global region, and then in the additional regions.
+func (b Basic) findInstanceByNodeName(ctx context.Context, name string) (Basic, *ecsmodel.ServerDetail, error) {
+	instance, err := b.getInstanceByNodeName(ctx, name)
+	if err == nil || !common.IsNotFound(err) {
+		return b, instance, err
+	}
+
+	for _, rb := range b.regions {
+		ins, e := rb.getInstanceByNodeName(ctx, name)
+		if e == nil {
+			return rb, ins, nil
+		}
+		if !common.IsNotFound(e) {
+			return Basic{}, nil, e
+		}
+	}
+	return Basic{}, nil, err
+}
+
+// newRegionBasic returns a copy of the Basic with the clients bound to the region configuration.
+func newRegionBasic(b Basic, region string, cloudConfig *config.CloudConfig) Basic {
+	ecsClient := &wrapper.EcsClient{AuthOpts: &cloudConfig.AuthOpts}
+
+	b.cloudConfig = cloudConfig
+	b.sharedELBClient = &wrapper.SharedLoadBalanceClient{AuthOpts: &cloudConfig.AuthOpts}
+	b.dedicatedELBClient = &wrapper.DedicatedLoadBalanceClient{AuthOpts: &cloudConfig.AuthOpts}
+	b.eipClient = &wrapper.EIpClient{AuthOpts: &cloudConfig.AuthOpts}
+	b.ecsClient = ecsClient
+	b.vpcClient = &wrapper.VpcClient{AuthOpts: &cloudConfig.AuthOpts}
+	b.erClient = &wrapper.ErClient{AuthOpts: &cloudConfig.AuthOpts}
+	b.dnsClient = &wrapper.DnsClient{AuthOpts: &cloudConfig.AuthOpts}
+	b.gaClient = &wrapper.GaClient{AuthOpts: &cloudConfig.AuthOpts}
+	b.cceClient = &wrapper.CceClient{AuthOpts: &cloudConfig.AuthOpts}
+	b.ecsCache = ecsapi.NewInstanceCache(ecsClient, ecsapi.DefaultInstanceCacheTTL)
+	b.subnetCache = newSubnetCache(defaultSubnetCacheTTL)
+	b.azCache = albapi.NewAZCache(b.dedicatedELBClient, b.loadbalancerOpts)
+	b.region = region
+	b.regions = nil
+	return b
+}
+
+// newProjectBasic returns a copy of the Basic with the load balancer and EIP clients bound to the additional project.
+// The ECS and VPC clients are kept, because the nodes are in the project of the Global section.
+func newProjectBasic(b Basic, cloudConfig *config.CloudConfig) Basic {
+	b.cloudConfig = cloudConfig
+	b.sharedELBClient = &wrapper.SharedLoadBalanceClient{AuthOpts: &cloudConfig.AuthOpts}
+	b.dedicatedELBClient = &wrapper.DedicatedLoadBalanceClient{AuthOpts: &cloudConfig.AuthOpts}
+	b.eipClient = &wrapper.EIpClient{AuthOpts: &cloudConfig.AuthOpts}
+	b.regions = nil
+	return b
+}
+
+// validateEndpoints sends a query to each endpoint of the region, and returns the errors of the endpoints
+// rejecting the credentials or project, keyed by catalog name, which indicates the region, project-id or cloud
+// is misconfigured. The other errors are ignored, so that a temporary failure does not prevent the startup.
+func validateEndpoints(b Basic) map[string]error {
+	region := b.cloudConfig.AuthOpts.Region
+	errs := make(map[string]error)
+	for catalog, check := range endpointChecks(b) {
+		if !b.cloudConfig.AuthOpts.ServiceSupported(catalog) {
+			continue
+		}
+		err := check()
+		if err == nil {
+			continue
+		}
+		code := common.GetStatusCode(err)
+		if code == 400 || code == 401 {
+			errs[catalog] = fmt.Errorf("the %s endpoint of region %s rejected the request, please check the region, "+
+				"project-id and cloud in the cloud config, error: %s", catalog, region, err)
+			continue
+		}
+		klog.Warningf("failed to validate the %s endpoint of region %s, ignored: %s", catalog, region, err)
+	}
+	return errs
+}
+
+// endpointChecks returns a cheap authenticated query to each endpoint of the region, keyed by catalog name.
+func endpointChecks(b Basic) map[string]func() error {
+	return map[string]func() error{
+		"ecs": func() error {
+			_, err := b.ecsClient.List(&ecsmodel.ListServersDetailsRequest{Limit: pointer.Int32(1)})
+			return err
+		},
+		"elb": func() error {
+			_, err := b.dedicatedELBClient.ListInstances(&elbmodel.ListLoadBalancersRequest{Limit: pointer.Int32(1)})
+			return err
+		},
+		"vpc": func() error {
+			_, err := b.eipClient.List(&eipmodel.ListPublicipsRequest{Limit: pointer.Int32(1)})
+			return err
+		},
+	}
+}
+
 type CloudProvider struct {
 	Basic
 	providers map[LoadBalanceVersion]cloudprovider.LoadBalancer
+	// regionProviders holds the load balancer providers of the additional regions, keyed by region name.
+	regionProviders map[string]map[LoadBalanceVersion]cloudprovider.LoadBalancer
+	// projectProviders holds the load balancer providers of the additional projects, keyed by project name.
+	projectProviders map[string]map[LoadBalanceVersion]cloudprovider.LoadBalancer
+	routeBatcher     *routeBatcher
+	// serviceLocks serializes the reconciles of a service by the service controller and the service queue,
+	// keyed by "<namespace>/<name>", see lockService.
+	serviceLocks *mutexkv.MutexKV
+	health       *healthChecker
+	// states records the desired and actual state of the load balancers served on /debug/state.
+	states *serviceStates
+	// reconciles tracks the in-flight reconciles changing the load balancers, they are waited for on stop.
+	reconciles *reconcileTracker
+	// shutdownOnce runs the shutdown of the reconciles once, on stop or on the termination of the binary.
+	shutdownOnce sync.Once
+	// disabledProviders holds the errors of the providers failed to be initialized, keyed by
+	// "<region>/<catalog name>", "region/<name>" or "project/<name>", the other providers keep working.
+	disabledProviders map[string]error
+	// namespaceDefaults lists the ConfigMaps of the default annotations of the namespaces, it is nil until Initialize.
+	namespaceDefaults corelisters.ConfigMapLister
+	// configLock serializes the applying of the loadbalancer-config ConfigMap and the reloads of the cloud config.
+	configLock sync.Mutex
+	// loadbalancerConfigData is the data of the loadbalancer-config ConfigMap last applied, it is re-applied on
+	// the reloads of the cloud config as the defaults of the options are taken from the cloud config.
+	loadbalancerConfigData map[string]string
+	// throttler throttles the requests of the NAT gateway clients, it is created once as it watches its config.
+	throttler *throttle.Throttler
 }
 
 type LoadBalanceVersion int
 
 const (
-	VersionNotNeedLB LoadBalanceVersion = iota // if the service type is not LoadBalancer
-	VersionELB                                 // classic load balancer
-	VersionShared                              // enhanced load balancer(performance share)
-	VersionDedicated                           // enhanced load balancer(performance guarantee)
-	VersionNAT                                 // network address translation
+	VersionNotNeedLB         LoadBalanceVersion = iota // if the service type is not LoadBalancer
+	VersionELB                                         // classic load balancer
+	VersionShared                                      // enhanced load balancer(performance share)
+	VersionDedicated                                   // enhanced load balancer(performance guarantee)
+	VersionNAT                                         // network address translation
+	VersionSharedToDedicated                           // the shared load balancer migrating to a dedicated one
+	VersionOctavia                                     // the load balancer of the OpenStack Octavia compatible APIs
 )
 
 func init() {
@@ -201,150 +490,491 @@ func NewHWSCloud(cfg io.Reader) (*CloudProvider, error) {
 		return nil, fmt.Errorf("huaweicloud provider config is nil")
 	}
 
+	metrics.Register()
+
 	cloudConfig, err := config.ReadConfig(cfg)
 	if err != nil {
 		klog.Fatalf("failed to read AuthOpts CloudConfig: %v", err)
 		return nil, err
 	}
-
-	elbCfg, err := config.LoadElbConfigFromCM()
-	if err != nil {
-		klog.Errorf("failed to read loadbalancer config: %v", err)
+	if err = cloudConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid cloud config: %s", err)
+	}
+	auth.ConfigureHTTPClient(&cloudConfig.AuthOpts)
+	if file := cloudConfig.AuditOpts.File; file != "" {
+		if err = audit.SetOutput(file); err != nil {
+			return nil, err
+		}
 	}
 
-	klog.Infof("get loadbalancer config: %#v", elbCfg)
+	if providerOpts := &cloudConfig.CredentialProviderOpts; providerOpts.Command != "" {
+		if err = cloudConfig.AuthOpts.LoadCredentialProvider(providerOpts); err != nil {
+			return nil, fmt.Errorf("failed to obtain the credentials from the credential provider: %s", err)
+		}
+	}
+	if cloudConfig.SecretOpts.Name == "" {
+		if err = auth.ResolveProjectID(&cloudConfig.AuthOpts); err != nil {
+			return nil, err
+		}
+	}
+	if csmsOpts := &cloudConfig.CSMSOpts; csmsOpts.SecretName != "" {
+		if err = cloudConfig.AuthOpts.LoadCSMSCredential(auth.ReadCSMSSecret, csmsOpts); err != nil {
+			return nil, fmt.Errorf("failed to read the credentials from CSMS secret %s: %s", csmsOpts.SecretName, err)
+		}
+	}
 
-	restConfig, kubeClient, err := newKubeClient()
+	throttler, err := throttle.InitialThrottler()
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to init the throttler: %s", err)
 	}
 
-	broadcaster := record.NewBroadcaster()
-	broadcaster.StartRecordingToSink(&corev1.EventSinkImpl{Interface: corev1.New(kubeClient.RESTClient()).Events("")})
-	recorder := broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "hws-cloudprovider"})
-
 	ccmOpts, err := options.NewCloudControllerManagerOptions()
 	if err != nil {
 		return nil, fmt.Errorf("failed to init CloudControllerManagerOptions: %s", err)
 	}
 
-	basic := Basic{
-		cloudControllerManagerOpts: ccmOpts,
-		cloudConfig:                cloudConfig,
+	// The loadbalancer-config ConfigMap is applied in Initialize, the defaults are taken from the cloud config.
+	elbCfg := cloudConfig.NewELBConfig()
+	hws := &CloudProvider{
+		Basic: Basic{
+			cloudControllerManagerOpts: ccmOpts,
+			cloudConfig:                cloudConfig,
 
-		loadbalancerOpts: &elbCfg.LoadBalancerOpts,
-		networkingOpts:   &elbCfg.NetworkingOpts,
-		metadataOpts:     &elbCfg.MetadataOpts,
+			options: lbconfig.NewStore(elbCfg, cloudConfig),
 
-		sharedELBClient:    &wrapper.SharedLoadBalanceClient{AuthOpts: &cloudConfig.AuthOpts},
-		dedicatedELBClient: &wrapper.DedicatedLoadBalanceClient{AuthOpts: &cloudConfig.AuthOpts},
-		eipClient:          &wrapper.EIpClient{AuthOpts: &cloudConfig.AuthOpts},
-		ecsClient:          &wrapper.EcsClient{AuthOpts: &cloudConfig.AuthOpts},
+			kubeClients: &kubeClients{},
+		},
+		routeBatcher:      newRouteBatcher(),
+		serviceLocks:      mutexkv.NewMutexKV(),
+		health:            newHealthChecker(),
+		states:            newServiceStates(),
+		reconciles:        newReconcileTracker(),
+		disabledProviders: make(map[string]error),
+		throttler:         throttler,
+	}
+	hws.watchReloadTriggers(cfg)
+	for catalog, err := range cloudConfig.EndpointErrors() {
+		hws.disableProvider(cloudConfig.AuthOpts.Region+"/"+catalog, err)
+	}
+	// The health endpoints are served on all the replicas, the cloud is probed on the leader after Initialize.
+	if cloudConfig.HealthOpts.BindAddress != "" {
+		if err = hws.serve(&cloudConfig.HealthOpts); err != nil {
+			return nil, err
+		}
+	}
 
-		restConfig:    restConfig,
-		kubeClient:    kubeClient,
-		eventRecorder: recorder,
+	// The credentials in a secret are read with the Kubernetes client, which is available in Initialize.
+	if cloudConfig.SecretOpts.Name == "" {
+		if err = hws.initClouds(); err != nil {
+			return nil, err
+		}
 	}
+	return hws, nil
+}
 
-	hws := &CloudProvider{
-		Basic:     basic,
-		providers: map[LoadBalanceVersion]cloudprovider.LoadBalancer{},
+// initClouds builds the clients and the load balancer providers of the regions and projects,
+// and validates the endpoints with the credentials.
+func (h *CloudProvider) initClouds() error {
+	cloudConfig := h.cloudConfig
+	if err := auth.ResolveProjectID(&cloudConfig.AuthOpts); err != nil {
+		return err
 	}
-	err = hws.listenerDeploy()
-	if err != nil {
-		return nil, err
+	if err := auth.DiscoverEndpoints(&cloudConfig.AuthOpts); err != nil {
+		return err
 	}
 
-	hws.providers[VersionELB] = &ELBCloud{Basic: basic}
-	hws.providers[VersionShared] = &SharedLoadBalancer{Basic: basic}
-	hws.providers[VersionDedicated] = &DedicatedLoadBalancer{Basic: basic}
-	hws.providers[VersionNAT] = &NATCloud{Basic: basic}
+	ecsClient := &wrapper.EcsClient{AuthOpts: &cloudConfig.AuthOpts}
+	dedicatedELBClient := &wrapper.DedicatedLoadBalanceClient{AuthOpts: &cloudConfig.AuthOpts}
+	basic := h.Basic
+	basic.sharedELBClient = &wrapper.SharedLoadBalanceClient{AuthOpts: &cloudConfig.AuthOpts}
+	basic.dedicatedELBClient = dedicatedELBClient
+	basic.eipClient = &wrapper.EIpClient{AuthOpts: &cloudConfig.AuthOpts}
+	basic.ecsClient = ecsClient
+	basic.vpcClient = &wrapper.VpcClient{AuthOpts: &cloudConfig.AuthOpts}
+	basic.erClient = &wrapper.ErClient{AuthOpts: &cloudConfig.AuthOpts}
+	basic.dnsClient = &wrapper.DnsClient{AuthOpts: &cloudConfig.AuthOpts}
+	basic.gaClient = &wrapper.GaClient{AuthOpts: &cloudConfig.AuthOpts}
+	basic.cceClient = &wrapper.CceClient{AuthOpts: &cloudConfig.AuthOpts}
+	basic.ecsCache = ecsapi.NewInstanceCache(ecsClient, ecsapi.DefaultInstanceCacheTTL)
+	basic.subnetCache = newSubnetCache(defaultSubnetCacheTTL)
+	basic.azCache = albapi.NewAZCache(dedicatedELBClient, basic.loadbalancerOpts)
+
+	// A misconfigured region, project or endpoint disables the load balancers depending on it,
+	// the others are initialized.
+	basic.regions = make(map[string]Basic, len(cloudConfig.Regions))
+	for name := range cloudConfig.Regions {
+		regionConfig, err := cloudConfig.ForRegion(name)
+		if err == nil {
+			err = auth.DiscoverEndpoints(&regionConfig.AuthOpts)
+		}
+		if err != nil {
+			h.disableProvider("region/"+name, err)
+			continue
+		}
+		klog.Infof("add the additional region: %s", name)
+		basic.regions[name] = newRegionBasic(basic, name, regionConfig)
+	}
 
-	return hws, nil
-}
+	for catalog, err := range validateEndpoints(basic) {
+		h.disableProvider(cloudConfig.AuthOpts.Region+"/"+catalog, err)
+	}
+	for name, rb := range basic.regions {
+		for catalog, err := range validateEndpoints(rb) {
+			h.disableProvider(name+"/"+catalog, err)
+		}
+	}
 
-func newKubeClient() (*rest.Config, *corev1.CoreV1Client, error) {
-	clusterCfg, err := rest.InClusterConfig()
-	if err != nil {
-		return nil, nil, fmt.Errorf("initial cluster configuration failed with error: %v", err)
+	h.Basic = basic
+	h.providers = newLoadBalancerProviders(basic, h.throttler)
+	h.regionProviders = make(map[string]map[LoadBalanceVersion]cloudprovider.LoadBalancer, len(basic.regions))
+	for name, rb := range basic.regions {
+		h.regionProviders[name] = newLoadBalancerProviders(rb, h.throttler)
 	}
 
-	kubeClient, err := corev1.NewForConfig(clusterCfg)
-	if err != nil {
-		return nil, nil, fmt.Errorf("create kubeClient failed with error: %v", err)
+	h.projectProviders = make(map[string]map[LoadBalanceVersion]cloudprovider.LoadBalancer, len(cloudConfig.Projects))
+	for name := range cloudConfig.Projects {
+		projectConfig, err := cloudConfig.ForProject(name)
+		if err != nil {
+			h.disableProvider("project/"+name, err)
+			continue
+		}
+		klog.Infof("add the additional project: %s", name)
+		h.projectProviders[name] = newLoadBalancerProviders(newProjectBasic(basic, projectConfig), h.throttler)
+	}
+	return nil
+}
+
+// disableProvider records the provider failed to be initialized, the services depending on it fail with the error,
+// which is reported in their events, and the providers check of the health endpoints fails.
+func (h *CloudProvider) disableProvider(name string, err error) {
+	klog.Errorf("the %s provider is disabled, the other providers keep working: %s", name, err)
+	h.disabledProviders[name] = err
+	h.health.setProviderError(name, err)
+}
+
+// lockService locks the service until the returned function is called. The service controller and the workers
+// of the service queue reconcile the same service, the lock keeps its listeners, pools and members from being
+// changed by two reconciles at once, which aborts one of them as a concurrent modification.
+func (h *CloudProvider) lockService(service *v1.Service) func() {
+	key := service.Namespace + "/" + service.Name
+	h.serviceLocks.Lock(key)
+	return func() {
+		h.serviceLocks.Unlock(key)
 	}
+}
 
-	return clusterCfg, kubeClient, nil
+func newLoadBalancerProviders(basic Basic, throttler *throttle.Throttler) map[LoadBalanceVersion]cloudprovider.LoadBalancer {
+	return map[LoadBalanceVersion]cloudprovider.LoadBalancer{
+		VersionELB:               &ELBCloud{Basic: basic},
+		VersionShared:            &SharedLoadBalancer{Basic: basic},
+		VersionDedicated:         &DedicatedLoadBalancer{Basic: basic},
+		VersionNAT:               &NATCloud{Basic: basic, throttler: throttler},
+		VersionSharedToDedicated: &SharedToDedicatedLoadBalancer{Basic: basic},
+		VersionOctavia:           &OctaviaCloud{Basic: basic},
+	}
 }
 
 func (h *CloudProvider) GetLoadBalancer(ctx context.Context, clusterName string, service *v1.Service) (status *v1.LoadBalancerStatus, exists bool, err error) {
-	LBVersion, err := getLoadBalancerVersion(service)
+	service = h.withDefaultAnnotations(service)
+	ctx = newReconcileContext(ctx, "GetLoadBalancer", service)
+	defer h.endReconcile(ctx, service, time.Now(), &err)
+	provider, err := h.getLoadBalancerProvider(service)
 	if err != nil {
 		return nil, false, err
 	}
 
-	provider, exist := h.providers[LBVersion]
-	if !exist {
+	provider = withProviderContext(ctx, provider)
+	services, err := servicesOf(provider, service)
+	if common.IsNotFound(err) {
+		h.states.recordActual(service, nil)
 		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
 	}
-
-	return provider.GetLoadBalancer(ctx, clusterName, service)
+	status, exists, err = getLoadBalancers(ctx, provider, clusterName, services)
+	if err == nil && exists {
+		h.states.recordActual(service, status)
+	} else if err == nil {
+		h.states.recordActual(service, nil)
+	}
+	return status, exists, err
 }
 
+// GetLoadBalancerName returns the name EnsureLoadBalancer gives the load balancer of the class of the service,
+// see loadBalancerName. The name of an invalid class falls back to the default name of the cloud providers.
 func (h *CloudProvider) GetLoadBalancerName(ctx context.Context, clusterName string, service *v1.Service) string {
-	LBVersion, err := getLoadBalancerVersion(service)
+	service = h.withDefaultAnnotations(service)
+	provider, err := h.getLoadBalancerProvider(service)
 	if err != nil {
-		return ""
-	}
-
-	provider, exist := h.providers[LBVersion]
-	if !exist {
-		return ""
+		// the name must not be empty, even if the class of the service is invalid.
+		return cloudprovider.DefaultLoadBalancerName(service)
 	}
 
 	return provider.GetLoadBalancerName(ctx, clusterName, service)
 }
 
-func (h *CloudProvider) EnsureLoadBalancer(ctx context.Context, clusterName string, service *v1.Service, nodes []*v1.Node) (*v1.LoadBalancerStatus, error) {
-	LBVersion, err := getLoadBalancerVersion(service)
+func (h *CloudProvider) EnsureLoadBalancer(ctx context.Context, clusterName string, service *v1.Service, nodes []*v1.Node) (status *v1.LoadBalancerStatus, err error) {
+	defer h.lockService(service)()
+	h.reportDeprecatedAnnotations(service)
+	service = h.withDefaultAnnotations(service)
+	ctx = newReconcileContext(ctx, "EnsureLoadBalancer", service)
+	defer h.endReconcile(ctx, service, time.Now(), &err)
+	if ctx, err = h.reconciles.start(ctx); err != nil {
+		return nil, err
+	}
+	provider, err := h.getLoadBalancerProvider(service)
 	if err != nil {
 		return nil, err
 	}
-
-	provider, exist := h.providers[LBVersion]
-	if !exist {
-		return nil, nil
+	if err = h.checkProtocols(ctx, service); err != nil {
+		return nil, err
 	}
 
-	return provider.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+	provider = withProviderContext(ctx, provider)
+	services, err := servicesOf(provider, service)
+	if err != nil {
+		return nil, err
+	}
+	status, err = h.ensureLoadBalancers(ctx, provider, clusterName, service, services, nodes)
+	if err != nil {
+		return nil, err
+	}
+	h.states.recordActual(service, status)
+	if basic, ok := basicOf(provider); ok {
+		if err = basic.ensureDNSRecords(service, status); err != nil {
+			return nil, err
+		}
+		if err = basic.ensureGAEndpoints(service, status); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
 }
 
+// UpdateLoadBalancer is called by the service controller when the nodes are changed, the members are updated
+// synchronously with the nodes passed in, so that the service controller retries the failed updates.
+// The node changes observed by the node informer are coalesced by the service queue instead, see listenerDeploy.
 func (h *CloudProvider) UpdateLoadBalancer(ctx context.Context, clusterName string, service *v1.Service, nodes []*v1.Node) error {
-	LBVersion, err := getLoadBalancerVersion(service)
+	return h.updateLoadBalancer(ctx, clusterName, service, nodes)
+}
+
+func (h *CloudProvider) updateLoadBalancer(ctx context.Context, clusterName string, service *v1.Service, nodes []*v1.Node) (err error) {
+	defer h.lockService(service)()
+	service = h.withDefaultAnnotations(service)
+	ctx = newReconcileContext(ctx, "UpdateLoadBalancer", service)
+	defer h.endReconcile(ctx, service, time.Now(), &err)
+	if ctx, err = h.reconciles.start(ctx); err != nil {
+		return err
+	}
+	provider, err := h.getLoadBalancerProvider(service)
+	if err != nil {
+		return err
+	}
+	if err = h.checkProtocols(ctx, service); err != nil {
+		return err
+	}
+
+	provider = withProviderContext(ctx, provider)
+	services, err := servicesOf(provider, service)
+	if err != nil {
+		return err
+	}
+	return updateLoadBalancers(ctx, provider, clusterName, services, nodes)
+}
+
+func (h *CloudProvider) EnsureLoadBalancerDeleted(ctx context.Context, clusterName string, service *v1.Service) (err error) {
+	defer h.lockService(service)()
+	service = h.withDefaultAnnotations(service)
+	ctx = newReconcileContext(ctx, "EnsureLoadBalancerDeleted", service)
+	defer h.endReconcile(ctx, service, time.Now(), &err)
+	if ctx, err = h.reconciles.start(ctx); err != nil {
+		return err
+	}
+	provider, err := h.getLoadBalancerProvider(service)
 	if err != nil {
 		return err
 	}
 
-	provider, exist := h.providers[LBVersion]
-	if !exist {
+	provider = withProviderContext(ctx, provider)
+	if basic, ok := basicOf(provider); ok {
+		if err = basic.ensureDNSRecordsDeleted(service); err != nil {
+			return err
+		}
+		if err = basic.ensureGAEndpointsDeleted(service); err != nil {
+			return err
+		}
+	}
+	services, err := servicesOf(provider, service)
+	if common.IsNotFound(err) {
+		klog.Warningf("the load balancer of service %s/%s is deleted already: %s", service.Namespace, service.Name, err)
 		return nil
+	} else if err != nil {
+		return err
+	}
+	if !supportsLoadBalancerList(provider) {
+		return deleteLoadBalancers(ctx, provider, clusterName, services)
+	}
+	if err = deleteDroppedListeners(ctx, provider, clusterName, service,
+		droppedIDs(service, specifiedIDs(services))); err != nil {
+		return err
+	}
+	if err = deleteLoadBalancers(ctx, provider, clusterName, services); err != nil {
+		return err
+	}
+	if err = h.recordAppliedIDs(service, nil); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// ensureLoadBalancers ensures the load balancers of the services, the copies of the service, and deletes the
+// listeners of the service from the load balancers removed from its ElbID annotation.
+// The load balancers are recorded in the ElbAppliedIDs annotation before they are ensured, so that the listeners
+// are not left behind if the ones failed to be ensured are removed from the ElbID annotation.
+func (h *CloudProvider) ensureLoadBalancers(ctx context.Context, provider cloudprovider.LoadBalancer,
+	clusterName string, service *v1.Service, services []*v1.Service, nodes []*v1.Node) (*v1.LoadBalancerStatus, error) {
+	if !supportsLoadBalancerList(provider) {
+		return ensureLoadBalancers(ctx, provider, clusterName, services, nodes)
+	}
+	ids := specifiedIDs(services)
+	dropped := droppedIDs(service, ids)
+	if err := h.recordAppliedIDs(service, append(append([]string{}, ids...), dropped...)); err != nil {
+		return nil, err
+	}
+	status, err := ensureLoadBalancers(ctx, provider, clusterName, services, nodes)
+	if err != nil {
+		return nil, err
+	}
+	if len(dropped) == 0 {
+		return status, nil
+	}
+	if err = deleteDroppedListeners(ctx, provider, clusterName, service, dropped); err != nil {
+		return nil, err
+	}
+	if err = h.recordAppliedIDs(service, ids); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// reportCredentialError records a warning event on the secret of the credentials when they fail to be read,
+// the credentials in use are kept.
+func (h *CloudProvider) reportCredentialError(err error) {
+	h.health.setCredentialError(err)
+	secretOpts := &h.cloudConfig.SecretOpts
+	if secretOpts.Name == "" {
+		return
+	}
+	ref := &v1.ObjectReference{
+		Kind:       "Secret",
+		APIVersion: "v1",
+		Namespace:  secretOpts.Namespace,
+		Name:       secretOpts.Name,
+	}
+	h.eventRecorder.Eventf(ref, v1.EventTypeWarning, "InvalidCredentials",
+		"Failed to read the credentials from the secret: %s", err)
+}
+
+// withDefaultAnnotations returns a copy of the service with the deprecated annotations converted, and the default
+// annotations of its namespace and of the cloud config, the annotations of the service take precedence over both,
+// and the defaults of the namespace over the ones of the cloud config. The service is returned as is if nothing
+// is added.
+func (h *CloudProvider) withDefaultAnnotations(service *v1.Service) *v1.Service {
+	service = withCurrentAnnotations(service)
+	defaults := h.currentOptions().Sections.DefaultAnnotations()
+	for key, value := range h.namespaceDefaultAnnotations(service.Namespace) {
+		defaults[key] = value
 	}
 
-	return provider.UpdateLoadBalancer(ctx, clusterName, service, nodes)
+	var rst *v1.Service
+	for key, value := range defaults {
+		if _, ok := service.Annotations[key]; ok {
+			continue
+		}
+		if rst == nil {
+			rst = service.DeepCopy()
+			if rst.Annotations == nil {
+				rst.Annotations = make(map[string]string)
+			}
+		}
+		rst.Annotations[key] = value
+	}
+	if rst == nil {
+		return service
+	}
+	return rst
 }
 
-func (h *CloudProvider) EnsureLoadBalancerDeleted(ctx context.Context, clusterName string, service *v1.Service) error {
+// getLoadBalancerProvider returns the provider of the service by the elb.class, elb.region and elb.project
+// annotations, and the project mapped to the namespace of the service.
+// nil is returned if the class is not supported.
+func (h *CloudProvider) getLoadBalancerProvider(service *v1.Service) (cloudprovider.LoadBalancer, error) {
 	LBVersion, err := getLoadBalancerVersion(service)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if h.cloudConfig.LoadBalancerOpts.Backend == config.LoadBalancerBackendOctavia {
+		if LBVersion, err = getOctaviaVersion(LBVersion, service); err != nil {
+			return nil, err
+		}
 	}
 
-	provider, exist := h.providers[LBVersion]
-	if !exist {
-		return nil
+	providers := h.providers
+	project := service.Annotations[ElbProject]
+	region := service.Annotations[ElbRegion]
+	if region != "" && region != h.cloudConfig.AuthOpts.Region {
+		if project != "" {
+			return nil, status.Errorf(codes.InvalidArgument, "%s is not supported with %s", ElbProject, ElbRegion)
+		}
+		if err, ok := h.disabledProviders["region/"+region]; ok {
+			return nil, status.Errorf(codes.FailedPrecondition, "region %s is disabled: %s", region, err)
+		}
+		var ok bool
+		if providers, ok = h.regionProviders[region]; !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "region %s is not configured in the cloud config", region)
+		}
+		return h.checkLoadBalancerSupported(providers, LBVersion, &h.regions[region].cloudConfig.AuthOpts)
+	}
+
+	if project == "" {
+		project = h.cloudConfig.ProjectOfNamespace(service.Namespace)
+	}
+	if project != "" {
+		if err, ok := h.disabledProviders["project/"+project]; ok {
+			return nil, status.Errorf(codes.FailedPrecondition, "project %s is disabled: %s", project, err)
+		}
+		var ok bool
+		if providers, ok = h.projectProviders[project]; !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "project %s is not configured in the cloud config", project)
+		}
 	}
 
-	return provider.EnsureLoadBalancerDeleted(ctx, clusterName, service)
+	// The additional projects are in the region of the [Global] section.
+	return h.checkLoadBalancerSupported(providers, LBVersion, &h.cloudConfig.AuthOpts)
+}
+
+// checkLoadBalancerSupported returns the provider of the version, or an error if the service it depends on
+// is absent from the region or disabled.
+func (h *CloudProvider) checkLoadBalancerSupported(providers map[LoadBalanceVersion]cloudprovider.LoadBalancer,
+	version LoadBalanceVersion, authOpts *config.AuthOptions) (cloudprovider.LoadBalancer, error) {
+	catalog := "elb"
+	if version == VersionNAT {
+		catalog = "nat"
+	}
+	if err, ok := h.disabledProviders[authOpts.Region+"/"+catalog]; ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "the %s service of region %s is disabled: %s",
+			catalog, authOpts.Region, err)
+	}
+	if !authOpts.ServiceSupported(catalog) {
+		return nil, status.Errorf(codes.Unimplemented, "the %s service is not supported in region %s",
+			catalog, authOpts.Region)
+	}
+	provider, ok := providers[version]
+	if !ok {
+		return nil, status.Errorf(codes.Unavailable, "the load balancers of region %s are not initialized yet",
+			authOpts.Region)
+	}
+	return provider, nil
 }
 
 func getLoadBalancerVersion(service *v1.Service) (LoadBalanceVersion, error) {
@@ -355,6 +985,13 @@ func getLoadBalancerVersion(service *v1.Service) (LoadBalanceVersion, error) {
 		klog.Infof("Load balancer Version I for service %v", service.Name)
 		return VersionELB, nil
 	case "shared", "":
+		if target, ok := service.Annotations[ElbMigrateTo]; ok {
+			if target != MigrateToDedicated {
+				return 0, fmt.Errorf("unknown load balancer elb.migrate-to: %s", target)
+			}
+			klog.Infof("Shared load balancer migrating to dedicated for service %v", service.Name)
+			return VersionSharedToDedicated, nil
+		}
 		klog.Infof("Shared load balancer for service %v", service.Name)
 		return VersionShared, nil
 	case "dedicated":
@@ -368,6 +1005,20 @@ func getLoadBalancerVersion(service *v1.Service) (LoadBalanceVersion, error) {
 	}
 }
 
+// getOctaviaVersion maps the shared and dedicated classes to the Octavia load balancers of the octavia backend,
+// the dnat class is kept, the classes depending on the native ELB APIs are not supported.
+func getOctaviaVersion(version LoadBalanceVersion, service *v1.Service) (LoadBalanceVersion, error) {
+	switch version {
+	case VersionShared, VersionDedicated:
+		return VersionOctavia, nil
+	case VersionNAT:
+		return version, nil
+	default:
+		return 0, status.Errorf(codes.Unimplemented, "elb.class %q or elb.migrate-to is not supported by the %s backend",
+			service.Annotations[ElbClass], config.LoadBalancerBackendOctavia)
+	}
+}
+
 // type Instances interface {}
 
 // ExternalID returns the cloud provider ID of the specified instance (deprecated).
@@ -383,6 +1034,87 @@ func (h *CloudProvider) HasClusterID() bool {
 // Initialize provides the cloud with a kubernetes client builder and may spawn goroutines
 // to perform housekeeping activities within the cloud provider.
 func (h *CloudProvider) Initialize(clientBuilder cloudprovider.ControllerClientBuilder, stop <-chan struct{}) {
+	h.kubeClient = clientBuilder.ClientOrDie(clientName).CoreV1()
+
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&corev1.EventSinkImpl{Interface: h.kubeClient.Events("")})
+	recorder := newDedupRecorder(broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "hws-cloudprovider"}),
+		time.Duration(h.cloudConfig.EventOpts.DedupWindow)*time.Second)
+	recorder.run(stop)
+	h.eventRecorder = recorder
+	h.nodes = newNodeCache(h.kubeClient)
+
+	if secretOpts := &h.cloudConfig.SecretOpts; secretOpts.Name != "" {
+		lister, synced := h.watchCredentialSecret(stop)
+		if !cache.WaitForNamedCacheSync("credentials secret", stop, synced) {
+			klog.Errorf("stopped waiting for the secret %s/%s to be synced", secretOpts.Namespace, secretOpts.Name)
+			return
+		}
+		// A malformed secret is reported with an event and re-read, instead of failing the startup repeatedly.
+		err := wait.PollImmediateUntil(credentialRetryInterval, func() (bool, error) {
+			if err := h.cloudConfig.AuthOpts.LoadSecretCredential(lister, secretOpts); err != nil {
+				klog.Errorf("failed to read the credentials from secret %s/%s, retry later: %s",
+					secretOpts.Namespace, secretOpts.Name, err)
+				h.reportCredentialError(err)
+				return false, nil
+			}
+			return true, nil
+		}, stop)
+		if err != nil {
+			klog.Errorf("stopped reading the credentials from secret %s/%s: %s", secretOpts.Namespace, secretOpts.Name, err)
+			return
+		}
+		if err := h.initClouds(); err != nil {
+			klog.Fatalf("failed to initialize the cloud provider: %s", err)
+		}
+	}
+
+	elbCfg, err := config.LoadElbConfigFromCM(h.kubeClient, h.cloudConfig.NewELBConfig())
+	if errors.Is(err, config.ErrInvalidLoadbalancerConfig) {
+		klog.Fatalf("%s", err)
+	}
+	if err != nil {
+		klog.Errorf("failed to read loadbalancer config: %v", err)
+	}
+	klog.Infof("get loadbalancer config: %#v", elbCfg)
+	h.options.Store(elbCfg, h.cloudConfig)
+
+	if tracingOpts := &h.cloudConfig.TracingOpts; tracingOpts.Endpoint != "" {
+		shutdown, err := tracing.Setup(context.Background(), tracingOpts.Endpoint, tracingOpts.SamplingRatePerMillion)
+		if err != nil {
+			klog.Errorf("failed to set up the tracing, the spans are dropped: %s", err)
+		} else {
+			go func() {
+				<-stop
+				if err := shutdown(context.Background()); err != nil {
+					klog.Errorf("failed to flush the spans: %s", err)
+				}
+			}()
+		}
+	}
+
+	h.cloudConfig.AuthOpts.SetCredentialErrorHandler(h.reportCredentialError)
+	h.cloudConfig.AuthOpts.StartCredentialRefresher(stop)
+	h.watchLoadBalancerConfig(stop)
+	h.watchNamespaceDefaults(stop)
+	h.listenerDeploy(stop)
+	h.runRouteRepair(stop)
+	h.runShutdown(stop)
+	go wait.Until(func() {
+		h.health.probe(h.allRegions())
+	}, time.Duration(h.cloudConfig.HealthOpts.CheckInterval)*time.Second, stop)
+	go wait.Until(func() {
+		collectQuotas(h.allRegions())
+	}, time.Duration(h.cloudConfig.QuotaOpts.Interval)*time.Second, stop)
+}
+
+// allRegions returns the region in the Global section and the additional regions, keyed by region name.
+func (h *CloudProvider) allRegions() map[string]Basic {
+	regions := map[string]Basic{h.cloudConfig.AuthOpts.Region: h.Basic}
+	for name, rb := range h.regions {
+		regions[name] = rb
+	}
+	return regions
 }
 
 // TCPLoadBalancer returns an implementation of TCPLoadBalancer for Huawei Web Services.
@@ -401,7 +1133,11 @@ func (h *CloudProvider) Instances() (cloudprovider.Instances, bool) {
 
 // Zones returns an implementation of Zones for Huawei Web Services.
 func (h *CloudProvider) Zones() (cloudprovider.Zones, bool) {
-	return nil, false
+	zones := &Zones{
+		Basic: h.Basic,
+	}
+
+	return zones, true
 }
 
 // Clusters returns an implementation of Clusters for Huawei Web Services.
@@ -410,8 +1146,22 @@ func (h *CloudProvider) Clusters() (cloudprovider.Clusters, bool) {
 }
 
 // Routes returns an implementation of Routes for Huawei Web Services.
+// The VPC ID is required in the cloud config to program the routes into the VPC route tables.
 func (h *CloudProvider) Routes() (cloudprovider.Routes, bool) {
-	return nil, false
+	if h.cloudConfig.VpcOpts.RouteType == config.RouteTypeVPC && h.cloudConfig.VpcOpts.ID == "" {
+		klog.Warningf("the VPC ID is not configured, routes are not supported")
+		return nil, false
+	}
+	if h.cloudConfig.VpcOpts.RouteType == config.RouteTypeER && !h.cloudConfig.AuthOpts.ServiceSupported("er") {
+		klog.Warningf("the er service is absent from the region, routes are not supported")
+		return nil, false
+	}
+
+	routes := &Routes{
+		Basic:        h.Basic,
+		routeBatcher: h.routeBatcher,
+	}
+	return routes, true
 }
 
 // ProviderName returns the cloud provider ID.
@@ -429,14 +1179,75 @@ func (h *CloudProvider) InstancesV2() (cloudprovider.InstancesV2, bool) {
 	return instance, true
 }
 
-// ListClusters is an implementation of Clusters.ListClusters
-func (h *CloudProvider) ListClusters(ctx context.Context) ([]string, error) {
-	return nil, nil
+// ListClusters is an implementation of Clusters.ListClusters, it returns the names of the CCE clusters
+// in the project.
+func (h *CloudProvider) ListClusters(_ context.Context) ([]string, error) {
+	klog.Infof("ListClusters is called")
+	if !h.cloudConfig.AuthOpts.ServiceSupported("cce") {
+		return nil, cloudprovider.NotImplemented
+	}
+	clusters, err := h.cceClient.ListClusters()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(clusters))
+	for _, c := range clusters {
+		if c.Metadata == nil {
+			continue
+		}
+		names = append(names, c.Metadata.Name)
+	}
+	return names, nil
 }
 
-// Master is an implementation of Clusters.Master
-func (h *CloudProvider) Master(ctx context.Context, clusterName string) (string, error) {
-	return "", nil
+// Master is an implementation of Clusters.Master, it returns the API server endpoint of the cluster.
+// The master-endpoint in the cloud config takes precedence, which is used for the self-managed clusters.
+func (h *CloudProvider) Master(_ context.Context, clusterName string) (string, error) {
+	klog.Infof("Master is called with cluster name: %s", clusterName)
+	if endpoint := h.cloudConfig.ClusterOpts.MasterEndpoint; endpoint != "" {
+		return endpoint, nil
+	}
+	if !h.cloudConfig.AuthOpts.ServiceSupported("cce") {
+		return "", cloudprovider.NotImplemented
+	}
+
+	clusters, err := h.cceClient.ListClusters()
+	if err != nil {
+		return "", err
+	}
+
+	for _, c := range clusters {
+		if c.Metadata == nil || c.Metadata.Name != clusterName {
+			continue
+		}
+		if endpoint := getClusterEndpoint(c); endpoint != "" {
+			return endpoint, nil
+		}
+		return "", status.Errorf(codes.Unavailable, "the endpoint of cluster %s is not available", clusterName)
+	}
+	return "", status.Errorf(codes.NotFound, "not found CCE cluster: %s", clusterName)
+}
+
+// getClusterEndpoint returns the internal endpoint of the CCE cluster, or the external one if absent.
+func getClusterEndpoint(c ccemodel.Cluster) string {
+	if c.Status == nil || c.Status.Endpoints == nil {
+		return ""
+	}
+
+	endpoint := ""
+	for _, ep := range *c.Status.Endpoints {
+		if ep.Url == nil || *ep.Url == "" {
+			continue
+		}
+		if ep.Type != nil && *ep.Type == clusterEndpointInternal {
+			return *ep.Url
+		}
+		if endpoint == "" {
+			endpoint = *ep.Url
+		}
+	}
+	return endpoint
 }
 
 //util functions
@@ -454,18 +1265,20 @@ func IsPodActive(p v1.Pod) bool {
 	return false
 }
 
-type EndpointSliceListener struct {
-	stopChannel chan struct{}
-	kubeClient  *corev1.CoreV1Client
-	mutexLock   *mutexkv.MutexKV
-}
+// endpointsResyncPeriod is the period to update the load balancers of all the Services without the endpoints
+// changes, which corrects the drift of the members.
+const endpointsResyncPeriod = 5 * time.Minute
 
-func (e *EndpointSliceListener) stopListenerSlice() {
-	klog.Warningf("Stop listening to Endpoints")
-	close(e.stopChannel)
+// EndpointSliceListener updates the load balancers when the endpoints of the services change.
+// It is stopped by the stop channel, which is closed when the cloud controller manager loses the leader lease.
+type EndpointSliceListener struct {
+	stopChannel <-chan struct{}
+	kubeClient  corev1.CoreV1Interface
+	queue       *serviceQueue
+	health      *healthChecker
 }
 
-func (e *EndpointSliceListener) startEndpointListener(handle func(*v1.Service)) {
+func (e *EndpointSliceListener) startEndpointListener() {
 	klog.Infof("starting EndpointListener")
 	for {
 		endpointsList, err := e.kubeClient.Endpoints(metav1.NamespaceAll).
@@ -473,7 +1286,12 @@ func (e *EndpointSliceListener) startEndpointListener(handle func(*v1.Service))
 
 		if err != nil {
 			klog.Errorf("failed to query a list of Endpoints, try again later, error: %s", err)
-			time.Sleep(5 * time.Second)
+			select {
+			case <-e.stopChannel:
+				klog.Warningf("Stop listening to Endpoints")
+				return
+			case <-time.After(5 * time.Second):
+			}
 			continue
 		}
 
@@ -497,7 +1315,6 @@ func (e *EndpointSliceListener) startEndpointListener(handle func(*v1.Service))
 			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
 		)
 
-		queue := make(chan v1.Service, 50)
 		_, err = endpointsInformer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj interface{}) {},
 			UpdateFunc: func(oldObj, newObj interface{}) {
@@ -507,120 +1324,97 @@ func (e *EndpointSliceListener) startEndpointListener(handle func(*v1.Service))
 					return
 				}
 				klog.V(4).Infof("Update Endpoints, namespace: %s, name: %s", newEndpoint.Namespace, newEndpoint.Name)
-
-				queue <- v1.Service{
-					ObjectMeta: metav1.ObjectMeta{
-						Namespace: newEndpoint.Namespace,
-						Name:      newEndpoint.Name,
-					},
-				}
-				go func() {
-					s := <-queue
-					klog.V(4).Infof("process endpoints: %s / %s", s.Namespace, s.Name)
-					e.dispatcher(s.Namespace, s.Name, handle)
-				}()
+				e.queue.add(newEndpoint.Namespace, newEndpoint.Name)
 			},
 			DeleteFunc: func(obj interface{}) {},
-		}, 5*time.Second)
+		}, endpointsResyncPeriod)
 		if err != nil {
 			klog.Errorf("failed to start EventHandler, try again later, error: %s", err)
-			time.Sleep(5 * time.Second)
+			select {
+			case <-e.stopChannel:
+				klog.Warningf("Stop listening to Endpoints")
+				return
+			case <-time.After(5 * time.Second):
+			}
 			continue
 		}
 
+		e.health.addInformer("endpoints", endpointsInformer.HasSynced)
 		go endpointsInformer.Run(e.stopChannel)
 		break
 	}
 	klog.Infof("EndpointListener started")
 }
 
-func (e *EndpointSliceListener) dispatcher(namespace, name string, handle func(*v1.Service)) {
-	key := fmt.Sprintf("%s/%s", namespace, name)
-	e.mutexLock.Lock(key)
-	defer e.mutexLock.Unlock(key)
-	svc, err := e.kubeClient.Services(namespace).Get(context.TODO(), name, metav1.GetOptions{})
-	klog.Infof("Dispatcher service, namespace: %s, name: %s", namespace, name)
-	if err != nil {
-		klog.Errorf("failed to query service, error: %s", err)
-	}
-	handle(svc)
-}
+// listenerDeploy starts the endpoints listener. It is called in Initialize, which the cloud controller manager calls
+// after acquiring the leader lease, so only the leader updates the load balancers, and the listener is stopped
+// by the stop channel when the lease is lost.
+func (h *CloudProvider) listenerDeploy(stop <-chan struct{}) {
+	clusterName := h.cloudControllerManagerOpts.KubeCloudShared.ClusterName
+	// The updates of the queue are canceled with the reconciles of the service controller once stopped.
+	ctx, cancel := wait.ContextForChannel(stop)
+	go func() {
+		<-stop
+		cancel()
+	}()
+	queue := newServiceQueue(h.kubeClient, h.cloudConfig.ConcurrencyOpts.ServiceWorkers, func(service *v1.Service) error {
+		if !cache.WaitForNamedCacheSync("nodes", stop, h.nodes.hasSynced) {
+			return fmt.Errorf("the node cache is not synced")
+		}
+		nodes, err := h.nodes.list()
+		if err != nil {
+			return fmt.Errorf("failed to list the nodes in the cache: %s", err)
+		}
 
-func (h *CloudProvider) listenerDeploy() error {
+		h.sendEvent("UpdateLoadBalancer", "Endpoints or nodes changed, start updating", service)
+		return h.updateLoadBalancer(ctx, clusterName, service, nodes)
+	})
 	listener := EndpointSliceListener{
-		kubeClient: h.kubeClient,
-		mutexLock:  mutexkv.NewMutexKV(),
+		stopChannel: stop,
+		kubeClient:  h.kubeClient,
+		queue:       queue,
+		health:      h.health,
 	}
 
-	clusterName := h.cloudControllerManagerOpts.KubeCloudShared.ClusterName
-	id, err := os.Hostname()
-	if err != nil {
-		return fmt.Errorf("failed to elect leader in listener EndpointSlice : %s", err)
+	// The members are rebuilt when the nodes are changed, the changes in the window are coalesced.
+	window := h.cloudConfig.ConcurrencyOpts.GetNodeUpdateWindow()
+	updateServices := func(node *v1.Node) {
+		if !cache.WaitForNamedCacheSync("services", stop, queue.hasSynced) {
+			return
+		}
+		services, err := queue.listLoadBalancers()
+		if err != nil {
+			klog.Errorf("failed to list the services to update for node %s: %s", node.Name, err)
+			return
+		}
+		for _, service := range services {
+			queue.addAfter(service.Namespace, service.Name, window)
+		}
 	}
-
-	go leaderElection(id, h.restConfig, h.eventRecorder, func(ctx context.Context) {
-		listener.startEndpointListener(func(service *v1.Service) {
-			if service.Spec.Type != v1.ServiceTypeLoadBalancer {
-				return
-			}
-			nodeList, err := h.kubeClient.Nodes().List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				klog.Errorf("failed to query node list: %s", err)
-			}
-			nodes := make([]*v1.Node, 0, len(nodeList.Items))
-			for _, n := range nodeList.Items {
-				node := n
-				nodes = append(nodes, &node)
-			}
-
-			h.sendEvent("UpdateLoadBalancer", "Endpoints changed, start updating", service)
-
-			err = h.UpdateLoadBalancer(context.TODO(), clusterName, service, nodes)
-			if err != nil {
-				klog.Errorf("failed to synchronization endpoint, service: %s/%s, error: %s",
-					service.Namespace, service.Name, err)
-			}
-		})
-	}, func() {
-		listener.stopListenerSlice()
+	err := h.nodes.run(stop, func(node *v1.Node) {
+		updateServices(node)
+		// the NotReady node is still served within the grace period, the services are updated again once it ends,
+		// unless the listener is stopped in the meantime.
+		if grace := h.nodeOpts().GetNotReadyGracePeriod(); grace > 0 && !isNodeReady(node) {
+			go func() {
+				timer := time.NewTimer(grace)
+				defer timer.Stop()
+				select {
+				case <-stop:
+					return
+				case <-timer.C:
+				}
+				if current, ok := h.nodes.lookup(node.Name); ok && !isNodeReady(current) {
+					updateServices(current)
+				}
+			}()
+		}
 	})
-	return nil
-}
-
-func leaderElection(id string, restConfig *rest.Config, recorder record.EventRecorder, onSuccess func(context.Context), onStop func()) {
-	leaseName := "endpoint-slice-listener"
-	leaseDuration := 30 * time.Second
-	renewDeadline := 20 * time.Second
-	retryPeriod := 10 * time.Second
-
-	configmapLock, err := resourcelock.NewFromKubeconfig(resourcelock.ConfigMapsLeasesResourceLock,
-		config.ProviderNamespace,
-		leaseName,
-		resourcelock.ResourceLockConfig{
-			Identity:      fmt.Sprintf("%s_%s", id, string(uuid.NewUUID())),
-			EventRecorder: recorder,
-		},
-		restConfig,
-		renewDeadline)
 	if err != nil {
-		klog.Fatalf("error creating lock: %v", err)
-	}
-
-	leaderelection.RunOrDie(context.TODO(), leaderelection.LeaderElectionConfig{
-		Lock:          configmapLock,
-		LeaseDuration: leaseDuration,
-		RenewDeadline: renewDeadline,
-		RetryPeriod:   retryPeriod,
-		Callbacks: leaderelection.LeaderCallbacks{
-			OnStartedLeading: func(ctx context.Context) {
-				klog.V(4).Infof("[Listener EndpointSlices] leader election got: %s", id)
-				onSuccess(ctx)
-			},
-			OnStoppedLeading: func() {
-				klog.V(4).Infof("[Listener EndpointSlices] leader election lost: %s", id)
-				onStop()
-			},
-		},
-		Name: leaseName,
-	})
+		klog.Errorf("failed to start the node informer: %s", err)
+	}
+	h.health.addInformer("nodes", h.nodes.hasSynced)
+	h.health.addInformer("services", queue.hasSynced)
+	queue.run(stop)
+	go listener.startEndpointListener()
 }
@@ -24,18 +24,49 @@ import (
 
 	ecsmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ecs/v2/model"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	cloudprovider "k8s.io/cloud-provider"
 	"k8s.io/klog/v2"
 
 	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/common"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/utils/metadata"
 )
 
 const (
 	instanceShutoffStatus = "SHUTOFF"
+
+	// chargingModeMetadataKey is added to the ECS metadata by the system, "2" indicates a spot instance.
+	chargingModeMetadataKey = "charging_mode"
+	chargingModeSpot        = "2"
+
+	// SpotInstanceLabelKey is applied to the nodes running on spot (competitive pricing) ECS instances.
+	SpotInstanceLabelKey = "node.huaweicloud.com/spot-instance"
+	// SpotInstanceTaintKey is applied to the nodes running on spot ECS instances when "taint-spot-instances" is enabled.
+	SpotInstanceTaintKey = "node.huaweicloud.com/spot-instance"
+
+	FlavorFamilyLabelKey    = "node.huaweicloud.com/flavor-family"
+	ImageIDLabelKey         = "node.huaweicloud.com/image-id"
+	DedicatedHostIDLabelKey = "node.huaweicloud.com/dedicated-host-id"
+
+	// The names of the extra labels which can be enabled by the "extra-labels" option.
+	ExtraLabelFlavorFamily  = "flavor-family"
+	ExtraLabelImage         = "image"
+	ExtraLabelDedicatedHost = "dedicated-host"
+)
+
+// ownedNodeLabels and ownedNodeTaints are the keys of the labels and taints of the nodes set by the cloud provider,
+// they are removed from the nodes once they no longer apply, including the extra labels removed from "extra-labels".
+var (
+	ownedNodeLabels = sets.NewString(SpotInstanceLabelKey, FlavorFamilyLabelKey, ImageIDLabelKey,
+		DedicatedHostIDLabelKey)
+	ownedNodeTaints = sets.NewString(SpotInstanceTaintKey)
 )
 
-var providerIDRegexp = regexp.MustCompile(`^` + ProviderName + `://([^/]+)$`)
+// providerIDRegexp matches "huaweicloud://<instance-id>" and "huaweicloud://<region>/<instance-id>".
+var providerIDRegexp = regexp.MustCompile(`^` + ProviderName + `://(?:([^/]+)/)?([^/]+)$`)
 
 type Instances struct {
 	Basic
@@ -44,32 +75,32 @@ type Instances struct {
 // NodeAddresses returns the addresses of the specified instance.
 func (i *Instances) NodeAddresses(ctx context.Context, name types.NodeName) ([]v1.NodeAddress, error) {
 	klog.Infof("NodeAddresses is called with name %s", name)
-	instance, err := i.ecsClient.GetByName(string(name))
+	rb, instance, err := i.findInstanceByNodeName(ctx, string(name))
 	if err != nil {
 		return nil, err
 	}
-	return i.NodeAddressesByProviderID(ctx, instance.Id)
+	return i.NodeAddressesByProviderID(ctx, rb.providerID(instance.Id))
 }
 
 // NodeAddressesByProviderID returns the addresses of the specified instance.
-func (i *Instances) NodeAddressesByProviderID(_ context.Context, providerID string) ([]v1.NodeAddress, error) {
+func (i *Instances) NodeAddressesByProviderID(ctx context.Context, providerID string) ([]v1.NodeAddress, error) {
 	klog.Infof("NodeAddressesByProviderID is called witd provider ID %s", providerID)
-	instanceID, err := parseInstanceID(providerID)
+	rb, instanceID, err := i.forProviderID(ctx, providerID)
 	if err != nil {
 		return nil, err
 	}
 
-	interfaces, err := i.ecsClient.ListInterfaces(&ecsmodel.ListServerInterfacesRequest{ServerId: instanceID})
+	interfaces, err := rb.ecsClient.ListInterfaces(&ecsmodel.ListServerInterfacesRequest{ServerId: instanceID})
 	if err != nil {
 		return nil, err
 	}
 
-	instance, err := i.ecsClient.Get(instanceID)
+	instance, err := rb.ecsCache.Get(instanceID)
 	if err != nil {
 		return nil, err
 	}
 
-	addresses, err := i.ecsClient.BuildAddresses(instance, interfaces, i.networkingOpts)
+	addresses, err := rb.ecsClient.BuildAddresses(instance, interfaces, i.networkingOpts())
 	if err != nil {
 		return nil, err
 	}
@@ -79,19 +110,19 @@ func (i *Instances) NodeAddressesByProviderID(_ context.Context, providerID stri
 }
 
 // InstanceID returns the cloud provider ID of the node with the specified NodeName.
-func (i *Instances) InstanceID(_ context.Context, name types.NodeName) (string, error) {
+func (i *Instances) InstanceID(ctx context.Context, name types.NodeName) (string, error) {
 	klog.Infof("InstanceID is called with name %s", name)
-	server, err := i.ecsClient.GetByName(string(name))
+	rb, server, err := i.findInstanceByNodeName(ctx, string(name))
 	if err != nil {
 		return "", err
 	}
-	return server.Id, nil
+	return rb.providerID(server.Id), nil
 }
 
 // InstanceType returns the type of the specified instance.
-func (i *Instances) InstanceType(_ context.Context, name types.NodeName) (string, error) {
+func (i *Instances) InstanceType(ctx context.Context, name types.NodeName) (string, error) {
 	klog.Infof("InstanceType is called with name %s", name)
-	instance, err := i.ecsClient.GetByName(string(name))
+	_, instance, err := i.findInstanceByNodeName(ctx, string(name))
 	if err != nil {
 		return "", err
 	}
@@ -111,14 +142,14 @@ func getInstanceFlavor(instance *ecsmodel.ServerDetail) (string, error) {
 }
 
 // InstanceTypeByProviderID returns the type of the specified instance.
-func (i *Instances) InstanceTypeByProviderID(_ context.Context, providerID string) (string, error) {
+func (i *Instances) InstanceTypeByProviderID(ctx context.Context, providerID string) (string, error) {
 	klog.Infof("InstanceTypeByProviderID is called with provider ID %s", providerID)
-	instanceID, err := parseInstanceID(providerID)
+	rb, instanceID, err := i.forProviderID(ctx, providerID)
 	if err != nil {
 		return "", err
 	}
 
-	instance, err := i.ecsClient.Get(instanceID)
+	instance, err := rb.ecsCache.Get(instanceID)
 	if err != nil {
 		return "", err
 	}
@@ -132,22 +163,35 @@ func (i *Instances) AddSSHKeyToAllInstances(_ context.Context, _ string, _ []byt
 	return cloudprovider.NotImplemented
 }
 
-// CurrentNodeName returns the name of the node we are currently running on
-// On most clouds (e.g. GCE) this is the hostname, so we provide the hostname
+// CurrentNodeName returns the name of the node we are currently running on, the hostname as the kubelet
+// registers the node by default. The ECS name read from the metadata service or config drive is used only if
+// node-name-from-metadata is enabled, as the ECS name may differ from the hostname, and falls back to the hostname.
 func (i *Instances) CurrentNodeName(_ context.Context, hostname string) (types.NodeName, error) {
 	klog.Infof("CurrentNodeName is called, hostname: %s", hostname)
-	return types.NodeName(hostname), nil
+	opts := i.metadataOpts()
+	if !opts.NodeNameFromMetadata {
+		return types.NodeName(hostname), nil
+	}
+	md, err := metadata.Get(opts.SearchOrder)
+	if err != nil {
+		klog.Warningf("failed to read the metadata of current host, use hostname %s instead: %s", hostname, err)
+		return types.NodeName(hostname), nil
+	}
+	if md.Name == "" {
+		return types.NodeName(hostname), nil
+	}
+	return types.NodeName(strings.ToLower(md.Name)), nil
 }
 
 // InstanceExistsByProviderID returns true if the instance for the given provider exists.
-func (i *Instances) InstanceExistsByProviderID(_ context.Context, providerID string) (bool, error) {
+func (i *Instances) InstanceExistsByProviderID(ctx context.Context, providerID string) (bool, error) {
 	klog.Infof("InstanceExistsByProviderID is called with provider ID %s", providerID)
-	instanceID, err := parseInstanceID(providerID)
+	rb, instanceID, err := i.forProviderID(ctx, providerID)
 	if err != nil {
 		return false, err
 	}
 
-	_, err = i.ecsClient.Get(instanceID)
+	_, err = rb.ecsClient.Get(instanceID)
 	if err != nil {
 		if common.IsNotFound(err) {
 			return false, nil
@@ -159,13 +203,13 @@ func (i *Instances) InstanceExistsByProviderID(_ context.Context, providerID str
 }
 
 // InstanceShutdownByProviderID returns true if the instance is shutdown in cloudprovider
-func (i *Instances) InstanceShutdownByProviderID(_ context.Context, providerID string) (bool, error) {
+func (i *Instances) InstanceShutdownByProviderID(ctx context.Context, providerID string) (bool, error) {
 	klog.Infof("InstanceShutdownByProviderID is called with provider ID %s", providerID)
-	instanceID, err := parseInstanceID(providerID)
+	rb, instanceID, err := i.forProviderID(ctx, providerID)
 	if err != nil {
 		return false, err
 	}
-	server, err := i.ecsClient.Get(instanceID)
+	server, err := rb.ecsClient.Get(instanceID)
 	if err != nil {
 		return false, err
 	}
@@ -192,18 +236,18 @@ func (i *Instances) InstanceMetadata(ctx context.Context, node *v1.Node) (*cloud
 	providerID := node.Spec.ProviderID
 	if providerID == "" {
 		klog.V(4).Infof("node.Spec.ProviderID is empty, query ECS details by hostname: %s", node.Name)
-		id, err := i.InstanceID(ctx, types.NodeName(node.Name))
+		rb, server, err := i.findInstanceByNode(node)
 		if err != nil {
 			return nil, err
 		}
-		providerID = id
+		providerID = rb.providerID(server.Id)
 	}
-	instanceID, err := parseInstanceID(providerID)
+	rb, instanceID, err := i.forProviderID(ctx, providerID)
 	if err != nil {
 		return nil, err
 	}
 
-	instance, err := i.ecsClient.Get(instanceID)
+	instance, err := rb.ecsCache.Get(instanceID)
 	if err != nil {
 		return nil, err
 	}
@@ -213,34 +257,169 @@ func (i *Instances) InstanceMetadata(ctx context.Context, node *v1.Node) (*cloud
 		return nil, err
 	}
 
-	interfaces, err := i.ecsClient.ListInterfaces(&ecsmodel.ListServerInterfacesRequest{ServerId: instanceID})
+	interfaces, err := rb.ecsClient.ListInterfaces(&ecsmodel.ListServerInterfacesRequest{ServerId: instanceID})
 	if err != nil {
 		return nil, err
 	}
 
-	addresses, err := i.ecsClient.BuildAddresses(instance, interfaces, i.networkingOpts)
+	addresses, err := rb.ecsClient.BuildAddresses(instance, interfaces, i.networkingOpts())
 	if err != nil {
 		return nil, err
 	}
 
+	nodeLabels, taints := i.getNodeMarks(instance)
+	if err := i.ensureNodeMarked(ctx, node, nodeLabels, taints); err != nil {
+		klog.Errorf("failed to update the labels and taints of node %s: %s", node.Name, err)
+	}
+
 	return &cloudprovider.InstanceMetadata{
 		ProviderID:    providerID,
 		InstanceType:  instanceFlavor,
 		NodeAddresses: addresses,
+		Zone:          instance.OSEXTAZavailabilityZone,
+		Region:        rb.cloudConfig.AuthOpts.Region,
 	}, nil
 }
 
-func parseInstanceID(providerID string) (string, error) {
-	klog.Infof("parseInstanceID is called with providerID %s", providerID)
+func isSpotInstance(instance *ecsmodel.ServerDetail) bool {
+	return instance.Metadata[chargingModeMetadataKey] == chargingModeSpot
+}
+
+// getNodeMarks returns the labels and taints derived from the ECS details that should be applied to the node.
+func (i *Instances) getNodeMarks(instance *ecsmodel.ServerDetail) (map[string]string, []v1.Taint) {
+	nodeLabels := make(map[string]string)
+	taints := make([]v1.Taint, 0)
+	nodeOpts := i.nodeOpts()
+
+	if isSpotInstance(instance) {
+		nodeLabels[SpotInstanceLabelKey] = "true"
+		if nodeOpts.TaintSpotInstances {
+			taints = append(taints, v1.Taint{
+				Key:    SpotInstanceTaintKey,
+				Value:  "true",
+				Effect: v1.TaintEffectNoSchedule,
+			})
+		}
+	}
+
+	for _, name := range nodeOpts.ExtraLabels {
+		switch name {
+		case ExtraLabelFlavorFamily:
+			if instance.Flavor != nil && instance.Flavor.Name != "" {
+				nodeLabels[FlavorFamilyLabelKey] = strings.SplitN(instance.Flavor.Name, ".", 2)[0]
+			}
+		case ExtraLabelImage:
+			if instance.Image != nil && instance.Image.Id != "" {
+				nodeLabels[ImageIDLabelKey] = instance.Image.Id
+			}
+		case ExtraLabelDedicatedHost:
+			hints := instance.OsschedulerHints
+			if hints != nil && hints.DedicatedHostId != nil && len(*hints.DedicatedHostId) > 0 {
+				nodeLabels[DedicatedHostIDLabelKey] = (*hints.DedicatedHostId)[0]
+			}
+		default:
+			klog.Warningf("unknown extra label %q in \"extra-labels\" option, ignored", name)
+		}
+	}
+	return nodeLabels, taints
+}
+
+// ensureNodeMarked sets the owned labels and taints of the node to the ones passed in, the owned ones not passed in
+// are removed, such as the spot taint once "taint-spot-instances" is disabled. The others are kept as they are.
+func (i *Instances) ensureNodeMarked(ctx context.Context, node *v1.Node, nodeLabels map[string]string,
+	taints []v1.Taint) error {
+	for retry := 0; retry < MaxRetry; retry++ {
+		toUpdate := node.DeepCopy()
+		labelsChanged := markNodeLabels(toUpdate, nodeLabels)
+		taintsChanged := markNodeTaints(toUpdate, taints)
+		if !labelsChanged && !taintsChanged {
+			return nil
+		}
+
+		_, err := i.kubeClient.Nodes().Update(ctx, toUpdate, metav1.UpdateOptions{})
+		if err == nil {
+			klog.Infof("Node %s updated, labels: %v, taints: %v", node.Name, nodeLabels, taints)
+			return nil
+		}
+		if !apierrors.IsConflict(err) {
+			return err
+		}
+
+		node, err = i.kubeClient.Nodes().Get(ctx, node.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("failed to update node %s after %d retries", node.Name, MaxRetry)
+}
+
+// markNodeLabels sets the owned labels of the node to the ones passed in, and returns whether they are changed.
+func markNodeLabels(node *v1.Node, nodeLabels map[string]string) bool {
+	changed := false
+	for _, key := range ownedNodeLabels.List() {
+		value, ok := nodeLabels[key]
+		current, exists := node.Labels[key]
+		switch {
+		case ok && (!exists || current != value):
+			if node.Labels == nil {
+				node.Labels = map[string]string{}
+			}
+			node.Labels[key] = value
+			changed = true
+		case !ok && exists:
+			delete(node.Labels, key)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// markNodeTaints sets the owned taints of the node to the ones passed in, and returns whether they are changed.
+func markNodeTaints(node *v1.Node, taints []v1.Taint) bool {
+	changed := false
+	updated := make([]v1.Taint, 0, len(node.Spec.Taints)+len(taints))
+	for _, taint := range node.Spec.Taints {
+		if ownedNodeTaints.Has(taint.Key) && !hasTaint(taints, taint) {
+			changed = true
+			continue
+		}
+		updated = append(updated, taint)
+	}
+	for _, taint := range taints {
+		if !hasTaint(updated, taint) {
+			updated = append(updated, taint)
+			changed = true
+		}
+	}
+	if changed {
+		node.Spec.Taints = updated
+	}
+	return changed
+}
+
+// hasTaint returns whether the taints contain the one of the same key, value and effect.
+func hasTaint(taints []v1.Taint, taint v1.Taint) bool {
+	for _, t := range taints {
+		if t.MatchTaint(&taint) && t.Value == taint.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// parseProviderID returns the region and the instance ID of the providerID,
+// the region is empty if the providerID does not contain it.
+func parseProviderID(providerID string) (string, string, error) {
+	klog.Infof("parseProviderID is called with providerID %s", providerID)
 
 	if providerID != "" && !strings.Contains(providerID, "://") {
 		providerID = ProviderName + "://" + providerID
 	}
 
 	matches := providerIDRegexp.FindStringSubmatch(providerID)
-	if len(matches) != 2 {
-		return "", fmt.Errorf("ProviderID \"%s\" didn't match expected format \"huaweicloud://InstanceID\"",
-			providerID)
+	if len(matches) != 3 {
+		return "", "", fmt.Errorf("ProviderID \"%s\" didn't match expected format "+
+			"\"huaweicloud://InstanceID\" or \"huaweicloud://Region/InstanceID\"", providerID)
 	}
-	return matches[1], nil
+	return matches[1], matches[2], nil
 }
@@ -0,0 +1,136 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCurrentNodeName(t *testing.T) {
+	i := &Instances{Basic: newFakeBasic()}
+
+	// the metadata is not read by default, the ECS name may differ from the hostname registered by the kubelet.
+	name, err := i.CurrentNodeName(context.TODO(), "Node-1.example.com")
+	if err != nil {
+		t.Fatalf("CurrentNodeName() error = %v", err)
+	}
+	if name != types.NodeName("Node-1.example.com") {
+		t.Errorf("CurrentNodeName() = %s, expected the hostname Node-1.example.com", name)
+	}
+}
+
+func TestEnsureNodeMarked(t *testing.T) {
+	spotTaint := v1.Taint{Key: SpotInstanceTaintKey, Value: "true", Effect: v1.TaintEffectNoSchedule}
+	otherTaint := v1.Taint{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule}
+
+	tests := []struct {
+		name       string
+		nodeLabels map[string]string
+		nodeTaints []v1.Taint
+		markLabels map[string]string
+		markTaints []v1.Taint
+		expLabels  map[string]string
+		expTaints  []v1.Taint
+		expUpdated bool
+	}{
+		{
+			name:       "adds the spot label and taint",
+			nodeLabels: map[string]string{"app": "web"},
+			markLabels: map[string]string{SpotInstanceLabelKey: "true"},
+			markTaints: []v1.Taint{spotTaint},
+			expLabels:  map[string]string{"app": "web", SpotInstanceLabelKey: "true"},
+			expTaints:  []v1.Taint{spotTaint},
+			expUpdated: true,
+		},
+		{
+			name:       "removes the spot taint once the option is disabled",
+			nodeLabels: map[string]string{SpotInstanceLabelKey: "true"},
+			nodeTaints: []v1.Taint{otherTaint, spotTaint},
+			markLabels: map[string]string{SpotInstanceLabelKey: "true"},
+			expLabels:  map[string]string{SpotInstanceLabelKey: "true"},
+			expTaints:  []v1.Taint{otherTaint},
+			expUpdated: true,
+		},
+		{
+			name:       "removes the spot label and taint once the ECS is on-demand",
+			nodeLabels: map[string]string{"app": "web", SpotInstanceLabelKey: "true"},
+			nodeTaints: []v1.Taint{spotTaint},
+			expLabels:  map[string]string{"app": "web"},
+			expTaints:  []v1.Taint{},
+			expUpdated: true,
+		},
+		{
+			name:       "removes the extra labels no longer computed",
+			nodeLabels: map[string]string{FlavorFamilyLabelKey: "c6", ImageIDLabelKey: "image-1"},
+			markLabels: map[string]string{FlavorFamilyLabelKey: "c7"},
+			expLabels:  map[string]string{FlavorFamilyLabelKey: "c7"},
+			expUpdated: true,
+		},
+		{
+			name:       "keeps the labels and taints not owned",
+			nodeLabels: map[string]string{"app": "web"},
+			nodeTaints: []v1.Taint{otherTaint},
+			expLabels:  map[string]string{"app": "web"},
+			expTaints:  []v1.Taint{otherTaint},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: tt.nodeLabels},
+				Spec:       v1.NodeSpec{Taints: tt.nodeTaints},
+			}
+			client := fake.NewSimpleClientset(node)
+			i := &Instances{Basic: newFakeBasic()}
+			i.kubeClients = &kubeClients{kubeClient: client.CoreV1()}
+
+			if err := i.ensureNodeMarked(context.TODO(), node, tt.markLabels, tt.markTaints); err != nil {
+				t.Fatalf("ensureNodeMarked() error = %v", err)
+			}
+			updated := false
+			for _, action := range client.Actions() {
+				if action.GetVerb() == "update" {
+					updated = true
+				}
+			}
+			if updated != tt.expUpdated {
+				t.Fatalf("the node is updated: %v, expected %v", updated, tt.expUpdated)
+			}
+			if !updated {
+				return
+			}
+			latest, err := client.CoreV1().Nodes().Get(context.TODO(), "node-1", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get the node: %v", err)
+			}
+			if !reflect.DeepEqual(latest.Labels, tt.expLabels) {
+				t.Errorf("labels = %v, expected %v", latest.Labels, tt.expLabels)
+			}
+			if !reflect.DeepEqual(latest.Spec.Taints, tt.expTaints) {
+				t.Errorf("taints = %v, expected %v", latest.Spec.Taints, tt.expTaints)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"strings"
+
+	elbmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/elb/v2/model"
+	elbmodelv3 "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/elb/v3/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	cloudprovider "k8s.io/cloud-provider"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+)
+
+// ElbName specifies the existing load balancer of the service by its name instead of ElbID, it is resolved to
+// the ID on each reconcile, so that the same manifests work across the environments. The name must be unique.
+const ElbName = "kubernetes.io/elb.name"
+
+// loadBalancerNameResolver is implemented by the providers of the classes supporting ElbName.
+type loadBalancerNameResolver interface {
+	// resolveLoadBalancerName returns the IDs of the load balancers of the name.
+	resolveLoadBalancerName(name string) ([]string, error)
+}
+
+func (l *SharedLoadBalancer) resolveLoadBalancerName(name string) ([]string, error) {
+	list, err := l.sharedELBClient.ListInstances(&elbmodel.ListLoadbalancersRequest{Name: &name})
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, lb := range list {
+		if lb.Name == name {
+			ids = append(ids, lb.Id)
+		}
+	}
+	return ids, nil
+}
+
+func (d *DedicatedLoadBalancer) resolveLoadBalancerName(name string) ([]string, error) {
+	names := []string{name}
+	list, err := d.dedicatedELBClient.ListInstances(&elbmodelv3.ListLoadBalancersRequest{
+		Name:       &names,
+		Guaranteed: pointer.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, lb := range list {
+		if lb.Name == name {
+			ids = append(ids, lb.Id)
+		}
+	}
+	return ids, nil
+}
+
+// specifiedLoadBalancer returns the annotation specifying the existing load balancer of the service,
+// ElbID or ElbName, empty if the load balancer is created by the service.
+func specifiedLoadBalancer(service *v1.Service) string {
+	switch {
+	case service.Annotations[ElbID] != "":
+		return ElbID
+	case service.Annotations[ElbName] != "":
+		return ElbName
+	}
+	return ""
+}
+
+// withLoadBalancerID returns a copy of the service with the ElbID annotation of the load balancer named by ElbName,
+// the service is returned as is without ElbName. The name must match exactly one load balancer, and the same one
+// as ElbID if both are specified. A NotFound error is returned if no load balancer has the name.
+func withLoadBalancerID(provider cloudprovider.LoadBalancer, service *v1.Service) (*v1.Service, error) {
+	name := strings.TrimSpace(service.Annotations[ElbName])
+	if name == "" {
+		return service, nil
+	}
+	resolver, ok := provider.(loadBalancerNameResolver)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "annotation %s is supported by the shared and dedicated "+
+			"classes without %s only, use %s instead", ElbName, ElbMigrateTo, ElbID)
+	}
+
+	ids, err := resolver.resolveLoadBalancerName(name)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(ids) == 0:
+		return nil, status.Errorf(codes.NotFound, "not found the load balancer named %q of annotation %s",
+			name, ElbName)
+	case len(ids) > 1:
+		return nil, status.Errorf(codes.FailedPrecondition, "found %d load balancers named %q of annotation %s: "+
+			"%s, rename them or use %s instead", len(ids), name, ElbName, strings.Join(ids, ", "), ElbID)
+	}
+	if id := service.Annotations[ElbID]; id != "" && id != ids[0] {
+		return nil, status.Errorf(codes.InvalidArgument, "the load balancer %s named %q of annotation %s "+
+			"conflicts with %s of annotation %s", ids[0], name, ElbName, id, ElbID)
+	}
+
+	klog.V(4).Infof("the load balancer named %q of service %s/%s is %s", name, service.Namespace, service.Name,
+		ids[0])
+	rst := service.DeepCopy()
+	rst.Annotations[ElbID] = ids[0]
+	return rst, nil
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/cloud-provider"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/audit"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/auth"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/wrapper"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/common"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/metrics"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/tracing"
+)
+
+// reconcileInfo identifies the reconcile, it is carried by the context of the reconcile.
+type reconcileInfo struct {
+	operation   string
+	reconcileID string
+}
+
+type reconcileInfoKey struct{}
+
+// newReconcileContext returns the context with a logger carrying the service, the operation and a new reconcile ID,
+// and a span of the operation, the API requests sent in the reconcile are logged, traced and audited with them.
+// The repeated lookups of the reconcile are memoized in the context.
+func newReconcileContext(ctx context.Context, operation string, service *v1.Service) context.Context {
+	info := reconcileInfo{operation: operation, reconcileID: string(uuid.NewUUID())}
+	logger := klog.FromContext(ctx).WithValues("service", klog.KObj(service), "operation", operation,
+		"reconcileID", info.reconcileID)
+	ctx = context.WithValue(klog.NewContext(ctx, logger), reconcileInfoKey{}, info)
+	ctx = audit.NewContext(ctx, klog.KObj(service).String(), info.reconcileID)
+	ctx = withReconcileMemo(ctx)
+	ctx, _ = tracing.Start(ctx, operation,
+		attribute.String("service", klog.KObj(service).String()), attribute.String("reconcile_id", info.reconcileID))
+
+	switch operation {
+	case "EnsureLoadBalancer", "UpdateLoadBalancer":
+		metrics.SetServiceState(service.Namespace, service.Name, metrics.ServiceStatePending)
+	case "EnsureLoadBalancerDeleted":
+		metrics.SetServiceState(service.Namespace, service.Name, metrics.ServiceStateDeleting)
+	}
+	return ctx
+}
+
+// observeServiceState sets the state of the service by the result of the reconcile,
+// the GetLoadBalancer queries do not change it.
+func observeServiceState(operation string, service *v1.Service, err error) {
+	switch {
+	case operation == "GetLoadBalancer":
+	case err != nil:
+		metrics.SetServiceState(service.Namespace, service.Name, metrics.ServiceStateError)
+	case operation == "EnsureLoadBalancerDeleted":
+		metrics.DeleteServiceState(service.Namespace, service.Name)
+	default:
+		metrics.SetServiceState(service.Namespace, service.Name, metrics.ServiceStateProvisioned)
+	}
+}
+
+// endReconcile logs and records the result of the reconcile started at the time, ends its span, and records
+// a warning event with the detail of the API error, it is deferred with the error of the reconcile.
+func (h *CloudProvider) endReconcile(ctx context.Context, service *v1.Service, start time.Time, err *error) {
+	info, _ := ctx.Value(reconcileInfoKey{}).(reconcileInfo)
+	logger := klog.FromContext(ctx)
+	if *err != nil {
+		logger.Error(*err, "Reconcile failed", "duration", time.Since(start))
+		h.reportAPIError(info.operation, service, *err)
+	} else {
+		logger.V(2).Info("Reconcile finished", "duration", time.Since(start))
+	}
+	if h.reconciles.finish(ctx) {
+		h.markInterrupted(info.operation, service)
+	} else if *err == nil && info.operation != "EnsureLoadBalancerDeleted" {
+		h.clearInterrupted(service)
+	}
+	h.states.recordReconcile(info, service, start, *err)
+	observeServiceState(info.operation, service, *err)
+	tracing.End(trace.SpanFromContext(ctx), *err)
+}
+
+// reportAPIError records a warning event with the error code, the error response body and the request ID
+// of the failed API request, so that the users can diagnose such as the quota and the invalid subnet errors.
+// The secrets in the body are masked, and the body is truncated. The exceeded quotas are reported with
+// the QuotaExceeded reason and counted, they are not fixed by the retries.
+func (h *CloudProvider) reportAPIError(operation string, service *v1.Service, err error) {
+	detail, ok := common.APIErrorDetail(err)
+	resource, quotaExceeded := common.IsQuotaExceeded(err)
+	var apiErr *auth.APIError
+	if !ok && errors.As(err, &apiErr) {
+		detail, ok = fmt.Sprintf("%s (status code: %d)", common.SanitizeErrorBody(apiErr.Body), apiErr.StatusCode), true
+		resource, quotaExceeded = common.QuotaExceededResource(apiErr.Body)
+	}
+	if quotaExceeded {
+		metrics.ObserveQuotaExceeded(resource)
+	}
+	if !ok || h.eventRecorder == nil {
+		return
+	}
+	if quotaExceeded {
+		h.eventRecorder.Eventf(service, v1.EventTypeWarning, "QuotaExceeded",
+			"The Huawei Cloud quota of %s is exceeded, increase the quota or release the unused resources: %s",
+			resource, detail)
+		return
+	}
+	h.eventRecorder.Eventf(service, v1.EventTypeWarning, operation+"Failed", "Huawei Cloud API error: %s", detail)
+}
+
+// withContext returns a copy of the Basic whose clients log, trace and audit the API requests with the context.
+// The caches are shared and keep using the background context. The options in use are pinned, so that the reconcile
+// reads one snapshot of them.
+func (b Basic) withContext(ctx context.Context) Basic {
+	b.pinned = b.currentOptions()
+	if b.cloudConfig != nil {
+		// The clients of the classic load balancers and the NAT gateways are built with the cloud config.
+		cloudConfig := *b.cloudConfig
+		cloudConfig.AuthOpts = *cloudConfig.AuthOpts.WithContext(ctx)
+		b.cloudConfig = &cloudConfig
+	}
+	// The clients behind the interfaces are rebound only if they are the wrapper clients, the fakes are kept.
+	if c, ok := b.sharedELBClient.(*wrapper.SharedLoadBalanceClient); ok {
+		b.sharedELBClient = &wrapper.SharedLoadBalanceClient{AuthOpts: c.AuthOpts.WithContext(ctx)}
+	}
+	if c, ok := b.dedicatedELBClient.(*wrapper.DedicatedLoadBalanceClient); ok {
+		b.dedicatedELBClient = &wrapper.DedicatedLoadBalanceClient{AuthOpts: c.AuthOpts.WithContext(ctx)}
+	}
+	if b.eipClient != nil {
+		b.eipClient = &wrapper.EIpClient{AuthOpts: b.eipClient.AuthOpts.WithContext(ctx)}
+	}
+	if c, ok := b.ecsClient.(*wrapper.EcsClient); ok {
+		b.ecsClient = &wrapper.EcsClient{AuthOpts: c.AuthOpts.WithContext(ctx)}
+	}
+	if c, ok := b.vpcClient.(*wrapper.VpcClient); ok {
+		b.vpcClient = &wrapper.VpcClient{AuthOpts: c.AuthOpts.WithContext(ctx)}
+	}
+	if b.erClient != nil {
+		b.erClient = &wrapper.ErClient{AuthOpts: b.erClient.AuthOpts.WithContext(ctx)}
+	}
+	if b.dnsClient != nil {
+		b.dnsClient = &wrapper.DnsClient{AuthOpts: b.dnsClient.AuthOpts.WithContext(ctx)}
+	}
+	if b.gaClient != nil {
+		b.gaClient = &wrapper.GaClient{AuthOpts: b.gaClient.AuthOpts.WithContext(ctx)}
+	}
+	if b.cceClient != nil {
+		b.cceClient = &wrapper.CceClient{AuthOpts: b.cceClient.AuthOpts.WithContext(ctx)}
+	}
+	return b
+}
+
+// context returns the context of the reconcile the Basic is bound to by withContext.
+func (b Basic) context() context.Context {
+	if b.cloudConfig == nil {
+		return context.Background()
+	}
+	return b.cloudConfig.AuthOpts.Context()
+}
+
+// withProviderContext returns a copy of the provider whose API requests are logged and traced with the context.
+func withProviderContext(ctx context.Context, provider cloudprovider.LoadBalancer) cloudprovider.LoadBalancer {
+	switch p := provider.(type) {
+	case *ELBCloud:
+		return &ELBCloud{Basic: p.Basic.withContext(ctx), client: p.client}
+	case *SharedLoadBalancer:
+		return &SharedLoadBalancer{Basic: p.Basic.withContext(ctx)}
+	case *DedicatedLoadBalancer:
+		return &DedicatedLoadBalancer{Basic: p.Basic.withContext(ctx)}
+	case *SharedToDedicatedLoadBalancer:
+		return &SharedToDedicatedLoadBalancer{Basic: p.Basic.withContext(ctx)}
+	case *OctaviaCloud:
+		return &OctaviaCloud{Basic: p.Basic.withContext(ctx)}
+	case *NATCloud:
+		return &NATCloud{Basic: p.Basic.withContext(ctx), natClient: p.natClient, vpcClient: p.vpcClient,
+			throttler: p.throttler}
+	}
+	return provider
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/klog/v2"
+)
+
+// reconcileMemo holds the results of the lookups made in a reconcile, such as the pods of the service and the
+// subnets of the nodes, so that they are fetched once instead of once for each port of the service.
+// It lives only as long as the reconcile, the results are never shared with the next reconcile.
+type reconcileMemo struct {
+	lock   sync.Mutex
+	values map[string]interface{}
+}
+
+type reconcileMemoKey struct{}
+
+func withReconcileMemo(ctx context.Context) context.Context {
+	return context.WithValue(ctx, reconcileMemoKey{}, &reconcileMemo{values: make(map[string]interface{})})
+}
+
+// memoize returns the result of fetch memoized under the key in the reconcile of the context.
+// fetch is called every time if the context has no reconcile, and the errors are never memoized.
+func memoize[T any](ctx context.Context, key string, fetch func() (T, error)) (T, error) {
+	memo, ok := ctx.Value(reconcileMemoKey{}).(*reconcileMemo)
+	if !ok {
+		return fetch()
+	}
+
+	memo.lock.Lock()
+	value, ok := memo.values[key]
+	memo.lock.Unlock()
+	if ok {
+		klog.FromContext(ctx).V(6).Info("Reuse the result of the lookup in the reconcile", "key", key)
+		return value.(T), nil
+	}
+
+	result, err := fetch()
+	if err != nil {
+		return result, err
+	}
+	memo.lock.Lock()
+	memo.values[key] = result
+	memo.lock.Unlock()
+	return result, nil
+}
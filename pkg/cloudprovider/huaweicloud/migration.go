@@ -0,0 +1,207 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"context"
+	"fmt"
+
+	eipmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/eip/v2/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/common"
+)
+
+const (
+	// ElbMigrateTo migrates the shared load balancer of the service to the class of the value.
+	ElbMigrateTo = "kubernetes.io/elb.migrate-to"
+	// MigrateToDedicated is the only class the shared load balancers migrate to.
+	MigrateToDedicated = "dedicated"
+)
+
+// SharedToDedicatedLoadBalancer migrates the shared load balancer of the service to a dedicated one.
+// The dedicated load balancer is created with the listeners and the members of the service first, then the EIP
+// of the shared load balancer is moved to it and the shared load balancer is deleted, so the service is only
+// unreachable while the EIP moves. Once migrated, it keeps working as a dedicated load balancer.
+type SharedToDedicatedLoadBalancer struct {
+	Basic
+}
+
+func (m *SharedToDedicatedLoadBalancer) shared() *SharedLoadBalancer {
+	return &SharedLoadBalancer{Basic: m.Basic}
+}
+
+func (m *SharedToDedicatedLoadBalancer) dedicated() *DedicatedLoadBalancer {
+	return &DedicatedLoadBalancer{Basic: m.Basic}
+}
+
+// GetLoadBalancer returns the status of the shared load balancer until it is migrated.
+func (m *SharedToDedicatedLoadBalancer) GetLoadBalancer(ctx context.Context, clusterName string, service *v1.Service) (
+	*v1.LoadBalancerStatus, bool, error) {
+	lbStatus, exists, err := m.shared().GetLoadBalancer(ctx, clusterName, service)
+	if err != nil || exists {
+		return lbStatus, exists, err
+	}
+	return m.dedicated().GetLoadBalancer(ctx, clusterName, service)
+}
+
+func (m *SharedToDedicatedLoadBalancer) GetLoadBalancerName(ctx context.Context, clusterName string, service *v1.Service) string {
+	return m.dedicated().GetLoadBalancerName(ctx, clusterName, service)
+}
+
+func (m *SharedToDedicatedLoadBalancer) EnsureLoadBalancer(ctx context.Context, clusterName string, service *v1.Service,
+	nodes []*v1.Node) (*v1.LoadBalancerStatus, error) {
+	klog.Infof("EnsureLoadBalancer: called with service %s/%s, node: %d",
+		service.Namespace, service.Name, len(nodes))
+
+	if getStringFromSvsAnnotation(service, ElbID, "") != "" {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid argument, annotation %q is not supported "+
+			"with the load balancer specified by %q", ElbMigrateTo, ElbID)
+	}
+
+	shared, dedicated := m.shared(), m.dedicated()
+	source, err := shared.getLoadBalancerInstance(ctx, clusterName, service)
+	if common.IsNotFound(err) {
+		// the service is new or migrated already.
+		if _, err = dedicated.EnsureLoadBalancer(ctx, clusterName, service, nodes); err != nil {
+			return nil, err
+		}
+		return m.getDedicatedStatus(ctx, clusterName, service)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	klog.Infof("Migrating the shared ELB %s of service %s/%s to a dedicated ELB", source.Id,
+		service.Namespace, service.Name)
+	// the EIP of the shared load balancer is moved to the dedicated one instead of a new EIP.
+	target := service.DeepCopy()
+	delete(target.Annotations, ElbEipID)
+	delete(target.Annotations, AutoCreateEipOptions)
+	if _, err = dedicated.EnsureLoadBalancer(ctx, clusterName, target, nodes); err != nil {
+		return nil, err
+	}
+	loadbalancer, err := dedicated.getLoadBalancerInstance(ctx, clusterName, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = m.moveEIP(source.VipPortId, loadbalancer.VipPortId, service); err != nil {
+		return nil, err
+	}
+
+	// the EIP is bound to the dedicated load balancer, it must not be released with the shared one.
+	deleted := service.DeepCopy()
+	delete(deleted.Annotations, ElbEipID)
+	deleted.Annotations[ELBKeepEip] = "true"
+	if err = shared.deleteSecurityGroupRules(service, "shared"); err != nil {
+		return nil, err
+	}
+	if err = shared.deleteELBInstance(source, deleted); err != nil {
+		return nil, err
+	}
+
+	msg := fmt.Sprintf("Migrated the shared ELB %s to the dedicated ELB %s", source.Id, loadbalancer.Id)
+	klog.Infof("%s of service %s/%s", msg, service.Namespace, service.Name)
+	m.sendEvent("MigratedLoadBalancer", msg, service)
+	return m.getDedicatedStatus(ctx, clusterName, service)
+}
+
+// moveEIP binds the EIP of the shared load balancer to the VIP port of the dedicated one. The EIP specified by
+// the annotation is also moved if it was unbound by a previous attempt.
+func (m *SharedToDedicatedLoadBalancer) moveEIP(fromPortID, toPortID string, service *v1.Service) error {
+	var eip *eipmodel.PublicipShowResp
+	if eipID := getStringFromSvsAnnotation(service, ElbEipID, ""); eipID != "" {
+		ip, err := m.eipClient.Get(eipID)
+		if err != nil {
+			return err
+		}
+		eip = ip
+	} else {
+		ips, err := m.eipClient.List(&eipmodel.ListPublicipsRequest{PortId: &[]string{fromPortID}})
+		if err != nil {
+			return status.Errorf(codes.Unavailable, "error querying EIPs base on PortId (%s): %s", fromPortID, err)
+		}
+		if len(ips) == 0 {
+			return nil
+		}
+		eip = &ips[0]
+	}
+
+	eipID := pointer.StringDeref(eip.Id, "")
+	portID := pointer.StringDeref(eip.PortId, "")
+	if portID == toPortID {
+		return nil
+	}
+	klog.Infof("Moving the EIP %s from port %s to port %s", eipID, portID, toPortID)
+	if portID != "" {
+		if err := m.eipClient.Unbind(eipID); err != nil {
+			return err
+		}
+	}
+	return m.eipClient.Bind(eipID, toPortID)
+}
+
+func (m *SharedToDedicatedLoadBalancer) getDedicatedStatus(ctx context.Context, clusterName string,
+	service *v1.Service) (*v1.LoadBalancerStatus, error) {
+	lbStatus, exists, err := m.dedicated().GetLoadBalancer(ctx, clusterName, service)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, status.Errorf(codes.Unavailable, "not found dedicated ELB instance %s",
+			m.GetLoadBalancerName(ctx, clusterName, service))
+	}
+	return lbStatus, nil
+}
+
+// UpdateLoadBalancer updates the members of the shared load balancer until it is migrated.
+func (m *SharedToDedicatedLoadBalancer) UpdateLoadBalancer(ctx context.Context, clusterName string, service *v1.Service,
+	nodes []*v1.Node) error {
+	_, err := m.shared().getLoadBalancerInstance(ctx, clusterName, service)
+	if common.IsNotFound(err) {
+		return m.dedicated().UpdateLoadBalancer(ctx, clusterName, service, nodes)
+	}
+	if err != nil {
+		return err
+	}
+	return m.shared().UpdateLoadBalancer(ctx, clusterName, service, nodes)
+}
+
+// EnsureLoadBalancerDeleted deletes the shared load balancer if it is not migrated yet, and the dedicated one.
+func (m *SharedToDedicatedLoadBalancer) EnsureLoadBalancerDeleted(ctx context.Context, clusterName string,
+	service *v1.Service) error {
+	shared := m.shared()
+	target := service
+	_, err := shared.getLoadBalancerInstance(ctx, clusterName, service)
+	if err != nil && !common.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		if err = shared.EnsureLoadBalancerDeleted(ctx, clusterName, service); err != nil {
+			return err
+		}
+		// the EIP specified is released with the shared load balancer, it was not moved.
+		target = service.DeepCopy()
+		delete(target.Annotations, ElbEipID)
+	}
+	return m.dedicated().EnsureLoadBalancerDeleted(ctx, clusterName, target)
+}
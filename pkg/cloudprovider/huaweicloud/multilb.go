@@ -0,0 +1,262 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	cloudprovider "k8s.io/cloud-provider"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/common"
+)
+
+// ElbAppliedIDs records the load balancers of the ElbID annotation the listeners of the service are ensured on,
+// so that the listeners are deleted from the ones removed from the annotation. It is set by the cloud provider.
+const ElbAppliedIDs = "kubernetes.io/elb.applied-ids"
+
+// loadBalancerIDs returns the IDs of the comma-separated ElbID annotation in order, without the duplicates.
+func loadBalancerIDs(service *v1.Service) []string {
+	return splitIDs(service.Annotations[ElbID])
+}
+
+// splitIDs returns the IDs of the comma-separated list in order, without the duplicates.
+func splitIDs(list string) []string {
+	var ids []string
+	seen := sets.NewString()
+	for _, id := range strings.Split(list, ",") {
+		if id = strings.TrimSpace(id); id != "" && !seen.Has(id) {
+			seen.Insert(id)
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// perLoadBalancer returns a copy of the service for each load balancer of the ElbID list, with the ElbID
+// annotation of the load balancer, so that the listeners and the members are kept in sync on each of them.
+// The service is returned as is if it specifies one load balancer or none.
+func perLoadBalancer(provider cloudprovider.LoadBalancer, service *v1.Service) ([]*v1.Service, error) {
+	ids := loadBalancerIDs(service)
+	if len(ids) <= 1 {
+		return []*v1.Service{service}, nil
+	}
+	if !supportsLoadBalancerList(provider) {
+		return nil, status.Errorf(codes.InvalidArgument, "the list of load balancers of annotation %s is "+
+			"supported by the shared and dedicated classes without %s only", ElbID, ElbMigrateTo)
+	}
+
+	services := make([]*v1.Service, 0, len(ids))
+	for _, id := range ids {
+		s := service.DeepCopy()
+		s.Annotations[ElbID] = id
+		services = append(services, s)
+	}
+	return services, nil
+}
+
+// supportsLoadBalancerList returns whether the provider serves a service on a list of load balancers.
+func supportsLoadBalancerList(provider cloudprovider.LoadBalancer) bool {
+	switch provider.(type) {
+	case *SharedLoadBalancer, *DedicatedLoadBalancer:
+		return true
+	}
+	return false
+}
+
+// servicesOf resolves the ElbName annotation of the service, see withLoadBalancerID, and returns a copy of
+// the service for each load balancer, see perLoadBalancer.
+func servicesOf(provider cloudprovider.LoadBalancer, service *v1.Service) ([]*v1.Service, error) {
+	resolved, err := withLoadBalancerID(provider, service)
+	if err != nil {
+		return nil, err
+	}
+	return perLoadBalancer(provider, resolved)
+}
+
+// mergeIngress appends the ingress of the status to the merged one, without the duplicated addresses.
+func mergeIngress(merged *v1.LoadBalancerStatus, lbStatus *v1.LoadBalancerStatus) {
+	if lbStatus == nil {
+		return
+	}
+	for _, ingress := range lbStatus.Ingress {
+		duplicated := false
+		for _, existing := range merged.Ingress {
+			if existing.IP == ingress.IP && existing.Hostname == ingress.Hostname {
+				duplicated = true
+				break
+			}
+		}
+		if !duplicated {
+			merged.Ingress = append(merged.Ingress, ingress)
+		}
+	}
+}
+
+// getLoadBalancers returns the ingress of all the load balancers of the services, it exists if any of them exists.
+func getLoadBalancers(ctx context.Context, provider cloudprovider.LoadBalancer, clusterName string,
+	services []*v1.Service) (*v1.LoadBalancerStatus, bool, error) {
+	if len(services) == 1 {
+		return provider.GetLoadBalancer(ctx, clusterName, services[0])
+	}
+	merged, found := &v1.LoadBalancerStatus{}, false
+	for _, service := range services {
+		lbStatus, exists, err := provider.GetLoadBalancer(ctx, clusterName, service)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get the load balancer %s: %w", service.Annotations[ElbID], err)
+		}
+		if exists {
+			found = true
+			mergeIngress(merged, lbStatus)
+		}
+	}
+	if !found {
+		return nil, false, nil
+	}
+	return merged, true, nil
+}
+
+// ensureLoadBalancers ensures all the load balancers of the services, the failure of one does not stop the others.
+// The ingress of all of them is returned.
+func ensureLoadBalancers(ctx context.Context, provider cloudprovider.LoadBalancer, clusterName string,
+	services []*v1.Service, nodes []*v1.Node) (*v1.LoadBalancerStatus, error) {
+	if len(services) == 1 {
+		return provider.EnsureLoadBalancer(ctx, clusterName, services[0], nodes)
+	}
+	merged := &v1.LoadBalancerStatus{}
+	var errs []error
+	for _, service := range services {
+		lbStatus, err := provider.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to ensure the load balancer %s: %w", service.Annotations[ElbID], err))
+			continue
+		}
+		mergeIngress(merged, lbStatus)
+	}
+	if len(errs) != 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+	return merged, nil
+}
+
+// updateLoadBalancers updates the members of all the load balancers of the services.
+func updateLoadBalancers(ctx context.Context, provider cloudprovider.LoadBalancer, clusterName string,
+	services []*v1.Service, nodes []*v1.Node) error {
+	if len(services) == 1 {
+		return provider.UpdateLoadBalancer(ctx, clusterName, services[0], nodes)
+	}
+	var errs []error
+	for _, service := range services {
+		if err := provider.UpdateLoadBalancer(ctx, clusterName, service, nodes); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update the load balancer %s: %w", service.Annotations[ElbID], err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// deleteLoadBalancers deletes the listeners of the service from all the load balancers of the services.
+func deleteLoadBalancers(ctx context.Context, provider cloudprovider.LoadBalancer, clusterName string,
+	services []*v1.Service) error {
+	if len(services) == 1 {
+		return provider.EnsureLoadBalancerDeleted(ctx, clusterName, services[0])
+	}
+	var errs []error
+	for _, service := range services {
+		if err := provider.EnsureLoadBalancerDeleted(ctx, clusterName, service); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete the listeners of the load balancer %s: %w",
+				service.Annotations[ElbID], err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// specifiedIDs returns the IDs of the load balancers of the services, none if the load balancer is created
+// by the service.
+func specifiedIDs(services []*v1.Service) []string {
+	var ids []string
+	for _, service := range services {
+		if id := service.Annotations[ElbID]; id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// droppedIDs returns the IDs of the ElbAppliedIDs annotation of the service removed from the IDs.
+func droppedIDs(service *v1.Service, ids []string) []string {
+	return sets.NewString(splitIDs(service.Annotations[ElbAppliedIDs])...).Delete(ids...).List()
+}
+
+// recordAppliedIDs sets the ElbAppliedIDs annotation of the service to the IDs if they are changed,
+// the annotation is removed if there is none.
+func (h *CloudProvider) recordAppliedIDs(service *v1.Service, ids []string) error {
+	value := strings.Join(ids, ",")
+	if service.Annotations[ElbAppliedIDs] == value {
+		return nil
+	}
+	var patchValue interface{}
+	if value != "" {
+		patchValue = value
+	}
+	if err := h.patchAnnotation(service, ElbAppliedIDs, patchValue); err != nil {
+		return fmt.Errorf("failed to update annotation %s of service %s/%s: %w", ElbAppliedIDs,
+			service.Namespace, service.Name, err)
+	}
+	return nil
+}
+
+// deleteDroppedListeners deletes the listeners of the service from the load balancers removed from the ElbID
+// annotation. The security group rules of the service are kept, they serve the load balancers still in use.
+func deleteDroppedListeners(ctx context.Context, provider cloudprovider.LoadBalancer, clusterName string,
+	service *v1.Service, ids []string) error {
+	var errs []error
+	for _, id := range ids {
+		s := service.DeepCopy()
+		s.Annotations[ElbID] = id
+		err := deleteListenersOf(ctx, provider, clusterName, s)
+		if err != nil && !common.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete the listeners of the load balancer %s: %w", id, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// deleteListenersOf deletes the listeners of the service from the load balancer of its ElbID annotation.
+func deleteListenersOf(ctx context.Context, provider cloudprovider.LoadBalancer, clusterName string,
+	service *v1.Service) error {
+	switch p := provider.(type) {
+	case *SharedLoadBalancer:
+		loadBalancer, err := p.getLoadBalancerInstance(ctx, clusterName, service)
+		if err != nil {
+			return err
+		}
+		return p.deleteListener(clusterName, loadBalancer, service)
+	case *DedicatedLoadBalancer:
+		loadBalancer, err := p.getLoadBalancerInstance(ctx, clusterName, service)
+		if err != nil {
+			return err
+		}
+		return p.deleteListener(clusterName, loadBalancer, service)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/utils"
+)
+
+// The load balancers, listeners and pools of the services are named and described after the cluster, so that
+// the clusters sharing a project, or a load balancer specified by ElbID, never take the resources of each other.
+
+// descriptionRegexp matches the descriptions of the resources created by the services, see resourceDescription.
+var descriptionRegexp = regexp.MustCompile(`^Created by the ELB service\(([^)]*)\) of the k8s cluster\(([^)]*)\)\.$`)
+
+// clusterID returns the id in the Cluster section of the cloud config, or the name of the cluster if it is empty.
+func (b Basic) clusterID(clusterName string) string {
+	if b.cloudConfig != nil && b.cloudConfig.ClusterOpts.ID != "" {
+		return b.cloudConfig.ClusterOpts.ID
+	}
+	return clusterName
+}
+
+// clusterIDs returns the clusterID, followed by the name of the cluster if the id in the Cluster section differs,
+// so that the resources created before the id is set are still found and taken as owned by the cluster.
+func (b Basic) clusterIDs(clusterName string) []string {
+	if id := b.clusterID(clusterName); id != clusterName {
+		return []string{id, clusterName}
+	}
+	return []string{clusterName}
+}
+
+// dnatClusterID returns the cluster ID of the DNAT rules, the id in the Cluster section of the cloud config,
+// or the CLUSTER_ID environment variable if it is empty.
+func (b Basic) dnatClusterID() string {
+	if b.cloudConfig != nil && b.cloudConfig.ClusterOpts.ID != "" {
+		return b.cloudConfig.ClusterOpts.ID
+	}
+	return os.Getenv(ClusterID)
+}
+
+// dnatClusterIDs returns the dnatClusterID, followed by the CLUSTER_ID environment variable if it differs,
+// so that the DNAT rules created before the id in the Cluster section is set are still found.
+func (b Basic) dnatClusterIDs() []string {
+	id := b.dnatClusterID()
+	if env := os.Getenv(ClusterID); env != "" && env != id {
+		return []string{id, env}
+	}
+	return []string{id}
+}
+
+// loadBalancerName returns the name of the load balancers created for the service,
+// "k8s_service_{clusterID}_{namespace}_{name}" cut to 255 characters.
+func loadBalancerName(clusterID string, service *v1.Service) string {
+	name := fmt.Sprintf("k8s_service_%s_%s_%s", clusterID, service.Namespace, service.Name)
+	return utils.CutString(name, defaultMaxNameLength)
+}
+
+// loadBalancerNames returns the names to find the load balancer of the service by, in order, the name of the
+// clusterID and then the legacy one of the name of the cluster, see clusterIDs. The load balancers found by the
+// legacy name keep it.
+func (b Basic) loadBalancerNames(clusterName string, service *v1.Service) []string {
+	var names []string
+	for _, id := range b.clusterIDs(clusterName) {
+		names = append(names, loadBalancerName(id, service))
+	}
+	return names
+}
+
+// listenerName returns the name of the listener of the service port,
+// "k8s_{clusterID}_{namespace}_{name}_{protocol}_{port}" cut to 255 characters.
+func (b Basic) listenerName(clusterName string, service *v1.Service, protocol string, port int32) string {
+	name := fmt.Sprintf("k8s_%s_%s_%s_%s_%d", b.clusterID(clusterName), service.Namespace, service.Name,
+		protocol, port)
+	return utils.CutString(name, defaultMaxNameLength)
+}
+
+// resourceDescription returns the description of the load balancers, listeners and pools of the service.
+func (b Basic) resourceDescription(clusterName string, service *v1.Service) string {
+	return describeResource(b.clusterID(clusterName), service)
+}
+
+func describeResource(clusterID string, service *v1.Service) string {
+	return fmt.Sprintf("Created by the ELB service(%s/%s) of the k8s cluster(%s).",
+		service.Namespace, service.Name, clusterID)
+}
+
+// ownedByOthers returns whether the description is of a resource created by another service or cluster,
+// the resources without such a description are created by hand or by the former versions, and are taken over.
+// The descriptions of the legacy cluster ID are owned by the cluster, they are updated to the current one
+// along with the resources.
+func (b Basic) ownedByOthers(clusterName string, service *v1.Service, description string) bool {
+	if !descriptionRegexp.MatchString(description) {
+		return false
+	}
+	for _, id := range b.clusterIDs(clusterName) {
+		if description == describeResource(id, service) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkListenerOwner returns a FailedPrecondition error if the listener of the port is created by another
+// service or cluster, it is not changed then.
+func (b Basic) checkListenerOwner(clusterName string, service *v1.Service, id string, port int32,
+	description string) error {
+	if !b.ownedByOthers(clusterName, service, description) {
+		return nil
+	}
+	match := descriptionRegexp.FindStringSubmatch(description)
+	return status.Errorf(codes.FailedPrecondition, "the listener %s of port %d is used by the service %s "+
+		"of the k8s cluster %s, change the port of service %s/%s", id, port, match[1], match[2],
+		service.Namespace, service.Name)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLegacyClusterIDs(t *testing.T) {
+	service := &v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"}}
+	tests := []struct {
+		name      string
+		id        string
+		env       string
+		names     []string
+		dnatIDs   []string
+		ownedDesc map[string]bool
+	}{
+		{
+			name:    "without the cluster id",
+			env:     "uuid-1",
+			names:   []string{"k8s_service_kubernetes_default_web"},
+			dnatIDs: []string{"uuid-1"},
+			ownedDesc: map[string]bool{
+				"Created by the ELB service(default/web) of the k8s cluster(kubernetes).": true,
+				"Created by the ELB service(default/web) of the k8s cluster(cluster-a).":  false,
+			},
+		},
+		{
+			name:    "with the cluster id set on a running cluster",
+			id:      "cluster-a",
+			env:     "uuid-1",
+			names:   []string{"k8s_service_cluster-a_default_web", "k8s_service_kubernetes_default_web"},
+			dnatIDs: []string{"cluster-a", "uuid-1"},
+			ownedDesc: map[string]bool{
+				"Created by the ELB service(default/web) of the k8s cluster(cluster-a).":  true,
+				"Created by the ELB service(default/web) of the k8s cluster(kubernetes).": true,
+				"Created by the ELB service(default/api) of the k8s cluster(kubernetes).": false,
+				"Created by the ELB service(default/web) of the k8s cluster(cluster-b).":  false,
+			},
+		},
+		{
+			name:    "with the cluster id of the cluster name",
+			id:      "kubernetes",
+			names:   []string{"k8s_service_kubernetes_default_web"},
+			dnatIDs: []string{"kubernetes"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(ClusterID, tt.env)
+			b := newFakeBasic()
+			b.cloudConfig.ClusterOpts.ID = tt.id
+
+			if names := b.loadBalancerNames("kubernetes", service); !reflect.DeepEqual(names, tt.names) {
+				t.Errorf("loadBalancerNames() = %v, expected %v", names, tt.names)
+			}
+			if ids := b.dnatClusterIDs(); !reflect.DeepEqual(ids, tt.dnatIDs) {
+				t.Errorf("dnatClusterIDs() = %v, expected %v", ids, tt.dnatIDs)
+			}
+			for desc, owned := range tt.ownedDesc {
+				if others := b.ownedByOthers("kubernetes", service, desc); others == owned {
+					t.Errorf("ownedByOthers(%q) = %v, expected %v", desc, others, !owned)
+				}
+			}
+		})
+	}
+}
@@ -21,7 +21,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
@@ -30,9 +29,15 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/cloud-provider"
 	"k8s.io/klog"
 	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
+
+	natapi "sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/nat"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/throttle"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/common"
 )
 
 const (
@@ -45,6 +50,13 @@ const (
 
 type NATCloud struct {
 	Basic
+
+	// natClient and vpcClient are the clients of the NAT gateways and the ports,
+	// the clients of the cloud config are used if they are nil.
+	natClient natapi.NATClient
+	vpcClient natapi.VPCClient
+	// throttler throttles the requests of the clients of the cloud config.
+	throttler *throttle.Throttler
 }
 
 /*
@@ -60,7 +72,7 @@ type NATCloud struct {
 
 func (nat *NATCloud) GetLoadBalancer(ctx context.Context, clusterName string, service *v1.Service) (status *v1.LoadBalancerStatus, exists bool, err error) {
 	status = &v1.LoadBalancerStatus{}
-	natClient, err := nat.getNATClient()
+	natClient, _, err := nat.getNATClient()
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			return nil, false, nil
@@ -74,7 +86,7 @@ func (nat *NATCloud) GetLoadBalancer(ctx context.Context, clusterName string, se
 	if natGatewayId == "" {
 		return nil, false, fmt.Errorf("The id of natGateway should be set by %v in annotations ", AnnotationsNATID)
 	}
-	dnatRuleList, err := listDnatRule(natClient, natGatewayId)
+	dnatRuleList, err := listDnatRule(natClient, natGatewayId, nat.dnatClusterIDs())
 	if err != nil {
 		return nil, false, err
 	}
@@ -93,11 +105,10 @@ func (nat *NATCloud) GetLoadBalancer(ctx context.Context, clusterName string, se
 	return status, true, nil
 }
 
-/*
- *    Not implemented
- */
+// GetLoadBalancerName returns the default name of the Kubernetes cloud providers, the DNAT rules of the service
+// are not named.
 func (nat *NATCloud) GetLoadBalancerName(ctx context.Context, clusterName string, service *v1.Service) string {
-	return ""
+	return cloudprovider.DefaultLoadBalancerName(service)
 }
 
 /*
@@ -109,7 +120,7 @@ func (nat *NATCloud) EnsureLoadBalancer(ctx context.Context, clusterName string,
 	status := &v1.LoadBalancerStatus{}
 
 	// step 0: ensure the nat gateway is exist
-	natProvider, err := nat.getNATClient()
+	natProvider, vpcProvider, err := nat.getNATClient()
 	if err != nil {
 		return nil, err
 	}
@@ -129,12 +140,12 @@ func (nat *NATCloud) EnsureLoadBalancer(ctx context.Context, clusterName string,
 	}
 
 	//step 1:get floatingip id by floatingip address and check the floatingIp can be used
-	dnatRuleList, err := listDnatRule(natProvider, natGatewayId)
+	dnatRuleList, err := listDnatRule(natProvider, natGatewayId, nat.dnatClusterIDs())
 	if err != nil {
 		return nil, err
 	}
 
-	floatingIp, err := nat.getFloatingIpInfoByIp(natProvider, service.Spec.LoadBalancerIP)
+	floatingIp, err := nat.getFloatingIpInfoByIp(vpcProvider, service.Spec.LoadBalancerIP)
 	if err != nil {
 		return nil, err
 	}
@@ -165,8 +176,11 @@ func (nat *NATCloud) EnsureLoadBalancer(ctx context.Context, clusterName string,
 		return nil, fmt.Errorf("There is no availabel endpoint for the service %s", service.Name)
 	}
 
-	subnetId := nat.getSubnetIdForPod(runningPod, hosts)
-	netPort, err := nat.getPortByFixedIp(natProvider, subnetId, runningPod.Status.HostIP)
+	subnetId, err := nat.getSubnetIdForPod(service, runningPod, hosts)
+	if err != nil {
+		return nil, err
+	}
+	netPort, err := nat.getPortByFixedIp(vpcProvider, subnetId, runningPod.Status.HostIP)
 	if err != nil {
 		return nil, err
 	}
@@ -183,7 +197,7 @@ func (nat *NATCloud) EnsureLoadBalancer(ctx context.Context, clusterName string,
 
 		err := nat.ensureCreateDNATRule(natProvider, &port, netPort, floatingIp, natGatewayId)
 		if err != nil {
-			errs = append(errs, fmt.Errorf("EnsureCreateDNATRule Failed: %v", err))
+			errs = append(errs, fmt.Errorf("EnsureCreateDNATRule Failed: %w", err))
 			continue
 		}
 	}
@@ -214,7 +228,7 @@ func (nat *NATCloud) EnsureLoadBalancer(ctx context.Context, clusterName string,
 
 		err := nat.ensureDeleteDNATRule(natProvider, &dnatRule, natGatewayId)
 		if err != nil {
-			errs = append(errs, fmt.Errorf("EnsureDeleteDNATRule Failed: %v", err))
+			errs = append(errs, fmt.Errorf("EnsureDeleteDNATRule Failed: %w", err))
 			continue
 		}
 	}
@@ -226,7 +240,7 @@ func (nat *NATCloud) EnsureLoadBalancer(ctx context.Context, clusterName string,
 	return status, nil
 }
 
-func (nat *NATCloud) getServicePort(dnatRule *DNATRule, ports []v1.ServicePort) *v1.ServicePort {
+func (nat *NATCloud) getServicePort(dnatRule *natapi.DNATRule, ports []v1.ServicePort) *v1.ServicePort {
 	for _, port := range ports {
 		if dnatRule.ExternalServicePort == port.Port &&
 			dnatRule.InternalServicePort == port.NodePort &&
@@ -237,19 +251,20 @@ func (nat *NATCloud) getServicePort(dnatRule *DNATRule, ports []v1.ServicePort)
 	return nil
 }
 
-func listDnatRule(natProvider *NATClient, natGatewayId string) (*DNATRuleList, error) {
+// listDnatRule returns the DNAT rules of the NAT gateway created by the cluster of the IDs, see dnatClusterIDs.
+func listDnatRule(natProvider natapi.NATClient, natGatewayId string, clusterIDs []string) (*natapi.DNATRuleList, error) {
 	params := map[string]string{"nat_gateway_id": natGatewayId}
 	dnatRuleList, err := natProvider.ListDNATRules(params)
 	if err != nil {
 		return nil, err
 	}
-	clusterID := os.Getenv(ClusterID)
-	var distList DNATRuleList
+	ids := sets.NewString(clusterIDs...)
+	var distList natapi.DNATRuleList
 	for _, rule := range dnatRuleList.DNATRules {
 		if rule.Description != "" {
 			desc := getDNATRuleDescription(rule.Description)
 			if desc != nil {
-				if desc.ClusterID == clusterID {
+				if ids.Has(desc.ClusterID) {
 					distList.DNATRules = append(distList.DNATRules, rule)
 				}
 			}
@@ -258,7 +273,7 @@ func listDnatRule(natProvider *NATClient, natGatewayId string) (*DNATRuleList, e
 	return &distList, nil
 }
 
-func listAllDnatRuleByFloatIP(natProvider *NATClient, floatIP string) (*DNATRuleList, error) {
+func listAllDnatRuleByFloatIP(natProvider natapi.NATClient, floatIP string) (*natapi.DNATRuleList, error) {
 	params := map[string]string{"floating_ip_address": floatIP}
 	dnatRuleList, err := natProvider.ListDNATRules(params)
 	if err != nil {
@@ -269,11 +284,11 @@ func listAllDnatRuleByFloatIP(natProvider *NATClient, floatIP string) (*DNATRule
 
 // update members in the service
 //
-//	(1) find the previous DNATRule
+//	(1) find the previous natapi.DNATRule
 //	(2) check whether the node whose port set in the rule is health
 //	(3) if not health delete the previous and create a new one
 func (nat *NATCloud) UpdateLoadBalancer(ctx context.Context, clusterName string, service *v1.Service, nodes []*v1.Node) error {
-	natProvider, err := nat.getNATClient()
+	natProvider, vpcProvider, err := nat.getNATClient()
 	if err != nil {
 		return err
 	}
@@ -293,12 +308,12 @@ func (nat *NATCloud) UpdateLoadBalancer(ctx context.Context, clusterName string,
 	}
 
 	//get floatingip id by floatingip address and check if it can be used
-	dnatRuleList, err := listDnatRule(natProvider, natGatewayId)
+	dnatRuleList, err := listDnatRule(natProvider, natGatewayId, nat.dnatClusterIDs())
 	if err != nil {
 		return err
 	}
 
-	floatingIp, err := nat.getFloatingIpInfoByIp(natProvider, service.Spec.LoadBalancerIP)
+	floatingIp, err := nat.getFloatingIpInfoByIp(vpcProvider, service.Spec.LoadBalancerIP)
 	if err != nil {
 		return err
 	}
@@ -330,7 +345,7 @@ func (nat *NATCloud) UpdateLoadBalancer(ctx context.Context, clusterName string,
 			dnatRule := nat.getDNATRule(dnatRuleList, &servicePort)
 			if dnatRule != nil {
 				if err = nat.ensureDeleteDNATRule(natProvider, dnatRule, natGatewayId); err != nil {
-					errs = append(errs, fmt.Errorf("UpdateDNATRule Failed: %v", err))
+					errs = append(errs, fmt.Errorf("UpdateDNATRule Failed: %w", err))
 					continue
 				}
 			}
@@ -341,15 +356,21 @@ func (nat *NATCloud) UpdateLoadBalancer(ctx context.Context, clusterName string,
 		return nil
 	}
 
-	subnetId := nat.getSubnetIdForPod(runningPod, nodes)
-	netPort, err := nat.getPortByFixedIp(natProvider, subnetId, runningPod.Status.HostIP)
+	subnetId, err := nat.getSubnetIdForPod(service, runningPod, nodes)
+	if err != nil {
+		return err
+	}
+	netPort, err := nat.getPortByFixedIp(vpcProvider, subnetId, runningPod.Status.HostIP)
 	if err != nil {
 		return err
 	}
 	for _, servicePort := range service.Spec.Ports {
 		dnatRule := nat.getDNATRule(dnatRuleList, &servicePort)
 		if dnatRule != nil {
-			networkPort, err := natProvider.GetPort(dnatRule.PortId)
+			// the DNAT rules of the ports of a service share the port of the node, it is queried once.
+			networkPort, err := memoize(nat.context(), "port/"+dnatRule.PortId, func() (*natapi.Port, error) {
+				return vpcProvider.GetPort(dnatRule.PortId)
+			})
 			if err != nil {
 				errs = append(errs, err)
 				continue
@@ -358,16 +379,19 @@ func (nat *NATCloud) UpdateLoadBalancer(ctx context.Context, clusterName string,
 				errs = append(errs, fmt.Errorf("The port has no ipAddress binded "))
 				continue
 			}
-			node, err := nat.kubeClient.Nodes().Get(context.TODO(), networkPort.FixedIps[0].IpAddress, metav1.GetOptions{})
+			nodeName := networkPort.FixedIps[0].IpAddress
+			node, err := memoize(nat.context(), "node/"+nodeName, func() (*v1.Node, error) {
+				return nat.kubeClient.Nodes().Get(nat.context(), nodeName, metav1.GetOptions{})
+			})
 			if err != nil {
 				klog.Errorf("Get node(%s) error: %v", networkPort.FixedIps[0].IpAddress, err)
 				continue
 			}
-			status, err := CheckNodeHealth(node)
-			if !status || err != nil {
-				klog.Warningf("The node %v is not ready. %v", node.Name, err)
+			status := nat.nodeHealthy(node)
+			if !status {
+				klog.Warningf("The node %v is not ready.", node.Name)
 				if err = nat.ensureDeleteDNATRule(natProvider, dnatRule, natGatewayId); err != nil {
-					errs = append(errs, fmt.Errorf("UpdateDNATRule Failed: %v", err))
+					errs = append(errs, fmt.Errorf("UpdateDNATRule Failed: %w", err))
 					continue
 				}
 			}
@@ -378,7 +402,7 @@ func (nat *NATCloud) UpdateLoadBalancer(ctx context.Context, clusterName string,
 		}
 
 		if err = nat.ensureCreateDNATRule(natProvider, &servicePort, netPort, floatingIp, natGateway.Id); err != nil {
-			errs = append(errs, fmt.Errorf("UpdateDNATRule Failed: %v", err))
+			errs = append(errs, fmt.Errorf("UpdateDNATRule Failed: %w", err))
 			continue
 		}
 
@@ -395,7 +419,7 @@ func (nat *NATCloud) UpdateLoadBalancer(ctx context.Context, clusterName string,
 //	(1) find the DNAT rules of the service
 //	(2) delete the DNAT rule
 func (nat *NATCloud) EnsureLoadBalancerDeleted(ctx context.Context, clusterName string, service *v1.Service) error {
-	natProvider, err := nat.getNATClient()
+	natProvider, _, err := nat.getNATClient()
 	if err != nil {
 		return err
 	}
@@ -403,7 +427,7 @@ func (nat *NATCloud) EnsureLoadBalancerDeleted(ctx context.Context, clusterName
 	if natGatewayId == "" {
 		return fmt.Errorf("The id of natGateway should be set by %v in annotations ", AnnotationsNATID)
 	}
-	dnatRuleList, err := listDnatRule(natProvider, natGatewayId)
+	dnatRuleList, err := listDnatRule(natProvider, natGatewayId, nat.dnatClusterIDs())
 	if err != nil {
 		return err
 	}
@@ -429,9 +453,13 @@ func (nat *NATCloud) EnsureLoadBalancerDeleted(ctx context.Context, clusterName
  *               Util function
  *    >>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>
  */
-func (nat *NATCloud) getNATClient() (*NATClient, error) {
-	authOpts := nat.cloudConfig.AuthOpts
-	return NewNATClient(authOpts.Cloud, authOpts.Region, authOpts.ProjectID, authOpts.AccessKey, authOpts.SecretKey), nil
+// getNATClient returns the clients of the NAT gateways and the ports.
+func (nat *NATCloud) getNATClient() (natapi.NATClient, natapi.VPCClient, error) {
+	if nat.natClient != nil && nat.vpcClient != nil {
+		return nat.natClient, nat.vpcClient, nil
+	}
+	client := natapi.NewNATServiceClient(&nat.cloudConfig.AuthOpts, nat.throttler)
+	return client, client, nil
 }
 
 func (nat *NATCloud) getPods(name, namespace string) (*v1.PodList, error) {
@@ -451,17 +479,17 @@ func (nat *NATCloud) getPods(name, namespace string) (*v1.PodList, error) {
 	return nat.kubeClient.Pods(namespace).List(context.TODO(), opts)
 }
 
-func genDNATRuleDescription() string {
-	desc := &DNATRuleDescription{
-		ClusterID:   os.Getenv(ClusterID),
+func genDNATRuleDescription(clusterID string) string {
+	desc := &natapi.DNATRuleDescription{
+		ClusterID:   clusterID,
 		Description: Attention,
 	}
 	tmp, _ := json.Marshal(desc)
 	return string(tmp)
 }
 
-func getDNATRuleDescription(desc string) *DNATRuleDescription {
-	var description DNATRuleDescription
+func getDNATRuleDescription(desc string) *natapi.DNATRuleDescription {
+	var description natapi.DNATRuleDescription
 	err := json.Unmarshal([]byte(desc), &description)
 	if err != nil {
 		return nil
@@ -469,15 +497,15 @@ func getDNATRuleDescription(desc string) *DNATRuleDescription {
 	return &description
 }
 
-func (nat *NATCloud) ensureCreateDNATRule(natProvider *NATClient, port *v1.ServicePort, netPort *Port, floatingIp *FloatingIp, natGatewayId string) error {
-	dnatRuleConf := &DNATRule{
+func (nat *NATCloud) ensureCreateDNATRule(natProvider natapi.NATClient, port *v1.ServicePort, netPort *natapi.Port, floatingIp *natapi.FloatingIp, natGatewayId string) error {
+	dnatRuleConf := &natapi.DNATRule{
 		NATGatewayId:        natGatewayId,
 		PortId:              netPort.Id,
 		InternalServicePort: port.NodePort,
 		FloatingIpId:        floatingIp.Id,
 		ExternalServicePort: port.Port,
-		Protocol:            NATProtocol(port.Protocol),
-		Description:         genDNATRuleDescription(),
+		Protocol:            natapi.NATProtocol(port.Protocol),
+		Description:         genDNATRuleDescription(nat.dnatClusterID()),
 	}
 
 	_, err := natProvider.CreateDNATRule(dnatRuleConf)
@@ -490,19 +518,19 @@ func (nat *NATCloud) ensureCreateDNATRule(natProvider *NATClient, port *v1.Servi
 // 1.delete the old dnatRule
 // 2.get the new port id
 // 3.create a new dnatRule
-func (nat *NATCloud) ensureDeleteDNATRule(natProvider *NATClient, dnatRule *DNATRule, natGatewayId string) error {
+func (nat *NATCloud) ensureDeleteDNATRule(natProvider natapi.NATClient, dnatRule *natapi.DNATRule, natGatewayId string) error {
 	klog.V(4).Infoln("Delete the DNAT Rule when the node is not ready", dnatRule.FloatingIpAddress+":"+fmt.Sprint(dnatRule.ExternalServicePort))
 	err := natProvider.DeleteDNATRule(dnatRule.Id, natGatewayId)
 	if err != nil {
 		return err
 	}
 
-	return wait.Poll(100*time.Millisecond, 5*time.Second, func() (bool, error) {
+	return wait.PollWithContext(nat.context(), 100*time.Millisecond, 5*time.Second, func(context.Context) (bool, error) {
 		return !nat.checkDNATRuleById(natProvider, dnatRule.Id), nil
 	})
 }
 
-func (nat *NATCloud) checkFloatingIp(dnatRuleList *DNATRuleList, floatingIp *FloatingIp, natGatewayId string) (available bool) {
+func (nat *NATCloud) checkFloatingIp(dnatRuleList *natapi.DNATRuleList, floatingIp *natapi.FloatingIp, natGatewayId string) (available bool) {
 	if floatingIp.PortId == "" {
 		return true
 	}
@@ -513,7 +541,7 @@ func (nat *NATCloud) checkFloatingIp(dnatRuleList *DNATRuleList, floatingIp *Flo
 	return false
 }
 
-func (nat *NATCloud) getDNATRule(dnatRuleList *DNATRuleList, port *v1.ServicePort) *DNATRule {
+func (nat *NATCloud) getDNATRule(dnatRuleList *natapi.DNATRuleList, port *v1.ServicePort) *natapi.DNATRule {
 	for _, dnatRule := range dnatRuleList.DNATRules {
 		if dnatRule.ExternalServicePort == port.Port &&
 			dnatRule.InternalServicePort == port.NodePort &&
@@ -524,18 +552,15 @@ func (nat *NATCloud) getDNATRule(dnatRuleList *DNATRuleList, port *v1.ServicePor
 	return nil
 }
 
-func (nat *NATCloud) checkDNATRuleById(natProvider *NATClient, dnatRuleId string) (exist bool) {
+func (nat *NATCloud) checkDNATRuleById(natProvider natapi.NATClient, dnatRuleId string) (exist bool) {
 	_, err := natProvider.GetDNATRule(dnatRuleId)
-	if err != nil && strings.Contains(err.Error(), "No DNAT rule exist") {
-		return false
-	}
-	return true
+	return !common.IsNotFound(err)
 }
 
-func (nat *NATCloud) getFloatingIpInfoByIp(natProvider *NATClient, ip string) (*FloatingIp, error) {
+func (nat *NATCloud) getFloatingIpInfoByIp(vpcProvider natapi.VPCClient, ip string) (*natapi.FloatingIp, error) {
 	listparams := make(map[string]string)
 	listparams["floating_ip_address"] = ip
-	floatingIpList, err := natProvider.ListFloatings(listparams)
+	floatingIpList, err := vpcProvider.ListFloatings(listparams)
 	if err != nil {
 		return nil, err
 	}
@@ -545,11 +570,11 @@ func (nat *NATCloud) getFloatingIpInfoByIp(natProvider *NATClient, ip string) (*
 	return &floatingIpList.FloatingIps[0], nil
 }
 
-func (nat *NATCloud) getPortByFixedIp(natProvider *NATClient, subnetId string, fixedIp string) (*Port, error) {
+func (nat *NATCloud) getPortByFixedIp(vpcProvider natapi.VPCClient, subnetId string, fixedIp string) (*natapi.Port, error) {
 	listparams := make(map[string]string)
 	listparams["network_id"] = subnetId
 	listparams["fixed_ips=ip_address"] = fixedIp
-	netPortList, err := natProvider.ListPorts(listparams)
+	netPortList, err := vpcProvider.ListPorts(listparams)
 	if err != nil {
 		return nil, err
 	}
@@ -559,32 +584,22 @@ func (nat *NATCloud) getPortByFixedIp(natProvider *NATClient, subnetId string, f
 	return &netPortList.Ports[0], nil
 }
 
-func (nat *NATCloud) getSubnetIdForPod(pod v1.Pod, nodes []*v1.Node) string {
-	var (
-		nodeRunningPod *v1.Node
-		subnetId       string
-	)
-
-	for _, node := range nodes {
-		for _, address := range node.Status.Addresses {
-			if address.Type == v1.NodeInternalIP && address.Address == pod.Status.HostIP {
-				nodeRunningPod = node
-			}
-		}
-		if nodeRunningPod != nil {
-			break
-		}
-	}
-
-	subnetId = nat.cloudConfig.VpcOpts.SubnetID
-	if nodeRunningPod != nil {
+// getSubnetIdForPod returns the subnet of the node of the pod, the subnet of the node label is checked against
+// the VPC of the cloud config.
+func (nat *NATCloud) getSubnetIdForPod(service *v1.Service, pod v1.Pod, nodes []*v1.Node) (string, error) {
+	subnetId := nat.cloudConfig.VpcOpts.SubnetID
+	if nodeRunningPod, ok := nat.nodes.getByAddress(pod.Status.HostIP, nodes); ok {
 		nodeSubnetId, ok := nodeRunningPod.Labels[NodeSubnetIDLabelKey]
 		if ok {
+			source := fmt.Sprintf("label %s of node %s", NodeSubnetIDLabelKey, nodeRunningPod.Name)
+			if err := nat.checkSubnet(service, nodeSubnetId, source); err != nil {
+				return "", err
+			}
 			subnetId = nodeSubnetId
 		}
 	}
 
-	return subnetId
+	return subnetId, nil
 }
 
 // if the node not health, it will not be added to ELB
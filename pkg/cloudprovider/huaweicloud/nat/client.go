@@ -0,0 +1,468 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package nat implements the client of the NAT gateway, port and floating IP APIs used by the dnat load balancers.
+// nolint:golint // stop check lint issues as this file will be refactored
+package nat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	eipmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/eip/v2/model"
+	natmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/nat/v2/model"
+	vpcmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/vpc/v2/model"
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/throttle"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/wrapper"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+)
+
+type NATProtocol string
+
+const (
+	NATProtocolTCP NATProtocol = "TCP"
+	NATProtocolUDP NATProtocol = "UDP"
+)
+
+type NATStatus string
+
+const (
+	NATStatusActive        NATStatus = "ACTIVE"
+	NATStatusError         NATStatus = "ERROR"
+	NATStatusPendingCreate NATStatus = "PENDING_CREATE"
+	NATStatusPendingUpdate NATStatus = "PENDING_UPDATE"
+	NATStatusPendingDelete NATStatus = "PENDING_DELETE"
+)
+
+type NATSpec string
+
+const (
+	NATSpecSmall      NATSpec = "1"
+	NATSpecMedium     NATSpec = "2"
+	NATSpecLarge      NATSpec = "3"
+	NATSpecExtraLarge NATSpec = "4"
+)
+
+type DNATRuleStatus string
+
+const (
+	DNATRuleStatusActive        DNATRuleStatus = "ACTIVE"
+	DNATRuleStatusError         DNATRuleStatus = "ERROR"
+	DNATRuleStatusPendingCreate DNATRuleStatus = "PENDING_CREATE"
+	DNATRuleStatusPendingUpdate DNATRuleStatus = "PENDING_UPDATE"
+	DNATRuleStatusPendingDelete DNATRuleStatus = "PENDING_DELETE"
+)
+
+type PortStatus string
+
+const (
+	PortStatusActive PortStatus = "ACTIVE"
+	PortStatusBuild  PortStatus = "BUILD"
+	PortStatusDown   PortStatus = "DOWN"
+)
+
+type FloatingIpStatus string
+
+const (
+	FloatingIpStatusActive FloatingIpStatus = "ACTIVE"
+	FloatingIpStatusError  FloatingIpStatus = "ERROR"
+	FloatingIpStatusDown   FloatingIpStatus = "DOWN"
+)
+
+// NAT Gateway
+type NATGateway struct {
+	Id                string    `json:"id,omitempty"`
+	Name              string    `json:"name,omitempty"`
+	Description       string    `json:"description,omitempty"`
+	RouterId          string    `json:"router_id,omitempty"`
+	InternalNetWorkId string    `json:"internal_network_id,omitempty"`
+	Status            NATStatus `json:"status,omitempty"`
+	Spec              NATSpec   `json:"spec,omitempty"`
+	TenantId          string    `json:"tenant_id,omitempty"`
+	AdminStateUp      bool      `json:"admin_state_up,omitempty"`
+}
+
+type NATArr struct {
+	NATGateway NATGateway `json:"nat_gateway"`
+}
+
+// list type
+type NATGatewayList struct {
+	NATGateways []NATGateway `json:"nat_gateways"`
+}
+
+type DNATRuleDescription struct {
+	ClusterID   string `json:"cluster_id,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// DNA Rule
+type DNATRule struct {
+	Id                  string         `json:"id,omitempty"`
+	TenantId            string         `json:"tenant_id,omitempty"`
+	NATGatewayId        string         `json:"nat_gateway_id,omitempty"`
+	PortId              string         `json:"port_id,omitempty"`
+	InternalServicePort int32          `json:"internal_service_port,omitempty"`
+	FloatingIpId        string         `json:"floating_ip_id,omitempty"`
+	ExternalServicePort int32          `json:"external_service_port,omitempty"`
+	FloatingIpAddress   string         `json:"floating_ip_address,omitempty"`
+	Protocol            NATProtocol    `json:"protocol,omitempty"`
+	Status              DNATRuleStatus `json:"status,omitempty"`
+	AdminStateUp        bool           `json:"admin_state_up,omitempty"`
+	Description         string         `json:"description,omitempty"`
+}
+
+type DNATRuleArr struct {
+	DNATRule DNATRule `json:"dnat_rule"`
+}
+
+// list type
+type DNATRuleList struct {
+	DNATRules []DNATRule `json:"dnat_rules"`
+}
+
+type Port struct {
+	Id                  string              `json:"id,omitempty"`
+	Name                string              `json:"name,omitempty"`
+	NetworkId           string              `json:"network_id,omitempty"`
+	AdminStateUp        bool                `json:"admin_state_up,omitempty"`
+	MacAddress          string              `json:"mac_address,omitempty"`
+	FixedIps            []*FixedIp          `json:"fixed_ips,omitempty"`
+	DeviceId            string              `json:"device_id,omitempty"`
+	DeviceOwner         string              `json:"device_owner,omitempty"`
+	TenantId            string              `json:"tenant_id,omitempty"`
+	Status              PortStatus          `json:"status,omitempty"`
+	SecurityGroups      []string            `json:"security_groups,omitempty"`
+	AllowedAddressPairs []*AllowAddressPair `json:"allow_address_pairs,omitempty"`
+}
+
+type PortArr struct {
+	Port Port `json:"port"`
+}
+
+type PortList struct {
+	Ports []Port `json:"ports,omitempty"`
+}
+
+type FixedIp struct {
+	SubnetId  string `json:"subnet_id,omitempty"`
+	IpAddress string `json:"ip_address"`
+}
+
+type AllowAddressPair struct {
+	IpAddress  string `json:"ip_address,omitempty"`
+	MacAddress string `json:"mac_address,omitempty"`
+	OptName    string `json:"opt_name,omitempty"`
+	OptValue   string `json:"opt_value,omitempty"`
+}
+
+type FloatingIp struct {
+	Id                string           `json:"id,omitempty"`
+	Status            FloatingIpStatus `json:"status,omitempty"`
+	FloatingIpAddress string           `json:"floating_ip_address,omitempty"`
+	FloatingNetworkId string           `json:"floating_network_id,omitempty"`
+	RouterId          string           `json:"router_id,omitempty"`
+	PortId            string           `json:"port_id,omitempty"`
+	FixedIpAddress    string           `json:"fixed_ip_address,omitempty"`
+	TenantId          string           `json:"tenant_id,omitempty"`
+}
+
+type FloatingIpArr struct {
+	FloatingIp FloatingIp `json:"floatingip"`
+}
+
+type FloatingIpList struct {
+	FloatingIps []FloatingIp `json:"floatingips,omitempty"`
+}
+
+// NATServiceClient is the client of the NAT gateways, the ports and the floating IPs with the SDK, it converts
+// the SDK models to the models used by the NATCloud.
+type NATServiceClient struct {
+	natClient *wrapper.NatClient
+	vpcClient *wrapper.VpcClient
+	eipClient *wrapper.EIpClient
+	throttler *throttle.Throttler
+}
+
+// NewNATServiceClient returns the client with the options, the requests of the NAT gateways are throttled by
+// the throttler if it is not nil.
+func NewNATServiceClient(authOpts *config.AuthOptions, throttler *throttle.Throttler) *NATServiceClient {
+	return &NATServiceClient{
+		natClient: &wrapper.NatClient{AuthOpts: authOpts},
+		vpcClient: &wrapper.VpcClient{AuthOpts: authOpts},
+		eipClient: &wrapper.EIpClient{AuthOpts: authOpts},
+		throttler: throttler,
+	}
+}
+
+// accept waits for the throttle of the API, the requests are throttled the same as the hand-rolled client.
+func (nat *NATServiceClient) accept(key throttle.ThrottleType) {
+	if nat.throttler == nil {
+		return
+	}
+	if limiter := nat.throttler.GetThrottleByKey(key); limiter != nil {
+		limiter.Accept()
+	}
+}
+
+/*
+ *    >>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>
+ *               NAT implement of functions regrding NAT gateway
+ *    >>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>
+ */
+func (nat *NATServiceClient) GetNATGateway(natGatewayId string) (*NATGateway, error) {
+	nat.accept(throttle.NAT_GATEWAY_GET)
+	gateway, err := nat.natClient.GetNatGateway(natGatewayId)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to GetNATGateway %s: %w", natGatewayId, err)
+	}
+	return toNATGateway(gateway), nil
+}
+
+func (nat *NATServiceClient) ListNATGateways(params map[string]string) (*NATGatewayList, error) {
+	req := &natmodel.ListNatGatewaysRequest{}
+	for key, value := range params {
+		value := value
+		switch key {
+		case "id":
+			req.Id = &value
+		case "name":
+			req.Name = &value
+		case "router_id":
+			req.RouterId = &value
+		case "internal_network_id":
+			req.InternalNetworkId = &value
+		default:
+			return nil, fmt.Errorf("unsupported filter %q of the NAT gateways", key)
+		}
+	}
+
+	nat.accept(throttle.NAT_GATEWAY_LIST)
+	gateways, err := nat.natClient.ListNatGateways(req)
+	if err != nil {
+		return nil, err
+	}
+	list := &NATGatewayList{}
+	for i := range gateways {
+		list.NATGateways = append(list.NATGateways, *toNATGateway(&gateways[i]))
+	}
+	return list, nil
+}
+
+func (nat *NATServiceClient) CreateDNATRule(dnatRuleConf *DNATRule) (*DNATRule, error) {
+	nat.accept(throttle.NAT_RULE_CREATE)
+	rule, err := nat.natClient.CreateDnatRule(&natmodel.CreateNatGatewayDnatOption{
+		Description:         &dnatRuleConf.Description,
+		PortId:              &dnatRuleConf.PortId,
+		NatGatewayId:        dnatRuleConf.NATGatewayId,
+		InternalServicePort: dnatRuleConf.InternalServicePort,
+		FloatingIpId:        dnatRuleConf.FloatingIpId,
+		ExternalServicePort: dnatRuleConf.ExternalServicePort,
+		Protocol:            strings.ToLower(string(dnatRuleConf.Protocol)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to CreateDNATRule: %w", err)
+	}
+	return toDNATRule(rule), nil
+}
+
+func (nat *NATServiceClient) DeleteDNATRule(dnatRuleId string, natGatewayId string) error {
+	nat.accept(throttle.NAT_RULE_DELETE)
+	if err := nat.natClient.DeleteDnatRule(natGatewayId, dnatRuleId); err != nil {
+		return fmt.Errorf("Failed to DeleteDNATRule %s: %w", dnatRuleId, err)
+	}
+	return nil
+}
+
+func (nat *NATServiceClient) GetDNATRule(dnatRuleId string) (*DNATRule, error) {
+	nat.accept(throttle.NAT_RULE_GET)
+	rule, err := nat.natClient.GetDnatRule(dnatRuleId)
+	if err != nil {
+		return nil, err
+	}
+	return toDNATRule(rule), nil
+}
+
+func (nat *NATServiceClient) ListDNATRules(params map[string]string) (*DNATRuleList, error) {
+	req := &natmodel.ListNatGatewayDnatRulesRequest{}
+	for key, value := range params {
+		value := value
+		switch key {
+		case "nat_gateway_id":
+			req.NatGatewayId = &[]string{value}
+		case "floating_ip_address":
+			req.FloatingIpAddress = &value
+		case "floating_ip_id":
+			req.FloatingIpId = &value
+		case "port_id":
+			req.PortId = &value
+		default:
+			return nil, fmt.Errorf("unsupported filter %q of the DNAT rules", key)
+		}
+	}
+
+	nat.accept(throttle.NAT_RULE_LIST)
+	rules, err := nat.natClient.ListDnatRules(req)
+	if err != nil {
+		return nil, err
+	}
+	list := &DNATRuleList{}
+	for i := range rules {
+		list.DNATRules = append(list.DNATRules, *toDNATRule(&rules[i]))
+	}
+	return list, nil
+}
+
+func (nat *NATServiceClient) ListPorts(params map[string]string) (*PortList, error) {
+	req := &vpcmodel.ListPortsRequest{}
+	for key, value := range params {
+		value := value
+		switch key {
+		case "network_id":
+			req.NetworkId = &value
+		case "device_id":
+			req.DeviceId = &value
+		case "fixed_ips=ip_address":
+			fixedIPs := "ip_address=" + value
+			req.FixedIps = &fixedIPs
+		default:
+			return nil, fmt.Errorf("unsupported filter %q of the ports", key)
+		}
+	}
+
+	ports, err := nat.vpcClient.ListPorts(req)
+	if err != nil {
+		return nil, err
+	}
+	list := &PortList{}
+	for i := range ports {
+		list.Ports = append(list.Ports, *toPort(&ports[i]))
+	}
+	return list, nil
+}
+
+func (nat *NATServiceClient) GetPort(portId string) (*Port, error) {
+	port, err := nat.vpcClient.GetPort(portId)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to GetPort %s: %w", portId, err)
+	}
+	return toPort(port), nil
+}
+
+func (nat *NATServiceClient) ListFloatings(params map[string]string) (*FloatingIpList, error) {
+	req := &eipmodel.NeutronListFloatingIpsRequest{}
+	for key, value := range params {
+		value := value
+		switch key {
+		case "floating_ip_address":
+			req.FloatingIpAddress = &value
+		case "port_id":
+			req.PortId = &value
+		default:
+			return nil, fmt.Errorf("unsupported filter %q of the floating IPs", key)
+		}
+	}
+
+	floatingIPs, err := nat.eipClient.ListFloatingIPs(req)
+	if err != nil {
+		return nil, err
+	}
+	list := &FloatingIpList{}
+	for _, ip := range floatingIPs {
+		list.FloatingIps = append(list.FloatingIps, FloatingIp{
+			Id:                pointer.StringDeref(ip.Id, ""),
+			Status:            FloatingIpStatus(enumValue(ip.Status)),
+			FloatingIpAddress: pointer.StringDeref(ip.FloatingIpAddress, ""),
+			FloatingNetworkId: pointer.StringDeref(ip.FloatingNetworkId, ""),
+			RouterId:          pointer.StringDeref(ip.RouterId, ""),
+			PortId:            pointer.StringDeref(ip.PortId, ""),
+			FixedIpAddress:    pointer.StringDeref(ip.FixedIpAddress, ""),
+			TenantId:          pointer.StringDeref(ip.TenantId, ""),
+		})
+	}
+	return list, nil
+}
+
+func toNATGateway(gateway *natmodel.NatGatewayResponseBody) *NATGateway {
+	return &NATGateway{
+		Id:                gateway.Id,
+		Name:              gateway.Name,
+		Description:       gateway.Description,
+		RouterId:          gateway.RouterId,
+		InternalNetWorkId: gateway.InternalNetworkId,
+		Status:            NATStatus(enumValue(&gateway.Status)),
+		Spec:              NATSpec(enumValue(&gateway.Spec)),
+		TenantId:          gateway.TenantId,
+		AdminStateUp:      gateway.AdminStateUp,
+	}
+}
+
+func toDNATRule(rule *natmodel.NatGatewayDnatRuleResponseBody) *DNATRule {
+	return &DNATRule{
+		Id:                  rule.Id,
+		TenantId:            rule.TenantId,
+		NATGatewayId:        rule.NatGatewayId,
+		PortId:              pointer.StringDeref(rule.PortId, ""),
+		InternalServicePort: rule.InternalServicePort,
+		FloatingIpId:        rule.FloatingIpId,
+		ExternalServicePort: rule.ExternalServicePort,
+		FloatingIpAddress:   rule.FloatingIpAddress,
+		Protocol:            NATProtocol(strings.ToUpper(enumValue(&rule.Protocol))),
+		Status:              DNATRuleStatus(enumValue(&rule.Status)),
+		AdminStateUp:        rule.AdminStateUp,
+		Description:         rule.Description,
+	}
+}
+
+func toPort(port *vpcmodel.Port) *Port {
+	rst := &Port{
+		Id:             port.Id,
+		Name:           port.Name,
+		NetworkId:      port.NetworkId,
+		AdminStateUp:   port.AdminStateUp,
+		MacAddress:     port.MacAddress,
+		DeviceId:       port.DeviceId,
+		DeviceOwner:    enumValue(&port.DeviceOwner),
+		TenantId:       port.TenantId,
+		Status:         PortStatus(enumValue(&port.Status)),
+		SecurityGroups: port.SecurityGroups,
+	}
+	for _, ip := range port.FixedIps {
+		rst.FixedIps = append(rst.FixedIps, &FixedIp{
+			SubnetId:  pointer.StringDeref(ip.SubnetId, ""),
+			IpAddress: pointer.StringDeref(ip.IpAddress, ""),
+		})
+	}
+	for _, pair := range port.AllowedAddressPairs {
+		rst.AllowedAddressPairs = append(rst.AllowedAddressPairs, &AllowAddressPair{
+			IpAddress:  pair.IpAddress,
+			MacAddress: pointer.StringDeref(pair.MacAddress, ""),
+		})
+	}
+	return rst
+}
+
+// enumValue returns the value of an enum of the SDK, which is only exported by its JSON encoding.
+func enumValue(enum json.Marshaler) string {
+	var value string
+	if b, err := json.Marshal(enum); err == nil {
+		_ = json.Unmarshal(b, &value)
+	}
+	return value
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nat
+
+// NATClient is the client of the NAT gateway APIs used by the dnat load balancers.
+// It is implemented by the NATServiceClient and by the FakeNATClient of the unit tests.
+type NATClient interface {
+	GetNATGateway(natGatewayId string) (*NATGateway, error)
+	ListNATGateways(params map[string]string) (*NATGatewayList, error)
+	CreateDNATRule(dnatRuleConf *DNATRule) (*DNATRule, error)
+	DeleteDNATRule(dnatRuleId string, natGatewayId string) error
+	GetDNATRule(dnatRuleId string) (*DNATRule, error)
+	ListDNATRules(params map[string]string) (*DNATRuleList, error)
+}
+
+// VPCClient is the client of the port and floating IP APIs used by the dnat load balancers.
+// It is implemented by the NATServiceClient and by the FakeVPCClient of the unit tests.
+type VPCClient interface {
+	ListPorts(params map[string]string) (*PortList, error)
+	GetPort(portId string) (*Port, error)
+	ListFloatings(params map[string]string) (*FloatingIpList, error)
+}
+
+var (
+	_ NATClient = &NATServiceClient{}
+	_ VPCClient = &NATServiceClient{}
+)
@@ -0,0 +1,154 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nat
+
+import "sync"
+
+// The fakes of the clients call the functions of the method names with the suffix Func, the methods whose
+// functions are nil return the zero values. The calls are counted by the method names.
+
+// FakeNATClient is the fake of the NATClient.
+type FakeNATClient struct {
+	lock  sync.Mutex
+	calls map[string]int
+
+	GetNATGatewayFunc   func(natGatewayId string) (*NATGateway, error)
+	ListNATGatewaysFunc func(params map[string]string) (*NATGatewayList, error)
+	CreateDNATRuleFunc  func(dnatRuleConf *DNATRule) (*DNATRule, error)
+	DeleteDNATRuleFunc  func(dnatRuleId string, natGatewayId string) error
+	GetDNATRuleFunc     func(dnatRuleId string) (*DNATRule, error)
+	ListDNATRulesFunc   func(params map[string]string) (*DNATRuleList, error)
+}
+
+func (f *FakeNATClient) called(method string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.calls == nil {
+		f.calls = make(map[string]int)
+	}
+	f.calls[method]++
+}
+
+// CallCount returns the number of the calls of the method.
+func (f *FakeNATClient) CallCount(method string) int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.calls[method]
+}
+
+func (f *FakeNATClient) GetNATGateway(natGatewayId string) (*NATGateway, error) {
+	f.called("GetNATGateway")
+	if f.GetNATGatewayFunc == nil {
+		return nil, nil
+	}
+	return f.GetNATGatewayFunc(natGatewayId)
+}
+
+func (f *FakeNATClient) ListNATGateways(params map[string]string) (*NATGatewayList, error) {
+	f.called("ListNATGateways")
+	if f.ListNATGatewaysFunc == nil {
+		return nil, nil
+	}
+	return f.ListNATGatewaysFunc(params)
+}
+
+func (f *FakeNATClient) CreateDNATRule(dnatRuleConf *DNATRule) (*DNATRule, error) {
+	f.called("CreateDNATRule")
+	if f.CreateDNATRuleFunc == nil {
+		return nil, nil
+	}
+	return f.CreateDNATRuleFunc(dnatRuleConf)
+}
+
+func (f *FakeNATClient) DeleteDNATRule(dnatRuleId string, natGatewayId string) error {
+	f.called("DeleteDNATRule")
+	if f.DeleteDNATRuleFunc == nil {
+		return nil
+	}
+	return f.DeleteDNATRuleFunc(dnatRuleId, natGatewayId)
+}
+
+func (f *FakeNATClient) GetDNATRule(dnatRuleId string) (*DNATRule, error) {
+	f.called("GetDNATRule")
+	if f.GetDNATRuleFunc == nil {
+		return nil, nil
+	}
+	return f.GetDNATRuleFunc(dnatRuleId)
+}
+
+func (f *FakeNATClient) ListDNATRules(params map[string]string) (*DNATRuleList, error) {
+	f.called("ListDNATRules")
+	if f.ListDNATRulesFunc == nil {
+		return nil, nil
+	}
+	return f.ListDNATRulesFunc(params)
+}
+
+// FakeVPCClient is the fake of the VPCClient.
+type FakeVPCClient struct {
+	lock  sync.Mutex
+	calls map[string]int
+
+	ListPortsFunc     func(params map[string]string) (*PortList, error)
+	GetPortFunc       func(portId string) (*Port, error)
+	ListFloatingsFunc func(params map[string]string) (*FloatingIpList, error)
+}
+
+func (f *FakeVPCClient) called(method string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.calls == nil {
+		f.calls = make(map[string]int)
+	}
+	f.calls[method]++
+}
+
+// CallCount returns the number of the calls of the method.
+func (f *FakeVPCClient) CallCount(method string) int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.calls[method]
+}
+
+func (f *FakeVPCClient) ListPorts(params map[string]string) (*PortList, error) {
+	f.called("ListPorts")
+	if f.ListPortsFunc == nil {
+		return nil, nil
+	}
+	return f.ListPortsFunc(params)
+}
+
+func (f *FakeVPCClient) GetPort(portId string) (*Port, error) {
+	f.called("GetPort")
+	if f.GetPortFunc == nil {
+		return nil, nil
+	}
+	return f.GetPortFunc(portId)
+}
+
+func (f *FakeVPCClient) ListFloatings(params map[string]string) (*FloatingIpList, error) {
+	f.called("ListFloatings")
+	if f.ListFloatingsFunc == nil {
+		return nil, nil
+	}
+	return f.ListFloatingsFunc(params)
+}
+
+var (
+	_ NATClient = &FakeNATClient{}
+	_ VPCClient = &FakeVPCClient{}
+)
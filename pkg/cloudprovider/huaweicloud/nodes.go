@@ -0,0 +1,241 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// nodeAddressIndex indexes the nodes by their internal IP addresses, which are the host IPs of the pods.
+const nodeAddressIndex = "address"
+
+// nodeCache holds the nodes in the cache of an informer, the members of the load balancers are built from
+// the latest status of the nodes in it. The nodes are looked up among the nodes passed by the service controller,
+// which are the nodes eligible for the load balancers, the methods of a nil cache fall back to the nodes passed in.
+type nodeCache struct {
+	informer cache.SharedIndexInformer
+	lister   corelisters.NodeLister
+}
+
+func newNodeCache(client corev1.NodesGetter) *nodeCache {
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return client.Nodes().List(context.TODO(), options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return client.Nodes().Watch(context.TODO(), options)
+		},
+	}
+	informer := cache.NewSharedIndexInformer(lw, &v1.Node{}, 0, cache.Indexers{nodeAddressIndex: indexNodeAddress})
+	return &nodeCache{
+		informer: informer,
+		lister:   corelisters.NewNodeLister(informer.GetIndexer()),
+	}
+}
+
+func indexNodeAddress(obj interface{}) ([]string, error) {
+	node, ok := obj.(*v1.Node)
+	if !ok {
+		return nil, nil
+	}
+	var addresses []string
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == v1.NodeInternalIP {
+			addresses = append(addresses, addr.Address)
+		}
+	}
+	return addresses, nil
+}
+
+// run runs the informer until the stop channel is closed, onChange is called when a node is added or removed,
+// or its readiness, eligibility, addresses or subnet label are changed, which changes the members of the load
+// balancers.
+func (c *nodeCache) run(stop <-chan struct{}, onChange func(node *v1.Node)) error {
+	_, err := c.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			// The nodes listed on start are not changes.
+			if node, ok := obj.(*v1.Node); ok && c.hasSynced() {
+				klog.Infof("node %s is added", node.Name)
+				onChange(node)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldNode, newNode := oldObj.(*v1.Node), newObj.(*v1.Node)
+			if reflect.DeepEqual(oldNode.Status.Addresses, newNode.Status.Addresses) &&
+				oldNode.Labels[NodeSubnetIDLabelKey] == newNode.Labels[NodeSubnetIDLabelKey] &&
+				isNodeReady(oldNode) == isNodeReady(newNode) && nodeEligible(oldNode) == nodeEligible(newNode) {
+				return
+			}
+			klog.Infof("the readiness, the eligibility, the addresses or the subnet of node %s are changed",
+				newNode.Name)
+			onChange(newNode)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if node, ok := obj.(*v1.Node); ok {
+				klog.Infof("node %s is removed", node.Name)
+				onChange(node)
+			}
+		},
+	})
+	if err != nil {
+		return err
+	}
+	go c.informer.Run(stop)
+	return nil
+}
+
+func isNodeReady(node *v1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == v1.NodeReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// nodeHealthy returns whether the node serves the load balancers as CheckNodeHealth does, except that a NotReady
+// node is still served for the not-ready-grace-period of the node options since it turned NotReady.
+func (b Basic) nodeHealthy(node *v1.Node) bool {
+	if healthy, _ := CheckNodeHealth(node); healthy || node.Spec.Unschedulable {
+		return healthy
+	}
+	grace := b.nodeOpts().GetNotReadyGracePeriod()
+	for _, cond := range node.Status.Conditions {
+		if cond.Type != v1.NodeReady || grace <= 0 {
+			continue
+		}
+		if notReady := time.Since(cond.LastTransitionTime.Time); notReady < grace {
+			klog.Infof("node %s is NotReady for %s, it is still served within the grace period of %s",
+				node.Name, notReady.Round(time.Second), grace)
+			return true
+		}
+	}
+	return false
+}
+
+func (c *nodeCache) hasSynced() bool {
+	return c.informer.HasSynced()
+}
+
+// get returns the node of the name in the nodes passed in, which are the nodes eligible for the load balancers,
+// the node in the cache is returned if it is synced, as it has the latest status of the node.
+func (c *nodeCache) get(name string, nodes []*v1.Node) (*v1.Node, bool) {
+	for _, node := range nodes {
+		if node.Name != name {
+			continue
+		}
+		if cached, ok := c.lookup(name); ok {
+			return cached, true
+		}
+		return node, true
+	}
+	return nil, false
+}
+
+// lookup returns the node of the name in the cache, false if the cache is not synced yet.
+func (c *nodeCache) lookup(name string) (*v1.Node, bool) {
+	if c == nil || !c.hasSynced() {
+		return nil, false
+	}
+	node, err := c.lister.Get(name)
+	if err != nil {
+		klog.V(4).Infof("node %s is not found in the cache: %s", name, err)
+		return nil, false
+	}
+	return node, true
+}
+
+// getByAddress returns the node with the internal IP address, such as the host IP of a pod, in the nodes passed in.
+// The cache is looked up first, as the addresses of the nodes passed in may be outdated.
+func (c *nodeCache) getByAddress(address string, nodes []*v1.Node) (*v1.Node, bool) {
+	if c != nil && c.hasSynced() {
+		objs, err := c.informer.GetIndexer().ByIndex(nodeAddressIndex, address)
+		if err == nil {
+			for _, obj := range objs {
+				if node := obj.(*v1.Node); containsNode(nodes, node.Name) {
+					return node, true
+				}
+			}
+		}
+	}
+	for _, node := range nodes {
+		for _, addr := range node.Status.Addresses {
+			if addr.Type == v1.NodeInternalIP && addr.Address == address {
+				return node, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func containsNode(nodes []*v1.Node, name string) bool {
+	for _, node := range nodes {
+		if node.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// toBeDeletedTaint is the taint of the nodes being removed by the cluster autoscaler.
+const toBeDeletedTaint = "ToBeDeletedByClusterAutoscaler"
+
+// nodeEligible returns whether the node is eligible for the load balancers as the service controller filters
+// the nodes, the nodes labeled node.kubernetes.io/exclude-from-external-load-balancers and the nodes being removed
+// by the cluster autoscaler are not. The readiness is checked by nodeHealthy instead, which keeps serving the
+// NotReady nodes in the not-ready-grace-period.
+func nodeEligible(node *v1.Node) bool {
+	if _, ok := node.Labels[v1.LabelNodeExcludeBalancers]; ok {
+		return false
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == toBeDeletedTaint {
+			return false
+		}
+	}
+	return true
+}
+
+// list returns the nodes in the cache eligible for the load balancers, see nodeEligible.
+func (c *nodeCache) list() ([]*v1.Node, error) {
+	all, err := c.lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*v1.Node, 0, len(all))
+	for _, node := range all {
+		if nodeEligible(node) {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/auth"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/common"
+)
+
+// octaviaPath is the path prefix of the OpenStack Octavia compatible APIs.
+const octaviaPath = "/v2/lbaas"
+
+const (
+	OctaviaStatusActive = "ACTIVE"
+	OctaviaStatusError  = "ERROR"
+)
+
+// LBaaSLoadBalancer is the load balancer of the Octavia API.
+type LBaaSLoadBalancer struct {
+	ID                 string `json:"id,omitempty"`
+	Name               string `json:"name,omitempty"`
+	Description        string `json:"description,omitempty"`
+	VipSubnetID        string `json:"vip_subnet_id,omitempty"`
+	VipAddress         string `json:"vip_address,omitempty"`
+	VipPortID          string `json:"vip_port_id,omitempty"`
+	Provider           string `json:"provider,omitempty"`
+	ProvisioningStatus string `json:"provisioning_status,omitempty"`
+}
+
+// LBaaSListener is the listener of the Octavia API.
+type LBaaSListener struct {
+	ID                     string `json:"id,omitempty"`
+	Name                   string `json:"name,omitempty"`
+	Description            string `json:"description,omitempty"`
+	LoadbalancerID         string `json:"loadbalancer_id,omitempty"`
+	Protocol               string `json:"protocol,omitempty"`
+	ProtocolPort           int32  `json:"protocol_port,omitempty"`
+	DefaultPoolID          string `json:"default_pool_id,omitempty"`
+	DefaultTLSContainerRef string `json:"default_tls_container_ref,omitempty"`
+}
+
+// LBaaSPool is the pool of the Octavia API.
+type LBaaSPool struct {
+	ID              string `json:"id,omitempty"`
+	Name            string `json:"name,omitempty"`
+	Description     string `json:"description,omitempty"`
+	Protocol        string `json:"protocol,omitempty"`
+	LBAlgorithm     string `json:"lb_algorithm,omitempty"`
+	ListenerID      string `json:"listener_id,omitempty"`
+	HealthmonitorID string `json:"healthmonitor_id,omitempty"`
+}
+
+// LBaaSMember is the member of the pools of the Octavia API.
+type LBaaSMember struct {
+	ID           string `json:"id,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Address      string `json:"address,omitempty"`
+	ProtocolPort int32  `json:"protocol_port,omitempty"`
+	SubnetID     string `json:"subnet_id,omitempty"`
+}
+
+// LBaaSHealthMonitor is the health monitor of the pools of the Octavia API,
+// the pool and the type can not be updated.
+type LBaaSHealthMonitor struct {
+	ID         string `json:"id,omitempty"`
+	PoolID     string `json:"pool_id,omitempty"`
+	Type       string `json:"type,omitempty"`
+	Delay      int32  `json:"delay,omitempty"`
+	Timeout    int32  `json:"timeout,omitempty"`
+	MaxRetries int32  `json:"max_retries,omitempty"`
+}
+
+// OctaviaClient is the client of the OpenStack Octavia compatible APIs, which are served by the ELB endpoint.
+type OctaviaClient struct {
+	client *auth.ServiceClient
+}
+
+// context returns the context of the reconcile the client is built for, the waits stop once it is done.
+func (o *OctaviaClient) context() context.Context {
+	if o.client.Context == nil {
+		return context.Background()
+	}
+	return o.client.Context
+}
+
+// do sends the request with the body wrapped in the key, and decodes the resource under the key of the response
+// into out. The resources not found are reported with the NotFound code.
+func (o *OctaviaClient) do(method, path, key string, body, out interface{}) error {
+	var obj interface{}
+	if body != nil {
+		obj = map[string]interface{}{key: body}
+	}
+	resp, err := auth.DoRequest(o.client, nil, auth.NewRequest(method, path, nil, obj))
+	if err != nil {
+		return err
+	}
+
+	var rsp map[string]json.RawMessage
+	if err = auth.DecodeBody(resp, &rsp); err != nil {
+		var apiErr *auth.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return status.Errorf(codes.NotFound, "%s %s is not found: %s", method, path, err)
+		}
+		return fmt.Errorf("%s %s failed: %w", method, path, err)
+	}
+	if raw, ok := rsp[key]; ok && out != nil {
+		if err = json.Unmarshal(raw, out); err != nil {
+			return fmt.Errorf("failed to decode the %s of %s %s: %w", key, method, path, err)
+		}
+	}
+	return nil
+}
+
+// listPath returns the path of the resources filtered by the query.
+func listPath(path string, query url.Values) string {
+	if len(query) == 0 {
+		return path
+	}
+	return path + "?" + query.Encode()
+}
+
+func (o *OctaviaClient) GetLoadBalancer(id string) (*LBaaSLoadBalancer, error) {
+	var rst LBaaSLoadBalancer
+	err := o.do(http.MethodGet, octaviaPath+"/loadbalancers/"+id, "loadbalancer", nil, &rst)
+	return &rst, err
+}
+
+func (o *OctaviaClient) ListLoadBalancers(name string) ([]LBaaSLoadBalancer, error) {
+	var rst []LBaaSLoadBalancer
+	path := listPath(octaviaPath+"/loadbalancers", url.Values{"name": {name}})
+	err := o.do(http.MethodGet, path, "loadbalancers", nil, &rst)
+	return rst, err
+}
+
+func (o *OctaviaClient) CreateLoadBalancer(opts *LBaaSLoadBalancer) (*LBaaSLoadBalancer, error) {
+	var rst LBaaSLoadBalancer
+	err := o.do(http.MethodPost, octaviaPath+"/loadbalancers", "loadbalancer", opts, &rst)
+	return &rst, err
+}
+
+func (o *OctaviaClient) DeleteLoadBalancer(id string) error {
+	return o.do(http.MethodDelete, octaviaPath+"/loadbalancers/"+id, "loadbalancer", nil, nil)
+}
+
+// WaitStatusActive waits for the load balancer to be ACTIVE, the load balancer is immutable while it is
+// PENDING_* after a change of itself or its children.
+func (o *OctaviaClient) WaitStatusActive(id string) (*LBaaSLoadBalancer, error) {
+	var loadbalancer *LBaaSLoadBalancer
+	err := common.WaitForCompletedWithContext(o.context(), func() (bool, error) {
+		var err error
+		if loadbalancer, err = o.GetLoadBalancer(id); err != nil {
+			return false, err
+		}
+		switch loadbalancer.ProvisioningStatus {
+		case OctaviaStatusActive:
+			return true, nil
+		case OctaviaStatusError:
+			return false, status.Errorf(codes.Unavailable, "the provisioning status of loadbalancer %s is %s",
+				id, OctaviaStatusError)
+		}
+		return false, nil
+	})
+	return loadbalancer, err
+}
+
+func (o *OctaviaClient) ListListeners(loadbalancerID string) ([]LBaaSListener, error) {
+	var rst []LBaaSListener
+	path := listPath(octaviaPath+"/listeners", url.Values{"loadbalancer_id": {loadbalancerID}})
+	err := o.do(http.MethodGet, path, "listeners", nil, &rst)
+	return rst, err
+}
+
+func (o *OctaviaClient) CreateListener(opts *LBaaSListener) (*LBaaSListener, error) {
+	var rst LBaaSListener
+	err := o.do(http.MethodPost, octaviaPath+"/listeners", "listener", opts, &rst)
+	return &rst, err
+}
+
+func (o *OctaviaClient) DeleteListener(id string) error {
+	return o.do(http.MethodDelete, octaviaPath+"/listeners/"+id, "listener", nil, nil)
+}
+
+func (o *OctaviaClient) GetPool(id string) (*LBaaSPool, error) {
+	var rst LBaaSPool
+	err := o.do(http.MethodGet, octaviaPath+"/pools/"+id, "pool", nil, &rst)
+	return &rst, err
+}
+
+func (o *OctaviaClient) CreatePool(opts *LBaaSPool) (*LBaaSPool, error) {
+	var rst LBaaSPool
+	err := o.do(http.MethodPost, octaviaPath+"/pools", "pool", opts, &rst)
+	return &rst, err
+}
+
+func (o *OctaviaClient) DeletePool(id string) error {
+	return o.do(http.MethodDelete, octaviaPath+"/pools/"+id, "pool", nil, nil)
+}
+
+func (o *OctaviaClient) ListMembers(poolID string) ([]LBaaSMember, error) {
+	var rst []LBaaSMember
+	err := o.do(http.MethodGet, octaviaPath+"/pools/"+poolID+"/members", "members", nil, &rst)
+	return rst, err
+}
+
+func (o *OctaviaClient) CreateMember(poolID string, opts *LBaaSMember) (*LBaaSMember, error) {
+	var rst LBaaSMember
+	err := o.do(http.MethodPost, octaviaPath+"/pools/"+poolID+"/members", "member", opts, &rst)
+	return &rst, err
+}
+
+func (o *OctaviaClient) DeleteMember(poolID, id string) error {
+	return o.do(http.MethodDelete, octaviaPath+"/pools/"+poolID+"/members/"+id, "member", nil, nil)
+}
+
+func (o *OctaviaClient) CreateHealthMonitor(opts *LBaaSHealthMonitor) (*LBaaSHealthMonitor, error) {
+	var rst LBaaSHealthMonitor
+	err := o.do(http.MethodPost, octaviaPath+"/healthmonitors", "healthmonitor", opts, &rst)
+	return &rst, err
+}
+
+func (o *OctaviaClient) UpdateHealthMonitor(id string, opts *LBaaSHealthMonitor) error {
+	return o.do(http.MethodPut, octaviaPath+"/healthmonitors/"+id, "healthmonitor", opts, nil)
+}
+
+func (o *OctaviaClient) DeleteHealthMonitor(id string) error {
+	return o.do(http.MethodDelete, octaviaPath+"/healthmonitors/"+id, "healthmonitor", nil, nil)
+}
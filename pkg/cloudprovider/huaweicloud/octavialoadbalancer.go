@@ -0,0 +1,567 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"context"
+	"fmt"
+
+	eipmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/eip/v2/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/auth"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/common"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/utils"
+)
+
+// OctaviaCloud serves the shared and dedicated classes with the OpenStack Octavia compatible APIs,
+// when the backend of the [LoadBalancer] section is "octavia".
+type OctaviaCloud struct {
+	Basic
+}
+
+// octaviaClient returns the client of the Octavia APIs served by the ELB endpoint.
+func (o *OctaviaCloud) octaviaClient() *OctaviaClient {
+	authOpts := &o.cloudConfig.AuthOpts
+	accessKey, secretKey, securityToken := authOpts.GetAccessKeys()
+	return &OctaviaClient{client: &auth.ServiceClient{
+		Client:   auth.HTTPClient(),
+		Endpoint: authOpts.GetEndpoint("elb"),
+		Catalog:  "elb",
+		Access: &auth.AccessInfo{
+			AccessKey:     accessKey,
+			SecretKey:     secretKey,
+			SecurityToken: securityToken,
+			Region:        authOpts.Region,
+		},
+		TenantId:      authOpts.ProjectID,
+		OnUnreachable: func(endpoint string) { authOpts.ReportEndpointFailure("elb", endpoint) },
+		Context:       authOpts.Context(),
+		Acquire:       authOpts.AcquireEndpoint,
+		Timeout:       authOpts.GetServiceRequestTimeout("elb"),
+	}}
+}
+
+func (o *OctaviaCloud) GetLoadBalancerName(_ context.Context, clusterName string, service *v1.Service) string {
+	return loadBalancerName(o.clusterID(clusterName), service)
+}
+
+func (o *OctaviaCloud) getLoadBalancerInstance(ctx context.Context, client *OctaviaClient, clusterName string,
+	service *v1.Service) (*LBaaSLoadBalancer, error) {
+	if id := getStringFromSvsAnnotation(service, ElbID, ""); id != "" {
+		return client.GetLoadBalancer(id)
+	}
+
+	names := o.loadBalancerNames(clusterName, service)
+	for _, name := range names {
+		list, err := client.ListLoadBalancers(name)
+		if err != nil {
+			return nil, err
+		}
+		if len(list) == 0 {
+			continue
+		}
+		if len(list) != 1 {
+			return nil, status.Errorf(codes.Unavailable, "error, found %d Octavia load balancers named %s, "+
+				"make sure there is only one", len(list), name)
+		}
+		return &list[0], nil
+	}
+	return nil, status.Errorf(codes.NotFound, "not found Octavia load balancer %s", names[0])
+}
+
+func (o *OctaviaCloud) GetLoadBalancer(ctx context.Context, clusterName string, service *v1.Service) (
+	*v1.LoadBalancerStatus, bool, error) {
+	klog.Infof("GetLoadBalancer: called with service %s/%s", service.Namespace, service.Name)
+	loadbalancer, err := o.getLoadBalancerInstance(ctx, o.octaviaClient(), clusterName, service)
+	if err != nil {
+		if common.IsNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	lbStatus, err := o.loadBalancerStatus(loadbalancer)
+	if err != nil {
+		return nil, false, err
+	}
+	return lbStatus, true, nil
+}
+
+// loadBalancerStatus returns the EIP bound to the VIP port as the ingress IP, or the VIP address if absent.
+func (o *OctaviaCloud) loadBalancerStatus(loadbalancer *LBaaSLoadBalancer) (*v1.LoadBalancerStatus, error) {
+	ingressIP := loadbalancer.VipAddress
+	ips, err := o.eipClient.List(&eipmodel.ListPublicipsRequest{PortId: &[]string{loadbalancer.VipPortID}})
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "error querying EIP list base on PortId (%s): %s",
+			loadbalancer.VipPortID, err)
+	}
+	if len(ips) > 0 {
+		ingressIP = pointer.StringDeref(ips[0].PublicIpAddress, ingressIP)
+	}
+	return &v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: ingressIP}}}, nil
+}
+
+func (o *OctaviaCloud) EnsureLoadBalancer(ctx context.Context, clusterName string, service *v1.Service,
+	nodes []*v1.Node) (*v1.LoadBalancerStatus, error) {
+	klog.Infof("EnsureLoadBalancer: called with service %s/%s, node: %d",
+		service.Namespace, service.Name, len(nodes))
+
+	if err := ensureLoadBalancerValidation(service, nodes); err != nil {
+		return nil, err
+	}
+
+	client := o.octaviaClient()
+	loadbalancer, err := o.getLoadBalancerInstance(ctx, client, clusterName, service)
+	specifiedID := getStringFromSvsAnnotation(service, ElbID, "")
+	if common.IsNotFound(err) && specifiedID == "" {
+		loadbalancer, err = o.createLoadBalancer(ctx, client, clusterName, service, nodes[0])
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	listeners, err := client.ListListeners(loadbalancer.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, port := range service.Spec.Ports {
+		listener := o.filterListenerByPort(listeners, service, port)
+		if listener == nil {
+			listener, err = o.createListener(client, clusterName, loadbalancer.ID, service, port)
+		} else {
+			err = o.checkListenerOwner(clusterName, service, listener.ID, port.Port, listener.Description)
+		}
+		if err != nil {
+			return nil, err
+		}
+		listeners = o.popListener(listeners, listener.ID)
+
+		if err = o.ensurePool(client, clusterName, loadbalancer, listener, service, port, nodes); err != nil {
+			return nil, err
+		}
+	}
+
+	if specifiedID == "" {
+		// All remaining listeners are obsolete, delete them
+		if err = o.deleteListeners(client, loadbalancer.ID, listeners); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = o.bindEIP(loadbalancer, service); err != nil {
+		return nil, err
+	}
+	return o.loadBalancerStatus(loadbalancer)
+}
+
+func (o *OctaviaCloud) createLoadBalancer(ctx context.Context, client *OctaviaClient, clusterName string,
+	service *v1.Service, node *v1.Node) (*LBaaSLoadBalancer, error) {
+	subnetID, err := o.getSubnetID(service, node)
+	if err != nil {
+		return nil, err
+	}
+
+	loadbalancer, err := client.CreateLoadBalancer(&LBaaSLoadBalancer{
+		Name:        o.GetLoadBalancerName(ctx, clusterName, service),
+		Description: o.resourceDescription(clusterName, service),
+		VipSubnetID: subnetID,
+		Provider:    o.loadbalancerOpts().LBProvider,
+	})
+	if err != nil {
+		o.invalidateSubnet(subnetID, err)
+		return nil, status.Errorf(codes.Internal, "failed to create Octavia load balancer: %v", err)
+	}
+	return client.WaitStatusActive(loadbalancer.ID)
+}
+
+// bindEIP binds the EIP of the elb.eip-id annotation to the VIP port, the Octavia API has no EIP of its own.
+func (o *OctaviaCloud) bindEIP(loadbalancer *LBaaSLoadBalancer, service *v1.Service) error {
+	eipID := getStringFromSvsAnnotation(service, ElbEipID, "")
+	if eipID == "" {
+		return nil
+	}
+	eip, err := o.eipClient.Get(eipID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to get EIP %s: %s", eipID, err)
+	}
+	if pointer.StringDeref(eip.PortId, "") == loadbalancer.VipPortID {
+		return nil
+	}
+	if err = o.eipClient.Bind(eipID, loadbalancer.VipPortID); err != nil {
+		return status.Errorf(codes.Internal, "failed to bind EIP %s to port %s: %s", eipID, loadbalancer.VipPortID, err)
+	}
+	return nil
+}
+
+func (o *OctaviaCloud) filterListenerByPort(listeners []LBaaSListener, service *v1.Service,
+	port v1.ServicePort) *LBaaSListener {
+	protocol := parseProtocol(service, port)
+	for _, listener := range listeners {
+		if listener.Protocol == protocol && listener.ProtocolPort == port.Port {
+			return &listener
+		}
+	}
+	return nil
+}
+
+func (o *OctaviaCloud) popListener(listeners []LBaaSListener, id string) []LBaaSListener {
+	for i, listener := range listeners {
+		if listener.ID == id {
+			listeners[i] = listeners[len(listeners)-1]
+			return listeners[:len(listeners)-1]
+		}
+	}
+	return listeners
+}
+
+func (o *OctaviaCloud) createListener(client *OctaviaClient, clusterName, loadbalancerID string, service *v1.Service,
+	port v1.ServicePort) (*LBaaSListener, error) {
+	opts := &LBaaSListener{
+		Name:           o.listenerName(clusterName, service, string(port.Protocol), port.Port),
+		Description:    o.resourceDescription(clusterName, service),
+		LoadbalancerID: loadbalancerID,
+		Protocol:       parseProtocol(service, port),
+		ProtocolPort:   port.Port,
+	}
+	if opts.Protocol == ProtocolTerminatedHTTPS {
+		opts.DefaultTLSContainerRef = getStringFromSvsAnnotation(service, DefaultTLSContainerRef, "")
+	}
+
+	listener, err := client.CreateListener(opts)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to create listener for loadbalancer %s: %v",
+			loadbalancerID, err)
+	}
+	if _, err = client.WaitStatusActive(loadbalancerID); err != nil {
+		return nil, err
+	}
+	return listener, nil
+}
+
+// ensurePool creates the default pool of the listener if absent, and reconciles its members and health monitor.
+func (o *OctaviaCloud) ensurePool(client *OctaviaClient, clusterName string, loadbalancer *LBaaSLoadBalancer,
+	listener *LBaaSListener, service *v1.Service, port v1.ServicePort, nodes []*v1.Node) error {
+	var pool *LBaaSPool
+	var err error
+	if listener.DefaultPoolID != "" {
+		pool, err = client.GetPool(listener.DefaultPoolID)
+	} else {
+		pool, err = o.createPool(client, clusterName, loadbalancer.ID, listener, service)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = o.addOrRemoveMembers(client, loadbalancer, service, pool, port, nodes); err != nil {
+		return err
+	}
+	return o.addOrRemoveHealthMonitor(client, loadbalancer.ID, pool, service)
+}
+
+func (o *OctaviaCloud) createPool(client *OctaviaClient, clusterName, loadbalancerID string, listener *LBaaSListener,
+	service *v1.Service) (*LBaaSPool, error) {
+	protocol := listener.Protocol
+	if protocol == ProtocolTerminatedHTTPS {
+		protocol = ProtocolHTTP
+	}
+	pool, err := client.CreatePool(&LBaaSPool{
+		Name:        utils.CutString(fmt.Sprintf("pl_%s", listener.Name), defaultMaxNameLength),
+		Description: o.resourceDescription(clusterName, service),
+		Protocol:    protocol,
+		LBAlgorithm: getStringFromSvsAnnotation(service, ElbAlgorithm, o.loadbalancerOpts().LBAlgorithm),
+		ListenerID:  listener.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating pool for listener %s: %w", listener.ID, err)
+	}
+	if _, err = client.WaitStatusActive(loadbalancerID); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+func (o *OctaviaCloud) addOrRemoveMembers(client *OctaviaClient, loadbalancer *LBaaSLoadBalancer,
+	service *v1.Service, pool *LBaaSPool, port v1.ServicePort, nodes []*v1.Node) error {
+	members, err := client.ListMembers(pool.ID)
+	if err != nil {
+		return err
+	}
+
+	existsMember := make(map[string]bool)
+	for _, m := range members {
+		existsMember[fmt.Sprintf("%s:%d", m.Address, m.ProtocolPort)] = true
+	}
+
+	podList, err := o.listPodsBySelector(o.context(), service.Namespace, service.Spec.Selector)
+	if err != nil {
+		return err
+	}
+	for _, pod := range podList.Items {
+		if !IsPodActive(pod) || pod.Status.HostIP == "" {
+			klog.Errorf("Pod %s/%s is not active or scheduled, skipping adding to ELB", pod.Namespace, pod.Name)
+			continue
+		}
+
+		// The nodes excluded from the load balancers are not passed in, the pods on them are not served.
+		node, ok := o.nodes.get(pod.Spec.NodeName, nodes)
+		if !ok {
+			klog.Warningf("the node %s of pod %s/%s is not eligible for the load balancers, skipping adding to ELB",
+				pod.Spec.NodeName, pod.Namespace, pod.Name)
+			continue
+		}
+
+		address, err := getNodeAddress(node)
+		if err != nil {
+			if common.IsNotFound(err) {
+				klog.Warningf("Failed to create Octavia pool member for node %s: %v", node.Name, err)
+				continue
+			}
+			return fmt.Errorf("error getting address for node %s: %w", node.Name, err)
+		}
+
+		key := fmt.Sprintf("%s:%d", address, port.NodePort)
+		if existsMember[key] {
+			members = o.popMember(members, address, port.NodePort)
+			continue
+		}
+
+		klog.Infof("[addOrRemoveMembers] add node to pool, name: %s, address: %s, port: %d",
+			node.Name, address, port.NodePort)
+		_, err = client.CreateMember(pool.ID, &LBaaSMember{
+			Name:         utils.CutString(fmt.Sprintf("member_%s_%s", pool.Name, node.Name), defaultMaxNameLength),
+			Address:      address,
+			ProtocolPort: port.NodePort,
+			SubnetID:     loadbalancer.VipSubnetID,
+		})
+		if err != nil {
+			return fmt.Errorf("error creating Octavia pool member for node: %s, %w", node.Name, err)
+		}
+		if _, err = client.WaitStatusActive(loadbalancer.ID); err != nil {
+			return err
+		}
+		existsMember[key] = true
+	}
+
+	// The members are re-read before removing the obsolete ones, which may be added by another controller.
+	if len(members) > 0 {
+		current, err := client.ListMembers(pool.ID)
+		if err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(current))
+		for _, m := range current {
+			keys = append(keys, fmt.Sprintf("%s:%d", m.Address, m.ProtocolPort))
+		}
+		if err = checkMembersRevision(pool.ID, existsMember, keys); err != nil {
+			return err
+		}
+	}
+
+	for _, member := range members {
+		klog.Infof("[addOrRemoveMembers] remove node from pool, name: %s, address: %s, port: %d",
+			member.Name, member.Address, member.ProtocolPort)
+		if err = client.DeleteMember(pool.ID, member.ID); err != nil && !common.IsNotFound(err) {
+			return fmt.Errorf("error deleting obsolete member %s for pool %s address %s: %v",
+				member.ID, pool.ID, member.Address, err)
+		}
+		if _, err = client.WaitStatusActive(loadbalancer.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *OctaviaCloud) popMember(members []LBaaSMember, addr string, port int32) []LBaaSMember {
+	for i, m := range members {
+		if m.Address == addr && m.ProtocolPort == port {
+			members[i] = members[len(members)-1]
+			return members[:len(members)-1]
+		}
+	}
+	return members
+}
+
+func (o *OctaviaCloud) addOrRemoveHealthMonitor(client *OctaviaClient, loadbalancerID string, pool *LBaaSPool,
+	service *v1.Service) error {
+	opts := getHealthCheckOptionFromAnnotation(service, o.loadbalancerOpts())
+	monitorID := pool.HealthmonitorID
+
+	// The UDP pools are checked by the UDP-CONNECT monitors of Octavia.
+	monitorType := pool.Protocol
+	if monitorType == ProtocolUDP {
+		monitorType = "UDP-CONNECT"
+	}
+
+	var err error
+	switch {
+	case monitorID == "" && opts.Enable:
+		_, err = client.CreateHealthMonitor(&LBaaSHealthMonitor{
+			PoolID:     pool.ID,
+			Type:       monitorType,
+			Delay:      opts.Delay,
+			Timeout:    opts.Timeout,
+			MaxRetries: opts.MaxRetries,
+		})
+	case monitorID != "" && opts.Enable:
+		err = client.UpdateHealthMonitor(monitorID, &LBaaSHealthMonitor{
+			Delay:      opts.Delay,
+			Timeout:    opts.Timeout,
+			MaxRetries: opts.MaxRetries,
+		})
+	case monitorID != "" && !opts.Enable:
+		klog.Infof("Deleting health monitor %s for pool %s", monitorID, pool.ID)
+		err = client.DeleteHealthMonitor(monitorID)
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reconcile the health monitor of pool %s: %w", pool.ID, err)
+	}
+	_, err = client.WaitStatusActive(loadbalancerID)
+	return err
+}
+
+// deleteListeners deletes the listeners with their pools, the members and health monitors are deleted with the pools.
+func (o *OctaviaCloud) deleteListeners(client *OctaviaClient, loadbalancerID string, listeners []LBaaSListener) error {
+	var errs []error
+	for _, listener := range listeners {
+		if listener.DefaultPoolID != "" {
+			if err := o.deletePool(client, loadbalancerID, listener.DefaultPoolID); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		if err := client.DeleteListener(listener.ID); err != nil && !common.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete listener %s: %s", listener.ID, err))
+			continue
+		}
+		if _, err := client.WaitStatusActive(loadbalancerID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("failed to delete listeners: %s", errors.NewAggregate(errs))
+	}
+	return nil
+}
+
+func (o *OctaviaCloud) deletePool(client *OctaviaClient, loadbalancerID, poolID string) error {
+	pool, err := client.GetPool(poolID)
+	if common.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if pool.HealthmonitorID != "" {
+		if err = client.DeleteHealthMonitor(pool.HealthmonitorID); err != nil && !common.IsNotFound(err) {
+			return err
+		}
+		if _, err = client.WaitStatusActive(loadbalancerID); err != nil {
+			return err
+		}
+	}
+	if err = client.DeletePool(poolID); err != nil && !common.IsNotFound(err) {
+		return err
+	}
+	_, err = client.WaitStatusActive(loadbalancerID)
+	return err
+}
+
+func (o *OctaviaCloud) UpdateLoadBalancer(ctx context.Context, clusterName string, service *v1.Service,
+	nodes []*v1.Node) error {
+	klog.Infof("UpdateLoadBalancer: called with service %s/%s, node: %d", service.Namespace, service.Name, len(nodes))
+	client := o.octaviaClient()
+	loadbalancer, err := o.getLoadBalancerInstance(ctx, client, clusterName, service)
+	if err != nil {
+		return err
+	}
+
+	listeners, err := client.ListListeners(loadbalancer.ID)
+	if err != nil {
+		return err
+	}
+	for _, port := range service.Spec.Ports {
+		listener := o.filterListenerByPort(listeners, service, port)
+		if listener == nil {
+			return status.Errorf(codes.Unavailable, "error, can not find a listener matching %s:%v",
+				port.Protocol, port.Port)
+		}
+		if err = o.checkListenerOwner(clusterName, service, listener.ID, port.Port, listener.Description); err != nil {
+			return err
+		}
+		if err = o.ensurePool(client, clusterName, loadbalancer, listener, service, port, nodes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *OctaviaCloud) EnsureLoadBalancerDeleted(ctx context.Context, clusterName string, service *v1.Service) error {
+	klog.Infof("EnsureLoadBalancerDeleted: called with service %s/%s", service.Namespace, service.Name)
+	client := o.octaviaClient()
+	loadbalancer, err := o.getLoadBalancerInstance(ctx, client, clusterName, service)
+	if common.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	listeners, err := client.ListListeners(loadbalancer.ID)
+	if err != nil {
+		return err
+	}
+	// The load balancer specified by elb.id is kept, only the listeners of the service ports are deleted.
+	if getStringFromSvsAnnotation(service, ElbID, "") != "" {
+		var matched []LBaaSListener
+		for _, port := range service.Spec.Ports {
+			listener := o.filterListenerByPort(listeners, service, port)
+			if listener == nil {
+				continue
+			}
+			if o.ownedByOthers(clusterName, service, listener.Description) {
+				klog.Warningf("the listener %s of port %d is not deleted, it is created by another service: %s",
+					listener.ID, port.Port, listener.Description)
+				continue
+			}
+			matched = append(matched, *listener)
+		}
+		return o.deleteListeners(client, loadbalancer.ID, matched)
+	}
+
+	if err = o.deleteListeners(client, loadbalancer.ID, listeners); err != nil {
+		return err
+	}
+	eipID := getStringFromSvsAnnotation(service, ElbEipID, "")
+	keepEip := getBoolFromSvsAnnotation(service, ELBKeepEip, o.loadbalancerOpts().KeepEIP)
+	if eipID != "" {
+		if err = unbindEIP(o.eipClient, loadbalancer.VipPortID, eipID, keepEip); err != nil {
+			return err
+		}
+	}
+	if err = client.DeleteLoadBalancer(loadbalancer.ID); err != nil && !common.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,321 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	cloudprovider "k8s.io/cloud-provider"
+	"k8s.io/klog/v2"
+)
+
+// CloudAllocatorType is the --cidr-allocator-type allocating the pod CIDRs of the nodes from the pod subnets
+// of the VPC, see pod-subnet-id in the [Vpc] section.
+const CloudAllocatorType = "CloudAllocator"
+
+// cidrNotAvailableReason is the reason of the event recorded on a node when no pod CIDR is left for it.
+const cidrNotAvailableReason = "CIDRNotAvailable"
+
+// podCIDRAllocator allocates the pod CIDRs of the nodes from the pod subnets. The destinations of the pod CIDR
+// routes of the cluster are not allocated until the route controller deletes them, so that the pods of a new node
+// are never routed to the node which had the CIDR before. The nodes are allocated by one worker in order.
+type podCIDRAllocator struct {
+	client      kubernetes.Interface
+	lister      corelisters.NodeLister
+	queue       workqueue.RateLimitingInterface
+	recorder    record.EventRecorder
+	clusterName string
+	// routes lists the pod CIDR routes of the cluster, it is nil if the routes are not supported.
+	routes   cloudprovider.Routes
+	subnets  []*net.IPNet
+	maskSize int
+	// pending holds the CIDRs patched to the nodes but not seen by the lister yet, keyed by the CIDR.
+	pending map[string]string
+}
+
+// StartPodCIDRAllocator starts the CloudAllocator of --cidr-allocator-type, which sets the pod CIDR of each node
+// without one to a free CIDR of node-cidr-mask-size in the pod subnets. It runs until the context is done.
+// The clusterCIDR is the comma-separated --cluster-cidr, the route controller deletes the stale routes in it only.
+func (h *CloudProvider) StartPodCIDRAllocator(ctx context.Context, client kubernetes.Interface,
+	nodeInformer coreinformers.NodeInformer, clusterName, clusterCIDR string) error {
+	subnets, err := h.podSubnets()
+	if err != nil {
+		return err
+	}
+	warnUncoveredSubnets(subnets, clusterCIDR)
+	a := &podCIDRAllocator{
+		client:      client,
+		lister:      nodeInformer.Lister(),
+		queue:       workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "pod-cidrs"),
+		recorder:    h.eventRecorder,
+		clusterName: clusterName,
+		subnets:     subnets,
+		maskSize:    h.cloudConfig.VpcOpts.NodeCIDRMaskSize,
+		pending:     make(map[string]string),
+	}
+	if routes, ok := h.Routes(); ok {
+		a.routes = routes
+	}
+
+	_, err = nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: a.enqueue,
+		UpdateFunc: func(_, obj interface{}) {
+			a.enqueue(obj)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch the nodes: %s", err)
+	}
+	klog.Infof("allocating the pod CIDRs of /%d from the pod subnets %s", a.maskSize, cidrsString(subnets))
+	go a.run(ctx, nodeInformer.Informer().HasSynced)
+	return nil
+}
+
+// podSubnets returns the CIDRs of the subnets of pod-subnet-id, which must be IPv4 subnets of the VPC
+// no smaller than the pod CIDRs of the nodes.
+func (h *CloudProvider) podSubnets() ([]*net.IPNet, error) {
+	vpcOpts := h.cloudConfig.VpcOpts
+	if len(vpcOpts.PodSubnetIDs) == 0 {
+		return nil, fmt.Errorf("pod-subnet-id is required in [Vpc] section by --cidr-allocator-type=%s",
+			CloudAllocatorType)
+	}
+	subnets, err := h.listSubnets(vpcOpts.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the subnets of the VPC %s: %s", vpcOpts.ID, err)
+	}
+
+	cidrs := make([]*net.IPNet, 0, len(vpcOpts.PodSubnetIDs))
+	for _, id := range vpcOpts.PodSubnetIDs {
+		subnet := findSubnet(subnets, id)
+		if subnet == nil {
+			return nil, fmt.Errorf("the pod subnet %s is not found in the VPC %s", id, vpcOpts.ID)
+		}
+		_, cidr, err := net.ParseCIDR(subnet.Cidr)
+		if err != nil || cidr.IP.To4() == nil {
+			return nil, fmt.Errorf("the pod subnet %s is not an IPv4 subnet: %q", id, subnet.Cidr)
+		}
+		if ones, _ := cidr.Mask.Size(); ones > vpcOpts.NodeCIDRMaskSize {
+			return nil, fmt.Errorf("the pod subnet %s(%s) is smaller than the pod CIDRs of node-cidr-mask-size %d",
+				id, subnet.Cidr, vpcOpts.NodeCIDRMaskSize)
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	return cidrs, nil
+}
+
+// warnUncoveredSubnets logs the pod subnets out of the cluster CIDRs, the stale routes of their CIDRs are not deleted
+// by the route controller, so the CIDRs are not allocated again.
+func warnUncoveredSubnets(subnets []*net.IPNet, clusterCIDR string) {
+	var clusterCIDRs []*net.IPNet
+	for _, cidr := range strings.Split(clusterCIDR, ",") {
+		if _, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr)); err == nil {
+			clusterCIDRs = append(clusterCIDRs, ipNet)
+		}
+	}
+	for _, subnet := range subnets {
+		covered := false
+		for _, cidr := range clusterCIDRs {
+			outer, _ := cidr.Mask.Size()
+			inner, _ := subnet.Mask.Size()
+			if cidr.Contains(subnet.IP) && outer <= inner {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			klog.Warningf("the pod subnet %s is not in --cluster-cidr %q, the routes of its pod CIDRs are not "+
+				"deleted by the route controller, and the CIDRs are not allocated again", subnet, clusterCIDR)
+		}
+	}
+}
+
+func (a *podCIDRAllocator) enqueue(obj interface{}) {
+	node, ok := obj.(*v1.Node)
+	if !ok || len(node.Spec.PodCIDRs) > 0 || node.Spec.PodCIDR != "" {
+		return
+	}
+	a.queue.Add(node.Name)
+}
+
+// run runs the worker until the context is done.
+func (a *podCIDRAllocator) run(ctx context.Context, synced cache.InformerSynced) {
+	defer a.queue.ShutDown()
+	if !cache.WaitForNamedCacheSync("pod CIDR allocator", ctx.Done(), synced) {
+		return
+	}
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		for a.processNextItem(ctx) {
+		}
+	}, time.Second)
+	<-ctx.Done()
+}
+
+func (a *podCIDRAllocator) processNextItem(ctx context.Context) bool {
+	item, quit := a.queue.Get()
+	if quit {
+		return false
+	}
+	defer a.queue.Done(item)
+
+	name := item.(string)
+	if err := a.allocate(ctx, name); err != nil {
+		klog.Errorf("failed to allocate the pod CIDR of node %s, retry later: %s", name, err)
+		a.queue.AddRateLimited(name)
+		return true
+	}
+	a.queue.Forget(name)
+	return true
+}
+
+// allocate sets the pod CIDR of the node to the first free CIDR of the pod subnets, nothing is done if the node
+// has one already.
+func (a *podCIDRAllocator) allocate(ctx context.Context, name string) error {
+	node, err := a.lister.Get(name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if len(node.Spec.PodCIDRs) > 0 || node.Spec.PodCIDR != "" {
+		return nil
+	}
+	for cidr, owner := range a.pending {
+		if owner == name {
+			klog.V(4).Infof("the pod CIDR %s of node %s is not seen yet, skip allocating", cidr, name)
+			return nil
+		}
+	}
+
+	used, err := a.usedCIDRs(ctx)
+	if err != nil {
+		return err
+	}
+	cidr := a.nextFreeCIDR(used)
+	if cidr == nil {
+		a.recorder.Eventf(node, v1.EventTypeWarning, cidrNotAvailableReason,
+			"No pod CIDR of /%d is left in the pod subnets %s", a.maskSize, cidrsString(a.subnets))
+		return fmt.Errorf("no pod CIDR of /%d is left in the pod subnets %s", a.maskSize, cidrsString(a.subnets))
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{"podCIDR": cidr.String(), "podCIDRs": []string{cidr.String()}},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err = a.client.CoreV1().Nodes().Patch(ctx, name, types.MergePatchType, patch,
+		metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to set the pod CIDR %s: %s", cidr, err)
+	}
+	a.pending[cidr.String()] = name
+	klog.Infof("allocated the pod CIDR %s to node %s", cidr, name)
+	return nil
+}
+
+// usedCIDRs returns the pod CIDRs of the nodes, the pending ones, and the destinations of the pod CIDR routes.
+func (a *podCIDRAllocator) usedCIDRs(ctx context.Context) ([]*net.IPNet, error) {
+	nodes, err := a.lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var cidrs []string
+	seen := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		cidrs = append(cidrs, node.Spec.PodCIDRs...)
+		cidrs = append(cidrs, node.Spec.PodCIDR)
+		seen[node.Name] = node.Spec.PodCIDR
+	}
+	for cidr, owner := range a.pending {
+		// the pending CIDR is seen in the node, or the node is deleted before it is seen.
+		if podCIDR, ok := seen[owner]; !ok || podCIDR != "" {
+			delete(a.pending, cidr)
+			continue
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	if a.routes != nil {
+		routes, err := a.routes.ListRoutes(ctx, a.clusterName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the pod CIDR routes: %s", err)
+		}
+		for _, route := range routes {
+			cidrs = append(cidrs, route.DestinationCIDR)
+		}
+	}
+
+	used := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if _, ipNet, err := net.ParseCIDR(cidr); err == nil {
+			used = append(used, ipNet)
+		}
+	}
+	return used, nil
+}
+
+// nextFreeCIDR returns the first CIDR of the mask size in the pod subnets overlapping none of the used CIDRs,
+// nil if there is none.
+func (a *podCIDRAllocator) nextFreeCIDR(used []*net.IPNet) *net.IPNet {
+	mask := net.CIDRMask(a.maskSize, 32)
+	for _, subnet := range a.subnets {
+		ones, _ := subnet.Mask.Size()
+		base := binary.BigEndian.Uint32(subnet.IP.To4())
+		for i := uint32(0); i < 1<<(a.maskSize-ones); i++ {
+			ip := make(net.IP, 4)
+			binary.BigEndian.PutUint32(ip, base+i<<(32-a.maskSize))
+			candidate := &net.IPNet{IP: ip, Mask: mask}
+			if !overlapsAny(candidate, used) {
+				return candidate
+			}
+		}
+	}
+	return nil
+}
+
+func overlapsAny(cidr *net.IPNet, others []*net.IPNet) bool {
+	for _, other := range others {
+		if cidr.Contains(other.IP) || other.Contains(cidr.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+func cidrsString(cidrs []*net.IPNet) string {
+	strs := make([]string, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		strs = append(strs, cidr.String())
+	}
+	return strings.Join(strs, ", ")
+}
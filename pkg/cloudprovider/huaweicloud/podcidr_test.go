@@ -0,0 +1,108 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"net"
+	"testing"
+)
+
+func parseCIDRs(t *testing.T, cidrs ...string) []*net.IPNet {
+	ipNets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatalf("failed to parse CIDR %s: %s", cidr, err)
+		}
+		ipNets = append(ipNets, ipNet)
+	}
+	return ipNets
+}
+
+func TestNextFreeCIDR(t *testing.T) {
+	tests := []struct {
+		name     string
+		subnets  []string
+		maskSize int
+		used     []string
+		expected string
+	}{
+		{
+			name:     "allocates the first CIDR of the subnet",
+			subnets:  []string{"172.16.0.0/16"},
+			maskSize: 24,
+			expected: "172.16.0.0/24",
+		},
+		{
+			name:     "skips the CIDRs in use",
+			subnets:  []string{"172.16.0.0/16"},
+			maskSize: 24,
+			used:     []string{"172.16.0.0/24", "172.16.1.0/24"},
+			expected: "172.16.2.0/24",
+		},
+		{
+			name:     "skips the CIDRs overlapping a larger CIDR in use",
+			subnets:  []string{"172.16.0.0/16"},
+			maskSize: 24,
+			used:     []string{"172.16.0.0/23"},
+			expected: "172.16.2.0/24",
+		},
+		{
+			name:     "skips the CIDR containing a smaller CIDR in use",
+			subnets:  []string{"172.16.0.0/16"},
+			maskSize: 24,
+			used:     []string{"172.16.0.128/25"},
+			expected: "172.16.1.0/24",
+		},
+		{
+			name:     "allocates from the next subnet once the first one is full",
+			subnets:  []string{"172.16.0.0/23", "172.17.0.0/16"},
+			maskSize: 24,
+			used:     []string{"172.16.0.0/24", "172.16.1.0/24"},
+			expected: "172.17.0.0/24",
+		},
+		{
+			name:     "allocates the subnet as a whole of the same mask size",
+			subnets:  []string{"172.16.0.0/24"},
+			maskSize: 24,
+			expected: "172.16.0.0/24",
+		},
+		{
+			name:     "returns nil if all the subnets are full",
+			subnets:  []string{"172.16.0.0/23"},
+			maskSize: 24,
+			used:     []string{"172.16.0.0/24", "172.16.1.0/24"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &podCIDRAllocator{subnets: parseCIDRs(t, tt.subnets...), maskSize: tt.maskSize}
+
+			cidr := a.nextFreeCIDR(parseCIDRs(t, tt.used...))
+			if cidr == nil {
+				if tt.expected != "" {
+					t.Errorf("nextFreeCIDR() = nil, expected %s", tt.expected)
+				}
+				return
+			}
+			if cidr.String() != tt.expected {
+				t.Errorf("nextFreeCIDR() = %s, expected %q", cidr, tt.expected)
+			}
+		})
+	}
+}
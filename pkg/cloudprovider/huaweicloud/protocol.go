@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// ServiceConditionProtocolsSupported is the condition of the services set to False when a port of the service
+	// uses a protocol the load balancers do not support, it is set back to True once the ports are changed.
+	ServiceConditionProtocolsSupported = "LoadBalancerProtocolsSupported"
+
+	// reasonUnsupportedProtocol is the reason of the warning events and the condition of the unsupported ports.
+	reasonUnsupportedProtocol = "UnsupportedProtocol"
+)
+
+// supportedProtocols are the protocols of the service ports the load balancers of all the classes support,
+// the listeners of the ELB, the DNAT rules and the classic ELB have no SCTP.
+var supportedProtocols = sets.NewString(string(v1.ProtocolTCP), string(v1.ProtocolUDP))
+
+// unsupportedPorts returns the ports of the service with an unsupported protocol,
+// in the form of "<name> <protocol>/<port>".
+func unsupportedPorts(service *v1.Service) []string {
+	var ports []string
+	for _, port := range service.Spec.Ports {
+		if !supportedProtocols.Has(string(port.Protocol)) {
+			ports = append(ports, fmt.Sprintf("%s %s/%d", port.Name, port.Protocol, port.Port))
+		}
+	}
+	return ports
+}
+
+// checkProtocols returns an InvalidArgument error before any load balancer is changed if a port of the service
+// uses an unsupported protocol, a warning event is recorded and the condition of the service is set to False.
+func (h *CloudProvider) checkProtocols(ctx context.Context, service *v1.Service) error {
+	ports := unsupportedPorts(service)
+	if len(ports) == 0 {
+		if cond := meta.FindStatusCondition(service.Status.Conditions, ServiceConditionProtocolsSupported); cond != nil &&
+			cond.Status != metav1.ConditionTrue {
+			h.setProtocolsCondition(ctx, service, metav1.ConditionTrue, "ProtocolsSupported",
+				"the protocols of all the ports are supported")
+		}
+		return nil
+	}
+
+	msg := fmt.Sprintf("the protocols of ports %s are not supported by the load balancers, the supported "+
+		"protocols are %s", strings.Join(ports, ", "), strings.Join(supportedProtocols.List(), ", "))
+	h.eventRecorder.Event(service, v1.EventTypeWarning, reasonUnsupportedProtocol, msg)
+	h.setProtocolsCondition(ctx, service, metav1.ConditionFalse, reasonUnsupportedProtocol, msg)
+	return status.Errorf(codes.InvalidArgument, "service %s/%s: %s", service.Namespace, service.Name, msg)
+}
+
+// setProtocolsCondition sets the protocols condition of the service, the failures are logged only.
+func (h *CloudProvider) setProtocolsCondition(ctx context.Context, service *v1.Service,
+	conditionStatus metav1.ConditionStatus, reason, msg string) {
+	if h.kubeClient == nil {
+		return
+	}
+	latest, err := h.kubeClient.Services(service.Namespace).Get(ctx, service.Name, metav1.GetOptions{})
+	if err != nil {
+		klog.Warningf("failed to get service %s/%s to set condition %s: %s", service.Namespace, service.Name,
+			ServiceConditionProtocolsSupported, err)
+		return
+	}
+	if cond := meta.FindStatusCondition(latest.Status.Conditions, ServiceConditionProtocolsSupported); cond != nil &&
+		cond.Status == conditionStatus && cond.Reason == reason && cond.Message == msg {
+		return
+	}
+	meta.SetStatusCondition(&latest.Status.Conditions, metav1.Condition{
+		Type:               ServiceConditionProtocolsSupported,
+		Status:             conditionStatus,
+		ObservedGeneration: latest.Generation,
+		Reason:             reason,
+		Message:            msg,
+	})
+	if _, err = h.kubeClient.Services(service.Namespace).UpdateStatus(ctx, latest, metav1.UpdateOptions{}); err != nil {
+		klog.Warningf("failed to set condition %s of service %s/%s: %s", ServiceConditionProtocolsSupported,
+			service.Namespace, service.Name, err)
+	}
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/metrics"
+)
+
+// collectQuotas exports the used amount and the limit of the quotas of the load balancers and the EIPs
+// of the regions, so that the exhaustion can be alerted before the provisioning fails.
+func collectQuotas(regions map[string]Basic) {
+	for region, b := range regions {
+		authOpts := b.cloudConfig.AuthOpts
+		if b.dedicatedELBClient != nil && authOpts.ServiceSupported("elb") {
+			quotas, err := b.dedicatedELBClient.ListQuotaDetails()
+			if err != nil {
+				klog.Warningf("failed to query the ELB quotas of region %s: %s", region, err)
+			}
+			for _, q := range quotas {
+				// The quotas per resource, such as "members_per_pool", have no used amount.
+				if q.Used < 0 {
+					continue
+				}
+				metrics.ObserveQuota(region, "elb", q.QuotaKey, int(q.Used), int(q.QuotaLimit))
+			}
+		}
+
+		if b.eipClient != nil && authOpts.ServiceSupported("vpc") {
+			quotas, err := b.eipClient.ListQuotas()
+			if err != nil {
+				klog.Warningf("failed to query the EIP quotas of region %s: %s", region, err)
+			}
+			for _, q := range quotas {
+				if q.Type == nil || q.Used == nil || q.Quota == nil {
+					continue
+				}
+				metrics.ObserveQuota(region, "eip", *q.Type, int(*q.Used), int(*q.Quota))
+			}
+		}
+	}
+}
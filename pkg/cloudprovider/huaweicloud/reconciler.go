@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+// serviceQueue updates the load balancers of the Services on the endpoints and node events. The pending events
+// of a Service are collapsed into one update, a Service is never updated by two workers at once,
+// and the failed updates are retried with the exponential backoff of the controllers. The updates are serialized
+// with the reconciles of the service controller by the lock of the Service, see CloudProvider.lockService.
+// The Services are read from the cache of an informer instead of the API server.
+type serviceQueue struct {
+	queue    workqueue.RateLimitingInterface
+	informer cache.SharedIndexInformer
+	lister   corelisters.ServiceLister
+	// workers is the number of the Services updated in parallel.
+	workers int
+	// update updates the load balancer of the Service, the Service is requeued if it fails.
+	update func(service *v1.Service) error
+}
+
+func newServiceQueue(client corev1.ServicesGetter, workers int,
+	update func(service *v1.Service) error) *serviceQueue {
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return client.Services(metav1.NamespaceAll).List(context.TODO(), options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return client.Services(metav1.NamespaceAll).Watch(context.TODO(), options)
+		},
+	}
+	informer := cache.NewSharedIndexInformer(lw, &v1.Service{}, 0,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	return &serviceQueue{
+		queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(),
+			"loadbalancer-services"),
+		informer: informer,
+		lister:   corelisters.NewServiceLister(informer.GetIndexer()),
+		workers:  workers,
+		update:   update,
+	}
+}
+
+func (q *serviceQueue) hasSynced() bool {
+	return q.informer.HasSynced()
+}
+
+// listLoadBalancers returns the Services of type LoadBalancer in the cache.
+func (q *serviceQueue) listLoadBalancers() ([]*v1.Service, error) {
+	all, err := q.lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	services := make([]*v1.Service, 0, len(all))
+	for _, service := range all {
+		if service.Spec.Type == v1.ServiceTypeLoadBalancer {
+			services = append(services, service)
+		}
+	}
+	return services, nil
+}
+
+// add queues the update of the Service, it is merged with the pending update of the same Service.
+func (q *serviceQueue) add(namespace, name string) {
+	q.queue.Add(namespace + "/" + name)
+}
+
+// addAfter queues the update of the Service after the delay, the events of the Service within the delay
+// are coalesced into the one update.
+func (q *serviceQueue) addAfter(namespace, name string, delay time.Duration) {
+	q.queue.AddAfter(namespace+"/"+name, delay)
+}
+
+// run runs the informer and the workers until the stop channel is closed, the workers start once the informer
+// is synced.
+func (q *serviceQueue) run(stop <-chan struct{}) {
+	go q.informer.Run(stop)
+	go func() {
+		if !cache.WaitForNamedCacheSync("services", stop, q.hasSynced) {
+			return
+		}
+		for i := 0; i < q.workers; i++ {
+			go wait.Until(func() {
+				for q.processNextItem() {
+				}
+			}, time.Second, stop)
+		}
+	}()
+	go func() {
+		<-stop
+		q.queue.ShutDown()
+	}()
+}
+
+func (q *serviceQueue) processNextItem() bool {
+	item, quit := q.queue.Get()
+	if quit {
+		return false
+	}
+	defer q.queue.Done(item)
+
+	key := item.(string)
+	if err := q.sync(key); err != nil {
+		klog.Errorf("failed to update the load balancer of service %s, retry later: %s", key, err)
+		q.queue.AddRateLimited(key)
+		return true
+	}
+	q.queue.Forget(key)
+	return true
+}
+
+func (q *serviceQueue) sync(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return nil
+	}
+	service, err := q.lister.Services(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		klog.V(4).Infof("service %s is deleted, skip updating", key)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get the service: %s", err)
+	}
+	if service.Spec.Type != v1.ServiceTypeLoadBalancer || service.DeletionTimestamp != nil {
+		return nil
+	}
+	return q.update(service)
+}
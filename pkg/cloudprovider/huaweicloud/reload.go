@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+)
+
+// watchReloadTriggers reloads the cloud config and the credentials on SIGHUP, and when the touch file of the
+// [Reload] section is modified. It is started on all the replicas, as the default action of SIGHUP is to exit.
+// The cloud config is only re-read if it is a file, otherwise only the credentials are reloaded.
+func (h *CloudProvider) watchReloadTriggers(cfg io.Reader) {
+	var path string
+	var baseline *config.CloudConfig
+	if file, ok := cfg.(*os.File); ok {
+		path = file.Name()
+		// the sections are compared with the cloud config read on starting, not the last reloaded one,
+		// so the changes requiring a restart are reported until restarted.
+		parsed, err := readConfigFile(path)
+		if err != nil {
+			klog.Warningf("failed to read the cloud config %s, only the credentials are reloaded: %s", path, err)
+			path = ""
+		}
+		baseline = parsed
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			klog.Infof("received SIGHUP, reload the cloud config and the credentials")
+			h.reload(path, baseline)
+		}
+	}()
+
+	reloadOpts := h.cloudConfig.ReloadOpts
+	if reloadOpts.TouchFile == "" {
+		return
+	}
+	var modTime time.Time
+	if info, err := os.Stat(reloadOpts.TouchFile); err == nil {
+		modTime = info.ModTime()
+	}
+	go wait.Forever(func() {
+		info, err := os.Stat(reloadOpts.TouchFile)
+		if err != nil || !info.ModTime().After(modTime) {
+			return
+		}
+		modTime = info.ModTime()
+		klog.Infof("the touch file %s is modified, reload the cloud config and the credentials", reloadOpts.TouchFile)
+		h.reload(path, baseline)
+	}, time.Duration(reloadOpts.Interval)*time.Second)
+}
+
+// reload forces the credential refreshers to re-read the credentials, and applies the LoadBalancer and Networking
+// sections of the cloud config file. The options are published as a new snapshot, which the providers of the
+// additional regions and projects share. The changes of the other sections are reported, as they require a restart.
+// An invalid cloud config is rejected, and the options in use are kept.
+func (h *CloudProvider) reload(path string, baseline *config.CloudConfig) {
+	h.cloudConfig.AuthOpts.ReloadCredentials()
+	if path == "" {
+		return
+	}
+
+	next, err := readConfigFile(path)
+	if err == nil {
+		err = next.ValidateLoadBalancer()
+	}
+	if err != nil {
+		klog.Errorf("failed to reload the cloud config %s, keep the config in use: %s", path, err)
+		return
+	}
+
+	var restart []string
+	for _, section := range baseline.ChangedSections(next) {
+		if !config.ReloadableSections[section] {
+			restart = append(restart, section)
+		}
+	}
+	if len(restart) > 0 {
+		klog.Warningf("the changes of the sections %s of the cloud config require a restart, they are not applied",
+			strings.Join(restart, ", "))
+	}
+
+	h.configLock.Lock()
+	defer h.configLock.Unlock()
+	// The load balancers of the backend in use would be orphaned by switching the backend without restarting.
+	if backend := h.cloudConfig.LoadBalancerOpts.Backend; next.LoadBalancerOpts.Backend != backend {
+		klog.Warningf("the change of the backend in [LoadBalancer] section requires a restart, keep the backend %s",
+			backend)
+		next.LoadBalancerOpts.Backend = backend
+	}
+	h.options.Publish(next, h.loadbalancerConfigData)
+}
+
+func readConfigFile(path string) (*config.CloudConfig, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return config.ParseConfig(file)
+}
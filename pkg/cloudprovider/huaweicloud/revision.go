@@ -0,0 +1,56 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+)
+
+// The load balancers may be shared by the services of several clusters, the listeners and the pools are re-read
+// before they are changed, and the reconcile is aborted if another controller changed them after they were read.
+// The service is retried with the backoff of the service controller, which reads the latest revision.
+
+// checkListenerRevision returns an Aborted error if the listener is updated after it was read.
+func checkListenerRevision(id, readAt, updatedAt string) error {
+	if readAt == "" || updatedAt == "" || readAt == updatedAt {
+		return nil
+	}
+	klog.Warningf("listener %s is modified at %s by another controller after it was read at %s", id, updatedAt, readAt)
+	return status.Errorf(codes.Aborted, "listener %s is modified concurrently by another controller "+
+		"(updated at %s, read at %s), retry with the latest revision", id, updatedAt, readAt)
+}
+
+// checkMembersRevision returns an Aborted error if the members of the pool are not the members read
+// and added in the reconcile, the members are keyed by address and port.
+func checkMembersRevision(poolID string, expected map[string]bool, current []string) error {
+	changed := len(current) != len(expected)
+	for _, key := range current {
+		if !expected[key] {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return nil
+	}
+	klog.Warningf("the members of pool %s are modified by another controller, expected %d, got %d",
+		poolID, len(expected), len(current))
+	return status.Errorf(codes.Aborted, "the members of pool %s are modified concurrently by another controller, "+
+		"retry with the latest revision", poolID)
+}
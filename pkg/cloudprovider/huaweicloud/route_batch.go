@@ -0,0 +1,206 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"fmt"
+	"sync"
+
+	vpcmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/vpc/v2/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/utils/mutexkv"
+)
+
+const (
+	// routeBatchSize is the maximum number of routes in an update request of a route table.
+	routeBatchSize = 100
+
+	routeUpdateQPS   = 5
+	routeUpdateBurst = 10
+)
+
+// routeRequest is a route to add to or delete from a route table, the result is sent to done.
+type routeRequest struct {
+	nodeName types.NodeName
+	route    vpcmodel.RouteTableRoute
+	delete   bool
+	done     chan error
+}
+
+// routeBatcher merges the concurrent route requests of a route table into bulk updates.
+// The route controller creates the routes of all nodes concurrently during cluster bootstrap,
+// the requests queued while a route table is being updated are sent in the next update.
+type routeBatcher struct {
+	tableLock   *mutexkv.MutexKV
+	rateLimiter flowcontrol.RateLimiter
+
+	lock    sync.Mutex
+	pending map[string][]*routeRequest
+}
+
+func newRouteBatcher() *routeBatcher {
+	return &routeBatcher{
+		tableLock:   mutexkv.NewMutexKV(),
+		rateLimiter: flowcontrol.NewTokenBucketRateLimiter(routeUpdateQPS, routeUpdateBurst),
+		pending:     make(map[string][]*routeRequest),
+	}
+}
+
+func (b *routeBatcher) enqueue(routeTableID string, req *routeRequest) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.pending[routeTableID] = append(b.pending[routeTableID], req)
+}
+
+func (b *routeBatcher) drain(routeTableID string) []*routeRequest {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	requests := b.pending[routeTableID]
+	delete(b.pending, routeTableID)
+	return requests
+}
+
+// submitRouteRequest queues the request, and waits until it is sent in a bulk update of the route table.
+func (r *Routes) submitRouteRequest(routeTableID string, req *routeRequest) error {
+	req.done = make(chan error, 1)
+	r.routeBatcher.enqueue(routeTableID, req)
+
+	r.routeBatcher.tableLock.Lock(routeTableID)
+	defer r.routeBatcher.tableLock.Unlock(routeTableID)
+
+	// The request may have been sent by the previous holder of the lock.
+	select {
+	case err := <-req.done:
+		return err
+	default:
+	}
+
+	r.processRouteRequests(routeTableID, r.routeBatcher.drain(routeTableID))
+	return <-req.done
+}
+
+func (r *Routes) processRouteRequests(routeTableID string, requests []*routeRequest) {
+	if len(requests) == 0 {
+		return
+	}
+
+	routeTable, err := r.vpcClient.GetRouteTable(routeTableID)
+	if err != nil {
+		for _, req := range requests {
+			req.done <- err
+		}
+		return
+	}
+
+	deleting := make(map[string]bool)
+	toDelete := make([]*routeRequest, 0)
+	for _, req := range requests {
+		if !req.delete {
+			continue
+		}
+		existing := findManagedRoute(routeTable.Routes, req.route)
+		if existing == nil || deleting[existing.Destination] {
+			req.done <- nil
+			continue
+		}
+		deleting[existing.Destination] = true
+		req.route = *existing
+		toDelete = append(toDelete, req)
+	}
+
+	// The routes to delete are not considered as conflicts of the routes to add.
+	existingRoutes := make([]vpcmodel.RouteTableRoute, 0, len(routeTable.Routes))
+	for _, rt := range routeTable.Routes {
+		if !deleting[rt.Destination] {
+			existingRoutes = append(existingRoutes, rt)
+		}
+	}
+
+	toAdd := make([]*routeRequest, 0)
+	for _, req := range requests {
+		if req.delete {
+			continue
+		}
+		if hasRoute(existingRoutes, req.route) {
+			req.done <- nil
+			continue
+		}
+		// Do not clobber the existing routes, such as the routes of peering, VPN or the custom routes.
+		if conflict := findConflictRoute(existingRoutes, req.route); conflict != nil {
+			msg := fmt.Sprintf("the route %s of node %s conflicts with the existing route %s "+
+				"(type: %s, next hop: %s) in route table %s", req.route.Destination, req.nodeName,
+				conflict.Destination, conflict.Type, conflict.Nexthop, routeTableID)
+			r.eventRecorder.Event(nodeReference(req.nodeName), v1.EventTypeWarning, "RouteConflict", msg)
+			req.done <- status.Error(codes.AlreadyExists, msg)
+			continue
+		}
+		existingRoutes = append(existingRoutes, req.route)
+		toAdd = append(toAdd, req)
+	}
+
+	r.sendRouteRequests(routeTableID, toDelete, r.vpcClient.DeleteRoutes)
+	r.sendRouteRequests(routeTableID, toAdd, r.vpcClient.AddRoutes)
+}
+
+func (r *Routes) sendRouteRequests(routeTableID string, requests []*routeRequest,
+	update func(string, []vpcmodel.RouteTableRoute) error) {
+	for start := 0; start < len(requests); start += routeBatchSize {
+		end := start + routeBatchSize
+		if end > len(requests) {
+			end = len(requests)
+		}
+
+		routes := make([]vpcmodel.RouteTableRoute, 0, end-start)
+		for _, req := range requests[start:end] {
+			routes = append(routes, req.route)
+		}
+
+		r.routeBatcher.rateLimiter.Accept()
+		klog.Infof("update %d routes of route table %s", len(routes), routeTableID)
+		err := update(routeTableID, routes)
+		for _, req := range requests[start:end] {
+			req.done <- err
+		}
+	}
+}
+
+// findManagedRoute returns the route with the same destination and description, nil is returned if not found.
+func findManagedRoute(routes []vpcmodel.RouteTableRoute, route vpcmodel.RouteTableRoute) *vpcmodel.RouteTableRoute {
+	for i := range routes {
+		rt := &routes[i]
+		if rt.Destination == route.Destination && rt.Description != nil && route.Description != nil &&
+			*rt.Description == *route.Description {
+			return rt
+		}
+	}
+	return nil
+}
+
+func hasRoute(routes []vpcmodel.RouteTableRoute, route vpcmodel.RouteTableRoute) bool {
+	for _, rt := range routes {
+		if rt.Destination == route.Destination && rt.Nexthop == route.Nexthop && rt.Type == route.Type {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	vpcmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/vpc/v2/model"
+	"k8s.io/client-go/tools/record"
+)
+
+const testRouteTableID = "rtb-1"
+
+func newFakeRoutes(vpc *FakeVPCClient) *Routes {
+	r := &Routes{Basic: newFakeBasic(), routeBatcher: newRouteBatcher()}
+	r.vpcClient = vpc
+	r.kubeClients = &kubeClients{eventRecorder: record.NewFakeRecorder(10)}
+	return r
+}
+
+func testRoute(destination, nexthop string) vpcmodel.RouteTableRoute {
+	desc := routeDescription("kubernetes")
+	return vpcmodel.RouteTableRoute{Type: "ecs", Destination: destination, Nexthop: nexthop, Description: &desc}
+}
+
+func routeDestinations(routes []vpcmodel.RouteTableRoute) []string {
+	destinations := make([]string, 0, len(routes))
+	for _, rt := range routes {
+		destinations = append(destinations, rt.Destination)
+	}
+	return destinations
+}
+
+func TestProcessRouteRequests(t *testing.T) {
+	others := vpcmodel.RouteTableRoute{Type: "peering", Destination: "10.1.0.0/16", Nexthop: "peering-1"}
+
+	tests := []struct {
+		name      string
+		existing  []vpcmodel.RouteTableRoute
+		requests  []*routeRequest
+		getErr    error
+		updateErr error
+
+		expectedErrs    []bool
+		expectedAdded   []string
+		expectedDeleted []string
+	}{
+		{
+			name: "adds the routes in one update",
+			requests: []*routeRequest{
+				{nodeName: "node-1", route: testRoute("172.16.0.0/24", "ecs-1")},
+				{nodeName: "node-2", route: testRoute("172.16.1.0/24", "ecs-2")},
+			},
+			expectedErrs:  []bool{false, false},
+			expectedAdded: []string{"172.16.0.0/24", "172.16.1.0/24"},
+		},
+		{
+			name:     "skips the existing route",
+			existing: []vpcmodel.RouteTableRoute{testRoute("172.16.0.0/24", "ecs-1")},
+			requests: []*routeRequest{
+				{nodeName: "node-1", route: testRoute("172.16.0.0/24", "ecs-1")},
+			},
+			expectedErrs: []bool{false},
+		},
+		{
+			name:     "deletes the route requested twice once",
+			existing: []vpcmodel.RouteTableRoute{testRoute("172.16.0.0/24", "ecs-1")},
+			requests: []*routeRequest{
+				{route: testRoute("172.16.0.0/24", ""), delete: true},
+				{route: testRoute("172.16.0.0/24", ""), delete: true},
+			},
+			expectedErrs:    []bool{false, false},
+			expectedDeleted: []string{"172.16.0.0/24"},
+		},
+		{
+			name:     "ignores the deletes of the routes not found or not managed",
+			existing: []vpcmodel.RouteTableRoute{others},
+			requests: []*routeRequest{
+				{route: testRoute("172.16.0.0/24", ""), delete: true},
+				{route: testRoute("10.1.0.0/16", ""), delete: true},
+			},
+			expectedErrs: []bool{false, false},
+		},
+		{
+			name:     "rejects the route conflicting with an existing route",
+			existing: []vpcmodel.RouteTableRoute{others},
+			requests: []*routeRequest{
+				{nodeName: "node-1", route: testRoute("10.1.0.0/24", "ecs-1")},
+				{nodeName: "node-2", route: testRoute("172.16.1.0/24", "ecs-2")},
+			},
+			expectedErrs:  []bool{true, false},
+			expectedAdded: []string{"172.16.1.0/24"},
+		},
+		{
+			name: "rejects the route conflicting with a route added in the same batch",
+			requests: []*routeRequest{
+				{nodeName: "node-1", route: testRoute("172.16.0.0/24", "ecs-1")},
+				{nodeName: "node-2", route: testRoute("172.16.0.0/16", "ecs-2")},
+			},
+			expectedErrs:  []bool{false, true},
+			expectedAdded: []string{"172.16.0.0/24"},
+		},
+		{
+			name:     "adds the route replacing a route deleted in the same batch",
+			existing: []vpcmodel.RouteTableRoute{testRoute("172.16.0.0/24", "ecs-1")},
+			requests: []*routeRequest{
+				{route: testRoute("172.16.0.0/24", ""), delete: true},
+				{nodeName: "node-2", route: testRoute("172.16.0.0/24", "ecs-2")},
+			},
+			expectedErrs:    []bool{false, false},
+			expectedAdded:   []string{"172.16.0.0/24"},
+			expectedDeleted: []string{"172.16.0.0/24"},
+		},
+		{
+			name: "fails all the routes of the update if it fails",
+			requests: []*routeRequest{
+				{nodeName: "node-1", route: testRoute("172.16.0.0/24", "ecs-1")},
+				{nodeName: "node-2", route: testRoute("172.16.1.0/24", "ecs-2")},
+			},
+			updateErr:     errors.New("internal error"),
+			expectedErrs:  []bool{true, true},
+			expectedAdded: []string{"172.16.0.0/24", "172.16.1.0/24"},
+		},
+		{
+			name: "fails all the requests if the route table is not found",
+			requests: []*routeRequest{
+				{nodeName: "node-1", route: testRoute("172.16.0.0/24", "ecs-1")},
+				{route: testRoute("172.16.1.0/24", ""), delete: true},
+			},
+			getErr:       errors.New("not found"),
+			expectedErrs: []bool{true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var added, deleted []string
+			vpc := &FakeVPCClient{
+				GetRouteTableFunc: func(id string) (*vpcmodel.RouteTableResp, error) {
+					if tt.getErr != nil {
+						return nil, tt.getErr
+					}
+					return &vpcmodel.RouteTableResp{Id: id, Routes: tt.existing}, nil
+				},
+				AddRoutesFunc: func(_ string, routes []vpcmodel.RouteTableRoute) error {
+					added = append(added, routeDestinations(routes)...)
+					return tt.updateErr
+				},
+				DeleteRoutesFunc: func(_ string, routes []vpcmodel.RouteTableRoute) error {
+					deleted = append(deleted, routeDestinations(routes)...)
+					return tt.updateErr
+				},
+			}
+			r := newFakeRoutes(vpc)
+			for _, req := range tt.requests {
+				req.done = make(chan error, 1)
+			}
+
+			r.processRouteRequests(testRouteTableID, tt.requests)
+
+			for i, req := range tt.requests {
+				select {
+				case err := <-req.done:
+					if (err != nil) != tt.expectedErrs[i] {
+						t.Errorf("request %d error = %v, expectedErr %v", i, err, tt.expectedErrs[i])
+					}
+				default:
+					t.Errorf("request %d is not done", i)
+				}
+			}
+			if !reflect.DeepEqual(added, tt.expectedAdded) {
+				t.Errorf("added routes = %v, expected %v", added, tt.expectedAdded)
+			}
+			if !reflect.DeepEqual(deleted, tt.expectedDeleted) {
+				t.Errorf("deleted routes = %v, expected %v", deleted, tt.expectedDeleted)
+			}
+			if count := vpc.CallCount("AddRoutes"); len(tt.expectedAdded) == 0 && count != 0 {
+				t.Errorf("the routes are added %d times, expected none", count)
+			}
+		})
+	}
+}
+
+func TestSubmitRouteRequestsMerged(t *testing.T) {
+	var lock sync.Mutex
+	var added []string
+	vpc := &FakeVPCClient{
+		GetRouteTableFunc: func(id string) (*vpcmodel.RouteTableResp, error) {
+			return &vpcmodel.RouteTableResp{Id: id}, nil
+		},
+		AddRoutesFunc: func(_ string, routes []vpcmodel.RouteTableRoute) error {
+			lock.Lock()
+			defer lock.Unlock()
+			added = append(added, routeDestinations(routes)...)
+			return nil
+		},
+	}
+	r := newFakeRoutes(vpc)
+
+	// the route table is held by an update in flight, the requests are queued in the meantime.
+	r.routeBatcher.tableLock.Lock(testRouteTableID)
+	destinations := []string{"172.16.0.0/24", "172.16.1.0/24", "172.16.2.0/24"}
+	errs := make(chan error, len(destinations))
+	for _, destination := range destinations {
+		go func(destination string) {
+			errs <- r.submitRouteRequest(testRouteTableID, &routeRequest{route: testRoute(destination, "ecs-1")})
+		}(destination)
+	}
+	for queued := 0; queued < len(destinations); {
+		time.Sleep(10 * time.Millisecond)
+		r.routeBatcher.lock.Lock()
+		queued = len(r.routeBatcher.pending[testRouteTableID])
+		r.routeBatcher.lock.Unlock()
+	}
+	r.routeBatcher.tableLock.Unlock(testRouteTableID)
+
+	for range destinations {
+		if err := <-errs; err != nil {
+			t.Errorf("submitRouteRequest() error = %v", err)
+		}
+	}
+	// the first holder of the lock sends all the queued routes, the others find their requests done.
+	if count := vpc.CallCount("GetRouteTable"); count != 1 {
+		t.Errorf("the route table is got %d times, expected 1", count)
+	}
+	if count := vpc.CallCount("AddRoutes"); count != 1 {
+		t.Errorf("the routes are added by %d updates, expected 1", count)
+	}
+	if len(added) != len(destinations) {
+		t.Errorf("added routes = %v, expected %v", added, destinations)
+	}
+}
@@ -0,0 +1,449 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	vpcmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/vpc/v2/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	cloudprovider "k8s.io/cloud-provider"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/common"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/utils"
+)
+
+const (
+	routeTypeECS = "ecs"
+
+	// routeRepairInterval is the interval to repair the routes of the VPC route tables, see repairRoutes.
+	routeRepairInterval = time.Minute
+)
+
+// Routes programs the pod CIDR routes of the nodes into the VPC route tables,
+// the next hop of a route is the ECS of the node.
+// If the route type is "er", the routes are programmed into the ER route table instead,
+// the next hop of a route is the VPC attachment of the cluster.
+type Routes struct {
+	Basic
+	// routeBatcher serializes the updates of a route table, and merges the concurrent updates into bulk updates.
+	routeBatcher *routeBatcher
+}
+
+func routeDescription(clusterName string) string {
+	return fmt.Sprintf("Created by the route controller of the k8s cluster(%s).", clusterName)
+}
+
+// getRouteTableIDs returns the route tables configured in the cloud config, or discovers the route tables in use
+// of the VPC, which are the default route table and the route tables associated with subnets.
+func (r *Routes) getRouteTableIDs() ([]string, error) {
+	if len(r.cloudConfig.VpcOpts.RouteTableIDs) > 0 {
+		return r.cloudConfig.VpcOpts.RouteTableIDs, nil
+	}
+
+	vpcID := r.cloudConfig.VpcOpts.ID
+	tables, err := r.vpcClient.ListRouteTables(&vpcmodel.ListRouteTablesRequest{VpcId: &vpcID})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(tables))
+	for _, t := range tables {
+		if t.Default || len(t.Subnets) > 0 {
+			ids = append(ids, t.Id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no route table in use found in VPC %s", vpcID)
+	}
+	return ids, nil
+}
+
+// managedRoute is a route created by the route controller, and the route tables containing it.
+type managedRoute struct {
+	route         vpcmodel.RouteTableRoute
+	routeTableIDs []string
+}
+
+// listManagedRoutes returns the routes of the cluster in the route tables, keyed by destination and next hop.
+func (r *Routes) listManagedRoutes(clusterName string, routeTableIDs []string) (map[string]*managedRoute, []string, error) {
+	desc := routeDescription(clusterName)
+	routes := make(map[string]*managedRoute)
+	keys := make([]string, 0)
+	for _, id := range routeTableIDs {
+		routeTable, err := r.vpcClient.GetRouteTable(id)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, rt := range routeTable.Routes {
+			if rt.Type != routeTypeECS || rt.Description == nil || *rt.Description != desc {
+				continue
+			}
+			key := rt.Destination + "/" + rt.Nexthop
+			if _, ok := routes[key]; !ok {
+				routes[key] = &managedRoute{route: rt}
+				keys = append(keys, key)
+			}
+			routes[key].routeTableIDs = append(routes[key].routeTableIDs, id)
+		}
+	}
+	return routes, keys, nil
+}
+
+// ListRoutes lists all managed routes that belong to the specified clusterName.
+// The routes whose next hop ECS no longer exists are reported as blackhole routes,
+// and the routes whose node has been removed are reported without a target node,
+// so that the route controller deletes them.
+// The route of a pod CIDR is reported with the node owning the pod CIDR, even if its next hop is not the current
+// ECS of the node, it is repaired by repairRoutes. ListRoutes never changes the route tables.
+func (r *Routes) ListRoutes(ctx context.Context, clusterName string) ([]*cloudprovider.Route, error) {
+	klog.V(4).Infof("ListRoutes is called, cluster: %s", clusterName)
+	if r.cloudConfig.VpcOpts.RouteType == config.RouteTypeER {
+		return r.listERRoutes(ctx)
+	}
+
+	routeTableIDs, err := r.getRouteTableIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	managedRoutes, keys, err := r.listManagedRoutes(clusterName, routeTableIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	targetsByInstance, targetsByCIDR, err := r.listRouteTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]*cloudprovider.Route, 0, len(keys))
+	for _, key := range keys {
+		rt := managedRoutes[key].route
+		target := targetsByInstance[rt.Nexthop]
+		if owner, ok := targetsByCIDR[rt.Destination]; ok {
+			target = owner
+		}
+
+		route := &cloudprovider.Route{
+			Name:            rt.Destination,
+			TargetNode:      target.nodeName,
+			DestinationCIDR: rt.Destination,
+		}
+		if route.TargetNode == "" {
+			if _, err := r.ecsCache.Get(rt.Nexthop); err == nil {
+				klog.Infof("the node of ECS %s is removed, the route %s is stale", rt.Nexthop, rt.Destination)
+			} else if common.IsNotFound(err) {
+				klog.Infof("the next hop ECS %s no longer exists, the route %s is stale", rt.Nexthop, rt.Destination)
+				route.Blackhole = true
+			} else {
+				return nil, err
+			}
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// runRouteRepair repairs the routes of the VPC route tables every routeRepairInterval until stopped.
+func (h *CloudProvider) runRouteRepair(stop <-chan struct{}) {
+	if h.cloudConfig.VpcOpts.RouteType == config.RouteTypeER {
+		return
+	}
+	routes, ok := h.Routes()
+	if !ok {
+		return
+	}
+	clusterName := h.cloudControllerManagerOpts.KubeCloudShared.ClusterName
+	ctx, cancel := wait.ContextForChannel(stop)
+	go func() {
+		<-stop
+		cancel()
+	}()
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := routes.(*Routes).repairRoutes(ctx, clusterName); err != nil {
+			klog.Errorf("failed to repair the routes, try again later: %s", err)
+		}
+	}, routeRepairInterval)
+}
+
+// repairRoutes repairs the managed routes of the cluster besides the route controller, which only creates
+// the missing routes and deletes the stale ones: the routes whose next hop is not the current ECS of the node
+// owning the pod CIDR are pointed to the current ECS, the route tables missing a route of an existing node
+// are added with the route, and the routes are propagated to the ER route table if enabled.
+func (r *Routes) repairRoutes(ctx context.Context, clusterName string) error {
+	routeTableIDs, err := r.getRouteTableIDs()
+	if err != nil {
+		return err
+	}
+
+	managedRoutes, keys, err := r.listManagedRoutes(clusterName, routeTableIDs)
+	if err != nil {
+		return err
+	}
+
+	targetsByInstance, targetsByCIDR, err := r.listRouteTargets(ctx)
+	if err != nil {
+		return err
+	}
+
+	destinations := make([]string, 0, len(keys))
+	for _, key := range keys {
+		rt := managedRoutes[key].route
+		target, ok := targetsByInstance[rt.Nexthop]
+		if owner, owned := targetsByCIDR[rt.Destination]; owned {
+			if owner.instanceID != rt.Nexthop {
+				if err := r.repairRoute(owner, rt, managedRoutes[key].routeTableIDs); err != nil {
+					msg := fmt.Sprintf("failed to point route %s to ECS %s: %s", rt.Destination, owner.instanceID, err)
+					klog.Errorf("node %s: %s", owner.nodeName, msg)
+					r.eventRecorder.Event(nodeReference(owner.nodeName), v1.EventTypeWarning, "RouteRepairFailed", msg)
+					continue
+				}
+				rt.Nexthop = owner.instanceID
+			}
+			target, ok = owner, true
+		}
+		if !ok {
+			continue
+		}
+
+		r.checkNextHopStopped(target.nodeName, rt)
+		if len(managedRoutes[key].routeTableIDs) < len(routeTableIDs) {
+			if err := r.addRouteToTables(target.nodeName, rt, routeTableIDs); err != nil {
+				klog.Warningf("failed to repair route %s of node %s, try again later", rt.Destination, target.nodeName)
+			}
+		}
+		destinations = append(destinations, rt.Destination)
+	}
+
+	if r.isERPropagationEnabled() {
+		if err := r.ensureERRoutes(destinations); err != nil {
+			return fmt.Errorf("failed to propagate the routes to ER route table: %s", err)
+		}
+	}
+	return nil
+}
+
+// addRouteToTables adds the route of the node to the route tables missing it.
+func (r *Routes) addRouteToTables(nodeName types.NodeName, route vpcmodel.RouteTableRoute, routeTableIDs []string) error {
+	errs := make([]error, 0)
+	for _, id := range routeTableIDs {
+		if err := r.addRoute(nodeName, id, route); err != nil {
+			klog.Errorf("failed to add route %s to route table %s: %s", route.Destination, id, err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.NewAggregate(errs)
+}
+
+func (r *Routes) addRoute(nodeName types.NodeName, routeTableID string, route vpcmodel.RouteTableRoute) error {
+	return r.submitRouteRequest(routeTableID, &routeRequest{nodeName: nodeName, route: route})
+}
+
+// findConflictRoute returns the route whose destination overlaps with the route, nil is returned if not found.
+func findConflictRoute(routes []vpcmodel.RouteTableRoute, route vpcmodel.RouteTableRoute) *vpcmodel.RouteTableRoute {
+	for i := range routes {
+		if utils.IsCIDROverlapped(routes[i].Destination, route.Destination) {
+			return &routes[i]
+		}
+	}
+	return nil
+}
+
+// nodeReference returns the reference of the node to record events, the same as the route controller.
+func nodeReference(nodeName types.NodeName) *v1.ObjectReference {
+	return &v1.ObjectReference{
+		Kind: "Node",
+		Name: string(nodeName),
+		UID:  types.UID(nodeName),
+	}
+}
+
+func (r *Routes) deleteRoute(routeTableID, clusterName, destination string) error {
+	desc := routeDescription(clusterName)
+	return r.submitRouteRequest(routeTableID, &routeRequest{
+		route:  vpcmodel.RouteTableRoute{Destination: destination, Description: &desc},
+		delete: true,
+	})
+}
+
+// routeTarget is a node and its ECS, which is the next hop of the pod CIDR routes of the node.
+type routeTarget struct {
+	nodeName   types.NodeName
+	instanceID string
+}
+
+// listRouteTargets returns the nodes keyed by the ECS ID, and keyed by the pod CIDRs.
+func (r *Routes) listRouteTargets(ctx context.Context) (map[string]routeTarget, map[string]routeTarget, error) {
+	nodes, err := r.kubeClient.Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byInstance := make(map[string]routeTarget, len(nodes.Items))
+	byCIDR := make(map[string]routeTarget, len(nodes.Items))
+	for _, node := range nodes.Items {
+		if node.Spec.ProviderID == "" {
+			continue
+		}
+		region, instanceID, err := parseProviderID(node.Spec.ProviderID)
+		if err != nil || region != "" {
+			continue
+		}
+
+		target := routeTarget{nodeName: types.NodeName(node.Name), instanceID: instanceID}
+		byInstance[instanceID] = target
+		for _, cidr := range node.Spec.PodCIDRs {
+			byCIDR[cidr] = target
+		}
+	}
+	return byInstance, byCIDR, nil
+}
+
+// repairRoute points the route to the current ECS of the node, which is replaced by a new ECS.
+func (r *Routes) repairRoute(owner routeTarget, route vpcmodel.RouteTableRoute, routeTableIDs []string) error {
+	staleNexthop := route.Nexthop
+	route.Nexthop = owner.instanceID
+	for _, id := range routeTableIDs {
+		if err := r.modifyRoute(id, route); err != nil {
+			return err
+		}
+	}
+
+	msg := fmt.Sprintf("the next hop of route %s is changed from ECS %s to ECS %s",
+		route.Destination, staleNexthop, owner.instanceID)
+	klog.Infof("node %s: %s", owner.nodeName, msg)
+	r.eventRecorder.Event(nodeReference(owner.nodeName), v1.EventTypeNormal, "RouteRepaired", msg)
+	return nil
+}
+
+func (r *Routes) modifyRoute(routeTableID string, route vpcmodel.RouteTableRoute) error {
+	r.routeBatcher.tableLock.Lock(routeTableID)
+	defer r.routeBatcher.tableLock.Unlock(routeTableID)
+	return r.vpcClient.ModifyRoutes(routeTableID, []vpcmodel.RouteTableRoute{route})
+}
+
+// checkNextHopStopped records an event if the next hop ECS of the route is stopped,
+// the route can not be repaired until the ECS is started or the node is replaced.
+func (r *Routes) checkNextHopStopped(nodeName types.NodeName, route vpcmodel.RouteTableRoute) {
+	instance, err := r.ecsCache.Get(route.Nexthop)
+	if err != nil || instance.Status != instanceShutoffStatus {
+		return
+	}
+
+	msg := fmt.Sprintf("the next hop ECS %s of route %s is stopped", route.Nexthop, route.Destination)
+	klog.Warningf("node %s: %s", nodeName, msg)
+	r.eventRecorder.Event(nodeReference(nodeName), v1.EventTypeWarning, "RouteNextHopStopped", msg)
+}
+
+func (r *Routes) getNodeInstanceID(ctx context.Context, nodeName types.NodeName) (string, error) {
+	node, err := r.kubeClient.Nodes().Get(ctx, string(nodeName), metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	if node.Spec.ProviderID != "" {
+		region, instanceID, err := parseProviderID(node.Spec.ProviderID)
+		if err != nil {
+			return "", err
+		}
+		if region != "" {
+			return "", status.Errorf(codes.InvalidArgument, "node %s is in the additional region %s, "+
+				"routes are only supported in the region of [Global] section", nodeName, region)
+		}
+		return instanceID, nil
+	}
+
+	instance, err := r.getInstanceByNode(node)
+	if err != nil {
+		return "", err
+	}
+	return instance.Id, nil
+}
+
+// CreateRoute creates the described managed route
+// route.Name will be ignored, although the cloud-provider may use nameHint
+// to create a more user-meaningful name.
+func (r *Routes) CreateRoute(ctx context.Context, clusterName string, _ string, route *cloudprovider.Route) error {
+	klog.Infof("CreateRoute is called, cluster: %s, node: %s, destination: %s",
+		clusterName, route.TargetNode, route.DestinationCIDR)
+	if r.cloudConfig.VpcOpts.RouteType == config.RouteTypeER {
+		return r.createERRoute(route)
+	}
+
+	instanceID, err := r.getNodeInstanceID(ctx, route.TargetNode)
+	if err != nil {
+		return err
+	}
+
+	routeTableIDs, err := r.getRouteTableIDs()
+	if err != nil {
+		return err
+	}
+
+	desc := routeDescription(clusterName)
+	err = r.addRouteToTables(route.TargetNode, vpcmodel.RouteTableRoute{
+		Type:        routeTypeECS,
+		Destination: route.DestinationCIDR,
+		Nexthop:     instanceID,
+		Description: &desc,
+	}, routeTableIDs)
+	if err != nil || !r.isERPropagationEnabled() {
+		return err
+	}
+	return r.createERRoute(route)
+}
+
+// DeleteRoute deletes the specified managed route
+// Route should be as returned by ListRoutes
+func (r *Routes) DeleteRoute(_ context.Context, clusterName string, route *cloudprovider.Route) error {
+	klog.Infof("DeleteRoute is called, cluster: %s, node: %s, destination: %s",
+		clusterName, route.TargetNode, route.DestinationCIDR)
+	if r.cloudConfig.VpcOpts.RouteType == config.RouteTypeER {
+		return r.deleteERRoute(route)
+	}
+
+	routeTableIDs, err := r.getRouteTableIDs()
+	if err != nil {
+		return err
+	}
+
+	errs := make([]error, 0)
+	for _, id := range routeTableIDs {
+		if err := r.deleteRoute(id, clusterName, route.DestinationCIDR); err != nil {
+			klog.Errorf("failed to delete route %s from route table %s: %s", route.DestinationCIDR, id, err)
+			errs = append(errs, err)
+		}
+	}
+	if r.isERPropagationEnabled() {
+		if err := r.deleteERRoute(route); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.NewAggregate(errs)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	cloudprovider "k8s.io/cloud-provider"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+)
+
+// listERRoutes lists the static routes of the ER route table pointing to the VPC attachment of the cluster.
+// The routes whose pod CIDR is not owned by any node are reported without a target node,
+// so that the route controller deletes them if they are in the cluster CIDR.
+func (r *Routes) listERRoutes(ctx context.Context) ([]*cloudprovider.Route, error) {
+	vpcOpts := r.cloudConfig.VpcOpts
+	staticRoutes, err := r.erClient.ListStaticRoutes(vpcOpts.ERRouteTableID, vpcOpts.ERAttachmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, targetsByCIDR, err := r.listRouteTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]*cloudprovider.Route, 0, len(staticRoutes))
+	for _, rt := range staticRoutes {
+		routes = append(routes, &cloudprovider.Route{
+			Name:            rt.Id,
+			TargetNode:      targetsByCIDR[rt.Destination].nodeName,
+			DestinationCIDR: rt.Destination,
+		})
+	}
+	return routes, nil
+}
+
+func (r *Routes) createERRoute(route *cloudprovider.Route) error {
+	return r.ensureERRoutes([]string{route.DestinationCIDR})
+}
+
+// ensureERRoutes adds the routes of the destinations missing in the ER route table.
+func (r *Routes) ensureERRoutes(destinations []string) error {
+	vpcOpts := r.cloudConfig.VpcOpts
+	staticRoutes, err := r.erClient.ListStaticRoutes(vpcOpts.ERRouteTableID, vpcOpts.ERAttachmentID)
+	if err != nil {
+		return err
+	}
+
+	existing := sets.NewString()
+	for _, rt := range staticRoutes {
+		existing.Insert(rt.Destination)
+	}
+
+	errs := make([]error, 0)
+	for _, destination := range destinations {
+		if existing.Has(destination) {
+			continue
+		}
+
+		r.routeBatcher.rateLimiter.Accept()
+		klog.Infof("add route %s via attachment %s to ER route table %s",
+			destination, vpcOpts.ERAttachmentID, vpcOpts.ERRouteTableID)
+		if _, err := r.erClient.CreateStaticRoute(vpcOpts.ERRouteTableID, destination, vpcOpts.ERAttachmentID); err != nil {
+			klog.Errorf("failed to add route %s to ER route table %s: %s", destination, vpcOpts.ERRouteTableID, err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.NewAggregate(errs)
+}
+
+// isERPropagationEnabled returns true if the routes of the VPC route tables are also programmed into the ER route table.
+func (r *Routes) isERPropagationEnabled() bool {
+	vpcOpts := r.cloudConfig.VpcOpts
+	return vpcOpts.RouteType == config.RouteTypeVPC && vpcOpts.ERPropagation
+}
+
+func (r *Routes) deleteERRoute(route *cloudprovider.Route) error {
+	vpcOpts := r.cloudConfig.VpcOpts
+	staticRoutes, err := r.erClient.ListStaticRoutes(vpcOpts.ERRouteTableID, vpcOpts.ERAttachmentID)
+	if err != nil {
+		return err
+	}
+
+	for _, rt := range staticRoutes {
+		if rt.Destination != route.DestinationCIDR {
+			continue
+		}
+		r.routeBatcher.rateLimiter.Accept()
+		klog.Infof("delete route %s from ER route table %s", rt.Destination, vpcOpts.ERRouteTableID)
+		return r.erClient.DeleteStaticRoute(vpcOpts.ERRouteTableID, rt.Id)
+	}
+
+	klog.Infof("the route %s is not found in ER route table %s, skip deleting",
+		route.DestinationCIDR, vpcOpts.ERRouteTableID)
+	return nil
+}
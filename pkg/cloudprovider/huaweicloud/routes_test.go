@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	ecsmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ecs/v2/model"
+	vpcmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/vpc/v2/model"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	ecsapi "sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud/ecs"
+)
+
+func TestRepairRoutes(t *testing.T) {
+	// node-1 is replaced by the ECS ecs-new, the route of its pod CIDR still points to the ECS ecs-old.
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Spec:       v1.NodeSpec{ProviderID: "huaweicloud://ecs-new", PodCIDRs: []string{"172.16.0.0/24"}},
+	}
+
+	tests := []struct {
+		name      string
+		modifyErr error
+
+		expectedEvent string
+	}{
+		{
+			name:          "points the route to the current ECS of the node",
+			expectedEvent: "RouteRepaired",
+		},
+		{
+			name:          "records the failure of the repair",
+			modifyErr:     errors.New("internal error"),
+			expectedEvent: "RouteRepairFailed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var modified []vpcmodel.RouteTableRoute
+			vpc := &FakeVPCClient{
+				GetRouteTableFunc: func(id string) (*vpcmodel.RouteTableResp, error) {
+					return &vpcmodel.RouteTableResp{Id: id, Routes: []vpcmodel.RouteTableRoute{
+						testRoute("172.16.0.0/24", "ecs-old"),
+					}}, nil
+				},
+				ModifyRoutesFunc: func(_ string, routes []vpcmodel.RouteTableRoute) error {
+					modified = append(modified, routes...)
+					return tt.modifyErr
+				},
+			}
+			ecs := &FakeECSClient{
+				GetFunc: func(id string) (*ecsmodel.ServerDetail, error) {
+					return &ecsmodel.ServerDetail{Id: id, Status: "ACTIVE"}, nil
+				},
+			}
+			recorder := record.NewFakeRecorder(10)
+			r := newFakeRoutes(vpc)
+			r.cloudConfig.VpcOpts.RouteTableIDs = []string{testRouteTableID}
+			r.ecsClient = ecs
+			r.ecsCache = ecsapi.NewInstanceCache(ecs, time.Minute)
+			r.kubeClients = &kubeClients{
+				kubeClient:    fake.NewSimpleClientset(node).CoreV1(),
+				eventRecorder: recorder,
+			}
+
+			routes, err := r.ListRoutes(context.TODO(), "kubernetes")
+			if err != nil {
+				t.Fatalf("ListRoutes() error = %v", err)
+			}
+			if len(routes) != 1 || routes[0].TargetNode != types.NodeName("node-1") || routes[0].Blackhole {
+				t.Fatalf("ListRoutes() = %v, expected the route of node-1", routes)
+			}
+			if count := vpc.CallCount("ModifyRoutes") + vpc.CallCount("AddRoutes"); count != 0 {
+				t.Fatalf("the route tables are changed %d times by ListRoutes, expected none", count)
+			}
+
+			if err := r.repairRoutes(context.TODO(), "kubernetes"); err != nil {
+				t.Fatalf("repairRoutes() error = %v", err)
+			}
+			if len(modified) != 1 || modified[0].Nexthop != "ecs-new" {
+				t.Errorf("modified routes = %v, expected the route pointed to ecs-new", modified)
+			}
+			select {
+			case event := <-recorder.Events:
+				if !containsReason(event, tt.expectedEvent) {
+					t.Errorf("event = %q, expected the reason %s", event, tt.expectedEvent)
+				}
+			default:
+				t.Errorf("no event is recorded, expected %s", tt.expectedEvent)
+			}
+		})
+	}
+}
+
+// containsReason returns whether the event of the FakeRecorder, in the form of "<type> <reason> <message>",
+// has the reason.
+func containsReason(event, reason string) bool {
+	for _, eventType := range []string{v1.EventTypeNormal, v1.EventTypeWarning} {
+		if strings.HasPrefix(event, eventType+" "+reason+" ") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	vpcmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/vpc/v2/model"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/common"
+)
+
+// anyIPv4 is the source of the rules if the clients of the service are not restricted.
+const anyIPv4 = "0.0.0.0/0"
+
+// securityGroupRule is the ingress rule allowing a node port from a source.
+type securityGroupRule struct {
+	securityGroupID string
+	protocol        string
+	port            int32
+	cidr            string
+}
+
+func (r securityGroupRule) String() string {
+	return fmt.Sprintf("%s %s/%d from %s", r.securityGroupID, r.protocol, r.port, r.cidr)
+}
+
+// securityGroupRuleMarker is the prefix of the descriptions of the rules of the service and the class,
+// the rules of a service migrating between the classes are managed separately.
+func securityGroupRuleMarker(service *v1.Service, class string) string {
+	return fmt.Sprintf("k8s_%s_%s", service.UID, class)
+}
+
+// sharedSecurityGroupSources returns the sources of the shared load balancers.
+func (b Basic) sharedSecurityGroupSources(*v1.Service) []string {
+	return b.cloudConfig.SecurityGroupOpts.SharedSourceCIDRs
+}
+
+// dedicatedSecurityGroupSources returns the sources of the dedicated load balancers, which pass the client
+// addresses through to the members, and the sources of their health checks.
+func (b Basic) dedicatedSecurityGroupSources(service *v1.Service) []string {
+	sources := sets.NewString(b.cloudConfig.SecurityGroupOpts.DedicatedSourceCIDRs...)
+	if len(service.Spec.LoadBalancerSourceRanges) == 0 {
+		sources.Insert(anyIPv4)
+	}
+	for _, cidr := range service.Spec.LoadBalancerSourceRanges {
+		sources.Insert(strings.TrimSpace(cidr))
+	}
+	return sources.List()
+}
+
+// nodeSecurityGroups returns the configured security group of the nodes, or the security groups of their ECSs.
+func (b Basic) nodeSecurityGroups(nodes []*v1.Node) ([]string, error) {
+	if id := b.cloudConfig.SecurityGroupOpts.ID; id != "" {
+		return []string{id}, nil
+	}
+	groups := sets.NewString()
+	for _, node := range nodes {
+		instance, err := b.getInstanceByNode(node)
+		if err != nil {
+			if common.IsNotFound(err) {
+				klog.Warningf("the security groups of node %s are skipped: %s", node.Name, err)
+				continue
+			}
+			return nil, err
+		}
+		for _, group := range instance.SecurityGroups {
+			groups.Insert(group.Id)
+		}
+	}
+	return groups.List(), nil
+}
+
+// desiredSecurityGroupRules returns the rules allowing the node ports of the service from the sources.
+// The protocols the security groups do not support, such as SCTP, are skipped.
+func desiredSecurityGroupRules(service *v1.Service, groups, sources []string) map[securityGroupRule]bool {
+	rules := make(map[securityGroupRule]bool)
+	for _, port := range service.Spec.Ports {
+		if port.NodePort == 0 || (port.Protocol != v1.ProtocolTCP && port.Protocol != v1.ProtocolUDP) {
+			continue
+		}
+		for _, group := range groups {
+			for _, cidr := range sources {
+				rules[securityGroupRule{
+					securityGroupID: group,
+					protocol:        strings.ToLower(string(port.Protocol)),
+					port:            port.NodePort,
+					cidr:            cidr,
+				}] = true
+			}
+		}
+	}
+	return rules
+}
+
+// listSecurityGroupRules returns the rules of the marker, in the configured security group if any.
+func (b Basic) listSecurityGroupRules(marker string) ([]vpcmodel.SecurityGroupRule, error) {
+	req := &vpcmodel.ListSecurityGroupRulesRequest{}
+	if id := b.cloudConfig.SecurityGroupOpts.ID; id != "" {
+		req.SecurityGroupId = &id
+	}
+	rules, err := b.vpcClient.ListSecurityGroupRules(req)
+	if err != nil {
+		return nil, err
+	}
+	var rst []vpcmodel.SecurityGroupRule
+	for _, rule := range rules {
+		if strings.HasPrefix(rule.Description, marker) {
+			rst = append(rst, rule)
+		}
+	}
+	return rst, nil
+}
+
+// ensureSecurityGroupRules adds the rules allowing the node ports of the service from the sources of the class
+// to the security groups of the nodes, and removes the obsolete ones, if manage-rules is enabled.
+func (b Basic) ensureSecurityGroupRules(service *v1.Service, class string, nodes []*v1.Node,
+	sources []string) error {
+	if !b.cloudConfig.SecurityGroupOpts.ManageRules {
+		return nil
+	}
+	groups, err := b.nodeSecurityGroups(nodes)
+	if err != nil {
+		return fmt.Errorf("failed to get the security groups of the nodes: %w", err)
+	}
+	return b.syncSecurityGroupRules(service, class, desiredSecurityGroupRules(service, groups, sources))
+}
+
+// syncSecurityGroupRules adds the missing rules of the marker and removes the others,
+// the rules are added first so that the ports are not closed in between.
+func (b Basic) syncSecurityGroupRules(service *v1.Service, class string, desired map[securityGroupRule]bool) error {
+	marker := securityGroupRuleMarker(service, class)
+	existing, err := b.listSecurityGroupRules(marker)
+	if err != nil {
+		return fmt.Errorf("failed to list the security group rules: %w", err)
+	}
+
+	var obsolete []vpcmodel.SecurityGroupRule
+	found := make(map[securityGroupRule]bool)
+	for _, rule := range existing {
+		key := securityGroupRule{
+			securityGroupID: rule.SecurityGroupId,
+			protocol:        rule.Protocol,
+			port:            rule.PortRangeMin,
+			cidr:            rule.RemoteIpPrefix,
+		}
+		if desired[key] && rule.PortRangeMax == rule.PortRangeMin && !found[key] {
+			found[key] = true
+			continue
+		}
+		obsolete = append(obsolete, rule)
+	}
+
+	var missing []securityGroupRule
+	for rule := range desired {
+		if !found[rule] {
+			missing = append(missing, rule)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i].String() < missing[j].String() })
+
+	description := fmt.Sprintf("%s %s/%s", marker, service.Namespace, service.Name)
+	for _, rule := range missing {
+		if err := b.createSecurityGroupRule(rule, description); err != nil {
+			return err
+		}
+	}
+	for _, rule := range obsolete {
+		klog.Infof("delete the security group rule %s of service %s/%s: %s/%d-%d from %s", rule.Id,
+			service.Namespace, service.Name, rule.Protocol, rule.PortRangeMin, rule.PortRangeMax, rule.RemoteIpPrefix)
+		if err := b.vpcClient.DeleteSecurityGroupRule(rule.Id); err != nil && !common.IsNotFound(err) {
+			return fmt.Errorf("failed to delete the security group rule %s: %w", rule.Id, err)
+		}
+	}
+	return nil
+}
+
+// createSecurityGroupRule adds the ingress rule, the rule is kept as is if an identical one already exists,
+// such as the one added by hand.
+func (b Basic) createSecurityGroupRule(rule securityGroupRule, description string) error {
+	klog.Infof("add the security group rule %s", rule)
+	ethertype := "IPv4"
+	if ip, _, err := net.ParseCIDR(rule.cidr); err == nil && ip.To4() == nil {
+		ethertype = "IPv6"
+	}
+	_, err := b.vpcClient.CreateSecurityGroupRule(&vpcmodel.CreateSecurityGroupRuleOption{
+		SecurityGroupId: rule.securityGroupID,
+		Description:     &description,
+		Direction:       "ingress",
+		Ethertype:       &ethertype,
+		Protocol:        &rule.protocol,
+		PortRangeMin:    &rule.port,
+		PortRangeMax:    &rule.port,
+		RemoteIpPrefix:  &rule.cidr,
+	})
+	if common.GetStatusCode(err) == http.StatusConflict {
+		klog.Infof("the security group rule %s already exists", rule)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add the security group rule %s: %w", rule, err)
+	}
+	return nil
+}
+
+// deleteSecurityGroupRules removes the rules of the service and the class, if manage-rules is enabled.
+func (b Basic) deleteSecurityGroupRules(service *v1.Service, class string) error {
+	if !b.cloudConfig.SecurityGroupOpts.ManageRules {
+		return nil
+	}
+	return b.syncSecurityGroupRules(service, class, nil)
+}
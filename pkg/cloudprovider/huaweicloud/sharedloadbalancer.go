@@ -22,7 +22,6 @@ import (
 	"fmt"
 	"strconv"
 
-	ecsmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ecs/v2/model"
 	eipmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/eip/v2/model"
 	elbmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/elb/v2/model"
 	elbmodelv3 "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/elb/v3/model"
@@ -93,27 +92,28 @@ func (l *SharedLoadBalancer) getLoadBalancerInstance(ctx context.Context, cluste
 		return l.sharedELBClient.GetInstance(id)
 	}
 
-	name := l.GetLoadBalancerName(ctx, clusterName, service)
-	list, err := l.sharedELBClient.ListInstances(&elbmodel.ListLoadbalancersRequest{Name: &name})
-	if err != nil {
-		return nil, err
-	}
-	if len(list) == 0 {
-		return nil, status.Errorf(codes.NotFound, "not found ELB instance %s", name)
-	}
-	if len(list) != 1 {
-		return nil, status.Errorf(codes.Unavailable, "error, found %d ELBs named %s, make sure there is only one",
-			len(list), name)
+	names := l.loadBalancerNames(clusterName, service)
+	for _, name := range names {
+		list, err := l.sharedELBClient.ListInstances(&elbmodel.ListLoadbalancersRequest{Name: &name})
+		if err != nil {
+			return nil, err
+		}
+		if len(list) == 0 {
+			continue
+		}
+		if len(list) != 1 {
+			return nil, status.Errorf(codes.Unavailable, "error, found %d ELBs named %s, make sure there is only one",
+				len(list), name)
+		}
+		return &list[0], nil
 	}
-	return &list[0], nil
+	return nil, status.Errorf(codes.NotFound, "not found ELB instance %s", names[0])
 }
 
 // GetLoadBalancerName returns the name of the load balancer. Implementations must treat the
 // *v1.Service parameter as read-only and not modify it.
 func (l *SharedLoadBalancer) GetLoadBalancerName(_ context.Context, clusterName string, service *v1.Service) string {
-	klog.Infof("GetLoadBalancerName: called with service %s/%s", service.Namespace, service.Name)
-	name := fmt.Sprintf("k8s_service_%s_%s_%s", clusterName, service.Namespace, service.Name)
-	return utils.CutString(name, defaultMaxNameLength)
+	return loadBalancerName(l.clusterID(clusterName), service)
 }
 
 func ensureLoadBalancerValidation(service *v1.Service, nodes []*v1.Node) error {
@@ -157,6 +157,9 @@ func (l *SharedLoadBalancer) EnsureLoadBalancer(ctx context.Context, clusterName
 			return nil, e
 		}
 		loadbalancer, err = l.createLoadbalancer(clusterName, subnetID, service)
+		if err != nil {
+			l.invalidateSubnet(subnetID, err)
+		}
 	}
 	if err != nil {
 		return nil, err
@@ -172,9 +175,9 @@ func (l *SharedLoadBalancer) EnsureLoadBalancer(ctx context.Context, clusterName
 		listener := l.filterListenerByPort(listeners, service, port)
 		// add or update listener
 		if listener == nil {
-			listener, err = l.createListener(loadbalancer.Id, service, port)
-		} else {
-			err = l.updateListener(listener, service)
+			listener, err = l.createListener(clusterName, loadbalancer.Id, service, port)
+		} else if err = l.checkListenerOwner(clusterName, service, listener.Id, port.Port, listener.Description); err == nil {
+			err = l.updateListener(clusterName, listener, service)
 		}
 		if err != nil {
 			return nil, err
@@ -183,9 +186,9 @@ func (l *SharedLoadBalancer) EnsureLoadBalancer(ctx context.Context, clusterName
 		listeners = popListener(listeners, listener.Id)
 
 		// query pool or create pool
-		pool, err := l.getPool(loadbalancer.Id, listener.Id)
+		pool, err := l.getPool(loadbalancer.Id, listener)
 		if err != nil && common.IsNotFound(err) {
-			pool, err = l.createPool(listener, service)
+			pool, err = l.createPool(clusterName, listener, service)
 		}
 		if err != nil {
 			return nil, err
@@ -210,6 +213,10 @@ func (l *SharedLoadBalancer) EnsureLoadBalancer(ctx context.Context, clusterName
 		}
 	}
 
+	if err = l.ensureSecurityGroupRules(service, "shared", nodes, l.sharedSecurityGroupSources(service)); err != nil {
+		return nil, err
+	}
+
 	ingressIP := loadbalancer.VipAddress
 	publicIPAddr, err := l.createOrAssociateEIP(loadbalancer, service)
 	if err == nil {
@@ -237,6 +244,15 @@ func (l *SharedLoadBalancer) createOrAssociateEIP(loadbalancer *elbmodel.Loadbal
 	var err error
 	eipID := getStringFromSvsAnnotation(service, ElbEipID, "")
 	if eipID == "" {
+		// the EIP created by a previous reconcile is bound to the VIP port already, it is not created again.
+		ips, err := l.eipClient.List(&eipmodel.ListPublicipsRequest{PortId: &[]string{loadbalancer.VipPortId}})
+		if err != nil {
+			return "", status.Errorf(codes.Unavailable, "error querying EIPs base on PortId (%s): %s",
+				loadbalancer.VipPortId, err)
+		}
+		if len(ips) > 0 {
+			return getEipAddress(&ips[0])
+		}
 		eipID, err = l.createEIP(service)
 		if err != nil {
 			return "", status.Errorf(codes.Internal, "rollback：failed to create EIP, delete ELB instance, error: %s", err)
@@ -278,8 +294,7 @@ func getEipAddress(eip *eipmodel.PublicipShowResp) (string, error) {
 func (l *SharedLoadBalancer) createLoadbalancer(clusterName, subnetID string, service *v1.Service) (*elbmodel.LoadbalancerResp, error) {
 	name := l.GetLoadBalancerName(context.TODO(), clusterName, service)
 	provider := elbmodel.GetCreateLoadbalancerReqProviderEnum().VLB
-	desc := fmt.Sprintf("Created by the ELB service(%s/%s) of the k8s cluster(%s).",
-		service.Namespace, service.Name, clusterName)
+	desc := l.resourceDescription(clusterName, service)
 	loadbalancer, err := l.sharedELBClient.CreateInstanceCompleted(&elbmodel.CreateLoadbalancerReq{
 		Name:        &name,
 		VipSubnetId: subnetID,
@@ -293,7 +308,7 @@ func (l *SharedLoadBalancer) createLoadbalancer(clusterName, subnetID string, se
 }
 
 func (l *SharedLoadBalancer) addOrRemoveHealthMonitor(loadbalancerID string, pool *elbmodel.PoolResp, port v1.ServicePort, service *v1.Service) error {
-	healthCheckOpts := getHealthCheckOptionFromAnnotation(service, l.loadbalancerOpts)
+	healthCheckOpts := getHealthCheckOptionFromAnnotation(service, l.loadbalancerOpts())
 	monitorID := pool.HealthmonitorId
 	klog.Infof("add or remove health check: %s : %#v", monitorID, healthCheckOpts)
 
@@ -314,7 +329,7 @@ func (l *SharedLoadBalancer) addOrRemoveHealthMonitor(loadbalancerID string, poo
 		klog.Infof("Deleting health monitor %s for pool %s", monitorID, pool.Id)
 		err := l.sharedELBClient.DeleteHealthMonitor(monitorID)
 		if err != nil {
-			return fmt.Errorf("failed to delete health monitor %s for pool %s, error: %v", monitorID, pool.Id, err)
+			return fmt.Errorf("failed to delete health monitor %s for pool %s, error: %w", monitorID, pool.Id, err)
 		}
 	}
 
@@ -357,13 +372,12 @@ func (l *SharedLoadBalancer) createHealthMonitor(loadbalancerID, poolID, protoco
 		MaxRetries: opts.MaxRetries,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("error creating SharedLoadBalancer pool health monitor: %v", err)
+		return nil, fmt.Errorf("error creating SharedLoadBalancer pool health monitor: %w", err)
 	}
 
-	loadbalancer, err := l.sharedELBClient.WaitStatusActive(loadbalancerID)
+	_, err = l.sharedELBClient.WaitStatusActive(loadbalancerID)
 	if err != nil {
-		return nil, fmt.Errorf("timeout when waiting for loadbalancer to be ACTIVE after creating member, "+
-			"current provisioning status %s", loadbalancer.ProvisioningStatus)
+		return nil, fmt.Errorf("failed to wait for loadbalancer to be ACTIVE after creating member: %w", err)
 	}
 	return monitor, nil
 }
@@ -381,12 +395,7 @@ func (l *SharedLoadBalancer) addOrRemoveMembers(loadbalancer *elbmodel.Loadbalan
 		existsMember[fmt.Sprintf("%s:%d", m.Address, m.ProtocolPort)] = true
 	}
 
-	nodeNameMapping := make(map[string]*v1.Node)
-	for _, node := range nodes {
-		nodeNameMapping[node.Name] = node
-	}
-
-	podList, err := l.listPodsBySelector(context.TODO(), service.Namespace, service.Spec.Selector)
+	podList, err := l.listPodsBySelector(l.context(), service.Namespace, service.Spec.Selector)
 	if err != nil {
 		return err
 	}
@@ -401,10 +410,12 @@ func (l *SharedLoadBalancer) addOrRemoveMembers(loadbalancer *elbmodel.Loadbalan
 			continue
 		}
 
-		node, ok := nodeNameMapping[pod.Spec.NodeName]
+		// The nodes excluded from the load balancers are not passed in, the pods on them are not served.
+		node, ok := l.nodes.get(pod.Spec.NodeName, nodes)
 		if !ok {
-			return fmt.Errorf("could not find the node where the Pod resides, Pod: %s/%s",
-				pod.Namespace, pod.Spec.NodeName)
+			klog.Warningf("the node %s of pod %s/%s is not eligible for the load balancers, skipping adding to ELB",
+				pod.Spec.NodeName, pod.Namespace, pod.Name)
+			continue
 		}
 
 		address, err := getNodeAddress(node)
@@ -414,7 +425,7 @@ func (l *SharedLoadBalancer) addOrRemoveMembers(loadbalancer *elbmodel.Loadbalan
 				klog.Warningf("Failed to create SharedLoadBalancer pool member for node %s: %v", node.Name, err)
 				continue
 			} else {
-				return fmt.Errorf("error getting address for node %s: %v", node.Name, err)
+				return fmt.Errorf("error getting address for node %s: %w", node.Name, err)
 			}
 		}
 
@@ -435,6 +446,21 @@ func (l *SharedLoadBalancer) addOrRemoveMembers(loadbalancer *elbmodel.Loadbalan
 		existsMember[key] = true
 	}
 
+	// The members are re-read before removing the obsolete ones, which may be added by another controller.
+	if len(members) > 0 {
+		current, err := l.sharedELBClient.ListMembers(&elbmodel.ListMembersRequest{PoolId: pool.Id})
+		if err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(current))
+		for _, m := range current {
+			keys = append(keys, fmt.Sprintf("%s:%d", m.Address, m.ProtocolPort))
+		}
+		if err = checkMembersRevision(pool.Id, existsMember, keys); err != nil {
+			return err
+		}
+	}
+
 	// delete the remaining elements in members
 	for _, member := range members {
 		klog.Infof("[addOrRemoveMembers] remove node from pool, name: %s, address: %s, port: %d",
@@ -461,13 +487,12 @@ func (l *SharedLoadBalancer) addMember(loadbalancer *elbmodel.LoadbalancerResp,
 		Address:      address,
 	})
 	if err != nil {
-		return fmt.Errorf("error creating SharedLoadBalancer pool member for node: %s, %v", node.Name, err)
+		return fmt.Errorf("error creating SharedLoadBalancer pool member for node: %s, %w", node.Name, err)
 	}
 
 	loadbalancer, err = l.sharedELBClient.WaitStatusActive(loadbalancer.Id)
 	if err != nil {
-		return fmt.Errorf("timeout when waiting for loadbalancer to be ACTIVE after adding members, "+
-			"current status %s", loadbalancer.ProvisioningStatus)
+		return fmt.Errorf("failed to wait for loadbalancer to be ACTIVE after adding members: %w", err)
 	}
 
 	return nil
@@ -480,34 +505,25 @@ func (l *SharedLoadBalancer) deleteMember(elbID string, poolID string, member el
 		return fmt.Errorf("error deleting obsolete member %s for pool %s address %s: %v",
 			poolID, member.Id, member.Address, err)
 	}
-	loadbalancer, err := l.sharedELBClient.WaitStatusActive(elbID)
+	_, err = l.sharedELBClient.WaitStatusActive(elbID)
 	if err != nil {
-		return fmt.Errorf("timeout when waiting for loadbalancer to be ACTIVE after creating member, "+
-			"current provisioning status %s", loadbalancer.ProvisioningStatus)
+		return fmt.Errorf("failed to wait for loadbalancer to be ACTIVE after creating member: %w", err)
 	}
 	return nil
 }
 
-func (l *SharedLoadBalancer) getPool(elbID, listenerID string) (*elbmodel.PoolResp, error) {
-	pools, err := l.sharedELBClient.ListPools(&elbmodel.ListPoolsRequest{
-		LoadbalancerId: &elbID,
-	})
-	if err != nil {
-		return nil, err
+// getPool returns the default pool of the listener, the pool is queried by ID instead of listing the pools
+// of the load balancer, as the API of the shared load balancers does not filter the pools by listener.
+func (l *SharedLoadBalancer) getPool(elbID string, listener *elbmodel.ListenerResp) (*elbmodel.PoolResp, error) {
+	if listener.DefaultPoolId == "" {
+		return nil, status.Errorf(codes.NotFound, "not found pool matched ListenerId: %s, ELB ID: %s",
+			listener.Id, elbID)
 	}
-
-	for _, p := range pools {
-		for _, lis := range p.Listeners {
-			if lis.Id == listenerID {
-				return &p, nil
-			}
-		}
-	}
-	return nil, status.Errorf(codes.NotFound, "not found pool matched ListenerId: %s, ELB ID: %s", listenerID, elbID)
+	return l.sharedELBClient.GetPool(listener.DefaultPoolId)
 }
 
 func (l *SharedLoadBalancer) getSessionAffinity(service *v1.Service) *elbmodel.SessionPersistence {
-	globalOpts := l.loadbalancerOpts
+	globalOpts := l.loadbalancerOpts()
 	sessionMode := getStringFromSvsAnnotation(service, ElbSessionAffinityFlag, globalOpts.SessionAffinityFlag)
 	if sessionMode == "" || sessionMode == "off" {
 		return nil
@@ -544,8 +560,9 @@ func printSessionAffinity(service *v1.Service, per elbmodel.SessionPersistence)
 		"PersistenceTimeout: %d min }", service.Namespace, service.Name, per.Type.Value(), cookieName, timeout)
 }
 
-func (l *SharedLoadBalancer) createPool(listener *elbmodel.ListenerResp, service *v1.Service) (*elbmodel.PoolResp, error) {
-	lbAlgorithm := getStringFromSvsAnnotation(service, ElbAlgorithm, l.loadbalancerOpts.LBAlgorithm)
+func (l *SharedLoadBalancer) createPool(clusterName string, listener *elbmodel.ListenerResp, service *v1.Service) (
+	*elbmodel.PoolResp, error) {
+	lbAlgorithm := getStringFromSvsAnnotation(service, ElbAlgorithm, l.loadbalancerOpts().LBAlgorithm)
 	persistence := l.getSessionAffinity(service)
 
 	protocolStr := listener.Protocol.Value()
@@ -558,8 +575,10 @@ func (l *SharedLoadBalancer) createPool(listener *elbmodel.ListenerResp, service
 	}
 
 	name := utils.CutString(fmt.Sprintf("sg_%s", listener.Name), maxServerGroupNameLength)
+	desc := l.resourceDescription(clusterName, service)
 	return l.sharedELBClient.CreatePool(&elbmodel.CreatePoolReq{
 		Name:               &name,
+		Description:        &desc,
 		Protocol:           protocol,
 		LbAlgorithm:        lbAlgorithm,
 		ListenerId:         &listener.Id,
@@ -591,7 +610,7 @@ func popListener(arr []elbmodel.ListenerResp, id string) []elbmodel.ListenerResp
 func (l *SharedLoadBalancer) deleteListeners(elbID string, listeners []elbmodel.ListenerResp) error {
 	errs := make([]error, 0)
 	for _, lis := range listeners {
-		pool, err := l.getPool(elbID, lis.Id)
+		pool, err := l.getPool(elbID, &lis)
 		if err != nil && !common.IsNotFound(err) {
 			errs = append(errs, err)
 			continue
@@ -632,28 +651,30 @@ func (l *SharedLoadBalancer) deletePool(pool *elbmodel.PoolResp) []error {
 	return errs
 }
 
-func (l *SharedLoadBalancer) createListener(loadbalancerID string, service *v1.Service, port v1.ServicePort) (
-	*elbmodel.ListenerResp, error) {
+func (l *SharedLoadBalancer) createListener(clusterName, loadbalancerID string, service *v1.Service,
+	port v1.ServicePort) (*elbmodel.ListenerResp, error) {
 	xForwardFor := getBoolFromSvsAnnotation(service, ElbXForwardedHost, false)
 	createOpt := &elbmodelv3.CreateListenerOption{
 		LoadbalancerId: loadbalancerID,
 		ProtocolPort:   port.Port,
-		InsertHeaders:  &elbmodelv3.ListenerInsertHeaders{XForwardedHost: &xForwardFor},
 	}
 
 	protocol := parseProtocol(service, port)
+	if protocol != ProtocolUDP {
+		createOpt.InsertHeaders = &elbmodelv3.ListenerInsertHeaders{XForwardedHost: &xForwardFor}
+	}
 	if protocol == ProtocolTerminatedHTTPS {
 		defaultTLSContainerRef := getStringFromSvsAnnotation(service, DefaultTLSContainerRef, "")
 		createOpt.DefaultTlsContainerRef = &defaultTLSContainerRef
-	} else if xForwardFor {
-		protocol = ProtocolHTTP
 	}
 	createOpt.Protocol = protocol
-	name := utils.CutString(fmt.Sprintf("%s_%s_%v", service.Name, protocol, port.Port), defaultMaxNameLength)
+	name := l.listenerName(clusterName, service, protocol, port.Port)
+	desc := l.resourceDescription(clusterName, service)
 	createOpt.Name = &name
+	createOpt.Description = &desc
 
 	// Set timeout parameters
-	globalOpts := l.loadbalancerOpts
+	globalOpts := l.loadbalancerOpts()
 	if timeout := getIntFromSvsAnnotation(service, ElbIdleTimeout, globalOpts.IdleTimeout); timeout != 0 {
 		createOpt.KeepaliveTimeout = pointer.Int32(int32(timeout))
 	}
@@ -676,17 +697,20 @@ func (l *SharedLoadBalancer) createListener(loadbalancerID string, service *v1.S
 	return convertToListenerV2(listener)
 }
 
-func (l *SharedLoadBalancer) updateListener(listener *elbmodel.ListenerResp, service *v1.Service) error {
-	name := fmt.Sprintf("%s_%s_%v", service.Name, listener.Protocol.Value(), listener.ProtocolPort)
-	name = utils.CutString(name, defaultMaxNameLength)
+func (l *SharedLoadBalancer) updateListener(clusterName string, listener *elbmodel.ListenerResp, service *v1.Service) error {
+	name := l.listenerName(clusterName, service, listener.Protocol.Value(), listener.ProtocolPort)
+	desc := l.resourceDescription(clusterName, service)
 	xForwardFor := getBoolFromSvsAnnotation(service, ElbXForwardedHost, false)
 	updateOpt := &elbmodelv3.UpdateListenerOption{
-		Name:          &name,
-		InsertHeaders: &elbmodelv3.ListenerInsertHeaders{XForwardedHost: &xForwardFor},
+		Name:        &name,
+		Description: &desc,
+	}
+	if listener.Protocol.Value() != ProtocolUDP {
+		updateOpt.InsertHeaders = &elbmodelv3.ListenerInsertHeaders{XForwardedHost: &xForwardFor}
 	}
 
 	// Set timeout parameters
-	globalOpts := l.loadbalancerOpts
+	globalOpts := l.loadbalancerOpts()
 	if timeout := getIntFromSvsAnnotation(service, ElbIdleTimeout, globalOpts.IdleTimeout); timeout != 0 {
 		updateOpt.KeepaliveTimeout = pointer.Int32(int32(timeout))
 	}
@@ -699,7 +723,15 @@ func (l *SharedLoadBalancer) updateListener(listener *elbmodel.ListenerResp, ser
 		}
 	}
 
-	err := l.dedicatedELBClient.UpdateListener(listener.Id, updateOpt)
+	current, err := l.sharedELBClient.GetListener(listener.Id)
+	if err != nil {
+		return err
+	}
+	if err = checkListenerRevision(listener.Id, listener.UpdatedAt, current.UpdatedAt); err != nil {
+		return err
+	}
+
+	err = l.dedicatedELBClient.UpdateListener(listener.Id, updateOpt)
 	if err != nil {
 		return err
 	}
@@ -733,6 +765,14 @@ func convertToListenerV2(listener *elbmodelv3.Listener) (*elbmodel.ListenerResp,
 		}
 		tags = append(tags, fmt.Sprintf("%s=%s", *t.Key, val))
 	}
+	// the UDP listeners have no insert headers.
+	var insertHeaders *elbmodel.InsertHeader
+	if listener.InsertHeaders != nil {
+		insertHeaders = &elbmodel.InsertHeader{
+			XForwardedHost:  listener.InsertHeaders.XForwardedHost,
+			XForwardedELBIP: listener.InsertHeaders.XForwardedELBIP,
+		}
+	}
 	return &elbmodel.ListenerResp{
 		Id:                      listener.Id,
 		TenantId:                "",
@@ -751,12 +791,9 @@ func convertToListenerV2(listener *elbmodelv3.Listener) (*elbmodel.ListenerResp,
 		Tags:                    tags,
 		CreatedAt:               listener.CreatedAt,
 		UpdatedAt:               listener.UpdatedAt,
-		InsertHeaders: &elbmodel.InsertHeader{
-			XForwardedHost:  listener.InsertHeaders.XForwardedHost,
-			XForwardedELBIP: listener.InsertHeaders.XForwardedELBIP,
-		},
-		ProjectId:        listener.ProjectId,
-		TlsCiphersPolicy: listener.TlsCiphersPolicy,
+		InsertHeaders:           insertHeaders,
+		ProjectId:               listener.ProjectId,
+		TlsCiphersPolicy:        listener.TlsCiphersPolicy,
 	}, nil
 }
 
@@ -792,11 +829,14 @@ func (l *SharedLoadBalancer) UpdateLoadBalancer(ctx context.Context, clusterName
 			return status.Errorf(codes.Unavailable, "error, can not find a listener matching %s:%v",
 				port.Protocol, port.Port)
 		}
+		if err = l.checkListenerOwner(clusterName, service, listener.Id, port.Port, listener.Description); err != nil {
+			return err
+		}
 
 		// query pool or create pool
-		pool, err := l.getPool(loadbalancer.Id, listener.Id)
+		pool, err := l.getPool(loadbalancer.Id, listener)
 		if err != nil && common.IsNotFound(err) {
-			pool, err = l.createPool(listener, service)
+			pool, err = l.createPool(clusterName, listener, service)
 		}
 		if err != nil {
 			return err
@@ -812,12 +852,16 @@ func (l *SharedLoadBalancer) UpdateLoadBalancer(ctx context.Context, clusterName
 			return err
 		}
 	}
-	return nil
+	return l.ensureSecurityGroupRules(service, "shared", nodes, l.sharedSecurityGroupSources(service))
 }
 
 // EnsureLoadBalancerDeleted deletes the specified load balancer
 func (l *SharedLoadBalancer) EnsureLoadBalancerDeleted(ctx context.Context, clusterName string, service *v1.Service) error {
 	klog.Infof("EnsureLoadBalancerDeleted: called with service %s/%s", service.Namespace, service.Name)
+	// the rules are removed first, the service is not reconciled again once the load balancer is deleted.
+	if err := l.deleteSecurityGroupRules(service, "shared"); err != nil {
+		return err
+	}
 
 	loadBalancer, err := l.getLoadBalancerInstance(ctx, clusterName, service)
 	if err != nil {
@@ -832,7 +876,7 @@ func (l *SharedLoadBalancer) EnsureLoadBalancerDeleted(ctx context.Context, clus
 
 	specifiedID := getStringFromSvsAnnotation(service, ElbID, "")
 	if specifiedID != "" {
-		err = l.deleteListener(loadBalancer, service)
+		err = l.deleteListener(clusterName, loadBalancer, service)
 	} else {
 		err = l.deleteELBInstance(loadBalancer, service)
 	}
@@ -843,7 +887,8 @@ func (l *SharedLoadBalancer) EnsureLoadBalancerDeleted(ctx context.Context, clus
 	return nil
 }
 
-func (l *SharedLoadBalancer) deleteListener(loadBalancer *elbmodel.LoadbalancerResp, service *v1.Service) error {
+func (l *SharedLoadBalancer) deleteListener(clusterName string, loadBalancer *elbmodel.LoadbalancerResp,
+	service *v1.Service) error {
 	// query ELB listeners list
 	listenerArr, err := l.sharedELBClient.ListListeners(&elbmodel.ListListenersRequest{
 		LoadbalancerId: &loadBalancer.Id,
@@ -855,9 +900,15 @@ func (l *SharedLoadBalancer) deleteListener(loadBalancer *elbmodel.LoadbalancerR
 	listenersMatched := make([]elbmodel.ListenerResp, 0)
 	for _, port := range service.Spec.Ports {
 		listener := l.filterListenerByPort(listenerArr, service, port)
-		if listener != nil {
-			listenersMatched = append(listenersMatched, *listener)
+		if listener == nil {
+			continue
 		}
+		if l.ownedByOthers(clusterName, service, listener.Description) {
+			klog.Warningf("the listener %s of port %d is not deleted, it is created by another service: %s", listener.Id, port.Port,
+				listener.Description)
+			continue
+		}
+		listenersMatched = append(listenersMatched, *listener)
 	}
 
 	if err = l.deleteListeners(loadBalancer.Id, listenersMatched); err != nil {
@@ -880,7 +931,7 @@ func (l *SharedLoadBalancer) deleteELBInstance(loadBalancer *elbmodel.Loadbalanc
 	}
 
 	eipID := getStringFromSvsAnnotation(service, ElbEipID, "")
-	keepEip := getBoolFromSvsAnnotation(service, ELBKeepEip, l.loadbalancerOpts.KeepEIP)
+	keepEip := getBoolFromSvsAnnotation(service, ELBKeepEip, l.loadbalancerOpts().KeepEIP)
 	if err = unbindEIP(l.eipClient, loadBalancer.VipPortId, eipID, keepEip); err != nil {
 		return err
 	}
@@ -917,47 +968,6 @@ func unbindEIP(eipClient *wrapper.EIpClient, vipPortID, eipID string, keepEIP bo
 	return nil
 }
 
-func (l *SharedLoadBalancer) getSubnetID(service *v1.Service, node *v1.Node) (string, error) {
-	subnetID := getStringFromSvsAnnotation(service, ElbSubnetID, l.cloudConfig.VpcOpts.SubnetID)
-	if subnetID != "" {
-		return subnetID, nil
-	}
-
-	subnetID, err := l.getNodeSubnetID(node)
-	if err != nil {
-		return "", status.Errorf(codes.InvalidArgument, "missing subnet-id, "+
-			"can not to read subnet-id from the node also, error: %s", err)
-	}
-	return subnetID, nil
-}
-
-func (l *SharedLoadBalancer) getNodeSubnetID(node *corev1.Node) (string, error) {
-	ipAddress, err := getNodeAddress(node)
-	if err != nil {
-		return "", err
-	}
-
-	instance, err := l.ecsClient.GetByName(node.Name)
-	if err != nil {
-		return "", err
-	}
-
-	interfaces, err := l.ecsClient.ListInterfaces(&ecsmodel.ListServerInterfacesRequest{ServerId: instance.Id})
-	if err != nil {
-		return "", err
-	}
-
-	for _, ia := range interfaces {
-		for _, fixedIP := range *ia.FixedIps {
-			if *fixedIP.IpAddress == ipAddress {
-				return *fixedIP.SubnetId, nil
-			}
-		}
-	}
-
-	return "", fmt.Errorf("failed to get node subnet ID")
-}
-
 func getNodeAddress(node *corev1.Node) (string, error) {
 	addresses := node.Status.Addresses
 	if len(addresses) == 0 {
@@ -1011,6 +1021,12 @@ func (l *SharedLoadBalancer) createEIP(service *v1.Service) (string, error) {
 		return "", err
 	}
 
+	// The EIP created by a request whose response is lost is unbound, it is adopted by the alias.
+	alias := eipAlias(service)
+	if id, err := l.findUnboundEIP(alias); err != nil || id != "" {
+		return id, err
+	}
+
 	name := fmt.Sprintf("%s_%s", service.Namespace, service.Name)
 	eip, err := l.eipClient.Create(&eipmodel.CreatePublicipRequestBody{
 		Bandwidth: &eipmodel.CreatePublicipBandwidthOption{
@@ -1020,7 +1036,7 @@ func (l *SharedLoadBalancer) createEIP(service *v1.Service) (string, error) {
 			ShareType:  shareType,
 			ChargeMode: chargeModel,
 		},
-		Publicip: &eipmodel.CreatePublicipOption{Type: opts.IPType},
+		Publicip: &eipmodel.CreatePublicipOption{Type: opts.IPType, Alias: &alias},
 	})
 	if err != nil {
 		return "", err
@@ -1029,6 +1045,26 @@ func (l *SharedLoadBalancer) createEIP(service *v1.Service) (string, error) {
 	return *eip.Id, nil
 }
 
+// eipAlias returns the alias marking the EIP created for the service.
+func eipAlias(service *v1.Service) string {
+	return fmt.Sprintf("k8s_%s", service.UID)
+}
+
+// findUnboundEIP returns the ID of the unbound EIP with the alias, an empty string is returned if not found.
+func (l *SharedLoadBalancer) findUnboundEIP(alias string) (string, error) {
+	ips, err := l.eipClient.List(&eipmodel.ListPublicipsRequest{})
+	if err != nil {
+		return "", status.Errorf(codes.Unavailable, "error querying EIPs by alias %s: %s", alias, err)
+	}
+	for _, ip := range ips {
+		if pointer.StringDeref(ip.Alias, "") == alias && pointer.StringDeref(ip.PortId, "") == "" {
+			klog.Warningf("adopt the unbound EIP %s created for the service previously", pointer.StringDeref(ip.Id, ""))
+			return pointer.StringDeref(ip.Id, ""), nil
+		}
+	}
+	return "", nil
+}
+
 type CreateEIPOptions struct {
 	BandwidthSize int32  `json:"bandwidth_size"`
 	ShareType     string `json:"share_type"`
@@ -1052,10 +1088,15 @@ func parseEIPAutoCreateOptions(service *v1.Service) (*CreateEIPOptions, error) {
 	return opts, err
 }
 
+// parseProtocol returns the protocol of the listener of the port, the TCP ports are terminated as HTTPS or HTTP
+// by the annotations, the UDP ports of a mixed-protocol service are kept as is.
 func parseProtocol(service *v1.Service, port v1.ServicePort) string {
 	xForwardFor := getBoolFromSvsAnnotation(service, ElbXForwardedHost, false)
 
 	protocol := string(port.Protocol)
+	if port.Protocol != v1.ProtocolTCP {
+		return protocol
+	}
 	defaultTLSContainerRef := getStringFromSvsAnnotation(service, DefaultTLSContainerRef, "")
 	if defaultTLSContainerRef != "" {
 		protocol = ProtocolTerminatedHTTPS
@@ -0,0 +1,195 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// ElbInterruptedOperation marks the Service whose reconcile is canceled by the shutdown of the cloud provider,
+// the value is the operation and the time it is interrupted. It is removed once the load balancer is ensured.
+const ElbInterruptedOperation = "kubernetes.io/elb.interrupted-operation"
+
+// markerTimeout is the timeout to update the marker of the Service, the context of the reconcile is done then.
+const markerTimeout = 10 * time.Second
+
+// runningReconcile is a reconcile tracked by the reconcileTracker.
+type runningReconcile struct {
+	cancel context.CancelFunc
+	// interrupted is set when the reconcile is canceled by the shutdown.
+	interrupted bool
+}
+
+// reconcileTracker tracks the in-flight reconciles changing the load balancers, so that they are waited for
+// and then canceled on stop, instead of being cut off in the middle of the requests.
+type reconcileTracker struct {
+	lock     sync.Mutex
+	stopping bool
+	running  map[string]*runningReconcile
+	wg       sync.WaitGroup
+}
+
+func newReconcileTracker() *reconcileTracker {
+	return &reconcileTracker{running: make(map[string]*runningReconcile)}
+}
+
+// start tracks the reconcile of the context, and returns the context canceled on the shutdown.
+// An Unavailable error is returned once the shutdown is started.
+func (t *reconcileTracker) start(ctx context.Context) (context.Context, error) {
+	info, _ := ctx.Value(reconcileInfoKey{}).(reconcileInfo)
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.stopping {
+		return ctx, status.Errorf(codes.Unavailable, "the cloud provider is shutting down, %s is retried by "+
+			"the next leader", info.operation)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	t.running[info.reconcileID] = &runningReconcile{cancel: cancel}
+	t.wg.Add(1)
+	return ctx, nil
+}
+
+// finish stops tracking the reconcile of the context, and returns whether it is canceled by the shutdown.
+func (t *reconcileTracker) finish(ctx context.Context) bool {
+	info, _ := ctx.Value(reconcileInfoKey{}).(reconcileInfo)
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	r, ok := t.running[info.reconcileID]
+	if !ok {
+		return false
+	}
+	delete(t.running, info.reconcileID)
+	r.cancel()
+	t.wg.Done()
+	return r.interrupted
+}
+
+// shutdown rejects the new reconciles and waits for the in-flight ones for the grace period,
+// the remaining ones are canceled then, and waited for the grace period again to record their markers.
+func (t *reconcileTracker) shutdown(grace time.Duration) {
+	t.lock.Lock()
+	t.stopping = true
+	count := len(t.running)
+	t.lock.Unlock()
+	if count == 0 {
+		return
+	}
+
+	klog.Infof("waiting %s for %d in-flight reconciles of the load balancers to finish", grace, count)
+	if t.wait(grace) {
+		return
+	}
+	t.lock.Lock()
+	klog.Warningf("cancel %d reconciles of the load balancers still running after %s", len(t.running), grace)
+	for _, r := range t.running {
+		r.interrupted = true
+		r.cancel()
+	}
+	t.lock.Unlock()
+	if !t.wait(grace) {
+		klog.Warningf("the canceled reconciles of the load balancers are not finished in %s", grace)
+	}
+}
+
+// wait returns whether all the tracked reconciles are finished in the timeout.
+func (t *reconcileTracker) wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// runShutdown shuts down the reconciles once the stop channel is closed.
+func (h *CloudProvider) runShutdown(stop <-chan struct{}) {
+	go func() {
+		<-stop
+		h.Shutdown()
+	}()
+}
+
+// Shutdown rejects the new reconciles of the load balancers and returns once the in-flight ones are finished,
+// or canceled after the grace period. It is safe to be called more than once, the later calls wait for the first.
+// The binary calls it before exiting, as the stop channel passed to Initialize is not closed on the termination
+// when the leader election is enabled.
+func (h *CloudProvider) Shutdown() {
+	h.shutdownOnce.Do(func() {
+		h.reconciles.shutdown(h.cloudConfig.ConcurrencyOpts.GetShutdownGracePeriod())
+	})
+}
+
+// markInterrupted records a warning event and the ElbInterruptedOperation annotation on the Service
+// whose reconcile is canceled by the shutdown, the failures are logged only.
+func (h *CloudProvider) markInterrupted(operation string, service *v1.Service) {
+	msg := fmt.Sprintf("%s is interrupted by the shutdown of the cloud provider, it is resumed by the next "+
+		"reconcile of the service", operation)
+	h.eventRecorder.Event(service, v1.EventTypeWarning, "ReconcileInterrupted", msg)
+	h.patchInterruptedOperation(service, fmt.Sprintf("%s at %s", operation, time.Now().UTC().Format(time.RFC3339)))
+}
+
+// clearInterrupted removes the ElbInterruptedOperation annotation of the Service once its load balancer is ensured.
+func (h *CloudProvider) clearInterrupted(service *v1.Service) {
+	if _, ok := service.Annotations[ElbInterruptedOperation]; !ok {
+		return
+	}
+	h.patchInterruptedOperation(service, nil)
+}
+
+// patchInterruptedOperation sets the ElbInterruptedOperation annotation to the value, or removes it if nil.
+func (h *CloudProvider) patchInterruptedOperation(service *v1.Service, value interface{}) {
+	if err := h.patchAnnotation(service, ElbInterruptedOperation, value); err != nil {
+		klog.Warningf("failed to update annotation %s of service %s/%s: %s", ElbInterruptedOperation,
+			service.Namespace, service.Name, err)
+	}
+}
+
+// patchAnnotation sets the annotation of the Service to the value, or removes it if nil.
+func (h *CloudProvider) patchAnnotation(service *v1.Service, key string, value interface{}) error {
+	if h.kubeClient == nil {
+		return nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{key: value},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build the patch of annotation %s: %w", key, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), markerTimeout)
+	defer cancel()
+	_, err = h.kubeClient.Services(service.Namespace).Patch(ctx, service.Name, types.MergePatchType, patch,
+		metav1.PatchOptions{})
+	return err
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/metrics"
+)
+
+const (
+	defaultSubnetCacheTTL = 10 * time.Minute
+	// subnetCacheName is the name of the subnet cache in the metrics.
+	subnetCacheName = "subnets"
+)
+
+type subnetEntry struct {
+	subnetID  string
+	expiresAt time.Time
+}
+
+// subnetCache holds the subnets of the node addresses resolved from the ECS interfaces. The entries expire after
+// the TTL, and the entries of a subnet are invalidated once it is not found, so that the changes of the VPC
+// are picked up without restarting.
+type subnetCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	lock    sync.Mutex
+	entries map[string]subnetEntry
+}
+
+func newSubnetCache(ttl time.Duration) *subnetCache {
+	return &subnetCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]subnetEntry),
+	}
+}
+
+// get returns the subnet ID of the node address, it is resolved by resolve if it is not cached or expired.
+func (c *subnetCache) get(address string, resolve func() (string, error)) (string, error) {
+	c.lock.Lock()
+	entry, ok := c.entries[address]
+	c.lock.Unlock()
+	if ok && c.now().Before(entry.expiresAt) {
+		metrics.ObserveCacheLookup(subnetCacheName, metrics.CacheHit)
+		return entry.subnetID, nil
+	}
+	metrics.ObserveCacheLookup(subnetCacheName, metrics.CacheMiss)
+
+	subnetID, err := resolve()
+	if err != nil {
+		return "", err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := c.now()
+	evicted := 0
+	for key, e := range c.entries {
+		if !now.Before(e.expiresAt) {
+			delete(c.entries, key)
+			evicted++
+		}
+	}
+	c.entries[address] = subnetEntry{subnetID: subnetID, expiresAt: now.Add(c.ttl)}
+	metrics.ObserveCacheRefresh(subnetCacheName, len(c.entries), evicted)
+	return subnetID, nil
+}
+
+// invalidate removes the entries of the subnet, it is called when the subnet is not found.
+func (c *subnetCache) invalidate(subnetID string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	evicted := 0
+	for key, e := range c.entries {
+		if e.subnetID == subnetID {
+			delete(c.entries, key)
+			evicted++
+		}
+	}
+	if evicted > 0 {
+		klog.Infof("subnet %s is not found, %d cached node addresses are invalidated", subnetID, evicted)
+		metrics.ObserveCacheRefresh(subnetCacheName, len(c.entries), evicted)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"fmt"
+
+	vpcmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/vpc/v2/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// listSubnets lists the subnets of the VPC, or all the subnets of the project if the VPC is empty.
+// The list is memoized in the reconcile.
+func (b Basic) listSubnets(vpcID string) ([]vpcmodel.Subnet, error) {
+	return memoize(b.context(), "subnets/"+vpcID, func() ([]vpcmodel.Subnet, error) {
+		req := &vpcmodel.ListSubnetsRequest{}
+		if vpcID != "" {
+			req.VpcId = &vpcID
+		}
+		return b.vpcClient.ListSubnets(req)
+	})
+}
+
+// findSubnet returns the subnet of the ID in the subnets, the ID is the ID of the subnet
+// or the ID of its IPv4 Neutron subnet or network, which the ELB and the port APIs use.
+func findSubnet(subnets []vpcmodel.Subnet, id string) *vpcmodel.Subnet {
+	for i, subnet := range subnets {
+		if subnet.Id == id || subnet.NeutronSubnetId == id || subnet.NeutronNetworkId == id {
+			return &subnets[i]
+		}
+	}
+	return nil
+}
+
+// checkSubnet verifies the subnet exists in the VPC of the cloud config before the load balancer or the rules are
+// created in it, so that a wrong subnet is reported with where it comes from instead of the 400 response of
+// the ELB API. The failure is recorded as an InvalidSubnet warning event of the service.
+// The check is skipped if the subnets can not be listed, such as without the permission of the VPC API.
+func (b Basic) checkSubnet(service *v1.Service, subnetID, source string) error {
+	vpcID := b.cloudConfig.VpcOpts.ID
+	subnets, err := b.listSubnets(vpcID)
+	if err != nil {
+		klog.Warningf("skip checking subnet %s of %s, failed to list the subnets: %s", subnetID, source, err)
+		return nil
+	}
+	if findSubnet(subnets, subnetID) != nil {
+		return nil
+	}
+
+	msg := fmt.Sprintf("subnet %s of %s is not found", subnetID, source)
+	if vpcID != "" {
+		msg = fmt.Sprintf("subnet %s of %s is not found in VPC %s", subnetID, source, vpcID)
+		if all, err := b.listSubnets(""); err == nil {
+			if subnet := findSubnet(all, subnetID); subnet != nil {
+				msg = fmt.Sprintf("subnet %s of %s belongs to VPC %s instead of VPC %s of the cloud config",
+					subnetID, source, subnet.VpcId, vpcID)
+			}
+		}
+	}
+	b.eventRecorder.Event(service, v1.EventTypeWarning, "InvalidSubnet", msg)
+	return status.Errorf(codes.InvalidArgument, "Invalid argument, %s", msg)
+}
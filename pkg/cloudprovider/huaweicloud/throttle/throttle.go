@@ -14,8 +14,10 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
+// Package throttle implements the rate limiters of the cloud APIs, configured by the environment variables
+// and the file of THROTTLE_CONFIG_FILE.
 // nolint:golint // stop check lint issues as this file will be refactored
-package huaweicloud
+package throttle
 
 import (
 	"encoding/json"
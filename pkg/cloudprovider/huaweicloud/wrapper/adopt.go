@@ -0,0 +1,52 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wrapper
+
+import (
+	"net/http"
+
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/common"
+)
+
+// mayBeCreated returns whether the resource may have been created although the create request failed, i.e. the
+// response is lost by a timeout, an unreachable endpoint or a failed gateway, or the retried request conflicts
+// with the resource created by the first one.
+func mayBeCreated(err error) bool {
+	code := common.GetStatusCode(err)
+	return common.IsUnreachable(err) || code >= http.StatusInternalServerError || code == http.StatusConflict
+}
+
+// createOrAdopt sends the create request, and if it fails with an error the resource may have been created by,
+// adopts the resource found by the lookup instead, so that the retries neither duplicate the resource nor fail
+// for it. The error of the create is returned if the lookup finds nothing.
+func createOrAdopt[T any](kind string, create func() (*T, error), lookup func() (*T, error)) (*T, error) {
+	rst, err := create()
+	if err == nil || !mayBeCreated(err) {
+		return rst, err
+	}
+	existing, e := lookup()
+	if e != nil || existing == nil {
+		if e != nil {
+			klog.Warningf("failed to look up the %s created by the failed request: %s", kind, e)
+		}
+		return nil, err
+	}
+	klog.Warningf("the request creating the %s failed, adopt the %s created by it: %s", kind, kind, err)
+	return existing, nil
+}
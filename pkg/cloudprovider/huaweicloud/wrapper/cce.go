@@ -0,0 +1,47 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wrapper
+
+import (
+	cce "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/cce/v3"
+	"github.com/huaweicloud/huaweicloud-sdk-go-v3/services/cce/v3/model"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+)
+
+type CceClient struct {
+	AuthOpts *config.AuthOptions
+}
+
+/** Clusters **/
+
+func (c *CceClient) ListClusters() ([]model.Cluster, error) {
+	var rst []model.Cluster
+	err := c.wrapper(func(cli *cce.CceClient) (interface{}, error) {
+		return cli.ListClusters(&model.ListClustersRequest{})
+	}, "Items", &rst)
+	return rst, err
+}
+
+func (c *CceClient) wrapper(handler func(*cce.CceClient) (interface{}, error), args ...interface{}) error {
+	return commonWrapper(withEndpointFailover(c.AuthOpts, "cce", func(endpoint string) (interface{}, error) {
+		return withCredentialRefresh(c.AuthOpts, func() (interface{}, error) {
+			hc := c.AuthOpts.GetHcClientWithEndpoint("cce", endpoint)
+			return handler(cce.NewCceClient(hc))
+		})()
+	}), OKCodes, args...)
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wrapper
+
+import (
+	"fmt"
+
+	csms "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/csms/v1"
+	"github.com/huaweicloud/huaweicloud-sdk-go-v3/services/csms/v1/model"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+)
+
+type CsmsClient struct {
+	AuthOpts *config.AuthOptions
+}
+
+/** Secrets **/
+
+// GetSecretString returns the secret string of the secret version, such as "latest".
+func (c *CsmsClient) GetSecretString(secretName, versionID string) (string, error) {
+	var rst *model.Version
+	err := c.wrapper(func(cli *csms.CsmsClient) (interface{}, error) {
+		return cli.ShowSecretVersion(&model.ShowSecretVersionRequest{
+			SecretName: secretName,
+			VersionId:  versionID,
+		})
+	}, "Version", &rst)
+	if err != nil {
+		return "", err
+	}
+	if rst == nil || rst.SecretString == nil {
+		return "", fmt.Errorf("the version %s of CSMS secret %s has no secret string", versionID, secretName)
+	}
+	return *rst.SecretString, nil
+}
+
+func (c *CsmsClient) wrapper(handler func(*csms.CsmsClient) (interface{}, error), args ...interface{}) error {
+	return commonWrapper(withEndpointFailover(c.AuthOpts, "kms", func(endpoint string) (interface{}, error) {
+		return withCredentialRefresh(c.AuthOpts, func() (interface{}, error) {
+			hc := c.AuthOpts.GetHcClientWithEndpoint("kms", endpoint)
+			return handler(csms.NewCsmsClient(hc))
+		})()
+	}), OKCodes, args...)
+}
@@ -18,6 +18,7 @@ package wrapper
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/huaweicloud/huaweicloud-sdk-go-v3/core/sdkerr"
 	elb "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/elb/v3"
@@ -65,7 +66,7 @@ func (s *DedicatedLoadBalanceClient) CreateInstanceCompleted(req *model.CreateLo
 func (s *DedicatedLoadBalanceClient) WaitStatusActive(id string) (*model.LoadBalancer, error) {
 	var instance *model.LoadBalancer
 
-	err := common.WaitForCompleted(func() (bool, error) {
+	err := common.WaitForCompletedWithContext(s.AuthOpts.Context(), func() (bool, error) {
 		ins, err := s.GetInstance(id)
 		if err != nil {
 			return false, err
@@ -98,11 +99,26 @@ func (s *DedicatedLoadBalanceClient) GetInstance(id string) (*model.LoadBalancer
 }
 
 func (s *DedicatedLoadBalanceClient) ListInstances(req *model.ListLoadBalancersRequest) ([]model.LoadBalancer, error) {
-	var rst []model.LoadBalancer
-	err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
-		return c.ListLoadBalancers(req)
-	}, "Loadbalancers", &rst)
-	return rst, err
+	return listPages(req.Marker, req.Limit, func(marker *string, limit *int32) ([]model.LoadBalancer, string, error) {
+		page := *req
+		page.Marker, page.Limit = marker, limit
+		var rsp *model.ListLoadBalancersResponse
+		err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
+			return c.ListLoadBalancers(&page)
+		}, &rsp)
+		if err != nil || rsp.Loadbalancers == nil {
+			return nil, "", err
+		}
+		return *rsp.Loadbalancers, nextMarker(rsp.PageInfo), nil
+	})
+}
+
+// nextMarker returns the marker of the next page in the page info, it is empty on the last page.
+func nextMarker(pageInfo *model.PageInfo) string {
+	if pageInfo == nil || pageInfo.NextMarker == nil {
+		return ""
+	}
+	return *pageInfo.NextMarker
 }
 
 func (s *DedicatedLoadBalanceClient) UpdateInstance(id, name, description string) (*model.LoadBalancer, error) {
@@ -133,17 +149,29 @@ func (s *DedicatedLoadBalanceClient) DeleteInstance(id string) error {
 
 /** Listeners **/
 
+// CreateListener creates the listener, the listener of the port created by a failed request is adopted.
 func (s *DedicatedLoadBalanceClient) CreateListener(req *model.CreateListenerOption) (*model.Listener, error) {
-	var rst *model.Listener
-	err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
-		return c.CreateListener(&model.CreateListenerRequest{
-			Body: &model.CreateListenerRequestBody{
-				Listener: req,
-			},
+	return createOrAdopt("listener", func() (*model.Listener, error) {
+		var rst *model.Listener
+		err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
+			return c.CreateListener(&model.CreateListenerRequest{
+				Body: &model.CreateListenerRequestBody{
+					Listener: req,
+				},
+			})
+		}, "Listener", &rst)
+		return rst, err
+	}, func() (*model.Listener, error) {
+		listeners, err := s.ListListeners(&model.ListListenersRequest{
+			LoadbalancerId: &[]string{req.LoadbalancerId},
+			ProtocolPort:   &[]string{strconv.Itoa(int(req.ProtocolPort))},
+			Protocol:       &[]string{req.Protocol},
 		})
-	}, "Listener", &rst)
-
-	return rst, err
+		if err != nil || len(listeners) == 0 {
+			return nil, err
+		}
+		return &listeners[0], nil
+	})
 }
 
 func (s *DedicatedLoadBalanceClient) GetListener(id string) (*model.Listener, error) {
@@ -156,12 +184,18 @@ func (s *DedicatedLoadBalanceClient) GetListener(id string) (*model.Listener, er
 }
 
 func (s *DedicatedLoadBalanceClient) ListListeners(req *model.ListListenersRequest) ([]model.Listener, error) {
-	var rst []model.Listener
-	err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
-		return c.ListListeners(req)
-	}, "Listeners", &rst)
-
-	return rst, err
+	return listPages(req.Marker, req.Limit, func(marker *string, limit *int32) ([]model.Listener, string, error) {
+		page := *req
+		page.Marker, page.Limit = marker, limit
+		var rsp *model.ListListenersResponse
+		err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
+			return c.ListListeners(&page)
+		}, &rsp)
+		if err != nil || rsp.Listeners == nil {
+			return nil, "", err
+		}
+		return *rsp.Listeners, nextMarker(rsp.PageInfo), nil
+	})
 }
 
 func (s *DedicatedLoadBalanceClient) UpdateListener(id string, opt *model.UpdateListenerOption) error {
@@ -205,17 +239,28 @@ func (s *DedicatedLoadBalanceClient) DeleteListener(elbID string, listenerID str
 
 /** Pools **/
 
+// CreatePool creates the pool, the pool of the listener created by a failed request is adopted.
 func (s *DedicatedLoadBalanceClient) CreatePool(req *model.CreatePoolOption) (*model.Pool, error) {
-	var rst *model.Pool
-	err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
-		return c.CreatePool(&model.CreatePoolRequest{
-			Body: &model.CreatePoolRequestBody{
-				Pool: req,
-			},
-		})
-	}, "Pool", &rst)
-
-	return rst, err
+	return createOrAdopt("pool", func() (*model.Pool, error) {
+		var rst *model.Pool
+		err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
+			return c.CreatePool(&model.CreatePoolRequest{
+				Body: &model.CreatePoolRequestBody{
+					Pool: req,
+				},
+			})
+		}, "Pool", &rst)
+		return rst, err
+	}, func() (*model.Pool, error) {
+		if req.ListenerId == nil {
+			return nil, nil
+		}
+		pools, err := s.ListPools(&model.ListPoolsRequest{ListenerId: &[]string{*req.ListenerId}})
+		if err != nil || len(pools) == 0 {
+			return nil, err
+		}
+		return &pools[0], nil
+	})
 }
 
 func (s *DedicatedLoadBalanceClient) GetPool(id string) (*model.Pool, error) {
@@ -230,12 +275,18 @@ func (s *DedicatedLoadBalanceClient) GetPool(id string) (*model.Pool, error) {
 }
 
 func (s *DedicatedLoadBalanceClient) ListPools(req *model.ListPoolsRequest) ([]model.Pool, error) {
-	var rst []model.Pool
-	err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
-		return c.ListPools(req)
-	}, "Pools", &rst)
-
-	return rst, err
+	return listPages(req.Marker, req.Limit, func(marker *string, limit *int32) ([]model.Pool, string, error) {
+		page := *req
+		page.Marker, page.Limit = marker, limit
+		var rsp *model.ListPoolsResponse
+		err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
+			return c.ListPools(&page)
+		}, &rsp)
+		if err != nil || rsp.Pools == nil {
+			return nil, "", err
+		}
+		return *rsp.Pools, nextMarker(rsp.PageInfo), nil
+	})
 }
 
 func (s *DedicatedLoadBalanceClient) UpdatePool(id string, req *model.UpdatePoolOption) (*model.Pool, error) {
@@ -261,17 +312,25 @@ func (s *DedicatedLoadBalanceClient) DeletePool(id string) error {
 
 /** Health Monitor **/
 
+// CreateHealthMonitor creates the health monitor, the monitor of the pool created by a failed request is adopted.
 func (s *DedicatedLoadBalanceClient) CreateHealthMonitor(req *model.CreateHealthMonitorOption) (*model.HealthMonitor, error) {
-	var rst *model.HealthMonitor
-	err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
-		return c.CreateHealthMonitor(&model.CreateHealthMonitorRequest{
-			Body: &model.CreateHealthMonitorRequestBody{
-				Healthmonitor: req,
-			},
-		})
-	}, "Healthmonitor", &rst)
-
-	return rst, err
+	return createOrAdopt("health monitor", func() (*model.HealthMonitor, error) {
+		var rst *model.HealthMonitor
+		err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
+			return c.CreateHealthMonitor(&model.CreateHealthMonitorRequest{
+				Body: &model.CreateHealthMonitorRequestBody{
+					Healthmonitor: req,
+				},
+			})
+		}, "Healthmonitor", &rst)
+		return rst, err
+	}, func() (*model.HealthMonitor, error) {
+		pool, err := s.GetPool(req.PoolId)
+		if err != nil || pool.HealthmonitorId == "" {
+			return nil, err
+		}
+		return s.GetHealthMonitor(pool.HealthmonitorId)
+	})
 }
 
 func (s *DedicatedLoadBalanceClient) GetHealthMonitor(id string) (*model.HealthMonitor, error) {
@@ -337,12 +396,18 @@ func (s *DedicatedLoadBalanceClient) GetMember(id string) (*model.Member, error)
 }
 
 func (s *DedicatedLoadBalanceClient) ListMembers(req *model.ListMembersRequest) ([]model.Member, error) {
-	var rst []model.Member
-	err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
-		return c.ListMembers(req)
-	}, "Members", &rst)
-
-	return rst, err
+	return listPages(req.Marker, req.Limit, func(marker *string, limit *int32) ([]model.Member, string, error) {
+		page := *req
+		page.Marker, page.Limit = marker, limit
+		var rsp *model.ListMembersResponse
+		err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
+			return c.ListMembers(&page)
+		}, &rsp)
+		if err != nil || rsp.Members == nil {
+			return nil, "", err
+		}
+		return *rsp.Members, nextMarker(rsp.PageInfo), nil
+	})
 }
 
 func (s *DedicatedLoadBalanceClient) UpdateMember(id string, req *model.UpdateMemberOption) (*model.Member, error) {
@@ -386,9 +451,35 @@ func (s *DedicatedLoadBalanceClient) DeleteAllPoolMembers(poolID string) error {
 	return nil
 }
 
+/** Availability Zones **/
+
+// ListAvailabilityZones returns the AZ sets where the load balancers can be created,
+// the AZs of a load balancer must be in the same set.
+func (s *DedicatedLoadBalanceClient) ListAvailabilityZones() ([][]model.AvailabilityZone, error) {
+	var rst [][]model.AvailabilityZone
+	err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
+		return c.ListAvailabilityZones(&model.ListAvailabilityZonesRequest{})
+	}, "AvailabilityZones", &rst)
+	return rst, err
+}
+
+/** Quotas **/
+
+// ListQuotaDetails returns the used and the limit of the quotas of the load balancers and their resources,
+// such as "loadbalancer", "listener" and "member".
+func (s *DedicatedLoadBalanceClient) ListQuotaDetails() ([]model.QuotaInfo, error) {
+	var rst []model.QuotaInfo
+	err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
+		return c.ListQuotaDetails(&model.ListQuotaDetailsRequest{})
+	}, "Quotas", &rst)
+	return rst, err
+}
+
 func (s *DedicatedLoadBalanceClient) wrapper(handler func(*elb.ElbClient) (interface{}, error), args ...interface{}) error {
-	return commonWrapper(func() (interface{}, error) {
-		hc := s.AuthOpts.GetHcClient("elb")
-		return handler(elb.NewElbClient(hc))
-	}, OKCodes, args...)
+	return commonWrapper(withEndpointFailover(s.AuthOpts, "elb", func(endpoint string) (interface{}, error) {
+		return withCredentialRefresh(s.AuthOpts, func() (interface{}, error) {
+			hc := s.AuthOpts.GetHcClientWithEndpoint("elb", endpoint)
+			return handler(elb.NewElbClient(hc))
+		})()
+	}), OKCodes, args...)
 }
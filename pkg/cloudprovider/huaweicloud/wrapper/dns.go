@@ -0,0 +1,117 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wrapper
+
+import (
+	dns "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/dns/v2"
+	"github.com/huaweicloud/huaweicloud-sdk-go-v3/services/dns/v2/model"
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/utils"
+)
+
+// dnsOKCodes are the status codes of the successful DNS requests, the changes of the record sets are accepted
+// with 202 and applied asynchronously.
+var dnsOKCodes = []int{200, 201, 202, 204}
+
+type DnsClient struct {
+	AuthOpts *config.AuthOptions
+}
+
+/** Zones **/
+
+func (d *DnsClient) ListPublicZones() ([]model.PublicZoneResp, error) {
+	return listPages(nil, nil, func(marker *string, limit *int32) ([]model.PublicZoneResp, string, error) {
+		var rst []model.PublicZoneResp
+		err := d.wrapper(func(c *dns.DnsClient) (interface{}, error) {
+			return c.ListPublicZones(&model.ListPublicZonesRequest{
+				Type:   pointer.String("public"),
+				Marker: marker,
+				Limit:  limit,
+			})
+		}, "Zones", &rst)
+		if err != nil || len(rst) == 0 {
+			return nil, "", err
+		}
+		return rst, utils.NextMarkerByLimit(len(rst), int(*limit), pointer.StringDeref(rst[len(rst)-1].Id, "")), nil
+	})
+}
+
+func (d *DnsClient) ListPrivateZones() ([]model.PrivateZoneResp, error) {
+	return listPages(nil, nil, func(marker *string, limit *int32) ([]model.PrivateZoneResp, string, error) {
+		var rst []model.PrivateZoneResp
+		err := d.wrapper(func(c *dns.DnsClient) (interface{}, error) {
+			return c.ListPrivateZones(&model.ListPrivateZonesRequest{
+				Type:   "private",
+				Marker: marker,
+				Limit:  limit,
+			})
+		}, "Zones", &rst)
+		if err != nil || len(rst) == 0 {
+			return nil, "", err
+		}
+		return rst, utils.NextMarkerByLimit(len(rst), int(*limit), pointer.StringDeref(rst[len(rst)-1].Id, "")), nil
+	})
+}
+
+/** Record Sets **/
+
+// ListRecordSets returns the record sets of all the zones matching the request.
+func (d *DnsClient) ListRecordSets(req *model.ListRecordSetsRequest) ([]model.ListRecordSetsWithTags, error) {
+	return listPages(req.Marker, req.Limit, func(marker *string, limit *int32) ([]model.ListRecordSetsWithTags, string, error) {
+		page := *req
+		page.Marker, page.Limit = marker, limit
+		var rst []model.ListRecordSetsWithTags
+		err := d.wrapper(func(c *dns.DnsClient) (interface{}, error) {
+			return c.ListRecordSets(&page)
+		}, "Recordsets", &rst)
+		if err != nil || len(rst) == 0 {
+			return nil, "", err
+		}
+		return rst, utils.NextMarkerByLimit(len(rst), int(*limit), pointer.StringDeref(rst[len(rst)-1].Id, "")), nil
+	})
+}
+
+func (d *DnsClient) CreateRecordSet(zoneID string, opts *model.CreateRecordSetReq) (*model.CreateRecordSetResponse, error) {
+	var rst *model.CreateRecordSetResponse
+	err := d.wrapper(func(c *dns.DnsClient) (interface{}, error) {
+		return c.CreateRecordSet(&model.CreateRecordSetRequest{ZoneId: zoneID, Body: opts})
+	}, &rst)
+	return rst, err
+}
+
+func (d *DnsClient) UpdateRecordSet(zoneID, recordSetID string, opts *model.UpdateRecordSetReq) error {
+	return d.wrapper(func(c *dns.DnsClient) (interface{}, error) {
+		return c.UpdateRecordSet(&model.UpdateRecordSetRequest{ZoneId: zoneID, RecordsetId: recordSetID, Body: opts})
+	})
+}
+
+func (d *DnsClient) DeleteRecordSet(zoneID, recordSetID string) error {
+	return d.wrapper(func(c *dns.DnsClient) (interface{}, error) {
+		return c.DeleteRecordSet(&model.DeleteRecordSetRequest{ZoneId: zoneID, RecordsetId: recordSetID})
+	})
+}
+
+func (d *DnsClient) wrapper(handler func(*dns.DnsClient) (interface{}, error), args ...interface{}) error {
+	return commonWrapper(withEndpointFailover(d.AuthOpts, "dns", func(endpoint string) (interface{}, error) {
+		return withCredentialRefresh(d.AuthOpts, func() (interface{}, error) {
+			hc := d.AuthOpts.GetHcClientWithEndpoint("dns", endpoint)
+			return handler(dns.NewDnsClient(hc))
+		})()
+	}), dnsOKCodes, args...)
+}
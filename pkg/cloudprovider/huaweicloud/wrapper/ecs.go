@@ -19,20 +19,27 @@ package wrapper
 import (
 	"fmt"
 	"net"
+	"net/http"
 	"reflect"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/huaweicloud/huaweicloud-sdk-go-v3/core/sdkerr"
 	ecs "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ecs/v2"
 	"github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ecs/v2/model"
 	"github.com/mitchellh/mapstructure"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
 
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/common"
 	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/metrics"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/tracing"
 	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/utils"
 )
 
@@ -65,6 +72,24 @@ func (e *EcsClient) GetByName(name string) (*model.ServerDetail, error) {
 	return &serverList[0], nil
 }
 
+// GetByIP returns the server that has the given private IP address bound to one of its interfaces.
+func (e *EcsClient) GetByIP(ip string) (*model.ServerDetail, error) {
+	rsp, err := e.List(&model.ListServersDetailsRequest{IpEq: &ip})
+	if err != nil {
+		return nil, err
+	}
+	serverList := *rsp.Servers
+	if len(serverList) == 0 {
+		return nil, status.Errorf(codes.NotFound, "Error, not found any servers matched IP: %s", ip)
+	}
+	if len(serverList) > 1 {
+		return nil, status.Errorf(codes.FailedPrecondition, "Error, found %d servers matched IP: %s",
+			len(serverList), ip)
+	}
+
+	return &serverList[0], nil
+}
+
 func (e *EcsClient) List(req *model.ListServersDetailsRequest) (*model.ListServersDetailsResponse, error) {
 	var rst *model.ListServersDetailsResponse
 	err := e.wrapper(func(c *ecs.EcsClient) (interface{}, error) {
@@ -73,6 +98,27 @@ func (e *EcsClient) List(req *model.ListServersDetailsRequest) (*model.ListServe
 	return rst, err
 }
 
+// ListAll queries all servers of the project page by page.
+func (e *EcsClient) ListAll() ([]model.ServerDetail, error) {
+	limit := int32(1000)
+	servers := make([]model.ServerDetail, 0)
+	for page := int32(1); ; page++ {
+		offset := page
+		rsp, err := e.List(&model.ListServersDetailsRequest{Limit: &limit, Offset: &offset})
+		if err != nil {
+			return nil, err
+		}
+		if rsp.Servers == nil {
+			break
+		}
+		servers = append(servers, *rsp.Servers...)
+		if int32(len(*rsp.Servers)) < limit {
+			break
+		}
+	}
+	return servers, nil
+}
+
 func (e *EcsClient) ListInterfaces(req *model.ListServerInterfacesRequest) ([]model.InterfaceAttachment, error) {
 	var rst []model.InterfaceAttachment
 	err := e.wrapper(func(c *ecs.EcsClient) (interface{}, error) {
@@ -102,7 +148,7 @@ func (e *EcsClient) BuildAddresses(server *model.ServerDetail, interfaces []mode
 	}
 
 	// process public IP addresses
-	if server.AccessIPv4 != "" {
+	if server.AccessIPv4 != "" && !networkingOpts.ExcludeEIP {
 		addToNodeAddresses(&addrs,
 			v1.NodeAddress{
 				Type:    v1.NodeExternalIP,
@@ -133,6 +179,9 @@ func (e *EcsClient) BuildAddresses(server *model.ServerDetail, interfaces []mode
 		for _, props := range addresses[network] {
 			var addressType v1.NodeAddressType
 			if props.IPType == "floating" {
+				if networkingOpts.ExcludeEIP {
+					continue
+				}
 				addressType = v1.NodeExternalIP
 			} else if utils.IsStrSliceContains(networkingOpts.PublicNetworkName, network) {
 				addressType = v1.NodeExternalIP
@@ -171,7 +220,59 @@ func (e *EcsClient) BuildAddresses(server *model.ServerDetail, interfaces []mode
 		}
 	}
 
-	return addrs, nil
+	addrs = filterInternalAddressesBySubnet(addrs, interfaces, networkingOpts.InternalSubnetIDs)
+	return sortAddressesByType(addrs, networkingOpts.AddressTypeOrder), nil
+}
+
+// filterInternalAddressesBySubnet removes the InternalIP addresses that do not belong to the NICs in the subnets.
+func filterInternalAddressesBySubnet(addresses []v1.NodeAddress, interfaces []model.InterfaceAttachment,
+	subnetIDs []string) []v1.NodeAddress {
+	if len(subnetIDs) == 0 {
+		return addresses
+	}
+
+	allowed := make(map[string]bool)
+	for _, iface := range interfaces {
+		if iface.FixedIps == nil {
+			continue
+		}
+		for _, fixedIP := range *iface.FixedIps {
+			if fixedIP.IpAddress == nil {
+				continue
+			}
+			if (iface.NetId != nil && utils.IsStrSliceContains(subnetIDs, *iface.NetId)) ||
+				(fixedIP.SubnetId != nil && utils.IsStrSliceContains(subnetIDs, *fixedIP.SubnetId)) {
+				allowed[*fixedIP.IpAddress] = true
+			}
+		}
+	}
+
+	rst := make([]v1.NodeAddress, 0, len(addresses))
+	for _, addr := range addresses {
+		if addr.Type == v1.NodeInternalIP && !allowed[addr.Address] {
+			klog.V(4).Infof("[DEBUG] address '%s' ignored due to 'internal-subnet-ids' option", addr.Address)
+			continue
+		}
+		rst = append(rst, addr)
+	}
+	return rst
+}
+
+// sortAddressesByType orders the addresses by the precedence of their types, and omits the types not listed.
+func sortAddressesByType(addresses []v1.NodeAddress, order []string) []v1.NodeAddress {
+	if len(order) == 0 {
+		return addresses
+	}
+
+	rst := make([]v1.NodeAddress, 0, len(addresses))
+	for _, addrType := range order {
+		for _, addr := range addresses {
+			if string(addr.Type) == addrType {
+				rst = append(rst, addr)
+			}
+		}
+	}
+	return rst
 }
 
 // addToNodeAddresses appends the NodeAddresses to the passed-by-pointer slice, only if they do not already exist.
@@ -209,10 +310,102 @@ func removeFromNodeAddresses(addresses *[]v1.NodeAddress, removeAddresses ...v1.
 }
 
 func (e *EcsClient) wrapper(handler func(*ecs.EcsClient) (interface{}, error), args ...interface{}) error {
-	return commonWrapper(func() (interface{}, error) {
-		hc := e.AuthOpts.GetHcClient("ecs")
-		return handler(ecs.NewEcsClient(hc))
-	}, OKCodes, args...)
+	return commonWrapper(withEndpointFailover(e.AuthOpts, "ecs", func(endpoint string) (interface{}, error) {
+		return withCredentialRefresh(e.AuthOpts, func() (interface{}, error) {
+			hc := e.AuthOpts.GetHcClientWithEndpoint("ecs", endpoint)
+			return handler(ecs.NewEcsClient(hc))
+		})()
+	}), OKCodes, args...)
+}
+
+// withCredentialRefresh retries the handler once with the refreshed temporary security credential
+// if the request is rejected with 401.
+func withCredentialRefresh(authOpts *config.AuthOptions, handler func() (interface{}, error)) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		response, err := handler()
+		if err != nil && common.GetStatusCode(err) == http.StatusUnauthorized && authOpts.RefreshCredentials() {
+			klog.Warningf("the request is unauthorized, retry with the refreshed security credential: %s", err)
+			return handler()
+		}
+		return response, err
+	}
+}
+
+// withEndpointFailover calls the handler with the endpoint of the service, and retries it with the fallback
+// endpoints if the endpoint in use is unreachable. No request is sent once the context of the options is done,
+// and the requests aborted by it are not reported as the failures of the endpoint.
+func withEndpointFailover(authOpts *config.AuthOptions, catalogName string,
+	handler func(endpoint string) (interface{}, error)) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		operation := operationName()
+		attempts := len(authOpts.GetEndpoints(catalogName))
+		for i := 1; ; i++ {
+			if err := authOpts.Context().Err(); err != nil {
+				return nil, fmt.Errorf("%s is not sent, the reconcile is canceled: %w", operation, err)
+			}
+			endpoint := authOpts.GetEndpoint(catalogName)
+			_, span := tracing.Start(authOpts.Context(), operation,
+				attribute.String("service", catalogName), attribute.String("endpoint", endpoint))
+			release := authOpts.AcquireEndpoint(endpoint)
+			done := metrics.StartRequest(catalogName)
+			response, err := handler(endpoint)
+			done()
+			release()
+			if ctxErr := authOpts.Context().Err(); err != nil && ctxErr != nil {
+				err = fmt.Errorf("%s is aborted, the reconcile is canceled: %w", operation, ctxErr)
+				tracing.End(span, err)
+				return nil, err
+			}
+			if err != nil {
+				observeError(catalogName, err)
+				span.SetAttributes(attribute.Int("http.status_code", common.GetStatusCode(err)),
+					attribute.String("error_code", common.GetErrorCode(err)),
+					attribute.String("request_id", common.GetRequestID(err)))
+			}
+			tracing.End(span, err)
+			if err == nil || !common.IsUnreachable(err) || !authOpts.ReportEndpointFailure(catalogName, endpoint) ||
+				i >= attempts {
+				return response, err
+			}
+		}
+	}
+}
+
+// operationName returns the name of the client method sending the request, such as "EcsClient.Get".
+func operationName() string {
+	pc := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pc[:runtime.Callers(2, pc)])
+	for {
+		frame, more := frames.Next()
+		// The methods of the clients are named as "<package path>/wrapper.(*EcsClient).Get".
+		name := frame.Function[strings.LastIndex(frame.Function, "/")+1:]
+		if strings.HasPrefix(name, "wrapper.(*") {
+			method := strings.Replace(strings.TrimPrefix(name, "wrapper.(*"), ").", ".", 1)
+			if !strings.HasSuffix(method, ".wrapper") && !strings.Contains(method, ".func") {
+				return method
+			}
+		}
+		if !more {
+			return "API request"
+		}
+	}
+}
+
+// observeError records the failed request with the error code of Huawei Cloud,
+// or the HTTP status code if absent.
+func observeError(catalogName string, err error) {
+	code := common.GetErrorCode(err)
+	if code == "" {
+		switch statusCode := common.GetStatusCode(err); {
+		case common.IsUnreachable(err) && statusCode == 0:
+			code = metrics.ErrorCodeConnection
+		case statusCode != 0:
+			code = strconv.Itoa(statusCode)
+		default:
+			code = "Unknown"
+		}
+	}
+	metrics.ObserveError(catalogName, code)
 }
 
 // commonWrapper wrapper common steps.
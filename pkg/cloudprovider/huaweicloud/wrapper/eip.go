@@ -19,23 +19,42 @@ package wrapper
 import (
 	eip "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/eip/v2"
 	"github.com/huaweicloud/huaweicloud-sdk-go-v3/services/eip/v2/model"
+	"k8s.io/utils/pointer"
 
 	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/utils"
 )
 
 type EIpClient struct {
 	AuthOpts *config.AuthOptions
 }
 
+// Create creates the EIP, the unbound EIP with the alias of the request created by a failed request is adopted.
 func (e *EIpClient) Create(req *model.CreatePublicipRequestBody) (*model.PublicipCreateResp, error) {
-	var rst *model.PublicipCreateResp
-	err := e.wrapper(func(c *eip.EipClient) (interface{}, error) {
-		return c.CreatePublicip(&model.CreatePublicipRequest{
-			Body: req,
-		})
-	}, "Publicip", &rst)
-
-	return rst, err
+	return createOrAdopt("EIP", func() (*model.PublicipCreateResp, error) {
+		var rst *model.PublicipCreateResp
+		err := e.wrapper(func(c *eip.EipClient) (interface{}, error) {
+			return c.CreatePublicip(&model.CreatePublicipRequest{
+				Body: req,
+			})
+		}, "Publicip", &rst)
+		return rst, err
+	}, func() (*model.PublicipCreateResp, error) {
+		alias := pointer.StringDeref(req.Publicip.Alias, "")
+		if alias == "" {
+			return nil, nil
+		}
+		ips, err := e.List(&model.ListPublicipsRequest{})
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			if pointer.StringDeref(ip.Alias, "") == alias && pointer.StringDeref(ip.PortId, "") == "" {
+				return &model.PublicipCreateResp{Id: ip.Id, PublicIpAddress: ip.PublicIpAddress}, nil
+			}
+		}
+		return nil, nil
+	})
 }
 
 func (e *EIpClient) Get(id string) (*model.PublicipShowResp, error) {
@@ -47,12 +66,18 @@ func (e *EIpClient) Get(id string) (*model.PublicipShowResp, error) {
 	return rst, err
 }
 func (e *EIpClient) List(req *model.ListPublicipsRequest) ([]model.PublicipShowResp, error) {
-	var rst []model.PublicipShowResp
-	err := e.wrapper(func(c *eip.EipClient) (interface{}, error) {
-		return c.ListPublicips(req)
-	}, "Publicips", &rst)
-
-	return rst, err
+	return listPages(req.Marker, req.Limit, func(marker *string, limit *int32) ([]model.PublicipShowResp, string, error) {
+		page := *req
+		page.Marker, page.Limit = marker, limit
+		var rst []model.PublicipShowResp
+		err := e.wrapper(func(c *eip.EipClient) (interface{}, error) {
+			return c.ListPublicips(&page)
+		}, "Publicips", &rst)
+		if err != nil || len(rst) == 0 {
+			return nil, "", err
+		}
+		return rst, utils.NextMarkerByLimit(len(rst), int(*limit), pointer.StringDeref(rst[len(rst)-1].Id, "")), nil
+	})
 }
 
 func (e *EIpClient) Update(id string, opts *model.UpdatePublicipOption) error {
@@ -81,9 +106,40 @@ func (e *EIpClient) Delete(id string) error {
 	})
 }
 
+// ListFloatingIPs returns the EIPs in the form of the floating IPs of the Neutron API, whose IDs are referenced by
+// the DNAT rules.
+func (e *EIpClient) ListFloatingIPs(req *model.NeutronListFloatingIpsRequest) ([]model.FloatingIpResp, error) {
+	return listPages(req.Marker, req.Limit, func(marker *string, limit *int32) ([]model.FloatingIpResp, string, error) {
+		page := *req
+		page.Marker, page.Limit = marker, limit
+		var rst []model.FloatingIpResp
+		err := e.wrapper(func(c *eip.EipClient) (interface{}, error) {
+			return c.NeutronListFloatingIps(&page)
+		}, "Floatingips", &rst)
+		if err != nil || len(rst) == 0 {
+			return nil, "", err
+		}
+		return rst, utils.NextMarkerByLimit(len(rst), int(*limit), pointer.StringDeref(rst[len(rst)-1].Id, "")), nil
+	})
+}
+
+// ListQuotas returns the used and the limit of the quotas of the EIPs and the bandwidths.
+func (e *EIpClient) ListQuotas() ([]model.QuotaShowResp, error) {
+	var rst *model.ResourceResp
+	err := e.wrapper(func(c *eip.EipClient) (interface{}, error) {
+		return c.ListQuotas(&model.ListQuotasRequest{})
+	}, "Quotas", &rst)
+	if err != nil || rst == nil {
+		return nil, err
+	}
+	return rst.Resources, nil
+}
+
 func (e *EIpClient) wrapper(handler func(*eip.EipClient) (interface{}, error), args ...interface{}) error {
-	return commonWrapper(func() (interface{}, error) {
-		hc := e.AuthOpts.GetHcClient("vpc")
-		return handler(eip.NewEipClient(hc))
-	}, OKCodes, args...)
+	return commonWrapper(withEndpointFailover(e.AuthOpts, "vpc", func(endpoint string) (interface{}, error) {
+		return withCredentialRefresh(e.AuthOpts, func() (interface{}, error) {
+			hc := e.AuthOpts.GetHcClientWithEndpoint("vpc", endpoint)
+			return handler(eip.NewEipClient(hc))
+		})()
+	}), OKCodes, args...)
 }
@@ -0,0 +1,91 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wrapper
+
+import (
+	er "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/er/v3"
+	"github.com/huaweicloud/huaweicloud-sdk-go-v3/services/er/v3/model"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+)
+
+type ErClient struct {
+	AuthOpts *config.AuthOptions
+}
+
+/** Static Routes **/
+
+// ListStaticRoutes returns all static routes of the route table pointing to the attachment.
+func (e *ErClient) ListStaticRoutes(routeTableID, attachmentID string) ([]model.Route, error) {
+	routes := make([]model.Route, 0)
+	var marker *string
+	for {
+		var rsp *model.ListStaticRoutesResponse
+		err := e.wrapper(func(c *er.ErClient) (interface{}, error) {
+			return c.ListStaticRoutes(&model.ListStaticRoutesRequest{
+				RouteTableId: routeTableID,
+				AttachmentId: &[]string{attachmentID},
+				Marker:       marker,
+			})
+		}, &rsp)
+		if err != nil {
+			return nil, err
+		}
+		if rsp.Routes != nil {
+			routes = append(routes, *rsp.Routes...)
+		}
+		if rsp.PageInfo == nil || rsp.PageInfo.NextMarker == nil || *rsp.PageInfo.NextMarker == "" {
+			break
+		}
+		marker = rsp.PageInfo.NextMarker
+	}
+	return routes, nil
+}
+
+func (e *ErClient) CreateStaticRoute(routeTableID, destination, attachmentID string) (*model.Route, error) {
+	var rst *model.Route
+	err := e.wrapper(func(c *er.ErClient) (interface{}, error) {
+		return c.CreateStaticRoute(&model.CreateStaticRouteRequest{
+			RouteTableId: routeTableID,
+			Body: &model.CreateRouteRequestBody{
+				Route: &model.CreateRoute{
+					Destination:  destination,
+					AttachmentId: &attachmentID,
+				},
+			},
+		})
+	}, "Route", &rst)
+	return rst, err
+}
+
+func (e *ErClient) DeleteStaticRoute(routeTableID, routeID string) error {
+	return e.wrapper(func(c *er.ErClient) (interface{}, error) {
+		return c.DeleteStaticRoute(&model.DeleteStaticRouteRequest{
+			RouteTableId: routeTableID,
+			RouteId:      routeID,
+		})
+	})
+}
+
+func (e *ErClient) wrapper(handler func(*er.ErClient) (interface{}, error), args ...interface{}) error {
+	return commonWrapper(withEndpointFailover(e.AuthOpts, "er", func(endpoint string) (interface{}, error) {
+		return withCredentialRefresh(e.AuthOpts, func() (interface{}, error) {
+			hc := e.AuthOpts.GetHcClientWithEndpoint("er", endpoint)
+			return handler(er.NewErClient(hc))
+		})()
+	}), OKCodes, args...)
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wrapper
+
+import (
+	ga "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ga/v1"
+	"github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ga/v1/model"
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/utils"
+)
+
+// GaClient is the client of the Global Accelerator, a global service whose resources are owned by the account,
+// the requests are signed with the account credentials.
+type GaClient struct {
+	AuthOpts *config.AuthOptions
+}
+
+/** Endpoints **/
+
+// ListEndpoints returns the endpoints of the endpoint group.
+func (g *GaClient) ListEndpoints(endpointGroupID string) ([]model.EndpointDetail, error) {
+	return listPages(nil, nil, func(marker *string, limit *int32) ([]model.EndpointDetail, string, error) {
+		var rst []model.EndpointDetail
+		err := g.wrapper(func(c *ga.GaClient) (interface{}, error) {
+			return c.ListEndpoints(&model.ListEndpointsRequest{
+				EndpointGroupId: endpointGroupID,
+				Marker:          marker,
+				Limit:           limit,
+			})
+		}, "Endpoints", &rst)
+		if err != nil || len(rst) == 0 {
+			return nil, "", err
+		}
+		return rst, utils.NextMarkerByLimit(len(rst), int(*limit), pointer.StringDeref(rst[len(rst)-1].Id, "")), nil
+	})
+}
+
+// CreateEndpoint adds the EIP of the ID and the address to the endpoint group.
+func (g *GaClient) CreateEndpoint(endpointGroupID, eipID, address string) (*model.EndpointDetail, error) {
+	var rst *model.EndpointDetail
+	resourceType := model.GetEndpointTypeEnum().EIP
+	err := g.wrapper(func(c *ga.GaClient) (interface{}, error) {
+		return c.CreateEndpoint(&model.CreateEndpointRequest{
+			EndpointGroupId: endpointGroupID,
+			Body: &model.CreateEndpointRequestBody{Endpoint: &model.CreateEndpointOption{
+				ResourceId:   eipID,
+				ResourceType: &resourceType,
+				IpAddress:    &address,
+			}},
+		})
+	}, "Endpoint", &rst)
+	return rst, err
+}
+
+func (g *GaClient) DeleteEndpoint(endpointGroupID, endpointID string) error {
+	return g.wrapper(func(c *ga.GaClient) (interface{}, error) {
+		return c.DeleteEndpoint(&model.DeleteEndpointRequest{EndpointGroupId: endpointGroupID, EndpointId: endpointID})
+	})
+}
+
+func (g *GaClient) wrapper(handler func(*ga.GaClient) (interface{}, error), args ...interface{}) error {
+	return commonWrapper(withEndpointFailover(g.AuthOpts, "ga", func(endpoint string) (interface{}, error) {
+		return withCredentialRefresh(g.AuthOpts, func() (interface{}, error) {
+			hc := g.AuthOpts.GetHcClientWithEndpoint("ga", endpoint)
+			return handler(ga.NewGaClient(hc))
+		})()
+	}), OKCodes, args...)
+}
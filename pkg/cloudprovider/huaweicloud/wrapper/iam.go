@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wrapper
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	iam "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/iam/v3"
+	"github.com/huaweicloud/huaweicloud-sdk-go-v3/services/iam/v3/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+)
+
+const endpointInterfacePublic = "public"
+
+type IamClient struct {
+	AuthOpts *config.AuthOptions
+}
+
+/** Service Catalog **/
+
+// ListEndpoints returns the public endpoints of the services in the region from the service catalog,
+// keyed by catalog name, such as "ecs" and "vpc". The paths of the endpoints are removed.
+func (i *IamClient) ListEndpoints() (map[string]string, error) {
+	var catalog []model.Catalog
+	err := i.wrapper(func(c *iam.IamClient) (interface{}, error) {
+		return c.KeystoneShowCatalog(&model.KeystoneShowCatalogRequest{})
+	}, "Catalog", &catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := make(map[string]string)
+	for _, service := range catalog {
+		for _, ep := range service.Endpoints {
+			if ep.Interface != endpointInterfacePublic || (ep.Region != i.AuthOpts.Region &&
+				ep.RegionId != i.AuthOpts.Region) {
+				continue
+			}
+			u, err := url.Parse(ep.Url)
+			if err != nil || u.Host == "" {
+				return nil, fmt.Errorf("invalid endpoint %q of service %s in the catalog", ep.Url, service.Name)
+			}
+			endpoints[strings.ToLower(service.Name)] = fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+		}
+	}
+	return endpoints, nil
+}
+
+/** Project **/
+
+// GetProjectID returns the ID of the project by name in the account of domain-id.
+func (i *IamClient) GetProjectID(name string) (string, error) {
+	var projects []model.ProjectResult
+	err := i.wrapper(func(c *iam.IamClient) (interface{}, error) {
+		return c.KeystoneListProjects(&model.KeystoneListProjectsRequest{
+			DomainId: &i.AuthOpts.DomainID,
+			Name:     &name,
+		})
+	}, "Projects", &projects)
+	if err != nil {
+		return "", err
+	}
+
+	for _, p := range projects {
+		if p.Name == name {
+			return p.Id, nil
+		}
+	}
+	return "", status.Errorf(codes.NotFound, "not found project: %s", name)
+}
+
+func (i *IamClient) wrapper(handler func(*iam.IamClient) (interface{}, error), args ...interface{}) error {
+	return commonWrapper(withCredentialRefresh(i.AuthOpts, func() (interface{}, error) {
+		hc := i.AuthOpts.GetHcClientWithEndpoint("iam", i.AuthOpts.GetIAMEndpoint())
+		return handler(iam.NewIamClient(hc))
+	}), OKCodes, args...)
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wrapper
+
+import (
+	nat "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/nat/v2"
+	"github.com/huaweicloud/huaweicloud-sdk-go-v3/services/nat/v2/model"
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+)
+
+// maxNATPageLimit is the maximum limit of the list APIs of the NAT gateways, which are not paginated by marker.
+const maxNATPageLimit = 2000
+
+type NatClient struct {
+	AuthOpts *config.AuthOptions
+}
+
+/** NAT Gateways **/
+
+func (n *NatClient) GetNatGateway(id string) (*model.NatGatewayResponseBody, error) {
+	var rst *model.NatGatewayResponseBody
+	err := n.wrapper(func(c *nat.NatClient) (interface{}, error) {
+		return c.ShowNatGateway(&model.ShowNatGatewayRequest{NatGatewayId: id})
+	}, "NatGateway", &rst)
+	return rst, err
+}
+
+func (n *NatClient) ListNatGateways(req *model.ListNatGatewaysRequest) ([]model.NatGatewayResponseBody, error) {
+	page := *req
+	if page.Limit == nil {
+		page.Limit = pointer.Int32(maxNATPageLimit)
+	}
+	var rst []model.NatGatewayResponseBody
+	err := n.wrapper(func(c *nat.NatClient) (interface{}, error) {
+		return c.ListNatGateways(&page)
+	}, "NatGateways", &rst)
+	return rst, err
+}
+
+/** DNAT Rules **/
+
+func (n *NatClient) CreateDnatRule(opts *model.CreateNatGatewayDnatOption) (*model.NatGatewayDnatRuleResponseBody, error) {
+	var rst *model.NatGatewayDnatRuleResponseBody
+	err := n.wrapper(func(c *nat.NatClient) (interface{}, error) {
+		return c.CreateNatGatewayDnatRule(&model.CreateNatGatewayDnatRuleRequest{
+			Body: &model.CreateNatGatewayDnatRuleOption{DnatRule: opts},
+		})
+	}, "DnatRule", &rst)
+	return rst, err
+}
+
+func (n *NatClient) GetDnatRule(id string) (*model.NatGatewayDnatRuleResponseBody, error) {
+	var rst *model.NatGatewayDnatRuleResponseBody
+	err := n.wrapper(func(c *nat.NatClient) (interface{}, error) {
+		return c.ShowNatGatewayDnatRule(&model.ShowNatGatewayDnatRuleRequest{DnatRuleId: id})
+	}, "DnatRule", &rst)
+	return rst, err
+}
+
+func (n *NatClient) ListDnatRules(req *model.ListNatGatewayDnatRulesRequest) ([]model.NatGatewayDnatRuleResponseBody, error) {
+	page := *req
+	if page.Limit == nil {
+		page.Limit = pointer.Int32(maxNATPageLimit)
+	}
+	var rst []model.NatGatewayDnatRuleResponseBody
+	err := n.wrapper(func(c *nat.NatClient) (interface{}, error) {
+		return c.ListNatGatewayDnatRules(&page)
+	}, "DnatRules", &rst)
+	return rst, err
+}
+
+func (n *NatClient) DeleteDnatRule(natGatewayID, id string) error {
+	return n.wrapper(func(c *nat.NatClient) (interface{}, error) {
+		return c.DeleteNatGatewayDnatRule(&model.DeleteNatGatewayDnatRuleRequest{
+			NatGatewayId: natGatewayID,
+			DnatRuleId:   id,
+		})
+	})
+}
+
+func (n *NatClient) wrapper(handler func(*nat.NatClient) (interface{}, error), args ...interface{}) error {
+	return commonWrapper(withEndpointFailover(n.AuthOpts, "nat", func(endpoint string) (interface{}, error) {
+		return withCredentialRefresh(n.AuthOpts, func() (interface{}, error) {
+			hc := n.AuthOpts.GetHcClientWithEndpoint("nat", endpoint)
+			return handler(nat.NewNatClient(hc))
+		})()
+	}), OKCodes, args...)
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wrapper
+
+import (
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/utils"
+)
+
+// listPages returns the items of all the pages of a list API paginated by the marker, which is the ID of the last
+// item of the previous page. If the limit is set by the caller, such as the probes of the endpoints,
+// only the page of the request is queried. list queries the page and returns the marker of the next page.
+func listPages[T any](marker *string, limit *int32,
+	list func(marker *string, limit *int32) ([]T, string, error)) ([]T, error) {
+	if limit != nil {
+		items, _, err := list(marker, limit)
+		return items, err
+	}
+	// The default limits of the APIs vary, the pages are queried with the same limit.
+	pageLimit := int32(utils.DefaultPageLimit)
+	return utils.ListAllByMarker(pointer.StringDeref(marker, ""), func(next string) ([]T, string, error) {
+		if next == "" {
+			return list(nil, &pageLimit)
+		}
+		return list(&next, &pageLimit)
+	})
+}
@@ -29,6 +29,7 @@ import (
 
 	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/common"
 	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/utils"
 )
 
 type SharedLoadBalanceClient struct {
@@ -60,7 +61,7 @@ func (s *SharedLoadBalanceClient) CreateInstanceCompleted(req *model.CreateLoadb
 func (s *SharedLoadBalanceClient) WaitStatusActive(id string) (*model.LoadbalancerResp, error) {
 	var instance *model.LoadbalancerResp
 
-	err := common.WaitForCompleted(func() (bool, error) {
+	err := common.WaitForCompletedWithContext(s.AuthOpts.Context(), func() (bool, error) {
 		ins, err := s.GetInstance(id)
 		instance = ins
 		if err != nil {
@@ -94,11 +95,18 @@ func (s *SharedLoadBalanceClient) GetInstance(id string) (*model.LoadbalancerRes
 }
 
 func (s *SharedLoadBalanceClient) ListInstances(req *model.ListLoadbalancersRequest) ([]model.LoadbalancerResp, error) {
-	var rst []model.LoadbalancerResp
-	err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
-		return c.ListLoadbalancers(req)
-	}, "Loadbalancers", &rst)
-	return rst, err
+	return listPages(req.Marker, req.Limit, func(marker *string, limit *int32) ([]model.LoadbalancerResp, string, error) {
+		page := *req
+		page.Marker, page.Limit = marker, limit
+		var rst []model.LoadbalancerResp
+		err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
+			return c.ListLoadbalancers(&page)
+		}, "Loadbalancers", &rst)
+		if err != nil || len(rst) == 0 {
+			return nil, "", err
+		}
+		return rst, utils.NextMarkerByLimit(len(rst), int(*limit), rst[len(rst)-1].Id), nil
+	})
 }
 
 func (s *SharedLoadBalanceClient) UpdateInstance(id, name, description string) (*model.LoadbalancerResp, error) {
@@ -129,17 +137,30 @@ func (s *SharedLoadBalanceClient) DeleteInstance(id string) error {
 
 /** Listeners **/
 
+// CreateListener creates the listener, the listener of the port created by a failed request is adopted.
 func (s *SharedLoadBalanceClient) CreateListener(req *model.CreateListenerReq) (*model.ListenerResp, error) {
-	var rst *model.ListenerResp
-	err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
-		return c.CreateListener(&model.CreateListenerRequest{
-			Body: &model.CreateListenerRequestBody{
-				Listener: req,
-			},
+	return createOrAdopt("listener", func() (*model.ListenerResp, error) {
+		var rst *model.ListenerResp
+		err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
+			return c.CreateListener(&model.CreateListenerRequest{
+				Body: &model.CreateListenerRequestBody{
+					Listener: req,
+				},
+			})
+		}, "Listener", &rst)
+		return rst, err
+	}, func() (*model.ListenerResp, error) {
+		protocol := req.Protocol.Value()
+		listeners, err := s.ListListeners(&model.ListListenersRequest{
+			LoadbalancerId: &req.LoadbalancerId,
+			ProtocolPort:   &req.ProtocolPort,
+			Protocol:       &protocol,
 		})
-	}, "Listener", &rst)
-
-	return rst, err
+		if err != nil || len(listeners) == 0 {
+			return nil, err
+		}
+		return &listeners[0], nil
+	})
 }
 
 func (s *SharedLoadBalanceClient) GetListener(id string) (*model.ListenerResp, error) {
@@ -152,13 +173,18 @@ func (s *SharedLoadBalanceClient) GetListener(id string) (*model.ListenerResp, e
 }
 
 func (s *SharedLoadBalanceClient) ListListeners(req *model.ListListenersRequest) ([]model.ListenerResp, error) {
-	//rst := make([]model.ListenerResp, 0)
-	var rst []model.ListenerResp
-	err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
-		return c.ListListeners(req)
-	}, "Listeners", &rst)
-
-	return rst, err
+	return listPages(req.Marker, req.Limit, func(marker *string, limit *int32) ([]model.ListenerResp, string, error) {
+		page := *req
+		page.Marker, page.Limit = marker, limit
+		var rst []model.ListenerResp
+		err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
+			return c.ListListeners(&page)
+		}, "Listeners", &rst)
+		if err != nil || len(rst) == 0 {
+			return nil, "", err
+		}
+		return rst, utils.NextMarkerByLimit(len(rst), int(*limit), rst[len(rst)-1].Id), nil
+	})
 }
 
 func (s *SharedLoadBalanceClient) UpdateListener(id string, req *model.UpdateListenerReq) error {
@@ -209,17 +235,35 @@ func (s *SharedLoadBalanceClient) DeleteListener(elbID string, listenerID string
 
 /** Pools **/
 
+// CreatePool creates the pool, the pool of the listener created by a failed request is adopted.
 func (s *SharedLoadBalanceClient) CreatePool(req *model.CreatePoolReq) (*model.PoolResp, error) {
-	var rst *model.PoolResp
-	err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
-		return c.CreatePool(&model.CreatePoolRequest{
-			Body: &model.CreatePoolRequestBody{
-				Pool: req,
-			},
-		})
-	}, "Pool", &rst)
-
-	return rst, err
+	return createOrAdopt("pool", func() (*model.PoolResp, error) {
+		var rst *model.PoolResp
+		err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
+			return c.CreatePool(&model.CreatePoolRequest{
+				Body: &model.CreatePoolRequestBody{
+					Pool: req,
+				},
+			})
+		}, "Pool", &rst)
+		return rst, err
+	}, func() (*model.PoolResp, error) {
+		if req.ListenerId == nil {
+			return nil, nil
+		}
+		pools, err := s.ListPools(&model.ListPoolsRequest{Name: req.Name})
+		if err != nil {
+			return nil, err
+		}
+		for _, pool := range pools {
+			for _, listener := range pool.Listeners {
+				if listener.Id == *req.ListenerId {
+					return &pool, nil
+				}
+			}
+		}
+		return nil, nil
+	})
 }
 
 func (s *SharedLoadBalanceClient) GetPool(id string) (*model.PoolResp, error) {
@@ -234,12 +278,18 @@ func (s *SharedLoadBalanceClient) GetPool(id string) (*model.PoolResp, error) {
 }
 
 func (s *SharedLoadBalanceClient) ListPools(req *model.ListPoolsRequest) ([]model.PoolResp, error) {
-	var rst []model.PoolResp
-	err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
-		return c.ListPools(req)
-	}, "Pools", &rst)
-
-	return rst, err
+	return listPages(req.Marker, req.Limit, func(marker *string, limit *int32) ([]model.PoolResp, string, error) {
+		page := *req
+		page.Marker, page.Limit = marker, limit
+		var rst []model.PoolResp
+		err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
+			return c.ListPools(&page)
+		}, "Pools", &rst)
+		if err != nil || len(rst) == 0 {
+			return nil, "", err
+		}
+		return rst, utils.NextMarkerByLimit(len(rst), int(*limit), rst[len(rst)-1].Id), nil
+	})
 }
 
 func (s *SharedLoadBalanceClient) UpdatePool(id string, req *model.UpdatePoolReq) (*model.PoolResp, error) {
@@ -265,17 +315,25 @@ func (s *SharedLoadBalanceClient) DeletePool(id string) error {
 
 /** Health Monitor **/
 
+// CreateHealthMonitor creates the health monitor, the monitor of the pool created by a failed request is adopted.
 func (s *SharedLoadBalanceClient) CreateHealthMonitor(req *model.CreateHealthmonitorReq) (*model.HealthmonitorResp, error) {
-	var rst *model.HealthmonitorResp
-	err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
-		return c.CreateHealthmonitor(&model.CreateHealthmonitorRequest{
-			Body: &model.CreateHealthmonitorRequestBody{
-				Healthmonitor: req,
-			},
-		})
-	}, "Healthmonitor", &rst)
-
-	return rst, err
+	return createOrAdopt("health monitor", func() (*model.HealthmonitorResp, error) {
+		var rst *model.HealthmonitorResp
+		err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
+			return c.CreateHealthmonitor(&model.CreateHealthmonitorRequest{
+				Body: &model.CreateHealthmonitorRequestBody{
+					Healthmonitor: req,
+				},
+			})
+		}, "Healthmonitor", &rst)
+		return rst, err
+	}, func() (*model.HealthmonitorResp, error) {
+		pool, err := s.GetPool(req.PoolId)
+		if err != nil || pool.HealthmonitorId == "" {
+			return nil, err
+		}
+		return s.GetHealthMonitor(pool.HealthmonitorId)
+	})
 }
 
 func (s *SharedLoadBalanceClient) GetHealthMonitor(id string) (*model.HealthmonitorResp, error) {
@@ -335,12 +393,18 @@ func (s *SharedLoadBalanceClient) GetMember(id string) (*model.MemberResp, error
 }
 
 func (s *SharedLoadBalanceClient) ListMembers(req *model.ListMembersRequest) ([]model.MemberResp, error) {
-	var rst []model.MemberResp
-	err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
-		return c.ListMembers(req)
-	}, "Members", &rst)
-
-	return rst, err
+	return listPages(req.Marker, req.Limit, func(marker *string, limit *int32) ([]model.MemberResp, string, error) {
+		page := *req
+		page.Marker, page.Limit = marker, limit
+		var rst []model.MemberResp
+		err := s.wrapper(func(c *elb.ElbClient) (interface{}, error) {
+			return c.ListMembers(&page)
+		}, "Members", &rst)
+		if err != nil || len(rst) == 0 {
+			return nil, "", err
+		}
+		return rst, utils.NextMarkerByLimit(len(rst), int(*limit), rst[len(rst)-1].Id), nil
+	})
 }
 
 func (s *SharedLoadBalanceClient) UpdateMember(id string, req *model.UpdateMemberReq) (*model.MemberResp, error) {
@@ -385,8 +449,10 @@ func (s *SharedLoadBalanceClient) DeleteAllPoolMembers(poolID string) error {
 }
 
 func (s *SharedLoadBalanceClient) wrapper(handler func(*elb.ElbClient) (interface{}, error), args ...interface{}) error {
-	return commonWrapper(func() (interface{}, error) {
-		hc := s.AuthOpts.GetHcClient("elb")
-		return handler(elb.NewElbClient(hc))
-	}, OKCodes, args...)
+	return commonWrapper(withEndpointFailover(s.AuthOpts, "elb", func(endpoint string) (interface{}, error) {
+		return withCredentialRefresh(s.AuthOpts, func() (interface{}, error) {
+			hc := s.AuthOpts.GetHcClientWithEndpoint("elb", endpoint)
+			return handler(elb.NewElbClient(hc))
+		})()
+	}), OKCodes, args...)
 }
@@ -0,0 +1,179 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wrapper
+
+import (
+	vpc "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/vpc/v2"
+	"github.com/huaweicloud/huaweicloud-sdk-go-v3/services/vpc/v2/model"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/utils"
+)
+
+const (
+	routeActionAdd = "add"
+	routeActionMod = "mod"
+	routeActionDel = "del"
+)
+
+type VpcClient struct {
+	AuthOpts *config.AuthOptions
+}
+
+/** Route Tables **/
+
+func (v *VpcClient) ListRouteTables(req *model.ListRouteTablesRequest) ([]model.RouteTableListResp, error) {
+	return listPages(req.Marker, req.Limit, func(marker *string, limit *int32) ([]model.RouteTableListResp, string, error) {
+		page := *req
+		page.Marker, page.Limit = marker, limit
+		var rst []model.RouteTableListResp
+		err := v.wrapper(func(c *vpc.VpcClient) (interface{}, error) {
+			return c.ListRouteTables(&page)
+		}, "Routetables", &rst)
+		if err != nil || len(rst) == 0 {
+			return nil, "", err
+		}
+		return rst, utils.NextMarkerByLimit(len(rst), int(*limit), rst[len(rst)-1].Id), nil
+	})
+}
+
+func (v *VpcClient) GetRouteTable(id string) (*model.RouteTableResp, error) {
+	var rst *model.RouteTableResp
+	err := v.wrapper(func(c *vpc.VpcClient) (interface{}, error) {
+		return c.ShowRouteTable(&model.ShowRouteTableRequest{RoutetableId: id})
+	}, "Routetable", &rst)
+	return rst, err
+}
+
+func (v *VpcClient) AddRoutes(routeTableID string, routes []model.RouteTableRoute) error {
+	return v.updateRoutes(routeTableID, routeActionAdd, routes)
+}
+
+// ModifyRoutes updates the next hops of the routes with the same destinations.
+func (v *VpcClient) ModifyRoutes(routeTableID string, routes []model.RouteTableRoute) error {
+	return v.updateRoutes(routeTableID, routeActionMod, routes)
+}
+
+func (v *VpcClient) DeleteRoutes(routeTableID string, routes []model.RouteTableRoute) error {
+	return v.updateRoutes(routeTableID, routeActionDel, routes)
+}
+
+func (v *VpcClient) updateRoutes(routeTableID, action string, routes []model.RouteTableRoute) error {
+	return v.wrapper(func(c *vpc.VpcClient) (interface{}, error) {
+		return c.UpdateRouteTable(&model.UpdateRouteTableRequest{
+			RoutetableId: routeTableID,
+			Body: &model.UpdateRoutetableReqBody{
+				Routetable: &model.UpdateRouteTableReq{
+					Routes: map[string][]model.RouteTableRoute{action: routes},
+				},
+			},
+		})
+	})
+}
+
+/** Subnets **/
+
+func (v *VpcClient) ListSubnets(req *model.ListSubnetsRequest) ([]model.Subnet, error) {
+	return listPages(req.Marker, req.Limit, func(marker *string, limit *int32) ([]model.Subnet, string, error) {
+		page := *req
+		page.Marker, page.Limit = marker, limit
+		var rst []model.Subnet
+		err := v.wrapper(func(c *vpc.VpcClient) (interface{}, error) {
+			return c.ListSubnets(&page)
+		}, "Subnets", &rst)
+		if err != nil || len(rst) == 0 {
+			return nil, "", err
+		}
+		return rst, utils.NextMarkerByLimit(len(rst), int(*limit), rst[len(rst)-1].Id), nil
+	})
+}
+
+/** Ports **/
+
+func (v *VpcClient) ListPorts(req *model.ListPortsRequest) ([]model.Port, error) {
+	return listPages(req.Marker, req.Limit, func(marker *string, limit *int32) ([]model.Port, string, error) {
+		page := *req
+		page.Marker, page.Limit = marker, limit
+		var rst []model.Port
+		err := v.wrapper(func(c *vpc.VpcClient) (interface{}, error) {
+			return c.ListPorts(&page)
+		}, "Ports", &rst)
+		if err != nil || len(rst) == 0 {
+			return nil, "", err
+		}
+		return rst, utils.NextMarkerByLimit(len(rst), int(*limit), rst[len(rst)-1].Id), nil
+	})
+}
+
+func (v *VpcClient) GetPort(id string) (*model.Port, error) {
+	var rst *model.Port
+	err := v.wrapper(func(c *vpc.VpcClient) (interface{}, error) {
+		return c.ShowPort(&model.ShowPortRequest{PortId: id})
+	}, "Port", &rst)
+	return rst, err
+}
+
+func (v *VpcClient) UpdatePort(id string, opts *model.UpdatePortOption) error {
+	return v.wrapper(func(c *vpc.VpcClient) (interface{}, error) {
+		return c.UpdatePort(&model.UpdatePortRequest{
+			PortId: id,
+			Body:   &model.UpdatePortRequestBody{Port: opts},
+		})
+	})
+}
+
+/** Security Group Rules **/
+
+func (v *VpcClient) ListSecurityGroupRules(req *model.ListSecurityGroupRulesRequest) ([]model.SecurityGroupRule, error) {
+	return listPages(req.Marker, req.Limit, func(marker *string, limit *int32) ([]model.SecurityGroupRule, string, error) {
+		page := *req
+		page.Marker, page.Limit = marker, limit
+		var rst []model.SecurityGroupRule
+		err := v.wrapper(func(c *vpc.VpcClient) (interface{}, error) {
+			return c.ListSecurityGroupRules(&page)
+		}, "SecurityGroupRules", &rst)
+		if err != nil || len(rst) == 0 {
+			return nil, "", err
+		}
+		return rst, utils.NextMarkerByLimit(len(rst), int(*limit), rst[len(rst)-1].Id), nil
+	})
+}
+
+func (v *VpcClient) CreateSecurityGroupRule(opts *model.CreateSecurityGroupRuleOption) (*model.SecurityGroupRule, error) {
+	var rst *model.SecurityGroupRule
+	err := v.wrapper(func(c *vpc.VpcClient) (interface{}, error) {
+		return c.CreateSecurityGroupRule(&model.CreateSecurityGroupRuleRequest{
+			Body: &model.CreateSecurityGroupRuleRequestBody{SecurityGroupRule: opts},
+		})
+	}, "SecurityGroupRule", &rst)
+	return rst, err
+}
+
+func (v *VpcClient) DeleteSecurityGroupRule(id string) error {
+	return v.wrapper(func(c *vpc.VpcClient) (interface{}, error) {
+		return c.DeleteSecurityGroupRule(&model.DeleteSecurityGroupRuleRequest{SecurityGroupRuleId: id})
+	})
+}
+
+func (v *VpcClient) wrapper(handler func(*vpc.VpcClient) (interface{}, error), args ...interface{}) error {
+	return commonWrapper(withEndpointFailover(v.AuthOpts, "vpc", func(endpoint string) (interface{}, error) {
+		return withCredentialRefresh(v.AuthOpts, func() (interface{}, error) {
+			hc := v.AuthOpts.GetHcClientWithEndpoint("vpc", endpoint)
+			return handler(vpc.NewVpcClient(hc))
+		})()
+	}), OKCodes, args...)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package huaweicloud
+
+import (
+	"context"
+
+	ecsmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ecs/v2/model"
+	"k8s.io/apimachinery/pkg/types"
+	cloudprovider "k8s.io/cloud-provider"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/utils/metadata"
+)
+
+type Zones struct {
+	Basic
+}
+
+// GetZone returns the Zone containing the current failure zone and locality region that the program is running in.
+func (z *Zones) GetZone(_ context.Context) (cloudprovider.Zone, error) {
+	klog.Infof("GetZone is called")
+	md, err := metadata.Get(z.metadataOpts().SearchOrder)
+	if err != nil {
+		return cloudprovider.Zone{}, err
+	}
+
+	az, err := md.GetAvailabilityZone()
+	if err != nil {
+		return cloudprovider.Zone{}, err
+	}
+
+	region := md.RegionID
+	if region == "" {
+		region = z.cloudConfig.AuthOpts.Region
+	}
+
+	zone := cloudprovider.Zone{
+		FailureDomain: az,
+		Region:        region,
+	}
+	klog.V(4).Infof("Current zone is %v", zone)
+	return zone, nil
+}
+
+// GetZoneByProviderID returns the Zone containing the current zone and locality region of the node specified by
+// providerID.
+func (z *Zones) GetZoneByProviderID(ctx context.Context, providerID string) (cloudprovider.Zone, error) {
+	klog.Infof("GetZoneByProviderID is called with provider ID %s", providerID)
+	rb, instanceID, err := z.forProviderID(ctx, providerID)
+	if err != nil {
+		return cloudprovider.Zone{}, err
+	}
+
+	instance, err := rb.ecsCache.Get(instanceID)
+	if err != nil {
+		return cloudprovider.Zone{}, err
+	}
+
+	return getInstanceZone(rb, instance), nil
+}
+
+func getInstanceZone(b Basic, instance *ecsmodel.ServerDetail) cloudprovider.Zone {
+	zone := cloudprovider.Zone{
+		FailureDomain: instance.OSEXTAZavailabilityZone,
+		Region:        b.cloudConfig.AuthOpts.Region,
+	}
+	klog.V(4).Infof("The zone of ECS %s is %v", instance.Id, zone)
+	return zone
+}
+
+// GetZoneByNodeName returns the Zone containing the current zone and locality region of the node specified by node
+// name.
+func (z *Zones) GetZoneByNodeName(ctx context.Context, nodeName types.NodeName) (cloudprovider.Zone, error) {
+	klog.Infof("GetZoneByNodeName is called with name %s", nodeName)
+	rb, instance, err := z.findInstanceByNodeName(ctx, string(nodeName))
+	if err != nil {
+		return cloudprovider.Zone{}, err
+	}
+
+	return getInstanceZone(rb, instance), nil
+}
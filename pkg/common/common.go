@@ -17,14 +17,27 @@ limitations under the License.
 package common
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/huaweicloud/huaweicloud-sdk-go-v3/core/sdkerr"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/utils"
 )
 
+// MaxErrorDetailLength is the maximum length of the error response body exposed in the events.
+const MaxErrorDetailLength = 512
+
 const (
 	DefaultInitDelay = 2 * time.Second
 	DefaultFactor    = 1.02
@@ -44,12 +57,135 @@ func IsNotFound(err error) bool {
 	return false
 }
 
+// GetStatusCode returns the HTTP status code of the API error, 0 is returned if it is not an API error.
+func GetStatusCode(err error) int {
+	if e, ok := err.(sdkerr.ServiceResponseError); ok {
+		return e.StatusCode
+	}
+	if e, ok := err.(*sdkerr.ServiceResponseError); ok {
+		return e.StatusCode
+	}
+	return 0
+}
+
+// GetErrorCode returns the error code of the API error, such as "ELB.8902",
+// an empty string is returned if it is not an API error or the response has no error code.
+func GetErrorCode(err error) string {
+	if e, ok := err.(sdkerr.ServiceResponseError); ok {
+		return e.ErrorCode
+	}
+	if e, ok := err.(*sdkerr.ServiceResponseError); ok {
+		return e.ErrorCode
+	}
+	return ""
+}
+
+// GetRequestID returns the X-Request-Id of the failed API request, which identifies the request
+// to Huawei Cloud support, an empty string is returned if it is not an API error.
+func GetRequestID(err error) string {
+	if e, ok := err.(sdkerr.ServiceResponseError); ok {
+		return e.RequestId
+	}
+	if e, ok := err.(*sdkerr.ServiceResponseError); ok {
+		return e.RequestId
+	}
+	return ""
+}
+
+// APIErrorDetail returns the error code, the sanitized message, the status code and the request ID of the API error
+// in the chain of the error, false is returned if there is no API error.
+func APIErrorDetail(err error) (string, bool) {
+	var e *sdkerr.ServiceResponseError
+	var v sdkerr.ServiceResponseError
+	switch {
+	case errors.As(err, &e):
+	case errors.As(err, &v):
+		e = &v
+	default:
+		return "", false
+	}
+	return fmt.Sprintf("%s %s (status code: %d, request ID: %s)", e.ErrorCode, SanitizeErrorBody(e.ErrorMessage),
+		e.StatusCode, e.RequestId), true
+}
+
+// SanitizeErrorBody masks the secrets in the error response body, and truncates it to MaxErrorDetailLength.
+func SanitizeErrorBody(body string) string {
+	body = utils.ScrubSecrets(strings.TrimSpace(body))
+	if len(body) <= MaxErrorDetailLength {
+		return body
+	}
+	n := MaxErrorDetailLength
+	for n > 0 && !utf8.RuneStart(body[n]) {
+		n--
+	}
+	return body[:n] + "..."
+}
+
+// quotaExceededRegexp matches the messages of the quota-exceeded errors of the ELB, EIP, VPC and NAT APIs,
+// such as "Quota exceeded for resources: ['listener']." and "The EIP quota is insufficient.".
+var (
+	quotaExceededRegexp = regexp.MustCompile(`(?i)quota\s+(?:exceeded|is\s+(?:insufficient|not\s+enough|exhausted))|` +
+		`exceed(?:s|ed)?\s+(?:the\s+)?quota|insufficient\s+quota`)
+	quotaResourceRegexp = regexp.MustCompile(`resources?:\s*\[?'?([A-Za-z_]+)`)
+	quotaNamedRegexp    = regexp.MustCompile(`(?i)\b(listener|member|pool|healthmonitor|loadbalancer|eip|publicip|` +
+		`floatingip|bandwidth|dnat_rule|snat_rule|route|security_group)s?\b`)
+)
+
+// QuotaExceededResource returns the resource whose quota is exceeded according to the error message,
+// such as "listener", or "unknown" if the message does not name it. False is returned if it is not
+// a quota-exceeded error.
+func QuotaExceededResource(message string) (string, bool) {
+	if !quotaExceededRegexp.MatchString(message) {
+		return "", false
+	}
+	if m := quotaResourceRegexp.FindStringSubmatch(message); m != nil {
+		return strings.ToLower(m[1]), true
+	}
+	if m := quotaNamedRegexp.FindStringSubmatch(message); m != nil {
+		return strings.ToLower(m[1]), true
+	}
+	return "unknown", true
+}
+
+// IsQuotaExceeded returns the resource whose quota is exceeded if the API error in the chain of the error
+// is a quota-exceeded error.
+func IsQuotaExceeded(err error) (string, bool) {
+	var e *sdkerr.ServiceResponseError
+	var v sdkerr.ServiceResponseError
+	switch {
+	case errors.As(err, &e):
+	case errors.As(err, &v):
+		e = &v
+	default:
+		return "", false
+	}
+	return QuotaExceededResource(e.ErrorMessage)
+}
+
+// IsUnreachable returns whether the error is caused by an unreachable API gateway, i.e. a network error,
+// or a bad gateway or gateway timeout response.
+func IsUnreachable(err error) bool {
+	switch GetStatusCode(err) {
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return true
+	case 0:
+		var netErr net.Error
+		return errors.As(err, &netErr)
+	}
+	return false
+}
+
 // WaitForCompleted wait for completion, interval 2s+, up to 30 pols
 func WaitForCompleted(condition wait.ConditionFunc) error {
+	return WaitForCompletedWithContext(context.Background(), condition)
+}
+
+// WaitForCompletedWithContext is WaitForCompleted stopped with the error of the context once it is done.
+func WaitForCompletedWithContext(ctx context.Context, condition wait.ConditionFunc) error {
 	backoff := wait.Backoff{
 		Duration: DefaultInitDelay,
 		Factor:   DefaultFactor,
 		Steps:    DefaultSteps,
 	}
-	return wait.ExponentialBackoff(backoff, condition)
+	return wait.ExponentialBackoffWithContext(ctx, backoff, condition)
 }
@@ -17,8 +17,14 @@ limitations under the License.
 package common
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/huaweicloud/huaweicloud-sdk-go-v3/core/sdkerr"
 	"google.golang.org/grpc/codes"
@@ -74,6 +80,226 @@ func TestIsNotFound(t *testing.T) {
 	}
 }
 
+func TestGetStatusCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{
+			name:     "test1",
+			err:      sdkerr.ServiceResponseError{StatusCode: 401},
+			expected: 401,
+		},
+		{
+			name:     "test2",
+			err:      &sdkerr.ServiceResponseError{StatusCode: 400},
+			expected: 400,
+		},
+		{
+			name:     "test3",
+			err:      status.Error(codes.NotFound, "not found"),
+			expected: 0,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			code := GetStatusCode(testCase.err)
+			if code != testCase.expected {
+				t.Fatalf("expected: %v, got : %v", testCase.expected, code)
+			}
+		})
+	}
+}
+
+func TestGetErrorCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "service response error",
+			err:      &sdkerr.ServiceResponseError{StatusCode: 400, ErrorCode: "ELB.8902"},
+			expected: "ELB.8902",
+		},
+		{
+			name:     "other error",
+			err:      fmt.Errorf("decode failed"),
+			expected: "",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := GetErrorCode(testCase.err); got != testCase.expected {
+				t.Fatalf("expected: %v, got : %v", testCase.expected, got)
+			}
+		})
+	}
+}
+
+func TestGetRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "service response error",
+			err:      sdkerr.ServiceResponseError{StatusCode: 404, RequestId: "0a1b2c3d"},
+			expected: "0a1b2c3d",
+		},
+		{
+			name:     "other error",
+			err:      fmt.Errorf("decode failed"),
+			expected: "",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := GetRequestID(testCase.err); got != testCase.expected {
+				t.Fatalf("expected: %v, got : %v", testCase.expected, got)
+			}
+		})
+	}
+}
+
+func TestAPIErrorDetail(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+		ok       bool
+	}{
+		{
+			name: "wrapped service response error",
+			err: fmt.Errorf("error creating pool: %w", &sdkerr.ServiceResponseError{StatusCode: 400,
+				ErrorCode: "ELB.8902", ErrorMessage: "Invalid subnet", RequestId: "0a1b2c3d"}),
+			expected: "ELB.8902 Invalid subnet (status code: 400, request ID: 0a1b2c3d)",
+			ok:       true,
+		},
+		{
+			name:     "other error",
+			err:      fmt.Errorf("decode failed"),
+			expected: "",
+			ok:       false,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, ok := APIErrorDetail(testCase.err)
+			if got != testCase.expected || ok != testCase.ok {
+				t.Fatalf("expected: %v, %v, got : %v, %v", testCase.expected, testCase.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestIsQuotaExceeded(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		resource string
+		ok       bool
+	}{
+		{
+			name: "listener quota",
+			err: fmt.Errorf("error creating listener: %w", &sdkerr.ServiceResponseError{StatusCode: 409,
+				ErrorCode: "ELB.8905", ErrorMessage: "Quota exceeded for resources: ['listener']."}),
+			resource: "listener",
+			ok:       true,
+		},
+		{
+			name: "eip quota",
+			err: &sdkerr.ServiceResponseError{StatusCode: 409, ErrorCode: "EIP.8910",
+				ErrorMessage: "The EIP quota is insufficient."},
+			resource: "eip",
+			ok:       true,
+		},
+		{
+			name: "unnamed quota",
+			err: sdkerr.ServiceResponseError{StatusCode: 403, ErrorCode: "APIGW.0308",
+				ErrorMessage: "The request exceeds the quota"},
+			resource: "unknown",
+			ok:       true,
+		},
+		{
+			name: "other API error",
+			err: &sdkerr.ServiceResponseError{StatusCode: 400, ErrorCode: "ELB.8902",
+				ErrorMessage: "Invalid subnet"},
+			ok: false,
+		},
+		{
+			name: "other error",
+			err:  fmt.Errorf("quota exceeded"),
+			ok:   false,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			resource, ok := IsQuotaExceeded(testCase.err)
+			if resource != testCase.resource || ok != testCase.ok {
+				t.Fatalf("expected: %v, %v, got : %v, %v", testCase.resource, testCase.ok, resource, ok)
+			}
+		})
+	}
+}
+
+func TestSanitizeErrorBody(t *testing.T) {
+	body := SanitizeErrorBody(`{"password": "secret"} ` + strings.Repeat("配额", MaxErrorDetailLength))
+	if !strings.HasPrefix(body, `{"password": "***"}`) {
+		t.Fatalf("expected the password to be masked, got: %s", body)
+	}
+	if len(body) > MaxErrorDetailLength+len("...") || !utf8.ValidString(body) {
+		t.Fatalf("expected the body to be truncated at a rune boundary, got: %s", body)
+	}
+}
+
+func TestIsUnreachable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "bad gateway",
+			err:      &sdkerr.ServiceResponseError{StatusCode: 502},
+			expected: true,
+		},
+		{
+			name:     "bad request",
+			err:      &sdkerr.ServiceResponseError{StatusCode: 400},
+			expected: false,
+		},
+		{
+			name: "connection refused",
+			err: fmt.Errorf("http client do request error. %w", &url.Error{
+				Op: "Get", URL: "https://ecs.ap-southeast-1.myhuaweicloud.com",
+				Err: &net.OpError{Op: "dial", Net: "tcp", Err: fmt.Errorf("connection refused")},
+			}),
+			expected: true,
+		},
+		{
+			name:     "other error",
+			err:      fmt.Errorf("decode failed"),
+			expected: false,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := IsUnreachable(testCase.err); got != testCase.expected {
+				t.Fatalf("expected: %v, got : %v", testCase.expected, got)
+			}
+		})
+	}
+}
+
 func TestWaitForCompleted(t *testing.T) {
 	count := 0
 	tests := []struct {
@@ -112,3 +338,16 @@ func TestWaitForCompleted(t *testing.T) {
 		})
 	}
 }
+
+func TestWaitForCompletedWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	count := 0
+	err := WaitForCompletedWithContext(ctx, func() (bool, error) {
+		count++
+		cancel()
+		return false, nil
+	})
+	if !errors.Is(err, context.Canceled) || count != 1 {
+		t.Fatalf("expected to stop with the error of the context after 1 poll, got: %v after %d polls", err, count)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// The defaults of the [Concurrency] section.
+const (
+	DefaultServiceWorkers      = 2
+	DefaultRequestsPerEndpoint = 10
+	DefaultNodeUpdateWindow    = 10
+	DefaultShutdownGracePeriod = 30
+)
+
+// ConcurrencyOptions limits the parallelism of the reconciles and the requests to the cloud APIs.
+type ConcurrencyOptions struct {
+	// ServiceWorkers is the number of the Services updated in parallel on the endpoints and node events.
+	ServiceWorkers int `gcfg:"service-workers"`
+	// RequestsPerEndpoint is the maximum number of the in-flight requests to an endpoint of the cloud APIs,
+	// the other requests wait for a slot.
+	RequestsPerEndpoint int `gcfg:"requests-per-endpoint"`
+	// NodeUpdateWindow is the window in seconds to coalesce the node changes into one update of each Service,
+	// such as the nodes flapping between Ready and NotReady, or added and removed in a scaling.
+	NodeUpdateWindow int `gcfg:"node-update-window"`
+	// ShutdownGracePeriod is the period in seconds the in-flight reconciles are waited for to finish when the cloud
+	// provider is stopped, the remaining ones are canceled then.
+	ShutdownGracePeriod int `gcfg:"shutdown-grace-period"`
+}
+
+// GetNodeUpdateWindow returns the window to coalesce the node changes.
+func (o *ConcurrencyOptions) GetNodeUpdateWindow() time.Duration {
+	return time.Duration(o.NodeUpdateWindow) * time.Second
+}
+
+// GetShutdownGracePeriod returns the period the in-flight reconciles are waited for on stop.
+func (o *ConcurrencyOptions) GetShutdownGracePeriod() time.Duration {
+	return time.Duration(o.ShutdownGracePeriod) * time.Second
+}
+
+// endpointLimiter limits the in-flight requests to each endpoint, it is shared by the copies of the options.
+type endpointLimiter struct {
+	limit int
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+func newEndpointLimiter(limit int) *endpointLimiter {
+	return &endpointLimiter{limit: limit, slots: make(map[string]chan struct{})}
+}
+
+// acquire waits for a slot of the endpoint, and returns the function to release it.
+// It stops waiting once the context is done, the request is aborted by the context then.
+func (l *endpointLimiter) acquire(ctx context.Context, endpoint string) func() {
+	// The endpoints are keyed by host, the clients of a service may differ in the scheme or the path.
+	key := endpoint
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		key = u.Host
+	}
+
+	l.mu.Lock()
+	slots, ok := l.slots[key]
+	if !ok {
+		slots = make(chan struct{}, l.limit)
+		l.slots[key] = slots
+	}
+	l.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }
+	case <-ctx.Done():
+		return func() {}
+	}
+}
+
+// AcquireEndpoint waits until the number of the in-flight requests to the endpoint is under the limit,
+// or the context of the options is done, and returns the function to call once the request is completed.
+func (a *AuthOptions) AcquireEndpoint(endpoint string) (release func()) {
+	if a.endpointLimiter == nil {
+		return func() {}
+	}
+	return a.endpointLimiter.acquire(a.Context(), endpoint)
+}
@@ -17,31 +17,633 @@ limitations under the License.
 package config
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/huaweicloud/huaweicloud-sdk-go-v3/core"
+	"github.com/huaweicloud/huaweicloud-sdk-go-v3/core/auth"
 	"github.com/huaweicloud/huaweicloud-sdk-go-v3/core/auth/basic"
+	"github.com/huaweicloud/huaweicloud-sdk-go-v3/core/auth/global"
 	sdkconfig "github.com/huaweicloud/huaweicloud-sdk-go-v3/core/config"
 	"github.com/huaweicloud/huaweicloud-sdk-go-v3/core/httphandler"
 	"github.com/huaweicloud/huaweicloud-sdk-go-v3/core/region"
+	"golang.org/x/net/http/httpproxy"
 	"gopkg.in/gcfg.v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/klog/v2"
 
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/audit"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/metrics"
 	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/utils"
 )
 
+// The environment variables to override the credentials and region of the [Global] section.
+const (
+	EnvAccessKey     = "HUAWEICLOUD_ACCESS_KEY"
+	EnvSecretKey     = "HUAWEICLOUD_SECRET_KEY"
+	EnvSecurityToken = "HUAWEICLOUD_SECURITY_TOKEN"
+	EnvRegion        = "HUAWEICLOUD_REGION"
+)
+
+// The defaults of the [CSMS] section.
+const (
+	DefaultCSMSVersionID       = "latest"
+	DefaultCSMSRefreshInterval = 300
+)
+
+// The defaults of the [CredentialProvider] section.
+const (
+	DefaultCredentialProviderTimeout         = 30
+	DefaultCredentialProviderRefreshInterval = 300
+)
+
+// DefaultHealthCheckInterval is the default interval in seconds to probe the cloud.
+const DefaultHealthCheckInterval = 60
+
+// DefaultQuotaInterval is the default interval in seconds to query the quotas.
+const DefaultQuotaInterval = 300
+
+// DefaultEventDedupWindow is the default window in seconds to aggregate the identical events.
+const DefaultEventDedupWindow = 600
+
+// DefaultReloadInterval is the default interval in seconds to check the touch file of the reload.
+const DefaultReloadInterval = 10
+
+// DefaultTracingSamplingRatePerMillion samples all the reconciles.
+const DefaultTracingSamplingRatePerMillion = 1000000
+
+// The defaults of the [Secret] section.
+const (
+	DefaultSecretNamespace = "kube-system"
+	DefaultAccessKeyKey    = "access-key"
+	DefaultSecretKeyKey    = "secret-key"
+
+	DefaultSecondaryAccessKeyKey = "secondary-access-key"
+	DefaultSecondarySecretKeyKey = "secondary-secret-key"
+)
+
+// regionRegexp matches the region names, such as "cn-north-4" and "ap-southeast-1".
+var regionRegexp = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// clusterIDRegexp matches the id of the Cluster section, which is a part of the names of the cloud resources.
+var clusterIDRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// apiVersionRegexp matches the API versions, such as "v2" and "v1.0".
+var apiVersionRegexp = regexp.MustCompile(`^v[0-9]+(\.[0-9]+)?$`)
+
+// defaultCloud is the domain name of the public cloud.
+const defaultCloud = "myhuaweicloud.com"
+
+// globalServices are the catalog names of the global services, their endpoints are not regional,
+// such as "https://ga.{cloud}", and their requests are signed with the account.
+var globalServices = sets.NewString("ga")
+
+// optionalServices are the catalog names of the services which can be absent from a region.
+var optionalServices = sets.NewString("elb", "nat", "er", "cce", "kms", "dns")
+
+const (
+	// RouteTypeVPC programs the pod CIDR routes into the VPC route tables, the next hop is the ECS of the node.
+	RouteTypeVPC = "vpc"
+	// RouteTypeER programs the pod CIDR routes into the Enterprise Router route table,
+	// the next hop is the VPC attachment of the cluster.
+	RouteTypeER = "er"
+)
+
+// DefaultNodeCIDRMaskSize is the default prefix length of the pod CIDRs allocated to the nodes, the limits
+// leave at least 4 addresses to a node.
+const (
+	DefaultNodeCIDRMaskSize = 24
+	minNodeCIDRMaskSize     = 8
+	maxNodeCIDRMaskSize     = 30
+)
+
+const (
+	// LoadBalancerBackendELB serves the load balancers with the native ELB APIs.
+	LoadBalancerBackendELB = "elb"
+	// LoadBalancerBackendOctavia serves the load balancers with the OpenStack Octavia compatible APIs,
+	// for the private clouds only exposing them.
+	LoadBalancerBackendOctavia = "octavia"
+)
+
 // CloudConfig define
 type CloudConfig struct {
 	AuthOpts AuthOptions `gcfg:"Global"`
 	VpcOpts  VpcOptions  `gcfg:"Vpc"`
+
+	ClusterOpts ClusterOptions `gcfg:"Cluster"`
+	SecretOpts  SecretOptions  `gcfg:"Secret"`
+	CSMSOpts    CSMSOptions    `gcfg:"CSMS"`
+
+	CredentialProviderOpts CredentialProviderOptions `gcfg:"CredentialProvider"`
+
+	TracingOpts TracingOptions `gcfg:"Tracing"`
+	HealthOpts  HealthOptions  `gcfg:"Health"`
+	AuditOpts   AuditOptions   `gcfg:"Audit"`
+	EventOpts   EventOptions   `gcfg:"Events"`
+	QuotaOpts   QuotaOptions   `gcfg:"Quota"`
+	ReloadOpts  ReloadOptions  `gcfg:"Reload"`
+
+	ConcurrencyOpts   ConcurrencyOptions   `gcfg:"Concurrency"`
+	SecurityGroupOpts SecurityGroupOptions `gcfg:"SecurityGroup"`
+
+	// LoadBalancerOpts and NetworkingOpts are the conventional sections of the cloud-config shared with the other
+	// providers, they provide the defaults of the loadbalancer-config ConfigMap.
+	LoadBalancerOpts LoadBalancerSection `gcfg:"LoadBalancer"`
+	NetworkingOpts   NetworkingSection   `gcfg:"Networking"`
+
+	// Regions holds the additional regions of the cluster, declared by the [Region "<name>"] sections.
+	Regions map[string]*RegionOptions `gcfg:"Region"`
+
+	// Projects holds the additional projects of the load balancers and EIPs, declared by the [Project "<name>"]
+	// sections.
+	Projects map[string]*ProjectOptions `gcfg:"Project"`
+
+	// Endpoints holds the endpoint overrides of the services in the [Global] region, declared by
+	// the [Endpoint "<catalog name>"] sections, such as [Endpoint "elb"].
+	Endpoints map[string]*EndpointOptions `gcfg:"Endpoint"`
+}
+
+// EndpointOptions overrides the endpoint of a service and pins its API version.
+type EndpointOptions struct {
+	URL string `gcfg:"url"`
+	// FallbackURLs are the endpoints the requests fail over to in order when the endpoint in use is unreachable,
+	// the key can be repeated.
+	FallbackURLs []string `gcfg:"fallback-url"`
+	// APIVersion pins the API version of the clients building the request paths, i.e. the classic load balancer
+	// client of the elb service, the other clients use the API versions of the SDK.
+	APIVersion string `gcfg:"api-version"`
+	// RequestTimeout overrides the request-timeout of the [Global] section for the requests to the service,
+	// in seconds.
+	RequestTimeout int `gcfg:"request-timeout"`
+}
+
+// ProjectOptions overrides the project and Vpc options for the load balancers of an additional project
+// in the same region.
+type ProjectOptions struct {
+	ProjectID string `gcfg:"project-id"`
+	VpcID     string `gcfg:"vpc-id"`
+	SubnetID  string `gcfg:"subnet-id"`
+	// Namespaces are the namespaces whose services are created in the project by default, the key can be repeated.
+	Namespaces []string `gcfg:"namespace"`
+}
+
+// RegionOptions overrides the Global and Vpc options for an additional region.
+type RegionOptions struct {
+	Cloud     string `gcfg:"cloud"`
+	ProjectID string `gcfg:"project-id"`
+	VpcID     string `gcfg:"vpc-id"`
+	SubnetID  string `gcfg:"subnet-id"`
+}
+
+// ForRegion returns a copy of the configuration bound to the additional region.
+func (c *CloudConfig) ForRegion(name string) (*CloudConfig, error) {
+	opts, ok := c.Regions[name]
+	if !ok || opts == nil {
+		return nil, fmt.Errorf("region %s is not configured", name)
+	}
+
+	cfg := &CloudConfig{
+		AuthOpts: c.AuthOpts,
+		VpcOpts: VpcOptions{
+			ID:       opts.VpcID,
+			SubnetID: opts.SubnetID,
+		},
+	}
+	cfg.AuthOpts.Region = name
+	cfg.AuthOpts.ProjectID = opts.ProjectID
+	cfg.AuthOpts.ProjectName = ""
+	cfg.AuthOpts.endpoints = nil
+	// The endpoint URLs are bound to the [Global] region, the API versions and the timeouts are kept.
+	cfg.AuthOpts.endpointOverrides = make(map[string]*EndpointOptions, len(c.AuthOpts.endpointOverrides))
+	for name, override := range c.AuthOpts.endpointOverrides {
+		cfg.AuthOpts.endpointOverrides[name] = &EndpointOptions{
+			APIVersion:     override.APIVersion,
+			RequestTimeout: override.RequestTimeout,
+		}
+	}
+	if opts.Cloud != "" {
+		cfg.AuthOpts.Cloud = opts.Cloud
+	}
+	return cfg, nil
+}
+
+// ForProject returns a copy of the configuration bound to the additional project.
+func (c *CloudConfig) ForProject(name string) (*CloudConfig, error) {
+	opts, ok := c.Projects[name]
+	if !ok || opts == nil {
+		return nil, fmt.Errorf("project %s is not configured", name)
+	}
+
+	cfg := &CloudConfig{
+		AuthOpts:    c.AuthOpts,
+		VpcOpts:     c.VpcOpts,
+		ClusterOpts: c.ClusterOpts,
+	}
+	cfg.AuthOpts.ProjectID = opts.ProjectID
+	cfg.AuthOpts.ProjectName = ""
+	if opts.VpcID != "" {
+		cfg.VpcOpts.ID = opts.VpcID
+	}
+	if opts.SubnetID != "" {
+		cfg.VpcOpts.SubnetID = opts.SubnetID
+	}
+	return cfg, nil
+}
+
+// ProjectOfNamespace returns the name of the project mapped to the namespace, empty if not mapped.
+func (c *CloudConfig) ProjectOfNamespace(namespace string) string {
+	for name, opts := range c.Projects {
+		if opts == nil {
+			continue
+		}
+		for _, ns := range opts.Namespaces {
+			if ns == namespace {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// EndpointErrors returns the errors of the [Endpoint] sections keyed by catalog name. They are not validated by
+// Validate, the features depending on a misconfigured endpoint are disabled instead of failing the startup.
+func (c *CloudConfig) EndpointErrors() map[string]error {
+	errs := make(map[string]error)
+	for name, opts := range c.Endpoints {
+		if opts == nil {
+			continue
+		}
+		if err := validateURL("url", opts.URL); err != nil {
+			errs[name] = fmt.Errorf("%s in [Endpoint %q] section", err, name)
+			continue
+		}
+		for _, fallback := range opts.FallbackURLs {
+			if err := validateURL("fallback-url", fallback); err != nil || fallback == "" {
+				errs[name] = fmt.Errorf("invalid fallback-url %q in [Endpoint %q] section", fallback, name)
+				break
+			}
+		}
+		if _, ok := errs[name]; !ok && opts.APIVersion != "" && !apiVersionRegexp.MatchString(opts.APIVersion) {
+			errs[name] = fmt.Errorf("invalid api-version %q in [Endpoint %q] section, expected a version such as \"v2\"",
+				opts.APIVersion, name)
+		}
+		if _, ok := errs[name]; !ok && opts.RequestTimeout < 0 {
+			errs[name] = fmt.Errorf("invalid request-timeout %d in [Endpoint %q] section, expected a positive number "+
+				"of seconds", opts.RequestTimeout, name)
+		}
+	}
+	return errs
+}
+
+// Validate checks the region and endpoint settings, so that a misconfiguration fails at startup
+// rather than sending requests to the endpoints of another region.
+func (c *CloudConfig) Validate() error {
+	if err := c.AuthOpts.validate(c.SecretOpts.Name != "" || c.CredentialProviderOpts.Command != ""); err != nil {
+		return err
+	}
+	if err := c.VpcOpts.validate(); err != nil {
+		return err
+	}
+	if c.CSMSOpts.SecretName != "" && c.SecretOpts.Name != "" {
+		return fmt.Errorf("secret-name in [CSMS] section and name in [Secret] section are mutually exclusive")
+	}
+	if c.CredentialProviderOpts.Command != "" && (c.SecretOpts.Name != "" || c.CSMSOpts.SecretName != "") {
+		return fmt.Errorf("command in [CredentialProvider] section is mutually exclusive with the [Secret] " +
+			"and [CSMS] sections")
+	}
+	for _, env := range c.CredentialProviderOpts.Env {
+		if name, _, ok := strings.Cut(env, "="); !ok || name == "" {
+			return fmt.Errorf("invalid env %q in [CredentialProvider] section, expected \"<name>=<value>\"", env)
+		}
+	}
+	if c.CSMSOpts.SecretName != "" && !c.AuthOpts.ServiceSupported("kms") {
+		return fmt.Errorf("secret-name in [CSMS] section requires the kms service, which is an unsupported-service")
+	}
+	if c.VpcOpts.RouteType == RouteTypeER && !c.AuthOpts.ServiceSupported("er") {
+		return fmt.Errorf("route-type %s in [Vpc] section requires the er service, which is an unsupported-service",
+			RouteTypeER)
+	}
+	if c.TracingOpts.SamplingRatePerMillion > DefaultTracingSamplingRatePerMillion {
+		return fmt.Errorf("sampling-rate-per-million in [Tracing] section must not be greater than %d",
+			DefaultTracingSamplingRatePerMillion)
+	}
+	if addr := c.HealthOpts.BindAddress; addr != "" {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return fmt.Errorf("invalid bind-address %q in [Health] section, expected \"<host>:<port>\"", addr)
+		}
+	}
+	if err := validateURL("master-endpoint", c.ClusterOpts.MasterEndpoint); err != nil {
+		return fmt.Errorf("%s in [Cluster] section", err)
+	}
+	if id := c.ClusterOpts.ID; id != "" && !clusterIDRegexp.MatchString(id) {
+		return fmt.Errorf("invalid id %q in [Cluster] section, expected at most 64 letters, digits, "+
+			"hyphens, underscores and dots", id)
+	}
+	if err := c.SecurityGroupOpts.validate(); err != nil {
+		return err
+	}
+
+	if err := c.ValidateLoadBalancer(); err != nil {
+		return err
+	}
+
+	for name, opts := range c.Regions {
+		if !regionRegexp.MatchString(name) {
+			return fmt.Errorf("invalid region name %q in [Region] section", name)
+		}
+		if name == c.AuthOpts.Region {
+			return fmt.Errorf("region %s in [Region] section is the same as the region in [Global] section", name)
+		}
+		if opts == nil || opts.ProjectID == "" {
+			return fmt.Errorf("project-id is required in [Region %q] section", name)
+		}
+		if err := validateCloud(opts.Cloud, name); opts.Cloud != "" && err != nil {
+			return err
+		}
+	}
+
+	namespaces := make(map[string]string)
+	for name, opts := range c.Projects {
+		if opts == nil || opts.ProjectID == "" {
+			return fmt.Errorf("project-id is required in [Project %q] section", name)
+		}
+		for _, ns := range opts.Namespaces {
+			if p, ok := namespaces[ns]; ok {
+				return fmt.Errorf("namespace %s is mapped to both project %s and %s", ns, p, name)
+			}
+			namespaces[ns] = name
+		}
+	}
+	return nil
+}
+
+func (v *VpcOptions) validate() error {
+	switch v.RouteType {
+	case RouteTypeVPC:
+		if v.ERPropagation && (v.ERRouteTableID == "" || v.ERAttachmentID == "") {
+			return fmt.Errorf("er-route-table-id and er-attachment-id are required in [Vpc] section " +
+				"when er-propagation is enabled")
+		}
+	case RouteTypeER:
+		if v.ERRouteTableID == "" || v.ERAttachmentID == "" {
+			return fmt.Errorf("er-route-table-id and er-attachment-id are required in [Vpc] section "+
+				"when route-type is %s", RouteTypeER)
+		}
+	default:
+		return fmt.Errorf("invalid route-type %q in [Vpc] section, expected %s or %s",
+			v.RouteType, RouteTypeVPC, RouteTypeER)
+	}
+	if v.NodeCIDRMaskSize < minNodeCIDRMaskSize || v.NodeCIDRMaskSize > maxNodeCIDRMaskSize {
+		return fmt.Errorf("invalid node-cidr-mask-size %d in [Vpc] section, expected %d to %d",
+			v.NodeCIDRMaskSize, minNodeCIDRMaskSize, maxNodeCIDRMaskSize)
+	}
+	return nil
+}
+
+// validate checks the [Global] section, the credentials are not required if they are read from a secret.
+func (a *AuthOptions) validate(fromSecret bool) error {
+	if a.Region == "" {
+		return fmt.Errorf("region is required in [Global] section")
+	}
+	if !regionRegexp.MatchString(a.Region) {
+		return fmt.Errorf("invalid region %q in [Global] section", a.Region)
+	}
+	if !fromSecret && !a.UseAgency && (a.AccessKey == "" || a.SecretKey == "") {
+		return fmt.Errorf("access-key and secret-key are required in [Global] section or the environment variables "+
+			"%s and %s", EnvAccessKey, EnvSecretKey)
+	}
+	if err := validateCloud(a.Cloud, a.Region); err != nil {
+		return err
+	}
+	if a.HCS && strings.TrimSpace(a.Cloud) == defaultCloud {
+		return fmt.Errorf("cloud is required in [Global] section in the HCS mode")
+	}
+	if a.ProjectName != "" && a.ProjectID != "" {
+		return fmt.Errorf("project-id and project-name in [Global] section are mutually exclusive")
+	}
+	if a.ProjectName != "" && a.DomainID == "" {
+		return fmt.Errorf("domain-id is required in [Global] section to resolve the project-name")
+	}
+	for _, s := range a.UnsupportedServices {
+		if !optionalServices.Has(s) {
+			return fmt.Errorf("invalid unsupported-service %q in [Global] section, expected one of %v",
+				s, optionalServices.List())
+		}
+	}
+
+	urls := []struct{ key, value string }{
+		{"auth-url", a.AuthURL},
+		{"http-proxy", a.HTTPProxy},
+		{"https-proxy", a.HTTPSProxy},
+	}
+	for _, u := range urls {
+		if err := validateURL(u.key, u.value); err != nil {
+			return fmt.Errorf("%s in [Global] section", err)
+		}
+	}
+	return nil
+}
+
+// validateURL checks the value is an absolute HTTP or HTTPS URL, an empty value is valid.
+func validateURL(key, value string) error {
+	if value == "" {
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid %s %q, expected an URL such as \"https://host:port\"", key, value)
+	}
+	return nil
+}
+
+// validateCloud checks the cloud is a domain name, because the endpoints are derived from it,
+// such as "https://ecs.{region}.{cloud}".
+func validateCloud(cloud, region string) error {
+	cloud = strings.TrimSpace(cloud)
+	if strings.Contains(cloud, "://") || strings.Contains(cloud, "/") {
+		return fmt.Errorf("invalid cloud %q, expected a domain name such as \"myhuaweicloud.com\"", cloud)
+	}
+	if strings.HasPrefix(cloud, region+".") || strings.Contains(cloud, "."+region+".") {
+		return fmt.Errorf("invalid cloud %q, it should not contain the region %s, "+
+			"the endpoints are derived from the region", cloud, region)
+	}
+	return nil
 }
 
 type VpcOptions struct {
 	ID       string `gcfg:"id"`
 	SubnetID string `gcfg:"subnet-id"`
+
+	// RouteTableIDs specifies the route tables to program the pod CIDR routes, the key can be repeated.
+	// The route tables in use of the VPC are discovered if it is empty.
+	RouteTableIDs []string `gcfg:"route-table-id"`
+
+	// RouteType specifies where to program the pod CIDR routes, "vpc" or "er", defaults to "vpc".
+	RouteType      string `gcfg:"route-type"`
+	ERRouteTableID string `gcfg:"er-route-table-id"`
+	ERAttachmentID string `gcfg:"er-attachment-id"`
+	// ERPropagation specifies whether to program the routes into the ER route table as well when route-type is "vpc".
+	ERPropagation bool `gcfg:"er-propagation"`
+
+	// PodSubnetIDs specifies the subnets of the VPC the pod CIDRs of the nodes are allocated from by the cloud
+	// allocator, the key can be repeated.
+	PodSubnetIDs []string `gcfg:"pod-subnet-id"`
+	// NodeCIDRMaskSize is the prefix length of the pod CIDRs of the nodes, defaults to 24.
+	NodeCIDRMaskSize int `gcfg:"node-cidr-mask-size"`
+}
+
+// LoadBalancerSection is the [LoadBalancer] section of the cloud-config, see LoadBalancerOptions.
+type LoadBalancerSection struct {
+	// Backend specifies the APIs serving the load balancers, "elb" or "octavia", defaults to "elb".
+	Backend     string `gcfg:"backend"`
+	LBAlgorithm string `gcfg:"lb-algorithm"`
+	// LBMethod is an alias of lb-algorithm.
+	LBMethod   string `gcfg:"lb-method"`
+	LBProvider string `gcfg:"lb-provider"`
+	// SubnetID is used as the subnet-id of the [Vpc] section if it is absent.
+	SubnetID string `gcfg:"subnet-id"`
+	KeepEIP  bool   `gcfg:"keep-eip"`
+
+	EnableCrossVpc bool   `gcfg:"enable-cross-vpc"`
+	L4FlavorID     string `gcfg:"l4-flavor-id"`
+	L7FlavorID     string `gcfg:"l7-flavor-id"`
+	// EnableAutoscaling and MinL7FlavorID are the options of the elastic flavors, see LoadBalancerOptions.
+	EnableAutoscaling bool   `gcfg:"enable-autoscaling"`
+	MinL7FlavorID     string `gcfg:"min-l7-flavor-id"`
+
+	SessionAffinityFlag string `gcfg:"session-affinity-flag"`
+	HealthCheckFlag     string `gcfg:"health-check-flag"`
+
+	IdleTimeout     int `gcfg:"idle-timeout"`
+	RequestTimeout  int `gcfg:"request-timeout"`
+	ResponseTimeout int `gcfg:"response-timeout"`
+
+	// DefaultAnnotations are the annotations applied to the LoadBalancer services without them,
+	// in the form of "<key>=<value>", the key can be repeated.
+	DefaultAnnotations []string `gcfg:"default-annotation"`
+}
+
+// NetworkingSection is the [Networking] section of the cloud-config, see NetworkingOptions.
+type NetworkingSection struct {
+	PublicNetworkName   []string `gcfg:"public-network-name"`
+	InternalNetworkName []string `gcfg:"internal-network-name"`
+	AddressTypeOrder    []string `gcfg:"address-type-order"`
+	ExcludeEIP          bool     `gcfg:"exclude-eip"`
+	InternalSubnetIDs   []string `gcfg:"internal-subnet-id"`
+}
+
+// SecretOptions specifies the secret to read the credentials from, instead of the [Global] section.
+type SecretOptions struct {
+	Name      string `gcfg:"name"`
+	Namespace string `gcfg:"namespace"`
+	// AccessKeyKey, SecretKeyKey and CredentialKey are the data keys of the access key, secret key
+	// and the temporary security credential.
+	AccessKeyKey  string `gcfg:"access-key-key"`
+	SecretKeyKey  string `gcfg:"secret-key-key"`
+	CredentialKey string `gcfg:"credential-key"`
+	// SecondaryAccessKeyKey and SecondarySecretKeyKey are the data keys of the secondary access key and secret key,
+	// which are used when the primary ones are rejected during the rotation.
+	SecondaryAccessKeyKey string `gcfg:"secondary-access-key-key"`
+	SecondarySecretKeyKey string `gcfg:"secondary-secret-key-key"`
+}
+
+// CSMSOptions specifies the secret of the Cloud Secret Management Service to read the credentials from,
+// the secret is read with the credentials of the [Global] section, such as the agency of the ECS.
+type CSMSOptions struct {
+	SecretName string `gcfg:"secret-name"`
+	VersionID  string `gcfg:"version-id"`
+	// AccessKeyKey and SecretKeyKey are the keys of the access key and secret key in the JSON secret string.
+	AccessKeyKey string `gcfg:"access-key-key"`
+	SecretKeyKey string `gcfg:"secret-key-key"`
+	// RefreshInterval is the interval in seconds to re-read the secret.
+	RefreshInterval int `gcfg:"refresh-interval"`
+}
+
+// CredentialProviderOptions specifies an executable to obtain the credentials from, such as a plugin of Vault or an
+// STS. The executable prints the credential in the format of the temporary security credential of IAM.
+type CredentialProviderOptions struct {
+	Command string `gcfg:"command"`
+	// Args are the arguments of the command, the key can be repeated.
+	Args []string `gcfg:"arg"`
+	// Env are the additional environment variables of the command in the form of "<name>=<value>",
+	// the key can be repeated.
+	Env []string `gcfg:"env"`
+	// Timeout is the timeout in seconds of the command.
+	Timeout int `gcfg:"timeout"`
+	// RefreshInterval is the interval in seconds to run the command again, if the credential has no expiration.
+	RefreshInterval int `gcfg:"refresh-interval"`
+}
+
+// TracingOptions exports the traces of the load balancer reconciles and the API requests with OTLP,
+// the tracing is disabled if the endpoint is empty.
+type TracingOptions struct {
+	// Endpoint is the OTLP gRPC endpoint of the collector, such as "otel-collector.monitoring:4317".
+	Endpoint string `gcfg:"endpoint"`
+	// SamplingRatePerMillion is the number of the reconciles sampled per million.
+	SamplingRatePerMillion int `gcfg:"sampling-rate-per-million"`
+}
+
+// AuditOptions writes the audit records of the requests creating, updating or deleting the cloud resources,
+// the records are logged if the file is empty.
+type AuditOptions struct {
+	// File is the file to append the records to in JSON lines.
+	File string `gcfg:"file"`
+}
+
+// QuotaOptions exports the quotas of the load balancers and the EIPs as metrics.
+type QuotaOptions struct {
+	// Interval is the interval in seconds to query the quotas.
+	Interval int `gcfg:"interval"`
+}
+
+// ReloadOptions reloads the cloud config and the credentials when the touch file is touched, in addition to SIGHUP.
+type ReloadOptions struct {
+	// TouchFile is the file whose modification triggers the reload, it is not required to exist until touched.
+	TouchFile string `gcfg:"touch-file"`
+	// Interval is the interval in seconds to check the modification time of the touch file.
+	Interval int `gcfg:"interval"`
+}
+
+// EventOptions aggregates the identical events of an object, such as the same quota error of every retry.
+type EventOptions struct {
+	// DedupWindow is the window in seconds, the first of the identical events in the window is recorded,
+	// and the others are summarized in an event at the end of the window.
+	DedupWindow int `gcfg:"dedup-window"`
+}
+
+// HealthOptions serves the health of the provider on the /healthz, /livez and /readyz endpoints,
+// they are not served if the bind address is empty.
+type HealthOptions struct {
+	// BindAddress is the address to serve the endpoints on, such as ":10270".
+	BindAddress string `gcfg:"bind-address"`
+	// CheckInterval is the interval in seconds to probe the credentials and the endpoints of the cloud.
+	CheckInterval int `gcfg:"check-interval"`
+	// DebugTokenFile is the file of the bearer token to access the /debug/state endpoint,
+	// the endpoint is not served if it is empty.
+	DebugTokenFile string `gcfg:"debug-token-file"`
+}
+
+// ClusterOptions describes the Kubernetes cluster.
+type ClusterOptions struct {
+	// MasterEndpoint is the API server endpoint of a self-managed cluster,
+	// the endpoint of a CCE cluster is queried from the CCE API if it is empty.
+	MasterEndpoint string `gcfg:"master-endpoint"`
+	// ID identifies the cluster in the names and descriptions of the load balancers, listeners and pools,
+	// and in the DNAT rules. It defaults to the cluster name for the former, and to the CLUSTER_ID environment
+	// variable for the latter.
+	ID string `gcfg:"id"`
 }
 
 type AuthOptions struct {
@@ -51,27 +653,408 @@ type AuthOptions struct {
 	AccessKey string `gcfg:"access-key"`
 	SecretKey string `gcfg:"secret-key"`
 	ProjectID string `gcfg:"project-id"`
+	// TenantID is an alias of project-id.
+	TenantID string `gcfg:"tenant-id"`
+	// SecurityToken is used with the temporary access key and secret key.
+	SecurityToken string `gcfg:"security-token"`
+	// SecurityCredentialFile is the file of the temporary security credential, which is re-read before the expiration.
+	// Defaults to the "security.credential" file beside the cloud-config file if exists.
+	SecurityCredentialFile string `gcfg:"security-credential-file"`
+	// UseAgency specifies whether to use the temporary security credential of the agency attached to the ECS,
+	// which is obtained from the metadata service, so that the access-key and secret-key are not required.
+	UseAgency bool `gcfg:"use-agency"`
+	// EndpointDiscovery specifies whether to resolve the service endpoints from the IAM service catalog,
+	// instead of deriving them from the region and cloud.
+	EndpointDiscovery bool `gcfg:"endpoint-discovery"`
+
+	// HTTPProxy, HTTPSProxy and NoProxy override the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+	// for the requests to the cloud APIs.
+	HTTPProxy  string `gcfg:"http-proxy"`
+	HTTPSProxy string `gcfg:"https-proxy"`
+	NoProxy    string `gcfg:"no-proxy"`
+
+	// EndpointRecoveryInterval is the seconds an unreachable endpoint is skipped for, before the requests
+	// are sent to it again. Defaults to 60.
+	EndpointRecoveryInterval int `gcfg:"endpoint-recovery-interval"`
+	// RequestTimeout is the timeout in seconds of a request to the cloud APIs. Defaults to 60.
+	RequestTimeout int `gcfg:"request-timeout"`
+	// DialTimeout is the timeout in seconds to connect to the cloud APIs. Defaults to 10.
+	DialTimeout int `gcfg:"dial-timeout"`
+
+	// HCS enables the compatibility mode of Huawei Cloud Stack and the other private regions,
+	// in which the services absent from the discovered service catalog are skipped.
+	HCS bool `gcfg:"hcs"`
+	// DomainID is the ID of the account, the requests to IAM are signed with the account instead of the project
+	// if it is specified. It is required by the global services, such as the Global Accelerator.
+	DomainID string `gcfg:"domain-id"`
+	// ProjectName is resolved to the project ID through IAM if project-id is empty, such as "{region}_{name}".
+	ProjectName string `gcfg:"project-name"`
+	// UnsupportedServices are the catalog names of the services absent from the region, the features depending on
+	// them are skipped, the key can be repeated.
+	UnsupportedServices []string `gcfg:"unsupported-service"`
+
+	credentialRefresher *CredentialRefresher
+	// endpoints holds the discovered service endpoints, keyed by catalog name.
+	endpoints map[string]string
+	// endpointOverrides holds the [Endpoint] sections, keyed by catalog name.
+	endpointOverrides map[string]*EndpointOptions
+	// endpointHealth is shared by the copies of the options.
+	endpointHealth *endpointHealth
+	// endpointLimiter is shared by the copies of the options, it is nil if the requests are not limited.
+	endpointLimiter *endpointLimiter
+	// ctx carries the logger and the trace span of the reconcile, see WithContext.
+	ctx context.Context
+}
+
+// WithContext returns a copy of the options, the API requests of the clients built with the copy are logged with
+// the logger of the context, and traced as the child spans of its span.
+// The credentials and the endpoint health are shared with the original options.
+func (a *AuthOptions) WithContext(ctx context.Context) *AuthOptions {
+	c := *a
+	c.ctx = ctx
+	return &c
+}
+
+// Context returns the context of the API requests, context.Background() is returned if WithContext is not used.
+func (a *AuthOptions) Context() context.Context {
+	if a.ctx == nil {
+		return context.Background()
+	}
+	return a.ctx
+}
+
+// Logger returns the logger of the API requests.
+func (a *AuthOptions) Logger() klog.Logger {
+	return klog.FromContext(a.Context())
+}
+
+// ServiceSupported returns whether the service is available in the region, i.e. it is not an unsupported-service,
+// and in the HCS mode, it is in the discovered service catalog unless its endpoint is configured.
+func (a *AuthOptions) ServiceSupported(catalogName string) bool {
+	for _, s := range a.UnsupportedServices {
+		if s == catalogName {
+			return false
+		}
+	}
+	if !a.HCS || a.endpoints == nil {
+		return true
+	}
+	if override, ok := a.endpointOverrides[catalogName]; ok && override != nil && override.URL != "" {
+		return true
+	}
+	_, ok := a.endpoints[catalogName]
+	return ok
+}
+
+// SetEndpoints sets the service endpoints discovered from the IAM service catalog, keyed by catalog name.
+func (a *AuthOptions) SetEndpoints(endpoints map[string]string) {
+	a.endpoints = endpoints
+}
+
+// GetEndpoint returns the endpoint of the service, such as "https://ecs.{region}.{cloud}",
+// it is the first reachable one of the primary and the fallback endpoints, the primary if none is reachable.
+func (a *AuthOptions) GetEndpoint(catalogName string) string {
+	endpoints := a.GetEndpoints(catalogName)
+	for _, endpoint := range endpoints {
+		if a.endpointHealth.healthy(endpoint) {
+			return endpoint
+		}
+	}
+	return endpoints[0]
+}
+
+// GetEndpoints returns the primary endpoint of the service followed by the fallback endpoints.
+func (a *AuthOptions) GetEndpoints(catalogName string) []string {
+	endpoints := []string{a.getPrimaryEndpoint(catalogName)}
+	if override, ok := a.endpointOverrides[catalogName]; ok && override != nil {
+		for _, fallback := range override.FallbackURLs {
+			endpoints = append(endpoints, strings.TrimSuffix(fallback, "/"))
+		}
+	}
+	return endpoints
+}
+
+// ReportEndpointFailure records the endpoint of the service as unreachable, it is skipped until the
+// endpoint-recovery-interval elapses. It returns false if there is no other reachable endpoint to fail over to.
+func (a *AuthOptions) ReportEndpointFailure(catalogName, endpoint string) bool {
+	interval := a.EndpointRecoveryInterval
+	if interval <= 0 {
+		interval = DefaultEndpointRecoveryInterval
+	}
+	a.endpointHealth.markUnhealthy(endpoint, time.Duration(interval)*time.Second)
+
+	endpoints := a.GetEndpoints(catalogName)
+	if len(endpoints) < 2 {
+		return false
+	}
+	for _, ep := range endpoints {
+		if ep != endpoint && a.endpointHealth.healthy(ep) {
+			klog.Warningf("The endpoint %s of %s is unreachable, fail over to %s for %ds",
+				endpoint, catalogName, ep, interval)
+			return true
+		}
+	}
+	return false
+}
+
+// getPrimaryEndpoint returns the configured, the discovered or the derived endpoint of the service in order.
+func (a *AuthOptions) getPrimaryEndpoint(catalogName string) string {
+	if override, ok := a.endpointOverrides[catalogName]; ok && override != nil && override.URL != "" {
+		return strings.TrimSuffix(override.URL, "/")
+	}
+	if endpoint, ok := a.endpoints[catalogName]; ok {
+		return endpoint
+	}
+
+	cloud := defaultCloud
+	if strings.TrimSpace(a.Cloud) != "" {
+		cloud = strings.TrimSpace(a.Cloud)
+	}
+	if globalServices.Has(catalogName) {
+		return fmt.Sprintf("https://%s.%s", catalogName, cloud)
+	}
+	return fmt.Sprintf("https://%s.%s.%s", catalogName, a.Region, cloud)
+}
+
+// GetAPIVersion returns the pinned API version of the service, defaultVersion if not pinned.
+func (a *AuthOptions) GetAPIVersion(catalogName, defaultVersion string) string {
+	if override, ok := a.endpointOverrides[catalogName]; ok && override != nil && override.APIVersion != "" {
+		return override.APIVersion
+	}
+	return defaultVersion
+}
+
+// GetIAMEndpoint returns the endpoint of IAM parsed from the auth-url, such as "https://iam.{cloud}:443",
+// unless it is configured in the [Endpoint "iam"] section.
+func (a *AuthOptions) GetIAMEndpoint() string {
+	if override, ok := a.endpointOverrides["iam"]; ok && override != nil && override.URL != "" {
+		return a.GetEndpoint("iam")
+	}
+	u, err := url.Parse(a.AuthURL)
+	if err != nil || u.Host == "" {
+		return a.GetEndpoint("iam")
+	}
+	return fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+}
+
+// AuthState is the state of the credentials and the endpoints for troubleshooting, it contains no secret.
+type AuthState struct {
+	Region    string `json:"region"`
+	ProjectID string `json:"projectID"`
+	// AccessKey is the masked access key in use.
+	AccessKey string `json:"accessKey"`
+	// ExpiresAt is the expiration of the temporary security credential.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// Secondary is set when the secondary credential of the secret is in use.
+	Secondary bool `json:"secondary,omitempty"`
+	// UnhealthyEndpoints are the unreachable endpoints, with the time until when they are skipped.
+	UnhealthyEndpoints map[string]time.Time `json:"unhealthyEndpoints,omitempty"`
+}
+
+// State returns the state of the credentials and the endpoints.
+func (a *AuthOptions) State() AuthState {
+	state := AuthState{
+		Region:             a.Region,
+		ProjectID:          a.ProjectID,
+		UnhealthyEndpoints: a.endpointHealth.unhealthy(),
+	}
+	ak, _, _ := a.GetAccessKeys()
+	if len(ak) > 4 {
+		state.AccessKey = ak[:4] + "***"
+	}
+	if r := a.credentialRefresher; r != nil {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		if expiresAt := r.credential.ExpiresAt; !expiresAt.IsZero() {
+			state.ExpiresAt = &expiresAt
+		}
+		state.Secondary = r.useSecondary && r.credential.secondary != nil
+	}
+	return state
+}
+
+// StartCredentialRefresher re-reads the temporary security credential in the background before the expiration.
+func (a *AuthOptions) StartCredentialRefresher(stop <-chan struct{}) {
+	if a.credentialRefresher != nil {
+		a.credentialRefresher.Start(stop)
+	}
+}
+
+// RefreshCredentials is called when the credential in use is rejected, it re-reads the credential, or switches to
+// the secondary credential of the secret. It returns false if there is no other credential to retry with.
+func (a *AuthOptions) RefreshCredentials() bool {
+	if a.credentialRefresher == nil {
+		return false
+	}
+	return a.credentialRefresher.Renew()
+}
+
+// ReloadCredentials re-reads the credential at once, such as when the secret is changed. The failure is reported
+// to the error handler, and the credential in use is kept.
+func (a *AuthOptions) ReloadCredentials() {
+	if a.credentialRefresher == nil {
+		return
+	}
+	if err := a.credentialRefresher.Refresh(); err != nil {
+		klog.Errorf("failed to reload the security credential: %s", err)
+		a.credentialRefresher.handleError(err)
+	}
+}
+
+// SetCredentialErrorHandler sets the handler called when the credential fails to be re-read,
+// such as a malformed secret, the credential in use is kept.
+func (a *AuthOptions) SetCredentialErrorHandler(handler func(err error)) {
+	if a.credentialRefresher != nil {
+		a.credentialRefresher.SetErrorHandler(handler)
+	}
+}
+
+func (a *AuthOptions) loadSecurityCredential(cfg io.Reader) error {
+	var refresher *CredentialRefresher
+	var err error
+	if a.UseAgency {
+		refresher, err = NewAgencyCredentialRefresher()
+	} else {
+		path := a.SecurityCredentialFile
+		if path == "" {
+			f, ok := cfg.(*os.File)
+			if !ok {
+				return nil
+			}
+			path = filepath.Join(filepath.Dir(f.Name()), SecurityCredentialKey)
+			if _, err := os.Stat(path); err != nil {
+				return nil
+			}
+		}
+		refresher, err = NewCredentialRefresher(path)
+	}
+	if err != nil {
+		return err
+	}
+	a.setCredentialRefresher(refresher)
+	return nil
+}
+
+// LoadSecretCredential reads the credentials from the secret in the cache of the lister, and re-reads them
+// in the background once StartCredentialRefresher is called, or at once when ReloadCredentials is called.
+func (a *AuthOptions) LoadSecretCredential(lister corelisters.SecretLister, opts *SecretOptions) error {
+	refresher, err := NewSecretCredentialRefresher(lister, opts)
+	if err != nil {
+		return err
+	}
+	a.setCredentialRefresher(refresher)
+	return nil
+}
+
+// LoadCSMSCredential reads the credentials from the CSMS secret with read, which sends the request with the
+// credentials of a, and re-reads them in the background once StartCredentialRefresher is called.
+func (a *AuthOptions) LoadCSMSCredential(read CSMSReader, opts *CSMSOptions) error {
+	// The CSMS requests keep using the credentials before loading the secret.
+	bootstrap := *a
+	refresher, err := NewCSMSCredentialRefresher(&bootstrap, read, opts)
+	if err != nil {
+		return err
+	}
+	a.setCredentialRefresher(refresher)
+	return nil
+}
+
+// LoadCredentialProvider runs the command of the credential provider to obtain the credentials,
+// and runs it again in the background once StartCredentialRefresher is called.
+func (a *AuthOptions) LoadCredentialProvider(opts *CredentialProviderOptions) error {
+	refresher, err := NewExecCredentialRefresher(opts)
+	if err != nil {
+		return err
+	}
+	a.setCredentialRefresher(refresher)
+	return nil
+}
+
+func (a *AuthOptions) setCredentialRefresher(refresher *CredentialRefresher) {
+	cred := refresher.Get()
+	a.AccessKey = cred.Access
+	a.SecretKey = cred.Secret
+	a.SecurityToken = cred.SecurityToken
+	a.credentialRefresher = refresher
+}
+
+// loadFromEnv overrides the credentials and region with the environment variables if set.
+func (a *AuthOptions) loadFromEnv() {
+	overrides := map[string]*string{
+		EnvAccessKey:     &a.AccessKey,
+		EnvSecretKey:     &a.SecretKey,
+		EnvSecurityToken: &a.SecurityToken,
+		EnvRegion:        &a.Region,
+	}
+	for env, field := range overrides {
+		if value := strings.TrimSpace(os.Getenv(env)); value != "" {
+			klog.V(4).Infof("%s is overridden by the environment variable", env)
+			*field = value
+		}
+	}
+}
+
+// GetAccessKeys returns the current access key, secret key and security token,
+// the security token is empty for the permanent credentials.
+func (a *AuthOptions) GetAccessKeys() (string, string, string) {
+	if a.credentialRefresher != nil {
+		cred := a.credentialRefresher.Get()
+		return cred.Access, cred.Secret, cred.SecurityToken
+	}
+	return a.AccessKey, a.SecretKey, a.SecurityToken
 }
 
 func (a *AuthOptions) GetCredentials() *basic.Credentials {
+	ak, sk, token := a.GetAccessKeys()
 	return basic.NewCredentialsBuilder().
-		WithAk(a.AccessKey).
-		WithSk(a.SecretKey).
+		WithAk(ak).
+		WithSk(sk).
 		WithProjectId(a.ProjectID).
+		WithSecurityToken(token).
+		WithIamEndpointOverride(a.GetIAMEndpoint()).
+		Build()
+}
+
+// GetGlobalCredentials returns the credentials signing the requests with the account of domain-id.
+func (a *AuthOptions) GetGlobalCredentials() *global.Credentials {
+	ak, sk, token := a.GetAccessKeys()
+	return global.NewCredentialsBuilder().
+		WithAk(ak).
+		WithSk(sk).
+		WithDomainId(a.DomainID).
+		WithSecurityToken(token).
+		WithIamEndpointOverride(a.GetIAMEndpoint()).
 		Build()
 }
 
 func (a *AuthOptions) GetHcClient(catalogName string) *core.HcHttpClient {
-	cloud := "myhuaweicloud.com"
-	if strings.TrimSpace(a.Cloud) != "" {
-		cloud = strings.TrimSpace(a.Cloud)
+	return a.GetHcClientWithEndpoint(catalogName, a.GetEndpoint(catalogName))
+}
+
+// GetHcClientWithEndpoint returns the client of the service sending requests to the endpoint.
+func (a *AuthOptions) GetHcClientWithEndpoint(catalogName, endpoint string) *core.HcHttpClient {
+	r := region.NewRegion(catalogName, endpoint)
+
+	httpConfig := newHTTPConfig(a.Context(), catalogName).
+		WithTimeout(a.GetServiceRequestTimeout(catalogName)).
+		WithDialContext(a.DialContext())
+	if u, err := url.Parse(endpoint); err == nil {
+		if proxy := a.getSDKProxy(u); proxy != nil {
+			httpConfig.WithProxy(proxy)
+		}
+	}
+
+	builder := core.NewHcHttpClientBuilder()
+	var credentials auth.ICredential = a.GetCredentials()
+	if globalServices.Has(catalogName) || catalogName == "iam" && a.DomainID != "" {
+		credentials = a.GetGlobalCredentials()
+		builder.WithCredentialsType("global.Credentials")
 	}
-	r := region.NewRegion(catalogName, fmt.Sprintf("https://%s.%s.%s", catalogName, a.Region, cloud))
 
-	client := core.NewHcHttpClientBuilder().
+	client := builder.
 		WithRegion(r).
-		WithCredential(a.GetCredentials()).
-		WithHttpConfig(newHTTPConfig()).
+		WithCredential(credentials).
+		WithHttpConfig(httpConfig).
 		Build()
 
 	client.PreInvoke(map[string]string{
@@ -80,9 +1063,50 @@ func (a *AuthOptions) GetHcClient(catalogName string) *core.HcHttpClient {
 	return client
 }
 
-func newHTTPConfig() *sdkconfig.HttpConfig {
-	lrt := utils.LogRoundTripper{}
+// ProxyFunc returns the proxy of the request URL, nil if the request should not be proxied.
+// The proxy settings in the [Global] section take precedence over the environment variables.
+func (a *AuthOptions) ProxyFunc() func(*url.URL) (*url.URL, error) {
+	cfg := httpproxy.FromEnvironment()
+	if a.HTTPProxy != "" {
+		cfg.HTTPProxy = a.HTTPProxy
+	}
+	if a.HTTPSProxy != "" {
+		cfg.HTTPSProxy = a.HTTPSProxy
+	}
+	if a.NoProxy != "" {
+		cfg.NoProxy = a.NoProxy
+	}
+	return cfg.ProxyFunc()
+}
+
+// getSDKProxy returns the proxy of the SDK client sending requests to the endpoint.
+func (a *AuthOptions) getSDKProxy(endpoint *url.URL) *sdkconfig.Proxy {
+	proxyURL, err := a.ProxyFunc()(endpoint)
+	if err != nil || proxyURL == nil {
+		return nil
+	}
+
+	proxy := sdkconfig.NewProxy().
+		WithSchema(proxyURL.Scheme).
+		WithHost(proxyURL.Hostname())
+	if port, err := strconv.Atoi(proxyURL.Port()); err == nil {
+		proxy.WithPort(port)
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		proxy.WithUsername(proxyURL.User.Username()).WithPassword(password)
+	}
+	return proxy
+}
+
+// newHTTPConfig returns the config logging the requests with the logger of the context,
+// and auditing the mutating requests with the Service of the context.
+func newHTTPConfig(ctx context.Context, catalogName string) *sdkconfig.HttpConfig {
+	logger := klog.FromContext(ctx)
+	lrt := utils.LogRoundTripper{Logger: &logger}
 	var err error
+	// The requests are sent one by one by the client, the record is completed with the response of the request.
+	var record *audit.Record
 
 	defConfig := sdkconfig.DefaultHttpConfig()
 	defConfig.Retries = 3
@@ -91,45 +1115,75 @@ func newHTTPConfig() *sdkconfig.HttpConfig {
 	defConfig.HttpHandler = httpHandler
 
 	httpHandler.AddRequestHandler(func(request http.Request) {
-		klog.V(6).Infof("Request: [%s] %s\nHeaders: %s",
-			request.Method, request.URL, utils.FormatHeaders(request.Header, "\n    "))
+		logger.V(6).Info("API request", "service", catalogName, "method", request.Method, "url", request.URL,
+			"headers", utils.RedactHeaders(request.Header))
+		record = audit.NewRecord(ctx, request.Method, request.URL)
 
 		if request.Body != nil {
 			request.Body, err = lrt.LogRequest(request.Body, request.Header.Get("Content-Type"))
 			if err != nil {
-				klog.Errorf("error printing request logs : %s", err)
+				logger.Error(err, "error printing request logs")
 			}
 		}
 	})
 
 	httpHandler.AddResponseHandler(func(response http.Response) {
-		klog.V(6).Infof("Response:\nStatus Code: %d\nHeaders: %s",
-			response.StatusCode, utils.FormatHeaders(response.Header, "\n    "))
+		logger.V(6).Info("API response", "service", catalogName, "statusCode", response.StatusCode,
+			"requestID", response.Header.Get("X-Request-Id"), "headers", utils.RedactHeaders(response.Header))
+		if record != nil {
+			var body []byte
+			if response.Body != nil {
+				body, _ = io.ReadAll(response.Body)
+				response.Body = io.NopCloser(bytes.NewReader(body))
+			}
+			record.Complete(response.StatusCode, response.Header.Get("X-Request-Id"), body)
+			audit.Log(record)
+			record = nil
+		}
 
 		response.Body, err = lrt.LogResponse(response.Body, response.Header.Get("Content-Type"))
 		if err != nil {
-			klog.Errorf("error printing response logs : %s", err)
+			logger.Error(err, "error printing response logs")
 		}
 	})
 
 	httpHandler.AddMonitorHandler(func(m *httphandler.MonitorMetric) {
-		klog.Infof("%s https://%s%s%s %d in %d milliseconds, request ID: %s",
-			m.Method, m.Host, m.Path, m.Raw, m.StatusCode, m.Latency.Milliseconds(), m.RequestId)
+		metrics.ObserveRequest(catalogName, m.Host, m.Method, m.StatusCode, m.Latency)
+		logger.Info("API request completed", "service", catalogName, "method", m.Method,
+			"url", fmt.Sprintf("https://%s%s%s", m.Host, m.Path, m.Raw), "statusCode", m.StatusCode,
+			"latency", m.Latency, "requestID", m.RequestId)
 	})
 
 	return defConfig
 }
 
 func ReadConfig(cfg io.Reader) (*CloudConfig, error) {
+	cc, err := ParseConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err = cc.AuthOpts.loadSecurityCredential(cfg); err != nil {
+		return nil, err
+	}
+	return cc, nil
+}
+
+// ParseConfig reads the cloud config without loading the security credential of the file or the agency,
+// such as to compare the cloud config with the one in use.
+func ParseConfig(cfg io.Reader) (*CloudConfig, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("Must provide a config file")
 	}
 	cc := &CloudConfig{}
-	// Read configuration
-	err := gcfg.FatalOnly(gcfg.ReadInto(cc, cfg))
+	// Read configuration, the unknown options are not fatal but reported, they are likely typos.
+	err := gcfg.ReadInto(cc, cfg)
+	if fatal := gcfg.FatalOnly(err); fatal != nil {
+		return nil, fatal
+	}
 	if err != nil {
-		return nil, err
+		klog.Warningf("ignored the unknown options of the cloud config: %s", err)
 	}
+	cc.AuthOpts.loadFromEnv()
 	// Set default value
 	setDefaultConfig(cc)
 	return cc, nil
@@ -137,9 +1191,169 @@ func ReadConfig(cfg io.Reader) (*CloudConfig, error) {
 
 func setDefaultConfig(cc *CloudConfig) {
 	if cc.AuthOpts.Cloud == "" {
-		cc.AuthOpts.Cloud = "myhuaweicloud.com"
+		cc.AuthOpts.Cloud = defaultCloud
+	}
+	cc.AuthOpts.endpointOverrides = cc.Endpoints
+	cc.AuthOpts.endpointHealth = newEndpointHealth()
+	if cc.AuthOpts.EndpointRecoveryInterval <= 0 {
+		cc.AuthOpts.EndpointRecoveryInterval = DefaultEndpointRecoveryInterval
+	}
+	if cc.SecretOpts.Namespace == "" {
+		cc.SecretOpts.Namespace = DefaultSecretNamespace
+	}
+	if cc.SecretOpts.AccessKeyKey == "" {
+		cc.SecretOpts.AccessKeyKey = DefaultAccessKeyKey
+	}
+	if cc.SecretOpts.SecretKeyKey == "" {
+		cc.SecretOpts.SecretKeyKey = DefaultSecretKeyKey
+	}
+	if cc.SecretOpts.CredentialKey == "" {
+		cc.SecretOpts.CredentialKey = SecurityCredentialKey
+	}
+	if cc.CSMSOpts.VersionID == "" {
+		cc.CSMSOpts.VersionID = DefaultCSMSVersionID
 	}
+	if cc.CSMSOpts.AccessKeyKey == "" {
+		cc.CSMSOpts.AccessKeyKey = DefaultAccessKeyKey
+	}
+	if cc.CSMSOpts.SecretKeyKey == "" {
+		cc.CSMSOpts.SecretKeyKey = DefaultSecretKeyKey
+	}
+	if cc.CSMSOpts.RefreshInterval <= 0 {
+		cc.CSMSOpts.RefreshInterval = DefaultCSMSRefreshInterval
+	}
+	if cc.CredentialProviderOpts.Timeout <= 0 {
+		cc.CredentialProviderOpts.Timeout = DefaultCredentialProviderTimeout
+	}
+	if cc.CredentialProviderOpts.RefreshInterval <= 0 {
+		cc.CredentialProviderOpts.RefreshInterval = DefaultCredentialProviderRefreshInterval
+	}
+	if cc.HealthOpts.CheckInterval <= 0 {
+		cc.HealthOpts.CheckInterval = DefaultHealthCheckInterval
+	}
+	if cc.QuotaOpts.Interval <= 0 {
+		cc.QuotaOpts.Interval = DefaultQuotaInterval
+	}
+	if cc.ConcurrencyOpts.ServiceWorkers <= 0 {
+		cc.ConcurrencyOpts.ServiceWorkers = DefaultServiceWorkers
+	}
+	if cc.ConcurrencyOpts.RequestsPerEndpoint <= 0 {
+		cc.ConcurrencyOpts.RequestsPerEndpoint = DefaultRequestsPerEndpoint
+	}
+	if cc.ConcurrencyOpts.NodeUpdateWindow <= 0 {
+		cc.ConcurrencyOpts.NodeUpdateWindow = DefaultNodeUpdateWindow
+	}
+	if cc.ConcurrencyOpts.ShutdownGracePeriod <= 0 {
+		cc.ConcurrencyOpts.ShutdownGracePeriod = DefaultShutdownGracePeriod
+	}
+	cc.AuthOpts.endpointLimiter = newEndpointLimiter(cc.ConcurrencyOpts.RequestsPerEndpoint)
+	if cc.ReloadOpts.Interval <= 0 {
+		cc.ReloadOpts.Interval = DefaultReloadInterval
+	}
+	if cc.EventOpts.DedupWindow <= 0 {
+		cc.EventOpts.DedupWindow = DefaultEventDedupWindow
+	}
+	if cc.TracingOpts.SamplingRatePerMillion <= 0 {
+		cc.TracingOpts.SamplingRatePerMillion = DefaultTracingSamplingRatePerMillion
+	}
+	if cc.SecretOpts.SecondaryAccessKeyKey == "" {
+		cc.SecretOpts.SecondaryAccessKeyKey = DefaultSecondaryAccessKeyKey
+	}
+	if cc.SecretOpts.SecondarySecretKeyKey == "" {
+		cc.SecretOpts.SecondarySecretKeyKey = DefaultSecondarySecretKeyKey
+	}
+	if cc.VpcOpts.RouteType == "" {
+		cc.VpcOpts.RouteType = RouteTypeVPC
+	}
+	if cc.VpcOpts.NodeCIDRMaskSize == 0 {
+		cc.VpcOpts.NodeCIDRMaskSize = DefaultNodeCIDRMaskSize
+	}
+	if cc.LoadBalancerOpts.Backend == "" {
+		cc.LoadBalancerOpts.Backend = LoadBalancerBackendELB
+	}
+	cc.SecurityGroupOpts.setDefaults()
 	if cc.AuthOpts.AuthURL == "" {
 		cc.AuthOpts.AuthURL = fmt.Sprintf("https://iam.%s:443/v3/", cc.AuthOpts.Cloud)
 	}
+	if cc.AuthOpts.ProjectID == "" {
+		cc.AuthOpts.ProjectID = cc.AuthOpts.TenantID
+	}
+	if cc.VpcOpts.SubnetID == "" {
+		cc.VpcOpts.SubnetID = cc.LoadBalancerOpts.SubnetID
+	}
+	if cc.LoadBalancerOpts.LBAlgorithm == "" {
+		cc.LoadBalancerOpts.LBAlgorithm = cc.LoadBalancerOpts.LBMethod
+	}
+}
+
+// ValidateLoadBalancer validates the LoadBalancer and Networking sections, which are reloaded without restarting.
+func (c *CloudConfig) ValidateLoadBalancer() error {
+	if backend := c.LoadBalancerOpts.Backend; backend != LoadBalancerBackendELB && backend != LoadBalancerBackendOctavia {
+		return fmt.Errorf("invalid backend %q in [LoadBalancer] section, expected %s or %s",
+			backend, LoadBalancerBackendELB, LoadBalancerBackendOctavia)
+	}
+	for _, str := range c.LoadBalancerOpts.DefaultAnnotations {
+		if key, _, ok := strings.Cut(str, "="); !ok || strings.TrimSpace(key) == "" {
+			return fmt.Errorf("invalid default-annotation %q in [LoadBalancer] section, expected \"<key>=<value>\"", str)
+		}
+	}
+
+	elbCfg := c.NewELBConfig()
+	if err := elbCfg.LoadBalancerOpts.validate(); err != nil {
+		return fmt.Errorf("%s in [LoadBalancer] section", err)
+	}
+	if err := elbCfg.NetworkingOpts.validate(); err != nil {
+		return fmt.Errorf("%s in [Networking] section", err)
+	}
+	return nil
+}
+
+// DefaultAnnotations returns the default annotations of the LoadBalancer services, keyed by annotation key.
+func (c *CloudConfig) DefaultAnnotations() map[string]string {
+	annotations := make(map[string]string, len(c.LoadBalancerOpts.DefaultAnnotations))
+	for _, str := range c.LoadBalancerOpts.DefaultAnnotations {
+		key, value, _ := strings.Cut(str, "=")
+		annotations[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return annotations
+}
+
+// NewELBConfig returns the default loadbalancer config, overridden by the [LoadBalancer] and [Networking] sections.
+func (c *CloudConfig) NewELBConfig() *LoadbalancerConfig {
+	cfg := NewDefaultELBConfig()
+
+	lb, opts := c.LoadBalancerOpts, &cfg.LoadBalancerOpts
+	setIfNotEmpty(&opts.LBAlgorithm, lb.LBAlgorithm)
+	setIfNotEmpty(&opts.LBProvider, lb.LBProvider)
+	setIfNotEmpty(&opts.L4FlavorID, lb.L4FlavorID)
+	setIfNotEmpty(&opts.L7FlavorID, lb.L7FlavorID)
+	setIfNotEmpty(&opts.MinL7FlavorID, lb.MinL7FlavorID)
+	setIfNotEmpty(&opts.SessionAffinityFlag, lb.SessionAffinityFlag)
+	setIfNotEmpty(&opts.HealthCheckFlag, lb.HealthCheckFlag)
+	opts.KeepEIP = opts.KeepEIP || lb.KeepEIP
+	opts.EnableCrossVpc = opts.EnableCrossVpc || lb.EnableCrossVpc
+	opts.EnableAutoscaling = opts.EnableAutoscaling || lb.EnableAutoscaling
+	if lb.IdleTimeout > 0 {
+		opts.IdleTimeout = lb.IdleTimeout
+	}
+	if lb.RequestTimeout > 0 {
+		opts.RequestTimeout = lb.RequestTimeout
+	}
+	if lb.ResponseTimeout > 0 {
+		opts.ResponseTimeout = lb.ResponseTimeout
+	}
+
+	nw := c.NetworkingOpts
+	cfg.NetworkingOpts.PublicNetworkName = nw.PublicNetworkName
+	cfg.NetworkingOpts.InternalNetworkName = nw.InternalNetworkName
+	cfg.NetworkingOpts.AddressTypeOrder = nw.AddressTypeOrder
+	cfg.NetworkingOpts.ExcludeEIP = nw.ExcludeEIP
+	cfg.NetworkingOpts.InternalSubnetIDs = nw.InternalSubnetIDs
+	return cfg
+}
+
+func setIfNotEmpty(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
 }
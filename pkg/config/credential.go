@@ -0,0 +1,393 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/utils/metadata"
+)
+
+const (
+	// SecurityCredentialKey is the key of the temporary security credential in the cloud-config secret,
+	// it is mounted beside the cloud-config file.
+	SecurityCredentialKey = "security.credential"
+
+	// credentialRefreshAhead is the duration before the expiration to re-read the temporary security credential.
+	credentialRefreshAhead = 5 * time.Minute
+	// credentialRetryInterval is the interval to re-read the file when the credential is not renewed yet.
+	credentialRetryInterval = 30 * time.Second
+)
+
+// SecurityCredential is the temporary security credential issued by IAM.
+type SecurityCredential struct {
+	Access        string    `json:"access"`
+	Secret        string    `json:"secret"`
+	SecurityToken string    `json:"securitytoken"`
+	ExpiresAt     time.Time `json:"expires_at"`
+
+	// secondary is the secondary access key and secret key in the secret, used during the rotation.
+	secondary *SecurityCredential
+}
+
+// equal returns whether the credentials are the same, the secondary credential is not compared.
+func (c *SecurityCredential) equal(other *SecurityCredential) bool {
+	return c.Access == other.Access && c.Secret == other.Secret && c.SecurityToken == other.SecurityToken
+}
+
+type securityCredentialFile struct {
+	Credential SecurityCredential `json:"credential"`
+}
+
+// ReadSecurityCredential reads the temporary security credential in the IAM response format, such as
+// {"credential": {"access": "", "secret": "", "securitytoken": "", "expires_at": ""}}.
+func ReadSecurityCredential(path string) (*SecurityCredential, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseSecurityCredential(data, path)
+}
+
+func parseSecurityCredential(data []byte, source string) (*SecurityCredential, error) {
+	file := &securityCredentialFile{}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("failed to parse the security credential %s: %s", source, err)
+	}
+	cred := file.Credential
+	if cred.Access == "" || cred.Secret == "" {
+		return nil, fmt.Errorf("access and secret are required in the security credential %s", source)
+	}
+	return &cred, nil
+}
+
+// readSecretCredential reads the credential from the secret, the temporary security credential takes precedence
+// over the access key and secret key.
+func readSecretCredential(lister corelisters.SecretLister, opts *SecretOptions) (*SecurityCredential, error) {
+	secret, err := lister.Secrets(opts.Namespace).Get(opts.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	source := fmt.Sprintf("secret %s/%s", opts.Namespace, opts.Name)
+	if data, ok := secret.Data[opts.CredentialKey]; ok {
+		return parseSecurityCredential([]byte(decodeCredentialValue(data)), source)
+	}
+
+	cred := &SecurityCredential{
+		Access: decodeCredentialValue(secret.Data[opts.AccessKeyKey]),
+		Secret: decodeCredentialValue(secret.Data[opts.SecretKeyKey]),
+	}
+	if cred.Access == "" || cred.Secret == "" {
+		return nil, fmt.Errorf("%s or %s and %s are required in the %s", opts.CredentialKey,
+			opts.AccessKeyKey, opts.SecretKeyKey, source)
+	}
+
+	secondary := &SecurityCredential{
+		Access: decodeCredentialValue(secret.Data[opts.SecondaryAccessKeyKey]),
+		Secret: decodeCredentialValue(secret.Data[opts.SecondarySecretKeyKey]),
+	}
+	if secondary.Access != "" && secondary.Secret != "" {
+		cred.secondary = secondary
+	}
+	return cred, nil
+}
+
+// decodeCredentialValue returns the credential value without the surrounding whitespaces, such as the trailing
+// newline of "echo". A value encoded in base64 once more, which is a common mistake of the secret data,
+// is decoded if the decoded value is printable, since the access keys, secret keys and tokens are printable
+// and the decoded plaintext ones are not.
+func decodeCredentialValue(data []byte) string {
+	value := strings.TrimSpace(string(data))
+	if value == "" || len(value)%4 != 0 {
+		return value
+	}
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(decoded) == 0 {
+		return value
+	}
+	for _, r := range string(decoded) {
+		if r == utf8.RuneError || (!unicode.IsPrint(r) && !unicode.IsSpace(r)) {
+			return value
+		}
+	}
+	klog.V(4).Infof("the credential value is encoded in base64 twice, decoded")
+	return strings.TrimSpace(string(decoded))
+}
+
+// readExecCredential runs the command of the credential provider, and parses its output in the format of
+// the temporary security credential.
+func readExecCredential(opts *CredentialProviderOptions) (*SecurityCredential, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(opts.Timeout)*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, opts.Command, opts.Args...)
+	cmd.Env = append(os.Environ(), opts.Env...)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run the credential provider %s: %s, stderr: %s",
+			opts.Command, err, strings.TrimSpace(stderr.String()))
+	}
+	return parseSecurityCredential(out, fmt.Sprintf("output of the credential provider %s", opts.Command))
+}
+
+// CSMSReader returns the secret string of the CSMS secret version, the request is sent with the credentials of
+// authOpts.
+type CSMSReader func(authOpts *AuthOptions, secretName, versionID string) (string, error)
+
+// readCSMSCredential parses the JSON secret string of the CSMS secret, such as
+// {"access-key": "", "secret-key": ""}.
+func readCSMSCredential(authOpts *AuthOptions, read CSMSReader, opts *CSMSOptions) (*SecurityCredential, error) {
+	str, err := read(authOpts, opts.SecretName, opts.VersionID)
+	if err != nil {
+		return nil, err
+	}
+
+	source := fmt.Sprintf("CSMS secret %s", opts.SecretName)
+	data := make(map[string]string)
+	if err = json.Unmarshal([]byte(str), &data); err != nil {
+		return nil, fmt.Errorf("failed to parse the %s, expected a JSON object: %s", source, err)
+	}
+	cred := &SecurityCredential{
+		Access: decodeCredentialValue([]byte(data[opts.AccessKeyKey])),
+		Secret: decodeCredentialValue([]byte(data[opts.SecretKeyKey])),
+	}
+	if cred.Access == "" || cred.Secret == "" {
+		return nil, fmt.Errorf("%s and %s are required in the %s", opts.AccessKeyKey, opts.SecretKeyKey, source)
+	}
+	return cred, nil
+}
+
+// CredentialRefresher holds the temporary security credential read from the file or the metadata service,
+// and re-reads it before the expiration.
+type CredentialRefresher struct {
+	read func() (*SecurityCredential, error)
+	// interval is the interval to re-read the credential without expiration, defaults to credentialRetryInterval.
+	interval time.Duration
+	// source is the refresher of the credential used to read this one, it is started together.
+	source *CredentialRefresher
+	// onError is called when the credential fails to be re-read, the current credential is kept in use.
+	onError func(err error)
+
+	mu         sync.RWMutex
+	credential *SecurityCredential
+	// useSecondary is set when the primary credential is rejected, it is reset when the primary one changes.
+	useSecondary bool
+}
+
+// NewCredentialRefresher reads the temporary security credential from the file.
+func NewCredentialRefresher(path string) (*CredentialRefresher, error) {
+	return newCredentialRefresher(func() (*SecurityCredential, error) {
+		return ReadSecurityCredential(path)
+	})
+}
+
+// NewAgencyCredentialRefresher reads the temporary security credential of the agency attached to the ECS
+// from the metadata service.
+func NewAgencyCredentialRefresher() (*CredentialRefresher, error) {
+	return newCredentialRefresher(func() (*SecurityCredential, error) {
+		key, err := metadata.GetSecurityKey()
+		if err != nil {
+			return nil, err
+		}
+		return &SecurityCredential{
+			Access:        key.Access,
+			Secret:        key.Secret,
+			SecurityToken: key.SecurityToken,
+			ExpiresAt:     key.ExpiresAt,
+		}, nil
+	})
+}
+
+// NewSecretCredentialRefresher reads the credential from the secret in the cache of the lister, the permanent
+// access key and secret key are re-read every 30 seconds to pick up the rotation.
+func NewSecretCredentialRefresher(lister corelisters.SecretLister, opts *SecretOptions) (*CredentialRefresher, error) {
+	return newCredentialRefresher(func() (*SecurityCredential, error) {
+		return readSecretCredential(lister, opts)
+	})
+}
+
+// NewCSMSCredentialRefresher reads the credential from the CSMS secret with the credentials of authOpts,
+// and re-reads it every refresh-interval.
+func NewCSMSCredentialRefresher(authOpts *AuthOptions, read CSMSReader, opts *CSMSOptions) (*CredentialRefresher, error) {
+	r, err := newCredentialRefresher(func() (*SecurityCredential, error) {
+		return readCSMSCredential(authOpts, read, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.interval = time.Duration(opts.RefreshInterval) * time.Second
+	r.source = authOpts.credentialRefresher
+	return r, nil
+}
+
+// NewExecCredentialRefresher runs the command of the credential provider to obtain the credential,
+// and runs it again before the expiration, or every refresh-interval if the credential has no expiration.
+func NewExecCredentialRefresher(opts *CredentialProviderOptions) (*CredentialRefresher, error) {
+	r, err := newCredentialRefresher(func() (*SecurityCredential, error) {
+		return readExecCredential(opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.interval = time.Duration(opts.RefreshInterval) * time.Second
+	return r, nil
+}
+
+func newCredentialRefresher(read func() (*SecurityCredential, error)) (*CredentialRefresher, error) {
+	r := &CredentialRefresher{read: read}
+	if err := r.Refresh(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Get returns the current temporary security credential.
+func (r *CredentialRefresher) Get() SecurityCredential {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.useSecondary && r.credential.secondary != nil {
+		return *r.credential.secondary
+	}
+	return *r.credential
+}
+
+// Renew is called when the current credential is rejected. It re-reads the credential, and switches between
+// the primary and the secondary credential if the re-read one is not changed.
+// It returns false if there is no other credential to retry with.
+func (r *CredentialRefresher) Renew() bool {
+	rejected := r.Get()
+	if err := r.Refresh(); err != nil {
+		klog.Errorf("failed to refresh the security credential: %s", err)
+		r.handleError(err)
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current := r.credential
+	if r.useSecondary && current.secondary != nil {
+		current = current.secondary
+	}
+	if !current.equal(&rejected) {
+		return true
+	}
+	if r.credential.secondary == nil {
+		return false
+	}
+
+	r.useSecondary = !r.useSecondary
+	klog.Warningf("the access key %s is rejected, switch to the other access key in the secret", rejected.Access)
+	return true
+}
+
+// Refresh re-reads the temporary security credential.
+func (r *CredentialRefresher) Refresh() error {
+	cred, err := r.read()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.credential == nil || !cred.ExpiresAt.Equal(r.credential.ExpiresAt) {
+		klog.Infof("the security credential is loaded, expires at: %s", cred.ExpiresAt)
+	}
+	if r.credential != nil && !cred.equal(r.credential) {
+		// The primary credential is rotated, it is preferred again.
+		r.useSecondary = false
+	}
+	r.credential = cred
+	return nil
+}
+
+// nextRefresh returns the duration to wait before re-reading the file.
+func (r *CredentialRefresher) nextRefresh() time.Duration {
+	expiresAt := r.Get().ExpiresAt
+	if expiresAt.IsZero() {
+		if r.interval > 0 {
+			return r.interval
+		}
+		return credentialRetryInterval
+	}
+
+	d := time.Until(expiresAt.Add(-credentialRefreshAhead))
+	if d < credentialRetryInterval {
+		return credentialRetryInterval
+	}
+	return d
+}
+
+// Run re-reads the temporary security credential ahead of the expiration until the stop channel is closed.
+func (r *CredentialRefresher) Run(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(r.nextRefresh()):
+		}
+
+		if err := r.Refresh(); err != nil {
+			klog.Errorf("failed to refresh the security credential, retry later: %s", err)
+			r.handleError(err)
+		}
+	}
+}
+
+// SetErrorHandler sets the handler called when the credential fails to be re-read.
+func (r *CredentialRefresher) SetErrorHandler(handler func(err error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onError = handler
+}
+
+func (r *CredentialRefresher) handleError(err error) {
+	r.mu.RLock()
+	handler := r.onError
+	r.mu.RUnlock()
+	if handler != nil {
+		handler(err)
+	}
+}
+
+// Start runs the refresher in the background, together with the refresher of the source credential.
+func (r *CredentialRefresher) Start(stop <-chan struct{}) {
+	if stop == nil {
+		stop = wait.NeverStop
+	}
+	if r.source != nil {
+		r.source.Start(stop)
+	}
+	go r.Run(stop)
+}
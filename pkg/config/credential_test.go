@@ -0,0 +1,302 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestReadConfigSecurityCredential(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "cloud-config"), "[Global]\nregion=ap-southeast-1\n")
+	credPath := filepath.Join(dir, SecurityCredentialKey)
+	writeFile(t, credPath, `{"credential": {"access": "ak-1", "secret": "sk-1", "securitytoken": "token-1",
+		"expires_at": "2023-06-01T08:00:00.000000Z"}}`)
+
+	f, err := os.Open(filepath.Join(dir, "cloud-config"))
+	if err != nil {
+		t.Fatalf("failed to open cloud-config: %s", err)
+	}
+	defer f.Close()
+
+	cfg, err := ReadConfig(f)
+	if err != nil {
+		t.Fatalf("failed to read config: %s", err)
+	}
+	if cfg.AuthOpts.AccessKey != "ak-1" || cfg.AuthOpts.SecurityToken != "token-1" {
+		t.Fatalf("AuthOpts, expected the security credential, got: %#v", cfg.AuthOpts)
+	}
+
+	writeFile(t, credPath, `{"credential": {"access": "ak-2", "secret": "sk-2", "securitytoken": "token-2",
+		"expires_at": "2023-06-01T09:00:00.000000Z"}}`)
+	if !cfg.AuthOpts.RefreshCredentials() {
+		t.Fatalf("expected the security credential to be refreshed")
+	}
+	if cred := cfg.AuthOpts.GetCredentials(); cred.AK != "ak-2" || cred.SecurityToken != "token-2" {
+		t.Fatalf("GetCredentials, expected: ak-2/token-2, got: %s/%s", cred.AK, cred.SecurityToken)
+	}
+}
+
+func TestCredentialRefresherNextRefresh(t *testing.T) {
+	r := &CredentialRefresher{credential: &SecurityCredential{ExpiresAt: time.Now().Add(time.Hour)}}
+	if d := r.nextRefresh(); d < 50*time.Minute || d > 55*time.Minute {
+		t.Fatalf("nextRefresh, expected about 55m, got: %s", d)
+	}
+
+	r.credential.ExpiresAt = time.Now().Add(time.Minute)
+	if d := r.nextRefresh(); d != credentialRetryInterval {
+		t.Fatalf("nextRefresh, expected: %s, got: %s", credentialRetryInterval, d)
+	}
+}
+
+func TestReadSecretCredential(t *testing.T) {
+	opts := &SecretOptions{
+		Name:          "hw-credentials",
+		Namespace:     "cloud-system",
+		AccessKeyKey:  "ak",
+		SecretKeyKey:  "sk",
+		CredentialKey: SecurityCredentialKey,
+	}
+	lister, _ := newSecretLister(t, &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "hw-credentials", Namespace: "cloud-system"},
+		Data: map[string][]byte{
+			"ak": []byte("ak-1"),
+			"sk": []byte("sk-1"),
+		},
+	})
+
+	cred, err := readSecretCredential(lister, opts)
+	if err != nil {
+		t.Fatalf("failed to read secret credential: %s", err)
+	}
+	if cred.Access != "ak-1" || cred.Secret != "sk-1" || cred.SecurityToken != "" {
+		t.Fatalf("readSecretCredential, expected: ak-1/sk-1, got: %#v", cred)
+	}
+
+	opts.SecretKeyKey = "secret-key"
+	if _, err = readSecretCredential(lister, opts); err == nil {
+		t.Fatalf("expected an error for the missing secret key")
+	}
+
+	opts.Namespace = "kube-system"
+	if _, err = readSecretCredential(lister, opts); err == nil {
+		t.Fatalf("expected an error for the secret in another namespace")
+	}
+}
+
+func TestCredentialRefresherRenew(t *testing.T) {
+	opts := &SecretOptions{
+		Name:                  "hw-credentials",
+		Namespace:             "kube-system",
+		AccessKeyKey:          DefaultAccessKeyKey,
+		SecretKeyKey:          DefaultSecretKeyKey,
+		CredentialKey:         SecurityCredentialKey,
+		SecondaryAccessKeyKey: DefaultSecondaryAccessKeyKey,
+		SecondarySecretKeyKey: DefaultSecondarySecretKeyKey,
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "hw-credentials", Namespace: "kube-system"},
+		Data: map[string][]byte{
+			DefaultAccessKeyKey:          []byte("ak-1"),
+			DefaultSecretKeyKey:          []byte("sk-1"),
+			DefaultSecondaryAccessKeyKey: []byte("ak-2"),
+			DefaultSecondarySecretKeyKey: []byte("sk-2"),
+		},
+	}
+	lister, indexer := newSecretLister(t, secret)
+
+	r, err := NewSecretCredentialRefresher(lister, opts)
+	if err != nil {
+		t.Fatalf("failed to create the refresher: %s", err)
+	}
+	if ak := r.Get().Access; ak != "ak-1" {
+		t.Fatalf("Get, expected the primary access key ak-1, got: %s", ak)
+	}
+
+	if !r.Renew() || r.Get().Access != "ak-2" {
+		t.Fatalf("Renew, expected to switch to the secondary access key ak-2, got: %s", r.Get().Access)
+	}
+	if !r.Renew() || r.Get().Access != "ak-1" {
+		t.Fatalf("Renew, expected to switch back to the primary access key ak-1, got: %s", r.Get().Access)
+	}
+
+	// The secondary is promoted to the primary, and the old primary is removed.
+	secret.Data = map[string][]byte{
+		DefaultAccessKeyKey: []byte("ak-2"),
+		DefaultSecretKeyKey: []byte("sk-2"),
+	}
+	if err = indexer.Update(secret); err != nil {
+		t.Fatalf("failed to update the secret: %s", err)
+	}
+	if !r.Renew() || r.Get().Access != "ak-2" {
+		t.Fatalf("Renew, expected the rotated access key ak-2, got: %s", r.Get().Access)
+	}
+	if r.Renew() {
+		t.Fatalf("Renew, expected no other credential to retry with")
+	}
+}
+
+// newSecretLister returns the lister of the secrets in the cache of an informer, and the indexer to update them.
+func newSecretLister(t *testing.T, secrets ...*v1.Secret) (corelisters.SecretLister, cache.Indexer) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, secret := range secrets {
+		if err := indexer.Add(secret); err != nil {
+			t.Fatalf("failed to add the secret: %s", err)
+		}
+	}
+	return corelisters.NewSecretLister(indexer), indexer
+}
+
+func TestDecodeCredentialValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{
+			name:     "plaintext access key",
+			value:    "HPUAL8QWMFEXAMPLEAKX",
+			expected: "HPUAL8QWMFEXAMPLEAKX",
+		},
+		{
+			name:     "plaintext secret key",
+			value:    "wJalrXUtnFEMIK7MDENGbPxRfiCYEXAMPLEKEY12",
+			expected: "wJalrXUtnFEMIK7MDENGbPxRfiCYEXAMPLEKEY12",
+		},
+		{
+			name:     "trailing newline",
+			value:    "HPUAL8QWMFEXAMPLEAKX\n",
+			expected: "HPUAL8QWMFEXAMPLEAKX",
+		},
+		{
+			name:     "encoded in base64 twice",
+			value:    base64.StdEncoding.EncodeToString([]byte("HPUAL8QWMFEXAMPLEAKX\n")),
+			expected: "HPUAL8QWMFEXAMPLEAKX",
+		},
+		{
+			name:     "empty",
+			value:    "",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeCredentialValue([]byte(tt.value)); got != tt.expected {
+				t.Errorf("decodeCredentialValue() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCSMSCredentialRefresher(t *testing.T) {
+	opts := &CSMSOptions{
+		SecretName:      "ccm-credentials",
+		VersionID:       DefaultCSMSVersionID,
+		AccessKeyKey:    DefaultAccessKeyKey,
+		SecretKeyKey:    DefaultSecretKeyKey,
+		RefreshInterval: 60,
+	}
+	authOpts := &AuthOptions{AccessKey: "bootstrap-ak", SecretKey: "bootstrap-sk"}
+	secretString := `{"access-key": "ak-1", "secret-key": "sk-1"}`
+	read := func(a *AuthOptions, secretName, versionID string) (string, error) {
+		if a.AccessKey != "bootstrap-ak" || secretName != "ccm-credentials" || versionID != "latest" {
+			t.Fatalf("unexpected CSMS request with %s: %s/%s", a.AccessKey, secretName, versionID)
+		}
+		return secretString, nil
+	}
+
+	if err := authOpts.LoadCSMSCredential(read, opts); err != nil {
+		t.Fatalf("failed to load CSMS credential: %s", err)
+	}
+	if ak, sk, _ := authOpts.GetAccessKeys(); ak != "ak-1" || sk != "sk-1" {
+		t.Fatalf("GetAccessKeys, expected: ak-1/sk-1, got: %s/%s", ak, sk)
+	}
+	if d := authOpts.credentialRefresher.nextRefresh(); d != time.Minute {
+		t.Fatalf("nextRefresh, expected the refresh interval 1m, got: %s", d)
+	}
+
+	// The CSMS requests are still sent with the bootstrap credentials.
+	secretString = `{"access-key": "ak-2", "secret-key": "sk-2"}`
+	if err := authOpts.credentialRefresher.Refresh(); err != nil {
+		t.Fatalf("failed to refresh CSMS credential: %s", err)
+	}
+	if ak, _, _ := authOpts.GetAccessKeys(); ak != "ak-2" {
+		t.Fatalf("GetAccessKeys, expected the rotated access key ak-2, got: %s", ak)
+	}
+
+	secretString = `{"access-key": "ak-3"}`
+	if err := authOpts.credentialRefresher.Refresh(); err == nil {
+		t.Fatalf("expected an error for the missing secret key")
+	}
+}
+
+func TestExecCredentialRefresher(t *testing.T) {
+	dir := t.TempDir()
+	credPath := filepath.Join(dir, "credential.json")
+	writeFile(t, credPath, `{"credential": {"access": "ak-1", "secret": "sk-1"}}`)
+	opts := &CredentialProviderOptions{
+		Command:         "sh",
+		Args:            []string{"-c", `test "$PROVIDER_ROLE" = ccm && cat "$1"`, "sh", credPath},
+		Env:             []string{"PROVIDER_ROLE=ccm"},
+		Timeout:         DefaultCredentialProviderTimeout,
+		RefreshInterval: 60,
+	}
+
+	authOpts := &AuthOptions{}
+	if err := authOpts.LoadCredentialProvider(opts); err != nil {
+		t.Fatalf("failed to load the credential provider: %s", err)
+	}
+	if ak, sk, _ := authOpts.GetAccessKeys(); ak != "ak-1" || sk != "sk-1" {
+		t.Fatalf("GetAccessKeys, expected: ak-1/sk-1, got: %s/%s", ak, sk)
+	}
+	if d := authOpts.credentialRefresher.nextRefresh(); d != time.Minute {
+		t.Fatalf("nextRefresh, expected the refresh interval 1m, got: %s", d)
+	}
+
+	writeFile(t, credPath, `{"credential": {"access": "ak-2", "secret": "sk-2", "securitytoken": "token-2",
+		"expires_at": "2099-06-01T08:00:00.000000Z"}}`)
+	if err := authOpts.credentialRefresher.Refresh(); err != nil {
+		t.Fatalf("failed to refresh the credential: %s", err)
+	}
+	if ak, _, token := authOpts.GetAccessKeys(); ak != "ak-2" || token != "token-2" {
+		t.Fatalf("GetAccessKeys, expected the renewed credential ak-2, got: %s", ak)
+	}
+
+	opts.Env = nil
+	if err := authOpts.credentialRefresher.Refresh(); err == nil {
+		t.Fatalf("expected an error for the failed command")
+	}
+	if ak, _, _ := authOpts.GetAccessKeys(); ak != "ak-2" {
+		t.Fatalf("GetAccessKeys, expected the credential in use to be kept, got: %s", ak)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write %s: %s", path, err)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultEndpointRecoveryInterval is the default seconds an unreachable endpoint is skipped for.
+const DefaultEndpointRecoveryInterval = 60
+
+// endpointHealth records the unreachable endpoints, which are skipped until the recovery interval elapses,
+// so that the requests fail over to the fallback endpoints and return to the primary endpoint once it recovers.
+type endpointHealth struct {
+	mu sync.Mutex
+	// unhealthyUntil holds the time until when the endpoint is skipped, keyed by endpoint.
+	unhealthyUntil map[string]time.Time
+	now            func() time.Time
+}
+
+func newEndpointHealth() *endpointHealth {
+	return &endpointHealth{
+		unhealthyUntil: make(map[string]time.Time),
+		now:            time.Now,
+	}
+}
+
+// healthy returns whether the endpoint is not recorded as unreachable, a nil endpointHealth treats all the
+// endpoints as healthy.
+func (h *endpointHealth) healthy(endpoint string) bool {
+	if h == nil {
+		return true
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	until, ok := h.unhealthyUntil[endpoint]
+	if !ok {
+		return true
+	}
+	if h.now().Before(until) {
+		return false
+	}
+	delete(h.unhealthyUntil, endpoint)
+	return true
+}
+
+// markUnhealthy skips the endpoint for the duration.
+func (h *endpointHealth) markUnhealthy(endpoint string, duration time.Duration) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.unhealthyUntil[endpoint] = h.now().Add(duration)
+}
+
+// unhealthy returns the endpoints recorded as unreachable, with the time until when they are skipped.
+func (h *endpointHealth) unhealthy() map[string]time.Time {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	rst := make(map[string]time.Time, len(h.unhealthyUntil))
+	for endpoint, until := range h.unhealthyUntil {
+		if h.now().Before(until) {
+			rst[endpoint] = until
+		}
+	}
+	return rst
+}
@@ -19,12 +19,15 @@ package config
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	elbmodel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/elb/v2/model"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
-	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
 
 	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/utils/metadata"
@@ -32,17 +35,31 @@ import (
 
 const (
 	ProviderNamespace     = "huawei-cloud-provider"
-	loadbalancerConfigMap = "loadbalancer-config"
+	LoadbalancerConfigMap = "loadbalancer-config"
 
 	HealthCheckTimeout    = 3
 	HealthCheckMaxRetries = 3
 	HealthCheckDelay      = 5
+
+	DefaultAvailabilityZoneCacheTTL = 600
+)
+
+// ErrInvalidLoadbalancerConfig is returned if the loadbalancer-config ConfigMap is malformed or has invalid values.
+var ErrInvalidLoadbalancerConfig = errors.New("invalid loadbalancer config")
+
+var (
+	lbAlgorithms = []string{"ROUND_ROBIN", "LEAST_CONNECTIONS", "SOURCE_IP"}
+	flagValues   = []string{"on", "off"}
+	addressTypes = []string{string(v1.NodeHostName), string(v1.NodeInternalIP), string(v1.NodeExternalIP),
+		string(v1.NodeInternalDNS), string(v1.NodeExternalDNS)}
+	searchOrderIDs = []string{metadata.MetadataID, metadata.ConfigDriveID}
 )
 
 type LoadbalancerConfig struct {
 	LoadBalancerOpts LoadBalancerOptions `json:"loadBalancerOption"`
 	NetworkingOpts   NetworkingOptions   `json:"networkingOption"`
 	MetadataOpts     MetadataOptions     `json:"metadataOption"`
+	NodeOpts         NodeOptions         `json:"nodeOption"`
 }
 
 type LoadBalancerOptions struct {
@@ -53,6 +70,11 @@ type LoadBalancerOptions struct {
 	EnableCrossVpc bool   `json:"enable-cross-vpc"`
 	L4FlavorID     string `json:"l4-flavor-id"`
 	L7FlavorID     string `json:"l7-flavor-id"`
+	// EnableAutoscaling creates the dedicated load balancers of the elastic flavors scaling with the traffic,
+	// the l4-flavor-id and l7-flavor-id are the upper limits then.
+	EnableAutoscaling bool `json:"enable-autoscaling"`
+	// MinL7FlavorID is the minimum elastic flavor at Layer 7 of the autoscaling, required by the HTTP listeners.
+	MinL7FlavorID string `json:"min-l7-flavor-id"`
 
 	SessionAffinityOption elbmodel.SessionPersistence `json:"session-affinity-option"`
 	SessionAffinityFlag   string                      `json:"session-affinity-flag"`
@@ -65,6 +87,9 @@ type LoadBalancerOptions struct {
 
 	HealthCheckFlag   string            `json:"health-check-flag"`
 	HealthCheckOption HealthCheckOption `json:"health-check-option"`
+
+	// AvailabilityZoneCacheTTL is the period in seconds the cached AZs of the dedicated load balancers are used.
+	AvailabilityZoneCacheTTL int `json:"availability-zone-cache-ttl"`
 }
 
 type HealthCheckOption struct {
@@ -80,11 +105,35 @@ type HealthCheckOption struct {
 type NetworkingOptions struct {
 	PublicNetworkName   []string `json:"public-network-name"`
 	InternalNetworkName []string `json:"internal-network-name"`
+
+	// AddressTypeOrder specifies the node address types to report and their precedence,
+	// the types not listed are omitted. All types are reported in the default order if it is empty.
+	AddressTypeOrder []string `json:"address-type-order"`
+	// ExcludeEIP specifies whether to omit the EIPs bound to the ECS from the node addresses.
+	ExcludeEIP bool `json:"exclude-eip"`
+	// InternalSubnetIDs specifies the subnets of the NICs to report the InternalIP from.
+	InternalSubnetIDs []string `json:"internal-subnet-ids"`
 }
 
 // MetadataOptions is used for configuring how to talk to metadata service or authConfig drive
 type MetadataOptions struct {
 	SearchOrder string `json:"search-order"`
+	// NodeNameFromMetadata names the current node by the ECS name in the metadata instead of the hostname.
+	NodeNameFromMetadata bool `json:"node-name-from-metadata"`
+}
+
+// NodeOptions is used for configuring the labels and taints applied to nodes
+type NodeOptions struct {
+	TaintSpotInstances bool     `json:"taint-spot-instances"`
+	ExtraLabels        []string `json:"extra-labels"`
+	// NotReadyGracePeriod is the period in seconds the nodes are still served after turning NotReady,
+	// so that a transient NotReady of the kubelet does not move the load balancers off the nodes.
+	NotReadyGracePeriod int `json:"not-ready-grace-period"`
+}
+
+// GetNotReadyGracePeriod returns the period the NotReady nodes are still served.
+func (n *NodeOptions) GetNotReadyGracePeriod() time.Duration {
+	return time.Duration(n.NotReadyGracePeriod) * time.Second
 }
 
 func NewDefaultELBConfig() *LoadbalancerConfig {
@@ -94,54 +143,172 @@ func NewDefaultELBConfig() *LoadbalancerConfig {
 	return cfg
 }
 
-func LoadElbConfigFromCM() (*LoadbalancerConfig, error) {
-	defaultCfg := NewDefaultELBConfig()
-	kubeClient, err := getKubeClient()
-	if err != nil {
-		return defaultCfg, err
+// LoadElbConfigFromCM reads the loadbalancer-config ConfigMap, the options absent in the ConfigMap are taken from
+// defaultCfg.
+func LoadElbConfigFromCM(client corev1.ConfigMapsGetter, defaultCfg *LoadbalancerConfig) (*LoadbalancerConfig, error) {
+	if defaultCfg == nil {
+		defaultCfg = NewDefaultELBConfig()
 	}
 
-	configMap, err := kubeClient.ConfigMaps(ProviderNamespace).
-		Get(context.TODO(), loadbalancerConfigMap, metav1.GetOptions{})
+	configMap, err := client.ConfigMaps(ProviderNamespace).
+		Get(context.TODO(), LoadbalancerConfigMap, metav1.GetOptions{})
 	if err != nil {
 		return defaultCfg, err
 	}
 
 	klog.Infof("get loadbalancer options: %v", configMap.Data)
 
-	return LoadELBConfig(configMap.Data), nil
+	cfg, err := ParseELBConfig(defaultCfg, configMap.Data)
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("%w in ConfigMap %s/%s: %s", ErrInvalidLoadbalancerConfig,
+			ProviderNamespace, LoadbalancerConfigMap, err)
+	}
+	return cfg, nil
 }
 
 func LoadELBConfig(data map[string]string) *LoadbalancerConfig {
-	cfg := NewDefaultELBConfig()
+	return MergeELBConfig(NewDefaultELBConfig(), data)
+}
 
-	loadBalancerOptions := []byte(data["loadBalancerOption"])
-	if err := json.Unmarshal(loadBalancerOptions, &cfg.LoadBalancerOpts); err != nil {
+// MergeELBConfig parses the data of the loadbalancer-config ConfigMap into cfg, the absent options are kept.
+// The malformed options are logged and ignored.
+func MergeELBConfig(cfg *LoadbalancerConfig, data map[string]string) *LoadbalancerConfig {
+	cfg, err := ParseELBConfig(cfg, data)
+	if err != nil {
 		klog.Errorf("error parsing loadbalancer config: %s", err)
 	}
-	networkingOptions := []byte(data["networkingOption"])
-	if err := json.Unmarshal(networkingOptions, &cfg.NetworkingOpts); err != nil {
-		klog.Errorf("error parsing networkingOption config: %s", err)
+	return cfg
+}
+
+// ParseELBConfig is like MergeELBConfig, but returns the error of the first malformed option.
+func ParseELBConfig(cfg *LoadbalancerConfig, data map[string]string) (*LoadbalancerConfig, error) {
+	options := []struct {
+		key   string
+		value interface{}
+	}{
+		{"loadBalancerOption", &cfg.LoadBalancerOpts},
+		{"networkingOption", &cfg.NetworkingOpts},
+		{"metadataOption", &cfg.MetadataOpts},
+		{"nodeOption", &cfg.NodeOpts},
 	}
-	metadataOption := []byte(data["metadataOption"])
-	if err := json.Unmarshal(metadataOption, &cfg.MetadataOpts); err != nil {
-		klog.Errorf("error parsing metadataOption config: %s", err)
+
+	var firstErr error
+	for _, opt := range options {
+		str := strings.TrimSpace(data[opt.key])
+		if str == "" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(str), opt.value); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%s: %s", opt.key, err)
+		}
 	}
-	return cfg
+	return cfg, firstErr
 }
 
-func getKubeClient() (*corev1.CoreV1Client, error) {
-	clusterCfg, err := rest.InClusterConfig()
-	if err != nil {
-		klog.Errorf("Initial cluster configuration failed with error: %v", err)
-		return nil, err
+// Validate checks the enum values and the ranges of the options, the error names the offending option.
+func (c *LoadbalancerConfig) Validate() error {
+	if err := c.LoadBalancerOpts.validate(); err != nil {
+		return fmt.Errorf("loadBalancerOption: %s", err)
+	}
+	if err := c.NetworkingOpts.validate(); err != nil {
+		return fmt.Errorf("networkingOption: %s", err)
+	}
+	if err := c.MetadataOpts.validate(); err != nil {
+		return fmt.Errorf("metadataOption: %s", err)
 	}
+	if err := c.NodeOpts.validate(); err != nil {
+		return fmt.Errorf("nodeOption: %s", err)
+	}
+	return nil
+}
 
-	kubeClient, err := corev1.NewForConfig(clusterCfg)
-	if err != nil {
-		return nil, err
+// Validate checks the enum values and the ranges of the load balancer options, such as the options overridden by
+// the annotations of a service.
+func (l *LoadBalancerOptions) Validate() error {
+	return l.validate()
+}
+
+func (l *LoadBalancerOptions) validate() error {
+	if err := validateOneOf("lb-algorithm", l.LBAlgorithm, lbAlgorithms); err != nil {
+		return err
+	}
+	if err := validateOneOf("session-affinity-flag", l.SessionAffinityFlag, flagValues); err != nil {
+		return err
+	}
+	if err := validateOneOf("health-check-flag", l.HealthCheckFlag, flagValues); err != nil {
+		return err
+	}
+	if err := validateRange("idle-timeout", l.IdleTimeout, 0, 4000); err != nil {
+		return err
+	}
+	if err := validateRange("request-timeout", l.RequestTimeout, 0, 300); err != nil {
+		return err
+	}
+	if err := validateRange("response-timeout", l.ResponseTimeout, 0, 300); err != nil {
+		return err
+	}
+	if l.AvailabilityZoneCacheTTL < 0 {
+		return fmt.Errorf("invalid availability-zone-cache-ttl %d, expected a non-negative number",
+			l.AvailabilityZoneCacheTTL)
+	}
+
+	check := l.HealthCheckOption
+	if err := validateRange("health-check-option.delay", int(check.Delay), 1, 50); err != nil {
+		return err
+	}
+	if err := validateRange("health-check-option.timeout", int(check.Timeout), 1, 50); err != nil {
+		return err
+	}
+	return validateRange("health-check-option.max_retries", int(check.MaxRetries), 1, 10)
+}
+
+func (n *NetworkingOptions) validate() error {
+	for _, t := range n.AddressTypeOrder {
+		if err := validateOneOf("address-type-order", t, addressTypes); err != nil {
+			return err
+		}
 	}
-	return kubeClient, err
+	return nil
+}
+
+func (n *NodeOptions) validate() error {
+	if n.NotReadyGracePeriod < 0 {
+		return fmt.Errorf("invalid not-ready-grace-period %d, expected a non-negative number",
+			n.NotReadyGracePeriod)
+	}
+	return nil
+}
+
+func (m *MetadataOptions) validate() error {
+	for _, id := range strings.Split(m.SearchOrder, ",") {
+		if err := validateOneOf("search-order", strings.TrimSpace(id), searchOrderIDs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateOneOf checks the value is one of the allowed, an empty value is valid as the default is used.
+func validateOneOf(key, value string, allowed []string) error {
+	if value == "" {
+		return nil
+	}
+	for _, v := range allowed {
+		if value == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid %s %q, expected one of %s", key, value, strings.Join(allowed, ", "))
+}
+
+func validateRange(key string, value, min, max int) error {
+	if value < min || value > max {
+		return fmt.Errorf("invalid %s %d, expected %d to %d", key, value, min, max)
+	}
+	return nil
 }
 
 func (l *LoadBalancerOptions) initDefaultValue() {
@@ -153,6 +320,9 @@ func (l *LoadBalancerOptions) initDefaultValue() {
 		MaxRetries: HealthCheckMaxRetries,
 		Delay:      HealthCheckDelay,
 	}
+	if l.AvailabilityZoneCacheTTL <= 0 {
+		l.AvailabilityZoneCacheTTL = DefaultAvailabilityZoneCacheTTL
+	}
 }
 
 func (m *MetadataOptions) initDefaultValue() {
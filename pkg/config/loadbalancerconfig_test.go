@@ -3,6 +3,7 @@ package config
 import (
 	"strconv"
 	"testing"
+	"time"
 )
 
 func TestLoadELBConfigBasic(t *testing.T) {
@@ -52,7 +53,12 @@ func TestLoadELBConfigBasic(t *testing.T) {
 			"internal-network-name": ["` + internalNetworkName + `"]
 		}`,
 		"metadataOption": `{
-			"search-order": "` + searchOrder + `"
+			"search-order": "` + searchOrder + `",
+			"node-name-from-metadata": true
+		}`,
+		"nodeOption": `{
+			"taint-spot-instances": true,
+			"not-ready-grace-period": 120
 		}`,
 	}
 
@@ -95,4 +101,76 @@ func TestLoadELBConfigBasic(t *testing.T) {
 	if cfg.MetadataOpts.SearchOrder != searchOrder {
 		t.Fatalf("SearchOrder, expected: %v, got: %v", searchOrder, cfg.MetadataOpts.SearchOrder)
 	}
+	if !cfg.MetadataOpts.NodeNameFromMetadata {
+		t.Fatalf("NodeNameFromMetadata, expected: true, got: %v", cfg.MetadataOpts.NodeNameFromMetadata)
+	}
+
+	if !cfg.NodeOpts.TaintSpotInstances {
+		t.Fatalf("TaintSpotInstances, expected: true, got: %v", cfg.NodeOpts.TaintSpotInstances)
+	}
+	if cfg.NodeOpts.GetNotReadyGracePeriod() != 2*time.Minute {
+		t.Fatalf("NotReadyGracePeriod, expected: 2m0s, got: %v", cfg.NodeOpts.GetNotReadyGracePeriod())
+	}
+}
+
+func TestParseELBConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "empty",
+			data:    map[string]string{},
+			wantErr: false,
+		},
+		{
+			name:    "malformed json",
+			data:    map[string]string{"loadBalancerOption": `{"lb-algorithm": "ROUND_ROBIN"`},
+			wantErr: true,
+		},
+		{
+			name:    "invalid lb-algorithm",
+			data:    map[string]string{"loadBalancerOption": `{"lb-algorithm": "RANDOM"}`},
+			wantErr: true,
+		},
+		{
+			name:    "invalid health-check-flag",
+			data:    map[string]string{"loadBalancerOption": `{"health-check-flag": "true"}`},
+			wantErr: true,
+		},
+		{
+			name:    "health check delay out of range",
+			data:    map[string]string{"loadBalancerOption": `{"health-check-option": {"delay": 100}}`},
+			wantErr: true,
+		},
+		{
+			name:    "invalid search-order",
+			data:    map[string]string{"metadataOption": `{"search-order": "metadataService,userData"}`},
+			wantErr: true,
+		},
+		{
+			name:    "negative not-ready-grace-period",
+			data:    map[string]string{"nodeOption": `{"not-ready-grace-period": -1}`},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseELBConfig(NewDefaultELBConfig(), tt.data)
+			if err == nil {
+				err = cfg.Validate()
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseELBConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefaultELBConfigValid(t *testing.T) {
+	if err := NewDefaultELBConfig().Validate(); err != nil {
+		t.Errorf("the default config is invalid: %s", err)
+	}
 }
@@ -0,0 +1,58 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"reflect"
+)
+
+// ReloadableSections are the sections of the cloud config applied without restarting, the other sections
+// require a restart.
+var ReloadableSections = map[string]bool{
+	"LoadBalancer": true,
+	"Networking":   true,
+}
+
+// ChangedSections returns the names of the sections of the cloud config which differ from the other,
+// in the order of the sections. Only the options of the sections are compared, not the states derived from them,
+// such as the credentials loaded.
+func (c *CloudConfig) ChangedSections(other *CloudConfig) []string {
+	var sections []string
+	current, next := reflect.ValueOf(c).Elem(), reflect.ValueOf(other).Elem()
+	for i := 0; i < current.NumField(); i++ {
+		if !optionsEqual(current.Field(i), next.Field(i)) {
+			sections = append(sections, current.Type().Field(i).Tag.Get("gcfg"))
+		}
+	}
+	return sections
+}
+
+// optionsEqual compares the exported fields of the sections, the unexported fields hold the states.
+func optionsEqual(a, b reflect.Value) bool {
+	if a.Kind() != reflect.Struct {
+		return reflect.DeepEqual(a.Interface(), b.Interface())
+	}
+	for i := 0; i < a.NumField(); i++ {
+		if !a.Type().Field(i).IsExported() {
+			continue
+		}
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			return false
+		}
+	}
+	return true
+}
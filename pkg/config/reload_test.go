@@ -0,0 +1,91 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const reloadBaseConfig = `
+[Global]
+region=ap-southeast-1
+access-key=ak
+secret-key=sk
+project-id=project-1
+
+[LoadBalancer]
+lb-algorithm=ROUND_ROBIN
+`
+
+func TestChangedSections(t *testing.T) {
+	base, err := ParseConfig(strings.NewReader(reloadBaseConfig))
+	if err != nil {
+		t.Fatalf("failed to parse config: %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		config   string
+		expected []string
+	}{
+		{
+			name:   "unchanged",
+			config: reloadBaseConfig,
+		},
+		{
+			name:     "load balancer options",
+			config:   strings.Replace(reloadBaseConfig, "ROUND_ROBIN", "LEAST_CONNECTIONS", 1),
+			expected: []string{"LoadBalancer"},
+		},
+		{
+			name:     "credentials and a new section",
+			config:   strings.Replace(reloadBaseConfig, "access-key=ak", "access-key=ak2", 1) + "\n[Quota]\ninterval=60\n",
+			expected: []string{"Global", "Quota"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := ParseConfig(strings.NewReader(tt.config))
+			if err != nil {
+				t.Fatalf("failed to parse config: %s", err)
+			}
+			if got := base.ChangedSections(next); !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("ChangedSections, expected: %v, got: %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseConfigDefaults(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader(reloadBaseConfig))
+	if err != nil {
+		t.Fatalf("failed to parse config: %s", err)
+	}
+	if cfg.ReloadOpts.Interval != DefaultReloadInterval {
+		t.Fatalf("ReloadOpts.Interval, expected: %d, got: %d", DefaultReloadInterval, cfg.ReloadOpts.Interval)
+	}
+	if err = cfg.ValidateLoadBalancer(); err != nil {
+		t.Fatalf("ValidateLoadBalancer, expected no error, got: %s", err)
+	}
+
+	cfg.LoadBalancerOpts.DefaultAnnotations = []string{"kubernetes.io/elb.class"}
+	if err = cfg.ValidateLoadBalancer(); err == nil {
+		t.Fatalf("ValidateLoadBalancer, expected an error of the default-annotation without value")
+	}
+}
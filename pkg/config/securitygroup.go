@@ -0,0 +1,62 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"net"
+)
+
+// DefaultSharedSourceCIDR is the source of the traffic and the health checks of the shared load balancers.
+const DefaultSharedSourceCIDR = "100.125.0.0/16"
+
+// SecurityGroupOptions manages the rules of the security group of the nodes, which allow the node ports of the
+// LoadBalancer services from the load balancers.
+type SecurityGroupOptions struct {
+	// ManageRules enables the rules, they are added when the members are registered and removed with the
+	// load balancers.
+	ManageRules bool `gcfg:"manage-rules"`
+	// ID is the security group of the nodes, the security groups of the ECSs of the nodes are used if it is empty.
+	ID string `gcfg:"id"`
+	// SharedSourceCIDRs are the sources of the shared load balancers, the key can be repeated.
+	// Defaults to DefaultSharedSourceCIDR.
+	SharedSourceCIDRs []string `gcfg:"shared-source-cidr"`
+	// DedicatedSourceCIDRs are the sources of the health checks of the dedicated load balancers, such as their
+	// backend subnets, the key can be repeated. The traffic comes from the clients, which are the
+	// loadBalancerSourceRanges of the service, or any address if they are absent.
+	DedicatedSourceCIDRs []string `gcfg:"dedicated-source-cidr"`
+}
+
+func (o *SecurityGroupOptions) setDefaults() {
+	if len(o.SharedSourceCIDRs) == 0 {
+		o.SharedSourceCIDRs = []string{DefaultSharedSourceCIDR}
+	}
+}
+
+func (o *SecurityGroupOptions) validate() error {
+	for key, cidrs := range map[string][]string{
+		"shared-source-cidr":    o.SharedSourceCIDRs,
+		"dedicated-source-cidr": o.DedicatedSourceCIDRs,
+	} {
+		for _, cidr := range cidrs {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return fmt.Errorf("invalid %s %q in [SecurityGroup] section: %s", key, cidr, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// The defaults of the timeouts in seconds of the requests to the cloud APIs.
+const (
+	DefaultRequestTimeout = 60
+	DefaultDialTimeout    = 10
+)
+
+// keepAlive is the interval of the TCP keep-alive probes of the connections to the cloud APIs.
+const keepAlive = 30 * time.Second
+
+// GetRequestTimeout returns the timeout of a request to the cloud APIs, including the retries of the SDK clients,
+// so that a stuck request does not hang the reconcile.
+func (a *AuthOptions) GetRequestTimeout() time.Duration {
+	if a.RequestTimeout <= 0 {
+		return DefaultRequestTimeout * time.Second
+	}
+	return time.Duration(a.RequestTimeout) * time.Second
+}
+
+// GetServiceRequestTimeout returns the timeout of a request to the service, the request-timeout of its [Endpoint]
+// section takes precedence over the one of the [Global] section.
+func (a *AuthOptions) GetServiceRequestTimeout(catalogName string) time.Duration {
+	if override, ok := a.endpointOverrides[catalogName]; ok && override != nil && override.RequestTimeout > 0 {
+		return time.Duration(override.RequestTimeout) * time.Second
+	}
+	return a.GetRequestTimeout()
+}
+
+// Dialer returns the dialer of the connections to the cloud APIs, with the dial timeout and TCP keep-alive.
+func (a *AuthOptions) Dialer() *net.Dialer {
+	timeout := time.Duration(a.DialTimeout) * time.Second
+	if a.DialTimeout <= 0 {
+		timeout = DefaultDialTimeout * time.Second
+	}
+	return &net.Dialer{Timeout: timeout, KeepAlive: keepAlive}
+}
+
+// DialContext returns the dial function of the connections to the cloud APIs bound to the context of the options,
+// see WithContext. As the SDK clients do not accept a context, the connections are closed once the context is done,
+// so that the requests of a canceled reconcile are aborted instead of being sent.
+// The connections are not shared, as each SDK client has its own transport.
+func (a *AuthOptions) DialContext() func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer, bound := a.Dialer(), a.Context()
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if err := bound.Err(); err != nil {
+			return nil, err
+		}
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil || bound.Done() == nil {
+			return conn, err
+		}
+		c := &contextConn{Conn: conn, closed: make(chan struct{})}
+		go func() {
+			select {
+			case <-bound.Done():
+				c.Close()
+			case <-c.closed:
+			}
+		}()
+		return c, nil
+	}
+}
+
+// contextConn is a connection closed when the context it is dialed with is done.
+type contextConn struct {
+	net.Conn
+	once   sync.Once
+	closed chan struct{}
+}
+
+func (c *contextConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() { close(c.closed) })
+	return err
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	subsystem             = "huaweicloud_api"
+	cacheSubsystem        = "huaweicloud_cache"
+	loadBalancerSubsystem = "huaweicloud_loadbalancer"
+	quotaSubsystem        = "huaweicloud_quota"
+)
+
+// ErrorCodeConnection is the error code of the requests failed without a response, such as a refused connection.
+const ErrorCodeConnection = "ConnectionError"
+
+var (
+	requestsTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subsystem,
+			Name:           "requests_total",
+			Help:           "Number of the requests to the Huawei Cloud APIs, partitioned by service, endpoint, method and HTTP status code.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"service", "endpoint", "method", "code"},
+	)
+
+	requestDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      subsystem,
+			Name:           "request_duration_seconds",
+			Help:           "Latency of the requests to the Huawei Cloud APIs, partitioned by service, endpoint and method.",
+			Buckets:        []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"service", "endpoint", "method"},
+	)
+
+	errorsTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subsystem,
+			Name:           "errors_total",
+			Help:           "Number of the failed requests to the Huawei Cloud APIs, partitioned by service and Huawei Cloud error code.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"service", "error_code"},
+	)
+
+	requestsInFlight = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      subsystem,
+			Name:           "requests_in_flight",
+			Help:           "Number of the requests to the Huawei Cloud APIs in flight, partitioned by service.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"service"},
+	)
+
+	cacheLookupsTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      cacheSubsystem,
+			Name:           "lookups_total",
+			Help:           "Number of the lookups of the caches of the cloud resources, partitioned by cache and result.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cache", "result"},
+	)
+
+	cacheEvictionsTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      cacheSubsystem,
+			Name:           "evictions_total",
+			Help:           "Number of the entries removed from the caches of the cloud resources, partitioned by cache.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cache"},
+	)
+
+	cacheEntries = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      cacheSubsystem,
+			Name:           "entries",
+			Help:           "Number of the entries in the caches of the cloud resources, partitioned by cache.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cache"},
+	)
+
+	serviceState = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      loadBalancerSubsystem,
+			Name:           "service_state",
+			Help:           "State of the load balancer of the Service, 1 for the current state and 0 for the others.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"namespace", "name", "state"},
+	)
+
+	serviceLastSuccess = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      loadBalancerSubsystem,
+			Name:           "service_last_success_timestamp_seconds",
+			Help:           "Unix time of the last successful reconcile of the load balancer of the Service.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"namespace", "name"},
+	)
+
+	quotaExceededTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      loadBalancerSubsystem,
+			Name:           "quota_exceeded_total",
+			Help:           "Number of the reconciles failed by the exceeded quotas of Huawei Cloud, partitioned by resource.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource"},
+	)
+
+	quotaUsed = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      quotaSubsystem,
+			Name:           "used",
+			Help:           "Used amount of the quotas of Huawei Cloud, partitioned by region, service and resource.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"region", "service", "resource"},
+	)
+
+	quotaLimit = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      quotaSubsystem,
+			Name:           "limit",
+			Help:           "Limit of the quotas of Huawei Cloud, partitioned by region, service and resource, -1 for unlimited.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"region", "service", "resource"},
+	)
+
+	cacheLastRefresh = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      cacheSubsystem,
+			Name:           "last_refresh_timestamp_seconds",
+			Help:           "Unix time of the last successful refresh of the caches of the cloud resources, partitioned by cache.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cache"},
+	)
+)
+
+// The states of the load balancers of the Services.
+const (
+	ServiceStateProvisioned = "Provisioned"
+	ServiceStateError       = "Error"
+	ServiceStatePending     = "Pending"
+	ServiceStateDeleting    = "Deleting"
+)
+
+var serviceStates = []string{ServiceStateProvisioned, ServiceStateError, ServiceStatePending, ServiceStateDeleting}
+
+// The results of the cache lookups.
+const (
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+var registerOnce sync.Once
+
+// Register registers the metrics to the legacy registry, which is served on the /metrics endpoint
+// of the cloud controller manager.
+func Register() {
+	registerOnce.Do(func() {
+		legacyregistry.MustRegister(requestsTotal)
+		legacyregistry.MustRegister(requestDuration)
+		legacyregistry.MustRegister(errorsTotal)
+		legacyregistry.MustRegister(requestsInFlight)
+		legacyregistry.MustRegister(cacheLookupsTotal)
+		legacyregistry.MustRegister(cacheEvictionsTotal)
+		legacyregistry.MustRegister(cacheEntries)
+		legacyregistry.MustRegister(cacheLastRefresh)
+		legacyregistry.MustRegister(serviceState)
+		legacyregistry.MustRegister(serviceLastSuccess)
+		legacyregistry.MustRegister(quotaExceededTotal)
+		legacyregistry.MustRegister(quotaUsed)
+		legacyregistry.MustRegister(quotaLimit)
+	})
+}
+
+// ObserveRequest records a request to the service which got a response with the status code.
+func ObserveRequest(service, endpoint, method string, statusCode int, latency time.Duration) {
+	requestsTotal.WithLabelValues(service, endpoint, method, strconv.Itoa(statusCode)).Inc()
+	requestDuration.WithLabelValues(service, endpoint, method).Observe(latency.Seconds())
+}
+
+// ObserveError records a failed request to the service, errorCode is the error code of Huawei Cloud,
+// such as "ELB.8902", or ErrorCodeConnection.
+func ObserveError(service, errorCode string) {
+	errorsTotal.WithLabelValues(service, errorCode).Inc()
+}
+
+// StartRequest records a request to the service in flight, the returned function is called once it completes.
+func StartRequest(service string) func() {
+	gauge := requestsInFlight.WithLabelValues(service)
+	gauge.Inc()
+	return gauge.Dec
+}
+
+// ObserveCacheLookup records a lookup of the cache, the result is CacheHit or CacheMiss.
+func ObserveCacheLookup(cache, result string) {
+	cacheLookupsTotal.WithLabelValues(cache, result).Inc()
+}
+
+// ObserveCacheRefresh records a successful refresh of the cache, which holds the entries after the refresh
+// and removed the evicted entries.
+func ObserveCacheRefresh(cache string, entries, evicted int) {
+	cacheEntries.WithLabelValues(cache).Set(float64(entries))
+	cacheEvictionsTotal.WithLabelValues(cache).Add(float64(evicted))
+	cacheLastRefresh.WithLabelValues(cache).SetToCurrentTime()
+}
+
+// SetServiceState sets the state of the load balancer of the Service, ServiceStateProvisioned records
+// the last success as well.
+func SetServiceState(namespace, name, state string) {
+	for _, s := range serviceStates {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		serviceState.WithLabelValues(namespace, name, s).Set(value)
+	}
+	if state == ServiceStateProvisioned {
+		serviceLastSuccess.WithLabelValues(namespace, name).SetToCurrentTime()
+	}
+}
+
+// DeleteServiceState removes the state of the Service whose load balancer is deleted.
+func DeleteServiceState(namespace, name string) {
+	for _, s := range serviceStates {
+		serviceState.DeleteLabelValues(namespace, name, s)
+	}
+	serviceLastSuccess.DeleteLabelValues(namespace, name)
+}
+
+// ObserveQuotaExceeded records a reconcile failed by the exceeded quota of the resource, such as "listener".
+func ObserveQuotaExceeded(resource string) {
+	quotaExceededTotal.WithLabelValues(resource).Inc()
+}
+
+// ObserveQuota records the used amount and the limit of the quota of the resource of the service in the region.
+func ObserveQuota(region, service, resource string, used, limit int) {
+	quotaUsed.WithLabelValues(region, service, resource).Set(float64(used))
+	quotaLimit.WithLabelValues(region, service, resource).Set(float64(limit))
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestObserve(t *testing.T) {
+	Register()
+
+	ObserveRequest("elb", "elb.ap-southeast-1.myhuaweicloud.com", "GET", 200, 80*time.Millisecond)
+	ObserveError("elb", "ELB.8902")
+	done := StartRequest("elb")
+
+	expected := `
+# HELP huaweicloud_api_errors_total [ALPHA] Number of the failed requests to the Huawei Cloud APIs, partitioned by service and Huawei Cloud error code.
+# TYPE huaweicloud_api_errors_total counter
+huaweicloud_api_errors_total{error_code="ELB.8902",service="elb"} 1
+# HELP huaweicloud_api_requests_in_flight [ALPHA] Number of the requests to the Huawei Cloud APIs in flight, partitioned by service.
+# TYPE huaweicloud_api_requests_in_flight gauge
+huaweicloud_api_requests_in_flight{service="elb"} 1
+# HELP huaweicloud_api_requests_total [ALPHA] Number of the requests to the Huawei Cloud APIs, partitioned by service, endpoint, method and HTTP status code.
+# TYPE huaweicloud_api_requests_total counter
+huaweicloud_api_requests_total{code="200",endpoint="elb.ap-southeast-1.myhuaweicloud.com",method="GET",service="elb"} 1
+`
+	err := testutil.GatherAndCompare(legacyregistry.DefaultGatherer, strings.NewReader(expected),
+		"huaweicloud_api_errors_total", "huaweicloud_api_requests_in_flight", "huaweicloud_api_requests_total")
+	if err != nil {
+		t.Fatalf("unexpected metrics: %s", err)
+	}
+
+	done()
+	if v, err := testutil.GetGaugeMetricValue(requestsInFlight.WithLabelValues("elb")); err != nil || v != 0 {
+		t.Fatalf("expected no request in flight, got: %v, %v", v, err)
+	}
+}
+
+func TestObserveCache(t *testing.T) {
+	Register()
+
+	ObserveCacheLookup("ecs", CacheHit)
+	ObserveCacheLookup("ecs", CacheHit)
+	ObserveCacheLookup("ecs", CacheMiss)
+	ObserveCacheRefresh("ecs", 10, 2)
+
+	expected := `
+# HELP huaweicloud_cache_entries [ALPHA] Number of the entries in the caches of the cloud resources, partitioned by cache.
+# TYPE huaweicloud_cache_entries gauge
+huaweicloud_cache_entries{cache="ecs"} 10
+# HELP huaweicloud_cache_evictions_total [ALPHA] Number of the entries removed from the caches of the cloud resources, partitioned by cache.
+# TYPE huaweicloud_cache_evictions_total counter
+huaweicloud_cache_evictions_total{cache="ecs"} 2
+# HELP huaweicloud_cache_lookups_total [ALPHA] Number of the lookups of the caches of the cloud resources, partitioned by cache and result.
+# TYPE huaweicloud_cache_lookups_total counter
+huaweicloud_cache_lookups_total{cache="ecs",result="hit"} 2
+huaweicloud_cache_lookups_total{cache="ecs",result="miss"} 1
+`
+	err := testutil.GatherAndCompare(legacyregistry.DefaultGatherer, strings.NewReader(expected),
+		"huaweicloud_cache_entries", "huaweicloud_cache_evictions_total", "huaweicloud_cache_lookups_total")
+	if err != nil {
+		t.Fatalf("unexpected metrics: %s", err)
+	}
+	if v, err := testutil.GetGaugeMetricValue(cacheLastRefresh.WithLabelValues("ecs")); err != nil || v == 0 {
+		t.Fatalf("expected the refresh time to be set, got: %v, %v", v, err)
+	}
+}
+
+func TestServiceState(t *testing.T) {
+	Register()
+
+	SetServiceState("default", "nginx", ServiceStatePending)
+	SetServiceState("default", "nginx", ServiceStateProvisioned)
+	SetServiceState("default", "web", ServiceStateError)
+	SetServiceState("default", "old", ServiceStateDeleting)
+	DeleteServiceState("default", "old")
+	ObserveQuotaExceeded("listener")
+
+	expected := `
+# HELP huaweicloud_loadbalancer_service_state [ALPHA] State of the load balancer of the Service, 1 for the current state and 0 for the others.
+# TYPE huaweicloud_loadbalancer_service_state gauge
+huaweicloud_loadbalancer_service_state{name="nginx",namespace="default",state="Deleting"} 0
+huaweicloud_loadbalancer_service_state{name="nginx",namespace="default",state="Error"} 0
+huaweicloud_loadbalancer_service_state{name="nginx",namespace="default",state="Pending"} 0
+huaweicloud_loadbalancer_service_state{name="nginx",namespace="default",state="Provisioned"} 1
+huaweicloud_loadbalancer_service_state{name="web",namespace="default",state="Deleting"} 0
+huaweicloud_loadbalancer_service_state{name="web",namespace="default",state="Error"} 1
+huaweicloud_loadbalancer_service_state{name="web",namespace="default",state="Pending"} 0
+huaweicloud_loadbalancer_service_state{name="web",namespace="default",state="Provisioned"} 0
+# HELP huaweicloud_loadbalancer_quota_exceeded_total [ALPHA] Number of the reconciles failed by the exceeded quotas of Huawei Cloud, partitioned by resource.
+# TYPE huaweicloud_loadbalancer_quota_exceeded_total counter
+huaweicloud_loadbalancer_quota_exceeded_total{resource="listener"} 1
+`
+	err := testutil.GatherAndCompare(legacyregistry.DefaultGatherer, strings.NewReader(expected),
+		"huaweicloud_loadbalancer_service_state", "huaweicloud_loadbalancer_quota_exceeded_total")
+	if err != nil {
+		t.Fatalf("unexpected metrics: %s", err)
+	}
+	if v, err := testutil.GetGaugeMetricValue(serviceLastSuccess.WithLabelValues("default", "nginx")); err != nil || v == 0 {
+		t.Fatalf("expected the last success to be set, got: %v, %v", v, err)
+	}
+}
+
+func TestObserveQuota(t *testing.T) {
+	Register()
+
+	ObserveQuota("ap-southeast-1", "elb", "listener", 8, 10)
+
+	expected := `
+# HELP huaweicloud_quota_limit [ALPHA] Limit of the quotas of Huawei Cloud, partitioned by region, service and resource, -1 for unlimited.
+# TYPE huaweicloud_quota_limit gauge
+huaweicloud_quota_limit{region="ap-southeast-1",resource="listener",service="elb"} 10
+# HELP huaweicloud_quota_used [ALPHA] Used amount of the quotas of Huawei Cloud, partitioned by region, service and resource.
+# TYPE huaweicloud_quota_used gauge
+huaweicloud_quota_used{region="ap-southeast-1",resource="listener",service="elb"} 8
+`
+	err := testutil.GatherAndCompare(legacyregistry.DefaultGatherer, strings.NewReader(expected),
+		"huaweicloud_quota_limit", "huaweicloud_quota_used")
+	if err != nil {
+		t.Fatalf("unexpected metrics: %s", err)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"k8s.io/component-base/tracing"
+	tracingapi "k8s.io/component-base/tracing/api/v1"
+)
+
+const (
+	instrumentationName = "sigs.k8s.io/cloud-provider-huaweicloud"
+	serviceName         = "huawei-cloud-controller-manager"
+)
+
+var (
+	lock     sync.RWMutex
+	provider oteltrace.TracerProvider = oteltrace.NewNoopTracerProvider()
+)
+
+// Setup exports the spans to the OTLP gRPC endpoint, the spans are dropped until it is called.
+// The returned function flushes and stops the exporter.
+func Setup(ctx context.Context, endpoint string, samplingRatePerMillion int) (func(context.Context) error, error) {
+	rate := int32(samplingRatePerMillion)
+	tp, err := tracing.NewProvider(ctx, &tracingapi.TracingConfiguration{
+		Endpoint:               &endpoint,
+		SamplingRatePerMillion: &rate,
+	}, nil, []resource.Option{resource.WithAttributes(semconv.ServiceNameKey.String(serviceName))})
+	if err != nil {
+		return nil, err
+	}
+
+	setProvider(tp)
+	return tp.Shutdown, nil
+}
+
+func setProvider(tp oteltrace.TracerProvider) {
+	lock.Lock()
+	defer lock.Unlock()
+	provider = tp
+}
+
+// Start starts a span, which is the child of the span in the context if any.
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, oteltrace.Span) {
+	lock.RLock()
+	tracer := provider.Tracer(instrumentationName)
+	lock.RUnlock()
+	return tracer.Start(ctx, name, oteltrace.WithAttributes(attributes...))
+}
+
+// End ends the span, the error is recorded and fails the span if it is not nil.
+func End(span oteltrace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
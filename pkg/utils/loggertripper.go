@@ -28,10 +28,23 @@ import (
 	"k8s.io/klog/v2"
 )
 
+// PayloadLogLevel is the verbosity to log the request and response payloads of the API requests,
+// the secrets in the payloads are masked.
+const PayloadLogLevel = 8
+
 // LogRoundTripper satisfies the http.RoundTripper interface and is used to
 // customize the default http client RoundTripper to allow for logging.
 type LogRoundTripper struct {
 	Rt http.RoundTripper
+	// Logger logs the payloads, such as the logger of the reconcile, defaults to the global logger.
+	Logger *klog.Logger
+}
+
+func (lrt *LogRoundTripper) payloadLogger() klog.Logger {
+	if lrt.Logger != nil {
+		return lrt.Logger.V(PayloadLogLevel)
+	}
+	return klog.Background().V(PayloadLogLevel)
 }
 
 // RoundTrip performs a round-trip HTTP request and logs relevant information about it.
@@ -67,9 +80,12 @@ func (lrt *LogRoundTripper) RoundTrip(request *http.Request) (*http.Response, er
 	return response, err
 }
 
-// LogRequest will log the HTTP Request details.
+// LogRequest will log the HTTP Request details at PayloadLogLevel.
 // If the body is JSON, it will attempt to be pretty-formatted.
 func (lrt *LogRoundTripper) LogRequest(original io.ReadCloser, contentType string) (io.ReadCloser, error) {
+	if !lrt.payloadLogger().Enabled() {
+		return original, nil
+	}
 	defer original.Close()
 
 	var bs bytes.Buffer
@@ -78,20 +94,16 @@ func (lrt *LogRoundTripper) LogRequest(original io.ReadCloser, contentType strin
 		return nil, err
 	}
 
-	// Handle request contentType
-	if strings.HasPrefix(contentType, "application/json") {
-		debugInfo := lrt.formatJSON(bs.Bytes())
-		klog.V(6).Infof("Request Body: %s", debugInfo)
-	} else {
-		klog.V(6).Infof("Request Body: %s", bs.String())
-	}
-
+	lrt.LogPayload("Request Body", bs.Bytes(), contentType)
 	return io.NopCloser(strings.NewReader(bs.String())), nil
 }
 
-// LogResponse will log the HTTP Response details.
+// LogResponse will log the HTTP Response details at PayloadLogLevel.
 // If the body is JSON, it will attempt to be pretty-formatted.
 func (lrt *LogRoundTripper) LogResponse(original io.ReadCloser, contentType string) (io.ReadCloser, error) {
+	if !lrt.payloadLogger().Enabled() || original == nil {
+		return original, nil
+	}
 	if strings.HasPrefix(contentType, "application/json") {
 		var bs bytes.Buffer
 		defer original.Close()
@@ -99,17 +111,29 @@ func (lrt *LogRoundTripper) LogResponse(original io.ReadCloser, contentType stri
 		if err != nil {
 			return nil, err
 		}
-		debugInfo := lrt.formatJSON(bs.Bytes())
-		if debugInfo != "" {
-			klog.V(6).Infof("Response Body: %s", debugInfo)
-		}
+		lrt.LogPayload("Response Body", bs.Bytes(), contentType)
 		return io.NopCloser(strings.NewReader(bs.String())), nil
 	}
 
-	klog.V(6).Infof("Not logging because response body isn't JSON")
+	lrt.payloadLogger().Info("Not logging because response body isn't JSON", "contentType", contentType)
 	return original, nil
 }
 
+// LogPayload logs the payload at PayloadLogLevel with the secrets masked.
+func (lrt *LogRoundTripper) LogPayload(msg string, body []byte, contentType string) {
+	logger := lrt.payloadLogger()
+	if !logger.Enabled() {
+		return
+	}
+	payload := string(body)
+	if strings.HasPrefix(contentType, "application/json") {
+		payload = lrt.formatJSON(body)
+	}
+	if payload != "" {
+		logger.Info(msg, "payload", ScrubSecrets(payload))
+	}
+}
+
 // formatJSON will try to pretty-format a JSON body.
 // It will also mask known fields which contain sensitive information.
 func (lrt *LogRoundTripper) formatJSON(raw []byte) string {
@@ -154,7 +178,7 @@ func RedactHeaders(headers http.Header) (processedHeaders []string) {
 	var redactheaders = []string{"x-auth-token", "x-auth-key", "x-service-token",
 		"x-storage-token", "x-account-meta-temp-url-key", "x-account-meta-temp-url-key-2",
 		"x-container-meta-temp-url-key", "x-container-meta-temp-url-key-2", "set-cookie",
-		"x-subject-token", "authorization"}
+		"x-subject-token", "x-security-token", "authorization"}
 
 	for name, header := range headers {
 		for _, v := range header {
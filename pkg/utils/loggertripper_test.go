@@ -0,0 +1,51 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+)
+
+func TestLogPayload(t *testing.T) {
+	var logs []string
+	logger := funcr.New(func(_, args string) { logs = append(logs, args) }, funcr.Options{Verbosity: PayloadLogLevel})
+	lrt := LogRoundTripper{Logger: &logger}
+
+	body := `{"secret_string": "sk", "version": {"id": "v1"}}`
+	rst, err := lrt.LogResponse(io.NopCloser(strings.NewReader(body)), "application/json;charset=UTF-8")
+	if err != nil {
+		t.Fatalf("failed to log the response: %s", err)
+	}
+	if read, _ := io.ReadAll(rst); string(read) != body {
+		t.Errorf("expected the body to be restored, got: %s", read)
+	}
+	if len(logs) != 1 || strings.Contains(logs[0], `\"sk\"`) || !strings.Contains(logs[0], "v1") {
+		t.Errorf("expected the payload to be logged with the secret masked, got: %v", logs)
+	}
+
+	logs = nil
+	quiet := funcr.New(func(_, args string) { logs = append(logs, args) }, funcr.Options{Verbosity: PayloadLogLevel - 1})
+	lrt = LogRoundTripper{Logger: &quiet}
+	lrt.LogPayload("Request Body", []byte(body), "application/json")
+	if len(logs) != 0 {
+		t.Errorf("expected the payload not to be logged below the payload log level, got: %v", logs)
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"k8s.io/klog/v2"
 	"k8s.io/utils/exec"
@@ -34,6 +35,7 @@ import (
 const (
 	defaultMetadataVersion = "latest"
 	metadataURLTemplate    = "http://169.254.169.254/openstack/%s/meta_data.json"
+	securityKeyURLTemplate = "http://169.254.169.254/openstack/%s/securitykey"
 
 	// MetadataID is used as an identifier on the metadata search order configuration.
 	MetadataID = "metadataService"
@@ -64,12 +66,38 @@ type Metadata struct {
 	// .. and other fields we don't care about.  Expand as necessary.
 }
 
+// SecurityKey is the temporary security key of the agency attached to the ECS.
+type SecurityKey struct {
+	Access        string    `json:"access"`
+	Secret        string    `json:"secret"`
+	SecurityToken string    `json:"securitytoken"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
 // IMetadata implements GetInstanceID & GetAvailabilityZone
 type IMetadata interface {
 	GetInstanceID() (string, error)
 	GetAvailabilityZone() (string, error)
 }
 
+var _ IMetadata = &Metadata{}
+
+// GetInstanceID returns the ECS ID of the current host.
+func (m *Metadata) GetInstanceID() (string, error) {
+	if m.UUID == "" {
+		return "", ErrBadMetadata
+	}
+	return m.UUID, nil
+}
+
+// GetAvailabilityZone returns the availability zone of the current host.
+func (m *Metadata) GetAvailabilityZone() (string, error) {
+	if m.AvailabilityZone == "" {
+		return "", fmt.Errorf("invalid HuaweiCloud metadata, got empty availability_zone")
+	}
+	return m.AvailabilityZone, nil
+}
+
 // parseMetadata reads JSON from HuaweiCloud metadata server and parses
 // instance ID out of it.
 func parseMetadata(r io.Reader) (*Metadata, error) {
@@ -176,6 +204,38 @@ func getFromMetadataService(metadataVersion string) (*Metadata, error) {
 	return parseMetadata(resp.Body)
 }
 
+func parseSecurityKey(r io.Reader) (*SecurityKey, error) {
+	var body struct {
+		Credential SecurityKey `json:"credential"`
+	}
+	if err := json.NewDecoder(r).Decode(&body); err != nil {
+		return nil, err
+	}
+	if body.Credential.Access == "" || body.Credential.Secret == "" {
+		return nil, fmt.Errorf("invalid security key, got empty access or secret, " +
+			"check whether an agency is attached to the ECS")
+	}
+	return &body.Credential, nil
+}
+
+// GetSecurityKey retrieves the temporary security key of the agency attached to the ECS from the metadata service.
+// The key is not cached, because it is renewed before the expiration.
+func GetSecurityKey() (*SecurityKey, error) {
+	url := fmt.Sprintf(securityKeyURLTemplate, defaultMetadataVersion)
+	klog.V(4).Infof("Attempting to fetch security key from %s", url)
+	resp, err := http.Get(url) //nolint: gosec
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code when reading security key from %s: %s", url, resp.Status)
+	}
+
+	return parseSecurityKey(resp.Body)
+}
+
 // Get retrieves metadata from either config drive or metadata service.
 // Search order depends on the order set in config file.
 func Get(order string) (*Metadata, error) {
@@ -55,4 +55,40 @@ func TestParseMetadata(t *testing.T) {
 	if md.RegionID != "ap-southeast-1" {
 		t.Errorf("incorrect region: %s", md.AvailabilityZone)
 	}
+
+	if id, err := md.GetInstanceID(); err != nil || id != md.UUID {
+		t.Errorf("incorrect instance ID: %s, error: %v", id, err)
+	}
+
+	if az, err := md.GetAvailabilityZone(); err != nil || az != md.AvailabilityZone {
+		t.Errorf("incorrect availability zone: %s, error: %v", az, err)
+	}
+}
+
+func TestParseSecurityKey(t *testing.T) {
+	if _, err := parseSecurityKey(strings.NewReader(`{"credential": {}}`)); err == nil {
+		t.Errorf("Should fail when the access and secret are empty")
+	}
+
+	key, err := parseSecurityKey(strings.NewReader(`
+{
+  "credential": {
+    "access": "ak",
+    "secret": "sk",
+    "securitytoken": "token",
+    "expires_at": "2023-06-01T08:00:00.000000Z"
+  }
+}
+`))
+	if err != nil {
+		t.Fatalf("Should succeed when provided with valid data: %s", err)
+	}
+
+	if key.Access != "ak" || key.Secret != "sk" || key.SecurityToken != "token" {
+		t.Errorf("incorrect security key: %#v", key)
+	}
+
+	if key.ExpiresAt.Hour() != 8 {
+		t.Errorf("incorrect expires_at: %s", key.ExpiresAt)
+	}
 }
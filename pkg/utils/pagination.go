@@ -0,0 +1,53 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import "fmt"
+
+// DefaultPageLimit is the page size of the list APIs paginated by the marker, it is accepted by all the ELB,
+// VPC, EIP and NAT list APIs, whose default and maximum limits vary.
+const DefaultPageLimit = 1000
+
+// maxPages stops the listing if the API keeps returning the pages, such as it ignores the marker.
+const maxPages = 10000
+
+// ListAllByMarker lists all the pages of a list API paginated by the marker, starting from the marker passed in.
+// list returns the items of the page of the marker and the marker of the next page, which is empty on the last page.
+func ListAllByMarker[T any](marker string, list func(marker string) ([]T, string, error)) ([]T, error) {
+	var all []T
+	for i := 0; i < maxPages; i++ {
+		items, next, err := list(marker)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if next == "" || next == marker {
+			return all, nil
+		}
+		marker = next
+	}
+	return nil, fmt.Errorf("failed to list all the pages, more than %d pages are returned", maxPages)
+}
+
+// NextMarkerByLimit returns the marker of the next page of the APIs which do not return the marker,
+// it is the ID of the last item of a full page, and empty if the page is not full.
+func NextMarkerByLimit(count, limit int, lastID string) string {
+	if count < limit {
+		return ""
+	}
+	return lastID
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestListAllByMarker(t *testing.T) {
+	items := []string{"a", "b", "c", "d", "e"}
+	limit := 2
+	// list returns the items after the marker, the marker of the next page is the last item of a full page.
+	list := func(marker string) ([]string, string, error) {
+		start := 0
+		for i, item := range items {
+			if item == marker {
+				start = i + 1
+			}
+		}
+		end := start + limit
+		if end > len(items) {
+			end = len(items)
+		}
+		page := items[start:end]
+		lastID := ""
+		if len(page) > 0 {
+			lastID = page[len(page)-1]
+		}
+		return page, NextMarkerByLimit(len(page), limit, lastID), nil
+	}
+
+	got, err := ListAllByMarker("", list)
+	if err != nil {
+		t.Fatalf("ListAllByMarker, unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(got, items) {
+		t.Errorf("ListAllByMarker, expected %v, got %v", items, got)
+	}
+
+	got, err = ListAllByMarker("b", list)
+	if err != nil {
+		t.Fatalf("ListAllByMarker, unexpected error: %s", err)
+	}
+	if want := []string{"c", "d", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ListAllByMarker from marker b, expected %v, got %v", want, got)
+	}
+
+	// A full last page is followed by an empty page.
+	limit = 5
+	got, err = ListAllByMarker("", list)
+	if err != nil || !reflect.DeepEqual(got, items) {
+		t.Errorf("ListAllByMarker with a full page, expected %v, got %v, error: %v", items, got, err)
+	}
+
+	if _, err = ListAllByMarker("", func(string) ([]string, string, error) {
+		return nil, "", fmt.Errorf("internal error")
+	}); err == nil {
+		t.Errorf("ListAllByMarker, expected the error of the list")
+	}
+
+	// The API ignoring the marker does not loop forever.
+	calls := 0
+	got, err = ListAllByMarker("", func(marker string) ([]string, string, error) {
+		calls++
+		return []string{"a"}, "a", nil
+	})
+	if err != nil || calls != 2 || len(got) != 2 {
+		t.Errorf("ListAllByMarker ignoring the marker, expected 2 calls, got %d calls, %v, error: %v",
+			calls, got, err)
+	}
+}
@@ -19,6 +19,17 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"net"
+	"regexp"
+)
+
+var (
+	// secretFieldRegexp matches the JSON fields of the secrets, such as "password": "xxx".
+	secretFieldRegexp = regexp.MustCompile(
+		`(?i)("[^"]*(?:password|secret|token|credential|access_?key|private_?key)[^"]*"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	// secretParamRegexp matches the secrets in the form of key=value, such as the signature of the authorization.
+	secretParamRegexp = regexp.MustCompile(
+		`(?i)\b(password|secret|token|credential|signature|access_?key|private_?key)=[^\s,&"]+`)
 )
 
 // IsStrSliceContains searches if a string list contains the given string or not.
@@ -39,6 +50,13 @@ func CutString(original string, length int) string {
 	return rst
 }
 
+// ScrubSecrets masks the values of the JSON fields and the parameters whose names suggest a secret,
+// such as the passwords, tokens and access keys, it is used before exposing a response body.
+func ScrubSecrets(s string) string {
+	s = secretFieldRegexp.ReplaceAllString(s, `$1"***"`)
+	return secretParamRegexp.ReplaceAllString(s, "$1=***")
+}
+
 func ToString(a any) string {
 	if v, ok := a.(string); ok {
 		return v
@@ -54,3 +72,16 @@ func ToString(a any) string {
 
 	return string(b)
 }
+
+// IsCIDROverlapped checks whether the two CIDRs overlap, an invalid CIDR is not overlapped with any CIDR.
+func IsCIDROverlapped(cidr1, cidr2 string) bool {
+	_, net1, err := net.ParseCIDR(cidr1)
+	if err != nil {
+		return false
+	}
+	_, net2, err := net.ParseCIDR(cidr2)
+	if err != nil {
+		return false
+	}
+	return net1.Contains(net2.IP) || net2.Contains(net1.IP)
+}
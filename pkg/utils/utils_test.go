@@ -176,3 +176,84 @@ func TestToJsonStr(t *testing.T) {
 		})
 	}
 }
+
+func TestIsCIDROverlapped(t *testing.T) {
+	tests := []struct {
+		name     string
+		cidr1    string
+		cidr2    string
+		expected bool
+	}{
+		{
+			name:     "test1",
+			cidr1:    "172.16.0.0/24",
+			cidr2:    "172.16.0.0/24",
+			expected: true,
+		},
+		{
+			name:     "test2",
+			cidr1:    "172.16.0.0/16",
+			cidr2:    "172.16.1.0/24",
+			expected: true,
+		},
+		{
+			name:     "test3",
+			cidr1:    "172.16.1.0/24",
+			cidr2:    "172.16.0.0/16",
+			expected: true,
+		},
+		{
+			name:     "test4",
+			cidr1:    "172.16.0.0/24",
+			cidr2:    "172.16.1.0/24",
+			expected: false,
+		},
+		{
+			name:     "test5",
+			cidr1:    "172.16.0.0/24",
+			cidr2:    "invalid",
+			expected: false,
+		},
+	}
+
+	for _, te := range tests {
+		t.Run(te.name, func(t *testing.T) {
+			overlapped := IsCIDROverlapped(te.cidr1, te.cidr2)
+			if overlapped != te.expected {
+				t.Fatalf("expected: %v, got : %v", te.expected, overlapped)
+			}
+		})
+	}
+}
+
+func TestScrubSecrets(t *testing.T) {
+	tests := []struct {
+		name     string
+		origin   string
+		expected string
+	}{
+		{
+			name:     "json fields",
+			origin:   `{"admin_password": "p@ss\"word", "security_token":"abc", "name": "elb-1"}`,
+			expected: `{"admin_password": "***", "security_token":"***", "name": "elb-1"}`,
+		},
+		{
+			name:     "parameters",
+			origin:   "Authorization: SDK-HMAC-SHA256 Access=AK123, Signature=abcdef, token=xyz",
+			expected: "Authorization: SDK-HMAC-SHA256 Access=AK123, Signature=***, token=***",
+		},
+		{
+			name:     "no secret",
+			origin:   `{"error_code": "ELB.8902", "error_msg": "Quota exceeded"}`,
+			expected: `{"error_code": "ELB.8902", "error_msg": "Quota exceeded"}`,
+		},
+	}
+
+	for _, te := range tests {
+		t.Run(te.name, func(t *testing.T) {
+			if got := ScrubSecrets(te.origin); got != te.expected {
+				t.Fatalf("expected: %v, got : %v", te.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakecloud
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// AddDNSZone adds the public or private zone of the name, which ends with a dot, it returns the ID of the zone.
+func (s *Server) AddDNSZone(name, zoneType string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	zone := s.create(KindDNSZone, Resource{
+		"name":      name,
+		"zone_type": zoneType,
+		"ttl":       300,
+		"status":    "ACTIVE",
+	})
+	return zone.String("id")
+}
+
+// hasTag returns whether the resource has the tag of the "key,value" form of the tags filter.
+func hasTag(r Resource, filter string) bool {
+	tags, _ := r["tags"].([]interface{})
+	for _, tag := range tags {
+		t, _ := tag.(map[string]interface{})
+		if fmt.Sprintf("%v,%v", t["key"], t["value"]) == filter {
+			return true
+		}
+	}
+	return false
+}
+
+// registerDNS registers the zone and record set APIs of the v2 DNS API, the changes of the record sets are
+// applied at once.
+func (s *Server) registerDNS() {
+	s.handle(http.MethodGet, "/v2/zones", func(r *http.Request, _ []string, _ Resource) (int, interface{}) {
+		var zones []Resource
+		for _, zone := range s.store.list(KindDNSZone, nil) {
+			if zone.String("zone_type") == r.URL.Query().Get("type") {
+				zones = append(zones, zone)
+			}
+		}
+		zones, _ = paginate(zones, r.URL.Query())
+		if zones == nil {
+			zones = []Resource{}
+		}
+		return http.StatusOK, map[string]interface{}{"zones": zones}
+	})
+
+	s.handle(http.MethodGet, "/v2/recordsets", func(r *http.Request, _ []string, _ Resource) (int, interface{}) {
+		var records []Resource
+		for _, record := range s.store.list(KindDNSRecordSet, nil) {
+			if tags := r.URL.Query().Get("tags"); tags == "" || hasTag(record, tags) {
+				records = append(records, record)
+			}
+		}
+		records, _ = paginate(records, r.URL.Query())
+		if records == nil {
+			records = []Resource{}
+		}
+		return http.StatusOK, map[string]interface{}{"recordsets": records}
+	})
+	s.handle(http.MethodPost, "/v2/zones/*/recordsets", func(_ *http.Request, params []string, body Resource) (int, interface{}) {
+		zone := s.store.get(KindDNSZone, params[0])
+		if zone == nil {
+			return notFound(KindDNSZone, params[0])
+		}
+		name := body.String("name")
+		if name != zone.String("name") && !strings.HasSuffix(name, "."+zone.String("name")) {
+			return http.StatusBadRequest, fmt.Sprintf("record set %s is not in zone %s", name, zone.String("name"))
+		}
+		for _, other := range s.store.list(KindDNSRecordSet, map[string][]string{"zone_id": {params[0]}}) {
+			if other.String("name") == name && other.String("type") == body.String("type") {
+				return http.StatusConflict, fmt.Sprintf("the %s record set %s exists", body.String("type"), name)
+			}
+		}
+		body["zone_id"] = params[0]
+		body["zone_name"] = zone.String("name")
+		body["status"] = "ACTIVE"
+		return http.StatusAccepted, s.create(KindDNSRecordSet, body)
+	})
+	s.handle(http.MethodPut, "/v2/zones/*/recordsets/*", func(_ *http.Request, params []string, body Resource) (int, interface{}) {
+		record := s.store.get(KindDNSRecordSet, params[1])
+		if record == nil || record.String("zone_id") != params[0] {
+			return notFound(KindDNSRecordSet, params[1])
+		}
+		for _, field := range []string{"records", "ttl", "description"} {
+			if value, ok := body[field]; ok {
+				record[field] = value
+			}
+		}
+		record["updated_at"] = s.now()
+		return http.StatusAccepted, record
+	})
+	s.handle(http.MethodDelete, "/v2/zones/*/recordsets/*", func(_ *http.Request, params []string, _ Resource) (int, interface{}) {
+		record := s.store.get(KindDNSRecordSet, params[1])
+		if record == nil || record.String("zone_id") != params[0] {
+			return notFound(KindDNSRecordSet, params[1])
+		}
+		s.store.remove(KindDNSRecordSet, params[1])
+		return http.StatusAccepted, record
+	})
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud"
+	"sigs.k8s.io/cloud-provider-huaweicloud/test/fakecloud"
+)
+
+var _ = ginkgo.Describe("cluster scoped names", func() {
+	var cloud *fakecloud.Server
+	var nodes []*corev1.Node
+
+	ginkgo.BeforeEach(func() {
+		cloud = fakecloud.NewServer()
+		ginkgo.DeferCleanup(cloud.Close)
+		cloud.AddAvailabilityZones("az1")
+		cloud.AddServer("node-1", "192.168.1.11", fakecloud.SubnetID)
+		nodes = []*corev1.Node{newNode("node-1", "192.168.1.11")}
+	})
+
+	ginkgo.It("names the resources after the cluster id and keeps the listeners of the other clusters", func() {
+		providerA, clientA := startProvider(cloud, "[Cluster]\nid = cluster-a\n", nodes...)
+		serviceA := newService(clientA, "web", map[string]string{
+			huaweicloud.ElbClass:             "dedicated",
+			huaweicloud.ElbAvailabilityZones: "az1",
+		}, 80)
+		newPods(clientA, serviceA, nodes...)
+		_, err := providerA.EnsureLoadBalancer(context.TODO(), clusterName, serviceA, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+		lbs := cloud.List(fakecloud.KindLoadBalancer)
+		gomega.Expect(lbs).Should(gomega.HaveLen(1))
+		gomega.Expect(lbs[0].String("name")).Should(gomega.Equal("k8s_service_cluster-a_default_web"))
+		description := "Created by the ELB service(default/web) of the k8s cluster(cluster-a)."
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.ConsistOf(gstruct.MatchKeys(gstruct.IgnoreExtras,
+			gstruct.Keys{
+				"name":        gomega.Equal("k8s_cluster-a_default_web_TCP_80"),
+				"description": gomega.Equal(description),
+			})))
+		gomega.Expect(cloud.List(fakecloud.KindPool)).Should(gomega.ConsistOf(gstruct.MatchKeys(gstruct.IgnoreExtras,
+			gstruct.Keys{"description": gomega.Equal(description)})))
+
+		ginkgo.By("sharing the load balancer with a service of the same name in another cluster")
+		providerB, clientB := startProvider(cloud, "[Cluster]\nid = cluster-b\n", nodes...)
+		serviceB := newService(clientB, "web", map[string]string{
+			huaweicloud.ElbClass: "dedicated",
+			huaweicloud.ElbID:    lbs[0].String("id"),
+		}, 80)
+		newPods(clientB, serviceB, nodes...)
+		_, err = providerB.EnsureLoadBalancer(context.TODO(), clusterName, serviceB, nodes)
+		gomega.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring(
+			"is used by the service default/web of the k8s cluster cluster-a")))
+
+		serviceB.Spec.Ports[0].Port = 8080
+		_, err = providerB.EnsureLoadBalancer(context.TODO(), clusterName, serviceB, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(listOf(cloud, fakecloud.KindListener, "name", "k8s_cluster-b_default_web_TCP_8080")).
+			Should(gomega.HaveLen(1))
+
+		ginkgo.By("deleting the service of the other cluster with both ports")
+		serviceB.Spec.Ports = append(serviceB.Spec.Ports, serviceA.Spec.Ports[0])
+		err = providerB.EnsureLoadBalancerDeleted(context.TODO(), clusterName, serviceB)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.ConsistOf(gstruct.MatchKeys(gstruct.IgnoreExtras,
+			gstruct.Keys{"name": gomega.Equal("k8s_cluster-a_default_web_TCP_80")})))
+	})
+
+	ginkgo.It("names the resources after the cluster name by default", func() {
+		provider, client := startProvider(cloud, "", nodes...)
+		service := newService(client, "web", map[string]string{huaweicloud.ElbClass: "shared"}, 80)
+		newPods(client, service, nodes...)
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.ConsistOf(gstruct.MatchKeys(gstruct.IgnoreExtras,
+			gstruct.Keys{
+				"name":        gomega.Equal("k8s_" + clusterName + "_default_web_TCP_80"),
+				"description": gomega.Equal("Created by the ELB service(default/web) of the k8s cluster(" + clusterName + ")."),
+			})))
+	})
+
+	ginkgo.It("keeps the resources created before the cluster id is set", func() {
+		provider, client := startProvider(cloud, "", nodes...)
+		service := newService(client, "web", map[string]string{
+			huaweicloud.ElbClass:             "dedicated",
+			huaweicloud.ElbAvailabilityZones: "az1",
+		}, 80)
+		newPods(client, service, nodes...)
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)).Should(gomega.HaveLen(1))
+
+		ginkgo.By("setting the cluster id on the running cluster")
+		provider, _ = startProvider(cloud, "[Cluster]\nid = cluster-a\n", nodes...)
+		_, exists, err := provider.GetLoadBalancer(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(exists).Should(gomega.BeTrue())
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		lbs := cloud.List(fakecloud.KindLoadBalancer)
+		gomega.Expect(lbs).Should(gomega.HaveLen(1))
+		gomega.Expect(lbs[0].String("name")).Should(gomega.Equal("k8s_service_" + clusterName + "_default_web"))
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.ConsistOf(gstruct.MatchKeys(gstruct.IgnoreExtras,
+			gstruct.Keys{
+				"name":        gomega.Equal("k8s_cluster-a_default_web_TCP_80"),
+				"description": gomega.Equal("Created by the ELB service(default/web) of the k8s cluster(cluster-a)."),
+			})))
+
+		err = provider.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)).Should(gomega.BeEmpty())
+	})
+})
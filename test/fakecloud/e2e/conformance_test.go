@@ -0,0 +1,184 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud"
+	"sigs.k8s.io/cloud-provider-huaweicloud/test/fakecloud"
+)
+
+// conformanceClass prepares the fake cloud for a load balancer class, and returns the annotations and
+// the loadBalancerIP of the service.
+type conformanceClass func(cloud *fakecloud.Server) (map[string]string, string)
+
+// The conformance specs check the contract of the LoadBalancer interface of k8s.io/cloud-provider relied on by
+// the service controller, for each class. They are focused by "make test-conformance".
+var _ = ginkgo.Describe("[Conformance] load balancer", func() {
+	describeConformance("shared", func(cloud *fakecloud.Server) (map[string]string, string) {
+		return map[string]string{
+			huaweicloud.ElbClass:             "shared",
+			huaweicloud.AutoCreateEipOptions: `{"ip_type": "5_bgp", "bandwidth_size": 5, "share_type": "PER", "charge_mode": "bandwidth"}`,
+		}, ""
+	})
+
+	describeConformance("dedicated", func(cloud *fakecloud.Server) (map[string]string, string) {
+		cloud.AddAvailabilityZones("az1")
+		return map[string]string{
+			huaweicloud.ElbClass:             "dedicated",
+			huaweicloud.ElbAvailabilityZones: "az1",
+		}, ""
+	})
+
+	describeConformance("dnat", func(cloud *fakecloud.Server) (map[string]string, string) {
+		const floatingIP = "100.64.1.10"
+		cloud.AddFloatingIP(floatingIP)
+		return map[string]string{
+			huaweicloud.ElbClass:         "dnat",
+			huaweicloud.AnnotationsNATID: cloud.AddNATGateway(fakecloud.VpcID, fakecloud.SubnetID),
+		}, floatingIP
+	})
+
+	ginkgo.Context("of an unknown class", func() {
+		var provider *huaweicloud.CloudProvider
+		var nodes []*corev1.Node
+		var service *corev1.Service
+
+		ginkgo.BeforeEach(func() {
+			cloud := fakecloud.NewServer()
+			ginkgo.DeferCleanup(cloud.Close)
+			cloud.AddServer("node-1", "192.168.1.11", fakecloud.SubnetID)
+			nodes = []*corev1.Node{newNode("node-1", "192.168.1.11")}
+
+			var client kubernetes.Interface
+			provider, client = startProvider(cloud, "", nodes...)
+			service = newService(client, "unknown", map[string]string{huaweicloud.ElbClass: "unknown"}, 80)
+		})
+
+		ginkgo.It("returns an error instead of an empty status", func() {
+			status, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+			gomega.Expect(err).Should(gomega.HaveOccurred())
+			gomega.Expect(status).Should(gomega.BeNil())
+
+			_, _, err = provider.GetLoadBalancer(context.TODO(), clusterName, service)
+			gomega.Expect(err).Should(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("returns a name", func() {
+			gomega.Expect(provider.GetLoadBalancerName(context.TODO(), clusterName, service)).ShouldNot(gomega.BeEmpty())
+		})
+	})
+})
+
+// describeConformance adds the conformance specs of the load balancer class.
+func describeConformance(class string, prepare conformanceClass) {
+	ginkgo.Context("of class "+class, func() {
+		var cloud *fakecloud.Server
+		var provider *huaweicloud.CloudProvider
+		var nodes []*corev1.Node
+		var service *corev1.Service
+
+		ginkgo.BeforeEach(func() {
+			cloud = fakecloud.NewServer()
+			ginkgo.DeferCleanup(cloud.Close)
+			cloud.AddServer("node-1", "192.168.1.11", fakecloud.SubnetID)
+			cloud.AddServer("node-2", "192.168.1.12", fakecloud.SubnetID)
+			nodes = []*corev1.Node{newNode("node-1", "192.168.1.11")}
+			annotations, loadBalancerIP := prepare(cloud)
+
+			var client kubernetes.Interface
+			provider, client = startProvider(cloud, "", nodes...)
+			service = newService(client, "conformance-"+class, annotations, 80, 443)
+			if loadBalancerIP != "" {
+				service.Spec.LoadBalancerIP = loadBalancerIP
+				var err error
+				service, err = client.CoreV1().Services(testNamespace).Update(context.TODO(), service, metav1.UpdateOptions{})
+				gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			}
+			newPods(client, service, nodes...)
+		})
+
+		ginkgo.It("returns a stable non-empty name", func() {
+			name := provider.GetLoadBalancerName(context.TODO(), clusterName, service)
+			gomega.Expect(name).ShouldNot(gomega.BeEmpty())
+			gomega.Expect(provider.GetLoadBalancerName(context.TODO(), clusterName, service)).Should(gomega.Equal(name))
+		})
+
+		ginkgo.It("does not find the load balancer before it is ensured", func() {
+			status, exists, err := provider.GetLoadBalancer(context.TODO(), clusterName, service)
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			gomega.Expect(exists).Should(gomega.BeFalse())
+			gomega.Expect(status).Should(gomega.BeNil())
+		})
+
+		ginkgo.It("populates the status and treats the service and the nodes as read-only", func() {
+			original, originalNodes := service.DeepCopy(), []*corev1.Node{nodes[0].DeepCopy()}
+			status, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			gomega.Expect(status.Ingress).ShouldNot(gomega.BeEmpty())
+			gomega.Expect(status.Ingress[0].IP).ShouldNot(gomega.BeEmpty())
+			gomega.Expect(service).Should(gomega.Equal(original))
+			gomega.Expect(nodes).Should(gomega.Equal(originalNodes))
+
+			actual, exists, err := provider.GetLoadBalancer(context.TODO(), clusterName, service)
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			gomega.Expect(exists).Should(gomega.BeTrue())
+			gomega.Expect(actual).Should(gomega.Equal(status))
+
+			again, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			gomega.Expect(again).Should(gomega.Equal(status))
+		})
+
+		ginkgo.It("updates the hosts of the existing load balancer", func() {
+			_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+			original := service.DeepCopy()
+			nodes = append(nodes, newNode("node-2", "192.168.1.12"))
+			gomega.Expect(provider.UpdateLoadBalancer(context.TODO(), clusterName, service, nodes)).Should(gomega.Succeed())
+			gomega.Expect(service).Should(gomega.Equal(original))
+
+			_, exists, err := provider.GetLoadBalancer(context.TODO(), clusterName, service)
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			gomega.Expect(exists).Should(gomega.BeTrue())
+		})
+
+		ginkgo.It("deletes the load balancer idempotently", func() {
+			gomega.Expect(provider.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)).Should(gomega.Succeed())
+
+			_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			original := service.DeepCopy()
+			gomega.Expect(provider.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)).Should(gomega.Succeed())
+			gomega.Expect(provider.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)).Should(gomega.Succeed())
+			gomega.Expect(service).Should(gomega.Equal(original))
+
+			status, exists, err := provider.GetLoadBalancer(context.TODO(), clusterName, service)
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			gomega.Expect(exists).Should(gomega.BeFalse())
+			gomega.Expect(status).Should(gomega.BeNil())
+		})
+	})
+}
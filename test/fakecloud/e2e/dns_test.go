@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud"
+	"sigs.k8s.io/cloud-provider-huaweicloud/test/fakecloud"
+)
+
+// dnsRecords returns the record sets in the form of "<zone> <type> <name> <records>", sorted.
+func dnsRecords(cloud *fakecloud.Server) []string {
+	var records []string
+	for _, record := range cloud.List(fakecloud.KindDNSRecordSet) {
+		records = append(records, fmt.Sprintf("%s %s %s %v", record.String("zone_name"), record.String("type"),
+			record.String("name"), record["records"]))
+	}
+	sort.Strings(records)
+	return records
+}
+
+var _ = ginkgo.Describe("DNS records", func() {
+	var cloud *fakecloud.Server
+	var provider *huaweicloud.CloudProvider
+	var client kubernetes.Interface
+	var nodes []*corev1.Node
+
+	ginkgo.BeforeEach(func() {
+		cloud = fakecloud.NewServer()
+		ginkgo.DeferCleanup(cloud.Close)
+		cloud.AddServer("node-1", "192.168.1.11", fakecloud.SubnetID)
+		cloud.AddDNSZone("example.com.", "public")
+		cloud.AddDNSZone("internal.example.com.", "private")
+		nodes = []*corev1.Node{newNode("node-1", "192.168.1.11")}
+		provider, client = startProvider(cloud, "", nodes...)
+	})
+
+	ginkgo.It("points the domain name at the address of the load balancer", func() {
+		service := newService(client, "www", map[string]string{
+			huaweicloud.ElbClass:   "shared",
+			huaweicloud.ElbDNSName: "www.example.com",
+		}, 80)
+		newPods(client, service, nodes...)
+
+		status, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		address := status.Ingress[0].IP
+		gomega.Expect(dnsRecords(cloud)).Should(gomega.Equal([]string{
+			fmt.Sprintf("example.com. A www.example.com. [%s]", address),
+		}))
+
+		ginkgo.By("changing the domain name to a name of the longer private zone")
+		service.Annotations[huaweicloud.ElbDNSName] = "api.internal.example.com."
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(dnsRecords(cloud)).Should(gomega.Equal([]string{
+			fmt.Sprintf("internal.example.com. A api.internal.example.com. [%s]", address),
+		}))
+
+		err = provider.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(dnsRecords(cloud)).Should(gomega.BeEmpty())
+	})
+
+	ginkgo.It("does not overwrite the records not created by the service", func() {
+		service := newService(client, "taken", map[string]string{
+			huaweicloud.ElbClass:   "shared",
+			huaweicloud.ElbDNSName: "www.example.com",
+		}, 80)
+		newPods(client, service, nodes...)
+		other := newService(client, "owner", map[string]string{
+			huaweicloud.ElbClass:   "shared",
+			huaweicloud.ElbDNSName: "www.example.com",
+		}, 80)
+		newPods(client, other, nodes...)
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, other, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring(
+			"the DNS A record www.example.com. already exists and is not created by service default/taken")))
+		gomega.Expect(dnsRecords(cloud)).Should(gomega.HaveLen(1))
+
+		ginkgo.By("deleting the service without touching the record of the other service")
+		err = provider.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(dnsRecords(cloud)).Should(gomega.HaveLen(1))
+	})
+
+	ginkgo.It("fails without a zone of the domain name", func() {
+		service := newService(client, "nozone", map[string]string{
+			huaweicloud.ElbClass:   "shared",
+			huaweicloud.ElbDNSName: "www.example.org",
+		}, 80)
+		newPods(client, service, nodes...)
+
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring("no DNS zone of www.example.org.")))
+	})
+
+	ginkgo.It("does not call the DNS service without the annotation", func() {
+		service := newService(client, "plain", map[string]string{huaweicloud.ElbClass: "shared"}, 80)
+		newPods(client, service, nodes...)
+
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		err = provider.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		for _, request := range cloud.Requests() {
+			gomega.Expect(strings.Contains(request, "/v2/zones") || strings.Contains(request, "/v2/recordsets")).
+				Should(gomega.BeFalse(), request)
+		}
+	})
+})
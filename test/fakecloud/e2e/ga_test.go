@@ -0,0 +1,126 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud"
+	"sigs.k8s.io/cloud-provider-huaweicloud/test/fakecloud"
+)
+
+// gaEndpoints returns the endpoints of the endpoint groups in the form of "<group> <resource> <address>", sorted.
+func gaEndpoints(cloud *fakecloud.Server) []string {
+	var endpoints []string
+	for _, ep := range cloud.List(fakecloud.KindGAEndpoint) {
+		endpoints = append(endpoints, fmt.Sprintf("%s %s %s", ep.String("endpoint_group_id"),
+			ep.String("resource_id"), ep.String("ip_address")))
+	}
+	sort.Strings(endpoints)
+	return endpoints
+}
+
+var _ = ginkgo.Describe("Global Accelerator endpoints", func() {
+	const domainConfig = "[Global]\ndomain-id = fake-domain-id\n"
+	const eipOptions = `{"ip_type": "5_bgp", "bandwidth_size": 5, "share_type": "PER", "charge_mode": "bandwidth"}`
+	var cloud *fakecloud.Server
+	var nodes []*corev1.Node
+	var groupID string
+
+	ginkgo.BeforeEach(func() {
+		cloud = fakecloud.NewServer()
+		ginkgo.DeferCleanup(cloud.Close)
+		cloud.AddServer("node-1", "192.168.1.11", fakecloud.SubnetID)
+		groupID = cloud.AddGAEndpointGroup()
+		nodes = []*corev1.Node{newNode("node-1", "192.168.1.11")}
+	})
+
+	ginkgo.It("adds the EIP of the load balancer to the endpoint group and removes it on delete", func() {
+		provider, client := startProvider(cloud, domainConfig, nodes...)
+		other := cloud.AddPublicIP("100.64.1.1")
+		cloud.AddGAEndpoint(groupID, other, "100.64.1.1")
+		service := newService(client, "accelerated", map[string]string{
+			huaweicloud.ElbClass:             "shared",
+			huaweicloud.AutoCreateEipOptions: eipOptions,
+			huaweicloud.ElbGAEndpointGroupID: groupID,
+		}, 80)
+		newPods(client, service, nodes...)
+
+		status, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		var eipID string
+		for _, eip := range cloud.List(fakecloud.KindPublicIP) {
+			if eip.String("public_ip_address") == status.Ingress[0].IP {
+				eipID = eip.String("id")
+			}
+		}
+		gomega.Expect(eipID).ShouldNot(gomega.BeEmpty())
+		gomega.Expect(gaEndpoints(cloud)).Should(gomega.ConsistOf(
+			fmt.Sprintf("%s %s %s", groupID, eipID, status.Ingress[0].IP),
+			fmt.Sprintf("%s %s 100.64.1.1", groupID, other)))
+
+		ginkgo.By("keeping the endpoint on the next reconcile")
+		service.Status.LoadBalancer = *status
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(gaEndpoints(cloud)).Should(gomega.HaveLen(2))
+
+		ginkgo.By("removing the endpoint of the service only")
+		err = provider.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(gaEndpoints(cloud)).Should(gomega.Equal([]string{
+			fmt.Sprintf("%s %s 100.64.1.1", groupID, other),
+		}))
+	})
+
+	ginkgo.It("fails without an EIP of the load balancer", func() {
+		provider, client := startProvider(cloud, domainConfig, nodes...)
+		service := newService(client, "private", map[string]string{
+			huaweicloud.ElbClass:             "shared",
+			huaweicloud.ElbGAEndpointGroupID: groupID,
+		}, 80)
+		newPods(client, service, nodes...)
+
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring(
+			"the load balancer of service default/private has no EIP to add to the endpoint group")))
+		gomega.Expect(gaEndpoints(cloud)).Should(gomega.BeEmpty())
+	})
+
+	ginkgo.It("requires the domain-id without calling the Global Accelerator", func() {
+		provider, client := startProvider(cloud, "", nodes...)
+		service := newService(client, "nodomain", map[string]string{
+			huaweicloud.ElbClass:             "shared",
+			huaweicloud.AutoCreateEipOptions: eipOptions,
+			huaweicloud.ElbGAEndpointGroupID: groupID,
+		}, 80)
+		newPods(client, service, nodes...)
+
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring("requires domain-id")))
+		for _, request := range cloud.Requests() {
+			gomega.Expect(strings.Contains(request, "/v1/endpoint-groups")).Should(gomega.BeFalse(), request)
+		}
+	})
+})
@@ -0,0 +1,559 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud"
+	"sigs.k8s.io/cloud-provider-huaweicloud/test/fakecloud"
+)
+
+// creations returns the requests creating or deleting the resources of the fake cloud.
+func creations(requests []string) []string {
+	var changes []string
+	for _, request := range requests {
+		if strings.HasPrefix(request, http.MethodPost+" ") || strings.HasPrefix(request, http.MethodDelete+" ") {
+			changes = append(changes, request)
+		}
+	}
+	return changes
+}
+
+var _ = ginkgo.Describe("dedicated load balancer", func() {
+	var cloud *fakecloud.Server
+	var provider *huaweicloud.CloudProvider
+	var client kubernetes.Interface
+	var nodes []*corev1.Node
+	var service *corev1.Service
+
+	ginkgo.BeforeEach(func() {
+		cloud = fakecloud.NewServer()
+		ginkgo.DeferCleanup(cloud.Close)
+		cloud.AddAvailabilityZones("az1", "az2")
+		cloud.AddServer("node-1", "192.168.1.11", fakecloud.SubnetID)
+		cloud.AddServer("node-2", "192.168.1.12", fakecloud.SubnetID)
+		nodes = []*corev1.Node{newNode("node-1", "192.168.1.11"), newNode("node-2", "192.168.1.12")}
+
+		provider, client = startProvider(cloud, "", nodes...)
+		service = newService(client, "dedicated", map[string]string{
+			huaweicloud.ElbClass:             "dedicated",
+			huaweicloud.ElbAvailabilityZones: "az1;az2",
+			huaweicloud.ElbHealthCheckFlag:   "on",
+			huaweicloud.AutoCreateEipOptions: `{"ip_type": "5_bgp", "bandwidth_size": 5, "share_type": "PER", "charge_mode": "bandwidth"}`,
+		}, 80, 443)
+		newPods(client, service, nodes...)
+	})
+
+	ginkgo.It("creates the load balancer with a listener, pool and health monitor for each port", func() {
+		status, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+		lbs := cloud.List(fakecloud.KindLoadBalancer)
+		gomega.Expect(lbs).Should(gomega.HaveLen(1))
+		gomega.Expect(lbs[0].String("name")).Should(gomega.Equal("k8s_service_kubernetes_default_dedicated"))
+		gomega.Expect(status.Ingress).Should(gomega.HaveLen(1))
+		gomega.Expect(status.Ingress[0].IP).Should(gomega.Equal(lbs[0].String("vip_address")))
+
+		gomega.Expect(listOf(cloud, fakecloud.KindListener, "loadbalancer_id", lbs[0].String("id"))).Should(gomega.HaveLen(2))
+		pools := cloud.List(fakecloud.KindPool)
+		gomega.Expect(pools).Should(gomega.HaveLen(2))
+		for _, pool := range pools {
+			gomega.Expect(pool.String("healthmonitor_id")).ShouldNot(gomega.BeEmpty())
+			members := listOf(cloud, fakecloud.KindMember, "pool_id", pool.String("id"))
+			gomega.Expect(members).Should(gomega.HaveLen(2))
+			for _, member := range members {
+				gomega.Expect(member.String("subnet_cidr_id")).Should(gomega.Equal(fakecloud.SubnetID))
+			}
+		}
+
+		eips := listOf(cloud, fakecloud.KindPublicIP, "port_id", lbs[0].String("vip_port_id"))
+		gomega.Expect(eips).Should(gomega.HaveLen(1))
+		status, exists, err := provider.GetLoadBalancer(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(exists).Should(gomega.BeTrue())
+		gomega.Expect(status.Ingress[0].IP).Should(gomega.Equal(eips[0].String("public_ip_address")))
+	})
+
+	ginkgo.It("names the load balancer as GetLoadBalancerName", func() {
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+		lbs := cloud.List(fakecloud.KindLoadBalancer)
+		gomega.Expect(lbs).Should(gomega.HaveLen(1))
+		gomega.Expect(provider.GetLoadBalancerName(context.TODO(), clusterName, service)).
+			Should(gomega.Equal(lbs[0].String("name")))
+	})
+
+	ginkgo.It("creates or deletes nothing when the load balancer is up to date", func() {
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		before := len(cloud.Requests())
+
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(creations(cloud.Requests()[before:])).Should(gomega.BeEmpty())
+	})
+
+	ginkgo.It("removes the members of the deleted pods", func() {
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+		err = client.CoreV1().Pods(testNamespace).Delete(context.TODO(), "dedicated-node-2", metav1.DeleteOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		members := cloud.List(fakecloud.KindMember)
+		gomega.Expect(members).Should(gomega.HaveLen(2))
+		for _, member := range members {
+			gomega.Expect(member.String("address")).Should(gomega.Equal("192.168.1.11"))
+		}
+	})
+
+	ginkgo.It("deletes the listeners of the removed ports", func() {
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+		service.Spec.Ports = service.Spec.Ports[:1]
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		listeners := cloud.List(fakecloud.KindListener)
+		gomega.Expect(listeners).Should(gomega.HaveLen(1))
+		gomega.Expect(listeners[0]["protocol_port"]).Should(gomega.BeNumerically("==", 80))
+		gomega.Expect(cloud.List(fakecloud.KindPool)).Should(gomega.HaveLen(1))
+	})
+
+	ginkgo.It("deletes the load balancer with its listeners and pools", func() {
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+		err = provider.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)).Should(gomega.BeEmpty())
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.BeEmpty())
+		gomega.Expect(cloud.List(fakecloud.KindPool)).Should(gomega.BeEmpty())
+		gomega.Expect(cloud.List(fakecloud.KindMember)).Should(gomega.BeEmpty())
+
+		_, exists, err := provider.GetLoadBalancer(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(exists).Should(gomega.BeFalse())
+	})
+
+	ginkgo.It("completes the load balancer on the retry of a failed request", func() {
+		cloud.InjectFault(http.MethodPost, "/v3/{project_id}/elb/listeners", http.StatusInternalServerError, 1)
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.HaveOccurred())
+
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)).Should(gomega.HaveLen(1))
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.HaveLen(2))
+		gomega.Expect(cloud.List(fakecloud.KindMember)).Should(gomega.HaveLen(4))
+	})
+
+	ginkgo.It("adds the members of the nodes passed by the service controller only", func() {
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes[:1])
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		members := cloud.List(fakecloud.KindMember)
+		gomega.Expect(members).Should(gomega.HaveLen(2))
+		for _, member := range members {
+			gomega.Expect(member.String("address")).Should(gomega.Equal("192.168.1.11"))
+		}
+	})
+
+	ginkgo.It("returns the error of a failed update to the service controller", func() {
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+		cloud.InjectFault(http.MethodGet, "/v3/{project_id}/elb/loadbalancers", http.StatusBadRequest, 10)
+		err = provider.UpdateLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.HaveOccurred())
+	})
+
+	ginkgo.It("adopts the resources created by the requests whose responses are lost", func() {
+		cloud.InjectLostResponse(http.MethodPost, "/v3/{project_id}/elb/listeners", http.StatusGatewayTimeout, 1)
+		cloud.InjectLostResponse(http.MethodPost, "/v3/{project_id}/elb/pools", http.StatusGatewayTimeout, 1)
+		cloud.InjectLostResponse(http.MethodPost, "/v3/{project_id}/elb/healthmonitors", http.StatusGatewayTimeout, 1)
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.HaveLen(2))
+		gomega.Expect(cloud.List(fakecloud.KindPool)).Should(gomega.HaveLen(2))
+		gomega.Expect(cloud.List(fakecloud.KindHealthMonitor)).Should(gomega.HaveLen(2))
+		gomega.Expect(cloud.List(fakecloud.KindMember)).Should(gomega.HaveLen(4))
+	})
+
+	ginkgo.It("sends no request once the reconcile is canceled", func() {
+		ctx, cancel := context.WithCancel(context.TODO())
+		cancel()
+		before := len(cloud.Requests())
+		_, err := provider.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.MatchError(context.Canceled))
+		gomega.Expect(cloud.Requests()[before:]).Should(gomega.BeEmpty())
+		gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)).Should(gomega.BeEmpty())
+	})
+
+	ginkgo.It("creates the load balancer of the elastic flavors with the autoscaling", func() {
+		service.Annotations[huaweicloud.ElbAutoscaling] = "true"
+		service.Annotations[huaweicloud.ElbL4FlavorID] = "flavor-l4-max"
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+		lbs := cloud.List(fakecloud.KindLoadBalancer)
+		gomega.Expect(lbs).Should(gomega.HaveLen(1))
+		gomega.Expect(lbs[0]["autoscaling"]).Should(gomega.Equal(map[string]interface{}{"enable": true}))
+		gomega.Expect(lbs[0].String("l4_flavor_id")).Should(gomega.Equal("flavor-l4-max"))
+	})
+
+	ginkgo.It("requires the minimum flavor at Layer 7 of the autoscaling with the HTTP listeners", func() {
+		service.Annotations[huaweicloud.ElbAutoscaling] = "true"
+		service.Annotations[huaweicloud.ElbXForwardedHost] = "true"
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring(
+			"annotation kubernetes.io/elb.min-l7-flavor-id is required by the autoscaling with the HTTP listener of port 80")))
+		gomega.Expect(creations(cloud.Requests())).Should(gomega.BeEmpty())
+
+		service.Annotations[huaweicloud.ElbMinL7FlavorID] = "flavor-l7-min"
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)[0]["autoscaling"]).Should(gomega.Equal(
+			map[string]interface{}{"enable": true, "min_l7_flavor_id": "flavor-l7-min"}))
+	})
+})
+
+var _ = ginkgo.Describe("shared load balancer", func() {
+	var cloud *fakecloud.Server
+	var provider *huaweicloud.CloudProvider
+	var client kubernetes.Interface
+	var nodes []*corev1.Node
+	var service *corev1.Service
+
+	ginkgo.BeforeEach(func() {
+		cloud = fakecloud.NewServer()
+		ginkgo.DeferCleanup(cloud.Close)
+		cloud.AddServer("node-1", "192.168.1.11", fakecloud.SubnetID)
+		nodes = []*corev1.Node{newNode("node-1", "192.168.1.11")}
+
+		provider, client = startProvider(cloud, "", nodes...)
+		service = newService(client, "shared", map[string]string{
+			huaweicloud.ElbClass:             "shared",
+			huaweicloud.AutoCreateEipOptions: `{"ip_type": "5_bgp", "bandwidth_size": 5, "share_type": "PER", "charge_mode": "bandwidth"}`,
+		}, 80)
+		newPods(client, service, nodes...)
+	})
+
+	ginkgo.It("creates the load balancer and binds the EIP created", func() {
+		status, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+		lbs := cloud.List(fakecloud.KindLoadBalancer)
+		gomega.Expect(lbs).Should(gomega.HaveLen(1))
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.HaveLen(1))
+		gomega.Expect(cloud.List(fakecloud.KindMember)).Should(gomega.HaveLen(1))
+
+		eips := listOf(cloud, fakecloud.KindPublicIP, "port_id", lbs[0].String("vip_port_id"))
+		gomega.Expect(eips).Should(gomega.HaveLen(1))
+		gomega.Expect(status.Ingress[0].IP).Should(gomega.Equal(eips[0].String("public_ip_address")))
+	})
+
+	ginkgo.It("adopts the EIP created by the request whose response is lost", func() {
+		cloud.InjectLostResponse(http.MethodPost, "/v1/{project_id}/publicips", http.StatusGatewayTimeout, 1)
+		cloud.InjectLostResponse(http.MethodPost, "/v2/{project_id}/elb/listeners", http.StatusGatewayTimeout, 1)
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.HaveLen(1))
+		gomega.Expect(cloud.List(fakecloud.KindPublicIP)).Should(gomega.HaveLen(1))
+	})
+
+	ginkgo.It("deletes the load balancer and releases the EIP created", func() {
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+		err = provider.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)).Should(gomega.BeEmpty())
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.BeEmpty())
+		gomega.Expect(cloud.List(fakecloud.KindPublicIP)).Should(gomega.BeEmpty())
+	})
+
+	ginkgo.It("applies the default annotations of the namespace", func() {
+		_, err := client.CoreV1().ConfigMaps(testNamespace).Create(context.TODO(), &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "elb-defaults",
+				Labels: map[string]string{huaweicloud.DefaultAnnotationsLabel: "true"},
+			},
+			Data: map[string]string{"elb.class": "dedicated", "elb.keep-eip": "true"},
+		}, metav1.CreateOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+		// the defaults apply once the ConfigMap is received by the informer, the EIP is kept from then on.
+		gomega.Eventually(func() []fakecloud.Resource {
+			_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			err = provider.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			return cloud.List(fakecloud.KindPublicIP)
+		}).Should(gomega.HaveLen(1))
+
+		// the class of the service takes precedence.
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		lbs := cloud.List(fakecloud.KindLoadBalancer)
+		gomega.Expect(lbs).Should(gomega.HaveLen(1))
+		gomega.Expect(lbs[0]["guaranteed"]).Should(gomega.BeFalse())
+	})
+})
+
+var _ = ginkgo.Describe("load balancer specified by name", func() {
+	var cloud *fakecloud.Server
+	var provider *huaweicloud.CloudProvider
+	var client kubernetes.Interface
+	var nodes []*corev1.Node
+
+	ginkgo.BeforeEach(func() {
+		cloud = fakecloud.NewServer()
+		ginkgo.DeferCleanup(cloud.Close)
+		cloud.AddServer("node-1", "192.168.1.11", fakecloud.SubnetID)
+		nodes = []*corev1.Node{newNode("node-1", "192.168.1.11")}
+		provider, client = startProvider(cloud, "", nodes...)
+	})
+
+	for _, class := range []string{"shared", "dedicated"} {
+		class := class
+		ginkgo.It("resolves the name to the load balancer of the "+class+" class", func() {
+			id := cloud.AddLoadBalancer("ingress-lb", fakecloud.SubnetID, class == "dedicated")
+			cloud.AddLoadBalancer("ingress-lb-2", fakecloud.SubnetID, class == "dedicated")
+			service := newService(client, "web", map[string]string{
+				huaweicloud.ElbClass: class,
+				huaweicloud.ElbName:  "ingress-lb",
+			}, 80)
+			newPods(client, service, nodes...)
+
+			_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)).Should(gomega.HaveLen(2))
+			gomega.Expect(listOf(cloud, fakecloud.KindListener, "loadbalancer_id", id)).Should(gomega.HaveLen(1))
+
+			ginkgo.By("deleting the listeners and keeping the load balancer")
+			err = provider.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.BeEmpty())
+			gomega.Expect(cloud.Get(fakecloud.KindLoadBalancer, id)).ShouldNot(gomega.BeNil())
+		})
+	}
+
+	ginkgo.It("fails on the names of no or several load balancers", func() {
+		service := newService(client, "web", map[string]string{
+			huaweicloud.ElbClass: "dedicated",
+			huaweicloud.ElbName:  "ingress-lb",
+		}, 80)
+		newPods(client, service, nodes...)
+
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring(
+			`not found the load balancer named "ingress-lb" of annotation kubernetes.io/elb.name`)))
+		_, exists, err := provider.GetLoadBalancer(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(exists).Should(gomega.BeFalse())
+
+		first := cloud.AddLoadBalancer("ingress-lb", fakecloud.SubnetID, true)
+		second := cloud.AddLoadBalancer("ingress-lb", fakecloud.SubnetID, true)
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.MatchError(gomega.And(
+			gomega.ContainSubstring(`found 2 load balancers named "ingress-lb"`),
+			gomega.ContainSubstring(first), gomega.ContainSubstring(second))))
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.BeEmpty())
+
+		ginkgo.By("conflicting with the ID of another load balancer")
+		cloud.AddLoadBalancer("other-lb", fakecloud.SubnetID, true)
+		service.Annotations[huaweicloud.ElbName] = "other-lb"
+		service.Annotations[huaweicloud.ElbID] = first
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring("conflicts with " + first)))
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.BeEmpty())
+	})
+})
+
+var _ = ginkgo.Describe("multiple load balancers of a service", func() {
+	var cloud *fakecloud.Server
+	var provider *huaweicloud.CloudProvider
+	var client kubernetes.Interface
+	var nodes []*corev1.Node
+	var ids []string
+
+	ginkgo.BeforeEach(func() {
+		cloud = fakecloud.NewServer()
+		ginkgo.DeferCleanup(cloud.Close)
+		cloud.AddServer("node-1", "192.168.1.11", fakecloud.SubnetID)
+		nodes = []*corev1.Node{newNode("node-1", "192.168.1.11")}
+		provider, client = startProvider(cloud, "", nodes...)
+		ids = []string{
+			cloud.AddLoadBalancer("entry-az1", fakecloud.SubnetID, true),
+			cloud.AddLoadBalancer("entry-az2", fakecloud.SubnetID, true),
+		}
+	})
+
+	ginkgo.It("keeps the listeners in sync on each load balancer and publishes all the ingress IPs", func() {
+		service := newService(client, "web", map[string]string{
+			huaweicloud.ElbClass: "dedicated",
+			huaweicloud.ElbID:    ids[0] + ", " + ids[1],
+		}, 80)
+		newPods(client, service, nodes...)
+
+		status, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		var ips []string
+		for _, ingress := range status.Ingress {
+			ips = append(ips, ingress.IP)
+		}
+		gomega.Expect(ips).Should(gomega.Equal([]string{
+			cloud.Get(fakecloud.KindLoadBalancer, ids[0]).String("vip_address"),
+			cloud.Get(fakecloud.KindLoadBalancer, ids[1]).String("vip_address"),
+		}))
+		for _, id := range ids {
+			gomega.Expect(listOf(cloud, fakecloud.KindListener, "loadbalancer_id", id)).Should(gomega.HaveLen(1))
+		}
+		gomega.Expect(cloud.List(fakecloud.KindMember)).Should(gomega.HaveLen(2))
+
+		ginkgo.By("getting the ingress IPs of both load balancers")
+		got, exists, err := provider.GetLoadBalancer(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(exists).Should(gomega.BeTrue())
+		gomega.Expect(got.Ingress).Should(gomega.Equal(status.Ingress))
+
+		ginkgo.By("deleting the listeners from both load balancers")
+		err = provider.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.BeEmpty())
+		gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)).Should(gomega.HaveLen(2))
+	})
+
+	ginkgo.It("ensures the other load balancers when one fails", func() {
+		service := newService(client, "web", map[string]string{
+			huaweicloud.ElbClass: "dedicated",
+			huaweicloud.ElbID:    ids[0] + "," + ids[1],
+		}, 80)
+		newPods(client, service, nodes...)
+
+		cloud.InjectFault(http.MethodPost, "/v3/{project_id}/elb/listeners", http.StatusInternalServerError, 1)
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring(
+			"failed to ensure the load balancer " + ids[0])))
+		gomega.Expect(listOf(cloud, fakecloud.KindListener, "loadbalancer_id", ids[1])).Should(gomega.HaveLen(1))
+
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.HaveLen(2))
+	})
+
+	ginkgo.It("deletes the listeners from the load balancers removed from the list", func() {
+		service := newService(client, "web", map[string]string{
+			huaweicloud.ElbClass: "dedicated",
+			huaweicloud.ElbID:    ids[0] + "," + ids[1],
+		}, 80)
+		newPods(client, service, nodes...)
+
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		service, err = client.CoreV1().Services(testNamespace).Get(context.TODO(), "web", metav1.GetOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(service.Annotations).Should(gomega.HaveKeyWithValue(huaweicloud.ElbAppliedIDs,
+			ids[0]+","+ids[1]))
+
+		ginkgo.By("removing the second load balancer from the list")
+		service.Annotations[huaweicloud.ElbID] = ids[0]
+		service, err = client.CoreV1().Services(testNamespace).Update(context.TODO(), service, metav1.UpdateOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		status, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(status.Ingress).Should(gomega.HaveLen(1))
+		gomega.Expect(listOf(cloud, fakecloud.KindListener, "loadbalancer_id", ids[0])).Should(gomega.HaveLen(1))
+		gomega.Expect(listOf(cloud, fakecloud.KindListener, "loadbalancer_id", ids[1])).Should(gomega.BeEmpty())
+		gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)).Should(gomega.HaveLen(2))
+		service, err = client.CoreV1().Services(testNamespace).Get(context.TODO(), "web", metav1.GetOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(service.Annotations).Should(gomega.HaveKeyWithValue(huaweicloud.ElbAppliedIDs, ids[0]))
+
+		ginkgo.By("deleting the listeners of the service")
+		err = provider.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.BeEmpty())
+	})
+
+	ginkgo.It("rejects the list with the dnat class", func() {
+		service := newService(client, "web", map[string]string{
+			huaweicloud.ElbClass: "dnat",
+			huaweicloud.ElbID:    ids[0] + "," + ids[1],
+		}, 80)
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring(
+			"the list of load balancers of annotation kubernetes.io/elb.id is supported by the shared and dedicated")))
+	})
+})
+
+var _ = ginkgo.Describe("load balancer members of the node changes", func() {
+	var cloud *fakecloud.Server
+	var provider *huaweicloud.CloudProvider
+	var client kubernetes.Interface
+	var nodes []*corev1.Node
+	var service *corev1.Service
+
+	ginkgo.BeforeEach(func() {
+		cloud = fakecloud.NewServer()
+		ginkgo.DeferCleanup(cloud.Close)
+		cloud.AddAvailabilityZones("az1")
+		cloud.AddServer("node-1", "192.168.1.11", fakecloud.SubnetID)
+		cloud.AddServer("node-2", "192.168.1.12", fakecloud.SubnetID)
+		nodes = []*corev1.Node{newNode("node-1", "192.168.1.11"), newNode("node-2", "192.168.1.12")}
+
+		provider, client = startProvider(cloud, "[Concurrency]\nnode-update-window = 1\n", nodes...)
+		service = newService(client, "members", map[string]string{
+			huaweicloud.ElbClass:             "dedicated",
+			huaweicloud.ElbAvailabilityZones: "az1",
+		}, 80)
+		newPods(client, service, nodes...)
+	})
+
+	ginkgo.It("removes the members of a node excluded from the load balancers", func() {
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindMember)).Should(gomega.HaveLen(2))
+
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), "node-2", metav1.GetOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		node.Labels = map[string]string{corev1.LabelNodeExcludeBalancers: "true"}
+		_, err = client.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+		addresses := func() []string {
+			var rst []string
+			for _, member := range cloud.List(fakecloud.KindMember) {
+				rst = append(rst, member.String("address"))
+			}
+			return rst
+		}
+		gomega.Eventually(addresses, 10*time.Second).Should(gomega.Equal([]string{"192.168.1.11"}))
+	})
+})
@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+	"sigs.k8s.io/cloud-provider-huaweicloud/test/fakecloud"
+)
+
+var _ = ginkgo.Describe("changes of the loadbalancer config", func() {
+	var cloud *fakecloud.Server
+	var nodes []*corev1.Node
+
+	ginkgo.BeforeEach(func() {
+		cloud = fakecloud.NewServer()
+		ginkgo.DeferCleanup(cloud.Close)
+		cloud.AddAvailabilityZones("az1")
+		cloud.AddServer("node-1", "192.168.1.11", fakecloud.SubnetID)
+		nodes = []*corev1.Node{newNode("node-1", "192.168.1.11")}
+	})
+
+	// ensure ensures the load balancer of a new dedicated service, and returns the algorithm of its pool.
+	ensure := func(provider *huaweicloud.CloudProvider, client kubernetes.Interface, name string) string {
+		service := newService(client, name, map[string]string{
+			huaweicloud.ElbClass:             "dedicated",
+			huaweicloud.ElbAvailabilityZones: "az1",
+		}, 80)
+		newPods(client, service, nodes...)
+		status, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		for _, lb := range cloud.List(fakecloud.KindLoadBalancer) {
+			if lb.String("vip_address") != status.Ingress[0].IP {
+				continue
+			}
+			listeners := listOf(cloud, fakecloud.KindListener, "loadbalancer_id", lb.String("id"))
+			gomega.Expect(listeners).Should(gomega.HaveLen(1))
+			return cloud.Get(fakecloud.KindPool, listeners[0].String("default_pool_id")).String("lb_algorithm")
+		}
+		ginkgo.Fail("not found the load balancer of service " + name)
+		return ""
+	}
+
+	ginkgo.It("applies the changes of the ConfigMap to the following reconciles", func() {
+		provider, client := startProviderWithConfig(cloud, "",
+			map[string]string{"loadBalancerOption": `{"lb-algorithm": "ROUND_ROBIN"}`}, nodes...)
+		gomega.Expect(ensure(provider, client, "before")).Should(gomega.Equal("ROUND_ROBIN"))
+
+		// the ConfigMap is changed while the load balancers are reconciled.
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer ginkgo.GinkgoRecover()
+			defer wg.Done()
+			for i := 0; i < 3; i++ {
+				ensure(provider, client, fmt.Sprintf("during-%d", i))
+			}
+		}()
+		cm, err := client.CoreV1().ConfigMaps(config.ProviderNamespace).Get(context.TODO(),
+			config.LoadbalancerConfigMap, metav1.GetOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		cm.Data["loadBalancerOption"] = `{"lb-algorithm": "LEAST_CONNECTIONS"}`
+		_, err = client.CoreV1().ConfigMaps(config.ProviderNamespace).Update(context.TODO(), cm, metav1.UpdateOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		wg.Wait()
+
+		i := 0
+		gomega.Eventually(func() string {
+			i++
+			return ensure(provider, client, fmt.Sprintf("after-%d", i))
+		}, 5*time.Second).Should(gomega.Equal("LEAST_CONNECTIONS"))
+	})
+
+	ginkgo.It("applies the reloaded [LoadBalancer] section to the following reconciles", func() {
+		dir := ginkgo.GinkgoT().TempDir()
+		path, touchFile := filepath.Join(dir, "cloud-config"), filepath.Join(dir, "reload")
+		reload := fmt.Sprintf("[Reload]\ntouch-file = %s\ninterval = 1\n", touchFile)
+		gomega.Expect(os.WriteFile(path, []byte(cloud.CloudConfig(reload)), 0600)).Should(gomega.Succeed())
+
+		file, err := os.Open(path)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		defer file.Close()
+		provider, err := huaweicloud.NewHWSCloud(file)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		client := fake.NewSimpleClientset(nodes[0])
+		stop := make(chan struct{})
+		ginkgo.DeferCleanup(func() { close(stop) })
+		provider.Initialize(clientBuilder{client: client}, stop)
+		gomega.Expect(ensure(provider, client, "before")).ShouldNot(gomega.Equal("SOURCE_IP"))
+
+		changed := cloud.CloudConfig(reload + "\n[LoadBalancer]\nlb-algorithm = SOURCE_IP\n")
+		gomega.Expect(os.WriteFile(path, []byte(changed), 0600)).Should(gomega.Succeed())
+		gomega.Expect(os.WriteFile(touchFile, []byte("reload"), 0600)).Should(gomega.Succeed())
+
+		i := 0
+		gomega.Eventually(func() string {
+			i++
+			return ensure(provider, client, fmt.Sprintf("after-%d", i))
+		}, 5*time.Second, 500*time.Millisecond).Should(gomega.Equal("SOURCE_IP"))
+	})
+})
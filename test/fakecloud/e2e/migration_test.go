@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud"
+	"sigs.k8s.io/cloud-provider-huaweicloud/test/fakecloud"
+)
+
+var _ = ginkgo.Describe("shared load balancer migrating to dedicated", func() {
+	var cloud *fakecloud.Server
+	var provider *huaweicloud.CloudProvider
+	var nodes []*corev1.Node
+	var service *corev1.Service
+	var sharedID, eipAddress string
+
+	ginkgo.BeforeEach(func() {
+		cloud = fakecloud.NewServer()
+		ginkgo.DeferCleanup(cloud.Close)
+		cloud.AddAvailabilityZones("az1")
+		cloud.AddServer("node-1", "192.168.1.11", fakecloud.SubnetID)
+		nodes = []*corev1.Node{newNode("node-1", "192.168.1.11")}
+
+		var client kubernetes.Interface
+		provider, client = startProvider(cloud, "", nodes...)
+		service = newService(client, "migrating", map[string]string{
+			huaweicloud.ElbClass:             "shared",
+			huaweicloud.AutoCreateEipOptions: `{"ip_type": "5_bgp", "bandwidth_size": 5, "share_type": "PER", "charge_mode": "bandwidth"}`,
+		}, 80, 443)
+		newPods(client, service, nodes...)
+
+		status, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		lbs := cloud.List(fakecloud.KindLoadBalancer)
+		gomega.Expect(lbs).Should(gomega.HaveLen(1))
+		sharedID, eipAddress = lbs[0].String("id"), status.Ingress[0].IP
+
+		service.Annotations[huaweicloud.ElbMigrateTo] = "dedicated"
+		service.Annotations[huaweicloud.ElbAvailabilityZones] = "az1"
+	})
+
+	ginkgo.It("moves the EIP to the dedicated load balancer and deletes the shared one", func() {
+		status, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(status.Ingress[0].IP).Should(gomega.Equal(eipAddress))
+
+		lbs := cloud.List(fakecloud.KindLoadBalancer)
+		gomega.Expect(lbs).Should(gomega.HaveLen(1))
+		gomega.Expect(lbs[0].String("id")).ShouldNot(gomega.Equal(sharedID))
+		gomega.Expect(lbs[0]["guaranteed"]).Should(gomega.BeTrue())
+		gomega.Expect(listOf(cloud, fakecloud.KindListener, "loadbalancer_id", lbs[0].String("id"))).Should(gomega.HaveLen(2))
+		gomega.Expect(cloud.List(fakecloud.KindMember)).Should(gomega.HaveLen(2))
+
+		eips := cloud.List(fakecloud.KindPublicIP)
+		gomega.Expect(eips).Should(gomega.HaveLen(1))
+		gomega.Expect(eips[0].String("port_id")).Should(gomega.Equal(lbs[0].String("vip_port_id")))
+
+		status, exists, err := provider.GetLoadBalancer(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(exists).Should(gomega.BeTrue())
+		gomega.Expect(status.Ingress[0].IP).Should(gomega.Equal(eipAddress))
+	})
+
+	ginkgo.It("keeps the dedicated load balancer once migrated", func() {
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		before := len(cloud.Requests())
+
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(creations(cloud.Requests()[before:])).Should(gomega.BeEmpty())
+
+		service.Annotations[huaweicloud.ElbClass] = "dedicated"
+		delete(service.Annotations, huaweicloud.ElbMigrateTo)
+		status, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)).Should(gomega.HaveLen(1))
+		gomega.Expect(creations(cloud.Requests()[before:])).Should(gomega.BeEmpty())
+		gomega.Expect(status.Ingress).Should(gomega.HaveLen(1))
+	})
+
+	ginkgo.It("completes the migration on the retry of a failed request", func() {
+		cloud.InjectFault(http.MethodPut, "/v1/{project_id}/publicips", http.StatusInternalServerError, 1)
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)).Should(gomega.HaveLen(2))
+
+		status, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(status.Ingress[0].IP).Should(gomega.Equal(eipAddress))
+		gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)).Should(gomega.HaveLen(1))
+	})
+
+	ginkgo.It("deletes both load balancers before the migration completes", func() {
+		cloud.InjectFault(http.MethodPut, "/v1/{project_id}/publicips", http.StatusInternalServerError, 1)
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.HaveOccurred())
+
+		err = provider.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)).Should(gomega.BeEmpty())
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.BeEmpty())
+		gomega.Expect(cloud.List(fakecloud.KindPublicIP)).Should(gomega.BeEmpty())
+	})
+})
@@ -0,0 +1,212 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud"
+	"sigs.k8s.io/cloud-provider-huaweicloud/test/fakecloud"
+)
+
+// listenerProtocols returns the listeners in the form of "<protocol>/<port>", sorted.
+func listenerProtocols(cloud *fakecloud.Server) []string {
+	var listeners []string
+	for _, listener := range cloud.List(fakecloud.KindListener) {
+		listeners = append(listeners, fmt.Sprintf("%s/%v", listener.String("protocol"), listener["protocol_port"]))
+	}
+	sort.Strings(listeners)
+	return listeners
+}
+
+// withUDPPort adds the UDP port of the same number to the service, the node port is 31000+port.
+func withUDPPort(service *corev1.Service, port int32) *corev1.Service {
+	service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+		Name:       fmt.Sprintf("udp-%d", port),
+		Protocol:   corev1.ProtocolUDP,
+		Port:       port,
+		TargetPort: intstr.FromInt(53),
+		NodePort:   31000 + port,
+	})
+	return service
+}
+
+var _ = ginkgo.Describe("mixed-protocol services", func() {
+	var cloud *fakecloud.Server
+	var provider *huaweicloud.CloudProvider
+	var client kubernetes.Interface
+	var nodes []*corev1.Node
+
+	ginkgo.BeforeEach(func() {
+		cloud = fakecloud.NewServer()
+		ginkgo.DeferCleanup(cloud.Close)
+		cloud.AddAvailabilityZones("az1")
+		cloud.AddServer("node-1", "192.168.1.11", fakecloud.SubnetID)
+		nodes = []*corev1.Node{newNode("node-1", "192.168.1.11")}
+		provider, client = startProvider(cloud, "", nodes...)
+	})
+
+	for _, class := range []string{"shared", "dedicated"} {
+		class := class
+		ginkgo.It("creates a TCP and a UDP listener of the same port with the "+class+" class", func() {
+			service := withUDPPort(newService(client, "dns-"+class, map[string]string{
+				huaweicloud.ElbClass:             class,
+				huaweicloud.ElbAvailabilityZones: "az1",
+				huaweicloud.ElbHealthCheckFlag:   "on",
+			}, 53), 53)
+			newPods(client, service, nodes...)
+
+			_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			gomega.Expect(listenerProtocols(cloud)).Should(gomega.Equal([]string{"TCP/53", "UDP/53"}))
+
+			var monitors []string
+			for _, monitor := range cloud.List(fakecloud.KindHealthMonitor) {
+				monitors = append(monitors, monitor.String("type"))
+			}
+			gomega.Expect(monitors).Should(gomega.ConsistOf("TCP", "UDP_CONNECT"))
+			var members []string
+			for _, member := range cloud.List(fakecloud.KindMember) {
+				members = append(members, fmt.Sprint(member["protocol_port"]))
+			}
+			gomega.Expect(members).Should(gomega.ConsistOf("30053", "31053"))
+
+			ginkgo.By("reconciling again without changes")
+			before := len(cloud.Requests())
+			_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			gomega.Expect(creations(cloud.Requests()[before:])).Should(gomega.BeEmpty())
+
+			ginkgo.By("removing the UDP port")
+			service.Spec.Ports = service.Spec.Ports[:1]
+			_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			gomega.Expect(listenerProtocols(cloud)).Should(gomega.Equal([]string{"TCP/53"}))
+		})
+	}
+
+	ginkgo.It("keeps the UDP listener of the port terminated as HTTP", func() {
+		service := withUDPPort(newService(client, "xff", map[string]string{
+			huaweicloud.ElbClass:             "dedicated",
+			huaweicloud.ElbAvailabilityZones: "az1",
+			huaweicloud.ElbXForwardedHost:    "true",
+		}, 80), 80)
+		newPods(client, service, nodes...)
+
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(listenerProtocols(cloud)).Should(gomega.Equal([]string{"HTTP/80", "UDP/80"}))
+		for _, listener := range listOf(cloud, fakecloud.KindListener, "protocol", "UDP") {
+			gomega.Expect(listener).ShouldNot(gomega.HaveKey("insert_headers"))
+		}
+
+		err = provider.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.BeEmpty())
+	})
+
+	ginkgo.It("fails fast on the SCTP ports", func() {
+		service := newService(client, "sctp", map[string]string{
+			huaweicloud.ElbClass:             "dedicated",
+			huaweicloud.ElbAvailabilityZones: "az1",
+		}, 80)
+		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+			Name:     "sctp-9000",
+			Protocol: corev1.ProtocolSCTP,
+			Port:     9000,
+			NodePort: 31900,
+		})
+		newPods(client, service, nodes...)
+
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring(
+			"the protocols of ports sctp-9000 SCTP/9000 are not supported by the load balancers")))
+		gomega.Expect(creations(cloud.Requests())).Should(gomega.BeEmpty())
+		gomega.Eventually(warningMessages(client, "UnsupportedProtocol")).Should(gomega.ConsistOf(
+			gomega.ContainSubstring("sctp-9000 SCTP/9000")))
+		current, err := client.CoreV1().Services(service.Namespace).Get(context.TODO(), service.Name, metav1.GetOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		cond := meta.FindStatusCondition(current.Status.Conditions, huaweicloud.ServiceConditionProtocolsSupported)
+		gomega.Expect(cond).ShouldNot(gomega.BeNil())
+		gomega.Expect(cond.Status).Should(gomega.Equal(metav1.ConditionFalse))
+		gomega.Expect(cond.Reason).Should(gomega.Equal("UnsupportedProtocol"))
+
+		ginkgo.By("removing the SCTP port")
+		current.Spec.Ports = current.Spec.Ports[:1]
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, current, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(listenerProtocols(cloud)).Should(gomega.Equal([]string{"TCP/80"}))
+		current, err = client.CoreV1().Services(service.Namespace).Get(context.TODO(), service.Name, metav1.GetOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(meta.IsStatusConditionTrue(current.Status.Conditions,
+			huaweicloud.ServiceConditionProtocolsSupported)).Should(gomega.BeTrue())
+	})
+
+	ginkgo.It("re-encrypts the traffic with the HTTPS pool protocol", func() {
+		service := newService(client, "reencrypt", map[string]string{
+			huaweicloud.ElbClass:               "dedicated",
+			huaweicloud.ElbAvailabilityZones:   "az1",
+			huaweicloud.DefaultTLSContainerRef: "cert-1",
+			huaweicloud.ElbPoolProtocol:        "HTTPS",
+			huaweicloud.ElbHealthCheckFlag:     "on",
+		}, 443)
+		newPods(client, service, nodes...)
+
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(listenerProtocols(cloud)).Should(gomega.Equal([]string{"TERMINATED_HTTPS/443"}))
+		pools := cloud.List(fakecloud.KindPool)
+		gomega.Expect(pools).Should(gomega.HaveLen(1))
+		gomega.Expect(pools[0].String("protocol")).Should(gomega.Equal("HTTPS"))
+		gomega.Expect(cloud.List(fakecloud.KindMember)).Should(gomega.HaveLen(1))
+
+		ginkgo.By("changing the pool protocol back to the default")
+		delete(service.Annotations, huaweicloud.ElbPoolProtocol)
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		pools = cloud.List(fakecloud.KindPool)
+		gomega.Expect(pools).Should(gomega.HaveLen(1))
+		gomega.Expect(pools[0].String("protocol")).Should(gomega.Equal("HTTP"))
+		gomega.Expect(cloud.List(fakecloud.KindMember)).Should(gomega.HaveLen(1))
+		gomega.Expect(cloud.List(fakecloud.KindHealthMonitor)).Should(gomega.HaveLen(1))
+	})
+
+	ginkgo.It("fails on a pool protocol not allowed behind the listener", func() {
+		service := newService(client, "tcp-https", map[string]string{
+			huaweicloud.ElbClass:             "dedicated",
+			huaweicloud.ElbAvailabilityZones: "az1",
+			huaweicloud.ElbPoolProtocol:      "HTTPS",
+		}, 443)
+		newPods(client, service, nodes...)
+
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring(
+			"the pool protocol HTTPS of annotation kubernetes.io/elb.pool-protocol is not supported behind " +
+				"the TCP listener of port 443, expected one of TCP")))
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.BeEmpty())
+	})
+})
@@ -0,0 +1,136 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud"
+	"sigs.k8s.io/cloud-provider-huaweicloud/test/fakecloud"
+)
+
+var _ = ginkgo.Describe("DNAT load balancer", func() {
+	const floatingIP = "100.64.1.10"
+
+	var cloud *fakecloud.Server
+	var provider *huaweicloud.CloudProvider
+	var nodes []*corev1.Node
+	var service *corev1.Service
+
+	ginkgo.BeforeEach(func() {
+		cloud = fakecloud.NewServer()
+		ginkgo.DeferCleanup(cloud.Close)
+		cloud.AddServer("node-1", "192.168.1.11", fakecloud.SubnetID)
+		cloud.AddFloatingIP(floatingIP)
+		gatewayID := cloud.AddNATGateway(fakecloud.VpcID, fakecloud.SubnetID)
+		nodes = []*corev1.Node{newNode("node-1", "192.168.1.11")}
+
+		var client kubernetes.Interface
+		provider, client = startProvider(cloud, "", nodes...)
+		service = newService(client, "dnat", map[string]string{
+			huaweicloud.ElbClass:         "dnat",
+			huaweicloud.AnnotationsNATID: gatewayID,
+		}, 80, 443)
+		service.Spec.LoadBalancerIP = floatingIP
+		_, err := client.CoreV1().Services(testNamespace).Update(context.TODO(), service, metav1.UpdateOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		newPods(client, service, nodes...)
+	})
+
+	ginkgo.It("creates a DNAT rule of the floating IP for each port", func() {
+		status, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(status.Ingress[0].IP).Should(gomega.Equal(floatingIP))
+
+		rules := listOf(cloud, fakecloud.KindDNATRule, "floating_ip_address", floatingIP)
+		gomega.Expect(rules).Should(gomega.HaveLen(2))
+		for _, rule := range rules {
+			port := cloud.Get(fakecloud.KindPort, rule.String("port_id"))
+			gomega.Expect(port).ShouldNot(gomega.BeNil())
+			gomega.Expect(port.String("name")).Should(gomega.Equal("node-1-nic"))
+		}
+	})
+
+	ginkgo.It("deletes the DNAT rules of the service", func() {
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+		err = provider.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindDNATRule)).Should(gomega.BeEmpty())
+	})
+
+	ginkgo.Context("with the NotReady grace period", func() {
+		var client kubernetes.Interface
+
+		// dnatRuleIDs returns the IDs of the DNAT rules, sorted.
+		dnatRuleIDs := func() []string {
+			var ids []string
+			for _, rule := range cloud.List(fakecloud.KindDNATRule) {
+				ids = append(ids, rule.String("id"))
+			}
+			sort.Strings(ids)
+			return ids
+		}
+		// setNotReady turns the node NotReady since the transition time.
+		setNotReady := func(since time.Time) {
+			node, err := client.CoreV1().Nodes().Get(context.TODO(), nodes[0].Name, metav1.GetOptions{})
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			node.Status.Conditions = []corev1.NodeCondition{{
+				Type:               corev1.NodeReady,
+				Status:             corev1.ConditionUnknown,
+				LastTransitionTime: metav1.NewTime(since),
+			}}
+			_, err = client.CoreV1().Nodes().UpdateStatus(context.TODO(), node, metav1.UpdateOptions{})
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		}
+
+		ginkgo.BeforeEach(func() {
+			// the DNAT rules are mapped to the nodes named after their IP addresses.
+			nodes = []*corev1.Node{newNode("192.168.1.11", "192.168.1.11")}
+			provider, client = startProviderWithConfig(cloud, "[Concurrency]\nnode-update-window = 1\n",
+				map[string]string{"nodeOption": `{"not-ready-grace-period": 3}`}, nodes...)
+			_, err := client.CoreV1().Services(testNamespace).Create(context.TODO(), service, metav1.CreateOptions{})
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			newPods(client, service, nodes...)
+		})
+
+		ginkgo.It("keeps the DNAT rules of a node NotReady within the grace period", func() {
+			_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			ids := dnatRuleIDs()
+			gomega.Expect(ids).Should(gomega.HaveLen(2))
+
+			before := len(cloud.Requests())
+			setNotReady(time.Now())
+			gomega.Eventually(func() []string { return cloud.Requests()[before:] }, 5*time.Second).
+				Should(gomega.ContainElement(gomega.ContainSubstring("/dnat_rules")))
+			gomega.Expect(dnatRuleIDs()).Should(gomega.Equal(ids))
+
+			ginkgo.By("moving the DNAT rules once the grace period ends")
+			gomega.Eventually(dnatRuleIDs, 10*time.Second).ShouldNot(gomega.ContainElement(ids[0]))
+		})
+	})
+})
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"strings"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud"
+	"sigs.k8s.io/cloud-provider-huaweicloud/test/fakecloud"
+)
+
+var _ = ginkgo.Describe("octavia backend", func() {
+	var cloud *fakecloud.Server
+	var provider *huaweicloud.CloudProvider
+	var nodes []*corev1.Node
+	var service *corev1.Service
+
+	ginkgo.BeforeEach(func() {
+		cloud = fakecloud.NewServer()
+		ginkgo.DeferCleanup(cloud.Close)
+		cloud.AddServer("node-1", "192.168.1.11", fakecloud.SubnetID)
+		cloud.AddServer("node-2", "192.168.1.12", fakecloud.SubnetID)
+		nodes = []*corev1.Node{newNode("node-1", "192.168.1.11"), newNode("node-2", "192.168.1.12")}
+
+		var client kubernetes.Interface
+		provider, client = startProvider(cloud, "[LoadBalancer]\nbackend = octavia\n", nodes...)
+		service = newService(client, "octavia", map[string]string{huaweicloud.ElbHealthCheckFlag: "on"}, 80, 443)
+		newPods(client, service, nodes...)
+	})
+
+	ginkgo.It("creates the load balancer with the Octavia APIs", func() {
+		status, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+		lbs := cloud.List(fakecloud.KindLoadBalancer)
+		gomega.Expect(lbs).Should(gomega.HaveLen(1))
+		gomega.Expect(lbs[0].String("name")).Should(gomega.Equal("k8s_service_kubernetes_default_octavia"))
+		gomega.Expect(lbs[0].String("vip_subnet_id")).Should(gomega.Equal(fakecloud.SubnetID))
+		gomega.Expect(status.Ingress[0].IP).Should(gomega.Equal(lbs[0].String("vip_address")))
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.HaveLen(2))
+		pools := cloud.List(fakecloud.KindPool)
+		gomega.Expect(pools).Should(gomega.HaveLen(2))
+		for _, pool := range pools {
+			gomega.Expect(pool.String("healthmonitor_id")).ShouldNot(gomega.BeEmpty())
+			gomega.Expect(listOf(cloud, fakecloud.KindMember, "pool_id", pool.String("id"))).Should(gomega.HaveLen(2))
+		}
+
+		for _, request := range creations(cloud.Requests()) {
+			gomega.Expect(strings.Contains(request, "/v2/lbaas/")).Should(gomega.BeTrue(), request)
+		}
+
+		_, exists, err := provider.GetLoadBalancer(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(exists).Should(gomega.BeTrue())
+	})
+
+	ginkgo.It("creates or deletes nothing when the load balancer is up to date", func() {
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		before := len(cloud.Requests())
+
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(creations(cloud.Requests()[before:])).Should(gomega.BeEmpty())
+	})
+
+	ginkgo.It("deletes the load balancer with its listeners and pools", func() {
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+		err = provider.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)).Should(gomega.BeEmpty())
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.BeEmpty())
+		gomega.Expect(cloud.List(fakecloud.KindPool)).Should(gomega.BeEmpty())
+		gomega.Expect(cloud.List(fakecloud.KindHealthMonitor)).Should(gomega.BeEmpty())
+		gomega.Expect(cloud.List(fakecloud.KindMember)).Should(gomega.BeEmpty())
+	})
+
+	ginkgo.It("rejects the classes depending on the native ELB APIs", func() {
+		service.Annotations[huaweicloud.ElbClass] = "elasticity"
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)).Should(gomega.BeEmpty())
+	})
+})
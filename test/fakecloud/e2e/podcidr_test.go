@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	cloudprovider "k8s.io/cloud-provider"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud"
+	"sigs.k8s.io/cloud-provider-huaweicloud/test/fakecloud"
+)
+
+// podCIDROf returns the pod CIDR of the node.
+func podCIDROf(client kubernetes.Interface, name string) func() string {
+	return func() string {
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		return node.Spec.PodCIDR
+	}
+}
+
+var _ = ginkgo.Describe("pod CIDR allocation", func() {
+	var cloud *fakecloud.Server
+	var client kubernetes.Interface
+	var provider *huaweicloud.CloudProvider
+
+	ginkgo.BeforeEach(func() {
+		cloud = fakecloud.NewServer()
+		ginkgo.DeferCleanup(cloud.Close)
+		cloud.AddSubnet("pod-subnet", fakecloud.VpcID, "172.16.0.0/23")
+		cloud.AddRouteTable(fakecloud.VpcID)
+		cloud.AddServer("node-1", "192.168.1.11", fakecloud.SubnetID)
+
+		client = fake.NewSimpleClientset()
+		stop := make(chan struct{})
+		ginkgo.DeferCleanup(func() { close(stop) })
+		provider = initProvider(cloud, "[Vpc]\npod-subnet-id = pod-subnet\nnode-cidr-mask-size = 24\n", client, stop)
+	})
+
+	startAllocator := func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		ginkgo.DeferCleanup(cancel)
+		factory := informers.NewSharedInformerFactory(client, 0)
+		err := provider.StartPodCIDRAllocator(ctx, client, factory.Core().V1().Nodes(), clusterName, "172.16.0.0/16")
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		factory.Start(ctx.Done())
+	}
+	addNode := func(name, podCIDR string) {
+		node := newNode(name, "192.168.1.99")
+		node.Spec.PodCIDR = podCIDR
+		if podCIDR != "" {
+			node.Spec.PodCIDRs = []string{podCIDR}
+		}
+		_, err := client.CoreV1().Nodes().Create(context.TODO(), node, metav1.CreateOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	}
+
+	ginkgo.It("allocates the free pod CIDRs of the pod subnets to the nodes", func() {
+		addNode("node-1", "172.16.0.0/24")
+		startAllocator()
+		addNode("node-2", "")
+		gomega.Eventually(podCIDROf(client, "node-2"), 5*time.Second).Should(gomega.Equal("172.16.1.0/24"))
+		gomega.Expect(podCIDROf(client, "node-1")()).Should(gomega.Equal("172.16.0.0/24"))
+
+		ginkgo.By("recording an event when no pod CIDR is left")
+		addNode("node-3", "")
+		gomega.Eventually(warningMessages(client, "CIDRNotAvailable"), 5*time.Second).Should(gomega.ContainElement(
+			"No pod CIDR of /24 is left in the pod subnets 172.16.0.0/23"))
+		gomega.Expect(podCIDROf(client, "node-3")()).Should(gomega.BeEmpty())
+	})
+
+	ginkgo.It("does not allocate the CIDR of a stale route until the route is deleted", func() {
+		routes, ok := provider.Routes()
+		gomega.Expect(ok).Should(gomega.BeTrue())
+		addNode("node-1", "172.16.0.0/24")
+		stale := &cloudprovider.Route{TargetNode: types.NodeName("node-1"), DestinationCIDR: "172.16.0.0/24"}
+		err := routes.CreateRoute(context.TODO(), clusterName, "", stale)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		err = client.CoreV1().Nodes().Delete(context.TODO(), "node-1", metav1.DeleteOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		startAllocator()
+
+		addNode("node-2", "")
+		gomega.Eventually(podCIDROf(client, "node-2"), 5*time.Second).Should(gomega.Equal("172.16.1.0/24"))
+
+		ginkgo.By("allocating the CIDR once the route controller deletes the route")
+		err = routes.DeleteRoute(context.TODO(), clusterName, stale)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		addNode("node-3", "")
+		gomega.Eventually(podCIDROf(client, "node-3"), 5*time.Second).Should(gomega.Equal("172.16.0.0/24"))
+	})
+
+	ginkgo.It("requires the pod subnets in the VPC", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		ginkgo.DeferCleanup(cancel)
+		stop := make(chan struct{})
+		ginkgo.DeferCleanup(func() { close(stop) })
+		other := initProvider(cloud, "[Vpc]\npod-subnet-id = missing-subnet\n", fake.NewSimpleClientset(), stop)
+		factory := informers.NewSharedInformerFactory(client, 0)
+		err := other.StartPodCIDRAllocator(ctx, client, factory.Core().V1().Nodes(), clusterName, "172.16.0.0/16")
+		gomega.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring(
+			"the pod subnet missing-subnet is not found in the VPC fake-vpc")))
+	})
+})
@@ -0,0 +1,155 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud"
+	"sigs.k8s.io/cloud-provider-huaweicloud/test/fakecloud"
+)
+
+// securityGroupRules returns the rules of the security group of the nodes in the form of
+// "<protocol>/<port> from <cidr>", sorted.
+func securityGroupRules(cloud *fakecloud.Server) []string {
+	var rules []string
+	for _, rule := range listOf(cloud, fakecloud.KindSecurityGroupRule, "security_group_id", fakecloud.SecurityGroupID) {
+		rules = append(rules, fmt.Sprintf("%s/%v from %s", rule.String("protocol"), rule["port_range_min"],
+			rule.String("remote_ip_prefix")))
+	}
+	sort.Strings(rules)
+	return rules
+}
+
+var _ = ginkgo.Describe("security group rules", func() {
+	var cloud *fakecloud.Server
+	var provider *huaweicloud.CloudProvider
+	var client kubernetes.Interface
+	var nodes []*corev1.Node
+
+	start := func(extra string) {
+		provider, client = startProvider(cloud, "[SecurityGroup]\nmanage-rules = true\n"+extra, nodes...)
+	}
+
+	ginkgo.BeforeEach(func() {
+		cloud = fakecloud.NewServer()
+		ginkgo.DeferCleanup(cloud.Close)
+		cloud.AddAvailabilityZones("az1")
+		cloud.AddServer("node-1", "192.168.1.11", fakecloud.SubnetID)
+		cloud.AddServer("node-2", "192.168.1.12", fakecloud.SubnetID)
+		nodes = []*corev1.Node{newNode("node-1", "192.168.1.11"), newNode("node-2", "192.168.1.12")}
+	})
+
+	ginkgo.It("allows the node ports from the shared load balancers in the security group of the nodes", func() {
+		start("")
+		service := newService(client, "shared", map[string]string{huaweicloud.ElbClass: "shared"}, 80, 443)
+		newPods(client, service, nodes...)
+
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(securityGroupRules(cloud)).Should(gomega.Equal([]string{
+			"tcp/30080 from 100.125.0.0/16",
+			"tcp/30443 from 100.125.0.0/16",
+		}))
+
+		err = provider.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(securityGroupRules(cloud)).Should(gomega.BeEmpty())
+	})
+
+	ginkgo.It("allows the node ports from the clients and the health checks of the dedicated load balancers", func() {
+		start("id = " + fakecloud.SecurityGroupID + "\ndedicated-source-cidr = 192.168.0.0/24\n")
+		service := newService(client, "dedicated", map[string]string{
+			huaweicloud.ElbClass:             "dedicated",
+			huaweicloud.ElbAvailabilityZones: "az1",
+		}, 80)
+		newPods(client, service, nodes...)
+
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(securityGroupRules(cloud)).Should(gomega.Equal([]string{
+			"tcp/30080 from 0.0.0.0/0",
+			"tcp/30080 from 192.168.0.0/24",
+		}))
+
+		ginkgo.By("restricting the clients and changing the ports")
+		service.Spec.LoadBalancerSourceRanges = []string{"10.0.0.0/8"}
+		service.Spec.Ports[0].NodePort = 31080
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(securityGroupRules(cloud)).Should(gomega.Equal([]string{
+			"tcp/31080 from 10.0.0.0/8",
+			"tcp/31080 from 192.168.0.0/24",
+		}))
+	})
+
+	ginkgo.It("keeps the identical rules added by hand", func() {
+		start("")
+		manual := cloud.AddSecurityGroupRule("tcp", 30080, "100.125.0.0/16")
+		service := newService(client, "manual", map[string]string{huaweicloud.ElbClass: "shared"}, 80)
+		newPods(client, service, nodes...)
+
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(securityGroupRules(cloud)).Should(gomega.Equal([]string{"tcp/30080 from 100.125.0.0/16"}))
+
+		err = provider.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.Get(fakecloud.KindSecurityGroupRule, manual)).ShouldNot(gomega.BeNil())
+	})
+
+	ginkgo.It("attaches the VIP ports of the dedicated load balancers to the security groups of the annotation", func() {
+		start("")
+		service := newService(client, "vip", map[string]string{
+			huaweicloud.ElbClass:             "dedicated",
+			huaweicloud.ElbAvailabilityZones: "az1",
+			huaweicloud.ElbSecurityGroupIDs:  "sg-elb-1, sg-elb-2",
+		}, 80)
+		newPods(client, service, nodes...)
+
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		lbs := cloud.List(fakecloud.KindLoadBalancer)
+		gomega.Expect(lbs).Should(gomega.HaveLen(1))
+		port := cloud.Get(fakecloud.KindPort, lbs[0].String("vip_port_id"))
+		gomega.Expect(port["security_groups"]).Should(gomega.ConsistOf("sg-elb-1", "sg-elb-2"))
+
+		ginkgo.By("keeping the security groups of the VIP port without the annotation")
+		delete(service.Annotations, huaweicloud.ElbSecurityGroupIDs)
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		port = cloud.Get(fakecloud.KindPort, lbs[0].String("vip_port_id"))
+		gomega.Expect(port["security_groups"]).Should(gomega.ConsistOf("sg-elb-1", "sg-elb-2"))
+	})
+
+	ginkgo.It("does not manage the rules by default", func() {
+		provider, client = startProvider(cloud, "", nodes...)
+		service := newService(client, "unmanaged", map[string]string{huaweicloud.ElbClass: "shared"}, 80)
+		newPods(client, service, nodes...)
+
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindSecurityGroupRule)).Should(gomega.BeEmpty())
+	})
+})
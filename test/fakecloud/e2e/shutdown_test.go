@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud"
+	"sigs.k8s.io/cloud-provider-huaweicloud/test/fakecloud"
+)
+
+var _ = ginkgo.Describe("graceful shutdown", func() {
+	var cloud *fakecloud.Server
+	var nodes []*corev1.Node
+
+	ginkgo.BeforeEach(func() {
+		cloud = fakecloud.NewServer()
+		ginkgo.DeferCleanup(cloud.Close)
+		cloud.AddAvailabilityZones("az1")
+		cloud.AddServer("node-1", "192.168.1.11", fakecloud.SubnetID)
+		nodes = []*corev1.Node{newNode("node-1", "192.168.1.11")}
+	})
+
+	ginkgo.It("marks the reconcile interrupted after the grace period and resumes it on the next leader", func() {
+		extra := "[Concurrency]\nshutdown-grace-period = 1\n"
+		client := fake.NewSimpleClientset(nodes[0])
+		stop := make(chan struct{})
+		provider := initProvider(cloud, extra, client, stop)
+		service := newService(client, "web", map[string]string{
+			huaweicloud.ElbClass:             "dedicated",
+			huaweicloud.ElbAvailabilityZones: "az1",
+		}, 80)
+		newPods(client, service, nodes...)
+
+		cloud.InjectDelay(http.MethodPost, "/v3/{project_id}/elb/loadbalancers", 3*time.Second, 1)
+		result := make(chan error, 1)
+		go func() {
+			defer ginkgo.GinkgoRecover()
+			_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+			result <- err
+		}()
+		time.Sleep(500 * time.Millisecond)
+		close(stop)
+		gomega.Eventually(result, 3*time.Second).Should(gomega.Receive(gomega.HaveOccurred()))
+		gomega.Eventually(warningMessages(client, "ReconcileInterrupted")).Should(gomega.ConsistOf(
+			gomega.ContainSubstring("EnsureLoadBalancer is interrupted by the shutdown")))
+		current, err := client.CoreV1().Services(service.Namespace).Get(context.TODO(), service.Name, metav1.GetOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(current.Annotations).Should(gomega.HaveKeyWithValue(huaweicloud.ElbInterruptedOperation,
+			gomega.HavePrefix("EnsureLoadBalancer at ")))
+
+		ginkgo.By("rejecting the reconciles after the stop")
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, current, nodes)
+		gomega.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring("the cloud provider is shutting down")))
+
+		ginkgo.By("resuming the reconcile on the next leader")
+		gomega.Eventually(func() []fakecloud.Resource {
+			return cloud.List(fakecloud.KindLoadBalancer)
+		}, 5*time.Second).Should(gomega.HaveLen(1))
+		next := make(chan struct{})
+		ginkgo.DeferCleanup(func() { close(next) })
+		provider = initProvider(cloud, extra, client, next)
+		_, err = provider.EnsureLoadBalancer(context.TODO(), clusterName, current, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)).Should(gomega.HaveLen(1))
+		gomega.Expect(cloud.List(fakecloud.KindListener)).Should(gomega.HaveLen(1))
+		current, err = client.CoreV1().Services(service.Namespace).Get(context.TODO(), service.Name, metav1.GetOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(current.Annotations).ShouldNot(gomega.HaveKey(huaweicloud.ElbInterruptedOperation))
+	})
+
+	ginkgo.It("returns from Shutdown once the in-flight reconciles are finished", func() {
+		extra := "[Concurrency]\nshutdown-grace-period = 5\n"
+		client := fake.NewSimpleClientset(nodes[0])
+		stop := make(chan struct{})
+		ginkgo.DeferCleanup(func() { close(stop) })
+		provider := initProvider(cloud, extra, client, stop)
+		service := newService(client, "web", map[string]string{
+			huaweicloud.ElbClass:             "dedicated",
+			huaweicloud.ElbAvailabilityZones: "az1",
+		}, 80)
+		newPods(client, service, nodes...)
+
+		cloud.InjectDelay(http.MethodPost, "/v3/{project_id}/elb/loadbalancers", 2*time.Second, 1)
+		result := make(chan error, 1)
+		go func() {
+			defer ginkgo.GinkgoRecover()
+			_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+			result <- err
+		}()
+		time.Sleep(500 * time.Millisecond)
+		provider.Shutdown()
+		gomega.Expect(result).Should(gomega.Receive(gomega.BeNil()))
+		gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)).Should(gomega.HaveLen(1))
+
+		ginkgo.By("rejecting the reconciles after the shutdown")
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring("the cloud provider is shutting down")))
+	})
+})
@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud"
+	"sigs.k8s.io/cloud-provider-huaweicloud/test/fakecloud"
+)
+
+// warningMessages returns the messages of the warning events of the reason in the namespace of the services.
+func warningMessages(client kubernetes.Interface, reason string) func() []string {
+	return func() []string {
+		events, err := client.CoreV1().Events(testNamespace).List(context.TODO(), metav1.ListOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		var messages []string
+		for _, event := range events.Items {
+			if event.Type == corev1.EventTypeWarning && event.Reason == reason {
+				messages = append(messages, event.Message)
+			}
+		}
+		return messages
+	}
+}
+
+var _ = ginkgo.Describe("subnet checks", func() {
+	var cloud *fakecloud.Server
+	var provider *huaweicloud.CloudProvider
+	var client kubernetes.Interface
+	var nodes []*corev1.Node
+
+	ginkgo.BeforeEach(func() {
+		cloud = fakecloud.NewServer()
+		ginkgo.DeferCleanup(cloud.Close)
+		cloud.AddAvailabilityZones("az1")
+		cloud.AddServer("node-1", "192.168.1.11", fakecloud.SubnetID)
+		cloud.AddSubnet("other-vpc-subnet", "other-vpc", "10.0.0.0/24")
+		nodes = []*corev1.Node{newNode("node-1", "192.168.1.11")}
+		provider, client = startProvider(cloud, "", nodes...)
+	})
+
+	ginkgo.It("does not create the load balancer in a subnet of another VPC", func() {
+		service := newService(client, "other-vpc", map[string]string{
+			huaweicloud.ElbClass:             "dedicated",
+			huaweicloud.ElbAvailabilityZones: "az1",
+			huaweicloud.ElbSubnetID:          "other-vpc-subnet",
+		}, 80)
+		newPods(client, service, nodes...)
+
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring(
+			"subnet other-vpc-subnet of annotation kubernetes.io/elb.subnet-id belongs to VPC other-vpc " +
+				"instead of VPC fake-vpc of the cloud config")))
+		gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)).Should(gomega.BeEmpty())
+		gomega.Eventually(warningMessages(client, "InvalidSubnet")).Should(gomega.ConsistOf(
+			gomega.ContainSubstring("subnet other-vpc-subnet of annotation kubernetes.io/elb.subnet-id")))
+	})
+
+	ginkgo.It("does not create the load balancer in a subnet not found", func() {
+		service := newService(client, "missing", map[string]string{
+			huaweicloud.ElbClass:    "shared",
+			huaweicloud.ElbSubnetID: "missing-subnet",
+		}, 80)
+		newPods(client, service, nodes...)
+
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring(
+			"subnet missing-subnet of annotation kubernetes.io/elb.subnet-id is not found in VPC fake-vpc")))
+		gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)).Should(gomega.BeEmpty())
+	})
+
+	ginkgo.It("creates the load balancer in the subnet of the cloud config", func() {
+		service := newService(client, "configured", map[string]string{huaweicloud.ElbClass: "shared"}, 80)
+		newPods(client, service, nodes...)
+
+		_, err := provider.EnsureLoadBalancer(context.TODO(), clusterName, service, nodes)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(cloud.List(fakecloud.KindLoadBalancer)).Should(gomega.HaveLen(1))
+	})
+})
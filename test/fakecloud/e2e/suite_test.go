@@ -0,0 +1,182 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	restclient "k8s.io/client-go/rest"
+
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/cloudprovider/huaweicloud"
+	"sigs.k8s.io/cloud-provider-huaweicloud/pkg/config"
+	"sigs.k8s.io/cloud-provider-huaweicloud/test/fakecloud"
+)
+
+const (
+	clusterName   = "kubernetes"
+	testNamespace = "default"
+)
+
+// The tests drive the cloud provider against the fake cloud, the Kubernetes API is faked with the fake clientset.
+// They run with "go test ./test/fakecloud/...", without a cluster or a cloud account.
+func TestE2E(t *testing.T) {
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	ginkgo.RunSpecs(t, "Fake Cloud E2E Suite")
+}
+
+// clientBuilder returns the fake clientset to the cloud provider.
+type clientBuilder struct {
+	client kubernetes.Interface
+}
+
+func (b clientBuilder) Config(string) (*restclient.Config, error) {
+	return &restclient.Config{}, nil
+}
+
+func (b clientBuilder) ConfigOrDie(string) *restclient.Config {
+	return &restclient.Config{}
+}
+
+func (b clientBuilder) Client(string) (kubernetes.Interface, error) {
+	return b.client, nil
+}
+
+func (b clientBuilder) ClientOrDie(string) kubernetes.Interface {
+	return b.client
+}
+
+// startProvider starts a cloud provider of the fake cloud with the Kubernetes objects,
+// the extra sections are appended to the cloud config. It is stopped when the spec ends.
+func startProvider(cloud *fakecloud.Server, extra string, objects ...*corev1.Node) (*huaweicloud.CloudProvider,
+	kubernetes.Interface) {
+	return startProviderWithConfig(cloud, extra, nil, objects...)
+}
+
+// startProviderWithConfig is startProvider with the data of the loadbalancer-config ConfigMap, which is read
+// when the cloud provider is initialized.
+func startProviderWithConfig(cloud *fakecloud.Server, extra string, elbConfig map[string]string,
+	objects ...*corev1.Node) (*huaweicloud.CloudProvider, kubernetes.Interface) {
+	client := fake.NewSimpleClientset()
+	var err error
+	if elbConfig != nil {
+		_, err = client.CoreV1().ConfigMaps(config.ProviderNamespace).Create(context.TODO(), &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: config.ProviderNamespace, Name: config.LoadbalancerConfigMap},
+			Data:       elbConfig,
+		}, metav1.CreateOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	}
+	for _, node := range objects {
+		_, err = client.CoreV1().Nodes().Create(context.TODO(), node, metav1.CreateOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	}
+	stop := make(chan struct{})
+	ginkgo.DeferCleanup(func() { close(stop) })
+	return initProvider(cloud, extra, client, stop), client
+}
+
+// initProvider initializes a cloud provider of the fake cloud with the client, it is stopped by the stop channel.
+func initProvider(cloud *fakecloud.Server, extra string, client kubernetes.Interface,
+	stop chan struct{}) *huaweicloud.CloudProvider {
+	provider, err := huaweicloud.NewHWSCloud(strings.NewReader(cloud.CloudConfig(extra)))
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	provider.Initialize(clientBuilder{client: client}, stop)
+	return provider
+}
+
+// newNode returns a ready node of the IP address, which is backed by the ECS of the same name.
+func newNode(name, ip string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Addresses:  []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: ip}},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+// newService creates the LoadBalancer service of the ports with the annotations.
+func newService(client kubernetes.Interface, name string, annotations map[string]string,
+	ports ...int32) *corev1.Service {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   testNamespace,
+			Name:        name,
+			UID:         types.UID("uid-" + name),
+			Annotations: annotations,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:                  corev1.ServiceTypeLoadBalancer,
+			ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeCluster,
+			Selector:              map[string]string{"app": name},
+		},
+	}
+	for _, port := range ports {
+		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+			Name:       "tcp-" + strconv.Itoa(int(port)),
+			Protocol:   corev1.ProtocolTCP,
+			Port:       port,
+			TargetPort: intstr.FromInt(80),
+			NodePort:   30000 + port,
+		})
+	}
+	service, err := client.CoreV1().Services(testNamespace).Create(context.TODO(), service, metav1.CreateOptions{})
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	return service
+}
+
+// newPods creates a ready Pod of the service on each node.
+func newPods(client kubernetes.Interface, service *corev1.Service, nodes ...*corev1.Node) {
+	for _, node := range nodes {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: service.Namespace,
+				Name:      service.Name + "-" + node.Name,
+				Labels:    service.Spec.Selector,
+			},
+			Spec: corev1.PodSpec{NodeName: node.Name},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				HostIP:     node.Status.Addresses[0].Address,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		}
+		_, err := client.CoreV1().Pods(service.Namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	}
+}
+
+// listOf returns the resources of the kind whose field is the value.
+func listOf(cloud *fakecloud.Server, kind, field, value string) []fakecloud.Resource {
+	var items []fakecloud.Resource
+	for _, r := range cloud.List(kind) {
+		if r.String(field) == value {
+			items = append(items, r)
+		}
+	}
+	return items
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakecloud
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+)
+
+// AddServer adds a running ECS of the name with a NIC of the private IP address in the subnet,
+// it returns the ID of the ECS. The nodes of the cluster are backed by the ECSs of the same names.
+func (s *Server) AddServer(name, ip, subnetID string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	port := s.create(KindPort, Resource{
+		"name":        name + "-nic",
+		"network_id":  subnetID,
+		"status":      "ACTIVE",
+		"mac_address": fmt.Sprintf("fa:16:3e:00:00:%02x", s.nextIP%256),
+		"fixed_ips": []interface{}{
+			map[string]interface{}{"subnet_id": subnetID, "ip_address": ip},
+		},
+	})
+	server := s.create(KindServer, Resource{
+		"name":                        name,
+		"status":                      "ACTIVE",
+		"OS-EXT-AZ:availability_zone": "az1",
+		"flavor":                      map[string]interface{}{"id": "s6.large.2", "name": "s6.large.2"},
+		"metadata":                    map[string]interface{}{},
+		"security_groups":             []interface{}{map[string]interface{}{"id": SecurityGroupID, "name": "default"}},
+		"addresses": map[string]interface{}{
+			VpcID: []interface{}{map[string]interface{}{
+				"addr":                    ip,
+				"version":                 "4",
+				"OS-EXT-IPS:type":         "fixed",
+				"OS-EXT-IPS:port_id":      port.String("id"),
+				"OS-EXT-IPS-MAC:mac_addr": port.String("mac_address"),
+			}},
+		},
+	})
+	port["device_id"] = server.String("id")
+	port["device_owner"] = "compute:az1"
+	return server.String("id")
+}
+
+// registerECS registers the ECS APIs, the ECSs are filtered by the regular expression of the name
+// and the private IP address, and paginated by the page number in the offset.
+func (s *Server) registerECS() {
+	const prefix = "/v1/{project_id}/cloudservers/"
+	s.handle(http.MethodGet, prefix+"detail", func(r *http.Request, _ []string, _ Resource) (int, interface{}) {
+		query := r.URL.Query()
+		var name *regexp.Regexp
+		if expr := query.Get("name"); expr != "" {
+			var err error
+			if name, err = regexp.Compile(expr); err != nil {
+				return http.StatusBadRequest, fmt.Sprintf("invalid name %q: %s", expr, err)
+			}
+		}
+		servers := []Resource{}
+		for _, server := range s.store.resources[KindServer] {
+			if name != nil && !name.MatchString(server.String("name")) {
+				continue
+			}
+			if ip := query.Get("ip_eq"); ip != "" && !hasAddress(server, ip) {
+				continue
+			}
+			servers = append(servers, server)
+		}
+		count := len(servers)
+		if limit, err := strconv.Atoi(query.Get("limit")); err == nil && limit > 0 {
+			page, _ := strconv.Atoi(query.Get("offset"))
+			if page < 1 {
+				page = 1
+			}
+			start := (page - 1) * limit
+			if start > len(servers) {
+				start = len(servers)
+			}
+			end := start + limit
+			if end > len(servers) {
+				end = len(servers)
+			}
+			servers = servers[start:end]
+		}
+		return http.StatusOK, map[string]interface{}{"servers": servers, "count": count}
+	})
+	s.handle(http.MethodGet, prefix+"*", func(_ *http.Request, params []string, _ Resource) (int, interface{}) {
+		server := s.store.get(KindServer, params[0])
+		if server == nil {
+			return notFound(KindServer, params[0])
+		}
+		return http.StatusOK, map[string]interface{}{"server": server}
+	})
+	s.handle(http.MethodGet, prefix+"*/os-interface", func(_ *http.Request, params []string, _ Resource) (int, interface{}) {
+		if s.store.get(KindServer, params[0]) == nil {
+			return notFound(KindServer, params[0])
+		}
+		attachments := []interface{}{}
+		for _, port := range s.store.list(KindPort, map[string][]string{"device_id": {params[0]}}) {
+			attachments = append(attachments, map[string]interface{}{
+				"port_id":    port.String("id"),
+				"net_id":     port.String("network_id"),
+				"mac_addr":   port.String("mac_address"),
+				"port_state": port.String("status"),
+				"fixed_ips":  port["fixed_ips"],
+			})
+		}
+		return http.StatusOK, map[string]interface{}{"interfaceAttachments": attachments}
+	})
+}
+
+// hasAddress returns true if the ECS has the private IP address.
+func hasAddress(server Resource, ip string) bool {
+	networks, _ := server["addresses"].(map[string]interface{})
+	for _, addresses := range networks {
+		items, _ := addresses.([]interface{})
+		for _, item := range items {
+			if address, ok := item.(map[string]interface{}); ok && address["addr"] == ip {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,376 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakecloud
+
+import (
+	"fmt"
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+// AddAvailabilityZones adds an AZ set of the dedicated load balancers, the AZs in a set are active.
+func (s *Server) AddAvailabilityZones(codes ...string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var set []interface{}
+	for _, code := range codes {
+		set = append(set, map[string]interface{}{
+			"code":     code,
+			"state":    "ACTIVE",
+			"protocol": []string{"L4", "L7"},
+		})
+	}
+	s.availabilityZones = append(s.availabilityZones, set)
+}
+
+// registerELB registers the load balancers, listeners, pools, members and health monitors of the ELB v2 and v3
+// APIs, the paths only differ in the version, and of the OpenStack Octavia compatible APIs.
+func (s *Server) registerELB() {
+	for _, version := range []string{"v2", "v3"} {
+		prefix := "/" + version + "/{project_id}/elb/"
+		pageInfo := version == "v3"
+		// The load balancers of the v3 API are dedicated, the v2 API only lists the shared ones like in the
+		// converged regions.
+		guaranteed := version == "v3"
+		if !guaranteed {
+			s.handle(http.MethodGet, prefix+KindLoadBalancer, func(r *http.Request, _ []string, _ Resource) (int, interface{}) {
+				query := r.URL.Query()
+				query.Set("guaranteed", "false")
+				r.URL.RawQuery = query.Encode()
+				return s.listResponse(KindLoadBalancer, KindLoadBalancer, r, pageInfo)
+			})
+		}
+
+		s.handleCollection(prefix+KindLoadBalancer, KindLoadBalancer, "loadbalancer", pageInfo,
+			func(lb Resource) (int, string) {
+				lb["guaranteed"] = guaranteed
+				return s.createLoadBalancer(lb)
+			}, s.deleteLoadBalancer)
+		s.handleCollection(prefix+KindListener, KindListener, "listener", pageInfo,
+			s.createListener, s.deleteListener)
+		s.handleCollection(prefix+KindPool, KindPool, "pool", pageInfo, s.createPool, s.deletePool)
+		s.handleCollection(prefix+KindHealthMonitor, KindHealthMonitor, "healthmonitor", pageInfo,
+			s.createHealthMonitor, s.deleteHealthMonitor)
+		s.handleMembers(prefix+"pools/*/members", pageInfo)
+	}
+
+	// The OpenStack Octavia compatible APIs are not project scoped in the path.
+	const lbaas = "/v2/lbaas/"
+	s.handleCollection(lbaas+KindLoadBalancer, KindLoadBalancer, "loadbalancer", false, s.createLoadBalancer,
+		s.deleteLoadBalancer)
+	s.handleCollection(lbaas+KindListener, KindListener, "listener", false, s.createListener, s.deleteListener)
+	s.handleCollection(lbaas+KindPool, KindPool, "pool", false, s.createPool, s.deletePool)
+	s.handleCollection(lbaas+KindHealthMonitor, KindHealthMonitor, "healthmonitor", false,
+		s.createHealthMonitor, s.deleteHealthMonitor)
+	s.handleMembers(lbaas+"pools/*/members", false)
+
+	s.handle(http.MethodGet, "/v3/{project_id}/elb/availability-zones", func(*http.Request, []string, Resource) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"availability_zones": s.availabilityZones}
+	})
+	s.handle(http.MethodGet, "/v3/{project_id}/elb/quotas/details", func(*http.Request, []string, Resource) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"quotas": []interface{}{}}
+	})
+}
+
+// handleCollection registers the list, create, show, update and delete APIs of the resources of the kind,
+// the request and response bodies hold the resource under the key. create and remove maintain the references
+// of the resource, create returns the status code and the error message if the resource is rejected.
+func (s *Server) handleCollection(path, kind, key string, pageInfo bool,
+	create func(r Resource) (int, string), remove func(r Resource) (int, string)) {
+	s.handle(http.MethodGet, path, func(r *http.Request, _ []string, _ Resource) (int, interface{}) {
+		return s.listResponse(kind, kind, r, pageInfo)
+	})
+	s.handle(http.MethodPost, path, func(_ *http.Request, _ []string, body Resource) (int, interface{}) {
+		object := bodyOf(body, key)
+		if object == nil {
+			return http.StatusBadRequest, fmt.Sprintf("%s is required in the request body", key)
+		}
+		// The ID is assigned before the hook, which references the resource in the others.
+		object["id"] = string(uuid.NewUUID())
+		if create != nil {
+			if code, msg := create(object); code != 0 {
+				return code, msg
+			}
+		}
+		return http.StatusCreated, map[string]interface{}{key: s.create(kind, object)}
+	})
+	s.handle(http.MethodGet, path+"/*", func(_ *http.Request, params []string, _ Resource) (int, interface{}) {
+		id := params[len(params)-1]
+		object := s.store.get(kind, id)
+		if object == nil {
+			return notFound(kind, id)
+		}
+		return http.StatusOK, map[string]interface{}{key: object}
+	})
+	s.handle(http.MethodPut, path+"/*", func(_ *http.Request, params []string, body Resource) (int, interface{}) {
+		id := params[len(params)-1]
+		object := s.store.get(kind, id)
+		if object == nil {
+			return notFound(kind, id)
+		}
+		s.update(object, bodyOf(body, key))
+		return http.StatusOK, map[string]interface{}{key: object}
+	})
+	s.handle(http.MethodDelete, path+"/*", func(_ *http.Request, params []string, _ Resource) (int, interface{}) {
+		id := params[len(params)-1]
+		object := s.store.get(kind, id)
+		if object == nil {
+			return notFound(kind, id)
+		}
+		if remove != nil {
+			if code, msg := remove(object); code != 0 {
+				return code, msg
+			}
+		}
+		s.store.remove(kind, id)
+		return http.StatusNoContent, nil
+	})
+}
+
+// AddLoadBalancer adds an active load balancer of the name in the subnet, dedicated or shared,
+// it returns the ID of the load balancer.
+func (s *Server) AddLoadBalancer(name, subnetID string, dedicated bool) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	lb := Resource{"name": name, "vip_subnet_cidr_id": subnetID, "guaranteed": dedicated}
+	_, _ = s.createLoadBalancer(lb)
+	return s.create(KindLoadBalancer, lb).String("id")
+}
+
+// createLoadBalancer allocates the VIP and its port in the subnet of the load balancer,
+// and creates or binds the EIPs of the v3 API. The load balancer is active once created.
+func (s *Server) createLoadBalancer(lb Resource) (int, string) {
+	subnetID := lb.String("vip_subnet_cidr_id")
+	if subnetID == "" {
+		subnetID = lb.String("vip_subnet_id")
+	}
+	if subnetID == "" {
+		return http.StatusBadRequest, "the subnet of the load balancer is required"
+	}
+	var eips []Resource
+	ids, _ := lb["publicip_ids"].([]interface{})
+	for _, id := range ids {
+		eip := s.store.get(KindPublicIP, fmt.Sprint(id))
+		if eip == nil {
+			return http.StatusBadRequest, fmt.Sprintf("publicip %s is not found", id)
+		}
+		eips = append(eips, eip)
+	}
+	delete(lb, "publicip_ids")
+	if lb.String("vip_address") == "" {
+		lb["vip_address"] = s.allocateIP("192.168.0.")
+	}
+	port := s.create(KindPort, Resource{
+		"name":            "loadbalancer-vip",
+		"network_id":      subnetID,
+		"device_owner":    "neutron:LOADBALANCERV2",
+		"status":          "ACTIVE",
+		"security_groups": []interface{}{SecurityGroupID},
+		"fixed_ips": []interface{}{
+			map[string]interface{}{"subnet_id": subnetID, "ip_address": lb.String("vip_address")},
+		},
+	})
+	lb["vip_port_id"] = port.String("id")
+	lb["provisioning_status"] = "ACTIVE"
+	lb["operating_status"] = "ONLINE"
+	lb["listeners"] = []interface{}{}
+
+	if option := bodyOf(lb, "publicip"); option != nil {
+		eips = append(eips, s.create(KindPublicIP, Resource{
+			"type":              option["network_type"],
+			"public_ip_address": s.allocateIP("100.64.0."),
+			"ip_version":        4,
+			"status":            "DOWN",
+		}))
+		delete(lb, "publicip")
+	}
+	var eipRefs, publicIPs []interface{}
+	for _, eip := range eips {
+		s.bindPublicIP(eip, port.String("id"))
+		eipRefs = append(eipRefs, map[string]interface{}{
+			"eip_id": eip.String("id"), "eip_address": eip.String("public_ip_address"), "ip_version": 4,
+		})
+		publicIPs = append(publicIPs, map[string]interface{}{
+			"publicip_id": eip.String("id"), "publicip_address": eip.String("public_ip_address"), "ip_version": 4,
+		})
+	}
+	if eipRefs != nil {
+		lb["eips"], lb["publicips"] = eipRefs, publicIPs
+	}
+	return 0, ""
+}
+
+// deleteLoadBalancer rejects the load balancers with listeners like the cloud, the EIPs are unbound.
+func (s *Server) deleteLoadBalancer(lb Resource) (int, string) {
+	if listeners, _ := lb["listeners"].([]interface{}); len(listeners) > 0 {
+		return http.StatusConflict, fmt.Sprintf("loadbalancer %s has %d listeners", lb.String("id"), len(listeners))
+	}
+	portID := lb.String("vip_port_id")
+	for _, eip := range s.store.list(KindPublicIP, map[string][]string{"port_id": {portID}}) {
+		s.bindPublicIP(eip, "")
+	}
+	s.store.remove(KindPort, portID)
+	return 0, ""
+}
+
+// createListener rejects the port used by another listener of the load balancer like the cloud.
+func (s *Server) createListener(listener Resource) (int, string) {
+	lbID := listener.String("loadbalancer_id")
+	lb := s.store.get(KindLoadBalancer, lbID)
+	if lb == nil {
+		return http.StatusBadRequest, fmt.Sprintf("loadbalancer %s is not found", lbID)
+	}
+	// the listeners of the protocols over TCP share the port, a UDP listener may use the port of a TCP one.
+	for _, other := range s.store.list(KindListener, map[string][]string{"loadbalancer_id": {lbID}}) {
+		if fmt.Sprint(other["protocol_port"]) == fmt.Sprint(listener["protocol_port"]) &&
+			(other.String("protocol") == "UDP") == (listener.String("protocol") == "UDP") {
+			return http.StatusConflict, fmt.Sprintf("the port %v is used by listener %s",
+				listener["protocol_port"], other.String("id"))
+		}
+	}
+	listener["loadbalancers"] = refs(lbID)
+	listener["provisioning_status"] = "ACTIVE"
+	if listener.String("default_pool_id") == "" {
+		listener["default_pool_id"] = ""
+	}
+	lb["listeners"] = append(lb["listeners"].([]interface{}), refs(listener.String("id"))...)
+	return 0, ""
+}
+
+func (s *Server) deleteListener(listener Resource) (int, string) {
+	for _, lb := range s.store.list(KindLoadBalancer, map[string][]string{"listeners": {listener.String("id")}}) {
+		removeRef(lb, "listeners", listener.String("id"))
+	}
+	return 0, ""
+}
+
+// createPool binds the pool to its listener and load balancer.
+func (s *Server) createPool(pool Resource) (int, string) {
+	lbID := pool.String("loadbalancer_id")
+	if listenerID := pool.String("listener_id"); listenerID != "" {
+		listener := s.store.get(KindListener, listenerID)
+		if listener == nil {
+			return http.StatusBadRequest, fmt.Sprintf("listener %s is not found", listenerID)
+		}
+		if listener.String("default_pool_id") != "" {
+			return http.StatusConflict, fmt.Sprintf("listener %s already has pool %s",
+				listenerID, listener.String("default_pool_id"))
+		}
+		lbID = listener.String("loadbalancer_id")
+		pool["listeners"] = refs(listenerID)
+		listener["default_pool_id"] = pool.String("id")
+	} else {
+		pool["listeners"] = []interface{}{}
+	}
+	pool["loadbalancers"] = refs(lbID)
+	pool["members"] = []interface{}{}
+	pool["healthmonitor_id"] = ""
+	return 0, ""
+}
+
+// deletePool unbinds the pool from its listener, its members are deleted.
+func (s *Server) deletePool(pool Resource) (int, string) {
+	for _, listener := range s.store.list(KindListener, map[string][]string{"default_pool_id": {pool.String("id")}}) {
+		listener["default_pool_id"] = ""
+	}
+	for _, member := range s.store.list(KindMember, map[string][]string{"pool_id": {pool.String("id")}}) {
+		s.store.remove(KindMember, member.String("id"))
+	}
+	return 0, ""
+}
+
+func (s *Server) createHealthMonitor(monitor Resource) (int, string) {
+	poolID := monitor.String("pool_id")
+	pool := s.store.get(KindPool, poolID)
+	if pool == nil {
+		return http.StatusBadRequest, fmt.Sprintf("pool %s is not found", poolID)
+	}
+	if pool.String("healthmonitor_id") != "" {
+		return http.StatusConflict, fmt.Sprintf("pool %s already has health monitor %s",
+			poolID, pool.String("healthmonitor_id"))
+	}
+	monitor["pools"] = refs(poolID)
+	pool["healthmonitor_id"] = monitor.String("id")
+	return 0, ""
+}
+
+func (s *Server) deleteHealthMonitor(monitor Resource) (int, string) {
+	if pool := s.store.get(KindPool, monitor.String("pool_id")); pool != nil {
+		pool["healthmonitor_id"] = ""
+	}
+	return 0, ""
+}
+
+// handleMembers registers the members APIs of the pools, the path has the wildcard of the pool ID.
+func (s *Server) handleMembers(path string, pageInfo bool) {
+	s.handle(http.MethodGet, path, func(r *http.Request, params []string, _ Resource) (int, interface{}) {
+		query := r.URL.Query()
+		query.Set("pool_id", params[0])
+		r.URL.RawQuery = query.Encode()
+		return s.listResponse(KindMember, KindMember, r, pageInfo)
+	})
+	s.handle(http.MethodPost, path, func(_ *http.Request, params []string, body Resource) (int, interface{}) {
+		poolID := params[0]
+		pool := s.store.get(KindPool, poolID)
+		if pool == nil {
+			return notFound(KindPool, poolID)
+		}
+		member := bodyOf(body, "member")
+		if member == nil {
+			return http.StatusBadRequest, "member is required in the request body"
+		}
+		for _, other := range s.store.list(KindMember, map[string][]string{"pool_id": {poolID}}) {
+			if other.String("address") == member.String("address") &&
+				fmt.Sprint(other["protocol_port"]) == fmt.Sprint(member["protocol_port"]) {
+				return http.StatusConflict, fmt.Sprintf("member %s:%v already exists in pool %s",
+					member.String("address"), member["protocol_port"], poolID)
+			}
+		}
+		member["pool_id"] = poolID
+		member["operating_status"] = "ONLINE"
+		member = s.create(KindMember, member)
+		pool["members"] = append(pool["members"].([]interface{}), refs(member.String("id"))...)
+		return http.StatusCreated, map[string]interface{}{"member": member}
+	})
+	s.handle(http.MethodGet, path+"/*", func(_ *http.Request, params []string, _ Resource) (int, interface{}) {
+		member := s.store.get(KindMember, params[1])
+		if member == nil || member.String("pool_id") != params[0] {
+			return notFound(KindMember, params[1])
+		}
+		return http.StatusOK, map[string]interface{}{"member": member}
+	})
+	s.handle(http.MethodPut, path+"/*", func(_ *http.Request, params []string, body Resource) (int, interface{}) {
+		member := s.store.get(KindMember, params[1])
+		if member == nil || member.String("pool_id") != params[0] {
+			return notFound(KindMember, params[1])
+		}
+		s.update(member, bodyOf(body, "member"))
+		return http.StatusOK, map[string]interface{}{"member": member}
+	})
+	s.handle(http.MethodDelete, path+"/*", func(_ *http.Request, params []string, _ Resource) (int, interface{}) {
+		member := s.store.get(KindMember, params[1])
+		if member == nil || member.String("pool_id") != params[0] {
+			return notFound(KindMember, params[1])
+		}
+		if pool := s.store.get(KindPool, params[0]); pool != nil {
+			removeRef(pool, "members", params[1])
+		}
+		s.store.remove(KindMember, params[1])
+		return http.StatusNoContent, nil
+	})
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakecloud
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// AddGAEndpointGroup adds an endpoint group of a Global Accelerator, it returns the ID of the endpoint group.
+func (s *Server) AddGAEndpointGroup() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	group := s.create(KindGAEndpointGroup, Resource{"status": "ACTIVE"})
+	return group.String("id")
+}
+
+// AddGAEndpoint adds the EIP of the ID and the address to the endpoint group, it returns the ID of the endpoint.
+func (s *Server) AddGAEndpoint(groupID, eipID, address string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	endpoint := s.create(KindGAEndpoint, Resource{
+		"endpoint_group_id": groupID,
+		"resource_id":       eipID,
+		"resource_type":     "EIP",
+		"ip_address":        address,
+		"status":            "ACTIVE",
+	})
+	return endpoint.String("id")
+}
+
+// registerGA registers the endpoint APIs of the v1 Global Accelerator API, the endpoints are active at once.
+func (s *Server) registerGA() {
+	const endpoints = "/v1/endpoint-groups/*/endpoints"
+	s.handle(http.MethodGet, endpoints, func(r *http.Request, params []string, _ Resource) (int, interface{}) {
+		if s.store.get(KindGAEndpointGroup, params[0]) == nil {
+			return notFound(KindGAEndpointGroup, params[0])
+		}
+		query := r.URL.Query()
+		filter := url.Values{"endpoint_group_id": {params[0]}}
+		items, next := paginate(s.store.list(KindGAEndpoint, filter), query)
+		if items == nil {
+			items = []Resource{}
+		}
+		info := map[string]interface{}{"current_count": len(items)}
+		if next != "" {
+			info["next_marker"] = next
+		}
+		return http.StatusOK, map[string]interface{}{"endpoints": items, "page_info": info}
+	})
+	s.handle(http.MethodPost, endpoints, func(_ *http.Request, params []string, body Resource) (int, interface{}) {
+		if s.store.get(KindGAEndpointGroup, params[0]) == nil {
+			return notFound(KindGAEndpointGroup, params[0])
+		}
+		option := bodyOf(body, "endpoint")
+		if option == nil || option.String("resource_id") == "" {
+			return http.StatusBadRequest, "endpoint.resource_id is required in the request body"
+		}
+		for _, other := range s.store.list(KindGAEndpoint, url.Values{"endpoint_group_id": {params[0]}}) {
+			if other.String("resource_id") == option.String("resource_id") {
+				return http.StatusConflict, fmt.Sprintf("the resource %s is an endpoint of the endpoint group %s",
+					option.String("resource_id"), params[0])
+			}
+		}
+		option["endpoint_group_id"] = params[0]
+		option["status"] = "ACTIVE"
+		return http.StatusCreated, map[string]interface{}{"endpoint": s.create(KindGAEndpoint, option)}
+	})
+	s.handle(http.MethodDelete, endpoints+"/*", func(_ *http.Request, params []string, _ Resource) (int, interface{}) {
+		endpoint := s.store.get(KindGAEndpoint, params[1])
+		if endpoint == nil || endpoint.String("endpoint_group_id") != params[0] {
+			return notFound(KindGAEndpoint, params[1])
+		}
+		s.store.remove(KindGAEndpoint, params[1])
+		return http.StatusNoContent, nil
+	})
+}
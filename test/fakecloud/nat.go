@@ -0,0 +1,97 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakecloud
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// AddNATGateway adds a running NAT gateway in the VPC and subnet, it returns the ID of the NAT gateway.
+func (s *Server) AddNATGateway(vpcID, subnetID string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	gateway := s.create(KindNATGateway, Resource{
+		"name":                "nat-" + vpcID,
+		"router_id":           vpcID,
+		"internal_network_id": subnetID,
+		"status":              "ACTIVE",
+		"spec":                "1",
+		"admin_state_up":      true,
+	})
+	return gateway.String("id")
+}
+
+// registerNAT registers the NAT gateway and DNAT rule APIs of the v2 NAT API.
+func (s *Server) registerNAT() {
+	const prefix = "/v2/{project_id}/"
+	s.handle(http.MethodGet, prefix+KindNATGateway, func(r *http.Request, _ []string, _ Resource) (int, interface{}) {
+		return s.listResponse(KindNATGateway, KindNATGateway, r, false)
+	})
+	s.handle(http.MethodGet, prefix+KindNATGateway+"/*", func(_ *http.Request, params []string, _ Resource) (int, interface{}) {
+		gateway := s.store.get(KindNATGateway, params[0])
+		if gateway == nil {
+			return notFound(KindNATGateway, params[0])
+		}
+		return http.StatusOK, map[string]interface{}{"nat_gateway": gateway}
+	})
+
+	s.handle(http.MethodGet, prefix+KindDNATRule, func(r *http.Request, _ []string, _ Resource) (int, interface{}) {
+		return s.listResponse(KindDNATRule, KindDNATRule, r, false)
+	})
+	s.handle(http.MethodPost, prefix+KindDNATRule, func(_ *http.Request, _ []string, body Resource) (int, interface{}) {
+		rule := bodyOf(body, "dnat_rule")
+		if rule == nil {
+			return http.StatusBadRequest, "dnat_rule is required in the request body"
+		}
+		if s.store.get(KindNATGateway, rule.String("nat_gateway_id")) == nil {
+			return http.StatusBadRequest, fmt.Sprintf("nat gateway %s is not found", rule.String("nat_gateway_id"))
+		}
+		fip := s.store.get(KindFloatingIP, rule.String("floating_ip_id"))
+		if fip == nil {
+			return http.StatusBadRequest, fmt.Sprintf("floating ip %s is not found", rule.String("floating_ip_id"))
+		}
+		for _, other := range s.store.list(KindDNATRule, map[string][]string{"floating_ip_id": {fip.String("id")}}) {
+			if fmt.Sprint(other["external_service_port"]) == fmt.Sprint(rule["external_service_port"]) &&
+				other.String("protocol") == rule.String("protocol") {
+				return http.StatusConflict, fmt.Sprintf("the port %v of floating ip %s is used by DNAT rule %s",
+					rule["external_service_port"], fip.String("floating_ip_address"), other.String("id"))
+			}
+		}
+		rule["floating_ip_address"] = fip.String("floating_ip_address")
+		rule["status"] = "ACTIVE"
+		rule["admin_state_up"] = true
+		fip["status"] = "ACTIVE"
+		return http.StatusCreated, map[string]interface{}{"dnat_rule": s.create(KindDNATRule, rule)}
+	})
+	s.handle(http.MethodGet, prefix+KindDNATRule+"/*", func(_ *http.Request, params []string, _ Resource) (int, interface{}) {
+		rule := s.store.get(KindDNATRule, params[0])
+		if rule == nil {
+			return notFound(KindDNATRule, params[0])
+		}
+		return http.StatusOK, map[string]interface{}{"dnat_rule": rule}
+	})
+	s.handle(http.MethodDelete, prefix+KindNATGateway+"/*/"+KindDNATRule+"/*",
+		func(_ *http.Request, params []string, _ Resource) (int, interface{}) {
+			rule := s.store.get(KindDNATRule, params[1])
+			if rule == nil || rule.String("nat_gateway_id") != params[0] {
+				return notFound(KindDNATRule, params[1])
+			}
+			s.store.remove(KindDNATRule, params[1])
+			return http.StatusNoContent, nil
+		})
+}
@@ -0,0 +1,418 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fakecloud is an in-memory fake of the Huawei Cloud APIs used by the cloud provider: the ELB v2 and v3,
+// VPC, EIP, NAT and ECS APIs. It serves the APIs over HTTP with httptest, so that the provider is tested with
+// its real clients and without a cloud account.
+package fakecloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// ProjectID is the project of the fake, the requests of the other projects are rejected.
+	ProjectID = "fake0000000000000000000000project"
+	// Region is the region of the cloud config of the fake.
+	Region = "fake-region-1"
+	// VpcID is the VPC of the cluster in the cloud config of the fake.
+	VpcID = "fake-vpc"
+	// SubnetID is the subnet of the nodes in the cloud config of the fake.
+	SubnetID = "fake-subnet"
+	// SecurityGroupID is the security group of the ECSs of the nodes.
+	SecurityGroupID = "fake-security-group"
+)
+
+// handler serves a request of a route, the params are the path segments matching the wildcards.
+type handler func(r *http.Request, params []string, body Resource) (int, interface{})
+
+type route struct {
+	method   string
+	segments []string
+	handle   handler
+}
+
+// fault fails the requests of a method and a path prefix with the status code.
+type fault struct {
+	method string
+	path   string
+	code   int
+	times  int
+	// handled specifies whether the request is handled before failing, as if the response is lost.
+	handled bool
+	// delay is the delay before the request is handled, the request is not failed if it is set.
+	delay time.Duration
+}
+
+// Server is the fake cloud, the resources are held in memory and lost when it is closed.
+type Server struct {
+	server *httptest.Server
+	routes []route
+
+	lock     sync.Mutex
+	store    *store
+	faults   []*fault
+	delays   []*fault
+	requests []string
+	// clock is the time of the last change, it advances one second on each change,
+	// so that the updated_at of the resources differ after each change like in the cloud.
+	clock time.Time
+	// nextIP is the last octet of the last private or public IP address allocated.
+	nextIP int
+	// availabilityZones are the AZ sets of the dedicated load balancers.
+	availabilityZones [][]interface{}
+}
+
+// NewServer starts a fake cloud without any resource, the caller closes it with Close.
+func NewServer() *Server {
+	s := &Server{
+		store:  newStore(),
+		clock:  time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		nextIP: 10,
+	}
+	s.AddSubnet(SubnetID, VpcID, "192.168.0.0/16")
+	s.registerELB()
+	s.registerVPC()
+	s.registerNAT()
+	s.registerECS()
+	s.registerDNS()
+	s.registerGA()
+	s.server = httptest.NewServer(s)
+	return s
+}
+
+// URL returns the endpoint of all the services of the fake.
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// Close stops serving the APIs.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// CloudConfig returns a cloud config of the fake, the endpoints of all the services are the fake.
+// The extra sections, such as [LoadBalancer], are appended.
+func (s *Server) CloudConfig(extra string) string {
+	cfg := fmt.Sprintf(`[Global]
+region = %s
+access-key = fake-access-key
+secret-key = fake-secret-key
+project-id = %s
+
+[Vpc]
+id = %s
+subnet-id = %s
+`, Region, ProjectID, VpcID, SubnetID)
+	for _, catalog := range []string{"ecs", "elb", "vpc", "nat", "iam", "dns", "ga"} {
+		cfg += fmt.Sprintf("\n[Endpoint %q]\nurl = %s\n", catalog, s.URL())
+	}
+	return cfg + "\n" + extra
+}
+
+// InjectFault fails the next requests of the method whose path starts with the prefix with the status code,
+// the project ID in the prefix is "{project_id}", such as "/v3/{project_id}/elb/listeners".
+func (s *Server) InjectFault(method, prefix string, code, times int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.faults = append(s.faults, &fault{
+		method: method,
+		path:   strings.ReplaceAll(prefix, "{project_id}", ProjectID),
+		code:   code,
+		times:  times,
+	})
+}
+
+// InjectLostResponse handles the next requests of the method whose path starts with the prefix, and then fails
+// them with the status code, as if the responses are lost by a gateway timeout after the changes are made.
+func (s *Server) InjectLostResponse(method, prefix string, code, times int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.faults = append(s.faults, &fault{
+		method:  method,
+		path:    strings.ReplaceAll(prefix, "{project_id}", ProjectID),
+		code:    code,
+		times:   times,
+		handled: true,
+	})
+}
+
+// InjectDelay delays the next requests of the method whose path starts with the prefix before they are handled,
+// the other requests are served in the meantime.
+func (s *Server) InjectDelay(method, prefix string, delay time.Duration, times int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.delays = append(s.delays, &fault{
+		method: method,
+		path:   strings.ReplaceAll(prefix, "{project_id}", ProjectID),
+		times:  times,
+		delay:  delay,
+	})
+}
+
+// Requests returns the requests served, in the form of "GET /v3/{project_id}/elb/loadbalancers".
+func (s *Server) Requests() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// List returns a copy of the resources of the kind.
+func (s *Server) List(kind string) []Resource {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var items []Resource
+	for _, r := range s.store.resources[kind] {
+		items = append(items, r.copy())
+	}
+	return items
+}
+
+// Get returns a copy of the resource of the kind, nil if it is not found.
+func (s *Server) Get(kind, id string) Resource {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if r := s.store.get(kind, id); r != nil {
+		return r.copy()
+	}
+	return nil
+}
+
+// handle registers the handler of the method and the path, "*" in the path matches any segment.
+func (s *Server) handle(method, path string, h handler) {
+	s.routes = append(s.routes, route{
+		method:   method,
+		segments: strings.Split(strings.Trim(path, "/"), "/"),
+		handle:   h,
+	})
+}
+
+// ServeHTTP serves the request with the matching route, the requests are served one by one.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if delay := s.takeDelay(r); delay > 0 {
+		time.Sleep(delay)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.requests = append(s.requests, r.Method+" "+strings.ReplaceAll(r.URL.Path, ProjectID, "{project_id}"))
+
+	injected := s.takeFault(r)
+	if injected != nil && !injected.handled {
+		writeError(w, injected.code, "FAKE.0001", "injected fault")
+		return
+	}
+
+	var body Resource
+	if data, _ := io.ReadAll(r.Body); len(data) > 0 {
+		if err := json.Unmarshal(data, &body); err != nil {
+			writeError(w, http.StatusBadRequest, "FAKE.0400", fmt.Sprintf("invalid request body: %s", err))
+			return
+		}
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	for _, rt := range s.routes {
+		params, ok := rt.match(r.Method, segments)
+		if !ok {
+			continue
+		}
+		code, rsp := rt.handle(r, params, body)
+		if injected != nil {
+			writeError(w, injected.code, "FAKE.0001", "injected fault, the response is lost")
+			return
+		}
+		if code >= http.StatusBadRequest {
+			writeError(w, code, fmt.Sprintf("FAKE.%04d", code), fmt.Sprint(rsp))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", string(uuid.NewUUID()))
+		w.WriteHeader(code)
+		if rsp != nil {
+			_ = json.NewEncoder(w).Encode(rsp)
+		}
+		return
+	}
+	klog.Warningf("fake cloud: no route of %s %s", r.Method, r.URL.Path)
+	writeError(w, http.StatusNotFound, "APIGW.0101", "the API does not exist or has not been published")
+}
+
+// match returns the path segments matching the wildcards if the request matches the route.
+// The project segment must be the project of the fake.
+func (rt route) match(method string, segments []string) ([]string, bool) {
+	if rt.method != method || len(rt.segments) != len(segments) {
+		return nil, false
+	}
+	var params []string
+	for i, segment := range rt.segments {
+		switch segment {
+		case "*":
+			params = append(params, segments[i])
+		case "{project_id}":
+			if segments[i] != ProjectID {
+				return nil, false
+			}
+		default:
+			if segment != segments[i] {
+				return nil, false
+			}
+		}
+	}
+	return params, true
+}
+
+// takeFault returns the fault injected for the request, nil if none.
+func (s *Server) takeFault(r *http.Request) *fault {
+	for i, f := range s.faults {
+		if f.method != r.Method || !strings.HasPrefix(r.URL.Path, f.path) {
+			continue
+		}
+		f.times--
+		if f.times <= 0 {
+			s.faults = append(s.faults[:i], s.faults[i+1:]...)
+		}
+		return f
+	}
+	return nil
+}
+
+// takeDelay returns the injected delay of the request.
+func (s *Server) takeDelay(r *http.Request) time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i, f := range s.delays {
+		if f.method != r.Method || !strings.HasPrefix(r.URL.Path, f.path) {
+			continue
+		}
+		f.times--
+		if f.times <= 0 {
+			s.delays = append(s.delays[:i], s.delays[i+1:]...)
+		}
+		return f.delay
+	}
+	return 0
+}
+
+// writeError writes the error in the form of the cloud APIs, which is parsed by the SDK.
+func writeError(w http.ResponseWriter, code int, errorCode, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Request-Id", string(uuid.NewUUID()))
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error_code": errorCode, "error_msg": msg})
+}
+
+// now advances the clock and returns the time of the change.
+func (s *Server) now() string {
+	s.clock = s.clock.Add(time.Second)
+	return s.clock.Format("2006-01-02T15:04:05Z")
+}
+
+// allocateIP returns an unused address of the prefix, such as "192.168.0.".
+func (s *Server) allocateIP(prefix string) string {
+	s.nextIP++
+	return fmt.Sprintf("%s%d", prefix, s.nextIP)
+}
+
+// create adds the resource of the kind with a new ID and the creation time.
+func (s *Server) create(kind string, r Resource) Resource {
+	if r == nil {
+		r = Resource{}
+	}
+	if r.String("id") == "" {
+		r["id"] = string(uuid.NewUUID())
+	}
+	now := s.now()
+	r["created_at"] = now
+	r["updated_at"] = now
+	r["project_id"] = ProjectID
+	r["tenant_id"] = ProjectID
+	s.store.add(kind, r)
+	return r
+}
+
+// update merges the fields into the resource and advances its update time.
+func (s *Server) update(r Resource, fields Resource) {
+	for key, value := range fields {
+		if key != "id" {
+			r[key] = value
+		}
+	}
+	r["updated_at"] = s.now()
+}
+
+// bodyOf returns the object under the key of the request body, such as "loadbalancer".
+func bodyOf(body Resource, key string) Resource {
+	object, _ := body[key].(map[string]interface{})
+	return object
+}
+
+// refs returns the references of the IDs in the form of the APIs, such as [{"id": "..."}].
+func refs(ids ...string) []interface{} {
+	items := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		if id != "" {
+			items = append(items, map[string]interface{}{"id": id})
+		}
+	}
+	return items
+}
+
+// removeRef removes the reference of the ID from the plural field of the resource.
+func removeRef(r Resource, field, id string) {
+	items, _ := r[field].([]interface{})
+	kept := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		if ref, ok := item.(map[string]interface{}); ok && ref["id"] == id {
+			continue
+		}
+		kept = append(kept, item)
+	}
+	r[field] = kept
+}
+
+// notFound returns the response of a missing resource.
+func notFound(kind, id string) (int, interface{}) {
+	return http.StatusNotFound, fmt.Sprintf("%s %s is not found", strings.TrimSuffix(kind, "s"), id)
+}
+
+// listResponse returns the page of the resources of the kind matching the query under the plural key.
+// The marker of the next page is returned in the page_info of the v3 APIs.
+func (s *Server) listResponse(kind, key string, r *http.Request, pageInfo bool) (int, interface{}) {
+	query := r.URL.Query()
+	items, next := paginate(s.store.list(kind, query), query)
+	if items == nil {
+		items = []Resource{}
+	}
+	rsp := map[string]interface{}{key: items}
+	if pageInfo {
+		info := map[string]interface{}{"current_count": len(items)}
+		if next != "" {
+			info["next_marker"] = next
+		}
+		rsp["page_info"] = info
+	}
+	return http.StatusOK, rsp
+}
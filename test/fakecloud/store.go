@@ -0,0 +1,198 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakecloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// The kinds of the resources held by the fake, the ELB v2 and v3 APIs share the load balancers, listeners,
+// pools, members and health monitors like the cloud does.
+const (
+	KindLoadBalancer  = "loadbalancers"
+	KindListener      = "listeners"
+	KindPool          = "pools"
+	KindMember        = "members"
+	KindHealthMonitor = "healthmonitors"
+	KindPublicIP      = "publicips"
+	KindPort          = "ports"
+	KindSubnet        = "subnets"
+	KindFloatingIP    = "floatingips"
+	KindRouteTable    = "routetables"
+	KindServer        = "servers"
+	KindNATGateway    = "nat_gateways"
+	KindDNATRule      = "dnat_rules"
+	KindDNSZone       = "zones"
+	KindDNSRecordSet  = "recordsets"
+
+	KindGAEndpointGroup = "endpoint_groups"
+	KindGAEndpoint      = "endpoints"
+
+	KindSecurityGroupRule = "security_group_rules"
+)
+
+// Resource is a resource in the JSON form of the API, keyed by the JSON field names.
+type Resource map[string]interface{}
+
+// String returns the string field of the resource, empty if it is absent or not a string.
+func (r Resource) String(field string) string {
+	value, _ := r[field].(string)
+	return value
+}
+
+// copy returns a deep copy of the resource, so that the callers never share the state of the fake.
+func (r Resource) copy() Resource {
+	data, _ := json.Marshal(r)
+	var out Resource
+	_ = json.Unmarshal(data, &out)
+	return out
+}
+
+// store holds the resources of each kind in the order they are created.
+type store struct {
+	resources map[string][]Resource
+}
+
+func newStore() *store {
+	return &store{resources: make(map[string][]Resource)}
+}
+
+func (s *store) add(kind string, r Resource) {
+	s.resources[kind] = append(s.resources[kind], r)
+}
+
+func (s *store) get(kind, id string) Resource {
+	for _, r := range s.resources[kind] {
+		if r.String("id") == id {
+			return r
+		}
+	}
+	return nil
+}
+
+func (s *store) remove(kind, id string) bool {
+	items := s.resources[kind]
+	for i, r := range items {
+		if r.String("id") == id {
+			s.resources[kind] = append(items[:i:i], items[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// list returns the resources of the kind matching all the filters of the query.
+func (s *store) list(kind string, query url.Values) []Resource {
+	var items []Resource
+	for _, r := range s.resources[kind] {
+		if matchQuery(r, query) {
+			items = append(items, r)
+		}
+	}
+	return items
+}
+
+// ignoredParams are the query parameters which are not the filters of the fields.
+var ignoredParams = map[string]bool{
+	"limit":                 true,
+	"marker":                true,
+	"offset":                true,
+	"page_reverse":          true,
+	"enterprise_project_id": true,
+}
+
+// matchQuery returns true if the resource matches each filter of the query, a filter repeated in the query
+// matches any of its values, such as "id=a&id=b".
+func matchQuery(r Resource, query url.Values) bool {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if ignoredParams[key] {
+			continue
+		}
+		matched := false
+		for _, value := range query[key] {
+			if matchField(r, key, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// matchField matches a filter of the query with the field of the same name. The filters of the referenced IDs,
+// such as "loadbalancer_id", also match the references in the plural fields, such as "loadbalancers":[{"id":...}].
+// The filters of the fields of the objects in a list are in the form of "fixed_ips=ip_address=...".
+func matchField(r Resource, key, value string) bool {
+	field, ok := r[key]
+	if !ok && strings.HasSuffix(key, "_id") {
+		field, ok = r[strings.TrimSuffix(key, "_id")+"s"]
+	}
+	if !ok {
+		return false
+	}
+
+	items, ok := field.([]interface{})
+	if !ok {
+		return fmt.Sprint(field) == value
+	}
+	subKey, subValue := "id", value
+	if k, v, found := strings.Cut(value, "="); found {
+		subKey, subValue = k, v
+	}
+	for _, item := range items {
+		if object, ok := item.(map[string]interface{}); ok {
+			if fmt.Sprint(object[subKey]) == subValue {
+				return true
+			}
+			continue
+		}
+		if fmt.Sprint(item) == value {
+			return true
+		}
+	}
+	return false
+}
+
+// paginate returns the page of the items after the marker and the marker of the next page,
+// which is empty on the last page.
+func paginate(items []Resource, query url.Values) ([]Resource, string) {
+	if marker := query.Get("marker"); marker != "" {
+		for i, r := range items {
+			if r.String("id") == marker {
+				items = items[i+1:]
+				break
+			}
+		}
+	}
+	var limit int
+	if _, err := fmt.Sscan(query.Get("limit"), &limit); err != nil || limit <= 0 || limit >= len(items) {
+		return items, ""
+	}
+	return items[:limit], items[limit-1].String("id")
+}
@@ -0,0 +1,283 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakecloud
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// AddPublicIP adds an unbound EIP of the address, it returns the ID of the EIP.
+func (s *Server) AddPublicIP(address string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	eip := s.create(KindPublicIP, Resource{
+		"type":              "5_bgp",
+		"public_ip_address": address,
+		"ip_version":        4,
+		"status":            "DOWN",
+	})
+	return eip.String("id")
+}
+
+// AddFloatingIP adds a floating IP of the address used by the DNAT rules, it returns the ID of the floating IP.
+func (s *Server) AddFloatingIP(address string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	fip := s.create(KindFloatingIP, Resource{
+		"floating_ip_address": address,
+		"status":              "DOWN",
+	})
+	return fip.String("id")
+}
+
+// AddRouteTable adds an empty route table of the VPC, it returns the ID of the route table.
+func (s *Server) AddRouteTable(vpcID string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	table := s.create(KindRouteTable, Resource{
+		"name":    "rtb-" + vpcID,
+		"vpc_id":  vpcID,
+		"default": true,
+		"routes":  []interface{}{},
+		"subnets": []interface{}{},
+	})
+	return table.String("id")
+}
+
+// AddSubnet adds the subnet of the VPC, the ID is also the ID of its Neutron subnet and network.
+func (s *Server) AddSubnet(id, vpcID, cidr string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.create(KindSubnet, Resource{
+		"id":                 id,
+		"name":               "subnet-" + id,
+		"vpc_id":             vpcID,
+		"cidr":               cidr,
+		"status":             "ACTIVE",
+		"neutron_subnet_id":  id,
+		"neutron_network_id": id,
+	})
+}
+
+// AddSecurityGroupRule adds an ingress rule of the security group of the nodes like the one added by hand,
+// it returns the ID of the rule.
+func (s *Server) AddSecurityGroupRule(protocol string, port int, cidr string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	rule := s.create(KindSecurityGroupRule, Resource{
+		"security_group_id": SecurityGroupID,
+		"direction":         "ingress",
+		"ethertype":         "IPv4",
+		"protocol":          protocol,
+		"port_range_min":    port,
+		"port_range_max":    port,
+		"remote_ip_prefix":  cidr,
+	})
+	return rule.String("id")
+}
+
+// bindPublicIP binds the EIP to the port, the EIP is unbound if the port is empty.
+func (s *Server) bindPublicIP(eip Resource, portID string) {
+	eip["port_id"] = portID
+	eip["status"] = "DOWN"
+	eip["private_ip_address"] = ""
+	if port := s.store.get(KindPort, portID); port != nil {
+		eip["status"] = "ACTIVE"
+		if ips, _ := port["fixed_ips"].([]interface{}); len(ips) > 0 {
+			eip["private_ip_address"] = ips[0].(map[string]interface{})["ip_address"]
+		}
+	}
+	eip["updated_at"] = s.now()
+}
+
+// registerVPC registers the EIP, subnet, port, floating IP, route table and security group rule APIs.
+func (s *Server) registerVPC() {
+	const publicIPs = "/v1/{project_id}/publicips"
+	s.handle(http.MethodGet, publicIPs, func(r *http.Request, _ []string, _ Resource) (int, interface{}) {
+		return s.listResponse(KindPublicIP, KindPublicIP, r, false)
+	})
+	s.handle(http.MethodPost, publicIPs, func(_ *http.Request, _ []string, body Resource) (int, interface{}) {
+		option, bandwidth := bodyOf(body, "publicip"), bodyOf(body, "bandwidth")
+		if option == nil || bandwidth == nil {
+			return http.StatusBadRequest, "publicip and bandwidth are required in the request body"
+		}
+		eip := s.create(KindPublicIP, Resource{
+			"type":                 option["type"],
+			"public_ip_address":    s.allocateIP("100.64.0."),
+			"ip_version":           4,
+			"status":               "DOWN",
+			"bandwidth_name":       bandwidth["name"],
+			"bandwidth_size":       bandwidth["size"],
+			"bandwidth_share_type": bandwidth["share_type"],
+			"alias":                option["alias"],
+		})
+		return http.StatusOK, map[string]interface{}{"publicip": eip}
+	})
+	s.handle(http.MethodGet, publicIPs+"/*", func(_ *http.Request, params []string, _ Resource) (int, interface{}) {
+		eip := s.store.get(KindPublicIP, params[0])
+		if eip == nil {
+			return notFound(KindPublicIP, params[0])
+		}
+		return http.StatusOK, map[string]interface{}{"publicip": eip}
+	})
+	s.handle(http.MethodPut, publicIPs+"/*", func(_ *http.Request, params []string, body Resource) (int, interface{}) {
+		eip := s.store.get(KindPublicIP, params[0])
+		if eip == nil {
+			return notFound(KindPublicIP, params[0])
+		}
+		option := bodyOf(body, "publicip")
+		if portID, ok := option["port_id"].(string); ok {
+			if portID != "" && eip.String("port_id") != "" {
+				return http.StatusConflict, fmt.Sprintf("publicip %s is bound to port %s",
+					params[0], eip.String("port_id"))
+			}
+			s.bindPublicIP(eip, portID)
+		}
+		return http.StatusOK, map[string]interface{}{"publicip": eip}
+	})
+	s.handle(http.MethodDelete, publicIPs+"/*", func(_ *http.Request, params []string, _ Resource) (int, interface{}) {
+		eip := s.store.get(KindPublicIP, params[0])
+		if eip == nil {
+			return notFound(KindPublicIP, params[0])
+		}
+		if eip.String("port_id") != "" {
+			return http.StatusConflict, fmt.Sprintf("publicip %s is bound to port %s", params[0], eip.String("port_id"))
+		}
+		s.store.remove(KindPublicIP, params[0])
+		return http.StatusNoContent, nil
+	})
+	s.handle(http.MethodGet, "/v1/{project_id}/quotas", func(*http.Request, []string, Resource) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"quotas": map[string]interface{}{"resources": []interface{}{}}}
+	})
+
+	s.handle(http.MethodGet, "/v1/{project_id}/subnets", func(r *http.Request, _ []string, _ Resource) (int, interface{}) {
+		return s.listResponse(KindSubnet, KindSubnet, r, false)
+	})
+	s.handle(http.MethodGet, "/v1/{project_id}/ports", func(r *http.Request, _ []string, _ Resource) (int, interface{}) {
+		return s.listResponse(KindPort, KindPort, r, false)
+	})
+	s.handle(http.MethodGet, "/v1/{project_id}/ports/*", func(_ *http.Request, params []string, _ Resource) (int, interface{}) {
+		port := s.store.get(KindPort, params[0])
+		if port == nil {
+			return notFound(KindPort, params[0])
+		}
+		return http.StatusOK, map[string]interface{}{"port": port}
+	})
+	s.handle(http.MethodPut, "/v1/{project_id}/ports/*", func(_ *http.Request, params []string, body Resource) (int, interface{}) {
+		port := s.store.get(KindPort, params[0])
+		if port == nil {
+			return notFound(KindPort, params[0])
+		}
+		if groups, ok := bodyOf(body, "port")["security_groups"]; ok {
+			port["security_groups"] = groups
+		}
+		return http.StatusOK, map[string]interface{}{"port": port}
+	})
+	s.handle(http.MethodGet, "/v2.0/floatingips", func(r *http.Request, _ []string, _ Resource) (int, interface{}) {
+		return s.listResponse(KindFloatingIP, KindFloatingIP, r, false)
+	})
+
+	const routeTables = "/v1/{project_id}/routetables"
+	s.handle(http.MethodGet, routeTables, func(r *http.Request, _ []string, _ Resource) (int, interface{}) {
+		return s.listResponse(KindRouteTable, KindRouteTable, r, false)
+	})
+	s.handle(http.MethodGet, routeTables+"/*", func(_ *http.Request, params []string, _ Resource) (int, interface{}) {
+		table := s.store.get(KindRouteTable, params[0])
+		if table == nil {
+			return notFound(KindRouteTable, params[0])
+		}
+		return http.StatusOK, map[string]interface{}{"routetable": table}
+	})
+	s.handle(http.MethodPut, routeTables+"/*", func(_ *http.Request, params []string, body Resource) (int, interface{}) {
+		table := s.store.get(KindRouteTable, params[0])
+		if table == nil {
+			return notFound(KindRouteTable, params[0])
+		}
+		actions, _ := bodyOf(body, "routetable")["routes"].(map[string]interface{})
+		if code, msg := updateRoutes(table, actions); code != 0 {
+			return code, msg
+		}
+		table["updated_at"] = s.now()
+		return http.StatusOK, map[string]interface{}{"routetable": table}
+	})
+
+	s.handleCollection("/v1/{project_id}/security-group-rules", KindSecurityGroupRule, "security_group_rule", false,
+		s.createSecurityGroupRule, nil)
+}
+
+// createSecurityGroupRule rejects the rule identical to an existing one like the cloud does.
+func (s *Server) createSecurityGroupRule(rule Resource) (int, string) {
+	for _, existing := range s.store.resources[KindSecurityGroupRule] {
+		same := true
+		for _, field := range []string{"security_group_id", "direction", "protocol", "port_range_min",
+			"port_range_max", "remote_ip_prefix"} {
+			if fmt.Sprint(existing[field]) != fmt.Sprint(rule[field]) {
+				same = false
+				break
+			}
+		}
+		if same {
+			return http.StatusConflict, fmt.Sprintf("the security group rule is the same as %s", existing.String("id"))
+		}
+	}
+	return 0, ""
+}
+
+// updateRoutes applies the add, mod and del actions of the routes to the route table, keyed by destination.
+// The route table is not changed if any action is rejected.
+func updateRoutes(table Resource, actions map[string]interface{}) (int, string) {
+	routes := make(map[string]interface{})
+	var order []string
+	seen := make(map[string]bool)
+	existing, _ := table["routes"].([]interface{})
+	for _, item := range existing {
+		destination := fmt.Sprint(item.(map[string]interface{})["destination"])
+		routes[destination] = item
+		order = append(order, destination)
+		seen[destination] = true
+	}
+	for _, action := range []string{"del", "mod", "add"} {
+		items, _ := actions[action].([]interface{})
+		for _, item := range items {
+			destination := fmt.Sprint(item.(map[string]interface{})["destination"])
+			_, exists := routes[destination]
+			switch {
+			case action == "add" && exists:
+				return http.StatusConflict, fmt.Sprintf("the route of %s already exists", destination)
+			case action != "add" && !exists:
+				return http.StatusBadRequest, fmt.Sprintf("the route of %s does not exist", destination)
+			case action == "del":
+				delete(routes, destination)
+			default:
+				if !seen[destination] {
+					order = append(order, destination)
+					seen[destination] = true
+				}
+				routes[destination] = item
+			}
+		}
+	}
+	updated := make([]interface{}, 0, len(routes))
+	for _, destination := range order {
+		if route, ok := routes[destination]; ok {
+			updated = append(updated, route)
+		}
+	}
+	table["routes"] = updated
+	return 0, ""
+}